@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,20 +10,38 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/api"
 	"github.com/cbalite/backend/internal/cache"
 	"github.com/cbalite/backend/internal/config"
 	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/deprecation"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/export"
+	"github.com/cbalite/backend/internal/features"
+	"github.com/cbalite/backend/internal/hooks"
+	"github.com/cbalite/backend/internal/inbound"
+	"github.com/cbalite/backend/internal/llm"
 	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/notify"
+	"github.com/cbalite/backend/internal/oncall"
+	"github.com/cbalite/backend/internal/repository/postgres"
+	"github.com/cbalite/backend/internal/throttle"
 	"github.com/cbalite/backend/internal/websocket"
 	"github.com/cbalite/backend/pkg/logger"
 )
 
 func main() {
+	validateConfig := flag.Bool("validate-config", false, "check the configuration for APP_ENV and exit without starting the server")
+	flag.Parse()
+
 	cfg, err := config.Load()
 	if err != nil {
 		logger.Fatal("Failed to load configuration: %v", err)
 	}
+	if *validateConfig {
+		fmt.Printf("Configuration is valid for %s\n", cfg.App.Env)
+		return
+	}
 
 	log, err := logger.New(cfg.Logger.Level, cfg.Logger.Output)
 	if err != nil {
@@ -38,55 +56,70 @@ func main() {
 		log.WithError(err).Fatal("Failed to connect to database")
 	}
 	defer db.Close()
+	db.Monitor(log)
+	db.WatchFailover(log)
 	log.Info("Connected to PostgreSQL database")
 
-	redisCache, err := cache.NewRedisCache(&cfg.Redis)
+	appCache, err := connectCache(&cfg.Redis, log)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to connect to Redis")
 	}
-	defer redisCache.Close()
-	log.Info("Connected to Redis cache")
-
-	wsHub := websocket.NewHub(log)
+	defer appCache.Close()
+	logSubsystems(cfg, log)
+
+	// background ends with the server, stopping the work started with it
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	wsHub := websocket.NewHub(&cfg.WebSocket, log)
+	wsHub.SetRegion(cfg.App.Region)
+	if cfg.WebSocket.Backplane && cfg.Redis.Enabled {
+		backplane := websocket.NewStreamBackplane(&cfg.Redis, cfg.App.Region, cfg.WebSocket.RegionPeers, log)
+		defer backplane.Close()
+		wsHub.UseBackplane(background, backplane)
+	}
 	go wsHub.Run()
 	log.Info("WebSocket hub started")
 
+	eventBus := events.NewBus(log)
+	hooks.NewDispatcher(db, log).Start(eventBus)
+
+	notifier := notify.NewNotifier(db, wsHub, &cfg.Clients, log)
+	escalator := oncall.NewEscalator(db, notifier, log)
+	escalator.Start(eventBus)
+
+	exporter := export.NewExporter(db, log)
+	exporter.Start()
+
+	invalidator := cache.NewInvalidator(appCache, db, log)
+	invalidator.Start()
+
 	authMiddleware := middleware.NewAuthMiddleware(&cfg.JWT, log)
 
-	app := &Application{
+	app := &api.Application{
 		Config:         cfg,
 		Logger:         log,
 		DB:             db,
-		Cache:          redisCache,
+		Repositories:   postgres.New(db),
+		Cache:          appCache,
+		Invalidator:    invalidator,
 		WSHub:          wsHub,
+		Events:         eventBus,
+		LLM:            llm.New(&cfg.LLM),
+		Notifier:       notifier,
+		Escalator:      escalator,
+		Exporter:       exporter,
+		Inbound:        inbound.NewRegistry(),
+		Throttle:       throttle.NewLimiter(&cfg.RateLimit, appCache),
+		Features:       features.Parse(cfg.Clients.FeatureFlags),
+		Deprecations:   deprecation.NewTracker(deprecation.Notices),
 		AuthMiddleware: authMiddleware,
 	}
 
-	corsMiddleware := middleware.NewCORSMiddleware(&cfg.CORS)
-	rateLimitMiddleware := middleware.NewRateLimitMiddleware(&cfg.RateLimit, redisCache)
-	loggingMiddleware := middleware.NewLoggingMiddleware(log)
-	recoveryMiddleware := middleware.NewRecoveryMiddleware(log)
-
-	// Create main router with WebSocket endpoint outside middleware
-	mainRouter := mux.NewRouter()
-	
-	// WebSocket endpoint - no middleware applied
-	mainRouter.HandleFunc("/api/v1/ws", app.websocketHandler)
-	
-	// API routes with full middleware stack
-	apiRouter := app.setupRoutes()
-	wrappedAPI := recoveryMiddleware(
-		loggingMiddleware(
-			corsMiddleware(
-				rateLimitMiddleware(apiRouter),
-			),
-		),
-	)
-	
-	// Mount API with middleware
-	mainRouter.PathPrefix("/").Handler(wrappedAPI)
-	
-	handler := mainRouter
+	app.Setup()
+	app.Start(background)
+
+	handler := app.Handler(db)
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%s", cfg.App.Host, cfg.App.Port),
@@ -114,6 +147,7 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down server...")
+	stopBackground()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -122,102 +156,42 @@ func main() {
 		log.WithError(err).Fatal("Server forced to shutdown")
 	}
 
-	log.Info("Server exited gracefully")
-}
+	// Keep the calls counted since the last flush
+	if err := app.FlushDeprecatedCalls(ctx); err != nil {
+		log.WithError(err).Error("Failed to save deprecated endpoint calls")
+	}
 
-type Application struct {
-	Config         *config.Config
-	Logger         *logger.Logger
-	DB             *database.PostgresDB
-	Cache          *cache.RedisCache
-	WSHub          *websocket.Hub
-	AuthMiddleware *middleware.AuthMiddleware
+	log.Info("Server exited gracefully")
 }
 
-func (app *Application) setupRoutes() *mux.Router {
-	r := mux.NewRouter()
-
-	api := r.PathPrefix("/api/v1").Subrouter()
-
-	api.HandleFunc("/health", app.healthCheckHandler).Methods("GET")
-
-	api.HandleFunc("/auth/register", app.registerHandler).Methods("POST")
-	api.HandleFunc("/auth/login", app.loginHandler).Methods("POST")
-	api.HandleFunc("/auth/refresh", app.refreshTokenHandler).Methods("POST")
-	api.HandleFunc("/auth/logout", app.logoutHandler).Methods("POST")
-
-	protected := api.PathPrefix("").Subrouter()
-	protected.Use(app.AuthMiddleware.Authenticate)
-
-	protected.HandleFunc("/users/me", app.getCurrentUserHandler).Methods("GET")
-	protected.HandleFunc("/users/me", app.updateCurrentUserHandler).Methods("PUT")
-
-	protected.HandleFunc("/teams", app.createTeamHandler).Methods("POST")
-	protected.HandleFunc("/teams", app.getTeamsHandler).Methods("GET")
-	protected.HandleFunc("/teams/{teamId}", app.getTeamHandler).Methods("GET")
-	protected.HandleFunc("/teams/{teamId}", app.updateTeamHandler).Methods("PUT")
-	protected.HandleFunc("/teams/{teamId}", app.deleteTeamHandler).Methods("DELETE")
-
-	protected.HandleFunc("/teams/{teamId}/members", app.getTeamMembersHandler).Methods("GET")
-	protected.HandleFunc("/teams/{teamId}/members", app.inviteTeamMemberHandler).Methods("POST")
-	protected.HandleFunc("/teams/{teamId}/members/{userId}", app.removeTeamMemberHandler).Methods("DELETE")
-
-	protected.HandleFunc("/teams/{teamId}/channels", app.createChannelHandler).Methods("POST")
-	protected.HandleFunc("/teams/{teamId}/channels", app.getChannelsHandler).Methods("GET")
-	protected.HandleFunc("/channels/{channelId}", app.getChannelHandler).Methods("GET")
-	protected.HandleFunc("/channels/{channelId}", app.updateChannelHandler).Methods("PUT")
-	protected.HandleFunc("/channels/{channelId}", app.deleteChannelHandler).Methods("DELETE")
-
-	protected.HandleFunc("/channels/{channelId}/messages", app.sendMessageHandler).Methods("POST")
-	protected.HandleFunc("/channels/{channelId}/messages", app.getMessagesHandler).Methods("GET")
-	protected.HandleFunc("/messages/{messageId}", app.updateMessageHandler).Methods("PUT")
-	protected.HandleFunc("/messages/{messageId}", app.deleteMessageHandler).Methods("DELETE")
-
-	protected.HandleFunc("/teams/{teamId}/tasks", app.createTaskHandler).Methods("POST")
-	protected.HandleFunc("/teams/{teamId}/tasks", app.getTasksHandler).Methods("GET")
-	protected.HandleFunc("/tasks/{taskId}", app.getTaskHandler).Methods("GET")
-	protected.HandleFunc("/tasks/{taskId}", app.updateTaskHandler).Methods("PUT")
-	protected.HandleFunc("/tasks/{taskId}", app.deleteTaskHandler).Methods("DELETE")
-
-	protected.HandleFunc("/tasks/{taskId}/comments", app.createTaskCommentHandler).Methods("POST")
-	protected.HandleFunc("/tasks/{taskId}/comments", app.getTaskCommentsHandler).Methods("GET")
-
+// connectCache returns the cache the configuration asks for: none, Redis
+// that may come up after the server, or Redis that must answer now.
+func connectCache(cfg *config.RedisConfig, log *logger.Logger) (cache.Cache, error) {
+	if !cfg.Enabled {
+		return cache.Noop{}, nil
+	}
+	if !cfg.LazyConnect {
+		return cache.NewRedisCache(cfg)
+	}
 
-	return r
+	return cache.NewLazyRedisCache(cfg), nil
 }
 
-func (app *Application) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	health := map[string]interface{}{
-		"status": "healthy",
-		"services": map[string]string{
-			"database": "unknown",
-			"cache":    "unknown",
-		},
+// logSubsystems says at startup which optional parts of the server are off,
+// so a missing feature isn't mistaken for a bug.
+func logSubsystems(cfg *config.Config, log *logger.Logger) {
+	switch {
+	case !cfg.Redis.Enabled:
+		log.Warn("Cache disabled (REDIS_ENABLED=false): nothing is cached and rate limits are counted per process")
+	case cfg.Redis.LazyConnect:
+		log.Infof("Cache enabled, connecting to Redis at %s in the background", cfg.Redis.Addr)
+	default:
+		log.Info("Connected to Redis cache")
 	}
 
-	if err := app.DB.HealthCheck(); err == nil {
-		health["services"].(map[string]string)["database"] = "healthy"
+	if cfg.LLM.BaseURL == "" {
+		log.Warn("AI features disabled (LLM_BASE_URL not set): channel summaries and task suggestions are unavailable")
 	} else {
-		health["services"].(map[string]string)["database"] = "unhealthy"
+		log.Infof("AI features enabled with model %s", cfg.LLM.Model)
 	}
-
-	if err := app.Cache.HealthCheck(); err == nil {
-		health["services"].(map[string]string)["cache"] = "healthy"
-	} else {
-		health["services"].(map[string]string)["cache"] = "unhealthy"
-	}
-
-	respondWithJSON(w, http.StatusOK, health)
 }
-
-func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	if payload != nil {
-		json.NewEncoder(w).Encode(payload)
-	}
-}
-
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
-}
\ No newline at end of file
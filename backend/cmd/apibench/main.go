@@ -0,0 +1,315 @@
+// Command apibench drives a running server with the REST traffic a team
+// generates through the day, mostly reading channels and messages with some
+// posting, and reports how many database queries the server ran for it,
+// read from the server's /metrics.
+//
+//	go run ./cmd/apibench -url http://localhost:8080 -token $ACCESS_TOKEN -team $TEAM_ID -duration 1m
+//
+// Run it once against a server with Redis and once without to see how much
+// of the load the cache takes off the database.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cbalite/backend/pkg/client"
+)
+
+type options struct {
+	URL          string
+	Token        string
+	MetricsToken string
+	Team         string
+	Workers      int
+	Duration     time.Duration
+	Think        time.Duration
+	PostShare    float64
+}
+
+// operation is one kind of request in the profile, picked in proportion to
+// its weight.
+type operation struct {
+	name   string
+	weight float64
+	run    func(ctx context.Context, c *client.Client, channelID string) error
+}
+
+func profile(opts options) []operation {
+	return []operation{
+		{"read_messages", 0.55 - opts.PostShare/2, func(ctx context.Context, c *client.Client, channelID string) error {
+			_, err := c.Messages(ctx, channelID, client.MessageQuery{Limit: 50})
+			return err
+		}},
+		{"list_channels", 0.2, func(ctx context.Context, c *client.Client, channelID string) error {
+			_, err := c.Channels(ctx, opts.Team)
+			return err
+		}},
+		{"post_message", opts.PostShare, func(ctx context.Context, c *client.Client, channelID string) error {
+			_, err := c.SendMessage(ctx, channelID, client.NewMessage{Content: "apibench " + strconv.FormatInt(time.Now().UnixNano(), 36)})
+			return err
+		}},
+		{"list_tasks", 0.15 - opts.PostShare/2, func(ctx context.Context, c *client.Client, channelID string) error {
+			_, err := c.Tasks(ctx, opts.Team)
+			return err
+		}},
+		{"list_members", 0.1, func(ctx context.Context, c *client.Client, channelID string) error {
+			_, err := c.TeamMembers(ctx, opts.Team)
+			return err
+		}},
+	}
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.URL, "url", "http://localhost:8080", "server base URL")
+	flag.StringVar(&opts.Token, "token", "", "access token of a member of -team")
+	flag.StringVar(&opts.MetricsToken, "metrics-token", "", "METRICS_TOKEN of the server, if it has one")
+	flag.StringVar(&opts.Team, "team", "", "team whose channels get the traffic")
+	flag.IntVar(&opts.Workers, "workers", 20, "number of concurrent simulated users")
+	flag.DurationVar(&opts.Duration, "duration", 30*time.Second, "how long to send traffic")
+	flag.DurationVar(&opts.Think, "think", 100*time.Millisecond, "pause between one worker's requests")
+	flag.Float64Var(&opts.PostShare, "post-share", 0.1, "share of requests that post a message, up to 0.3")
+	flag.Parse()
+
+	if opts.Token == "" || opts.Team == "" {
+		fmt.Fprintln(os.Stderr, "apibench: -token and -team are required")
+		os.Exit(2)
+	}
+	if opts.Workers < 1 || opts.PostShare < 0 || opts.PostShare > 0.3 {
+		fmt.Fprintln(os.Stderr, "apibench: -workers must be positive and -post-share between 0 and 0.3")
+		os.Exit(2)
+	}
+
+	if err := run(os.Stdout, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "apibench: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// stats is shared by every worker.
+type stats struct {
+	requests atomic.Int64
+
+	mu     sync.Mutex
+	counts map[string]int
+	errors map[string]int
+}
+
+func (s *stats) record(op string, err error) {
+	s.requests.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[op]++
+	if err != nil {
+		code := "other"
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) {
+			code = strconv.Itoa(apiErr.StatusCode)
+		}
+		s.errors[op+":"+code]++
+	}
+}
+
+func run(w io.Writer, opts options) error {
+	ctx := context.Background()
+	c := client.New(opts.URL)
+	c.SetTokens(opts.Token, "")
+
+	channels, err := c.Channels(ctx, opts.Team)
+	if err != nil {
+		return fmt.Errorf("failed to list channels: %w", err)
+	}
+	var channelIDs []string
+	for _, channel := range channels {
+		if channel.Type != "direct" {
+			channelIDs = append(channelIDs, channel.ID)
+		}
+	}
+	if len(channelIDs) == 0 {
+		return errors.New("the team has no channels to send traffic to")
+	}
+
+	before, err := scrape(opts)
+	if err != nil {
+		return err
+	}
+
+	ops := profile(opts)
+	var total float64
+	for _, op := range ops {
+		total += op.weight
+	}
+
+	s := &stats{counts: make(map[string]int), errors: make(map[string]int)}
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			// Users mostly stay in one channel
+			home := channelIDs[rng.Intn(len(channelIDs))]
+			for time.Now().Before(deadline) {
+				channelID := home
+				if rng.Float64() < 0.2 {
+					channelID = channelIDs[rng.Intn(len(channelIDs))]
+				}
+				op := pick(ops, total, rng.Float64())
+				s.record(op.name, op.run(ctx, c, channelID))
+				time.Sleep(opts.Think)
+			}
+		}(time.Now().UnixNano() + int64(i))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	after, err := scrape(opts)
+	if err != nil {
+		return err
+	}
+
+	report(w, opts, s, elapsed, before, after)
+	return nil
+}
+
+func pick(ops []operation, total, r float64) operation {
+	r *= total
+	for _, op := range ops {
+		if r < op.weight {
+			return op
+		}
+		r -= op.weight
+	}
+	return ops[len(ops)-1]
+}
+
+// scrape reads the database query and cache lookup counters from /metrics.
+func scrape(opts options) (map[string]float64, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(opts.URL, "/")+"/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MetricsToken != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.MetricsToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read metrics: %s (is METRICS_ENABLED on, and -metrics-token right?)", resp.Status)
+	}
+
+	values := make(map[string]float64)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "cbalite_db_queries_total") && !strings.HasPrefix(line, "cbalite_cache_lookups_total") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			continue
+		}
+		if value, err := strconv.ParseFloat(line[i+1:], 64); err == nil {
+			values[line[:i]] = value
+		}
+	}
+	return values, scanner.Err()
+}
+
+func report(w io.Writer, opts options, s *stats, elapsed time.Duration, before, after map[string]float64) {
+	requests := s.requests.Load()
+	queries := after["cbalite_db_queries_total"] - before["cbalite_db_queries_total"]
+	seconds := elapsed.Seconds()
+
+	fmt.Fprintf(w, "workers     %d for %s\n", opts.Workers, elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "requests    %d (%.1f/s)\n", requests, float64(requests)/seconds)
+
+	names := make([]string, 0, len(s.counts))
+	for name := range s.counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %d\n", name, s.counts[name])
+	}
+
+	fmt.Fprintf(w, "db queries  %.0f (%.1f/s", queries, queries/seconds)
+	if requests > 0 {
+		fmt.Fprintf(w, ", %.2f per request", queries/float64(requests))
+	}
+	fmt.Fprintln(w, ")")
+	fmt.Fprintln(w, "  (includes whatever else the server ran meanwhile, such as jobs)")
+
+	// Hit rates per cache, from the lookup counters
+	lookups := make(map[string]map[string]float64)
+	for series, value := range after {
+		if !strings.HasPrefix(series, "cbalite_cache_lookups_total{") {
+			continue
+		}
+		cache, result := label(series, "cache"), label(series, "result")
+		if lookups[cache] == nil {
+			lookups[cache] = make(map[string]float64)
+		}
+		lookups[cache][result] += value - before[series]
+	}
+	if len(lookups) == 0 {
+		fmt.Fprintln(w, "cache       no lookups (server running without a shared cache?)")
+	}
+	caches := make([]string, 0, len(lookups))
+	for cache := range lookups {
+		caches = append(caches, cache)
+	}
+	sort.Strings(caches)
+	for _, cache := range caches {
+		r := lookups[cache]
+		n := r["local"] + r["shared"] + r["miss"]
+		if n == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "cache       %-16s %6.0f lookups  local %5.1f%%  shared %5.1f%%  miss %5.1f%%\n",
+			cache, n, 100*r["local"]/n, 100*r["shared"]/n, 100*r["miss"]/n)
+	}
+
+	if len(s.errors) > 0 {
+		codes := make([]string, 0, len(s.errors))
+		for code, n := range s.errors {
+			codes = append(codes, fmt.Sprintf("%s=%d", code, n))
+		}
+		sort.Strings(codes)
+		fmt.Fprintf(w, "errors      %s\n", strings.Join(codes, " "))
+	}
+}
+
+// label reads one label's value from a series name such as
+// cbalite_cache_lookups_total{cache="team_role",result="local"}.
+func label(series, name string) string {
+	i := strings.Index(series, name+`="`)
+	if i < 0 {
+		return ""
+	}
+	value := series[i+len(name)+2:]
+	if j := strings.IndexByte(value, '"'); j >= 0 {
+		value = value[:j]
+	}
+	return value
+}
@@ -0,0 +1,321 @@
+// Command wsbench load-tests the WebSocket layer: it connects simulated
+// clients to a server, has them send chat and typing traffic into a shared
+// room, and reports how long deliveries took and how many went missing.
+//
+//	go run ./cmd/wsbench -url ws://localhost:8080/api/v1/ws -clients 200 -duration 1m
+//
+// The hub's broadcast path is benchmarked in process by the
+// BenchmarkBroadcast benchmarks in internal/websocket.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type options struct {
+	URL        string
+	Token      string
+	Clients    int
+	Duration   time.Duration
+	Ramp       time.Duration
+	Grace      time.Duration
+	ChatRate   float64
+	TypingRate float64
+	Size       int
+	Room       string
+}
+
+// frame is the subset of the server's Message the bench reads and writes.
+type frame struct {
+	Type string                 `json:"type"`
+	Room string                 `json:"room,omitempty"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.URL, "url", "ws://localhost:8080/api/v1/ws", "WebSocket endpoint")
+	flag.StringVar(&opts.Token, "token", "", "access token for every client; anonymous clients only get chat traffic")
+	flag.IntVar(&opts.Clients, "clients", 50, "number of simulated clients")
+	flag.DurationVar(&opts.Duration, "duration", 30*time.Second, "how long clients send traffic")
+	flag.DurationVar(&opts.Ramp, "ramp", 5*time.Second, "time over which clients connect")
+	flag.DurationVar(&opts.Grace, "grace", 3*time.Second, "how long to wait for deliveries after sending stops")
+	flag.Float64Var(&opts.ChatRate, "chat-rate", 1, "chat messages per second per client")
+	flag.Float64Var(&opts.TypingRate, "typing-rate", 2, "typing events per second per client")
+	flag.IntVar(&opts.Size, "size", 64, "chat content size in bytes")
+	flag.StringVar(&opts.Room, "room", "", "room to send chat to (default: a fresh bench room)")
+	flag.Parse()
+
+	if opts.Clients < 1 || opts.Size < 1 {
+		fmt.Fprintln(os.Stderr, "wsbench: -clients and -size must be positive")
+		os.Exit(2)
+	}
+	if opts.Room == "" {
+		opts.Room = fmt.Sprintf("bench:%d", time.Now().UnixNano())
+	}
+
+	report := run(opts)
+	report.print(os.Stdout, opts)
+	if report.connected.Load() == 0 {
+		os.Exit(1)
+	}
+}
+
+// stats is shared by every client goroutine.
+type stats struct {
+	connected      atomic.Int64
+	connectFailed  atomic.Int64
+	disconnected   atomic.Int64
+	chatSent       atomic.Int64
+	typingSent     atomic.Int64
+	chatExpected   atomic.Int64
+	chatReceived   atomic.Int64
+	typingReceived atomic.Int64
+
+	mu          sync.Mutex
+	chatLatency []time.Duration
+	errorCodes  map[string]int
+}
+
+func (s *stats) recordLatency(d time.Duration) {
+	s.mu.Lock()
+	s.chatLatency = append(s.chatLatency, d)
+	s.mu.Unlock()
+}
+
+func (s *stats) recordError(code string) {
+	s.mu.Lock()
+	s.errorCodes[code]++
+	s.mu.Unlock()
+}
+
+func run(opts options) *stats {
+	s := &stats{errorCodes: make(map[string]int)}
+
+	header := http.Header{}
+	if opts.Token != "" {
+		header.Set("Authorization", "Bearer "+opts.Token)
+	}
+
+	start := time.Now()
+	stopSending := start.Add(opts.Ramp + opts.Duration)
+	stopReading := stopSending.Add(opts.Grace)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Clients; i++ {
+		delay := time.Duration(0)
+		if opts.Clients > 1 {
+			delay = opts.Ramp * time.Duration(i) / time.Duration(opts.Clients-1)
+		}
+		wg.Add(1)
+		go func(delay time.Duration) {
+			defer wg.Done()
+			time.Sleep(delay)
+			runClient(opts, header, s, stopSending, stopReading)
+		}(delay)
+	}
+	wg.Wait()
+
+	return s
+}
+
+func runClient(opts options, header http.Header, s *stats, stopSending, stopReading time.Time) {
+	conn, _, err := websocket.DefaultDialer.Dial(opts.URL, header)
+	if err != nil {
+		s.connectFailed.Add(1)
+		return
+	}
+	defer conn.Close()
+
+	// Frames are written from this goroutine only; the reader below never
+	// writes.
+	send := func(f frame) error {
+		conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		return conn.WriteJSON(f)
+	}
+	if err := send(frame{Type: "notification", Data: map[string]interface{}{"action": "join_room", "room": opts.Room}}); err != nil {
+		s.connectFailed.Add(1)
+		return
+	}
+	s.connected.Add(1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		readFrames(conn, opts, s, stopReading)
+	}()
+
+	content := strings.Repeat("x", opts.Size)
+	chatEvery := interval(opts.ChatRate)
+	typingEvery := interval(opts.TypingRate)
+	// Stagger the first sends so clients don't move in lockstep
+	nextChat := time.Now().Add(jitter(chatEvery))
+	nextTyping := time.Now().Add(jitter(typingEvery))
+
+	for {
+		now := time.Now()
+		if now.After(stopSending) {
+			break
+		}
+
+		switch {
+		case chatEvery > 0 && !now.Before(nextChat):
+			// Everyone in the room, including this client, should get it
+			s.chatExpected.Add(s.connected.Load() - s.disconnected.Load())
+			err = send(frame{Type: "chat", Room: opts.Room, Data: map[string]interface{}{
+				"content":    content,
+				"bench_room": opts.Room,
+				"sent_at":    time.Now().UnixNano(),
+			}})
+			s.chatSent.Add(1)
+			nextChat = nextChat.Add(chatEvery)
+		case typingEvery > 0 && !now.Before(nextTyping):
+			err = send(frame{Type: "typing", Data: map[string]interface{}{"bench_room": opts.Room}})
+			s.typingSent.Add(1)
+			nextTyping = nextTyping.Add(typingEvery)
+		default:
+			time.Sleep(time.Until(earliest(nextChat, nextTyping, chatEvery, typingEvery, stopSending)))
+			continue
+		}
+
+		// The reader sees the broken connection too and counts it
+		if err != nil {
+			break
+		}
+	}
+
+	<-done
+}
+
+// readFrames counts what arrives until the deadline. The server may batch
+// several messages into one frame, separated by newlines.
+func readFrames(conn *websocket.Conn, opts options, s *stats, deadline time.Time) {
+	conn.SetReadDeadline(deadline)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if time.Now().Before(deadline) {
+				s.disconnected.Add(1)
+			}
+			return
+		}
+		received := time.Now()
+
+		for _, line := range bytes.Split(data, []byte{'\n'}) {
+			var f frame
+			if json.Unmarshal(line, &f) != nil {
+				continue
+			}
+			switch f.Type {
+			case "chat":
+				if f.Data["bench_room"] != opts.Room {
+					continue
+				}
+				s.chatReceived.Add(1)
+				if sentAt, ok := f.Data["sent_at"].(float64); ok {
+					s.recordLatency(received.Sub(time.Unix(0, int64(sentAt))))
+				}
+			case "typing":
+				if f.Data["bench_room"] == opts.Room {
+					s.typingReceived.Add(1)
+				}
+			case "error":
+				code, _ := f.Data["code"].(string)
+				s.recordError(code)
+			}
+		}
+	}
+}
+
+func interval(perSecond float64) time.Duration {
+	if perSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / perSecond)
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+func earliest(nextChat, nextTyping time.Time, chatEvery, typingEvery time.Duration, limit time.Time) time.Time {
+	next := limit
+	if chatEvery > 0 && nextChat.Before(next) {
+		next = nextChat
+	}
+	if typingEvery > 0 && nextTyping.Before(next) {
+		next = nextTyping
+	}
+	return next
+}
+
+func (s *stats) print(w io.Writer, opts options) {
+	fmt.Fprintf(w, "target      %s (room %s)\n", redact(opts.URL), opts.Room)
+	fmt.Fprintf(w, "clients     %d connected, %d failed, %d dropped early\n",
+		s.connected.Load(), s.connectFailed.Load(), s.disconnected.Load())
+	fmt.Fprintf(w, "chat        %d sent, %d/%d deliveries", s.chatSent.Load(), s.chatReceived.Load(), s.chatExpected.Load())
+	if missing := s.chatExpected.Load() - s.chatReceived.Load(); missing > 0 {
+		fmt.Fprintf(w, ", %d missing (%.2f%%)", missing, 100*float64(missing)/float64(s.chatExpected.Load()))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "typing      %d sent, %d deliveries\n", s.typingSent.Load(), s.typingReceived.Load())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.chatLatency) > 0 {
+		sort.Slice(s.chatLatency, func(i, j int) bool { return s.chatLatency[i] < s.chatLatency[j] })
+		fmt.Fprintf(w, "latency     p50 %s  p90 %s  p99 %s  max %s\n",
+			percentile(s.chatLatency, 50), percentile(s.chatLatency, 90),
+			percentile(s.chatLatency, 99), s.chatLatency[len(s.chatLatency)-1])
+	}
+	if len(s.errorCodes) > 0 {
+		codes := make([]string, 0, len(s.errorCodes))
+		for code, n := range s.errorCodes {
+			codes = append(codes, fmt.Sprintf("%s=%d", code, n))
+		}
+		sort.Strings(codes)
+		fmt.Fprintf(w, "errors      %s\n", strings.Join(codes, " "))
+	}
+}
+
+// percentile expects sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p + 99) / 100
+	if i > 0 {
+		i--
+	}
+	return sorted[i].Round(time.Microsecond)
+}
+
+// redact keeps tokens passed in the URL out of the report.
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	query := u.Query()
+	if query.Has("token") {
+		query.Set("token", "redacted")
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// accessCacheTTL bounds how long a role or channel access answer can be
+// served after an invalidation that failed to reach the cache.
+const accessCacheTTL = 5 * time.Minute
+
+// userCacheScope covers what depends on a user's team memberships and
+// roles, including the channels those memberships open.
+func userCacheScope(userID string) string {
+	return "user:" + userID
+}
+
+// channelCacheScope covers what depends on a channel's settings and
+// shares.
+func channelCacheScope(channelID string) string {
+	return "channel:" + channelID
+}
+
+// teamCacheScope covers what depends on a team's own settings, such as its
+// name.
+func teamCacheScope(teamID string) string {
+	return "team:" + teamID
+}
+
+// invalidateAccess tells every instance that cached roles, channel access
+// and team and channel metadata for the scopes are out of date. Call it once
+// the write has committed.
+func (app *Application) invalidateAccess(ctx context.Context, scopes ...string) {
+	if err := app.Invalidator.Invalidate(ctx, scopes...); err != nil {
+		app.Logger.WithError(err).Error("Failed to invalidate cached access")
+	}
+}
+
+// invalidateAppBot invalidates the memberships of an app's bot user, which
+// joins and leaves teams as the app is installed and uninstalled.
+func (app *Application) invalidateAppBot(ctx context.Context, appID string) {
+	var botUserID *string
+	err := app.DB.QueryRowContext(ctx, `SELECT bot_user_id FROM apps WHERE id = $1`, appID).Scan(&botUserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get app bot for cache invalidation")
+		return
+	}
+	if botUserID != nil {
+		app.invalidateAccess(ctx, userCacheScope(*botUserID))
+	}
+}
@@ -0,0 +1,389 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/timeparse"
+)
+
+// maxJumpMatches caps the channels jump_to_channel lists when a name is
+// ambiguous.
+const maxJumpMatches = 10
+
+// actionInvocation is a quick action run by a team member. Params have been
+// checked against the action's schema.
+type actionInvocation struct {
+	Claims *middleware.Claims
+	TeamID string
+	Role   string
+	Params map[string]string
+}
+
+// quickActionFunc runs an action and returns its result.
+type quickActionFunc func(ctx context.Context, inv actionInvocation) (map[string]interface{}, error)
+
+type quickAction struct {
+	domain.QuickAction
+	run quickActionFunc
+}
+
+// actionError is returned by quick actions for problems the caller should
+// see, with the status to answer them with.
+type actionError struct {
+	status  int
+	message string
+}
+
+func (e *actionError) Error() string {
+	return e.message
+}
+
+func actionErrorf(status int, format string, args ...interface{}) error {
+	return &actionError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func (app *Application) quickActions() []quickAction {
+	priorities := []string{
+		string(domain.PriorityLow), string(domain.PriorityMedium),
+		string(domain.PriorityHigh), string(domain.PriorityUrgent),
+	}
+	statuses := []string{
+		string(domain.TaskStatusTodo), string(domain.TaskStatusInProgress), string(domain.TaskStatusReview),
+		string(domain.TaskStatusDone), string(domain.TaskStatusCancelled),
+	}
+
+	return []quickAction{
+		{
+			QuickAction: domain.QuickAction{
+				Name:        "create_task",
+				Description: "Create a task in the team",
+				Params: []domain.ActionParam{
+					{Name: "title", Kind: domain.ActionParamText, Description: "Task title", Required: true},
+					{Name: "description", Kind: domain.ActionParamText, Description: "Task description"},
+					{Name: "priority", Kind: domain.ActionParamText, Description: "Defaults to medium", Enum: priorities},
+					{Name: "assignee", Kind: domain.ActionParamUser, Description: "Team member ID or @username"},
+					{Name: "due", Kind: domain.ActionParamTime, Description: "Due time, e.g. \"friday 5pm\", in your timezone"},
+				},
+			},
+			run: app.createTaskAction,
+		},
+		{
+			QuickAction: domain.QuickAction{
+				Name:        "jump_to_channel",
+				Description: "Find a channel to open",
+				Params: []domain.ActionParam{
+					{Name: "channel", Kind: domain.ActionParamChannel, Description: "Channel ID, name, #name or the start of a name", Required: true},
+				},
+			},
+			run: app.jumpToChannelAction,
+		},
+		{
+			QuickAction: domain.QuickAction{
+				Name:        "set_status",
+				Description: "Move a task to another status",
+				Params: []domain.ActionParam{
+					{Name: "task", Kind: domain.ActionParamTask, Description: "Task ID", Required: true},
+					{Name: "status", Kind: domain.ActionParamText, Description: "New status", Required: true, Enum: statuses},
+				},
+			},
+			run: app.setTaskStatusAction,
+		},
+	}
+}
+
+// validateActionParams checks params against the action's schema: required
+// params are present, values are in their enum and nothing unknown is sent.
+// Values are trimmed in place.
+func validateActionParams(action domain.QuickAction, params map[string]string) error {
+	known := make(map[string]domain.ActionParam, len(action.Params))
+	for _, param := range action.Params {
+		known[param.Name] = param
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := known[name]; !ok {
+			return actionErrorf(http.StatusBadRequest, "%s doesn't take param %q", action.Name, name)
+		}
+		params[name] = strings.TrimSpace(params[name])
+	}
+
+	for _, param := range action.Params {
+		value := params[param.Name]
+		if value == "" {
+			if param.Required {
+				return actionErrorf(http.StatusBadRequest, "%s requires param %q", action.Name, param.Name)
+			}
+			continue
+		}
+		if len(param.Enum) > 0 && !slices.Contains(param.Enum, value) {
+			return actionErrorf(http.StatusBadRequest, "Param %q must be one of %s", param.Name, strings.Join(param.Enum, ", "))
+		}
+	}
+	return nil
+}
+
+// getQuickActionsHandler lists the actions POST /actions runs and their
+// params, so the command palette can build its forms from the server.
+func (app *Application) getQuickActionsHandler(w http.ResponseWriter, r *http.Request) {
+	actions := app.quickActions()
+	list := make([]domain.QuickAction, 0, len(actions))
+	for _, action := range actions {
+		list = append(list, action.QuickAction)
+	}
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// runQuickActionHandler runs one named action in a team for the command
+// palette. Actions check the same permissions as the endpoints they stand
+// in for.
+func (app *Application) runQuickActionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.RunQuickAction
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Params == nil {
+		req.Params = map[string]string{}
+	}
+
+	var action *quickAction
+	for _, candidate := range app.quickActions() {
+		if candidate.Name == req.Action {
+			action = &candidate
+			break
+		}
+	}
+	if action == nil {
+		respondWithError(w, http.StatusBadRequest, "Unknown action "+req.Action)
+		return
+	}
+
+	if !middleware.IsCanonicalUUID(req.TeamID) {
+		respondWithError(w, http.StatusBadRequest, "team_id must be a team ID")
+		return
+	}
+
+	if err := validateActionParams(action.QuickAction, req.Params); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	role, err := app.getTeamRole(req.TeamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	result, err := action.run(r.Context(), actionInvocation{
+		Claims: claims,
+		TeamID: req.TeamID,
+		Role:   role,
+		Params: req.Params,
+	})
+	if err != nil {
+		var actErr *actionError
+		if errors.As(err, &actErr) {
+			respondWithError(w, actErr.status, actErr.message)
+			return
+		}
+		app.Logger.WithError(err).Errorf("Quick action %s failed", action.Name)
+		respondWithError(w, http.StatusInternalServerError, "Failed to run action")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"action": action.Name,
+		"result": result,
+	})
+}
+
+func (app *Application) createTaskAction(ctx context.Context, inv actionInvocation) (map[string]interface{}, error) {
+	subject := authz.NewSubject(inv.Claims, inv.Role)
+	if !authz.Team(subject).CanPost {
+		return nil, actionErrorf(http.StatusForbidden, "You can't create tasks in this team")
+	}
+
+	priority := inv.Params["priority"]
+	if priority == "" {
+		priority = string(domain.PriorityMedium)
+	}
+
+	var assigneeID *string
+	if inv.Params["assignee"] != "" {
+		userID, err := app.resolveTeamMember(ctx, inv.TeamID, inv.Params["assignee"])
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, actionErrorf(http.StatusUnprocessableEntity, "No team member matches %q", inv.Params["assignee"])
+		}
+		if err != nil {
+			return nil, err
+		}
+		assigneeID = &userID
+	}
+
+	var dueDate *time.Time
+	if inv.Params["due"] != "" {
+		loc, err := app.userLocation(ctx, inv.Claims.UserID, "")
+		if err != nil {
+			return nil, err
+		}
+		due, err := timeparse.Parse(inv.Params["due"], time.Now(), loc)
+		if err != nil {
+			return nil, actionErrorf(http.StatusUnprocessableEntity, "Couldn't understand due time %q", inv.Params["due"])
+		}
+		dueDate = &due
+	}
+
+	task, err := app.createTask(ctx, inv.TeamID, inv.Claims.UserID, inv.Params["title"], inv.Params["description"], priority, assigneeID, dueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// The task map is also the event payload, so decorate a copy
+	result := maps.Clone(task)
+	result["permissions"] = authz.Task(subject, inv.Claims.UserID)
+	if assigneeID != nil {
+		if warning := app.outOfOfficeWarning(ctx, *assigneeID); warning != nil {
+			result["warnings"] = []map[string]interface{}{warning}
+		}
+	}
+	return result, nil
+}
+
+// jumpToChannelAction finds a channel the caller can open: an exact ID or
+// name, else the only channel whose name starts with the text. Ambiguous
+// text answers 409 with the candidates in the message.
+func (app *Application) jumpToChannelAction(ctx context.Context, inv actionInvocation) (map[string]interface{}, error) {
+	ref := strings.TrimPrefix(inv.Params["channel"], "#")
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(ref)) + "%"
+
+	// The team's own channels plus those other teams share with it, like
+	// the channel list
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT c.id, c.team_id, c.name, c.type, c.is_private, c.is_default,
+		       c.id::text = $3 OR lower(c.name) = lower($3) AS exact
+		FROM channels c
+		WHERE ((c.team_id = $1
+		        AND (c.type <> 'direct' OR EXISTS(
+		            SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $2)))
+		    OR EXISTS(
+		        SELECT 1 FROM channel_shares s
+		        WHERE s.channel_id = c.id AND s.guest_team_id = $1 AND s.status = 'active'))
+		  AND (c.id::text = $3 OR lower(c.name) LIKE $4)
+		ORDER BY exact DESC, c.name
+		LIMIT $5
+	`, inv.TeamID, inv.Claims.UserID, ref, pattern, maxJumpMatches+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type match struct {
+		id, teamID, name, channelType string
+		isPrivate, isDefault, exact   bool
+	}
+	var matches []match
+	for rows.Next() {
+		var m match
+		if err := rows.Scan(&m.id, &m.teamID, &m.name, &m.channelType, &m.isPrivate, &m.isDefault, &m.exact); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, actionErrorf(http.StatusNotFound, "No channel matches %q", ref)
+	case len(matches) > 1 && !matches[0].exact:
+		names := make([]string, 0, maxJumpMatches)
+		for i, m := range matches {
+			if i == maxJumpMatches {
+				names = append(names, "…")
+				break
+			}
+			names = append(names, "#"+m.name)
+		}
+		return nil, actionErrorf(http.StatusConflict, "%q matches several channels: %s", ref, strings.Join(names, ", "))
+	}
+	channel := matches[0]
+
+	role := inv.Role
+	if channel.teamID != inv.TeamID {
+		// Guests have no role in the host team
+		role = ""
+	}
+	return map[string]interface{}{
+		"channel": map[string]interface{}{
+			"id":          channel.id,
+			"team_id":     channel.teamID,
+			"name":        channel.name,
+			"type":        channel.channelType,
+			"is_private":  channel.isPrivate,
+			"permissions": authz.Channel(authz.NewSubject(inv.Claims, role), channel.channelType, channel.isPrivate, channel.isDefault, false),
+		},
+	}, nil
+}
+
+func (app *Application) setTaskStatusAction(ctx context.Context, inv actionInvocation) (map[string]interface{}, error) {
+	taskID := inv.Params["task"]
+	if !middleware.IsCanonicalUUID(taskID) {
+		return nil, actionErrorf(http.StatusBadRequest, "Param \"task\" must be a task ID")
+	}
+
+	var createdBy string
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT created_by FROM tasks WHERE id = $1 AND team_id = $2 AND deleted_at IS NULL
+	`, taskID, inv.TeamID).Scan(&createdBy)
+	if err == sql.ErrNoRows {
+		return nil, actionErrorf(http.StatusNotFound, "Task not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	subject := authz.NewSubject(inv.Claims, inv.Role)
+	if !authz.Task(subject, createdBy).CanEdit {
+		return nil, actionErrorf(http.StatusForbidden, "You can't edit this task")
+	}
+
+	status := inv.Params["status"]
+	task, err := app.updateTask(ctx, inv.TeamID, taskID, inv.Claims.UserID, taskUpdate{Status: &status})
+	if err != nil {
+		return nil, err
+	}
+
+	result := maps.Clone(task)
+	result["permissions"] = authz.Task(subject, createdBy)
+	return result, nil
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/llm"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const (
+	defaultMonthlyTokenBudget = 100000
+	defaultSummaryWindow      = 24 * time.Hour
+	summaryMessageLimit       = 500
+	maxSummaryTranscriptChars = 24000
+	summaryCacheTTL           = time.Hour
+)
+
+const summarySystemPrompt = `You summarize team chat conversations. Write a short summary of the ` +
+	`conversation below for someone catching up: the main topics, decisions made, open questions, ` +
+	`and any action items with their owners. Use plain sentences or a few bullet points. ` +
+	`Do not invent details that are not in the conversation.`
+
+var errTokenBudgetExceeded = errors.New("monthly AI token budget exceeded")
+
+func (app *Application) loadAISettings(ctx context.Context, teamID string) (domain.TeamAISettings, error) {
+	settings := domain.TeamAISettings{
+		TeamID:             teamID,
+		MonthlyTokenBudget: defaultMonthlyTokenBudget,
+	}
+
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT summaries_enabled, task_suggestions_enabled, monthly_token_budget, updated_at
+		FROM team_ai_settings
+		WHERE team_id = $1
+	`, teamID).Scan(&settings.SummariesEnabled, &settings.TaskSuggestionsEnabled, &settings.MonthlyTokenBudget, &settings.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return settings, err
+	}
+
+	err = app.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0)
+		FROM llm_usage
+		WHERE team_id = $1 AND created_at >= date_trunc('month', NOW())
+	`, teamID).Scan(&settings.TokensUsed)
+
+	return settings, err
+}
+
+// reserveAIBudget checks that a request of roughly the given size fits in the
+// team's remaining monthly budget.
+func reserveAIBudget(settings domain.TeamAISettings, estimatedTokens int) error {
+	if settings.TokensUsed+estimatedTokens > settings.MonthlyTokenBudget {
+		return errTokenBudgetExceeded
+	}
+	return nil
+}
+
+func (app *Application) recordLLMUsage(ctx context.Context, teamID, userID, feature string, resp *llm.Response) {
+	_, err := app.DB.ExecContext(ctx, `
+		INSERT INTO llm_usage (team_id, user_id, feature, model, prompt_tokens, completion_tokens, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, teamID, userID, feature, resp.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to record LLM usage")
+	}
+}
+
+func (app *Application) getAISettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	if _, err := app.getTeamRole(teamID, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	settings, err := app.loadAISettings(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get AI settings")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"settings":  settings,
+		"available": app.LLM != nil,
+	})
+}
+
+func (app *Application) updateAISettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	var req domain.UpdateTeamAISettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.MonthlyTokenBudget != nil && *req.MonthlyTokenBudget < 0 {
+		respondWithError(w, http.StatusBadRequest, "Monthly token budget cannot be negative")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	settings, err := app.loadAISettings(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get AI settings")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if req.SummariesEnabled != nil {
+		settings.SummariesEnabled = *req.SummariesEnabled
+	}
+	if req.TaskSuggestionsEnabled != nil {
+		settings.TaskSuggestionsEnabled = *req.TaskSuggestionsEnabled
+	}
+	if req.MonthlyTokenBudget != nil {
+		settings.MonthlyTokenBudget = *req.MonthlyTokenBudget
+	}
+
+	err = app.DB.QueryRow(`
+		INSERT INTO team_ai_settings (team_id, summaries_enabled, task_suggestions_enabled, monthly_token_budget, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (team_id) DO UPDATE
+		SET summaries_enabled = EXCLUDED.summaries_enabled,
+		    task_suggestions_enabled = EXCLUDED.task_suggestions_enabled,
+		    monthly_token_budget = EXCLUDED.monthly_token_budget
+		RETURNING updated_at
+	`, teamID, settings.SummariesEnabled, settings.TaskSuggestionsEnabled, settings.MonthlyTokenBudget).Scan(&settings.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update AI settings")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update AI settings")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"settings":  settings,
+		"available": app.LLM != nil,
+	})
+}
+
+func (app *Application) markChannelReadHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	channelID := vars["channelId"]
+
+	if _, err := app.getChannelTeam(channelID, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	var lastReadAt time.Time
+	err := app.DB.QueryRow(`
+		INSERT INTO channel_read_states (channel_id, user_id, last_read_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (channel_id, user_id) DO UPDATE SET last_read_at = EXCLUDED.last_read_at
+		RETURNING last_read_at
+	`, channelID, claims.UserID).Scan(&lastReadAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to mark channel as read")
+		respondWithError(w, http.StatusInternalServerError, "Failed to mark channel as read")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"channel_id":   channelID,
+		"last_read_at": lastReadAt,
+	})
+}
+
+func (app *Application) summarizeChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	channelID := vars["channelId"]
+
+	teamID, err := app.getChannelTeam(channelID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if app.LLM == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Channel summaries are not available on this server")
+		return
+	}
+
+	settings, err := app.loadAISettings(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get AI settings")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if !settings.SummariesEnabled {
+		respondWithError(w, http.StatusForbidden, "Channel summaries are disabled for this team")
+		return
+	}
+
+	since, err := app.unreadWindowStart(r, channelID, claims.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid since parameter, expected RFC 3339 timestamp")
+		return
+	}
+
+	lines, lastMessageID, err := app.summaryTranscript(r.Context(), channelID, since)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get messages for summary")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	summary := domain.ChannelSummary{
+		ChannelID:    channelID,
+		Since:        since,
+		MessageCount: len(lines),
+		GeneratedAt:  time.Now(),
+	}
+
+	if len(lines) == 0 {
+		respondWithJSON(w, http.StatusOK, summary)
+		return
+	}
+
+	// The newest message identifies the window's content, so the cached
+	// summary goes stale as soon as anything new is posted.
+	cacheKey := fmt.Sprintf("summary:%s:%d:%s", channelID, since.Unix(), lastMessageID)
+	if cached, err := app.Cache.Get(r.Context(), cacheKey); err == nil {
+		if json.Unmarshal([]byte(cached), &summary) == nil {
+			summary.Cached = true
+			summary.TokensUsed = 0
+			respondWithJSON(w, http.StatusOK, summary)
+			return
+		}
+	} else if err != cache.ErrCacheMiss {
+		app.Logger.WithError(err).Warn("Failed to read summary cache")
+	}
+
+	transcript := strings.Join(lines, "\n")
+	if err := reserveAIBudget(settings, llm.EstimateTokens(summarySystemPrompt+transcript)+app.Config.LLM.MaxTokens); err != nil {
+		respondWithError(w, http.StatusTooManyRequests, "This team's monthly AI token budget has been used up")
+		return
+	}
+
+	resp, err := app.LLM.Complete(r.Context(), llm.Request{
+		Messages: []llm.Message{
+			{Role: "system", Content: summarySystemPrompt},
+			{Role: "user", Content: transcript},
+		},
+	})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate channel summary")
+		respondWithError(w, http.StatusBadGateway, "Failed to generate summary")
+		return
+	}
+
+	app.recordLLMUsage(r.Context(), teamID, claims.UserID, "channel_summary", resp)
+
+	summary.Summary = resp.Content
+	summary.TokensUsed = resp.Usage.Total()
+
+	if err := app.Cache.Set(r.Context(), cacheKey, summary, summaryCacheTTL); err != nil {
+		app.Logger.WithError(err).Warn("Failed to cache channel summary")
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// unreadWindowStart resolves where the summary window begins: the explicit
+// ?since= parameter, else the user's read marker, else the last 24 hours.
+func (app *Application) unreadWindowStart(r *http.Request, channelID, userID string) (time.Time, error) {
+	if since := r.URL.Query().Get("since"); since != "" {
+		return time.Parse(time.RFC3339, since)
+	}
+
+	var lastReadAt time.Time
+	err := app.DB.QueryRowContext(r.Context(), `
+		SELECT last_read_at FROM channel_read_states WHERE channel_id = $1 AND user_id = $2
+	`, channelID, userID).Scan(&lastReadAt)
+	if err == nil {
+		return lastReadAt, nil
+	}
+	if err != sql.ErrNoRows {
+		app.Logger.WithError(err).Warn("Failed to get channel read state")
+	}
+
+	return time.Now().Add(-defaultSummaryWindow), nil
+}
+
+// summaryTranscript returns the window's messages as "username: content"
+// lines, oldest first. When the window is too large, the oldest messages are
+// dropped so the most recent conversation is always included.
+func (app *Application) summaryTranscript(ctx context.Context, channelID string, since time.Time) ([]string, string, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT m.id, u.username, m.content
+		FROM messages m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.channel_id = $1 AND m.created_at > $2 AND m.is_deleted = false
+		  AND m.type IN ('text', 'system')
+		ORDER BY m.created_at DESC
+		LIMIT $3
+	`, channelID, since, summaryMessageLimit)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	var lastMessageID string
+	size := 0
+
+	for rows.Next() {
+		var id, username, content string
+		if err := rows.Scan(&id, &username, &content); err != nil {
+			return nil, "", err
+		}
+
+		if lastMessageID == "" {
+			lastMessageID = id
+		}
+
+		line := username + ": " + content
+		if size+len(line) > maxSummaryTranscriptChars {
+			break
+		}
+		size += len(line) + 1
+		lines = append(lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines, lastMessageID, nil
+}
@@ -0,0 +1,450 @@
+// Package api is the HTTP API: the Application that holds the server's
+// dependencies, its handlers and the router that serves them. cmd/api
+// builds one from the configuration; tests build one with fakes.
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/apiversion"
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/automation"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/deprecation"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/export"
+	"github.com/cbalite/backend/internal/features"
+	"github.com/cbalite/backend/internal/inbound"
+	"github.com/cbalite/backend/internal/llm"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/notify"
+	"github.com/cbalite/backend/internal/oncall"
+	"github.com/cbalite/backend/internal/repository"
+	"github.com/cbalite/backend/internal/scheduler"
+	"github.com/cbalite/backend/internal/statuspage"
+	"github.com/cbalite/backend/internal/throttle"
+	"github.com/cbalite/backend/internal/websocket"
+	"github.com/cbalite/backend/pkg/httpjson"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+type Application struct {
+	Config         *config.Config
+	Logger         *logger.Logger
+	DB             *database.PostgresDB
+	// Repositories hold what the SDK's endpoints read and write; the rest
+	// of the API still queries DB directly.
+	repository.Repositories
+	Cache          cache.Cache
+	Invalidator    *cache.Invalidator
+	WSHub          *websocket.Hub
+	Events         *events.Bus
+	LLM            llm.Provider
+	Notifier       *notify.Notifier
+	Escalator      *oncall.Escalator
+	Exporter       *export.Exporter
+	Inbound        *inbound.Registry
+	Throttle       *throttle.Limiter
+	Requests       *throttle.RequestLimiter
+	Features       *features.Set
+	Deprecations   *deprecation.Tracker
+	AuthMiddleware *middleware.AuthMiddleware
+}
+
+// Setup finishes an Application whose fields are set: it sets up the
+// request limiter and the inbound webhook kinds, and logs failed responses
+// with app.Logger. Call it once, before Handler.
+func (app *Application) Setup() {
+	responseLogger = app.Logger
+	app.Requests = throttle.NewRequestLimiter(&app.Config.RateLimit, app.Cache, appAuthzStore{app: app}, app.Logger)
+
+	app.Inbound.Register(statuspage.KindStatuspage, app.statusWebhookHandler(statuspage.ParseStatuspage))
+	app.Inbound.Register(statuspage.KindGeneric, app.statusWebhookHandler(statuspage.ParseGeneric))
+}
+
+// Start runs the work that happens outside requests: warming the cache,
+// automation rules and the other event subscribers, and the scheduled jobs.
+// Work that runs in the background stops when ctx is cancelled.
+func (app *Application) Start(ctx context.Context) {
+	go app.warmCache(ctx)
+
+	automation.NewEngine(app.DB, &automationExecutor{app: app}, app.Logger).Start(app.Events)
+	app.Events.Subscribe(events.MessagePosted, app.classifyMessage)
+	app.Events.Subscribe(events.MessagePosted, app.checkWatchLists)
+	app.Events.Subscribe(events.MessagePosted, app.relayToKiosks)
+	app.Events.Subscribe(events.MessagePosted, app.replyOutOfOffice)
+	app.Events.Subscribe(events.MessagePosted, app.forwardMentionsToDelegates)
+	app.Events.Subscribe(events.MessagePosted, app.mirrorThreadReplyToTask)
+	app.Events.Subscribe(events.TaskCommented, app.mirrorCommentToThread)
+	app.Events.Subscribe(events.TaskCreated, app.forwardAssignmentsToDelegates)
+	app.Events.Subscribe(events.TaskUpdated, app.forwardAssignmentsToDelegates)
+	app.Events.Subscribe(events.TaskCreated, app.relayToKiosks)
+	app.Events.Subscribe(events.TaskUpdated, app.relayToKiosks)
+
+	jobs := scheduler.New(app.Logger)
+	jobs.Every("task-reports", time.Minute, app.runDueTaskReports)
+	jobs.Every("held-notifications", time.Minute, app.Notifier.DeliverHeld)
+	jobs.Every("deprecated-calls", time.Minute, app.FlushDeprecatedCalls)
+	jobs.Every("undo-expiry", time.Minute, app.purgeExpiredUndos)
+	jobs.Every("integration-deliveries", time.Hour, app.purgeIntegrationDeliveries)
+	jobs.Start()
+}
+
+// Handler returns the server's routes: the API with its middleware, and the
+// WebSocket, status, readiness and metrics endpoints outside it. pool
+// admits requests to the database.
+func (app *Application) Handler(pool middleware.Pool) http.Handler {
+	cfg := app.Config
+	corsMiddleware := middleware.NewCORSMiddleware(&cfg.CORS)
+	admissionMiddleware := middleware.NewAdmissionMiddleware(&cfg.Admission)
+	poolAdmissionMiddleware := middleware.NewPoolAdmissionMiddleware(pool, cfg.Database.AcquireTimeout)
+	loggingMiddleware := middleware.NewLoggingMiddleware(app.Logger)
+	recoveryMiddleware := middleware.NewRecoveryMiddleware(app.Logger)
+
+	// Create main router with WebSocket endpoint outside middleware
+	mainRouter := mux.NewRouter()
+	
+	// WebSocket endpoint - no middleware applied
+	mainRouter.HandleFunc("/api/v1/ws", app.websocketHandler)
+	mainRouter.HandleFunc("/api/v1/kiosk/ws", app.kioskWebsocketHandler)
+
+	// Public status for uptime monitors: outside the API stack so checks
+	// don't use up a client's rate limit, with a cheap limiter of its own
+	statusLimiter := middleware.NewLocalRateLimitMiddleware(cfg.RateLimit.StatusRequestsPerMinute, middleware.NewClientIPResolver(&cfg.RateLimit))
+	mainRouter.Handle("/status", recoveryMiddleware(statusLimiter(app.publicStatusHandler()))).Methods("GET")
+
+	// Readiness for load balancers, which should stop sending traffic while
+	// the database fails over
+	mainRouter.Handle("/ready", recoveryMiddleware(http.HandlerFunc(app.readyHandler))).Methods("GET")
+
+	if cfg.Metrics.Enabled {
+		mainRouter.Handle("/metrics", recoveryMiddleware(app.metricsHandler())).Methods("GET")
+	}
+	
+	// API routes with full middleware stack
+	apiRouter := app.setupRoutes()
+	wrappedAPI := recoveryMiddleware(
+		loggingMiddleware(
+			corsMiddleware(
+				admissionMiddleware(app.Requests.EnforceIP(poolAdmissionMiddleware(apiversion.Negotiate(apiRouter)))),
+			),
+		),
+	)
+	
+	// Mount API with middleware
+	mainRouter.PathPrefix("/").Handler(wrappedAPI)
+
+	return mainRouter
+}
+
+func (app *Application) setupRoutes() *mux.Router {
+	r := mux.NewRouter()
+	r.Use(app.Deprecations.Annotate)
+
+	for _, version := range apiversion.Supported {
+		api := r.PathPrefix(version.Prefix()).Subrouter()
+		api.Use(apiversion.Pin(version))
+		app.registerRoutes(api, version)
+	}
+
+	return r
+}
+
+// registerRoutes adds one API version's routes. Versions share handlers;
+// those whose payloads differ between versions check
+// apiversion.FromContext.
+func (app *Application) registerRoutes(api *mux.Router, version apiversion.Version) {
+	api.HandleFunc("/health", app.healthCheckHandler).Methods("GET")
+	api.HandleFunc("/time", app.timeHandler).Methods("GET")
+	api.Handle("/meta", app.AuthMiddleware.OptionalAuth(http.HandlerFunc(app.metaHandler))).Methods("GET")
+
+	schemas := newSchemaCatalog(version.Prefix())
+	api.HandleFunc("/schemas", schemas.listSchemasHandler).Methods("GET")
+	api.HandleFunc("/schemas/{kind}/{name}", schemas.getSchemaHandler).Methods("GET")
+
+	api.HandleFunc("/auth/register", app.registerHandler).Methods("POST")
+	api.HandleFunc("/auth/login", app.loginHandler).Methods("POST")
+	api.HandleFunc("/auth/refresh", app.refreshTokenHandler).Methods("POST")
+	api.HandleFunc("/auth/logout", app.logoutHandler).Methods("POST")
+
+	api.HandleFunc("/inbound/{token}", app.receiveInboundWebhookHandler).Methods("POST")
+
+	api.HandleFunc("/public/{token}", app.getPublicShareHandler).Methods("GET")
+
+	kiosk := api.PathPrefix("/kiosk").Subrouter()
+	kiosk.Use(middleware.ValidateIDParams, app.kioskAuth)
+	kiosk.HandleFunc("", app.getKioskHandler).Methods("GET")
+	kiosk.HandleFunc("/channels/{channelId}/messages", app.getKioskMessagesHandler).Methods("GET")
+	kiosk.HandleFunc("/tasks", app.getKioskTasksHandler).Methods("GET")
+
+	api.HandleFunc("/oauth/token", app.oauthTokenHandler).Methods("POST")
+
+	authorizer := authz.NewAuthorizer(appAuthzStore{app: app}, app.Logger)
+
+	protected := api.PathPrefix("").Subrouter()
+	protected.Use(app.AuthMiddleware.Authenticate, app.Deprecations.Record, middleware.ValidateIDParams, app.Requests.Enforce, authorizer.Enforce)
+
+	protected.HandleFunc("/users/me", app.getCurrentUserHandler).Methods("GET")
+	protected.HandleFunc("/users/me", app.updateCurrentUserHandler).Methods("PUT")
+	protected.HandleFunc("/users/me/visibility", app.getProfileVisibilityHandler).Methods("GET")
+	protected.HandleFunc("/users/me/visibility", app.updateProfileVisibilityHandler).Methods("PUT")
+	protected.HandleFunc("/users/me/working-hours", app.getWorkingHoursHandler).Methods("GET")
+	protected.HandleFunc("/users/me/working-hours", app.updateWorkingHoursHandler).Methods("PUT")
+	protected.HandleFunc("/users/me/working-hours", app.deleteWorkingHoursHandler).Methods("DELETE")
+	protected.HandleFunc("/users/me/out-of-office", app.getOutOfOfficeHandler).Methods("GET")
+	protected.HandleFunc("/users/me/out-of-office", app.updateOutOfOfficeHandler).Methods("PUT")
+	protected.HandleFunc("/users/me/out-of-office", app.deleteOutOfOfficeHandler).Methods("DELETE")
+	protected.HandleFunc("/users/me/out-of-office/delegated", app.getDelegatedNotificationsHandler).Methods("GET")
+
+	protected.HandleFunc("/teams", app.createTeamHandler).Methods("POST")
+	protected.HandleFunc("/teams", app.getTeamsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}", app.getTeamHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}", app.updateTeamHandler).Methods("PUT")
+	protected.HandleFunc("/teams/{teamId}", app.deleteTeamHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/members", app.getTeamMembersHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/members", app.inviteTeamMemberHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/members/mentions", app.mentionSuggestionsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/members/{userId}", app.removeTeamMemberHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/channels", app.createChannelHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/channels", app.getChannelsHandler).Methods("GET")
+	protected.HandleFunc("/channels/{channelId}", app.getChannelHandler).Methods("GET")
+	protected.HandleFunc("/channels/{channelId}", app.updateChannelHandler).Methods("PUT")
+	protected.HandleFunc("/channels/{channelId}", app.deleteChannelHandler).Methods("DELETE")
+	protected.HandleFunc("/channels/{channelId}/archive", app.archiveChannelHandler).Methods("POST")
+	protected.HandleFunc("/channels/{channelId}/archive", app.unarchiveChannelHandler).Methods("DELETE")
+
+	protected.HandleFunc("/channels/{channelId}/messages", app.sendMessageHandler).Methods("POST")
+	protected.HandleFunc("/channels/{channelId}/messages", app.getMessagesHandler).Methods("GET")
+	protected.HandleFunc("/channels/{channelId}/read", app.markChannelReadHandler).Methods("POST")
+	protected.HandleFunc("/channels/{channelId}/summarize", app.summarizeChannelHandler).Methods("POST")
+	protected.HandleFunc("/channels/{channelId}/suggested-tasks", app.getSuggestedTasksHandler).Methods("GET")
+	protected.HandleFunc("/task-suggestions/{suggestionId}/accept", app.acceptTaskSuggestionHandler).Methods("POST")
+	protected.HandleFunc("/task-suggestions/{suggestionId}/dismiss", app.dismissTaskSuggestionHandler).Methods("POST")
+	protected.HandleFunc("/messages/{messageId}", app.updateMessageHandler).Methods("PUT")
+	protected.HandleFunc("/messages/{messageId}", app.deleteMessageHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/tasks", app.createTaskHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/tasks", app.getTasksHandler).Methods("GET")
+	protected.HandleFunc("/tasks/{taskId}", app.getTaskHandler).Methods("GET")
+	protected.HandleFunc("/tasks/{taskId}", app.updateTaskHandler).Methods("PUT")
+	protected.HandleFunc("/tasks/{taskId}", app.deleteTaskHandler).Methods("DELETE")
+
+	protected.HandleFunc("/tasks/{taskId}/comments", app.createTaskCommentHandler).Methods("POST")
+	protected.HandleFunc("/tasks/{taskId}/comments", app.getTaskCommentsHandler).Methods("GET")
+	protected.HandleFunc("/tasks/{taskId}/thread", app.getTaskThreadHandler).Methods("GET")
+	protected.HandleFunc("/tasks/{taskId}/thread", app.linkTaskThreadHandler).Methods("POST")
+	protected.HandleFunc("/tasks/{taskId}/thread", app.unlinkTaskThreadHandler).Methods("DELETE")
+	protected.HandleFunc("/tasks/{taskId}/events", app.getTaskEventsHandler).Methods("GET")
+	protected.HandleFunc("/tasks/{taskId}/undo", app.undoTaskHandler).Methods("POST")
+
+	protected.HandleFunc("/undo/{actionId}", app.undoHandler).Methods("POST")
+
+	protected.HandleFunc("/actions", app.getQuickActionsHandler).Methods("GET")
+	protected.HandleFunc("/actions", app.runQuickActionHandler).Methods("POST")
+
+	protected.HandleFunc("/sync/outbox", app.syncOutboxHandler).Methods("POST")
+
+	protected.HandleFunc("/teams/{teamId}/hooks", app.subscribeHookHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/hooks", app.getHooksHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/hooks/samples/{event}", app.hookSampleHandler).Methods("GET")
+	protected.HandleFunc("/hooks/{hookId}", app.unsubscribeHookHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/automations", app.createAutomationRuleHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/automations", app.getAutomationRulesHandler).Methods("GET")
+	protected.HandleFunc("/automations/{ruleId}", app.getAutomationRuleHandler).Methods("GET")
+	protected.HandleFunc("/automations/{ruleId}", app.updateAutomationRuleHandler).Methods("PUT")
+	protected.HandleFunc("/automations/{ruleId}", app.deleteAutomationRuleHandler).Methods("DELETE")
+	protected.HandleFunc("/automations/{ruleId}/runs", app.getAutomationRunsHandler).Methods("GET")
+
+	protected.HandleFunc("/teams/{teamId}/snippets", app.getSnippetsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/snippets", app.createSnippetHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/snippets/analytics", app.getSnippetAnalyticsHandler).Methods("GET")
+	protected.HandleFunc("/snippets/{snippetId}", app.updateSnippetHandler).Methods("PUT")
+	protected.HandleFunc("/snippets/{snippetId}", app.deleteSnippetHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/watch-lists", app.getWatchListsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/watch-lists", app.createWatchListHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/watch-lists/matches", app.getWatchListMatchesHandler).Methods("GET")
+	protected.HandleFunc("/watch-lists/{watchListId}", app.updateWatchListHandler).Methods("PUT")
+	protected.HandleFunc("/watch-lists/{watchListId}", app.deleteWatchListHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/welcome", app.getWelcomeSettingsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/welcome", app.updateWelcomeSettingsHandler).Methods("PUT")
+
+	protected.HandleFunc("/teams/{teamId}/ai-settings", app.getAISettingsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/ai-settings", app.updateAISettingsHandler).Methods("PUT")
+
+	protected.HandleFunc("/teams/{teamId}/deprecations", app.getDeprecationsHandler).Methods("GET")
+
+	protected.HandleFunc("/teams/{teamId}/oncall", app.getCurrentOnCallHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/oncall/schedules", app.createOnCallScheduleHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/oncall/schedules", app.getOnCallSchedulesHandler).Methods("GET")
+	protected.HandleFunc("/oncall/schedules/{scheduleId}", app.getOnCallScheduleHandler).Methods("GET")
+	protected.HandleFunc("/oncall/schedules/{scheduleId}", app.updateOnCallScheduleHandler).Methods("PUT")
+	protected.HandleFunc("/oncall/schedules/{scheduleId}", app.deleteOnCallScheduleHandler).Methods("DELETE")
+	protected.HandleFunc("/oncall/schedules/{scheduleId}/overrides", app.createOnCallOverrideHandler).Methods("POST")
+	protected.HandleFunc("/oncall/schedules/{scheduleId}/calendar.ics", app.getOnCallCalendarHandler).Methods("GET")
+	protected.HandleFunc("/oncall/overrides/{overrideId}", app.deleteOnCallOverrideHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/escalation-policies", app.createEscalationPolicyHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/escalation-policies", app.getEscalationPoliciesHandler).Methods("GET")
+	protected.HandleFunc("/escalation-policies/{policyId}", app.getEscalationPolicyHandler).Methods("GET")
+	protected.HandleFunc("/escalation-policies/{policyId}", app.updateEscalationPolicyHandler).Methods("PUT")
+	protected.HandleFunc("/escalation-policies/{policyId}", app.deleteEscalationPolicyHandler).Methods("DELETE")
+	protected.HandleFunc("/escalation-policies/{policyId}/trigger", app.triggerEscalationHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/escalations", app.getEscalationsHandler).Methods("GET")
+	protected.HandleFunc("/escalations/{escalationId}/acknowledge", app.acknowledgeEscalationHandler).Methods("POST")
+	protected.HandleFunc("/escalations/{escalationId}/resolve", app.resolveEscalationHandler).Methods("POST")
+
+	protected.HandleFunc("/incidents", app.createIncidentHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/incidents", app.getIncidentsHandler).Methods("GET")
+	protected.HandleFunc("/incidents/{incidentId}", app.getIncidentHandler).Methods("GET")
+	protected.HandleFunc("/incidents/{incidentId}", app.updateIncidentHandler).Methods("PUT")
+	protected.HandleFunc("/incidents/{incidentId}/tasks", app.linkIncidentTaskHandler).Methods("POST")
+	protected.HandleFunc("/incidents/{incidentId}/timeline", app.getIncidentTimelineHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/incident-templates", app.createIncidentTemplateHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/incident-templates", app.getIncidentTemplatesHandler).Methods("GET")
+	protected.HandleFunc("/incident-templates/{templateId}", app.deleteIncidentTemplateHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/inbound-webhooks", app.createInboundWebhookHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/inbound-webhooks", app.getInboundWebhooksHandler).Methods("GET")
+	protected.HandleFunc("/inbound-webhooks/{webhookId}", app.deleteInboundWebhookHandler).Methods("DELETE")
+	protected.HandleFunc("/teams/{teamId}/integrations/deliveries", app.getIntegrationDeliveriesHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/integrations/suspensions", app.getIntegrationSuspensionsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/integrations/suspensions/{kind}/{integrationId}", app.liftIntegrationSuspensionHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/status/components", app.createStatusComponentHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/status/components", app.getStatusComponentsHandler).Methods("GET")
+	protected.HandleFunc("/status/components/{componentId}", app.updateStatusComponentHandler).Methods("PUT")
+	protected.HandleFunc("/status/components/{componentId}", app.deleteStatusComponentHandler).Methods("DELETE")
+	protected.HandleFunc("/teams/{teamId}/status/subscriptions", app.createStatusSubscriptionHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/status/subscriptions", app.getStatusSubscriptionsHandler).Methods("GET")
+	protected.HandleFunc("/status/subscriptions/{subscriptionId}", app.deleteStatusSubscriptionHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/kiosk-tokens", app.createKioskTokenHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/kiosk-tokens", app.getKioskTokensHandler).Methods("GET")
+	protected.HandleFunc("/kiosk-tokens/{kioskTokenId}", app.revokeKioskTokenHandler).Methods("DELETE")
+
+	protected.HandleFunc("/teams/{teamId}/share-links", app.createShareLinkHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/share-links", app.getShareLinksHandler).Methods("GET")
+	protected.HandleFunc("/share-links/{shareLinkId}", app.revokeShareLinkHandler).Methods("DELETE")
+
+	protected.HandleFunc("/apps", app.createAppHandler).Methods("POST")
+	protected.HandleFunc("/apps", app.getAppsHandler).Methods("GET")
+	protected.HandleFunc("/apps/{appId}", app.deleteAppHandler).Methods("DELETE")
+	protected.HandleFunc("/apps/{appId}/manifest", app.updateAppManifestHandler).Methods("PUT")
+	protected.HandleFunc("/oauth/authorize", app.authorizeAppHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/apps", app.installAppHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/apps", app.getInstalledAppsHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/apps/{appId}", app.updateInstalledAppHandler).Methods("PUT")
+	protected.HandleFunc("/teams/{teamId}/apps/{appId}", app.uninstallAppHandler).Methods("DELETE")
+
+	protected.HandleFunc("/channels/{channelId}/export", app.requestChannelExportHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/message-policy", app.getMessagePolicyHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/message-policy", app.updateMessagePolicyHandler).Methods("PUT")
+	protected.HandleFunc("/channels/{channelId}/shares", app.shareChannelHandler).Methods("POST")
+	protected.HandleFunc("/channels/{channelId}/shares", app.getChannelSharesHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/channel-shares", app.getTeamChannelSharesHandler).Methods("GET")
+	protected.HandleFunc("/channel-shares/{shareId}/accept", app.acceptChannelShareHandler).Methods("POST")
+	protected.HandleFunc("/channel-shares/{shareId}", app.endChannelShareHandler).Methods("DELETE")
+	protected.HandleFunc("/exports/{exportId}", app.getChannelExportHandler).Methods("GET")
+	protected.HandleFunc("/exports/{exportId}/download", app.downloadChannelExportHandler).Methods("GET")
+
+	protected.HandleFunc("/teams/{teamId}/retention", app.getRetentionPolicyHandler).Methods("GET")
+	protected.HandleFunc("/teams/{teamId}/retention", app.updateRetentionPolicyHandler).Methods("PUT")
+	protected.HandleFunc("/teams/{teamId}/retention/preview", app.retentionPreviewHandler).Methods("GET")
+
+	protected.HandleFunc("/teams/{teamId}/task-reports", app.createTaskReportHandler).Methods("POST")
+	protected.HandleFunc("/teams/{teamId}/task-reports", app.getTaskReportsHandler).Methods("GET")
+	protected.HandleFunc("/task-reports/{reportId}", app.updateTaskReportHandler).Methods("PUT")
+	protected.HandleFunc("/task-reports/{reportId}", app.deleteTaskReportHandler).Methods("DELETE")
+	protected.HandleFunc("/task-reports/{reportId}/run", app.runTaskReportHandler).Methods("POST")
+
+	protected.HandleFunc("/time/parse", app.parseTimeHandler).Methods("POST")
+	protected.HandleFunc("/notifications", app.getNotificationsHandler).Methods("GET")
+	protected.HandleFunc("/notifications/{notificationId}/read", app.markNotificationReadHandler).Methods("POST")
+
+	protected.HandleFunc("/orgs", app.createOrganizationHandler).Methods("POST")
+	protected.HandleFunc("/orgs", app.getOrganizationsHandler).Methods("GET")
+	protected.HandleFunc("/orgs/{orgId}/members", app.addOrganizationMemberHandler).Methods("POST")
+	protected.HandleFunc("/orgs/{orgId}/directory", app.getDirectoryHandler).Methods("GET")
+
+	// The only routes third-party app tokens may call
+	authorizer.Require("GET", version.Prefix()+"/teams/{teamId}/channels", authz.ChannelsRead)
+	authorizer.Require("GET", version.Prefix()+"/channels/{channelId}", authz.ChannelsRead)
+	authorizer.Require("GET", version.Prefix()+"/channels/{channelId}/messages", authz.MessagesRead)
+	authorizer.Require("POST", version.Prefix()+"/channels/{channelId}/messages", authz.MessagesWrite)
+	authorizer.Require("GET", version.Prefix()+"/teams/{teamId}/tasks", authz.TasksRead)
+	authorizer.Require("GET", version.Prefix()+"/tasks/{taskId}", authz.TasksRead)
+	authorizer.Require("GET", version.Prefix()+"/tasks/{taskId}/comments", authz.TasksRead)
+	authorizer.Require("GET", version.Prefix()+"/tasks/{taskId}/thread", authz.TasksRead)
+	authorizer.Require("GET", version.Prefix()+"/tasks/{taskId}/events", authz.TasksRead)
+	authorizer.Require("POST", version.Prefix()+"/teams/{teamId}/tasks", authz.TasksWrite)
+	authorizer.Require("PUT", version.Prefix()+"/tasks/{taskId}", authz.TasksWrite)
+	authorizer.Require("DELETE", version.Prefix()+"/tasks/{taskId}", authz.TasksWrite)
+	authorizer.Require("POST", version.Prefix()+"/tasks/{taskId}/comments", authz.TasksWrite)
+	authorizer.Require("POST", version.Prefix()+"/tasks/{taskId}/thread", authz.TasksWrite)
+	authorizer.Require("DELETE", version.Prefix()+"/tasks/{taskId}/thread", authz.TasksWrite)
+	authorizer.Require("POST", version.Prefix()+"/tasks/{taskId}/undo", authz.TasksWrite)
+}
+
+func (app *Application) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	health := map[string]interface{}{
+		"status": "healthy",
+		"services": map[string]string{
+			"database": "unknown",
+			"cache":    "unknown",
+		},
+	}
+
+	if err := app.DB.HealthCheck(); err == nil {
+		health["services"].(map[string]string)["database"] = "healthy"
+	} else {
+		health["services"].(map[string]string)["database"] = "unhealthy"
+	}
+
+	if !app.Cache.Enabled() {
+		health["services"].(map[string]string)["cache"] = "disabled"
+	} else if err := app.Cache.HealthCheck(); err == nil {
+		health["services"].(map[string]string)["cache"] = "healthy"
+	} else {
+		health["services"].(map[string]string)["cache"] = "unhealthy"
+	}
+
+	health["websocket"] = app.WSHub.Stats()
+
+	respondWithJSON(w, http.StatusOK, health)
+}
+
+// responseLogger reports responses that failed to encode. It's set by
+// Setup.
+var responseLogger *logger.Logger
+
+// respondWithJSON sends payload, or a 500 when it can't be encoded. Those
+// failures are logged with the request, since the client only sees the 500.
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	err := httpjson.Respond(w, code, payload)
+	if err == nil || responseLogger == nil {
+		return
+	}
+
+	log := responseLogger.WithError(err)
+	path := "unknown path"
+	if r, ok := middleware.RequestFor(w); ok {
+		log = correlation.Log(r.Context(), log)
+		path = r.Method + " " + r.URL.Path
+	}
+	log.Errorf("Failed to encode %T response to %s", payload, path)
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	respondWithJSON(w, code, map[string]string{"error": message})
+}
\ No newline at end of file
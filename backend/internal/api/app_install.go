@@ -0,0 +1,255 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/hooks"
+)
+
+const appCommandTimeout = 5 * time.Second
+
+var (
+	errBotUsernameTaken = errors.New("bot username is already taken")
+	errCommandTaken     = errors.New("slash command is already used in this team")
+)
+
+var appCommandClient = hooks.NewClient(appCommandTimeout)
+
+// builtinCommandNames lists the slash commands apps can't claim.
+func (app *Application) builtinCommandNames() []string {
+	names := make([]string, 0, len(app.slashCommands()))
+	for name := range app.slashCommands() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func scanAppManifest(raw []byte) (*domain.AppManifest, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var manifest domain.AppManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// checkManifestTargets rejects webhook and command URLs that point inside
+// the network. Calls to them are checked again when they're made.
+func checkManifestTargets(ctx context.Context, manifest *domain.AppManifest) error {
+	for _, webhook := range manifest.Webhooks {
+		if err := hooks.CheckTarget(ctx, webhook.URL); err != nil {
+			return fmt.Errorf("webhook URL %q must be a public address", webhook.URL)
+		}
+	}
+	for _, command := range manifest.Commands {
+		if err := hooks.CheckTarget(ctx, command.URL); err != nil {
+			return fmt.Errorf("command URL %q must be a public address", command.URL)
+		}
+	}
+	return nil
+}
+
+// installApp installs an app in a team, or refreshes an existing
+// installation, and provisions the bot user, webhooks and slash commands its
+// manifest declares. It runs inside tx so a failed step leaves the team as
+// it was.
+func (app *Application) installApp(ctx context.Context, tx *sql.Tx, teamID, appID, userID string, scopes []string) (string, error) {
+	var rawManifest []byte
+	var botUserID *string
+	err := tx.QueryRowContext(ctx, `
+		SELECT manifest, bot_user_id FROM apps WHERE id = $1 FOR UPDATE
+	`, appID).Scan(&rawManifest, &botUserID)
+	if err != nil {
+		return "", err
+	}
+
+	manifest, err := scanAppManifest(rawManifest)
+	if err != nil {
+		return "", err
+	}
+
+	// Installing again replaces the granted scopes and invalidates the
+	// previous refresh token.
+	var installationID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO app_installations (id, team_id, app_id, scopes, installed_by, installed_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (team_id, app_id) DO UPDATE
+		SET scopes = EXCLUDED.scopes, installed_by = EXCLUDED.installed_by,
+		    installed_at = EXCLUDED.installed_at, refresh_token_hash = NULL
+		RETURNING id
+	`, uuid.New().String(), teamID, appID, pq.Array(scopes), userID).Scan(&installationID)
+	if err != nil {
+		return "", err
+	}
+
+	if manifest == nil {
+		return installationID, nil
+	}
+
+	if manifest.Bot != nil {
+		if botUserID == nil {
+			id := uuid.New().String()
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO users (id, email, username, password_hash, first_name, last_name, is_active, is_verified, is_bot)
+				VALUES ($1, $2, $3, '!', $4, '', true, true, true)
+			`, id, fmt.Sprintf("bot+%s@bots.invalid", appID), manifest.Bot.Username, manifest.Bot.DisplayName)
+			if err != nil {
+				if isUniqueViolation(err) {
+					return "", errBotUsernameTaken
+				}
+				return "", err
+			}
+			if _, err = tx.ExecContext(ctx, `UPDATE apps SET bot_user_id = $2 WHERE id = $1`, appID, id); err != nil {
+				return "", err
+			}
+			botUserID = &id
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, 'member')
+			ON CONFLICT (team_id, user_id) DO NOTHING
+		`, teamID, *botUserID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM rest_hooks WHERE installation_id = $1`, installationID); err != nil {
+		return "", err
+	}
+	for _, webhook := range manifest.Webhooks {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO rest_hooks (id, team_id, event, target_url, created_by, installation_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		`, uuid.New().String(), teamID, webhook.Event, webhook.URL, userID, installationID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM app_commands WHERE installation_id = $1`, installationID); err != nil {
+		return "", err
+	}
+	for _, command := range manifest.Commands {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO app_commands (id, installation_id, team_id, name, description, url)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, uuid.New().String(), installationID, teamID, command.Name, command.Description, command.URL)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return "", fmt.Errorf("%w: /%s", errCommandTaken, command.Name)
+			}
+			return "", err
+		}
+	}
+
+	return installationID, nil
+}
+
+// respondInstallError maps provisioning conflicts to 409s.
+func (app *Application) respondInstallError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBotUsernameTaken) || errors.Is(err, errCommandTaken) {
+		respondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+	app.Logger.WithError(err).Error("Failed to install app")
+	respondWithError(w, http.StatusInternalServerError, "Failed to install app")
+}
+
+// appSlashCommand finds a slash command an installed app registered in the
+// team. It returns nil when there is none.
+func (app *Application) appSlashCommand(ctx context.Context, teamID, name string) (slashCommandFunc, error) {
+	var appID, appName, url string
+	var botUserID, signingSecret *string
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT a.id, a.name, a.bot_user_id, a.signing_secret, c.url
+		FROM app_commands c
+		JOIN app_installations i ON i.id = c.installation_id
+		JOIN apps a ON a.id = i.app_id
+		WHERE c.team_id = $1 AND c.name = $2
+	`, teamID, name).Scan(&appID, &appName, &botUserID, &signingSecret, &url)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, inv commandInvocation) (map[string]interface{}, error) {
+		body, err := json.Marshal(map[string]interface{}{
+			"command":    "/" + inv.Name,
+			"text":       inv.Args,
+			"team_id":    inv.TeamID,
+			"channel_id": inv.ChannelID,
+			"user_id":    inv.UserID,
+			"app_id":     appID,
+			"timestamp":  time.Now().Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if id := correlation.FromContext(ctx); id != "" {
+			req.Header.Set("X-CBA-Correlation-ID", id)
+		}
+		if signingSecret != nil {
+			mac := hmac.New(sha256.New, []byte(*signingSecret))
+			mac.Write(body)
+			req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+
+		resp, err := appCommandClient.Do(req)
+		if err != nil {
+			app.Logger.WithError(err).Warnf("App command /%s could not be delivered", inv.Name)
+			return nil, &commandError{message: appName + " didn't respond to /" + inv.Name}
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, &commandError{message: appName + " couldn't run /" + inv.Name}
+		}
+
+		var reply struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&reply); err != nil && err != io.EOF {
+			return nil, &commandError{message: appName + " sent an invalid reply to /" + inv.Name}
+		}
+
+		if reply.Text == "" {
+			return map[string]interface{}{"message": "/" + inv.Name + " sent to " + appName}, nil
+		}
+
+		// Replies come from the app's bot; apps without one answer as a
+		// system message from the person who ran the command.
+		if botUserID != nil {
+			return app.createMessage(ctx, inv.TeamID, inv.ChannelID, *botUserID, reply.Text, "text")
+		}
+		return app.createMessage(ctx, inv.TeamID, inv.ChannelID, inv.UserID, reply.Text, "system")
+	}, nil
+}
@@ -1,13 +1,15 @@
-package main
+package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
 )
 
 func (app *Application) registerHandler(w http.ResponseWriter, r *http.Request) {
@@ -28,20 +30,6 @@ func (app *Application) registerHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Check if user already exists
-	var exists bool
-	err := app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 OR username = $2)", req.Email, req.Username).Scan(&exists)
-	if err != nil {
-		app.Logger.WithError(err).Error("Failed to check if user exists")
-		respondWithError(w, http.StatusInternalServerError, "Internal server error")
-		return
-	}
-
-	if exists {
-		respondWithError(w, http.StatusConflict, "User with this email or username already exists")
-		return
-	}
-
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -65,15 +53,11 @@ func (app *Application) registerHandler(w http.ResponseWriter, r *http.Request)
 		UpdatedAt:    time.Now(),
 	}
 
-	query := `
-		INSERT INTO users (id, email, username, password_hash, first_name, last_name, is_active, is_verified, last_seen, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`
-	
-	_, err = app.DB.Exec(query, user.ID, user.Email, user.Username, user.PasswordHash, 
-		user.FirstName, user.LastName, user.IsActive, user.IsVerified, 
-		user.LastSeen, user.CreatedAt, user.UpdatedAt)
-	if err != nil {
+	if err := app.Users.Create(r.Context(), user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			respondWithError(w, http.StatusConflict, "User with this email or username already exists")
+			return
+		}
 		app.Logger.WithError(err).Error("Failed to create user")
 		respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
@@ -119,24 +103,7 @@ func (app *Application) loginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find user by email or username
-	var user domain.User
-	var avatar *string
-	query := `
-		SELECT id, email, username, password_hash, first_name, last_name, avatar, is_active, is_verified, last_seen, created_at, updated_at
-		FROM users 
-		WHERE (email = $1 OR username = $1) AND is_active = true
-	`
-	
-	err := app.DB.QueryRow(query, req.EmailOrUsername).Scan(
-		&user.ID, &user.Email, &user.Username, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &avatar, &user.IsActive,
-		&user.IsVerified, &user.LastSeen, &user.CreatedAt, &user.UpdatedAt,
-	)
-	
-	// Handle NULL avatar
-	if avatar != nil {
-		user.Avatar = *avatar
-	}
+	user, err := app.Users.FindByLogin(r.Context(), req.EmailOrUsername)
 	if err != nil {
 		app.Logger.WithError(err).Debug("User not found")
 		respondWithError(w, http.StatusUnauthorized, "Invalid credentials")
@@ -151,8 +118,7 @@ func (app *Application) loginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update last seen
-	_, err = app.DB.Exec("UPDATE users SET last_seen = $1 WHERE id = $2", time.Now(), user.ID)
-	if err != nil {
+	if err := app.Users.Touch(r.Context(), user.ID, time.Now()); err != nil {
 		app.Logger.WithError(err).Error("Failed to update last seen")
 		// Continue anyway
 	}
@@ -196,29 +162,13 @@ func (app *Application) refreshTokenHandler(w http.ResponseWriter, r *http.Reque
 
 	// Validate refresh token
 	claims, err := app.AuthMiddleware.ValidateToken(req.RefreshToken)
-	if err != nil {
+	if err != nil || claims.AppID != "" {
 		respondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
 		return
 	}
 
 	// Get user
-	var user domain.User
-	var avatar *string
-	query := `
-		SELECT id, email, username, first_name, last_name, avatar, is_active, is_verified
-		FROM users 
-		WHERE id = $1 AND is_active = true
-	`
-	
-	err = app.DB.QueryRow(query, claims.UserID).Scan(
-		&user.ID, &user.Email, &user.Username, &user.FirstName,
-		&user.LastName, &avatar, &user.IsActive, &user.IsVerified,
-	)
-	
-	// Handle NULL avatar
-	if avatar != nil {
-		user.Avatar = *avatar
-	}
+	user, err := app.Users.Get(r.Context(), claims.UserID)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "User not found")
 		return
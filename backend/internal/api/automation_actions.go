@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/oncall"
+)
+
+// automationExecutor runs automation rule actions on behalf of the rule's
+// creator, reusing the same helpers as the REST handlers.
+type automationExecutor struct {
+	app *Application
+}
+
+func (e *automationExecutor) PostMessage(ctx context.Context, teamID, actorID, channel, content string) error {
+	channelID, err := e.app.resolveChannel(ctx, teamID, channel)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.app.createMessage(ctx, teamID, channelID, actorID, content, string(domain.MessageTypeSystem))
+	return err
+}
+
+func (e *automationExecutor) CreateTask(ctx context.Context, teamID, actorID string, params map[string]string) error {
+	priority := params["priority"]
+	if priority == "" {
+		priority = string(domain.PriorityMedium)
+	}
+	if !isValidPriority(priority) {
+		return fmt.Errorf("invalid task priority %q", priority)
+	}
+
+	var assigneeID *string
+	if params["assignee"] != "" {
+		userID, err := e.app.resolveTeamMember(ctx, teamID, params["assignee"])
+		if err != nil {
+			return err
+		}
+		assigneeID = &userID
+	}
+
+	_, err := e.app.createTask(ctx, teamID, actorID, params["title"], params["description"], priority, assigneeID, nil)
+	return err
+}
+
+func (e *automationExecutor) AssignTask(ctx context.Context, teamID, actorID, taskID, assignee string) error {
+	userID, err := e.app.resolveTeamMember(ctx, teamID, assignee)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.app.updateTask(ctx, teamID, taskID, actorID, taskUpdate{AssigneeID: &userID})
+	return err
+}
+
+func (e *automationExecutor) SetTaskStatus(ctx context.Context, teamID, actorID, taskID, status string) error {
+	if !isValidTaskStatus(status) {
+		return fmt.Errorf("invalid task status %q", status)
+	}
+
+	_, err := e.app.updateTask(ctx, teamID, taskID, actorID, taskUpdate{Status: &status})
+	return err
+}
+
+func (e *automationExecutor) Escalate(ctx context.Context, teamID, actorID, policy, summary, sourceType, sourceID string) error {
+	escalationPolicy, err := oncall.ScanPolicy(e.app.DB.QueryRowContext(ctx, `
+		SELECT `+oncall.PolicyColumns+`
+		FROM escalation_policies
+		WHERE team_id = $1 AND (id::text = $2 OR name = $2)
+		LIMIT 1
+	`, teamID, strings.TrimSpace(policy)))
+	if err != nil {
+		return fmt.Errorf("escalation policy %q not found: %w", policy, err)
+	}
+
+	_, err = e.app.Escalator.Trigger(ctx, escalationPolicy, sourceType, sourceID, summary)
+	if err == oncall.ErrEscalationOpen {
+		return nil
+	}
+	return err
+}
+
+// resolveChannel accepts a channel ID, a channel name, or a #name reference.
+func (app *Application) resolveChannel(ctx context.Context, teamID, ref string) (string, error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "#")
+
+	var channelID string
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT id FROM channels
+		WHERE team_id = $1 AND (id::text = $2 OR name = $2)
+		LIMIT 1
+	`, teamID, ref).Scan(&channelID)
+	if err != nil {
+		return "", fmt.Errorf("channel %q not found: %w", ref, err)
+	}
+
+	return channelID, nil
+}
+
+// resolveTeamMember accepts a user ID, a username, or an @username reference
+// and only resolves users who belong to the team.
+func (app *Application) resolveTeamMember(ctx context.Context, teamID, ref string) (string, error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "@")
+
+	var userID string
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT u.id FROM users u
+		JOIN team_members tm ON tm.user_id = u.id
+		WHERE tm.team_id = $1 AND (u.id::text = $2 OR u.username = $2)
+		LIMIT 1
+	`, teamID, ref).Scan(&userID)
+	if err != nil {
+		return "", fmt.Errorf("team member %q not found: %w", ref, err)
+	}
+
+	return userID, nil
+}
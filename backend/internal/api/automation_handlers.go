@@ -0,0 +1,324 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/automation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const automationRunsLimit = 100
+
+func (app *Application) createAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	var req domain.CreateAutomationRule
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rule := domain.AutomationRule{
+		ID:         uuid.New().String(),
+		TeamID:     teamID,
+		Name:       req.Name,
+		Trigger:    req.Trigger,
+		Conditions: req.Conditions,
+		Actions:    req.Actions,
+		IsEnabled:  true,
+		CreatedBy:  claims.UserID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if req.IsEnabled != nil {
+		rule.IsEnabled = *req.IsEnabled
+	}
+
+	if rule.Conditions == nil {
+		rule.Conditions = []domain.RuleCondition{}
+	}
+
+	if err := automation.ValidateRule(rule); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	conditions, _ := json.Marshal(rule.Conditions)
+	actions, _ := json.Marshal(rule.Actions)
+
+	_, err := app.DB.Exec(`
+		INSERT INTO automation_rules (id, team_id, name, trigger, conditions, actions, is_enabled, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, rule.ID, rule.TeamID, rule.Name, rule.Trigger, conditions, actions,
+		rule.IsEnabled, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create automation rule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create automation rule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, rule)
+}
+
+func (app *Application) getAutomationRulesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	if _, err := app.getTeamRole(teamID, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT `+automation.RuleColumns+`
+		FROM automation_rules
+		WHERE team_id = $1
+		ORDER BY created_at
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get automation rules")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	rules := []domain.AutomationRule{}
+	for rows.Next() {
+		rule, err := automation.ScanRule(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan automation rule row")
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating automation rule rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rules)
+}
+
+func (app *Application) getAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rule, ok := app.loadAutomationRule(w, mux.Vars(r)["ruleId"])
+	if !ok {
+		return
+	}
+
+	if _, err := app.getTeamRole(rule.TeamID, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rule)
+}
+
+func (app *Application) updateAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateAutomationRule
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rule, ok := app.loadAutomationRule(w, mux.Vars(r)["ruleId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, rule.TeamID, claims.UserID) {
+		return
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Trigger != nil {
+		rule.Trigger = *req.Trigger
+	}
+	if req.Conditions != nil {
+		rule.Conditions = *req.Conditions
+	}
+	if req.Actions != nil {
+		rule.Actions = *req.Actions
+	}
+	if req.IsEnabled != nil {
+		rule.IsEnabled = *req.IsEnabled
+	}
+
+	if rule.Conditions == nil {
+		rule.Conditions = []domain.RuleCondition{}
+	}
+
+	if err := automation.ValidateRule(rule); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conditions, _ := json.Marshal(rule.Conditions)
+	actions, _ := json.Marshal(rule.Actions)
+
+	err := app.DB.QueryRow(`
+		UPDATE automation_rules
+		SET name = $2, trigger = $3, conditions = $4, actions = $5, is_enabled = $6
+		WHERE id = $1
+		RETURNING updated_at
+	`, rule.ID, rule.Name, rule.Trigger, conditions, actions, rule.IsEnabled).Scan(&rule.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update automation rule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update automation rule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rule)
+}
+
+func (app *Application) deleteAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rule, ok := app.loadAutomationRule(w, mux.Vars(r)["ruleId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, rule.TeamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM automation_rules WHERE id = $1`, rule.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete automation rule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete automation rule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Automation rule deleted successfully"})
+}
+
+func (app *Application) getAutomationRunsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rule, ok := app.loadAutomationRule(w, mux.Vars(r)["ruleId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, rule.TeamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, rule_id, team_id, event_id, event_type, status, error,
+		       actions_executed, chain_depth, created_at
+		FROM automation_runs
+		WHERE rule_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, rule.ID, automationRunsLimit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get automation runs")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	runs := []domain.AutomationRun{}
+	for rows.Next() {
+		var run domain.AutomationRun
+		var runErr *string
+
+		err := rows.Scan(&run.ID, &run.RuleID, &run.TeamID, &run.EventID, &run.EventType, &run.Status,
+			&runErr, &run.ActionsExecuted, &run.ChainDepth, &run.CreatedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan automation run row")
+			continue
+		}
+
+		if runErr != nil {
+			run.Error = *runErr
+		}
+
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating automation run rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, runs)
+}
+
+func (app *Application) loadAutomationRule(w http.ResponseWriter, ruleID string) (domain.AutomationRule, bool) {
+	rule, err := automation.ScanRule(app.DB.QueryRow(`
+		SELECT `+automation.RuleColumns+`
+		FROM automation_rules
+		WHERE id = $1
+	`, ruleID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Automation rule not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get automation rule")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return rule, false
+	}
+
+	return rule, true
+}
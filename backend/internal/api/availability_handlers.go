@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/availability"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const mentionSuggestionLimit = 10
+
+func (app *Application) getWorkingHoursHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var hours domain.WorkingHours
+	var weekdays pq.StringArray
+	err := app.DB.QueryRow(`
+		SELECT timezone, weekdays, start_time, end_time, hold_notifications, updated_at
+		FROM user_working_hours
+		WHERE user_id = $1
+	`, claims.UserID).Scan(&hours.Timezone, &weekdays, &hours.StartTime, &hours.EndTime, &hours.HoldNotifications, &hours.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No working hours set")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get working hours")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	hours.Weekdays = []string(weekdays)
+
+	respondWithJSON(w, http.StatusOK, hours)
+}
+
+func (app *Application) updateWorkingHoursHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateWorkingHours
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	hours := domain.WorkingHours{
+		Timezone:          req.Timezone,
+		Weekdays:          req.Weekdays,
+		StartTime:         req.StartTime,
+		EndTime:           req.EndTime,
+		HoldNotifications: true,
+	}
+	if hours.Timezone == "" {
+		hours.Timezone = "UTC"
+	}
+	if req.HoldNotifications != nil {
+		hours.HoldNotifications = *req.HoldNotifications
+	}
+
+	if _, err := availability.New(hours); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := app.DB.QueryRow(`
+		INSERT INTO user_working_hours (user_id, timezone, weekdays, start_time, end_time, hold_notifications, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET timezone = EXCLUDED.timezone, weekdays = EXCLUDED.weekdays, start_time = EXCLUDED.start_time,
+		    end_time = EXCLUDED.end_time, hold_notifications = EXCLUDED.hold_notifications
+		RETURNING updated_at
+	`, claims.UserID, hours.Timezone, pq.Array(hours.Weekdays), hours.StartTime, hours.EndTime,
+		hours.HoldNotifications).Scan(&hours.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update working hours")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update working hours")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, hours)
+}
+
+// deleteWorkingHoursHandler clears the user's working hours. Anything held
+// for them is pushed on the scheduler's next pass.
+func (app *Application) deleteWorkingHoursHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	err := app.DB.RunInTransaction(r.Context(), func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(r.Context(), `DELETE FROM user_working_hours WHERE user_id = $1`, claims.UserID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(r.Context(), `
+			UPDATE notifications SET held_until = NOW() WHERE user_id = $1 AND held_until IS NOT NULL
+		`, claims.UserID)
+		return err
+	})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to delete working hours")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete working hours")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Working hours cleared"})
+}
+
+// loadAvailability computes the availability hint for each of the users
+// that has working hours set. Users without any are left out.
+func (app *Application) loadAvailability(ctx context.Context, userIDs []string) (map[string]domain.Availability, error) {
+	hours, err := app.Users.WorkingHours(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]domain.Availability{}
+	now := time.Now()
+	for userID, hours := range hours {
+		schedule, err := availability.New(hours)
+		if err != nil {
+			app.Logger.WithError(err).Warnf("User %s has invalid working hours", userID)
+			continue
+		}
+		result[userID] = schedule.Availability(now)
+	}
+	return result, nil
+}
+
+// mentionSuggestionsHandler powers @mention autocomplete: team members whose
+// username or name starts with q, with their availability hint.
+func (app *Application) mentionSuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	prefix := strings.TrimPrefix(strings.TrimSpace(r.URL.Query().Get("q")), "@")
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(prefix)) + "%"
+
+	rows, err := app.DB.QueryContext(r.Context(), `
+		SELECT u.id, u.username, u.first_name, u.last_name, u.avatar
+		FROM team_members tm
+		JOIN users u ON u.id = tm.user_id
+		WHERE tm.team_id = $1 AND u.is_active = true
+		  AND (LOWER(u.username) LIKE $2 OR LOWER(u.first_name) LIKE $2 OR LOWER(u.last_name) LIKE $2)
+		ORDER BY u.username
+		LIMIT $3
+	`, teamID, pattern, mentionSuggestionLimit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get mention suggestions")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	suggestions := []map[string]interface{}{}
+	userIDs := []string{}
+	for rows.Next() {
+		var userID, username, firstName, lastName string
+		var avatar *string
+		if err := rows.Scan(&userID, &username, &firstName, &lastName, &avatar); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan mention suggestion row")
+			continue
+		}
+		suggestion := map[string]interface{}{
+			"user_id":    userID,
+			"username":   username,
+			"first_name": firstName,
+			"last_name":  lastName,
+		}
+		if avatar != nil {
+			suggestion["avatar"] = *avatar
+		}
+		suggestions = append(suggestions, suggestion)
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating mention suggestion rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	availabilities, err := app.loadAvailability(r.Context(), userIDs)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get availability")
+	}
+	for i, userID := range userIDs {
+		if hint, ok := availabilities[userID]; ok {
+			suggestions[i]["availability"] = hint
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, suggestions)
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+// getChannelAccess is the one place handlers decide who can see a channel,
+// through app.Channels.Access. It returns sql.ErrNoRows when the user has no
+// access. Answers are cached until the channel or the user's memberships
+// change.
+func (app *Application) getChannelAccess(channelID, userID string) (repository.ChannelAccess, error) {
+	ctx := context.Background()
+	access, err := cache.Load(ctx, app.Invalidator, "channel_access:"+channelID+":"+userID, accessCacheTTL,
+		[]string{channelCacheScope(channelID), userCacheScope(userID)}, func() (*repository.ChannelAccess, error) {
+			access, err := app.Channels.Access(ctx, channelID, userID)
+			// No access is cached too
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &access, nil
+		})
+	if err != nil {
+		return repository.ChannelAccess{}, err
+	}
+	if access == nil {
+		return repository.ChannelAccess{}, sql.ErrNoRows
+	}
+	return *access, nil
+}
+
+const channelShareColumns = `s.id, s.channel_id, c.name, s.host_team_id, ht.name, s.guest_team_id, gt.name, s.status,
+	s.guest_keeps_history, s.requested_by, s.accepted_by, s.ended_by, s.created_at, s.accepted_at, s.ended_at`
+
+const channelShareFrom = `channel_shares s
+	JOIN channels c ON c.id = s.channel_id
+	JOIN teams ht ON ht.id = s.host_team_id
+	JOIN teams gt ON gt.id = s.guest_team_id`
+
+func scanChannelShare(row rowScanner) (domain.ChannelShare, error) {
+	var share domain.ChannelShare
+	err := row.Scan(&share.ID, &share.ChannelID, &share.ChannelName, &share.HostTeamID, &share.HostTeamName,
+		&share.GuestTeamID, &share.GuestTeamName, &share.Status, &share.GuestKeepsHistory, &share.RequestedBy,
+		&share.AcceptedBy, &share.EndedBy, &share.CreatedAt, &share.AcceptedAt, &share.EndedAt)
+	return share, err
+}
+
+func (app *Application) loadChannelShare(w http.ResponseWriter, shareID string) (domain.ChannelShare, bool) {
+	share, err := scanChannelShare(app.DB.QueryRow(`
+		SELECT `+channelShareColumns+` FROM `+channelShareFrom+` WHERE s.id = $1
+	`, shareID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel share not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel share")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return share, false
+	}
+	return share, true
+}
+
+func (app *Application) isTeamAdmin(teamID, userID string) (bool, error) {
+	role, err := app.getTeamRole(teamID, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return role == "owner" || role == "admin", err
+}
+
+// shareChannelHandler invites another team into a channel. The invitation
+// counts as the host team's acceptance; the guest team's admin still has to
+// accept it.
+func (app *Application) shareChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	channelID := mux.Vars(r)["channelId"]
+
+	var req domain.CreateChannelShare
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TeamID == "" {
+		respondWithError(w, http.StatusBadRequest, "team_id is required")
+		return
+	}
+
+	var hostTeamID, channelType string
+	var isPrivate bool
+	err := app.DB.QueryRow(`SELECT team_id, type, is_private FROM channels WHERE id = $1`, channelID).Scan(&hostTeamID, &channelType, &isPrivate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, hostTeamID, claims.UserID) {
+		return
+	}
+
+	if channelType == string(domain.ChannelTypeDirect) || isPrivate {
+		respondWithError(w, http.StatusBadRequest, "Private channels and direct messages can't be shared")
+		return
+	}
+	if req.TeamID == hostTeamID {
+		respondWithError(w, http.StatusBadRequest, "A channel can't be shared with its own team")
+		return
+	}
+
+	var guestExists bool
+	if err := app.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM teams WHERE id = $1)`, req.TeamID).Scan(&guestExists); err != nil {
+		app.Logger.WithError(err).Error("Failed to check team")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !guestExists {
+		respondWithError(w, http.StatusBadRequest, "Team not found")
+		return
+	}
+
+	var shareID string
+	err = app.DB.QueryRow(`
+		INSERT INTO channel_shares (channel_id, host_team_id, guest_team_id, guest_keeps_history, requested_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, channelID, hostTeamID, req.TeamID, req.GuestKeepsHistory, claims.UserID).Scan(&shareID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Channel is already shared with that team")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to share channel")
+		respondWithError(w, http.StatusInternalServerError, "Failed to share channel")
+		return
+	}
+
+	share, ok := app.loadChannelShare(w, shareID)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, share)
+}
+
+// getChannelSharesHandler lists the teams a channel is, was or may be shared
+// with.
+func (app *Application) getChannelSharesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	channelID := mux.Vars(r)["channelId"]
+
+	access, err := app.getChannelAccess(channelID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	query := `SELECT ` + channelShareColumns + ` FROM ` + channelShareFrom + ` WHERE s.channel_id = $1`
+	args := []interface{}{channelID}
+	if access.Guest() {
+		// Guests only see their own team's share
+		query += ` AND s.guest_team_id = $2`
+		args = append(args, access.OriginTeamID)
+	}
+	app.respondWithChannelShares(w, query+` ORDER BY s.created_at DESC`, args...)
+}
+
+// getTeamChannelSharesHandler lists shares a team hosts or is invited to,
+// including pending invitations waiting for its admins.
+func (app *Application) getTeamChannelSharesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	app.respondWithChannelShares(w, `
+		SELECT `+channelShareColumns+` FROM `+channelShareFrom+`
+		WHERE s.host_team_id = $1 OR s.guest_team_id = $1
+		ORDER BY s.created_at DESC
+	`, teamID)
+}
+
+func (app *Application) respondWithChannelShares(w http.ResponseWriter, query string, args ...interface{}) {
+	rows, err := app.DB.Query(query, args...)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get channel shares")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	shares := []domain.ChannelShare{}
+	for rows.Next() {
+		share, err := scanChannelShare(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan channel share row")
+			continue
+		}
+		shares = append(shares, share)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating channel share rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, shares)
+}
+
+func (app *Application) acceptChannelShareHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	share, ok := app.loadChannelShare(w, mux.Vars(r)["shareId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, share.GuestTeamID, claims.UserID) {
+		return
+	}
+
+	result, err := app.DB.Exec(`
+		UPDATE channel_shares SET status = 'active', accepted_by = $2, accepted_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, share.ID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to accept channel share")
+		respondWithError(w, http.StatusInternalServerError, "Failed to accept channel share")
+		return
+	}
+	if accepted, _ := result.RowsAffected(); accepted == 0 {
+		respondWithError(w, http.StatusConflict, "Channel share is not pending")
+		return
+	}
+	app.invalidateAccess(r.Context(), channelCacheScope(share.ChannelID))
+
+	if share, ok = app.loadChannelShare(w, share.ID); !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, share)
+}
+
+// endChannelShareHandler declines a pending share or unshares an active one.
+// Admins of either team can do it. Guests lose access straight away unless
+// the share lets them keep the history, in which case they can still read,
+// but not post, everything up to now.
+func (app *Application) endChannelShareHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	share, ok := app.loadChannelShare(w, mux.Vars(r)["shareId"])
+	if !ok {
+		return
+	}
+
+	hostAdmin, err := app.isTeamAdmin(share.HostTeamID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check user role")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	guestAdmin, err := app.isTeamAdmin(share.GuestTeamID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check user role")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !hostAdmin && !guestAdmin {
+		respondWithError(w, http.StatusForbidden, "Only admins of either team can unshare a channel")
+		return
+	}
+
+	result, err := app.DB.Exec(`
+		UPDATE channel_shares SET status = 'ended', ended_by = $2, ended_at = NOW()
+		WHERE id = $1 AND status <> 'ended'
+	`, share.ID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to end channel share")
+		respondWithError(w, http.StatusInternalServerError, "Failed to unshare channel")
+		return
+	}
+	if ended, _ := result.RowsAffected(); ended == 0 {
+		respondWithError(w, http.StatusConflict, "Channel share has already ended")
+		return
+	}
+	app.invalidateAccess(r.Context(), channelCacheScope(share.ChannelID))
+
+	if share, ok = app.loadChannelShare(w, share.ID); !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, share)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cbalite/backend/internal/classify"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/llm"
+)
+
+// classifyMessage is the optional classification stage of the message
+// pipeline. Messages posted in channels with classification enabled are
+// labelled with urgency and sentiment, and a message.classified event is
+// published so automation rules can react to the labels.
+func (app *Application) classifyMessage(ctx context.Context, event events.Event) {
+	message, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	// System messages come from automations and integrations; labelling
+	// them would let rules trigger themselves through the label event.
+	messageID, _ := message["id"].(string)
+	channelID, _ := message["channel_id"].(string)
+	content, _ := message["content"].(string)
+	if message["type"] != "text" || messageID == "" || content == "" {
+		return
+	}
+
+	channel, err := app.getChannelMeta(ctx, channelID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check channel classification setting")
+		return
+	}
+	if !channel.ClassificationEnabled {
+		return
+	}
+
+	result, err := app.classifierFor(ctx, event.TeamID, content).Classify(ctx, content)
+	if result != nil && result.PromptTokens+result.CompletionTokens > 0 {
+		app.recordLLMUsage(ctx, event.TeamID, event.ActorID, "message_classification", &llm.Response{
+			Model: result.Model,
+			Usage: llm.Usage{PromptTokens: result.PromptTokens, CompletionTokens: result.CompletionTokens},
+		})
+	}
+	if err != nil {
+		app.Logger.WithError(err).Warn("Message classification failed, falling back to keywords")
+		result, _ = classify.KeywordClassifier{}.Classify(ctx, content)
+	}
+
+	_, err = app.DB.ExecContext(ctx, `
+		INSERT INTO message_labels (message_id, urgency, sentiment, model, classified_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (message_id) DO UPDATE
+		SET urgency = EXCLUDED.urgency, sentiment = EXCLUDED.sentiment,
+		    model = EXCLUDED.model, classified_at = EXCLUDED.classified_at
+	`, messageID, result.Labels.Urgency, result.Labels.Sentiment, result.Model)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to store message labels")
+		return
+	}
+
+	// Other subscribers share the original payload, so build a copy
+	classified := make(map[string]interface{}, len(message)+1)
+	for key, value := range message {
+		classified[key] = value
+	}
+	classified["labels"] = map[string]interface{}{
+		"urgency":   result.Labels.Urgency,
+		"sentiment": result.Labels.Sentiment,
+	}
+
+	app.Events.Publish(ctx, events.Event{
+		Type:    events.MessageClassified,
+		TeamID:  event.TeamID,
+		ActorID: event.ActorID,
+		Data:    classified,
+	})
+}
+
+// classifierFor uses the configured model while the team has token budget
+// left and keyword heuristics otherwise.
+func (app *Application) classifierFor(ctx context.Context, teamID, content string) classify.Classifier {
+	if app.LLM == nil {
+		return classify.KeywordClassifier{}
+	}
+
+	settings, err := app.loadAISettings(ctx, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get AI settings")
+		return classify.KeywordClassifier{}
+	}
+
+	if reserveAIBudget(settings, classify.EstimateTokens(content)) != nil {
+		return classify.KeywordClassifier{}
+	}
+
+	return classify.NewLLMClassifier(app.LLM)
+}
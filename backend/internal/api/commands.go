@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// commandInvocation describes a slash command typed into a channel, e.g.
+// "/snippet standup".
+type commandInvocation struct {
+	TeamID    string
+	ChannelID string
+	UserID    string
+	Name      string
+	Args      string
+}
+
+// slashCommandFunc runs a command and returns the message it posted, if any.
+type slashCommandFunc func(ctx context.Context, inv commandInvocation) (map[string]interface{}, error)
+
+// commandError is returned by commands for problems the caller should see,
+// as opposed to internal failures.
+type commandError struct {
+	message string
+}
+
+func (e *commandError) Error() string {
+	return e.message
+}
+
+func (app *Application) slashCommands() map[string]slashCommandFunc {
+	return map[string]slashCommandFunc{
+		"snippet": app.snippetCommand,
+	}
+}
+
+func parseSlashCommand(content string) (name, args string) {
+	content = strings.TrimPrefix(strings.TrimSpace(content), "/")
+	name, args, _ = strings.Cut(content, " ")
+	return strings.ToLower(name), strings.TrimSpace(args)
+}
+
+func (app *Application) handleSlashCommand(w http.ResponseWriter, r *http.Request, inv commandInvocation) {
+	command, ok := app.slashCommands()[inv.Name]
+	if !ok {
+		var err error
+		command, err = app.appSlashCommand(r.Context(), inv.TeamID, inv.Name)
+		if err != nil {
+			app.Logger.WithError(err).Errorf("Failed to look up slash command /%s", inv.Name)
+			respondWithError(w, http.StatusInternalServerError, "Failed to run command")
+			return
+		}
+		ok = command != nil
+	}
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Unknown command /"+inv.Name)
+		return
+	}
+
+	message, err := command(r.Context(), inv)
+	if err != nil {
+		var cmdErr *commandError
+		if errors.As(err, &cmdErr) {
+			respondWithError(w, http.StatusBadRequest, cmdErr.Error())
+			return
+		}
+		app.Logger.WithError(err).Errorf("Slash command /%s failed", inv.Name)
+		respondWithError(w, http.StatusInternalServerError, "Failed to run command")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, message)
+}
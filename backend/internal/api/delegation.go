@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+// notifyDelegate sends the delegate of an absent user a copy of something
+// meant for them, marked with on_behalf_of.
+func (app *Application) notifyDelegate(ctx context.Context, ooo domain.OutOfOffice, notification domain.Notification) {
+	if ooo.DelegateID == nil {
+		return
+	}
+
+	absentUserID := ooo.UserID
+	notification.UserID = *ooo.DelegateID
+	notification.OnBehalfOf = &absentUserID
+	if notification.Data == nil {
+		notification.Data = map[string]interface{}{}
+	}
+	notification.Data["on_behalf_of"] = absentUserID
+
+	var username string
+	if err := app.DB.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1`, absentUserID).Scan(&username); err == nil {
+		notification.Title += " (for @" + username + ")"
+	}
+
+	if _, err := app.Notifier.Notify(ctx, notification); err != nil {
+		app.Logger.WithError(err).Errorf("Failed to notify delegate of user %s", absentUserID)
+	}
+}
+
+// forwardMentionsToDelegates copies mentions of users who are out of office
+// to their delegates.
+func (app *Application) forwardMentionsToDelegates(ctx context.Context, event events.Event) {
+	message, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	messageID, _ := message["id"].(string)
+	channelID, _ := message["channel_id"].(string)
+	senderID, _ := message["sender_id"].(string)
+	content, _ := message["content"].(string)
+	if message["type"] == string(domain.MessageTypeSystem) || messageID == "" {
+		return
+	}
+
+	usernames := mentionedUsernames(content)
+	if len(usernames) == 0 {
+		return
+	}
+
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT u.id FROM users u
+		JOIN team_members tm ON tm.user_id = u.id AND tm.team_id = $1
+		WHERE LOWER(u.username) = ANY($2) AND u.id <> $3
+	`, event.TeamID, pq.Array(usernames), senderID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get mentioned users")
+		return
+	}
+	mentioned := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err == nil {
+			mentioned = append(mentioned, userID)
+		}
+	}
+	rows.Close()
+
+	away, err := app.Users.OutOfOffice(ctx, mentioned)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check out of office")
+		return
+	}
+
+	for _, ooo := range away {
+		// The delegate was mentioned too and will see it anyway
+		if ooo.DelegateID == nil || *ooo.DelegateID == senderID {
+			continue
+		}
+		data := map[string]interface{}{
+			"message_id": messageID,
+			"channel_id": channelID,
+			"sender_id":  senderID,
+		}
+		// Lets pushes about one thread collapse together
+		if threadID, _ := message["reply_to_id"].(string); threadID != "" {
+			data["thread_id"] = threadID
+		}
+		app.notifyDelegate(ctx, ooo, domain.Notification{
+			TeamID: event.TeamID,
+			Type:   "delegated_mention",
+			Title:  "Mention",
+			Body:   content,
+			Data:   data,
+		})
+	}
+}
+
+// forwardAssignmentsToDelegates copies task assignments of users who are out
+// of office to their delegates.
+func (app *Application) forwardAssignmentsToDelegates(ctx context.Context, event events.Event) {
+	task, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	taskID, _ := task["id"].(string)
+	title, _ := task["title"].(string)
+	assigneeID, _ := task["assignee_id"].(string)
+	previousAssigneeID, _ := task["previous_assignee_id"].(string)
+	if assigneeID == "" || assigneeID == previousAssigneeID || assigneeID == event.ActorID {
+		return
+	}
+
+	away, err := app.Users.OutOfOffice(ctx, []string{assigneeID})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check out of office")
+		return
+	}
+	ooo, ok := away[assigneeID]
+	if !ok || ooo.DelegateID == nil || *ooo.DelegateID == event.ActorID {
+		return
+	}
+
+	app.notifyDelegate(ctx, ooo, domain.Notification{
+		TeamID: event.TeamID,
+		Type:   "delegated_task_assignment",
+		Title:  "Task assigned",
+		Body:   title,
+		Data: map[string]interface{}{
+			"task_id":     taskID,
+			"assigned_by": event.ActorID,
+		},
+	})
+}
+
+// getDelegatedNotificationsHandler lists what was sent to delegates on the
+// user's behalf, so nothing from their absence gets lost. read_at shows
+// whether the delegate has seen each one.
+func (app *Application) getDelegatedNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, user_id, COALESCE(team_id::text, ''), type, title, COALESCE(body, ''), data, read_at, on_behalf_of, created_at
+		FROM notifications
+		WHERE on_behalf_of = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, claims.UserID, notificationsLimit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get delegated notifications")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	notifications := []domain.Notification{}
+	for rows.Next() {
+		var notification domain.Notification
+		var data []byte
+
+		err := rows.Scan(&notification.ID, &notification.UserID, &notification.TeamID, &notification.Type,
+			&notification.Title, &notification.Body, &data, &notification.ReadAt, &notification.OnBehalfOf, &notification.CreatedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan notification row")
+			continue
+		}
+
+		if err := json.Unmarshal(data, &notification.Data); err != nil {
+			app.Logger.WithError(err).Error("Failed to decode notification data")
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating notification rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, notifications)
+}
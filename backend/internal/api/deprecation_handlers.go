@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/deprecation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+// FlushDeprecatedCalls moves the tracker's in-memory counts into the
+// database. Counts that fail to save are dropped rather than retried; the
+// report only needs to show who is still calling.
+func (app *Application) FlushDeprecatedCalls(ctx context.Context) error {
+	for _, usage := range app.Deprecations.Drain() {
+		var appID sql.NullString
+		if usage.AppID != "" {
+			appID = sql.NullString{String: usage.AppID, Valid: true}
+		}
+		_, err := app.DB.ExecContext(ctx, `
+			INSERT INTO deprecated_endpoint_calls (method, endpoint, user_id, app_id, calls, first_called_at, last_called_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (method, endpoint, user_id, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'::uuid))
+			DO UPDATE SET calls = deprecated_endpoint_calls.calls + EXCLUDED.calls,
+			              last_called_at = GREATEST(deprecated_endpoint_calls.last_called_at, EXCLUDED.last_called_at)
+		`, usage.Method, usage.Endpoint, usage.UserID, appID, usage.Calls, usage.First, usage.Last)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDeprecationsHandler shows team admins the deprecations in effect and
+// which of their members and installed apps still call those endpoints.
+func (app *Application) getDeprecationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.QueryContext(r.Context(), `
+		SELECT d.method, d.endpoint, d.user_id, u.username, COALESCE(d.app_id::text, ''), COALESCE(a.name, ''),
+		       d.calls, d.first_called_at, d.last_called_at
+		FROM deprecated_endpoint_calls d
+		JOIN users u ON u.id = d.user_id
+		LEFT JOIN apps a ON a.id = d.app_id
+		WHERE (d.app_id IS NULL AND d.user_id IN (SELECT user_id FROM team_members WHERE team_id = $1))
+		   OR d.app_id IN (SELECT app_id FROM app_installations WHERE team_id = $1)
+		ORDER BY d.last_called_at DESC
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get deprecated endpoint usage")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	usage := []domain.DeprecatedEndpointUsage{}
+	for rows.Next() {
+		var u domain.DeprecatedEndpointUsage
+		if err := rows.Scan(&u.Method, &u.Endpoint, &u.UserID, &u.Username, &u.AppID, &u.AppName,
+			&u.Calls, &u.FirstCalledAt, &u.LastCalledAt); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan deprecated endpoint usage")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Failed to get deprecated endpoint usage")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"deprecations": deprecation.Notices,
+		"usage":        usage,
+	})
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/oncall"
+)
+
+const escalationsLimit = 100
+
+func (app *Application) createEscalationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateEscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	policy := domain.EscalationPolicy{
+		ID:                uuid.New().String(),
+		TeamID:            teamID,
+		Name:              strings.TrimSpace(req.Name),
+		Steps:             req.Steps,
+		RepeatCount:       req.RepeatCount,
+		NotifyUrgentTasks: req.NotifyUrgentTasks,
+		CreatedBy:         claims.UserID,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if policy.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Policy name is required")
+		return
+	}
+
+	if err := oncall.ValidatePolicy(policy); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if !app.requirePolicyTargets(w, r.Context(), policy) {
+		return
+	}
+
+	steps, _ := json.Marshal(policy.Steps)
+
+	_, err := app.DB.Exec(`
+		INSERT INTO escalation_policies (id, team_id, name, steps, repeat_count, notify_urgent_tasks, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, policy.ID, policy.TeamID, policy.Name, steps, policy.RepeatCount, policy.NotifyUrgentTasks,
+		policy.CreatedBy, policy.CreatedAt, policy.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A policy with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create escalation policy")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create escalation policy")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, policy)
+}
+
+func (app *Application) getEscalationPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT `+oncall.PolicyColumns+`
+		FROM escalation_policies
+		WHERE team_id = $1
+		ORDER BY name
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get escalation policies")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	policies := []domain.EscalationPolicy{}
+	for rows.Next() {
+		policy, err := oncall.ScanPolicy(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan escalation policy row")
+			continue
+		}
+		policies = append(policies, policy)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating escalation policy rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, policies)
+}
+
+func (app *Application) getEscalationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	policy, ok := app.loadEscalationPolicy(w, r.Context(), mux.Vars(r)["policyId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, policy.TeamID, claims.UserID) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, policy)
+}
+
+func (app *Application) updateEscalationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateEscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	policy, ok := app.loadEscalationPolicy(w, r.Context(), mux.Vars(r)["policyId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, policy.TeamID, claims.UserID) {
+		return
+	}
+
+	if req.Name != nil {
+		policy.Name = strings.TrimSpace(*req.Name)
+		if policy.Name == "" {
+			respondWithError(w, http.StatusBadRequest, "Policy name is required")
+			return
+		}
+	}
+	if req.Steps != nil {
+		policy.Steps = *req.Steps
+	}
+	if req.RepeatCount != nil {
+		policy.RepeatCount = *req.RepeatCount
+	}
+	if req.NotifyUrgentTasks != nil {
+		policy.NotifyUrgentTasks = *req.NotifyUrgentTasks
+	}
+
+	if err := oncall.ValidatePolicy(policy); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !app.requirePolicyTargets(w, r.Context(), policy) {
+		return
+	}
+
+	steps, _ := json.Marshal(policy.Steps)
+
+	err := app.DB.QueryRow(`
+		UPDATE escalation_policies
+		SET name = $2, steps = $3, repeat_count = $4, notify_urgent_tasks = $5
+		WHERE id = $1
+		RETURNING updated_at
+	`, policy.ID, policy.Name, steps, policy.RepeatCount, policy.NotifyUrgentTasks).Scan(&policy.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A policy with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to update escalation policy")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update escalation policy")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, policy)
+}
+
+func (app *Application) deleteEscalationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	policy, ok := app.loadEscalationPolicy(w, r.Context(), mux.Vars(r)["policyId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, policy.TeamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM escalation_policies WHERE id = $1`, policy.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete escalation policy")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete escalation policy")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Escalation policy deleted successfully"})
+}
+
+// triggerEscalationHandler lets any team member page the policy by hand.
+func (app *Application) triggerEscalationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.TriggerEscalation
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Summary = strings.TrimSpace(req.Summary)
+	if req.Summary == "" {
+		respondWithError(w, http.StatusBadRequest, "Summary is required")
+		return
+	}
+
+	policy, ok := app.loadEscalationPolicy(w, r.Context(), mux.Vars(r)["policyId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, policy.TeamID, claims.UserID) {
+		return
+	}
+
+	escalation, err := app.Escalator.Trigger(r.Context(), policy, oncall.SourceManual, "", req.Summary)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to trigger escalation")
+		respondWithError(w, http.StatusInternalServerError, "Failed to trigger escalation")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, escalation)
+}
+
+func (app *Application) getEscalationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	status := r.URL.Query().Get("status")
+	switch domain.EscalationStatus(status) {
+	case "", domain.EscalationTriggered, domain.EscalationAcknowledged, domain.EscalationResolved:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid escalation status")
+		return
+	}
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT `+oncall.EscalationColumns+`
+		FROM escalations
+		WHERE team_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, teamID, status, escalationsLimit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get escalations")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	escalations := []domain.Escalation{}
+	for rows.Next() {
+		escalation, err := oncall.ScanEscalation(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan escalation row")
+			continue
+		}
+		escalations = append(escalations, escalation)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating escalation rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, escalations)
+}
+
+func (app *Application) acknowledgeEscalationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	escalationID := mux.Vars(r)["escalationId"]
+	if !app.requireEscalationAccess(w, r.Context(), escalationID, claims.UserID) {
+		return
+	}
+
+	escalation, err := app.Escalator.Acknowledge(r.Context(), escalationID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusConflict, "Escalation is not awaiting acknowledgement")
+		} else {
+			app.Logger.WithError(err).Error("Failed to acknowledge escalation")
+			respondWithError(w, http.StatusInternalServerError, "Failed to acknowledge escalation")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, escalation)
+}
+
+func (app *Application) resolveEscalationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	escalationID := mux.Vars(r)["escalationId"]
+	if !app.requireEscalationAccess(w, r.Context(), escalationID, claims.UserID) {
+		return
+	}
+
+	escalation, err := app.Escalator.Resolve(r.Context(), escalationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusConflict, "Escalation is already resolved")
+		} else {
+			app.Logger.WithError(err).Error("Failed to resolve escalation")
+			respondWithError(w, http.StatusInternalServerError, "Failed to resolve escalation")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, escalation)
+}
+
+func (app *Application) loadEscalationPolicy(w http.ResponseWriter, ctx context.Context, policyID string) (domain.EscalationPolicy, bool) {
+	policy, err := oncall.LoadPolicy(ctx, app.DB, policyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Escalation policy not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get escalation policy")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return policy, false
+	}
+
+	return policy, true
+}
+
+func (app *Application) requireEscalationAccess(w http.ResponseWriter, ctx context.Context, escalationID, userID string) bool {
+	var teamID string
+	err := app.DB.QueryRowContext(ctx, `SELECT team_id FROM escalations WHERE id = $1`, escalationID).Scan(&teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Escalation not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get escalation")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return false
+	}
+
+	return app.requireTeamMember(w, teamID, userID)
+}
+
+// requirePolicyTargets checks that every user target belongs to the team and
+// every schedule target is one of the team's schedules.
+func (app *Application) requirePolicyTargets(w http.ResponseWriter, ctx context.Context, policy domain.EscalationPolicy) bool {
+	var userIDs []string
+	for _, step := range policy.Steps {
+		for _, target := range step.Targets {
+			if target.Type == domain.EscalationTargetUser {
+				userIDs = append(userIDs, target.ID)
+				continue
+			}
+
+			var exists bool
+			err := app.DB.QueryRowContext(ctx, `
+				SELECT EXISTS(SELECT 1 FROM oncall_schedules WHERE id::text = $1 AND team_id = $2)
+			`, target.ID, policy.TeamID).Scan(&exists)
+			if err != nil {
+				app.Logger.WithError(err).Error("Failed to check escalation target")
+				respondWithError(w, http.StatusInternalServerError, "Internal server error")
+				return false
+			}
+			if !exists {
+				respondWithError(w, http.StatusBadRequest, "Schedule "+target.ID+" does not belong to the team")
+				return false
+			}
+		}
+	}
+
+	if len(userIDs) == 0 {
+		return true
+	}
+	return app.requireScheduleMembers(w, ctx, policy.TeamID, userIDs)
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/apiversion"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+var exportFilenameUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// parseExportBound reads a from/to parameter as an RFC 3339 timestamp or a
+// plain date. A plain date for the end of the range includes that whole day.
+func parseExportBound(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// requestChannelExportHandler queues an export of a channel's history and
+// returns straight away; poll the export to follow its progress.
+func (app *Application) requestChannelExportHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	channelID := mux.Vars(r)["channelId"]
+	query := r.URL.Query()
+
+	format := domain.ExportFormat(strings.ToLower(query.Get("format")))
+	switch format {
+	case "":
+		format = domain.ExportFormatJSON
+	case domain.ExportFormatJSON, domain.ExportFormatHTML:
+	default:
+		respondWithError(w, http.StatusBadRequest, "format must be json or html")
+		return
+	}
+
+	var teamID string
+	var channelCreatedAt time.Time
+	err := app.DB.QueryRow(`SELECT team_id, created_at FROM channels WHERE id = $1`, channelID).Scan(&teamID, &channelCreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	from, to := channelCreatedAt, time.Now()
+	if value := query.Get("from"); value != "" {
+		if from, err = parseExportBound(value, false); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid from parameter, expected RFC 3339 timestamp or YYYY-MM-DD")
+			return
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = parseExportBound(value, true); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid to parameter, expected RFC 3339 timestamp or YYYY-MM-DD")
+			return
+		}
+	}
+	if !from.Before(to) {
+		respondWithError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	export := domain.ChannelExport{
+		ID:          uuid.New().String(),
+		TeamID:      teamID,
+		ChannelID:   channelID,
+		Format:      format,
+		From:        from,
+		To:          to,
+		Status:      domain.ExportStatusPending,
+		RequestedBy: claims.UserID,
+		CreatedAt:   time.Now(),
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO channel_exports (id, team_id, channel_id, format, range_start, range_end, status, requested_by, correlation_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+	`, export.ID, export.TeamID, export.ChannelID, export.Format, export.From, export.To, export.Status, export.RequestedBy,
+		correlation.FromContext(r.Context()), export.CreatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create channel export")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create export")
+		return
+	}
+
+	app.Exporter.Wake()
+
+	w.Header().Set("Location", apiversion.FromContext(r.Context()).Prefix()+"/exports/"+export.ID)
+	respondWithJSON(w, http.StatusAccepted, export)
+}
+
+// loadChannelExport gets an export for one of the team's admins, writing the
+// error response itself when it can't.
+func (app *Application) loadChannelExport(w http.ResponseWriter, exportID, userID string) (domain.ChannelExport, bool) {
+	var export domain.ChannelExport
+	err := app.DB.QueryRow(`
+		SELECT id, team_id, channel_id, format, range_start, range_end, status, progress,
+		       message_count, error, requested_by, created_at, completed_at
+		FROM channel_exports
+		WHERE id = $1
+	`, exportID).Scan(&export.ID, &export.TeamID, &export.ChannelID, &export.Format, &export.From, &export.To,
+		&export.Status, &export.Progress, &export.MessageCount, &export.Error, &export.RequestedBy,
+		&export.CreatedAt, &export.CompletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Export not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel export")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return export, false
+	}
+
+	if !app.requireTeamAdmin(w, export.TeamID, userID) {
+		return export, false
+	}
+
+	return export, true
+}
+
+func (app *Application) getChannelExportHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	export, ok := app.loadChannelExport(w, mux.Vars(r)["exportId"], claims.UserID)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, export)
+}
+
+func (app *Application) downloadChannelExportHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	export, ok := app.loadChannelExport(w, mux.Vars(r)["exportId"], claims.UserID)
+	if !ok {
+		return
+	}
+
+	if export.Status != domain.ExportStatusCompleted {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Export is %s", export.Status))
+		return
+	}
+
+	var channelName, result string
+	err := app.DB.QueryRow(`
+		SELECT c.name, e.result FROM channel_exports e JOIN channels c ON c.id = e.channel_id WHERE e.id = $1
+	`, export.ID).Scan(&channelName, &result)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get channel export result")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	contentType := "application/json"
+	if export.Format == domain.ExportFormatHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	filename := fmt.Sprintf("%s-%s-to-%s.%s",
+		exportFilenameUnsafe.ReplaceAllString(strings.ToLower(channelName), "-"),
+		export.From.UTC().Format("2006-01-02"), export.To.UTC().Format("2006-01-02"), export.Format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(result))
+}
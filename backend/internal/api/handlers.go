@@ -0,0 +1,1573 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/classify"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/messagepolicy"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/repository"
+	"github.com/cbalite/backend/internal/throttle"
+	wsHandler "github.com/cbalite/backend/internal/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// Auth handlers are now in auth_handlers.go
+
+// getTeamRole returns the caller's role in the team, or sql.ErrNoRows when the
+// user is not a member. Roles are cached until the user's memberships change.
+func (app *Application) getTeamRole(teamID, userID string) (string, error) {
+	ctx := context.Background()
+	role, err := cache.Load(ctx, app.Invalidator, "team_role:"+teamID+":"+userID, accessCacheTTL,
+		[]string{userCacheScope(userID)}, func() (string, error) {
+			role, err := app.Teams.Role(ctx, teamID, userID)
+			// Not being a member is cached too
+			if err == sql.ErrNoRows {
+				return "", nil
+			}
+			return role, err
+		})
+	if err == nil && role == "" {
+		err = sql.ErrNoRows
+	}
+	return role, err
+}
+
+// getChannelTeam returns the team of a channel the user can read, or
+// sql.ErrNoRows when the channel doesn't exist or isn't visible to them.
+// Direct message channels are only visible to their participants.
+func (app *Application) getChannelTeam(channelID, userID string) (string, error) {
+	access, err := app.getChannelAccess(channelID, userID)
+	if err != nil {
+		return "", err
+	}
+	// Kept history after a share ended is only readable through the message list
+	if access.HistoryUntil != nil {
+		return "", sql.ErrNoRows
+	}
+	return access.TeamID, nil
+}
+
+func (app *Application) requireTeamAdmin(w http.ResponseWriter, teamID, userID string) bool {
+	role, err := app.getTeamRole(teamID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check user role")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return false
+	}
+
+	if role != "owner" && role != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only team owners and admins can perform this action")
+		return false
+	}
+
+	return true
+}
+
+func (app *Application) requireTeamMember(w http.ResponseWriter, teamID, userID string) bool {
+	if _, err := app.getTeamRole(teamID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return false
+	}
+
+	return true
+}
+
+func (app *Application) getCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	user, err := app.Users.Get(r.Context(), claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get current user")
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
+
+func (app *Application) updateCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UserUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Phone) > 30 {
+		respondWithError(w, http.StatusBadRequest, "Phone number is too long")
+		return
+	}
+	if req.FirstName == "" && req.LastName == "" && req.Avatar == "" && req.Phone == "" {
+		respondWithError(w, http.StatusBadRequest, "No fields to update")
+		return
+	}
+
+	if err := app.Users.Update(r.Context(), claims.UserID, req); err != nil {
+		app.Logger.WithError(err).Error("Failed to update user")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	app.getCurrentUserHandler(w, r)
+}
+
+func (app *Application) createTeamHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Team name is required")
+		return
+	}
+
+	team := domain.Team{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		OwnerID:     claims.UserID,
+	}
+	if err := app.Teams.Create(r.Context(), &team); err != nil {
+		app.Logger.WithError(err).Error("Failed to create team")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create team")
+		return
+	}
+	app.invalidateAccess(r.Context(), userCacheScope(claims.UserID))
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":          team.ID,
+		"name":        team.Name,
+		"description": team.Description,
+		"owner_id":    team.OwnerID,
+	})
+}
+
+func (app *Application) getTeamsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	memberships, err := app.Teams.ForUser(r.Context(), claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get user teams")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Ensure we always return an array, even if empty
+	teams := []map[string]interface{}{}
+	for _, membership := range memberships {
+		teams = append(teams, membershipPayload(membership, claims))
+	}
+
+	respondWithJSON(w, http.StatusOK, teams)
+}
+
+// getTeamHandler returns one of the caller's teams, as the team list shows
+// it.
+func (app *Application) getTeamHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	membership, err := app.Teams.Membership(r.Context(), teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get team")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, membershipPayload(membership, claims))
+}
+
+// membershipPayload is one of the caller's teams with their role in it.
+func membershipPayload(membership repository.Membership, claims *middleware.Claims) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          membership.Team.ID,
+		"name":        membership.Team.Name,
+		"description": membership.Team.Description,
+		"owner_id":    membership.Team.OwnerID,
+		"created_at":  membership.Team.CreatedAt,
+		"updated_at":  membership.Team.UpdatedAt,
+		"role":        membership.Role,
+		"joined_at":   membership.JoinedAt,
+		"permissions": authz.Team(authz.NewSubject(claims, membership.Role)),
+	}
+}
+
+func (app *Application) updateTeamHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusNotImplemented, map[string]string{"message": "Update team endpoint"})
+}
+
+func (app *Application) deleteTeamHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusNotImplemented, map[string]string{"message": "Delete team endpoint"})
+}
+
+func (app *Application) getTeamMembersHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.Teams.Members(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get team members")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Ensure we always return an array, even if empty
+	members := []map[string]interface{}{}
+	for _, row := range rows {
+		user := map[string]interface{}{
+			"email":      row.User.Email,
+			"username":   row.User.Username,
+			"first_name": row.User.FirstName,
+			"last_name":  row.User.LastName,
+		}
+		if row.User.Avatar != "" {
+			user["avatar"] = row.User.Avatar
+		}
+		members = append(members, map[string]interface{}{
+			"user_id":    row.UserID,
+			"role":       row.Role,
+			"joined_at":  row.JoinedAt,
+			"updated_at": row.UpdatedAt,
+			"user":       user,
+		})
+	}
+
+	userIDs := make([]string, len(members))
+	for i, member := range members {
+		userIDs[i] = member["user_id"].(string)
+	}
+	availabilities, err := app.loadAvailability(r.Context(), userIDs)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get availability")
+	}
+	away, err := app.Users.OutOfOffice(r.Context(), userIDs)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check out of office")
+	}
+	for _, member := range members {
+		userID := member["user_id"].(string)
+		if hint, ok := availabilities[userID]; ok {
+			member["availability"] = hint
+		}
+		if ooo, ok := away[userID]; ok {
+			member["out_of_office"] = map[string]interface{}{
+				"until":       ooo.EndsAt,
+				"delegate_id": ooo.DelegateID,
+			}
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, members)
+}
+
+func (app *Application) inviteTeamMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	var req struct {
+		Email    string `json:"email"`
+		Role     string `json:"role"`
+		Username string `json:"username,omitempty"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Email == "" {
+		respondWithError(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = "member"
+	}
+
+	// Verify that the requesting user has permission to invite members (owner or admin)
+	userRole, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check user role")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if userRole != "owner" && userRole != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only team owners and admins can invite members")
+		return
+	}
+
+	// Find user by email or username
+	var user domain.User
+	if req.Username != "" {
+		user, err = app.Users.FindByUsername(r.Context(), req.Username)
+	} else {
+		user, err = app.Users.FindByEmail(r.Context(), req.Email)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to find user")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	// Add user to team; this also joins the default channels and sends the
+	// welcome message
+	if err = app.addTeamMember(r.Context(), teamID, user.ID, req.Role, claims.UserID); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			respondWithError(w, http.StatusConflict, "User is already a member of this team")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to add team member")
+		respondWithError(w, http.StatusInternalServerError, "Failed to add team member")
+		return
+	}
+
+	var avatar *string
+	if user.Avatar != "" {
+		avatar = &user.Avatar
+	}
+	response := map[string]interface{}{
+		"message": "Team member added successfully",
+		"user_id": user.ID,
+		"role":    req.Role,
+		"user": map[string]interface{}{
+			"id":         user.ID,
+			"email":      user.Email,
+			"username":   user.Username,
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+			"avatar":     avatar,
+		},
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// createChannelHandler adds a custom channel to a team, with its creator as
+// the first member. Any member can create one; only admins can make it a
+// default channel, which new members join automatically.
+func (app *Application) createChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateChannel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		respondWithError(w, http.StatusBadRequest, "Channel name is required")
+		return
+	}
+	if len(name) > 100 || len(req.Description) > 500 {
+		respondWithError(w, http.StatusBadRequest, "Channel names are limited to 100 characters and descriptions to 500")
+		return
+	}
+	if req.IsPrivate && req.IsDefault {
+		respondWithError(w, http.StatusBadRequest, "Private channels cannot be default channels")
+		return
+	}
+	if req.IsDefault && role != "owner" && role != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only team owners and admins can create default channels")
+		return
+	}
+
+	channel := domain.Channel{
+		ID:                    uuid.New().String(),
+		TeamID:                teamID,
+		Name:                  name,
+		Description:           req.Description,
+		Type:                  domain.ChannelTypeCustom,
+		IsPrivate:             req.IsPrivate,
+		IsDefault:             req.IsDefault,
+		ClassificationEnabled: req.ClassificationEnabled,
+		CreatedBy:             claims.UserID,
+	}
+	if err := app.Channels.Create(r.Context(), &channel); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			respondWithError(w, http.StatusConflict, "A channel with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create channel")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create channel")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, channelPayload(channel,
+		authz.Channel(authz.NewSubject(claims, role), string(channel.Type), channel.IsPrivate, channel.IsDefault, false)))
+}
+
+// channelPayload is a channel as the API shows it to one caller.
+func channelPayload(channel domain.Channel, permissions authz.Permissions) map[string]interface{} {
+	payload := map[string]interface{}{
+		"id":                     channel.ID,
+		"team_id":                channel.TeamID,
+		"name":                   channel.Name,
+		"description":            channel.Description,
+		"type":                   channel.Type,
+		"is_private":             channel.IsPrivate,
+		"is_default":             channel.IsDefault,
+		"classification_enabled": channel.ClassificationEnabled,
+		"created_by":             channel.CreatedBy,
+		"created_at":             channel.CreatedAt,
+		"updated_at":             channel.UpdatedAt,
+		"permissions":            permissions,
+	}
+	if channel.ArchivedAt != nil {
+		payload["archived_at"] = *channel.ArchivedAt
+	}
+	return payload
+}
+
+func (app *Application) getChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	// Verify user has access to this team; the role decides the permissions
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	subject := authz.NewSubject(claims, role)
+
+	// The team's own channels plus those other teams share with it
+	rows, err := app.Channels.ForTeam(r.Context(), teamID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get team channels")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Ensure we always return an array, even if empty
+	channels := []map[string]interface{}{}
+	for _, row := range rows {
+		archived := row.ArchivedAt != nil
+		var channel map[string]interface{}
+		if row.TeamID != teamID {
+			// Guests have no role in the host team
+			channel = channelPayload(row.Channel, authz.Channel(authz.NewSubject(claims, ""), string(row.Type),
+				row.IsPrivate, row.IsDefault, archived))
+			channel["host_team"] = map[string]interface{}{
+				"id":   row.TeamID,
+				"name": row.HostTeamName,
+			}
+		} else {
+			channel = channelPayload(row.Channel, authz.Channel(subject, string(row.Type), row.IsPrivate, row.IsDefault, archived))
+		}
+		channel["is_shared"] = row.Shared
+		channels = append(channels, channel)
+	}
+
+	respondWithJSON(w, http.StatusOK, channels)
+}
+
+// getChannelHandler returns a channel the caller can see, through its team
+// or a share. Guests get the host team, and only read access when their
+// share has ended.
+func (app *Application) getChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	channelID := mux.Vars(r)["channelId"]
+
+	access, err := app.getChannelAccess(channelID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	channel, err := app.Channels.Get(r.Context(), channelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	// Guests have no role in the host team
+	role := ""
+	if !access.Guest() {
+		if role, err = app.getTeamRole(access.TeamID, claims.UserID); err != nil {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+	}
+	readOnly := access.HistoryUntil != nil || channel.ArchivedAt != nil
+
+	payload := channelPayload(channel.Channel, authz.Channel(authz.NewSubject(claims, role), string(channel.Type),
+		channel.IsPrivate, channel.IsDefault, readOnly))
+	payload["is_shared"] = channel.Shared
+	if access.Guest() {
+		host, err := app.getTeamMeta(r.Context(), access.TeamID)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to get host team")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		payload["host_team"] = map[string]interface{}{
+			"id":   host.ID,
+			"name": host.Name,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, payload)
+}
+
+func (app *Application) updateChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	channelID := vars["channelId"]
+
+	var req domain.UpdateChannel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	current, err := app.Channels.Get(r.Context(), channelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, current.TeamID, claims.UserID) {
+		return
+	}
+
+	if current.Type == domain.ChannelTypeDirect {
+		respondWithError(w, http.StatusBadRequest, "Direct message channels cannot be updated")
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			respondWithError(w, http.StatusBadRequest, "Channel name cannot be empty")
+			return
+		}
+		req.Name = &name
+	}
+	isPrivate, isDefault := current.IsPrivate, current.IsDefault
+	if req.IsPrivate != nil {
+		isPrivate = *req.IsPrivate
+	}
+	if req.IsDefault != nil {
+		isDefault = *req.IsDefault
+	}
+
+	// Private channels are invite-only, so they can never be auto-joined
+	if isPrivate && isDefault {
+		respondWithError(w, http.StatusBadRequest, "Private channels cannot be default channels")
+		return
+	}
+
+	if req.Name == nil && req.Description == nil && req.IsPrivate == nil && req.IsDefault == nil &&
+		req.ClassificationEnabled == nil {
+		respondWithError(w, http.StatusBadRequest, "No fields to update")
+		return
+	}
+
+	channel, err := app.Channels.Update(r.Context(), channelID, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			respondWithError(w, http.StatusConflict, "A channel with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to update channel")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update channel")
+		return
+	}
+	app.invalidateAccess(r.Context(), channelCacheScope(channel.ID))
+
+	respondWithJSON(w, http.StatusOK, channel)
+}
+
+func (app *Application) deleteChannelHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusNotImplemented, map[string]string{"message": "Delete channel endpoint"})
+}
+
+func (app *Application) sendMessageHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	channelID := vars["channelId"]
+
+	var req struct {
+		Content   string  `json:"content"`
+		Type      string  `json:"type"`
+		ReplyToID *string `json:"reply_to_id"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		app.Logger.WithError(err).Error("Failed to decode JSON request body")
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Content == "" {
+		respondWithError(w, http.StatusBadRequest, "Message content is required")
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = "text"
+	}
+
+	// Verify user has access to this channel (through its team or a share)
+	access, err := app.getChannelAccess(channelID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if access.HistoryUntil != nil {
+		respondWithError(w, http.StatusForbidden, "This channel is no longer shared with your team")
+		return
+	}
+	if access.ArchivedAt != nil {
+		respondWithError(w, http.StatusForbidden, "This channel is archived")
+		return
+	}
+	teamID := access.TeamID
+
+	if strings.HasPrefix(req.Content, "/") {
+		name, args := parseSlashCommand(req.Content)
+		app.handleSlashCommand(w, r, commandInvocation{
+			TeamID:    teamID,
+			ChannelID: channelID,
+			UserID:    claims.UserID,
+			Name:      name,
+			Args:      args,
+		})
+		return
+	}
+
+	outgoing := outgoingMessage{
+		TeamID:    teamID,
+		ChannelID: channelID,
+		UserID:    claims.UserID,
+		Content:   req.Content,
+		Type:      req.Type,
+	}
+	if access.Guest() {
+		outgoing.OriginTeamID = access.OriginTeamID
+	}
+	if req.ReplyToID != nil && *req.ReplyToID != "" {
+		parent, err := app.Messages.Get(r.Context(), *req.ReplyToID)
+		if err != nil && err != sql.ErrNoRows {
+			app.Logger.WithError(err).Error("Failed to check reply parent")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if err == sql.ErrNoRows || parent.ChannelID != channelID {
+			respondWithError(w, http.StatusBadRequest, "Replies must be to a message in the same channel")
+			return
+		}
+		outgoing.ReplyToID = *req.ReplyToID
+	}
+
+	ctx := r.Context()
+	if claims.AppID != "" {
+		ctx = withIntegration(ctx, throttle.Integration{Kind: domain.IntegrationApp, ID: claims.AppID})
+	}
+
+	message, err := app.postMessage(ctx, outgoing)
+	var throttled *integrationThrottledError
+	if errors.As(err, &throttled) {
+		respondThrottled(w, throttled.throttle)
+		return
+	}
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create message")
+		respondWithError(w, http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+
+	// The message map is also the event payload, so decorate a copy
+	response := maps.Clone(message)
+	actor, err := app.messageActor(access, claims.UserID)
+	if err == nil {
+		var policy domain.MessagePolicy
+		if policy, err = app.loadMessagePolicy(r.Context(), teamID); err == nil {
+			response["permissions"] = authz.Message(authz.NewSubject(claims, actor.Role), policy, messagepolicy.Message{
+				SenderID:  claims.UserID,
+				Type:      req.Type,
+				CreatedAt: time.Now(),
+			}, time.Now(), false)
+		}
+	}
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to compute message permissions")
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// createMessage stores a message and publishes message.posted. It is shared by
+// the REST handler and server-side producers such as automation rules.
+func (app *Application) createMessage(ctx context.Context, teamID, channelID, userID, content, messageType string) (map[string]interface{}, error) {
+	return app.postMessage(ctx, outgoingMessage{
+		TeamID:    teamID,
+		ChannelID: channelID,
+		UserID:    userID,
+		Content:   content,
+		Type:      messageType,
+	})
+}
+
+// outgoingMessage is a message about to be posted. TaskCommentID marks
+// messages mirrored from a task comment so the thread bridge doesn't copy
+// them back. OriginTeamID labels messages from guests in a shared channel.
+// ClientID is the sender's own ID for a message queued while offline.
+type outgoingMessage struct {
+	TeamID        string
+	ChannelID     string
+	UserID        string
+	Content       string
+	Type          string
+	ReplyToID     string
+	TaskCommentID string
+	OriginTeamID  string
+	ClientID      string
+}
+
+// Messages posted for an integration (see withIntegration) are held to the
+// integration rate limits first.
+func (app *Application) postMessage(ctx context.Context, msg outgoingMessage) (map[string]interface{}, error) {
+	if integration, ok := integrationFromContext(ctx); ok {
+		if err := app.admitIntegrationMessage(ctx, integration, msg.TeamID, msg.ChannelID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Delivery latency metrics count from here
+	acceptedAt := time.Now()
+	stored := repository.NewMessage{
+		Message: domain.Message{
+			ID:        uuid.New().String(),
+			TeamID:    msg.TeamID,
+			ChannelID: msg.ChannelID,
+			UserID:    msg.UserID,
+			Content:   msg.Content,
+			Type:      domain.MessageType(msg.Type),
+		},
+		OriginTeamID: msg.OriginTeamID,
+		ClientID:     msg.ClientID,
+	}
+	if msg.ReplyToID != "" {
+		stored.ReplyToID = &msg.ReplyToID
+	}
+	if err := app.Messages.Create(ctx, &stored); err != nil {
+		return nil, err
+	}
+
+	// Get user info for the response
+	sender, err := app.Users.Get(ctx, msg.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get user info")
+		// Continue anyway with basic info
+	}
+
+	message := map[string]interface{}{
+		"id":         stored.ID,
+		"team_id":    stored.TeamID,
+		"channel_id": stored.ChannelID,
+		"content":    stored.Content,
+		"type":       msg.Type,
+		"sender_id":  stored.UserID,
+		"created_at": stored.CreatedAt,
+		"updated_at": stored.UpdatedAt,
+		"sender": map[string]interface{}{
+			"username":   sender.Username,
+			"first_name": sender.FirstName,
+			"last_name":  sender.LastName,
+		},
+	}
+	if msg.ReplyToID != "" {
+		message["reply_to_id"] = msg.ReplyToID
+	}
+	if msg.TaskCommentID != "" {
+		message["task_comment_id"] = msg.TaskCommentID
+	}
+	if msg.OriginTeamID != "" {
+		message["origin_team_id"] = msg.OriginTeamID
+	}
+	if msg.ClientID != "" {
+		message["client_id"] = msg.ClientID
+	}
+
+	// Replies and rules the message sets off aren't the integration's own
+	app.Events.Publish(withoutIntegration(ctx), events.Event{
+		Type:       events.MessagePosted,
+		TeamID:     msg.TeamID,
+		ActorID:    msg.UserID,
+		Data:       message,
+		OccurredAt: acceptedAt,
+	})
+
+	return message, nil
+}
+
+func (app *Application) getMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	channelID := vars["channelId"]
+
+	// Verify user has access to this channel (through its team or a share)
+	access, err := app.getChannelAccess(channelID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	limit, ok := messageLimit(r.URL.Query().Get("limit"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+		return
+	}
+
+	// Optional search filters; urgency and sentiment match classification labels
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	urgency := r.URL.Query().Get("urgency")
+	sentiment := r.URL.Query().Get("sentiment")
+	thread := r.URL.Query().Get("thread")
+
+	if urgency != "" && !classify.IsValidUrgency(urgency) {
+		respondWithError(w, http.StatusBadRequest, "Invalid urgency filter")
+		return
+	}
+	if sentiment != "" && !classify.IsValidSentiment(sentiment) {
+		respondWithError(w, http.StatusBadRequest, "Invalid sentiment filter")
+		return
+	}
+
+	compact := compactRequested(r)
+
+	// Capability flags follow the policy of the channel's team
+	actor, err := app.messageActor(access, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check user role")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	policy, err := app.loadMessagePolicy(r.Context(), access.TeamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get message policy")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	subject := authz.NewSubject(claims, actor.Role)
+	now := time.Now()
+	// Read-only history from an ended share can't be changed
+	readOnly := access.HistoryUntil != nil
+
+	rows, err := app.Messages.List(r.Context(), channelID, repository.MessageQuery{
+		Limit:     limit,
+		Search:    search,
+		Urgency:   urgency,
+		Sentiment: sentiment,
+		Thread:    thread,
+		Until:     access.HistoryUntil,
+	})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get messages")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Ensure we always return an array, even if empty
+	messages := []map[string]interface{}{}
+	for _, row := range rows {
+		if compact {
+			// Just enough to render a line of text on a slow connection
+			messages = append(messages, map[string]interface{}{
+				"id":         row.ID,
+				"content":    row.Content,
+				"type":       row.Type,
+				"sender_id":  row.UserID,
+				"sender":     map[string]interface{}{"username": row.Sender.Username},
+				"created_at": row.CreatedAt,
+			})
+			continue
+		}
+
+		message := map[string]interface{}{
+			"id":         row.ID,
+			"content":    row.Content,
+			"type":       row.Type,
+			"sender_id":  row.UserID,
+			"is_pinned":  row.IsPinned,
+			"created_at": row.CreatedAt,
+			"updated_at": row.UpdatedAt,
+			"sender": map[string]interface{}{
+				"username":   row.Sender.Username,
+				"first_name": row.Sender.FirstName,
+				"last_name":  row.Sender.LastName,
+			},
+		}
+
+		if row.ReplyToID != nil {
+			message["reply_to_id"] = *row.ReplyToID
+		}
+
+		message["permissions"] = authz.Message(subject, policy, messagepolicy.Message{
+			SenderID:  row.UserID,
+			Type:      string(row.Type),
+			CreatedAt: row.CreatedAt,
+		}, now, readOnly)
+
+		if row.OriginTeam != nil {
+			message["origin_team"] = map[string]interface{}{
+				"id":   row.OriginTeam.ID,
+				"name": row.OriginTeam.Name,
+			}
+		}
+
+		if row.Labels != nil {
+			message["labels"] = map[string]interface{}{
+				"urgency":   row.Labels.Urgency,
+				"sentiment": row.Labels.Sentiment,
+			}
+		}
+
+		messages = append(messages, message)
+	}
+
+	// Reverse the order to show oldest first (since we queried DESC for limit)
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	respondWithJSON(w, http.StatusOK, messages)
+}
+
+// messageLimit reads how many messages a page of history holds: 50 unless
+// the limit parameter asks for between 1 and 200.
+func messageLimit(param string) (int, bool) {
+	if param == "" {
+		return 50, true
+	}
+	limit, err := strconv.Atoi(param)
+	if err != nil || limit < 1 || limit > 200 {
+		return 0, false
+	}
+	return limit, true
+}
+
+func (app *Application) createTaskHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	var req struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Priority    string `json:"priority"`
+		AssigneeID  string `json:"assignee_id,omitempty"`
+		DueDate     string `json:"due_date,omitempty"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Title == "" {
+		respondWithError(w, http.StatusBadRequest, "Task title is required")
+		return
+	}
+
+	// Verify user has access to this team; the role decides the permissions
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	subject := authz.NewSubject(claims, role)
+
+	var assigneeID *string
+	if req.AssigneeID != "" {
+		assigneeID = &req.AssigneeID
+	}
+
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		parsed, err := time.Parse(time.RFC3339, req.DueDate)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid due_date, expected RFC 3339 timestamp")
+			return
+		}
+		dueDate = &parsed
+	}
+	
+	task, err := app.createTask(r.Context(), teamID, claims.UserID, req.Title, req.Description, req.Priority, assigneeID, dueDate)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create task")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create task")
+		return
+	}
+
+	// The task map is also the event payload, so decorate a copy
+	response := maps.Clone(task)
+	response["permissions"] = authz.Task(subject, claims.UserID)
+	if assigneeID != nil {
+		if warning := app.outOfOfficeWarning(r.Context(), *assigneeID); warning != nil {
+			response["warnings"] = []map[string]interface{}{warning}
+		}
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// createTask stores a new task, starts its history and publishes
+// task.created.
+func (app *Application) createTask(ctx context.Context, teamID, createdBy, title, description, priority string, assigneeID *string, dueDate *time.Time) (map[string]interface{}, error) {
+	stored := domain.Task{
+		ID:          uuid.New().String(),
+		TeamID:      teamID,
+		Title:       title,
+		Description: description,
+		Status:      domain.TaskStatusTodo,
+		Priority:    domain.Priority(priority),
+		AssigneeID:  assigneeID,
+		DueDate:     dueDate,
+		CreatedBy:   createdBy,
+	}
+	err := app.Tasks.Create(ctx, &stored, domain.TaskEvent{
+		Type:    domain.TaskEventCreated,
+		ActorID: createdBy,
+		Changes: diffTaskFields(nil, fieldsOfTask(stored)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task := taskMap(stored)
+	app.Events.Publish(ctx, events.Event{
+		Type:    events.TaskCreated,
+		TeamID:  teamID,
+		ActorID: createdBy,
+		Data:    task,
+	})
+
+	return task, nil
+}
+
+// taskMap is a task as the API shows it, and task events carry it.
+func taskMap(task domain.Task) map[string]interface{} {
+	payload := map[string]interface{}{
+		"id":          task.ID,
+		"team_id":     task.TeamID,
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      string(task.Status),
+		"priority":    string(task.Priority),
+		"created_by":  task.CreatedBy,
+		"created_at":  task.CreatedAt,
+		"updated_at":  task.UpdatedAt,
+	}
+	if task.AssigneeID != nil {
+		payload["assignee_id"] = *task.AssigneeID
+	}
+	if task.DueDate != nil {
+		payload["due_date"] = *task.DueDate
+	}
+	return payload
+}
+
+func (app *Application) getTasksHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	// Verify user has access to this team; the role decides the permissions
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	subject := authz.NewSubject(claims, role)
+
+	rows, err := app.Tasks.ForTeam(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get team tasks")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Ensure we always return an array, even if empty
+	tasks := []map[string]interface{}{}
+	for _, row := range rows {
+		task := taskMap(row)
+		task["permissions"] = authz.Task(subject, row.CreatedBy)
+		tasks = append(tasks, task)
+	}
+
+	respondWithJSON(w, http.StatusOK, tasks)
+}
+
+// getTaskHandler returns a task in one of the caller's teams, as the task
+// list shows it.
+func (app *Application) getTaskHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	stored, err := app.Tasks.Get(r.Context(), taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	role, err := app.getTeamRole(stored.TeamID, claims.UserID)
+	if err != nil {
+		// Tasks of other teams don't exist as far as the caller knows
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	task := taskMap(stored)
+	task["permissions"] = authz.Task(authz.NewSubject(claims, role), stored.CreatedBy)
+
+	respondWithJSON(w, http.StatusOK, task)
+}
+
+// taskUpdate carries a partial task update; nil fields are left unchanged.
+type taskUpdate struct {
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Status      *string    `json:"status"`
+	Priority    *string    `json:"priority"`
+	AssigneeID  *string    `json:"assignee_id"`
+	DueDate     *time.Time `json:"due_date"`
+
+	// clearDueDate removes the due date; only undo needs it
+	clearDueDate bool
+}
+
+func (app *Application) updateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID := vars["taskId"]
+
+	var req taskUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Title != nil && *req.Title == "" {
+		respondWithError(w, http.StatusBadRequest, "Task title cannot be empty")
+		return
+	}
+
+	if req.Status != nil && !isValidTaskStatus(*req.Status) {
+		respondWithError(w, http.StatusBadRequest, "Invalid task status")
+		return
+	}
+
+	if req.Priority != nil && !isValidPriority(*req.Priority) {
+		respondWithError(w, http.StatusBadRequest, "Invalid task priority")
+		return
+	}
+
+	stored, err := app.Tasks.Get(r.Context(), taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	teamID := stored.TeamID
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	task, err := app.updateTask(r.Context(), teamID, taskID, claims.UserID, req)
+	if err != nil {
+		if err == errNoTaskChanges {
+			respondWithError(w, http.StatusBadRequest, "No fields to update")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to update task")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update task")
+		return
+	}
+
+	// The task map is also the event payload, so decorate a copy
+	response := maps.Clone(task)
+	createdBy, _ := task["created_by"].(string)
+	response["permissions"] = authz.Task(authz.NewSubject(claims, role), createdBy)
+	if req.AssigneeID != nil && *req.AssigneeID != "" {
+		if warning := app.outOfOfficeWarning(r.Context(), *req.AssigneeID); warning != nil {
+			response["warnings"] = []map[string]interface{}{warning}
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+var errNoTaskChanges = errors.New("no task fields to update")
+
+// updateTask applies a validated partial update, records it in the task's
+// history and publishes task.updated. The event payload carries
+// previous_status and previous_assignee_id so subscribers can react to status
+// transitions and reassignments.
+func (app *Application) updateTask(ctx context.Context, teamID, taskID, actorID string, update taskUpdate) (map[string]interface{}, error) {
+	return app.applyTaskUpdate(ctx, teamID, taskID, actorID, update, nil)
+}
+
+// applyTaskUpdate is updateTask, or an undo when reverting is set: then the
+// update must put back what that event changed, and fails with
+// errTaskChangedSince if those fields have changed again since.
+func (app *Application) applyTaskUpdate(ctx context.Context, teamID, taskID, actorID string, update taskUpdate, reverting *domain.TaskEvent) (map[string]interface{}, error) {
+	stored := update.stored()
+	if stored.Empty() {
+		return nil, errNoTaskChanges
+	}
+
+	before, after, err := app.Tasks.Update(ctx, taskID, stored, func(before, after domain.Task) (*domain.TaskEvent, error) {
+		previous := fieldsOfTask(before)
+		if reverting != nil && !previous.matches(reverting.Changes) {
+			return nil, errTaskChangedSince
+		}
+
+		event := &domain.TaskEvent{
+			Type:    domain.TaskEventUpdated,
+			ActorID: actorID,
+			Changes: diffTaskFields(&previous, fieldsOfTask(after)),
+		}
+		if reverting != nil {
+			event.Type = domain.TaskEventReverted
+			event.RevertsEventID = &reverting.ID
+		}
+		// Setting fields to the values they already have isn't a change
+		// worth keeping, or undoing
+		if len(event.Changes) == 0 {
+			return nil, nil
+		}
+		return event, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task := taskMap(after)
+	task["previous_status"] = string(before.Status)
+	if before.AssigneeID != nil {
+		task["previous_assignee_id"] = *before.AssigneeID
+	}
+
+	app.Events.Publish(ctx, events.Event{
+		Type:    events.TaskUpdated,
+		TeamID:  teamID,
+		ActorID: actorID,
+		Data:    task,
+	})
+
+	return task, nil
+}
+
+// stored is the update as the task repository takes it.
+func (u taskUpdate) stored() repository.TaskUpdate {
+	return repository.TaskUpdate{
+		Title:        u.Title,
+		Description:  u.Description,
+		Status:       u.Status,
+		Priority:     u.Priority,
+		AssigneeID:   u.AssigneeID,
+		DueDate:      u.DueDate,
+		ClearDueDate: u.clearDueDate,
+	}
+}
+
+func isValidTaskStatus(status string) bool {
+	switch domain.TaskStatus(status) {
+	case domain.TaskStatusTodo, domain.TaskStatusInProgress, domain.TaskStatusReview,
+		domain.TaskStatusDone, domain.TaskStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func isValidPriority(priority string) bool {
+	switch domain.Priority(priority) {
+	case domain.PriorityLow, domain.PriorityMedium, domain.PriorityHigh, domain.PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+func (app *Application) websocketHandler(w http.ResponseWriter, r *http.Request) {
+	// Try to get token from query params or headers
+	var userID, teamID string = "anonymous", ""
+	
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		// Try Authorization header
+		authHeader := r.Header.Get("Authorization")
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			token = strings.TrimPrefix(authHeader, "Bearer ")
+		}
+	}
+	
+	if token != "" {
+		// Validate token and get user info
+		// App tokens are scoped to REST routes and can't open a socket
+		if claims, err := app.AuthMiddleware.ValidateToken(token); err == nil && claims.AppID == "" {
+			userID = claims.UserID
+			
+			// Get user's team (for now, just use first team they're a member of)
+			memberships, err := app.Teams.ForUser(r.Context(), claims.UserID)
+			if err == nil && len(memberships) > 0 {
+				teamID = memberships[0].Team.ID
+			}
+		}
+	}
+
+	// The connection's ID is its correlation ID for connect and disconnect
+	// logs; each frame the client sends gets its own
+	clientID := correlation.New()
+	conn, err := upgrader.Upgrade(w, r, http.Header{correlation.Header: []string{clientID}})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to upgrade connection")
+		return
+	}
+
+	client := &wsHandler.Client{
+		ID:      clientID,
+		UserID:  userID,
+		TeamID:  teamID,
+		Conn:    conn,
+		Hub:     app.WSHub,
+		Rooms:   make(map[string]bool),
+		Compact: compactRequested(r),
+	}
+
+	if userID != "" {
+		away, err := app.Users.OutOfOffice(r.Context(), []string{userID})
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to check out of office")
+		}
+		_, client.OutOfOffice = away[userID]
+	}
+
+	app.Logger.WithRequestID(clientID).Infof("WebSocket client connected: %s (User: %s, Team: %s)", clientID, userID, teamID)
+
+	app.WSHub.Register(client)
+
+	go client.WritePump()
+	go client.ReadPump()
+}
\ No newline at end of file
@@ -0,0 +1,394 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/hooks"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const hookSampleLimit = 3
+
+func (app *Application) subscribeHookHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	var req domain.CreateRestHook
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if !hooks.IsSupportedEvent(req.Event) {
+		respondWithError(w, http.StatusBadRequest, "Unsupported event")
+		return
+	}
+
+	if err := hooks.CheckTarget(r.Context(), req.TargetURL); err != nil {
+		if errors.Is(err, hooks.ErrPrivateTarget) {
+			respondWithError(w, http.StatusBadRequest, "target_url must be a public address")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "A valid http(s) target_url is required")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	hook := domain.RestHook{
+		ID:        uuid.New().String(),
+		TeamID:    teamID,
+		Event:     req.Event,
+		TargetURL: req.TargetURL,
+		CreatedBy: claims.UserID,
+		CreatedAt: time.Now(),
+	}
+
+	_, err := app.DB.Exec(`
+		INSERT INTO rest_hooks (id, team_id, event, target_url, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, hook.ID, hook.TeamID, hook.Event, hook.TargetURL, hook.CreatedBy, hook.CreatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create REST hook")
+		respondWithError(w, http.StatusInternalServerError, "Failed to subscribe hook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, hook)
+}
+
+func (app *Application) getHooksHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, team_id, event, target_url, created_by, created_at
+		FROM rest_hooks
+		WHERE team_id = $1
+		ORDER BY created_at
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get REST hooks")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	hookList := []domain.RestHook{}
+	for rows.Next() {
+		var hook domain.RestHook
+		if err := rows.Scan(&hook.ID, &hook.TeamID, &hook.Event, &hook.TargetURL, &hook.CreatedBy, &hook.CreatedAt); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan REST hook row")
+			continue
+		}
+		hookList = append(hookList, hook)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating REST hook rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, hookList)
+}
+
+func (app *Application) unsubscribeHookHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	hookID := vars["hookId"]
+
+	var teamID string
+	err := app.DB.QueryRow(`SELECT team_id FROM rest_hooks WHERE id = $1`, hookID).Scan(&teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Hook not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get REST hook")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM rest_hooks WHERE id = $1`, hookID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete REST hook")
+		respondWithError(w, http.StatusInternalServerError, "Failed to unsubscribe hook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Hook unsubscribed successfully"})
+}
+
+// hookSampleHandler returns recent items shaped exactly like the hook payload
+// for an event, which Zapier uses to populate field mapping while building a Zap.
+func (app *Application) hookSampleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+	event := vars["event"]
+
+	if !hooks.IsSupportedEvent(event) {
+		respondWithError(w, http.StatusNotFound, "Unsupported event")
+		return
+	}
+
+	if _, err := app.getTeamRole(teamID, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	var samples []map[string]interface{}
+	var err error
+
+	switch events.Type(event) {
+	case events.MessagePosted:
+		samples, err = app.sampleMessages(teamID)
+	case events.TaskCreated:
+		samples, err = app.sampleTasks(teamID, "created_at")
+	case events.TaskUpdated:
+		samples, err = app.sampleTasks(teamID, "updated_at")
+	case events.MemberJoined:
+		samples, err = app.sampleMembers(teamID)
+	}
+
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to load hook samples")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if len(samples) == 0 {
+		samples = []map[string]interface{}{staticHookSample(events.Type(event), teamID)}
+	}
+
+	respondWithJSON(w, http.StatusOK, samples)
+}
+
+func (app *Application) sampleMessages(teamID string) ([]map[string]interface{}, error) {
+	rows, err := app.DB.Query(`
+		SELECT m.id, m.channel_id, m.content, m.type, m.user_id, m.created_at, m.updated_at,
+		       u.username, u.first_name, u.last_name
+		FROM messages m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.team_id = $1 AND m.is_deleted = false
+		ORDER BY m.created_at DESC
+		LIMIT $2
+	`, teamID, hookSampleLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []map[string]interface{}
+	for rows.Next() {
+		var id, channelID, content, messageType, senderID, username, firstName, lastName string
+		var createdAt, updatedAt time.Time
+
+		if err := rows.Scan(&id, &channelID, &content, &messageType, &senderID, &createdAt, &updatedAt,
+			&username, &firstName, &lastName); err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, map[string]interface{}{
+			"id":         id,
+			"team_id":    teamID,
+			"channel_id": channelID,
+			"content":    content,
+			"type":       messageType,
+			"sender_id":  senderID,
+			"created_at": createdAt,
+			"updated_at": updatedAt,
+			"sender": map[string]interface{}{
+				"username":   username,
+				"first_name": firstName,
+				"last_name":  lastName,
+			},
+		})
+	}
+
+	return samples, rows.Err()
+}
+
+func (app *Application) sampleTasks(teamID, orderColumn string) ([]map[string]interface{}, error) {
+	// orderColumn is always one of two literals chosen by the caller
+	rows, err := app.DB.Query(`
+		SELECT id, title, description, status, priority,
+		       assignee_id, due_date, created_by, created_at, updated_at
+		FROM tasks
+		WHERE team_id = $1 AND deleted_at IS NULL
+		ORDER BY `+orderColumn+` DESC
+		LIMIT $2
+	`, teamID, hookSampleLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []map[string]interface{}
+	for rows.Next() {
+		var id, title, description, status, priority, createdBy string
+		var assigneeID *string
+		var dueDate *time.Time
+		var createdAt, updatedAt time.Time
+
+		if err := rows.Scan(&id, &title, &description, &status, &priority,
+			&assigneeID, &dueDate, &createdBy, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		task := map[string]interface{}{
+			"id":          id,
+			"team_id":     teamID,
+			"title":       title,
+			"description": description,
+			"status":      status,
+			"priority":    priority,
+			"created_by":  createdBy,
+			"created_at":  createdAt,
+			"updated_at":  updatedAt,
+		}
+
+		if assigneeID != nil {
+			task["assignee_id"] = *assigneeID
+		}
+
+		if dueDate != nil {
+			task["due_date"] = *dueDate
+		}
+
+		samples = append(samples, task)
+	}
+
+	return samples, rows.Err()
+}
+
+func (app *Application) sampleMembers(teamID string) ([]map[string]interface{}, error) {
+	rows, err := app.DB.Query(`
+		SELECT tm.user_id, tm.role, tm.joined_at, u.username, u.first_name, u.last_name
+		FROM team_members tm
+		JOIN users u ON tm.user_id = u.id
+		WHERE tm.team_id = $1
+		ORDER BY tm.joined_at DESC
+		LIMIT $2
+	`, teamID, hookSampleLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []map[string]interface{}
+	for rows.Next() {
+		var userID, role, username, firstName, lastName string
+		var joinedAt time.Time
+
+		if err := rows.Scan(&userID, &role, &joinedAt, &username, &firstName, &lastName); err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, map[string]interface{}{
+			"team_id":   teamID,
+			"user_id":   userID,
+			"role":      role,
+			"joined_at": joinedAt,
+			"user": map[string]interface{}{
+				"username":   username,
+				"first_name": firstName,
+				"last_name":  lastName,
+			},
+		})
+	}
+
+	return samples, rows.Err()
+}
+
+// staticHookSample is returned when a team has no data yet, so Zap setup still
+// has fields to map.
+func staticHookSample(event events.Type, teamID string) map[string]interface{} {
+	now := time.Now()
+	sender := map[string]interface{}{
+		"username":   "jdoe",
+		"first_name": "Jane",
+		"last_name":  "Doe",
+	}
+
+	switch event {
+	case events.MessagePosted:
+		return map[string]interface{}{
+			"id":         "00000000-0000-0000-0000-000000000001",
+			"team_id":    teamID,
+			"channel_id": "00000000-0000-0000-0000-000000000002",
+			"content":    "Hello from CBA!",
+			"type":       "text",
+			"sender_id":  "00000000-0000-0000-0000-000000000003",
+			"created_at": now,
+			"updated_at": now,
+			"sender":     sender,
+		}
+	case events.MemberJoined:
+		return map[string]interface{}{
+			"team_id":   teamID,
+			"user_id":   "00000000-0000-0000-0000-000000000003",
+			"role":      "member",
+			"joined_at": now,
+			"user":      sender,
+		}
+	default:
+		return map[string]interface{}{
+			"id":          "00000000-0000-0000-0000-000000000004",
+			"team_id":     teamID,
+			"title":       "Prepare weekly report",
+			"description": "Collect metrics and share with the team",
+			"status":      "todo",
+			"priority":    "medium",
+			"assignee_id": "00000000-0000-0000-0000-000000000003",
+			"created_by":  "00000000-0000-0000-0000-000000000003",
+			"created_at":  now,
+			"updated_at":  now,
+		}
+	}
+}
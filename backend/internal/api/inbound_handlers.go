@@ -0,0 +1,227 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/inbound"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/throttle"
+	"github.com/cbalite/backend/internal/token"
+)
+
+func (app *Application) createInboundWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateInboundWebhook
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Webhook name is required")
+		return
+	}
+	if !app.Inbound.Supports(req.Kind) {
+		respondWithError(w, http.StatusBadRequest, "Unsupported kind; expected one of: "+strings.Join(app.Inbound.Kinds(), ", "))
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	secret, tokenHash, err := token.Generate()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate inbound webhook token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create inbound webhook")
+		return
+	}
+
+	hook := domain.InboundWebhook{
+		ID:        uuid.New().String(),
+		TeamID:    teamID,
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Token:     secret,
+		CreatedBy: claims.UserID,
+		CreatedAt: time.Now(),
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO inbound_webhooks (id, team_id, name, kind, token_hash, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, hook.ID, hook.TeamID, hook.Name, hook.Kind, tokenHash, hook.CreatedBy, hook.CreatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create inbound webhook")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create inbound webhook")
+		return
+	}
+
+	// The token is only ever returned here; it's stored hashed
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"webhook": hook,
+		"url":     "/api/v1/inbound/" + secret,
+	})
+}
+
+func (app *Application) getInboundWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, team_id, name, kind, created_by, created_at, last_received_at
+		FROM inbound_webhooks
+		WHERE team_id = $1
+		ORDER BY created_at
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get inbound webhooks")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	hooks := []domain.InboundWebhook{}
+	for rows.Next() {
+		var hook domain.InboundWebhook
+		err := rows.Scan(&hook.ID, &hook.TeamID, &hook.Name, &hook.Kind, &hook.CreatedBy, &hook.CreatedAt, &hook.LastReceivedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan inbound webhook row")
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating inbound webhook rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, hooks)
+}
+
+func (app *Application) deleteInboundWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	hookID := mux.Vars(r)["webhookId"]
+
+	var teamID string
+	err := app.DB.QueryRow(`SELECT team_id FROM inbound_webhooks WHERE id = $1`, hookID).Scan(&teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Inbound webhook not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get inbound webhook")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM inbound_webhooks WHERE id = $1`, hookID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete inbound webhook")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete inbound webhook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Inbound webhook deleted successfully"})
+}
+
+// receiveInboundWebhookHandler accepts deliveries from external services. It
+// is unauthenticated; the secret token in the URL identifies the webhook.
+func (app *Application) receiveInboundWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	tokenHash := token.Hash(mux.Vars(r)["token"])
+
+	var hook domain.InboundWebhook
+	err := app.DB.QueryRow(`
+		SELECT id, team_id, name, kind, created_by, created_at, last_received_at
+		FROM inbound_webhooks
+		WHERE token_hash = $1
+	`, tokenHash).Scan(&hook.ID, &hook.TeamID, &hook.Name, &hook.Kind, &hook.CreatedBy, &hook.CreatedAt, &hook.LastReceivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Unknown webhook")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get inbound webhook")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, inbound.MaxBodyBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	if len(body) > inbound.MaxBodyBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+
+	// Messages the delivery posts count against the webhook's rate limits;
+	// while it is suspended, deliveries are refused outright.
+	integration := throttle.Integration{Kind: domain.IntegrationInboundWebhook, ID: hook.ID}
+	suspended, err := app.integrationSuspension(r.Context(), integration, hook.TeamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check integration suspension")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if suspended != nil {
+		respondThrottled(w, *suspended)
+		return
+	}
+
+	if err := app.Inbound.Dispatch(withIntegration(r.Context(), integration), hook, body); err != nil {
+		switch {
+		case errors.Is(err, inbound.ErrInvalidPayload):
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, inbound.ErrUnknownKind):
+			respondWithError(w, http.StatusUnprocessableEntity, "Webhook kind is no longer supported")
+		default:
+			app.Logger.WithError(err).Errorf("Failed to process inbound webhook %s", hook.ID)
+			respondWithError(w, http.StatusInternalServerError, "Failed to process webhook")
+		}
+		return
+	}
+
+	if _, err := app.DB.Exec(`UPDATE inbound_webhooks SET last_received_at = NOW() WHERE id = $1`, hook.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to record inbound webhook delivery")
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Webhook received"})
+}
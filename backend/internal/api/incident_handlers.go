@@ -0,0 +1,881 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/automation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/incident"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const incidentsLimit = 100
+
+// createIncidentHandler declares an incident: it opens a dedicated channel,
+// posts and pins a status message, and starts the incident's history.
+func (app *Application) createIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateIncident
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	if req.TeamID == "" || req.Title == "" {
+		respondWithError(w, http.StatusBadRequest, "team_id and title are required")
+		return
+	}
+	if req.Severity != nil && !incident.IsValidSeverity(*req.Severity) {
+		respondWithError(w, http.StatusBadRequest, "Invalid severity")
+		return
+	}
+
+	if !app.requireTeamMember(w, req.TeamID, claims.UserID) {
+		return
+	}
+
+	template := domain.IncidentTemplate{
+		ChannelPrefix:   incident.DefaultChannelPrefix,
+		StatusTemplate:  incident.DefaultStatusTemplate,
+		DefaultSeverity: domain.SeveritySev3,
+	}
+	if req.TemplateID != nil {
+		loaded, err := app.loadIncidentTemplate(r.Context(), *req.TemplateID)
+		if err != nil || loaded.TeamID != req.TeamID {
+			if err != nil && err != sql.ErrNoRows {
+				app.Logger.WithError(err).Error("Failed to get incident template")
+				respondWithError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			respondWithError(w, http.StatusBadRequest, "Incident template not found")
+			return
+		}
+		template = loaded
+	}
+
+	record := domain.Incident{
+		ID:         uuid.New().String(),
+		TeamID:     req.TeamID,
+		Title:      req.Title,
+		Severity:   template.DefaultSeverity,
+		Status:     domain.IncidentInvestigating,
+		TemplateID: req.TemplateID,
+		CreatedBy:  claims.UserID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if req.Severity != nil {
+		record.Severity = *req.Severity
+	}
+
+	tx, err := app.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to declare incident")
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the team so concurrent declarations get consecutive numbers
+	err = tx.QueryRowContext(r.Context(), `
+		SELECT (SELECT COALESCE(MAX(number), 0) + 1 FROM incidents WHERE team_id = t.id)
+		FROM teams t WHERE t.id = $1
+		FOR UPDATE
+	`, record.TeamID).Scan(&record.Number)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to number incident")
+		respondWithError(w, http.StatusInternalServerError, "Failed to declare incident")
+		return
+	}
+
+	channelID := uuid.New().String()
+	record.ChannelID = &channelID
+
+	_, err = tx.ExecContext(r.Context(), `
+		INSERT INTO channels (id, team_id, name, description, type, is_private, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'custom', false, $5, NOW(), NOW())
+	`, channelID, record.TeamID, incident.ChannelName(template.ChannelPrefix, record.Number, record.Title),
+		template.ChannelDescription, claims.UserID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A channel with this incident's name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create incident channel")
+		respondWithError(w, http.StatusInternalServerError, "Failed to declare incident")
+		return
+	}
+
+	_, err = tx.ExecContext(r.Context(), `
+		INSERT INTO channel_members (channel_id, user_id, joined_at) VALUES ($1, $2, NOW())
+	`, channelID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to join incident channel")
+		respondWithError(w, http.StatusInternalServerError, "Failed to declare incident")
+		return
+	}
+
+	_, err = tx.ExecContext(r.Context(), `
+		INSERT INTO incidents (id, team_id, number, title, severity, status, channel_id, template_id, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, record.ID, record.TeamID, record.Number, record.Title, record.Severity, record.Status, channelID,
+		record.TemplateID, record.CreatedBy, record.CreatedAt, record.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create incident")
+		respondWithError(w, http.StatusInternalServerError, "Failed to declare incident")
+		return
+	}
+
+	err = recordIncidentEvent(r.Context(), tx, record.ID, incident.EventDeclared, "", string(record.Severity), "", claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to record incident event")
+		respondWithError(w, http.StatusInternalServerError, "Failed to declare incident")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit incident")
+		respondWithError(w, http.StatusInternalServerError, "Failed to declare incident")
+		return
+	}
+
+	content := app.renderIncidentStatus(r.Context(), record, template.StatusTemplate)
+	message, err := app.createMessage(r.Context(), record.TeamID, channelID, claims.UserID, content, string(domain.MessageTypeSystem))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to post incident status message")
+	} else {
+		messageID := message["id"].(string)
+		_, err = app.DB.ExecContext(r.Context(), `
+			WITH pinned AS (UPDATE messages SET is_pinned = true WHERE id = $2)
+			UPDATE incidents SET status_message_id = $2 WHERE id = $1
+		`, record.ID, messageID)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to pin incident status message")
+		} else {
+			record.StatusMessageID = &messageID
+		}
+	}
+
+	respondWithJSON(w, http.StatusCreated, record)
+}
+
+func (app *Application) getIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !incident.IsValidStatus(domain.IncidentStatus(status)) {
+		respondWithError(w, http.StatusBadRequest, "Invalid incident status")
+		return
+	}
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT `+incident.Columns+`
+		FROM incidents
+		WHERE team_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY number DESC
+		LIMIT $3
+	`, teamID, status, incidentsLimit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get incidents")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	incidents := []domain.Incident{}
+	for rows.Next() {
+		record, err := incident.Scan(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan incident row")
+			continue
+		}
+		incidents = append(incidents, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating incident rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, incidents)
+}
+
+func (app *Application) getIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	record, ok := app.loadIncident(w, r.Context(), mux.Vars(r)["incidentId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, record.TeamID, claims.UserID) {
+		return
+	}
+
+	detail := domain.IncidentDetail{Incident: record}
+
+	var err error
+	if detail.Events, err = app.loadIncidentEvents(r.Context(), record.ID); err == nil {
+		detail.Tasks, err = app.loadIncidentTasks(r.Context(), record.ID)
+	}
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get incident details")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, detail)
+}
+
+// updateIncidentHandler changes an incident's title, severity or status.
+// Every change is recorded, announced in the incident channel and reflected
+// in the pinned status message; resolving generates the timeline export.
+func (app *Application) updateIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateIncident
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Severity != nil && !incident.IsValidSeverity(*req.Severity) {
+		respondWithError(w, http.StatusBadRequest, "Invalid severity")
+		return
+	}
+	if req.Status != nil && !incident.IsValidStatus(*req.Status) {
+		respondWithError(w, http.StatusBadRequest, "Invalid incident status")
+		return
+	}
+	if req.Title != nil {
+		*req.Title = strings.TrimSpace(*req.Title)
+		if *req.Title == "" {
+			respondWithError(w, http.StatusBadRequest, "Title cannot be empty")
+			return
+		}
+	}
+
+	record, ok := app.loadIncident(w, r.Context(), mux.Vars(r)["incidentId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, record.TeamID, claims.UserID) {
+		return
+	}
+
+	previous := record
+	var announcements []string
+
+	tx, err := app.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update incident")
+		return
+	}
+	defer tx.Rollback()
+
+	record, err = incident.Scan(tx.QueryRowContext(r.Context(), `
+		UPDATE incidents
+		SET title = COALESCE($2, title),
+		    severity = COALESCE($3, severity),
+		    status = COALESCE($4, status),
+		    resolved_at = CASE
+		        WHEN $4 = 'resolved' AND status <> 'resolved' THEN NOW()
+		        WHEN $4 IS NOT NULL AND $4 <> 'resolved' THEN NULL
+		        ELSE resolved_at
+		    END
+		WHERE id = $1
+		RETURNING `+incident.Columns+`
+	`, record.ID, req.Title, req.Severity, req.Status))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update incident")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update incident")
+		return
+	}
+
+	changes := []struct {
+		eventType, from, to, announcement string
+	}{
+		{incident.EventTitleChanged, previous.Title, record.Title,
+			fmt.Sprintf("Title changed to %q", record.Title)},
+		{incident.EventSeverityChanged, string(previous.Severity), string(record.Severity),
+			fmt.Sprintf("Severity changed from %s to %s", previous.Severity, record.Severity)},
+		{incident.EventStatusChanged, string(previous.Status), string(record.Status),
+			fmt.Sprintf("Status changed from %s to %s", previous.Status, record.Status)},
+	}
+	for _, change := range changes {
+		if change.from == change.to {
+			continue
+		}
+
+		err := recordIncidentEvent(r.Context(), tx, record.ID, change.eventType, change.from, change.to, req.Note, claims.UserID)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to record incident event")
+			respondWithError(w, http.StatusInternalServerError, "Failed to update incident")
+			return
+		}
+		announcements = append(announcements, change.announcement)
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit incident update")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update incident")
+		return
+	}
+
+	if len(announcements) > 0 && record.ChannelID != nil {
+		content := strings.Join(announcements, "\n")
+		if req.Note != "" {
+			content += "\n" + req.Note
+		}
+		if _, err := app.createMessage(r.Context(), record.TeamID, *record.ChannelID, claims.UserID, content, string(domain.MessageTypeSystem)); err != nil {
+			app.Logger.WithError(err).Error("Failed to announce incident update")
+		}
+		app.refreshIncidentStatus(r.Context(), record)
+	}
+
+	if record.Status == domain.IncidentResolved && previous.Status != domain.IncidentResolved {
+		if _, err := app.saveIncidentTimeline(r.Context(), record); err != nil {
+			app.Logger.WithError(err).Error("Failed to generate incident timeline")
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, record)
+}
+
+func (app *Application) linkIncidentTaskHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.LinkIncidentTask
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TaskID == "" {
+		respondWithError(w, http.StatusBadRequest, "task_id is required")
+		return
+	}
+
+	record, ok := app.loadIncident(w, r.Context(), mux.Vars(r)["incidentId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, record.TeamID, claims.UserID) {
+		return
+	}
+
+	var title string
+	err := app.DB.QueryRow(`
+		SELECT title FROM tasks WHERE id::text = $1 AND team_id = $2 AND deleted_at IS NULL
+	`, req.TaskID, record.TeamID).Scan(&title)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "Task not found in this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	result, err := app.DB.Exec(`
+		INSERT INTO incident_tasks (incident_id, task_id, linked_by, linked_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT DO NOTHING
+	`, record.ID, req.TaskID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to link incident task")
+		respondWithError(w, http.StatusInternalServerError, "Failed to link task")
+		return
+	}
+
+	if linked, _ := result.RowsAffected(); linked > 0 {
+		err := recordIncidentEvent(r.Context(), app.DB, record.ID, incident.EventTaskLinked, "", title, "", claims.UserID)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to record incident event")
+		}
+		if record.ChannelID != nil {
+			content := fmt.Sprintf("Linked task: %s", title)
+			if _, err := app.createMessage(r.Context(), record.TeamID, *record.ChannelID, claims.UserID, content, string(domain.MessageTypeSystem)); err != nil {
+				app.Logger.WithError(err).Error("Failed to announce incident task")
+			}
+		}
+	}
+
+	tasks, err := app.loadIncidentTasks(r.Context(), record.ID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get incident tasks")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tasks)
+}
+
+// getIncidentTimelineHandler returns the timeline document attached on
+// resolution, or a live preview while the incident is still open.
+// ?format=markdown returns the rendered document instead of JSON.
+func (app *Application) getIncidentTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	record, ok := app.loadIncident(w, r.Context(), mux.Vars(r)["incidentId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, record.TeamID, claims.UserID) {
+		return
+	}
+
+	document, err := app.loadIncidentDocument(r.Context(), record.ID, incident.DocumentTimeline)
+	if err == sql.ErrNoRows {
+		var timeline incident.Timeline
+		timeline, err = app.buildIncidentTimeline(r.Context(), record)
+		document = domain.IncidentDocument{
+			IncidentID: record.ID,
+			Kind:       incident.DocumentTimeline,
+			Content:    timeline.Markdown(),
+			Data:       timeline,
+			CreatedAt:  time.Now(),
+		}
+	}
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get incident timeline")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if r.URL.Query().Get("format") == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="incident-%d-timeline.md"`, record.Number))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(document.Content))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, document)
+}
+
+func (app *Application) createIncidentTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateIncidentTemplate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	template := domain.IncidentTemplate{
+		ID:                 uuid.New().String(),
+		TeamID:             teamID,
+		Name:               strings.TrimSpace(req.Name),
+		ChannelPrefix:      strings.Trim(strings.ToLower(strings.TrimSpace(req.ChannelPrefix)), "-"),
+		ChannelDescription: req.ChannelDescription,
+		StatusTemplate:     req.StatusTemplate,
+		DefaultSeverity:    domain.SeveritySev3,
+		CreatedBy:          claims.UserID,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if template.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Template name is required")
+		return
+	}
+	if template.ChannelPrefix == "" {
+		template.ChannelPrefix = incident.DefaultChannelPrefix
+	}
+	if len(template.ChannelPrefix) > 20 {
+		respondWithError(w, http.StatusBadRequest, "channel_prefix must be at most 20 characters")
+		return
+	}
+	if strings.TrimSpace(template.StatusTemplate) == "" {
+		template.StatusTemplate = incident.DefaultStatusTemplate
+	}
+	if req.DefaultSeverity != nil {
+		if !incident.IsValidSeverity(*req.DefaultSeverity) {
+			respondWithError(w, http.StatusBadRequest, "Invalid default_severity")
+			return
+		}
+		template.DefaultSeverity = *req.DefaultSeverity
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	_, err := app.DB.Exec(`
+		INSERT INTO incident_templates (id, team_id, name, channel_prefix, channel_description, status_template,
+		                                default_severity, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, template.ID, template.TeamID, template.Name, template.ChannelPrefix, template.ChannelDescription,
+		template.StatusTemplate, template.DefaultSeverity, template.CreatedBy, template.CreatedAt, template.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A template with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create incident template")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create incident template")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, template)
+}
+
+func (app *Application) getIncidentTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, team_id, name, channel_prefix, COALESCE(channel_description, ''), status_template,
+		       default_severity, created_by, created_at, updated_at
+		FROM incident_templates
+		WHERE team_id = $1
+		ORDER BY name
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get incident templates")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	templates := []domain.IncidentTemplate{}
+	for rows.Next() {
+		template, err := scanIncidentTemplate(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan incident template row")
+			continue
+		}
+		templates = append(templates, template)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating incident template rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, templates)
+}
+
+func (app *Application) deleteIncidentTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	template, err := app.loadIncidentTemplate(r.Context(), mux.Vars(r)["templateId"])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Incident template not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get incident template")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, template.TeamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM incident_templates WHERE id = $1`, template.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete incident template")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete incident template")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Incident template deleted successfully"})
+}
+
+func (app *Application) loadIncident(w http.ResponseWriter, ctx context.Context, incidentID string) (domain.Incident, bool) {
+	record, err := incident.Scan(app.DB.QueryRowContext(ctx, `
+		SELECT `+incident.Columns+` FROM incidents WHERE id = $1
+	`, incidentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Incident not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get incident")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return record, false
+	}
+
+	return record, true
+}
+
+func (app *Application) loadIncidentTemplate(ctx context.Context, templateID string) (domain.IncidentTemplate, error) {
+	return scanIncidentTemplate(app.DB.QueryRowContext(ctx, `
+		SELECT id, team_id, name, channel_prefix, COALESCE(channel_description, ''), status_template,
+		       default_severity, created_by, created_at, updated_at
+		FROM incident_templates
+		WHERE id::text = $1
+	`, templateID))
+}
+
+func scanIncidentTemplate(row rowScanner) (domain.IncidentTemplate, error) {
+	var t domain.IncidentTemplate
+	err := row.Scan(&t.ID, &t.TeamID, &t.Name, &t.ChannelPrefix, &t.ChannelDescription, &t.StatusTemplate,
+		&t.DefaultSeverity, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+func (app *Application) loadIncidentEvents(ctx context.Context, incidentID string) ([]domain.IncidentEvent, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT id, incident_id, type, COALESCE(from_value, ''), COALESCE(to_value, ''), COALESCE(note, ''),
+		       actor_id, created_at
+		FROM incident_events
+		WHERE incident_id = $1
+		ORDER BY created_at
+	`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.IncidentEvent{}
+	for rows.Next() {
+		var e domain.IncidentEvent
+		if err := rows.Scan(&e.ID, &e.IncidentID, &e.Type, &e.FromValue, &e.ToValue, &e.Note, &e.ActorID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+func (app *Application) loadIncidentTasks(ctx context.Context, incidentID string) ([]domain.IncidentTask, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT t.id, t.title, t.status, it.linked_by, it.linked_at
+		FROM incident_tasks it
+		JOIN tasks t ON t.id = it.task_id
+		WHERE it.incident_id = $1 AND t.deleted_at IS NULL
+		ORDER BY it.linked_at
+	`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []domain.IncidentTask{}
+	for rows.Next() {
+		var t domain.IncidentTask
+		if err := rows.Scan(&t.TaskID, &t.Title, &t.Status, &t.LinkedBy, &t.LinkedAt); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+func (app *Application) loadIncidentDocument(ctx context.Context, incidentID, kind string) (domain.IncidentDocument, error) {
+	var document domain.IncidentDocument
+	var data []byte
+
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT id, incident_id, kind, content, data, created_at
+		FROM incident_documents
+		WHERE incident_id = $1 AND kind = $2
+	`, incidentID, kind).Scan(&document.ID, &document.IncidentID, &document.Kind, &document.Content, &data, &document.CreatedAt)
+	if err != nil {
+		return document, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return document, err
+	}
+	document.Data = decoded
+
+	return document, nil
+}
+
+// buildIncidentTimeline collects the incident's history, linked tasks and
+// every message posted in its channel.
+func (app *Application) buildIncidentTimeline(ctx context.Context, record domain.Incident) (incident.Timeline, error) {
+	events, err := app.loadIncidentEvents(ctx, record.ID)
+	if err != nil {
+		return incident.Timeline{}, err
+	}
+
+	tasks, err := app.loadIncidentTasks(ctx, record.ID)
+	if err != nil {
+		return incident.Timeline{}, err
+	}
+
+	var messages []incident.ChannelMessage
+	if record.ChannelID != nil {
+		rows, err := app.DB.QueryContext(ctx, `
+			SELECT u.username, m.content, m.created_at
+			FROM messages m
+			JOIN users u ON u.id = m.user_id
+			WHERE m.channel_id = $1 AND m.is_deleted = false AND m.type <> 'system'
+			ORDER BY m.created_at
+		`, *record.ChannelID)
+		if err != nil {
+			return incident.Timeline{}, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var message incident.ChannelMessage
+			if err := rows.Scan(&message.Author, &message.Content, &message.CreatedAt); err != nil {
+				return incident.Timeline{}, err
+			}
+			messages = append(messages, message)
+		}
+		if err := rows.Err(); err != nil {
+			return incident.Timeline{}, err
+		}
+	}
+
+	actors := make(map[string]string)
+	for _, event := range events {
+		if event.ActorID == nil || actors[*event.ActorID] != "" {
+			continue
+		}
+		var username string
+		err := app.DB.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1`, *event.ActorID).Scan(&username)
+		if err != nil && err != sql.ErrNoRows {
+			return incident.Timeline{}, err
+		}
+		actors[*event.ActorID] = username
+	}
+
+	return incident.BuildTimeline(record, events, messages, tasks, actors), nil
+}
+
+// saveIncidentTimeline generates the timeline export and attaches it to the
+// incident, replacing the export from any earlier resolution.
+func (app *Application) saveIncidentTimeline(ctx context.Context, record domain.Incident) (domain.IncidentDocument, error) {
+	timeline, err := app.buildIncidentTimeline(ctx, record)
+	if err != nil {
+		return domain.IncidentDocument{}, err
+	}
+
+	data, err := json.Marshal(timeline)
+	if err != nil {
+		return domain.IncidentDocument{}, err
+	}
+
+	document := domain.IncidentDocument{
+		ID:         uuid.New().String(),
+		IncidentID: record.ID,
+		Kind:       incident.DocumentTimeline,
+		Content:    timeline.Markdown(),
+		Data:       timeline,
+		CreatedAt:  time.Now(),
+	}
+
+	_, err = app.DB.ExecContext(ctx, `
+		INSERT INTO incident_documents (id, incident_id, kind, content, data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (incident_id, kind) DO UPDATE
+		SET content = EXCLUDED.content, data = EXCLUDED.data, created_at = EXCLUDED.created_at
+	`, document.ID, document.IncidentID, document.Kind, document.Content, data, document.CreatedAt)
+
+	return document, err
+}
+
+func (app *Application) renderIncidentStatus(ctx context.Context, record domain.Incident, statusTemplate string) string {
+	var declaredBy string
+	if err := app.DB.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1`, record.CreatedBy).Scan(&declaredBy); err != nil {
+		app.Logger.WithError(err).Error("Failed to get incident declarer")
+	}
+
+	return automation.Render(statusTemplate, incident.TemplateData(record, declaredBy))
+}
+
+// refreshIncidentStatus rewrites the pinned status message to match the
+// incident's current state.
+func (app *Application) refreshIncidentStatus(ctx context.Context, record domain.Incident) {
+	if record.StatusMessageID == nil {
+		return
+	}
+
+	statusTemplate := incident.DefaultStatusTemplate
+	if record.TemplateID != nil {
+		if template, err := app.loadIncidentTemplate(ctx, *record.TemplateID); err == nil {
+			statusTemplate = template.StatusTemplate
+		}
+	}
+
+	_, err := app.DB.ExecContext(ctx, `
+		UPDATE messages SET content = $2, is_edited = true, updated_at = NOW() WHERE id = $1
+	`, *record.StatusMessageID, app.renderIncidentStatus(ctx, record, statusTemplate))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update incident status message")
+	}
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func recordIncidentEvent(ctx context.Context, db execer, incidentID, eventType, from, to, note, actorID string) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO incident_events (id, incident_id, type, from_value, to_value, note, actor_id, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, NOW())
+	`, uuid.New().String(), incidentID, eventType, from, to, note, actorID)
+	return err
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/throttle"
+)
+
+// integrationDeliveryRetention is how long refused deliveries stay in the
+// log.
+const integrationDeliveryRetention = 7 * 24 * time.Hour
+
+type integrationContextKey struct{}
+
+// withIntegration marks messages posted with ctx as coming from an
+// integration, so postMessage holds them to the integration rate limits.
+func withIntegration(ctx context.Context, integration throttle.Integration) context.Context {
+	return context.WithValue(ctx, integrationContextKey{}, integration)
+}
+
+// withoutIntegration drops the mark again, for work that outlives the
+// integration's own message such as event subscribers.
+func withoutIntegration(ctx context.Context) context.Context {
+	return context.WithValue(ctx, integrationContextKey{}, nil)
+}
+
+func integrationFromContext(ctx context.Context) (throttle.Integration, bool) {
+	integration, ok := ctx.Value(integrationContextKey{}).(throttle.Integration)
+	return integration, ok
+}
+
+// integrationThrottledError is returned by postMessage when an integration
+// is over its rate limit or suspended.
+type integrationThrottledError struct {
+	throttle domain.IntegrationThrottle
+}
+
+func (e *integrationThrottledError) Error() string {
+	return "integration throttled: " + e.throttle.Code
+}
+
+func respondThrottled(w http.ResponseWriter, throttle domain.IntegrationThrottle) {
+	message := "Integration rate limit exceeded"
+	if throttle.Code == domain.ThrottleSuspended {
+		message = "Integration is suspended for exceeding its rate limit"
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(throttle.RetryAfterSeconds))
+	respondWithJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+		"error":    message,
+		"throttle": throttle,
+	})
+}
+
+// admitIntegrationMessage decides whether an integration may post in the
+// channel. Rate-limited messages are written to the delivery log, and an
+// integration throttled too often is suspended; messages refused while it
+// is suspended aren't logged one by one.
+func (app *Application) admitIntegrationMessage(ctx context.Context, integration throttle.Integration, teamID, channelID string) error {
+	suspended, err := app.integrationSuspension(ctx, integration, teamID)
+	if err != nil {
+		return err
+	}
+	if suspended != nil {
+		return &integrationThrottledError{throttle: *suspended}
+	}
+
+	limited, abusive := app.Throttle.Allow(ctx, integration, teamID, channelID)
+	if limited == nil {
+		return nil
+	}
+
+	if abusive {
+		until := time.Now().Add(app.Config.RateLimit.IntegrationSuspendFor)
+		if err := app.suspendIntegration(ctx, integration, teamID, until, *limited); err != nil {
+			app.Logger.WithError(err).Error("Failed to suspend integration")
+		} else {
+			limited.SuspendedUntil = &until
+		}
+	}
+
+	app.logIntegrationDelivery(ctx, integration, teamID, channelID, *limited)
+	return &integrationThrottledError{throttle: *limited}
+}
+
+// integrationSuspension returns the throttle error for an integration that
+// is suspended in the team, or nil.
+func (app *Application) integrationSuspension(ctx context.Context, integration throttle.Integration, teamID string) (*domain.IntegrationThrottle, error) {
+	var until time.Time
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT suspended_until FROM integration_suspensions
+		WHERE team_id = $1 AND integration_kind = $2 AND integration_id = $3 AND suspended_until > NOW()
+	`, teamID, integration.Kind, integration.ID).Scan(&until)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.IntegrationThrottle{
+		Code:              domain.ThrottleSuspended,
+		RetryAfterSeconds: int(time.Until(until).Seconds()) + 1,
+		SuspendedUntil:    &until,
+	}, nil
+}
+
+func (app *Application) suspendIntegration(ctx context.Context, integration throttle.Integration, teamID string, until time.Time, limited domain.IntegrationThrottle) error {
+	reason := fmt.Sprintf("Throttled %d times within %s; last over the %s limit of %d messages per minute",
+		app.Config.RateLimit.IntegrationSuspendAfter, app.Config.RateLimit.IntegrationSuspendWindow, limited.Scope, limited.Limit)
+
+	_, err := app.DB.ExecContext(ctx, `
+		INSERT INTO integration_suspensions (team_id, integration_kind, integration_id, reason, suspended_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (team_id, integration_kind, integration_id)
+		DO UPDATE SET reason = EXCLUDED.reason, suspended_until = EXCLUDED.suspended_until, created_at = NOW()
+	`, teamID, integration.Kind, integration.ID, reason, until)
+	if err != nil {
+		return err
+	}
+
+	app.Throttle.Forgive(ctx, integration, teamID)
+	app.Logger.Warnf("Suspended %s in team %s until %s: %s", integration, teamID, until.Format(time.RFC3339), reason)
+	return nil
+}
+
+func (app *Application) logIntegrationDelivery(ctx context.Context, integration throttle.Integration, teamID, channelID string, limited domain.IntegrationThrottle) {
+	details, err := json.Marshal(limited)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to encode throttle error")
+		return
+	}
+
+	var channel *string
+	if channelID != "" {
+		channel = &channelID
+	}
+
+	_, err = app.DB.ExecContext(ctx, `
+		INSERT INTO integration_deliveries (team_id, integration_kind, integration_id, channel_id, error)
+		VALUES ($1, $2, $3, $4, $5)
+	`, teamID, integration.Kind, integration.ID, channel, details)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to log integration delivery")
+	}
+}
+
+func (app *Application) purgeIntegrationDeliveries(ctx context.Context) error {
+	_, err := app.DB.ExecContext(ctx, `
+		DELETE FROM integration_deliveries WHERE created_at < $1
+	`, time.Now().Add(-integrationDeliveryRetention))
+	return err
+}
+
+func (app *Application) getIntegrationDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 200 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = parsed
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.QueryContext(r.Context(), `
+		SELECT id, team_id, integration_kind, integration_id, channel_id, error, created_at
+		FROM integration_deliveries
+		WHERE team_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, teamID, limit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get integration deliveries")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []domain.IntegrationDelivery{}
+	for rows.Next() {
+		var delivery domain.IntegrationDelivery
+		var details []byte
+		err := rows.Scan(&delivery.ID, &delivery.TeamID, &delivery.IntegrationKind, &delivery.IntegrationID,
+			&delivery.ChannelID, &details, &delivery.CreatedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan integration delivery row")
+			continue
+		}
+		if err := json.Unmarshal(details, &delivery.Error); err != nil {
+			app.Logger.WithError(err).Error("Failed to decode throttle error")
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating integration delivery rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+func (app *Application) getIntegrationSuspensionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.QueryContext(r.Context(), `
+		SELECT team_id, integration_kind, integration_id, reason, suspended_until, created_at
+		FROM integration_suspensions
+		WHERE team_id = $1 AND suspended_until > NOW()
+		ORDER BY suspended_until
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get integration suspensions")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	suspensions := []domain.IntegrationSuspension{}
+	for rows.Next() {
+		var suspension domain.IntegrationSuspension
+		err := rows.Scan(&suspension.TeamID, &suspension.IntegrationKind, &suspension.IntegrationID,
+			&suspension.Reason, &suspension.SuspendedUntil, &suspension.CreatedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan integration suspension row")
+			continue
+		}
+		suspensions = append(suspensions, suspension)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating integration suspension rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, suspensions)
+}
+
+// liftIntegrationSuspensionHandler lets an admin end a suspension early.
+func (app *Application) liftIntegrationSuspensionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+	integration := throttle.Integration{Kind: domain.IntegrationKind(vars["kind"]), ID: vars["integrationId"]}
+
+	if !domain.IsValidIntegrationKind(vars["kind"]) {
+		respondWithError(w, http.StatusBadRequest, "Invalid integration kind")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	result, err := app.DB.ExecContext(r.Context(), `
+		DELETE FROM integration_suspensions
+		WHERE team_id = $1 AND integration_kind = $2 AND integration_id = $3 AND suspended_until > NOW()
+	`, teamID, integration.Kind, integration.ID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to lift integration suspension")
+		respondWithError(w, http.StatusInternalServerError, "Failed to lift suspension")
+		return
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Integration is not suspended")
+		return
+	}
+
+	app.Throttle.Forgive(r.Context(), integration, teamID)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Suspension lifted"})
+}
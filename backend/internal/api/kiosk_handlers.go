@@ -0,0 +1,677 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/token"
+	wsHandler "github.com/cbalite/backend/internal/websocket"
+)
+
+// Kiosk connections are registered with the hub under this user ID prefix so
+// revoking a token can drop its open sockets.
+const kioskUserPrefix = "kiosk:"
+
+type kioskContextKey struct{}
+
+// Kiosk rooms use the hub's reserved prefix, so only kiosk sockets, which
+// are put in them on connect, receive what's relayed to them.
+func channelRoom(channelID string) string {
+	return wsHandler.ReservedRoomPrefix + "channel:" + channelID
+}
+
+func taskBoardRoom(teamID string) string {
+	return wsHandler.ReservedRoomPrefix + "tasks:" + teamID
+}
+
+// kioskCacheScope covers what depends on a team's kiosk tokens.
+func kioskCacheScope(teamID string) string {
+	return "kiosks:" + teamID
+}
+
+// kioskTargets is what a team's active kiosk tokens show: their channels
+// and whether any shows the task board.
+type kioskTargets struct {
+	ChannelIDs []string `json:"channel_ids"`
+	TaskBoard  bool     `json:"task_board"`
+}
+
+func (t kioskTargets) showsChannel(channelID string) bool {
+	for _, id := range t.ChannelIDs {
+		if id == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// getKioskTargets returns what the team's active kiosk tokens show. It's
+// cached until a token is created or revoked; expired tokens drop out when
+// the cache does, and their sockets are closed at expiry anyway.
+func (app *Application) getKioskTargets(ctx context.Context, teamID string) (kioskTargets, error) {
+	return cache.Load(ctx, app.Invalidator, "kiosk_targets:"+teamID, accessCacheTTL,
+		[]string{kioskCacheScope(teamID)}, func() (kioskTargets, error) {
+			var targets kioskTargets
+			var channelIDs pq.StringArray
+			err := app.DB.QueryRowContext(ctx, `
+				SELECT COALESCE(BOOL_OR(k.task_board), false),
+				       ARRAY(
+				           SELECT DISTINCT kc.channel_id::text
+				           FROM kiosk_token_channels kc
+				           JOIN kiosk_tokens t ON t.id = kc.token_id
+				           WHERE t.team_id = $1 AND t.revoked_at IS NULL
+				             AND (t.expires_at IS NULL OR t.expires_at > NOW())
+				       )
+				FROM kiosk_tokens k
+				WHERE k.team_id = $1 AND k.revoked_at IS NULL
+				  AND (k.expires_at IS NULL OR k.expires_at > NOW())
+			`, teamID).Scan(&targets.TaskBoard, &channelIDs)
+			targets.ChannelIDs = []string(channelIDs)
+			return targets, err
+		})
+}
+
+func (app *Application) createKioskTokenHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateKioskToken
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Kiosk token name is required")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	channelIDs := []string{}
+	seen := make(map[string]bool)
+	for _, channelID := range req.ChannelIDs {
+		if !seen[channelID] {
+			seen[channelID] = true
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+	if len(channelIDs) == 0 && !req.TaskBoard {
+		respondWithError(w, http.StatusBadRequest, "A kiosk token needs at least one channel or the task board")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if len(channelIDs) > 0 {
+		// Direct messages are never shown on a wallboard
+		var found int
+		err := app.DB.QueryRow(`
+			SELECT COUNT(*) FROM channels
+			WHERE team_id = $1 AND type <> 'direct' AND id::text = ANY($2)
+		`, teamID, pq.Array(channelIDs)).Scan(&found)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to check kiosk channels")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if found != len(channelIDs) {
+			respondWithError(w, http.StatusBadRequest, "Channels must belong to the team and can't be direct messages")
+			return
+		}
+	}
+
+	secret, tokenHash, err := token.Generate()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate kiosk token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create kiosk token")
+		return
+	}
+
+	kiosk := domain.KioskToken{
+		ID:         uuid.New().String(),
+		TeamID:     teamID,
+		Name:       req.Name,
+		Token:      secret,
+		ChannelIDs: channelIDs,
+		TaskBoard:  req.TaskBoard,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedBy:  claims.UserID,
+		CreatedAt:  time.Now(),
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create kiosk token")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO kiosk_tokens (id, team_id, name, token_hash, task_board, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, kiosk.ID, kiosk.TeamID, kiosk.Name, tokenHash, kiosk.TaskBoard, kiosk.ExpiresAt, kiosk.CreatedBy, kiosk.CreatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create kiosk token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create kiosk token")
+		return
+	}
+
+	for _, channelID := range channelIDs {
+		_, err = tx.Exec(`
+			INSERT INTO kiosk_token_channels (token_id, channel_id) VALUES ($1, $2)
+		`, kiosk.ID, channelID)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to add kiosk token channel")
+			respondWithError(w, http.StatusInternalServerError, "Failed to create kiosk token")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit kiosk token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create kiosk token")
+		return
+	}
+	app.invalidateAccess(r.Context(), kioskCacheScope(kiosk.TeamID))
+
+	// The token is only ever returned here; it's stored hashed
+	respondWithJSON(w, http.StatusCreated, kiosk)
+}
+
+func (app *Application) getKioskTokensHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT `+kioskTokenColumns+`
+		FROM kiosk_tokens k
+		WHERE k.team_id = $1
+		ORDER BY k.created_at
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get kiosk tokens")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	kiosks := []domain.KioskToken{}
+	for rows.Next() {
+		kiosk, err := scanKioskToken(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan kiosk token row")
+			continue
+		}
+		kiosks = append(kiosks, kiosk)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating kiosk token rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, kiosks)
+}
+
+// revokeKioskTokenHandler keeps the row so the wallboard's history stays
+// visible to admins, and drops any sockets the token has open.
+func (app *Application) revokeKioskTokenHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	kioskID := mux.Vars(r)["kioskTokenId"]
+
+	var teamID string
+	err := app.DB.QueryRow(`SELECT team_id FROM kiosk_tokens WHERE id = $1`, kioskID).Scan(&teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Kiosk token not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get kiosk token")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	_, err = app.DB.Exec(`
+		UPDATE kiosk_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+	`, kioskID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to revoke kiosk token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke kiosk token")
+		return
+	}
+
+	app.invalidateAccess(r.Context(), kioskCacheScope(teamID))
+	app.WSHub.DisconnectUser(kioskUserPrefix + kioskID)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Kiosk token revoked successfully"})
+}
+
+// kioskAuth authenticates wallboard requests by kiosk token, sent in the
+// X-Kiosk-Token header or the token query parameter.
+func (app *Application) kioskAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kiosk, err := app.lookupKioskToken(r.Context(), kioskSecret(r))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusUnauthorized, "Invalid or expired kiosk token")
+			} else {
+				app.Logger.WithError(err).Error("Failed to get kiosk token")
+				respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), kioskContextKey{}, kiosk)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func kioskSecret(r *http.Request) string {
+	if secret := r.Header.Get("X-Kiosk-Token"); secret != "" {
+		return secret
+	}
+	return r.URL.Query().Get("token")
+}
+
+func kioskFromContext(ctx context.Context) (domain.KioskToken, bool) {
+	kiosk, ok := ctx.Value(kioskContextKey{}).(domain.KioskToken)
+	return kiosk, ok
+}
+
+// lookupKioskToken returns sql.ErrNoRows for unknown, revoked and expired
+// tokens alike.
+func (app *Application) lookupKioskToken(ctx context.Context, secret string) (domain.KioskToken, error) {
+	if secret == "" {
+		return domain.KioskToken{}, sql.ErrNoRows
+	}
+
+	kiosk, err := scanKioskToken(app.DB.QueryRowContext(ctx, `
+		SELECT `+kioskTokenColumns+`
+		FROM kiosk_tokens k
+		WHERE k.token_hash = $1
+		  AND k.revoked_at IS NULL
+		  AND (k.expires_at IS NULL OR k.expires_at > NOW())
+	`, token.Hash(secret)))
+	if err != nil {
+		return domain.KioskToken{}, err
+	}
+
+	if _, err := app.DB.ExecContext(ctx, `UPDATE kiosk_tokens SET last_used_at = NOW() WHERE id = $1`, kiosk.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to record kiosk token use")
+	}
+
+	return kiosk, nil
+}
+
+const kioskTokenColumns = `k.id, k.team_id, k.name, k.task_board, k.expires_at, k.revoked_at, k.last_used_at,
+		       k.created_by, k.created_at,
+		       ARRAY(SELECT channel_id::text FROM kiosk_token_channels WHERE token_id = k.id ORDER BY channel_id)`
+
+func scanKioskToken(row rowScanner) (domain.KioskToken, error) {
+	var kiosk domain.KioskToken
+	var channelIDs pq.StringArray
+	err := row.Scan(&kiosk.ID, &kiosk.TeamID, &kiosk.Name, &kiosk.TaskBoard, &kiosk.ExpiresAt, &kiosk.RevokedAt,
+		&kiosk.LastUsedAt, &kiosk.CreatedBy, &kiosk.CreatedAt, &channelIDs)
+	kiosk.ChannelIDs = []string(channelIDs)
+	if kiosk.ChannelIDs == nil {
+		kiosk.ChannelIDs = []string{}
+	}
+	return kiosk, err
+}
+
+func (app *Application) getKioskHandler(w http.ResponseWriter, r *http.Request) {
+	kiosk, ok := kioskFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Kiosk token not found in context")
+		return
+	}
+
+	team, err := app.getTeamMeta(r.Context(), kiosk.TeamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get kiosk team")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, name, COALESCE(description, '')
+		FROM channels
+		WHERE id::text = ANY($1)
+		ORDER BY name
+	`, pq.Array(kiosk.ChannelIDs))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get kiosk channels")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	channels := []map[string]interface{}{}
+	for rows.Next() {
+		var id, name, description string
+		if err := rows.Scan(&id, &name, &description); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan kiosk channel row")
+			continue
+		}
+		channels = append(channels, map[string]interface{}{
+			"id":          id,
+			"name":        name,
+			"description": description,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating kiosk channel rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"name":       kiosk.Name,
+		"team":       map[string]string{"id": kiosk.TeamID, "name": team.Name},
+		"channels":   channels,
+		"task_board": kiosk.TaskBoard,
+		"expires_at": kiosk.ExpiresAt,
+	})
+}
+
+func (app *Application) getKioskMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	kiosk, ok := kioskFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Kiosk token not found in context")
+		return
+	}
+
+	channelID := mux.Vars(r)["channelId"]
+
+	shared := false
+	for _, id := range kiosk.ChannelIDs {
+		if id == channelID {
+			shared = true
+			break
+		}
+	}
+	if !shared {
+		respondWithError(w, http.StatusForbidden, "Channel is not shared with this kiosk")
+		return
+	}
+
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 100 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT m.id, m.content, m.type, m.is_pinned, m.created_at, u.username, u.first_name, u.last_name
+		FROM messages m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.channel_id = $1 AND m.is_deleted = false
+		ORDER BY m.created_at DESC
+		LIMIT $2
+	`, channelID, limit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get kiosk messages")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	messages := []map[string]interface{}{}
+	for rows.Next() {
+		var id, content, messageType, username, firstName, lastName string
+		var isPinned bool
+		var createdAt time.Time
+		if err := rows.Scan(&id, &content, &messageType, &isPinned, &createdAt, &username, &firstName, &lastName); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan kiosk message row")
+			continue
+		}
+		messages = append(messages, map[string]interface{}{
+			"id":         id,
+			"channel_id": channelID,
+			"content":    content,
+			"type":       messageType,
+			"is_pinned":  isPinned,
+			"created_at": createdAt,
+			"sender": map[string]interface{}{
+				"username":   username,
+				"first_name": firstName,
+				"last_name":  lastName,
+			},
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating kiosk message rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Oldest first, like the channel view
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	respondWithJSON(w, http.StatusOK, messages)
+}
+
+func (app *Application) getKioskTasksHandler(w http.ResponseWriter, r *http.Request) {
+	kiosk, ok := kioskFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Kiosk token not found in context")
+		return
+	}
+
+	if !kiosk.TaskBoard {
+		respondWithError(w, http.StatusForbidden, "Task board is not shared with this kiosk")
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT t.id, t.title, t.status, t.priority, t.due_date, t.updated_at,
+		       u.username, u.first_name, u.last_name
+		FROM tasks t
+		LEFT JOIN users u ON t.assignee_id = u.id
+		WHERE t.team_id = $1 AND t.status <> 'cancelled' AND t.deleted_at IS NULL
+		ORDER BY t.created_at
+	`, kiosk.TeamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get kiosk tasks")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	tasks := []map[string]interface{}{}
+	for rows.Next() {
+		var id, title, status, priority string
+		var dueDate *time.Time
+		var updatedAt time.Time
+		var username, firstName, lastName *string
+		err := rows.Scan(&id, &title, &status, &priority, &dueDate, &updatedAt, &username, &firstName, &lastName)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan kiosk task row")
+			continue
+		}
+
+		task := map[string]interface{}{
+			"id":         id,
+			"title":      title,
+			"status":     status,
+			"priority":   priority,
+			"updated_at": updatedAt,
+		}
+		if dueDate != nil {
+			task["due_date"] = *dueDate
+		}
+		if username != nil {
+			task["assignee"] = map[string]interface{}{
+				"username":   *username,
+				"first_name": *firstName,
+				"last_name":  *lastName,
+			}
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating kiosk task rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tasks)
+}
+
+// kioskWebsocketHandler opens a read-only socket that only receives the
+// channels and task board the token was granted.
+func (app *Application) kioskWebsocketHandler(w http.ResponseWriter, r *http.Request) {
+	kiosk, err := app.lookupKioskToken(r.Context(), kioskSecret(r))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired kiosk token")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get kiosk token")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	clientID := correlation.New()
+	conn, err := upgrader.Upgrade(w, r, http.Header{correlation.Header: []string{clientID}})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to upgrade connection")
+		return
+	}
+
+	rooms := make(map[string]bool)
+	for _, channelID := range kiosk.ChannelIDs {
+		rooms[channelRoom(channelID)] = true
+	}
+	if kiosk.TaskBoard {
+		rooms[taskBoardRoom(kiosk.TeamID)] = true
+	}
+
+	client := &wsHandler.Client{
+		ID:       clientID,
+		UserID:   kioskUserPrefix + kiosk.ID,
+		TeamID:   kiosk.TeamID,
+		Conn:     conn,
+		Hub:      app.WSHub,
+		Rooms:    rooms,
+		ReadOnly: true,
+	}
+
+	app.Logger.WithRequestID(clientID).Infof("Kiosk client connected: %s (Kiosk: %s, Team: %s)", client.ID, kiosk.ID, kiosk.TeamID)
+
+	app.WSHub.Register(client)
+
+	// Expiry is checked on connect; close the socket when the token runs out
+	if kiosk.ExpiresAt != nil {
+		time.AfterFunc(time.Until(*kiosk.ExpiresAt), func() {
+			app.WSHub.DisconnectUser(client.UserID)
+		})
+	}
+
+	go client.WritePump()
+	go client.ReadPump()
+}
+
+// relayToKiosks forwards posted messages and task changes to the rooms kiosk
+// sockets listen on, for the channels and boards an active kiosk token
+// shows.
+func (app *Application) relayToKiosks(ctx context.Context, event events.Event) {
+	data, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	targets, err := app.getKioskTargets(ctx, event.TeamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get kiosk tokens for relay")
+		return
+	}
+
+	switch event.Type {
+	case events.MessagePosted:
+		channelID, _ := data["channel_id"].(string)
+		if channelID == "" || !targets.showsChannel(channelID) {
+			return
+		}
+		app.WSHub.SendToRoom(channelRoom(channelID), &wsHandler.Message{
+			Type:       string(wsHandler.MessageTypeChat),
+			Data:       data,
+			Timestamp:  event.OccurredAt,
+			Meta:       wsHandler.MetaFor(event.CorrelationID),
+			AcceptedAt: event.OccurredAt,
+		})
+
+	case events.TaskCreated, events.TaskUpdated:
+		if !targets.TaskBoard {
+			return
+		}
+		// Only what the board shows; descriptions can hold internal detail
+		task := map[string]interface{}{
+			"event": string(event.Type),
+		}
+		for _, key := range []string{"id", "title", "status", "priority", "assignee_id", "due_date", "updated_at"} {
+			if value, ok := data[key]; ok {
+				task[key] = value
+			}
+		}
+		app.WSHub.SendToRoom(taskBoardRoom(event.TeamID), &wsHandler.Message{
+			Type:      string(wsHandler.MessageTypeTaskUpdate),
+			Data:      task,
+			Timestamp: event.OccurredAt,
+			Meta:      wsHandler.MetaFor(event.CorrelationID),
+		})
+	}
+}
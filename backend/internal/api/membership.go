@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/automation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+)
+
+// addTeamMember is the single place users join a team. Besides the membership
+// row it joins the user to the team's default channels and sends the team's
+// welcome message, if one is configured.
+func (app *Application) addTeamMember(ctx context.Context, teamID, userID, role, inviterID string) error {
+	if err := app.Teams.AddMember(ctx, teamID, userID, role); err != nil {
+		return err
+	}
+	app.invalidateAccess(ctx, userCacheScope(userID))
+
+	user, err := app.Users.Get(ctx, userID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get new member details")
+	}
+
+	app.Events.Publish(ctx, events.Event{
+		Type:    events.MemberJoined,
+		TeamID:  teamID,
+		ActorID: inviterID,
+		Data: map[string]interface{}{
+			"team_id":   teamID,
+			"user_id":   userID,
+			"role":      role,
+			"joined_at": time.Now(),
+			"user": map[string]interface{}{
+				"username":   user.Username,
+				"first_name": user.FirstName,
+				"last_name":  user.LastName,
+			},
+		},
+	})
+
+	// The member is in; a failed welcome message shouldn't undo that
+	if err := app.sendWelcomeMessage(ctx, teamID, userID, inviterID); err != nil {
+		app.Logger.WithError(err).Error("Failed to send welcome message")
+	}
+
+	return nil
+}
+
+// sendWelcomeMessage DMs a new member the team's welcome template. Messages
+// come from the configured sender, falling back to the team owner.
+func (app *Application) sendWelcomeMessage(ctx context.Context, teamID, userID, inviterID string) error {
+	settings, err := app.Teams.WelcomeSettings(ctx, teamID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !settings.Enabled || strings.TrimSpace(settings.Template) == "" {
+		return nil
+	}
+
+	sender := ""
+	if settings.SenderID != nil {
+		sender = *settings.SenderID
+	} else {
+		team, err := app.getTeamMeta(ctx, teamID)
+		if err != nil {
+			return err
+		}
+		sender = team.OwnerID
+	}
+
+	if sender == userID {
+		return nil
+	}
+
+	data, err := app.welcomeTemplateData(ctx, teamID, userID, inviterID)
+	if err != nil {
+		return err
+	}
+
+	channelID, err := app.Channels.OpenDirect(ctx, teamID, sender, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.createMessage(ctx, teamID, channelID, sender, automation.Render(settings.Template, data), string(domain.MessageTypeText))
+	return err
+}
+
+// welcomeTemplateData builds the variables available to welcome templates:
+// {{user.*}}, {{inviter.*}}, {{team.name}} and {{default_channels}}.
+func (app *Application) welcomeTemplateData(ctx context.Context, teamID, userID, inviterID string) (map[string]interface{}, error) {
+	user, err := app.welcomeUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	inviter := map[string]interface{}{}
+	if inviterID != "" {
+		if inviter, err = app.welcomeUserData(ctx, inviterID); err != nil {
+			return nil, err
+		}
+	}
+
+	team, err := app.getTeamMeta(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := app.Channels.DefaultNames(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]string, len(names))
+	for i, name := range names {
+		channels[i] = "#" + name
+	}
+
+	return map[string]interface{}{
+		"user":             user,
+		"inviter":          inviter,
+		"team":             map[string]interface{}{"name": team.Name},
+		"default_channels": strings.Join(channels, ", "),
+	}, nil
+}
+
+func (app *Application) welcomeUserData(ctx context.Context, userID string) (map[string]interface{}, error) {
+	user, err := app.Users.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"username":   user.Username,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+	}, nil
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/messagepolicy"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+func (app *Application) loadMessagePolicy(ctx context.Context, teamID string) (domain.MessagePolicy, error) {
+	policy, err := app.Teams.MessagePolicy(ctx, teamID)
+	if err == sql.ErrNoRows {
+		return messagepolicy.Default(teamID), nil
+	}
+	return policy, err
+}
+
+// messageActor is the user as the message policy of a channel's team sees
+// them. Guests from a shared channel have no role in the host team.
+func (app *Application) messageActor(access repository.ChannelAccess, userID string) (messagepolicy.Actor, error) {
+	actor := messagepolicy.Actor{UserID: userID}
+	if access.Guest() {
+		return actor, nil
+	}
+	role, err := app.getTeamRole(access.TeamID, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return actor, err
+	}
+	actor.Role = role
+	return actor, nil
+}
+
+func (app *Application) getMessagePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	policy, err := app.loadMessagePolicy(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get message policy")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, policy)
+}
+
+// updateMessagePolicyHandler is for team owners only, since the policy also
+// limits what admins can do.
+func (app *Application) updateMessagePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.UpdateMessagePolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if (req.EditWindowMinutes != nil && *req.EditWindowMinutes < 0) ||
+		(req.DeleteWindowMinutes != nil && *req.DeleteWindowMinutes < 0) {
+		respondWithError(w, http.StatusBadRequest, "Windows cannot be negative")
+		return
+	}
+
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check user role")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	if role != "owner" {
+		respondWithError(w, http.StatusForbidden, "Only team owners can change the message policy")
+		return
+	}
+
+	policy, err := app.loadMessagePolicy(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get message policy")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if req.MembersCanEdit != nil {
+		policy.MembersCanEdit = *req.MembersCanEdit
+	}
+	if req.MembersCanDelete != nil {
+		policy.MembersCanDelete = *req.MembersCanDelete
+	}
+	if req.EditWindowMinutes != nil {
+		policy.EditWindowMinutes = windowMinutes(*req.EditWindowMinutes)
+	}
+	if req.DeleteWindowMinutes != nil {
+		policy.DeleteWindowMinutes = windowMinutes(*req.DeleteWindowMinutes)
+	}
+	if req.AdminsCanDeleteOthers != nil {
+		policy.AdminsCanDeleteOthers = *req.AdminsCanDeleteOthers
+	}
+
+	err = app.DB.QueryRow(`
+		INSERT INTO team_message_policies (team_id, members_can_edit, members_can_delete, edit_window_minutes,
+		                                   delete_window_minutes, admins_can_delete_others, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (team_id) DO UPDATE
+		SET members_can_edit = EXCLUDED.members_can_edit,
+		    members_can_delete = EXCLUDED.members_can_delete,
+		    edit_window_minutes = EXCLUDED.edit_window_minutes,
+		    delete_window_minutes = EXCLUDED.delete_window_minutes,
+		    admins_can_delete_others = EXCLUDED.admins_can_delete_others,
+		    updated_by = EXCLUDED.updated_by
+		RETURNING updated_at
+	`, teamID, policy.MembersCanEdit, policy.MembersCanDelete, policy.EditWindowMinutes,
+		policy.DeleteWindowMinutes, policy.AdminsCanDeleteOthers, claims.UserID).Scan(&policy.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update message policy")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update message policy")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, policy)
+}
+
+// windowMinutes turns a window from a request into what's stored, where 0
+// means no limit.
+func windowMinutes(minutes int) *int {
+	if minutes == 0 {
+		return nil
+	}
+	return &minutes
+}
+
+// messageChange is a message someone wants to edit or delete, with what the
+// policy needs to decide.
+type messageChange struct {
+	ID        string
+	ChannelID string
+	TeamID    string
+	Message   messagepolicy.Message
+	Actor     messagepolicy.Actor
+	Policy    domain.MessagePolicy
+}
+
+// loadMessageChange resolves the message, the caller's access to its channel
+// and the team's policy, writing the error response and returning false when
+// the caller can't reach the message at all.
+func (app *Application) loadMessageChange(w http.ResponseWriter, r *http.Request, userID string) (messageChange, bool) {
+	change := messageChange{ID: mux.Vars(r)["messageId"]}
+
+	message, err := app.Messages.Get(r.Context(), change.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Message not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get message")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return change, false
+	}
+	change.ChannelID = message.ChannelID
+	change.Message = messagepolicy.Message{
+		SenderID:  message.UserID,
+		Type:      string(message.Type),
+		CreatedAt: message.CreatedAt,
+	}
+
+	access, err := app.getChannelAccess(change.ChannelID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return change, false
+	}
+	if access.HistoryUntil != nil {
+		respondWithError(w, http.StatusForbidden, "This channel is no longer shared with your team")
+		return change, false
+	}
+
+	change.TeamID = access.TeamID
+	if change.Actor, err = app.messageActor(access, userID); err != nil {
+		app.Logger.WithError(err).Error("Failed to check user role")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return change, false
+	}
+
+	if change.Policy, err = app.loadMessagePolicy(r.Context(), access.TeamID); err != nil {
+		app.Logger.WithError(err).Error("Failed to get message policy")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return change, false
+	}
+
+	return change, true
+}
+
+func (app *Application) updateMessageHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateMessage
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" || len(req.Content) > 4000 {
+		respondWithError(w, http.StatusBadRequest, "Message must be between 1 and 4000 characters")
+		return
+	}
+
+	change, ok := app.loadMessageChange(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if err := messagepolicy.CanEdit(change.Policy, change.Message, change.Actor, now); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	updatedAt, err := app.Messages.Edit(r.Context(), change.ID, req.Content)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Message not found")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to update message")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update message")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"id":          change.ID,
+		"channel_id":  change.ChannelID,
+		"content":     req.Content,
+		"type":        change.Message.Type,
+		"sender_id":   change.Message.SenderID,
+		"is_edited":   true,
+		"created_at":  change.Message.CreatedAt,
+		"updated_at":  updatedAt,
+		"permissions": authz.Message(authz.NewSubject(claims, change.Actor.Role), change.Policy, change.Message, now, false),
+	})
+}
+
+// deleteMessageHandler soft-deletes a message: its content is cleared but
+// the row stays so replies and exports keep their place in the thread. The
+// content is kept with the undo action until the undo window passes.
+func (app *Application) deleteMessageHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	change, ok := app.loadMessageChange(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if err := messagepolicy.CanDelete(change.Policy, change.Message, change.Actor, time.Now()); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	action := app.newUndoableAction(r.Context(), domain.UndoMessageDelete, change.TeamID, claims.UserID, change.ID)
+	err := app.Messages.Delete(r.Context(), change.ID, &action)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Message not found")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to delete message")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete message")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Message deleted",
+		"undo":    action,
+	})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/apiversion"
+	"github.com/cbalite/backend/internal/deprecation"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/version"
+)
+
+// metaHandler tells clients what this server supports so they can ask users
+// to upgrade before payload changes break them. Authentication is optional;
+// signed-in users also get the feature flags rolled out to them.
+func (app *Application) metaHandler(w http.ResponseWriter, r *http.Request) {
+	var userID string
+	if claims, ok := middleware.GetUserFromContext(r.Context()); ok {
+		userID = claims.UserID
+	}
+
+	minVersions := app.Config.Clients.MinVersions
+	if minVersions == nil {
+		minVersions = map[string]string{}
+	}
+
+	meta := map[string]interface{}{
+		"server": map[string]string{
+			"version":     app.Config.App.Version,
+			"region":      app.Config.App.Region,
+			"api_version": apiversion.FromContext(r.Context()).String(),
+		},
+		"api_versions":        apiversion.Supported,
+		"min_client_versions": minVersions,
+		"deprecations":        deprecation.Notices,
+		"features":            app.Features.EnabledFor(userID),
+	}
+	if regions := app.regionHints(r); regions != nil {
+		meta["regions"] = regions
+	}
+
+	platform := strings.ToLower(firstNonEmpty(r.Header.Get("X-Client-Platform"), r.URL.Query().Get("platform")))
+	clientVersion := firstNonEmpty(r.Header.Get("X-Client-Version"), r.URL.Query().Get("version"))
+	if platform != "" && clientVersion != "" {
+		minimum, known := minVersions[platform]
+		meta["client"] = map[string]interface{}{
+			"platform":         platform,
+			"version":          clientVersion,
+			"upgrade_required": known && version.Compare(clientVersion, minimum) < 0,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, meta)
+}
+
+// regionHints lists the regions clients can connect to and picks the one
+// they should prefer: the region the edge saw the request arrive in, when
+// it's one of them, or else this server's. It's nil when REGION_ENDPOINTS
+// isn't set.
+func (app *Application) regionHints(r *http.Request) map[string]interface{} {
+	endpoints := app.Config.App.RegionEndpoints
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	preferred := app.Config.App.Region
+	if edge := r.Header.Get(app.Config.App.RegionHeader); edge != "" {
+		if _, ok := endpoints[edge]; ok {
+			preferred = edge
+		}
+	}
+
+	regions := make([]string, 0, len(endpoints))
+	for region := range endpoints {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	available := make([]map[string]string, 0, len(regions))
+	for _, region := range regions {
+		available = append(available, map[string]string{"region": region, "url": endpoints[region]})
+	}
+	return map[string]interface{}{
+		"preferred":     preferred,
+		"preferred_url": endpoints[preferred],
+		"available":     available,
+	}
+}
+
+// timeHandler reports the server clock so clients can work out their offset
+// for relative timestamps and scheduled sends. Clients should halve the round
+// trip time when applying it.
+func (app *Application) timeHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"server_time": now,
+		"unix_ms":     now.UnixMilli(),
+	})
+}
+
+// compactRequested reports whether the client asked for the low-bandwidth
+// payloads, with ?compact=true or an X-Client-Capabilities header listing
+// "compact". It is negotiated per request or per socket.
+func compactRequested(r *http.Request) bool {
+	if compact, err := strconv.ParseBool(r.URL.Query().Get("compact")); err == nil {
+		return compact
+	}
+	for _, capability := range strings.Split(r.Header.Get("X-Client-Capabilities"), ",") {
+		if strings.EqualFold(strings.TrimSpace(capability), "compact") {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cbalite/backend/internal/cache"
+)
+
+// Team and channel rows change rarely but are read on nearly every message,
+// share view and app request, so reads that only display or route on them
+// go through these cached copies. Handlers that validate a write read the
+// row itself.
+
+// metadataCacheTTL bounds how long metadata can be served after an
+// invalidation that failed to reach the cache.
+const metadataCacheTTL = 10 * time.Minute
+
+type channelMeta struct {
+	ID                    string `json:"id"`
+	TeamID                string `json:"team_id"`
+	Name                  string `json:"name"`
+	Description           string `json:"description"`
+	Type                  string `json:"type"`
+	IsPrivate             bool   `json:"is_private"`
+	IsDefault             bool   `json:"is_default"`
+	ClassificationEnabled bool   `json:"classification_enabled"`
+}
+
+type teamMeta struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	OwnerID string `json:"owner_id"`
+}
+
+// getChannelMeta returns a channel's settings, or sql.ErrNoRows when there's
+// no such channel. They're cached until the channel is updated.
+func (app *Application) getChannelMeta(ctx context.Context, channelID string) (channelMeta, error) {
+	meta, err := cache.Load(ctx, app.Invalidator, "channel_meta:"+channelID, metadataCacheTTL,
+		[]string{channelCacheScope(channelID)}, func() (*channelMeta, error) {
+			channel, err := app.Channels.Get(ctx, channelID)
+			// A missing channel is cached too
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &channelMeta{
+				ID:                    channel.ID,
+				TeamID:                channel.TeamID,
+				Name:                  channel.Name,
+				Description:           channel.Description,
+				Type:                  string(channel.Type),
+				IsPrivate:             channel.IsPrivate,
+				IsDefault:             channel.IsDefault,
+				ClassificationEnabled: channel.ClassificationEnabled,
+			}, nil
+		})
+	if err != nil {
+		return channelMeta{}, err
+	}
+	if meta == nil {
+		return channelMeta{}, sql.ErrNoRows
+	}
+	return *meta, nil
+}
+
+// getTeamMeta returns a team's name and owner, or sql.ErrNoRows when there's
+// no such team. They're cached until the team is updated.
+func (app *Application) getTeamMeta(ctx context.Context, teamID string) (teamMeta, error) {
+	meta, err := cache.Load(ctx, app.Invalidator, "team_meta:"+teamID, metadataCacheTTL,
+		[]string{teamCacheScope(teamID)}, func() (*teamMeta, error) {
+			team, err := app.Teams.Get(ctx, teamID)
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &teamMeta{ID: team.ID, Name: team.Name, OwnerID: team.OwnerID}, nil
+		})
+	if err != nil {
+		return teamMeta{}, err
+	}
+	if meta == nil {
+		return teamMeta{}, sql.ErrNoRows
+	}
+	return *meta, nil
+}
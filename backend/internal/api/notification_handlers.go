@@ -0,0 +1,101 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const notificationsLimit = 100
+
+func (app *Application) getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	rows, err := app.DB.Query(`
+		SELECT id, user_id, COALESCE(team_id::text, ''), type, title, COALESCE(body, ''), data, read_at, held_until, on_behalf_of, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, claims.UserID, unreadOnly, notificationsLimit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get notifications")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	notifications := []domain.Notification{}
+	for rows.Next() {
+		var notification domain.Notification
+		var data []byte
+
+		err := rows.Scan(&notification.ID, &notification.UserID, &notification.TeamID, &notification.Type,
+			&notification.Title, &notification.Body, &data, &notification.ReadAt, &notification.HeldUntil, &notification.OnBehalfOf, &notification.CreatedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan notification row")
+			continue
+		}
+
+		if err := json.Unmarshal(data, &notification.Data); err != nil {
+			app.Logger.WithError(err).Error("Failed to decode notification data")
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating notification rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, notifications)
+}
+
+func (app *Application) markNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var id string
+	err := app.DB.QueryRow(`
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING id
+	`, mux.Vars(r)["notificationId"], claims.UserID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Notification not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to mark notification read")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	// The new count lets mobile clients update their badge
+	unread, err := app.Notifier.UnreadCount(r.Context(), claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to count unread notifications")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":      "Notification marked as read",
+		"unread_count": unread,
+	})
+}
@@ -0,0 +1,826 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/appmanifest"
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/token"
+)
+
+const authorizationCodeTTL = 10 * time.Minute
+
+// appAuthzStore backs the authz layer's lookups for app tokens.
+type appAuthzStore struct {
+	app *Application
+}
+
+func (s appAuthzStore) InstalledScopes(ctx context.Context, teamID, appID string) ([]string, error) {
+	var scopes pq.StringArray
+	err := s.app.DB.QueryRowContext(ctx, `
+		SELECT scopes FROM app_installations WHERE team_id = $1 AND app_id = $2
+	`, teamID, appID).Scan(&scopes)
+	if err == sql.ErrNoRows {
+		return nil, authz.ErrNotInstalled
+	}
+	return scopes, err
+}
+
+// IsMember reads the cached team role.
+func (s appAuthzStore) IsMember(ctx context.Context, teamID, userID string) (bool, error) {
+	_, err := s.app.getTeamRole(teamID, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s appAuthzStore) ResourceTeam(ctx context.Context, param, id string) (string, error) {
+	var teamID string
+	var err error
+	switch param {
+	case "channelId":
+		var channel channelMeta
+		channel, err = s.app.getChannelMeta(ctx, id)
+		teamID = channel.TeamID
+	case "taskId":
+		var task domain.Task
+		task, err = s.app.Tasks.Get(ctx, id)
+		teamID = task.TeamID
+	default:
+		return "", nil
+	}
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return teamID, err
+}
+
+// scopesDeclared reports whether every requested scope is in the manifest.
+func scopesDeclared(requested, declared []string) bool {
+	for _, scope := range requested {
+		if !authz.HasScope(declared, authz.Scope(scope)) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateScopes checks a requested scope list and returns it without
+// duplicates.
+func validateScopes(scopes []string) ([]string, bool) {
+	valid := []string{}
+	seen := make(map[string]bool)
+	for _, scope := range scopes {
+		if !authz.IsValidScope(scope) {
+			return nil, false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			valid = append(valid, scope)
+		}
+	}
+	return valid, len(valid) > 0
+}
+
+func (app *Application) createAppHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateApp
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Manifest != nil {
+		if err := appmanifest.Validate(req.Manifest, app.builtinCommandNames()); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := checkManifestTargets(r.Context(), req.Manifest); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		req.Name, req.Description, req.RedirectURIs = req.Manifest.Name, req.Manifest.Description, req.Manifest.RedirectURIs
+	} else {
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			respondWithError(w, http.StatusBadRequest, "App name is required")
+			return
+		}
+		if len(req.RedirectURIs) == 0 {
+			respondWithError(w, http.StatusBadRequest, "At least one redirect URI is required")
+			return
+		}
+		for _, uri := range req.RedirectURIs {
+			if !appmanifest.ValidRedirectURI(uri) {
+				respondWithError(w, http.StatusBadRequest, "Redirect URIs must be https URLs without a fragment, or http on a loopback host")
+				return
+			}
+		}
+	}
+	if req.RedirectURIs == nil {
+		req.RedirectURIs = []string{}
+	}
+
+	secret, secretHash, err := token.Generate()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate app client secret")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create app")
+		return
+	}
+
+	// Signs slash command requests so apps can check they came from us
+	signingSecret, _, err := token.Generate()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate app signing secret")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create app")
+		return
+	}
+
+	var manifest []byte
+	if req.Manifest != nil {
+		if manifest, err = json.Marshal(req.Manifest); err != nil {
+			app.Logger.WithError(err).Error("Failed to encode app manifest")
+			respondWithError(w, http.StatusInternalServerError, "Failed to create app")
+			return
+		}
+	}
+
+	now := time.Now()
+	registered := domain.App{
+		ID:            uuid.New().String(),
+		Name:          req.Name,
+		Description:   req.Description,
+		ClientID:      strings.ReplaceAll(uuid.New().String(), "-", ""),
+		ClientSecret:  secret,
+		SigningSecret: signingSecret,
+		RedirectURIs:  req.RedirectURIs,
+		Manifest:      req.Manifest,
+		OwnerID:       claims.UserID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO apps (id, name, description, client_id, client_secret_hash, signing_secret, redirect_uris,
+		                  manifest, owner_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, registered.ID, registered.Name, registered.Description, registered.ClientID, secretHash, signingSecret,
+		pq.Array(registered.RedirectURIs), manifest, registered.OwnerID, registered.CreatedAt, registered.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create app")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create app")
+		return
+	}
+
+	// The secrets are only ever returned here
+	respondWithJSON(w, http.StatusCreated, registered)
+}
+
+// updateAppManifestHandler replaces an app's manifest. Teams pick up the
+// change the next time they install or authorize the app.
+func (app *Application) updateAppManifestHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	appID := mux.Vars(r)["appId"]
+
+	var manifest domain.AppManifest
+	if err := json.NewDecoder(r.Body).Decode(&manifest); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := appmanifest.Validate(&manifest, app.builtinCommandNames()); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkManifestTargets(r.Context(), &manifest); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to encode app manifest")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update app manifest")
+		return
+	}
+
+	redirectURIs := manifest.RedirectURIs
+	if redirectURIs == nil {
+		redirectURIs = []string{}
+	}
+
+	result, err := app.DB.Exec(`
+		UPDATE apps SET name = $3, description = $4, redirect_uris = $5, manifest = $6
+		WHERE id = $1 AND owner_id = $2
+	`, appID, claims.UserID, manifest.Name, manifest.Description, pq.Array(redirectURIs), encoded)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update app manifest")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update app manifest")
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		respondWithError(w, http.StatusNotFound, "App not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, manifest)
+}
+
+func (app *Application) getAppsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, name, COALESCE(description, ''), client_id, redirect_uris, manifest, bot_user_id,
+		       owner_id, created_at, updated_at
+		FROM apps
+		WHERE owner_id = $1
+		ORDER BY created_at
+	`, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get apps")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	apps := []domain.App{}
+	for rows.Next() {
+		var registered domain.App
+		var redirectURIs pq.StringArray
+		var manifest []byte
+		err := rows.Scan(&registered.ID, &registered.Name, &registered.Description, &registered.ClientID,
+			&redirectURIs, &manifest, &registered.BotUserID, &registered.OwnerID, &registered.CreatedAt, &registered.UpdatedAt)
+		if err == nil {
+			registered.Manifest, err = scanAppManifest(manifest)
+		}
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan app row")
+			continue
+		}
+		registered.RedirectURIs = redirectURIs
+		apps = append(apps, registered)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating app rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, apps)
+}
+
+func (app *Application) deleteAppHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	appID := mux.Vars(r)["appId"]
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete app")
+		return
+	}
+	defer tx.Rollback()
+
+	var botUserID *string
+	err = tx.QueryRow(`
+		DELETE FROM apps WHERE id = $1 AND owner_id = $2 RETURNING bot_user_id
+	`, appID, claims.UserID).Scan(&botUserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "App not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to delete app")
+			respondWithError(w, http.StatusInternalServerError, "Failed to delete app")
+		}
+		return
+	}
+
+	// The bot's messages stay, so the account is deactivated rather than
+	// deleted
+	if botUserID != nil {
+		_, err = tx.Exec(`DELETE FROM team_members WHERE user_id = $1`, *botUserID)
+		if err == nil {
+			_, err = tx.Exec(`UPDATE users SET is_active = false WHERE id = $1`, *botUserID)
+		}
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to deactivate app bot")
+			respondWithError(w, http.StatusInternalServerError, "Failed to delete app")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit app deletion")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete app")
+		return
+	}
+	if botUserID != nil {
+		app.invalidateAccess(r.Context(), userCacheScope(*botUserID))
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "App deleted successfully"})
+}
+
+// authorizeAppHandler records a team admin's consent: the app is installed
+// with the requested scopes and a single-use authorization code is handed
+// back in the redirect URI.
+func (app *Application) authorizeAppHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.AuthorizeApp
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	scopes, valid := validateScopes(authz.ParseScopes(req.Scope))
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, "scope must list one or more known scopes")
+		return
+	}
+
+	var appID string
+	var redirectURIs pq.StringArray
+	var rawManifest []byte
+	err := app.DB.QueryRow(`
+		SELECT id, redirect_uris, manifest FROM apps WHERE client_id = $1
+	`, req.ClientID).Scan(&appID, &redirectURIs, &rawManifest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "Unknown client_id")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get app")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	registered := false
+	for _, uri := range redirectURIs {
+		if uri == req.RedirectURI {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		respondWithError(w, http.StatusBadRequest, "redirect_uri is not registered for this app")
+		return
+	}
+
+	manifest, err := scanAppManifest(rawManifest)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to read app manifest")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if manifest != nil && !scopesDeclared(scopes, manifest.Scopes) {
+		respondWithError(w, http.StatusBadRequest, "scope asks for more than the app's manifest declares")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, req.TeamID, claims.UserID) {
+		return
+	}
+
+	code, codeHash, err := token.Generate()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate authorization code")
+		respondWithError(w, http.StatusInternalServerError, "Failed to authorize app")
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to authorize app")
+		return
+	}
+	defer tx.Rollback()
+
+	installationID, err := app.installApp(r.Context(), tx, req.TeamID, appID, claims.UserID, scopes)
+	if err != nil {
+		app.respondInstallError(w, err)
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO oauth_authorization_codes (code_hash, installation_id, redirect_uri, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, codeHash, installationID, req.RedirectURI, time.Now().Add(authorizationCodeTTL))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to store authorization code")
+		respondWithError(w, http.StatusInternalServerError, "Failed to authorize app")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit app authorization")
+		respondWithError(w, http.StatusInternalServerError, "Failed to authorize app")
+		return
+	}
+	app.invalidateAppBot(r.Context(), appID)
+
+	redirect, _ := url.Parse(req.RedirectURI)
+	query := redirect.Query()
+	query.Set("code", code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	redirect.RawQuery = query.Encode()
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"redirect_uri": redirect.String()})
+}
+
+// oauthTokenHandler is the OAuth2 token endpoint. It takes form-encoded
+// requests with client credentials and supports the authorization_code and
+// refresh_token grants. Errors use the OAuth2 error codes.
+func (app *Application) oauthTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	var appID, secretHash string
+	err := app.DB.QueryRow(`
+		SELECT id, client_secret_hash FROM apps WHERE client_id = $1
+	`, clientID).Scan(&appID, &secretHash)
+	if err != nil && err != sql.ErrNoRows {
+		app.Logger.WithError(err).Error("Failed to get app")
+		respondWithError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	if err == sql.ErrNoRows || subtle.ConstantTimeCompare([]byte(token.Hash(clientSecret)), []byte(secretHash)) != 1 {
+		respondWithError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	var installationID string
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		var redirectURI string
+		var expiresAt time.Time
+		err = app.DB.QueryRow(`
+			DELETE FROM oauth_authorization_codes c
+			USING app_installations i
+			WHERE c.code_hash = $1 AND c.installation_id = i.id AND i.app_id = $2
+			RETURNING c.installation_id, c.redirect_uri, c.expires_at
+		`, token.Hash(r.PostForm.Get("code")), appID).Scan(&installationID, &redirectURI, &expiresAt)
+		if err == nil && (time.Now().After(expiresAt) || redirectURI != r.PostForm.Get("redirect_uri")) {
+			err = sql.ErrNoRows
+		}
+
+	case "refresh_token":
+		err = app.DB.QueryRow(`
+			SELECT id FROM app_installations WHERE refresh_token_hash = $1 AND app_id = $2
+		`, token.Hash(r.PostForm.Get("refresh_token")), appID).Scan(&installationID)
+
+	default:
+		respondWithError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "invalid_grant")
+		} else {
+			app.Logger.WithError(err).Error("Failed to redeem OAuth grant")
+			respondWithError(w, http.StatusInternalServerError, "server_error")
+		}
+		return
+	}
+
+	refreshToken, refreshHash, err := token.Generate()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate refresh token")
+		respondWithError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	// Refresh tokens rotate on every use
+	// Apps with a bot user act as the bot; others act for the installer
+	var teamID, actorID string
+	var scopes pq.StringArray
+	err = app.DB.QueryRow(`
+		UPDATE app_installations i SET refresh_token_hash = $2
+		FROM apps a
+		WHERE i.id = $1 AND a.id = i.app_id
+		RETURNING i.team_id, COALESCE(a.bot_user_id, i.installed_by), i.scopes
+	`, installationID, refreshHash).Scan(&teamID, &actorID, &scopes)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to rotate refresh token")
+		respondWithError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	accessToken, err := app.AuthMiddleware.GenerateAppToken(actorID, appID, teamID, scopes)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate app token")
+		respondWithError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusOK, domain.OAuthToken{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(app.Config.JWT.AppTokenExpiry.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(scopes, " "),
+		TeamID:       teamID,
+	})
+}
+
+// installAppHandler installs an app straight from its manifest, for apps that
+// only need webhooks, slash commands or a bot and no OAuth tokens.
+func (app *Application) installAppHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.InstallApp
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	var rawManifest []byte
+	err := app.DB.QueryRow(`SELECT manifest FROM apps WHERE id = $1`, req.AppID).Scan(&rawManifest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "App not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get app")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	manifest, err := scanAppManifest(rawManifest)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to read app manifest")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if manifest == nil {
+		respondWithError(w, http.StatusBadRequest, "Apps without a manifest are installed through OAuth authorization")
+		return
+	}
+
+	scopes := manifest.Scopes
+	if req.Scopes != nil {
+		scopes = req.Scopes
+		if !scopesDeclared(scopes, manifest.Scopes) {
+			respondWithError(w, http.StatusBadRequest, "scopes ask for more than the app's manifest declares")
+			return
+		}
+	}
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to install app")
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := app.installApp(r.Context(), tx, teamID, req.AppID, claims.UserID, scopes); err != nil {
+		app.respondInstallError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit app install")
+		respondWithError(w, http.StatusInternalServerError, "Failed to install app")
+		return
+	}
+	app.invalidateAppBot(r.Context(), req.AppID)
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{"app_id": req.AppID, "team_id": teamID, "scopes": scopes})
+}
+
+func (app *Application) getInstalledAppsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT i.id, i.team_id, i.app_id, a.name, i.scopes, i.installed_by, i.installed_at
+		FROM app_installations i
+		JOIN apps a ON a.id = i.app_id
+		WHERE i.team_id = $1
+		ORDER BY i.installed_at
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get installed apps")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	installations := []domain.AppInstallation{}
+	for rows.Next() {
+		var installation domain.AppInstallation
+		var scopes pq.StringArray
+		err := rows.Scan(&installation.ID, &installation.TeamID, &installation.AppID, &installation.AppName,
+			&scopes, &installation.InstalledBy, &installation.InstalledAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan installed app row")
+			continue
+		}
+		installation.Scopes = scopes
+		installations = append(installations, installation)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating installed app rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, installations)
+}
+
+// updateInstalledAppHandler changes the scopes a team grants an app. The
+// change applies to tokens that were already issued.
+func (app *Application) updateInstalledAppHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID, appID := vars["teamId"], vars["appId"]
+
+	var req domain.UpdateAppInstallation
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	scopes, valid := validateScopes(req.Scopes)
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, "scopes must list one or more known scopes")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	// Installations can't be given more than installing could grant
+	var rawManifest []byte
+	err := app.DB.QueryRow(`SELECT manifest FROM apps WHERE id = $1`, appID).Scan(&rawManifest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "App is not installed in this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get app")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	manifest, err := scanAppManifest(rawManifest)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to read app manifest")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if manifest != nil && !scopesDeclared(scopes, manifest.Scopes) {
+		respondWithError(w, http.StatusBadRequest, "scopes ask for more than the app's manifest declares")
+		return
+	}
+
+	result, err := app.DB.Exec(`
+		UPDATE app_installations SET scopes = $3 WHERE team_id = $1 AND app_id = $2
+	`, teamID, appID, pq.Array(scopes))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update installed app")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update installed app")
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		respondWithError(w, http.StatusNotFound, "App is not installed in this team")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"app_id": appID, "team_id": teamID, "scopes": scopes})
+}
+
+func (app *Application) uninstallAppHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID, appID := vars["teamId"], vars["appId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to uninstall app")
+		return
+	}
+	defer tx.Rollback()
+
+	// Webhooks, slash commands and authorization codes go with the
+	// installation; the bot leaves the team.
+	result, err := tx.Exec(`DELETE FROM app_installations WHERE team_id = $1 AND app_id = $2`, teamID, appID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to uninstall app")
+		respondWithError(w, http.StatusInternalServerError, "Failed to uninstall app")
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		respondWithError(w, http.StatusNotFound, "App is not installed in this team")
+		return
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM team_members
+		WHERE team_id = $1 AND user_id = (SELECT bot_user_id FROM apps WHERE id = $2)
+	`, teamID, appID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to remove app bot from team")
+		respondWithError(w, http.StatusInternalServerError, "Failed to uninstall app")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit app uninstall")
+		respondWithError(w, http.StatusInternalServerError, "Failed to uninstall app")
+		return
+	}
+	app.invalidateAppBot(r.Context(), appID)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "App uninstalled successfully"})
+}
@@ -0,0 +1,601 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/oncall"
+)
+
+const (
+	defaultRotationHours = 168
+	defaultCalendarDays  = 28
+	maxCalendarDays      = 180
+)
+
+func (app *Application) createOnCallScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateOnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Schedule name is required")
+		return
+	}
+	if len(req.Members) == 0 {
+		respondWithError(w, http.StatusBadRequest, "At least one member is required")
+		return
+	}
+	if req.RotationHours == 0 {
+		req.RotationHours = defaultRotationHours
+	}
+	if req.RotationHours < 0 {
+		respondWithError(w, http.StatusBadRequest, "rotation_hours must be positive")
+		return
+	}
+	if req.HandoffAt.IsZero() {
+		req.HandoffAt = time.Now().Truncate(time.Hour)
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if !app.requireScheduleMembers(w, r.Context(), teamID, req.Members) {
+		return
+	}
+
+	schedule := domain.OnCallSchedule{
+		ID:            uuid.New().String(),
+		TeamID:        teamID,
+		Name:          req.Name,
+		RotationHours: req.RotationHours,
+		HandoffAt:     req.HandoffAt,
+		Members:       req.Members,
+		Overrides:     []domain.OnCallOverride{},
+		CreatedBy:     claims.UserID,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	tx, err := app.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create on-call schedule")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(r.Context(), `
+		INSERT INTO oncall_schedules (id, team_id, name, rotation_hours, handoff_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, schedule.ID, schedule.TeamID, schedule.Name, schedule.RotationHours, schedule.HandoffAt,
+		schedule.CreatedBy, schedule.CreatedAt, schedule.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A schedule with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create on-call schedule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create on-call schedule")
+		return
+	}
+
+	if err := insertScheduleMembers(r.Context(), tx, schedule.ID, schedule.Members); err != nil {
+		app.Logger.WithError(err).Error("Failed to add on-call schedule members")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create on-call schedule")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit on-call schedule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create on-call schedule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, schedule)
+}
+
+func (app *Application) getOnCallSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	schedules, err := app.loadTeamSchedules(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get on-call schedules")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schedules)
+}
+
+func (app *Application) getOnCallScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	schedule, ok := app.loadOnCallSchedule(w, r.Context(), mux.Vars(r)["scheduleId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, schedule.TeamID, claims.UserID) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schedule)
+}
+
+func (app *Application) updateOnCallScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateOnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	schedule, ok := app.loadOnCallSchedule(w, r.Context(), mux.Vars(r)["scheduleId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, schedule.TeamID, claims.UserID) {
+		return
+	}
+
+	if req.Name != nil {
+		schedule.Name = strings.TrimSpace(*req.Name)
+		if schedule.Name == "" {
+			respondWithError(w, http.StatusBadRequest, "Schedule name is required")
+			return
+		}
+	}
+	if req.RotationHours != nil {
+		if *req.RotationHours <= 0 {
+			respondWithError(w, http.StatusBadRequest, "rotation_hours must be positive")
+			return
+		}
+		schedule.RotationHours = *req.RotationHours
+	}
+	if req.HandoffAt != nil {
+		schedule.HandoffAt = *req.HandoffAt
+	}
+	if req.Members != nil {
+		if len(*req.Members) == 0 {
+			respondWithError(w, http.StatusBadRequest, "At least one member is required")
+			return
+		}
+		if !app.requireScheduleMembers(w, r.Context(), schedule.TeamID, *req.Members) {
+			return
+		}
+		schedule.Members = *req.Members
+	}
+
+	tx, err := app.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to start transaction")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update on-call schedule")
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(r.Context(), `
+		UPDATE oncall_schedules
+		SET name = $2, rotation_hours = $3, handoff_at = $4
+		WHERE id = $1
+		RETURNING updated_at
+	`, schedule.ID, schedule.Name, schedule.RotationHours, schedule.HandoffAt).Scan(&schedule.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A schedule with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to update on-call schedule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update on-call schedule")
+		return
+	}
+
+	if req.Members != nil {
+		_, err = tx.ExecContext(r.Context(), `DELETE FROM oncall_schedule_members WHERE schedule_id = $1`, schedule.ID)
+		if err == nil {
+			err = insertScheduleMembers(r.Context(), tx, schedule.ID, schedule.Members)
+		}
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to update on-call schedule members")
+			respondWithError(w, http.StatusInternalServerError, "Failed to update on-call schedule")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		app.Logger.WithError(err).Error("Failed to commit on-call schedule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update on-call schedule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schedule)
+}
+
+func (app *Application) deleteOnCallScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	schedule, ok := app.loadOnCallSchedule(w, r.Context(), mux.Vars(r)["scheduleId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, schedule.TeamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM oncall_schedules WHERE id = $1`, schedule.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete on-call schedule")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete on-call schedule")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "On-call schedule deleted successfully"})
+}
+
+// getCurrentOnCallHandler lists who is on call right now for each of the
+// team's schedules.
+func (app *Application) getCurrentOnCallHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	schedules, err := app.loadTeamSchedules(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get on-call schedules")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	now := time.Now()
+	current := []domain.CurrentOnCall{}
+	for _, schedule := range schedules {
+		// One full cycle is always long enough to reach the next handoff
+		cycle := time.Duration(schedule.RotationHours*(len(schedule.Members)+1)) * time.Hour
+		shifts := oncall.Shifts(schedule, now, now.Add(cycle))
+		if len(shifts) == 0 {
+			continue
+		}
+
+		current = append(current, domain.CurrentOnCall{
+			ScheduleID:   schedule.ID,
+			ScheduleName: schedule.Name,
+			UserID:       shifts[0].UserID,
+			IsOverride:   shifts[0].IsOverride,
+			Until:        shifts[0].EndsAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, current)
+}
+
+func (app *Application) createOnCallOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateOnCallOverride
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.UserID == "" || req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		respondWithError(w, http.StatusBadRequest, "user_id, starts_at and ends_at are required")
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		respondWithError(w, http.StatusBadRequest, "ends_at must be after starts_at")
+		return
+	}
+	if !req.EndsAt.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "Override has already ended")
+		return
+	}
+
+	schedule, ok := app.loadOnCallSchedule(w, r.Context(), mux.Vars(r)["scheduleId"])
+	if !ok {
+		return
+	}
+
+	role, err := app.getTeamRole(schedule.TeamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check user role")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	// Members may cover shifts themselves; only admins can assign others
+	if req.UserID != claims.UserID && role != "owner" && role != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only team owners and admins can assign overrides to other members")
+		return
+	}
+
+	if !app.requireScheduleMembers(w, r.Context(), schedule.TeamID, []string{req.UserID}) {
+		return
+	}
+
+	override := domain.OnCallOverride{
+		ID:         uuid.New().String(),
+		ScheduleID: schedule.ID,
+		UserID:     req.UserID,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		CreatedBy:  claims.UserID,
+		CreatedAt:  time.Now(),
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO oncall_overrides (id, schedule_id, user_id, starts_at, ends_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, override.ID, override.ScheduleID, override.UserID, override.StartsAt, override.EndsAt,
+		override.CreatedBy, override.CreatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create on-call override")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create on-call override")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, override)
+}
+
+func (app *Application) deleteOnCallOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	overrideID := mux.Vars(r)["overrideId"]
+
+	var teamID, createdBy string
+	err := app.DB.QueryRow(`
+		SELECT s.team_id, o.created_by
+		FROM oncall_overrides o
+		JOIN oncall_schedules s ON s.id = o.schedule_id
+		WHERE o.id = $1
+	`, overrideID).Scan(&teamID, &createdBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Override not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get on-call override")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if createdBy != claims.UserID && !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM oncall_overrides WHERE id = $1`, overrideID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete on-call override")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete on-call override")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Override deleted successfully"})
+}
+
+// getOnCallCalendarHandler exports the upcoming shifts as an iCalendar feed
+// so members can subscribe from their calendar app.
+func (app *Application) getOnCallCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	days := defaultCalendarDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxCalendarDays {
+			respondWithError(w, http.StatusBadRequest, "days must be between 1 and 180")
+			return
+		}
+		days = parsed
+	}
+
+	schedule, ok := app.loadOnCallSchedule(w, r.Context(), mux.Vars(r)["scheduleId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamMember(w, schedule.TeamID, claims.UserID) {
+		return
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	shifts := oncall.Shifts(schedule, now, now.AddDate(0, 0, days))
+
+	names, err := app.userDisplayNames(r.Context(), schedule)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get on-call member names")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="oncall.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(oncall.Calendar(schedule, shifts, names)))
+}
+
+func (app *Application) loadOnCallSchedule(w http.ResponseWriter, ctx context.Context, scheduleID string) (domain.OnCallSchedule, bool) {
+	schedule, err := oncall.LoadSchedule(ctx, app.DB, scheduleID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "On-call schedule not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get on-call schedule")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return schedule, false
+	}
+
+	return schedule, true
+}
+
+func (app *Application) loadTeamSchedules(ctx context.Context, teamID string) ([]domain.OnCallSchedule, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT `+oncall.ScheduleColumns+`
+		FROM oncall_schedules
+		WHERE team_id = $1
+		ORDER BY name
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := []domain.OnCallSchedule{}
+	for rows.Next() {
+		schedule, err := oncall.ScanSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range schedules {
+		if err := oncall.LoadScheduleDetails(ctx, app.DB, &schedules[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return schedules, nil
+}
+
+// requireScheduleMembers rejects user IDs that don't belong to the team.
+func (app *Application) requireScheduleMembers(w http.ResponseWriter, ctx context.Context, teamID string, userIDs []string) bool {
+	unique := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		unique[userID] = true
+	}
+
+	var count int
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM team_members WHERE team_id = $1 AND user_id::text = ANY($2)
+	`, teamID, pq.Array(userIDs)).Scan(&count)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check team membership")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return false
+	}
+
+	if count != len(unique) {
+		respondWithError(w, http.StatusBadRequest, "All on-call members must belong to the team")
+		return false
+	}
+
+	return true
+}
+
+func (app *Application) userDisplayNames(ctx context.Context, schedule domain.OnCallSchedule) (map[string]string, error) {
+	userIDs := append([]string(nil), schedule.Members...)
+	for _, override := range schedule.Overrides {
+		userIDs = append(userIDs, override.UserID)
+	}
+
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT id, username, first_name, last_name FROM users WHERE id::text = ANY($1)
+	`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]string, len(userIDs))
+	for rows.Next() {
+		var id, username, firstName, lastName string
+		if err := rows.Scan(&id, &username, &firstName, &lastName); err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSpace(firstName + " " + lastName)
+		if name == "" {
+			name = username
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
+
+func insertScheduleMembers(ctx context.Context, tx *sql.Tx, scheduleID string, members []string) error {
+	for position, userID := range members {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO oncall_schedule_members (schedule_id, position, user_id)
+			VALUES ($1, $2, $3)
+		`, scheduleID, position, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
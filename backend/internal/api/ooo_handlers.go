@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.-]+)`)
+
+// mentionedUsernames returns the distinct @usernames in a message.
+func mentionedUsernames(content string) []string {
+	seen := map[string]bool{}
+	usernames := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := strings.TrimRight(strings.ToLower(match[1]), ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+const outOfOfficeColumns = `user_id, starts_at, ends_at, message, delegate_id, updated_at, starts_at <= NOW()`
+
+func scanOutOfOffice(row rowScanner) (domain.OutOfOffice, error) {
+	var ooo domain.OutOfOffice
+	err := row.Scan(&ooo.UserID, &ooo.StartsAt, &ooo.EndsAt, &ooo.Message, &ooo.DelegateID, &ooo.UpdatedAt, &ooo.Active)
+	return ooo, err
+}
+
+func (app *Application) getOutOfOfficeHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	ooo, err := scanOutOfOffice(app.DB.QueryRow(`
+		SELECT `+outOfOfficeColumns+` FROM user_out_of_office WHERE user_id = $1 AND ends_at > NOW()
+	`, claims.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Not out of office")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get out of office")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ooo)
+}
+
+func (app *Application) updateOutOfOfficeHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateOutOfOffice
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt.IsZero() || !req.EndsAt.After(startsAt) || !req.EndsAt.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "ends_at must be in the future and after starts_at")
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if len(req.Message) > 1000 {
+		respondWithError(w, http.StatusBadRequest, "Message must be at most 1000 characters")
+		return
+	}
+
+	if req.DelegateID != nil {
+		if *req.DelegateID == claims.UserID {
+			respondWithError(w, http.StatusBadRequest, "You can't delegate to yourself")
+			return
+		}
+
+		// Delegates must be active and share a team with the user
+		var shared bool
+		err := app.DB.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM team_members a
+				JOIN team_members b ON b.team_id = a.team_id
+				JOIN users u ON u.id = b.user_id AND u.is_active = true
+				WHERE a.user_id = $1 AND b.user_id = $2
+			)
+		`, claims.UserID, *req.DelegateID).Scan(&shared)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to check delegate")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if !shared {
+			respondWithError(w, http.StatusBadRequest, "Delegate must be an active member of one of your teams")
+			return
+		}
+	}
+
+	ooo, err := scanOutOfOffice(app.DB.QueryRow(`
+		INSERT INTO user_out_of_office (user_id, starts_at, ends_at, message, delegate_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET starts_at = EXCLUDED.starts_at, ends_at = EXCLUDED.ends_at,
+		    message = EXCLUDED.message, delegate_id = EXCLUDED.delegate_id
+		RETURNING `+outOfOfficeColumns, claims.UserID, startsAt, req.EndsAt, req.Message, req.DelegateID))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update out of office")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update out of office")
+		return
+	}
+
+	app.WSHub.SetOutOfOffice(claims.UserID, ooo.Active)
+
+	respondWithJSON(w, http.StatusOK, ooo)
+}
+
+func (app *Application) deleteOutOfOfficeHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM user_out_of_office WHERE user_id = $1`, claims.UserID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete out of office")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete out of office")
+		return
+	}
+
+	app.WSHub.SetOutOfOffice(claims.UserID, false)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Welcome back"})
+}
+
+// outOfOfficeWarning describes why assigning a task to the user may not be a
+// good idea right now, offering their delegate instead. It returns nil when
+// the user isn't away.
+func (app *Application) outOfOfficeWarning(ctx context.Context, userID string) map[string]interface{} {
+	away, err := app.Users.OutOfOffice(ctx, []string{userID})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check out of office")
+		return nil
+	}
+	ooo, ok := away[userID]
+	if !ok {
+		return nil
+	}
+
+	warning := map[string]interface{}{
+		"code":    "assignee_out_of_office",
+		"message": "The assignee is out of office until " + ooo.EndsAt.UTC().Format("Mon 2 Jan 15:04 MST"),
+		"until":   ooo.EndsAt,
+	}
+
+	if ooo.DelegateID != nil {
+		delegate, err := app.Users.Get(ctx, *ooo.DelegateID)
+		if err == nil {
+			warning["delegate"] = map[string]interface{}{
+				"user_id":    delegate.ID,
+				"username":   delegate.Username,
+				"first_name": delegate.FirstName,
+				"last_name":  delegate.LastName,
+			}
+		} else if err != sql.ErrNoRows {
+			app.Logger.WithError(err).Error("Failed to get delegate")
+		}
+	}
+
+	return warning
+}
+
+// replyOutOfOffice answers DMs and mentions of users who are away with their
+// out-of-office message, once per sender for each absence. Replies are
+// system messages, which this handler ignores, so they can't set each other
+// off.
+func (app *Application) replyOutOfOffice(ctx context.Context, event events.Event) {
+	message, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	channelID, _ := message["channel_id"].(string)
+	senderID, _ := message["sender_id"].(string)
+	content, _ := message["content"].(string)
+	if message["type"] == string(domain.MessageTypeSystem) || channelID == "" || senderID == "" {
+		return
+	}
+
+	// Everyone else in a DM, plus whoever was mentioned
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT cm.user_id FROM channel_members cm
+		JOIN channels c ON c.id = cm.channel_id AND c.type = 'direct'
+		WHERE cm.channel_id = $1 AND cm.user_id <> $2
+		UNION
+		SELECT u.id FROM users u
+		JOIN team_members tm ON tm.user_id = u.id AND tm.team_id = $3
+		WHERE LOWER(u.username) = ANY($4) AND u.id <> $2
+	`, channelID, senderID, event.TeamID, pq.Array(mentionedUsernames(content)))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get message recipients")
+		return
+	}
+	recipients := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err == nil {
+			recipients = append(recipients, userID)
+		}
+	}
+	rows.Close()
+
+	away, err := app.Users.OutOfOffice(ctx, recipients)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check out of office")
+		return
+	}
+
+	for userID, ooo := range away {
+		result, err := app.DB.ExecContext(ctx, `
+			INSERT INTO out_of_office_replies (user_id, sender_id, period_start)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, userID, senderID, ooo.StartsAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to record out of office reply")
+			continue
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 0 {
+			continue
+		}
+
+		if _, err := app.createMessage(ctx, event.TeamID, channelID, userID, app.outOfOfficeReply(ctx, ooo), string(domain.MessageTypeSystem)); err != nil {
+			app.Logger.WithError(err).Error("Failed to post out of office reply")
+		}
+	}
+}
+
+func (app *Application) outOfOfficeReply(ctx context.Context, ooo domain.OutOfOffice) string {
+	text := fmt.Sprintf("🌴 Out of office until %s.", ooo.EndsAt.UTC().Format("Mon 2 Jan 15:04 MST"))
+	if ooo.Message != "" {
+		text += " " + ooo.Message
+	}
+	if ooo.DelegateID != nil {
+		var username string
+		err := app.DB.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1 AND is_active = true`, *ooo.DelegateID).Scan(&username)
+		if err == nil {
+			text += " For anything urgent, contact @" + username + "."
+		}
+	}
+	return text
+}
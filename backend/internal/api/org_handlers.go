@@ -0,0 +1,366 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const (
+	defaultDirectoryPageSize = 25
+	maxDirectoryPageSize     = 100
+)
+
+var orgSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,49}$`)
+
+// getOrgRole returns the caller's role in the organization, or sql.ErrNoRows
+// if they are not a member.
+func (app *Application) getOrgRole(orgID, userID string) (string, error) {
+	var role string
+	err := app.DB.QueryRow(`
+		SELECT role FROM organization_members WHERE org_id = $1 AND user_id = $2
+	`, orgID, userID).Scan(&role)
+	return role, err
+}
+
+func isOrgAdmin(role string) bool {
+	return role == string(domain.OrgRoleOwner) || role == string(domain.OrgRoleAdmin)
+}
+
+func (app *Application) createOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateOrganization
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Organization name is required")
+		return
+	}
+	if !orgSlugPattern.MatchString(req.Slug) {
+		respondWithError(w, http.StatusBadRequest, "Slug must be 2-50 lowercase letters, digits or dashes")
+		return
+	}
+
+	org := domain.Organization{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Slug:      req.Slug,
+		OwnerID:   claims.UserID,
+		Role:      string(domain.OrgRoleOwner),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := app.DB.RunInTransaction(r.Context(), func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO organizations (id, name, slug, owner_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, org.ID, org.Name, org.Slug, org.OwnerID, org.CreatedAt, org.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO organization_members (org_id, user_id, role, joined_at)
+			VALUES ($1, $2, 'owner', NOW())
+		`, org.ID, claims.UserID)
+		return err
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "An organization with this slug already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create organization")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create organization")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, org)
+}
+
+func (app *Application) getOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT o.id, o.name, o.slug, o.owner_id, om.role, o.created_at, o.updated_at
+		FROM organizations o
+		JOIN organization_members om ON om.org_id = o.id
+		WHERE om.user_id = $1
+		ORDER BY o.name
+	`, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get organizations")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	orgs := []domain.Organization{}
+	for rows.Next() {
+		var org domain.Organization
+		err := rows.Scan(&org.ID, &org.Name, &org.Slug, &org.OwnerID, &org.Role, &org.CreatedAt, &org.UpdatedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan organization row")
+			continue
+		}
+		orgs = append(orgs, org)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating organization rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, orgs)
+}
+
+func (app *Application) addOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	orgID := vars["orgId"]
+
+	var req domain.AddOrganizationMember
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Email == "" && req.Username == "" {
+		respondWithError(w, http.StatusBadRequest, "Email or username is required")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = domain.OrgRoleMember
+	}
+	if req.Role != domain.OrgRoleAdmin && req.Role != domain.OrgRoleMember {
+		respondWithError(w, http.StatusBadRequest, "Invalid role")
+		return
+	}
+
+	role, err := app.getOrgRole(orgID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this organization")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check organization role")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !isOrgAdmin(role) {
+		respondWithError(w, http.StatusForbidden, "Only organization owners and admins can add members")
+		return
+	}
+
+	var userID string
+	err = app.DB.QueryRow(`
+		SELECT id FROM users
+		WHERE is_active = true AND (($1 <> '' AND username = $1) OR ($1 = '' AND email = $2))
+	`, req.Username, req.Email).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to find user")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO organization_members (org_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, NOW())
+	`, orgID, userID, string(req.Role))
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "User is already a member of this organization")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to add organization member")
+		respondWithError(w, http.StatusInternalServerError, "Failed to add organization member")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "Organization member added successfully",
+		"user_id": userID,
+		"role":    req.Role,
+	})
+}
+
+func (app *Application) getDirectoryHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	orgID := vars["orgId"]
+
+	role, err := app.getOrgRole(orgID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this organization")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check organization role")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	page, perPage := directoryPage(r.URL.Query())
+
+	search := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	viewerIsAdmin := isOrgAdmin(role)
+
+	// Hidden emails must not be searchable by members who can't see them
+	rows, err := app.DB.Query(`
+		SELECT u.id, u.username, u.first_name, u.last_name, u.avatar, u.email, u.phone,
+		       u.email_visible, u.phone_visible, om.role, om.joined_at, u.last_seen,
+		       COUNT(*) OVER ()
+		FROM organization_members om
+		JOIN users u ON u.id = om.user_id
+		WHERE om.org_id = $1 AND u.is_active = true
+		  AND ($2 = '' OR lower(u.username) LIKE '%' || $2 || '%'
+		       OR lower(u.first_name || ' ' || u.last_name) LIKE '%' || $2 || '%'
+		       OR (($3 OR u.email_visible OR u.id = $4) AND lower(u.email) LIKE '%' || $2 || '%'))
+		ORDER BY lower(u.first_name), lower(u.last_name), u.username
+		LIMIT $5 OFFSET $6
+	`, orgID, search, viewerIsAdmin, claims.UserID, perPage, (page-1)*perPage)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get directory")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	entries := []domain.DirectoryEntry{}
+	total := 0
+	for rows.Next() {
+		var profile domain.DirectoryProfile
+		err := rows.Scan(&profile.UserID, &profile.Username, &profile.FirstName, &profile.LastName,
+			&profile.Avatar, &profile.Email, &profile.Phone, &profile.Visibility.EmailVisible,
+			&profile.Visibility.PhoneVisible, &profile.Role, &profile.JoinedAt, &profile.LastSeen, &total)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan directory row")
+			continue
+		}
+		entries = append(entries, profile.ForViewer(claims.UserID, viewerIsAdmin))
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating directory rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"users":    entries,
+		"page":     page,
+		"per_page": perPage,
+		"total":    total,
+	})
+}
+
+// directoryPage reads which page of the directory to show. Missing or bad
+// values fall back to the first page of the default size, and pages are
+// never larger than the maximum.
+func directoryPage(query url.Values) (page, perPage int) {
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err = strconv.Atoi(query.Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultDirectoryPageSize
+	}
+	if perPage > maxDirectoryPageSize {
+		perPage = maxDirectoryPageSize
+	}
+	return page, perPage
+}
+
+func (app *Application) getProfileVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var visibility domain.ProfileVisibility
+	err := app.DB.QueryRow(`
+		SELECT email_visible, phone_visible FROM users WHERE id = $1
+	`, claims.UserID).Scan(&visibility.EmailVisible, &visibility.PhoneVisible)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get profile visibility")
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, visibility)
+}
+
+func (app *Application) updateProfileVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateProfileVisibility
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var visibility domain.ProfileVisibility
+	err := app.DB.QueryRow(`
+		UPDATE users
+		SET email_visible = COALESCE($2, email_visible),
+		    phone_visible = COALESCE($3, phone_visible)
+		WHERE id = $1
+		RETURNING email_visible, phone_visible
+	`, claims.UserID, req.EmailVisible, req.PhoneVisible).Scan(&visibility.EmailVisible, &visibility.PhoneVisible)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update profile visibility")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update profile visibility")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, visibility)
+}
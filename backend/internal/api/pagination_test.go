@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestMessageLimit(t *testing.T) {
+	tests := []struct {
+		param string
+		want  int
+		ok    bool
+	}{
+		{param: "", want: 50, ok: true},
+		{param: "1", want: 1, ok: true},
+		{param: "200", want: 200, ok: true},
+		{param: "0", ok: false},
+		{param: "201", ok: false},
+		{param: "-5", ok: false},
+		{param: "ten", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := messageLimit(tt.param)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("messageLimit(%q) = %d, %v, want %d, %v", tt.param, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDirectoryPage(t *testing.T) {
+	tests := []struct {
+		query      string
+		page, size int
+		wantOffset int
+	}{
+		{query: "", page: 1, size: defaultDirectoryPageSize, wantOffset: 0},
+		{query: "page=3&per_page=10", page: 3, size: 10, wantOffset: 20},
+		{query: "page=0&per_page=0", page: 1, size: defaultDirectoryPageSize, wantOffset: 0},
+		{query: "page=-2&per_page=-1", page: 1, size: defaultDirectoryPageSize, wantOffset: 0},
+		{query: "page=two&per_page=many", page: 1, size: defaultDirectoryPageSize, wantOffset: 0},
+		{query: "page=2&per_page=1000", page: 2, size: maxDirectoryPageSize, wantOffset: maxDirectoryPageSize},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, perPage := directoryPage(query)
+		if page != tt.page || perPage != tt.size || (page-1)*perPage != tt.wantOffset {
+			t.Errorf("directoryPage(%q) = page %d of %d, want page %d of %d at offset %d",
+				tt.query, page, perPage, tt.page, tt.size, tt.wantOffset)
+		}
+	}
+}
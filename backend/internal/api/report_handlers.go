@@ -0,0 +1,411 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/reports"
+	"github.com/cbalite/backend/internal/scheduler"
+)
+
+// Reports without a previous run cover the last week of completions
+const defaultReportWindow = 7 * 24 * time.Hour
+
+const taskReportColumns = `id, team_id, channel_id, name, weekdays, time_of_day, timezone, is_enabled,
+		       next_run_at, last_run_at, created_by, created_at, updated_at`
+
+func scanTaskReport(row rowScanner) (domain.TaskReport, error) {
+	var report domain.TaskReport
+	var weekdays pq.StringArray
+	err := row.Scan(&report.ID, &report.TeamID, &report.ChannelID, &report.Name, &weekdays, &report.TimeOfDay,
+		&report.Timezone, &report.IsEnabled, &report.NextRunAt, &report.LastRunAt, &report.CreatedBy,
+		&report.CreatedAt, &report.UpdatedAt)
+	report.Weekdays = []string(weekdays)
+	return report, err
+}
+
+// checkReportChannel makes sure a report posts into a shared channel of its
+// own team.
+func (app *Application) checkReportChannel(w http.ResponseWriter, teamID, channelID string) bool {
+	var channelType string
+	err := app.DB.QueryRow(`SELECT type FROM channels WHERE id = $1 AND team_id = $2`, channelID, teamID).Scan(&channelType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "Channel not found in this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return false
+	}
+	if channelType == string(domain.ChannelTypeDirect) {
+		respondWithError(w, http.StatusBadRequest, "Reports can't be posted into direct messages")
+		return false
+	}
+	return true
+}
+
+func (app *Application) createTaskReportHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateTaskReport
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		respondWithError(w, http.StatusBadRequest, "Report name is required and must be at most 100 characters")
+		return
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+
+	schedule, err := scheduler.ParseWeekly(req.Weekdays, req.TimeOfDay, req.Timezone)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+	if !app.checkReportChannel(w, teamID, req.ChannelID) {
+		return
+	}
+
+	now := time.Now()
+	report := domain.TaskReport{
+		ID:        uuid.New().String(),
+		TeamID:    teamID,
+		ChannelID: req.ChannelID,
+		Name:      req.Name,
+		Weekdays:  req.Weekdays,
+		TimeOfDay: req.TimeOfDay,
+		Timezone:  req.Timezone,
+		IsEnabled: true,
+		NextRunAt: schedule.Next(now),
+		CreatedBy: claims.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO task_reports (id, team_id, channel_id, name, weekdays, time_of_day, timezone, is_enabled,
+		                          next_run_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, report.ID, report.TeamID, report.ChannelID, report.Name, pq.Array(report.Weekdays), report.TimeOfDay,
+		report.Timezone, report.IsEnabled, report.NextRunAt, report.CreatedBy, report.CreatedAt, report.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create task report")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create task report")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, report)
+}
+
+func (app *Application) getTaskReportsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT `+taskReportColumns+`
+		FROM task_reports
+		WHERE team_id = $1
+		ORDER BY name
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get task reports")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	taskReports := []domain.TaskReport{}
+	for rows.Next() {
+		report, err := scanTaskReport(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan task report row")
+			continue
+		}
+		taskReports = append(taskReports, report)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating task report rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, taskReports)
+}
+
+// loadTaskReportForAdmin gets a report for one of its team's admins, writing
+// the error response itself when it can't.
+func (app *Application) loadTaskReportForAdmin(w http.ResponseWriter, reportID, userID string) (domain.TaskReport, bool) {
+	report, err := scanTaskReport(app.DB.QueryRow(`SELECT `+taskReportColumns+` FROM task_reports WHERE id = $1`, reportID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task report not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task report")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return report, false
+	}
+
+	if !app.requireTeamAdmin(w, report.TeamID, userID) {
+		return report, false
+	}
+
+	return report, true
+}
+
+func (app *Application) updateTaskReportHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateTaskReport
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	report, ok := app.loadTaskReportForAdmin(w, mux.Vars(r)["reportId"], claims.UserID)
+	if !ok {
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 100 {
+			respondWithError(w, http.StatusBadRequest, "Report name is required and must be at most 100 characters")
+			return
+		}
+		report.Name = name
+	}
+	if req.ChannelID != nil {
+		if !app.checkReportChannel(w, report.TeamID, *req.ChannelID) {
+			return
+		}
+		report.ChannelID = *req.ChannelID
+	}
+	if req.Weekdays != nil {
+		report.Weekdays = req.Weekdays
+	}
+	if req.TimeOfDay != nil {
+		report.TimeOfDay = *req.TimeOfDay
+	}
+	if req.Timezone != nil {
+		report.Timezone = *req.Timezone
+	}
+	if req.IsEnabled != nil {
+		report.IsEnabled = *req.IsEnabled
+	}
+
+	schedule, err := scheduler.ParseWeekly(report.Weekdays, report.TimeOfDay, report.Timezone)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	report.NextRunAt = schedule.Next(time.Now())
+
+	err = app.DB.QueryRow(`
+		UPDATE task_reports
+		SET channel_id = $2, name = $3, weekdays = $4, time_of_day = $5, timezone = $6, is_enabled = $7, next_run_at = $8
+		WHERE id = $1
+		RETURNING updated_at
+	`, report.ID, report.ChannelID, report.Name, pq.Array(report.Weekdays), report.TimeOfDay, report.Timezone,
+		report.IsEnabled, report.NextRunAt).Scan(&report.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update task report")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update task report")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+func (app *Application) deleteTaskReportHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	report, ok := app.loadTaskReportForAdmin(w, mux.Vars(r)["reportId"], claims.UserID)
+	if !ok {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM task_reports WHERE id = $1`, report.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete task report")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete task report")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Task report deleted successfully"})
+}
+
+// runTaskReportHandler posts a report straight away, without moving its
+// schedule, so admins can see what it will look like.
+func (app *Application) runTaskReportHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	report, ok := app.loadTaskReportForAdmin(w, mux.Vars(r)["reportId"], claims.UserID)
+	if !ok {
+		return
+	}
+
+	message, err := app.postTaskReport(r.Context(), report, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to post task report")
+		respondWithError(w, http.StatusInternalServerError, "Failed to post task report")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, message)
+}
+
+// runDueTaskReports is the scheduler job posting every report that is due.
+// Each report is moved to its next run before posting, so a report is never
+// posted twice for the same slot, even with several servers.
+func (app *Application) runDueTaskReports(ctx context.Context) error {
+	for {
+		var report domain.TaskReport
+		err := app.DB.RunInTransaction(ctx, func(tx *sql.Tx) error {
+			var err error
+			report, err = scanTaskReport(tx.QueryRowContext(ctx, `
+				SELECT `+taskReportColumns+`
+				FROM task_reports
+				WHERE is_enabled = true AND next_run_at <= NOW()
+				ORDER BY next_run_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			`))
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			next := now.Add(7 * 24 * time.Hour)
+			if schedule, err := scheduler.ParseWeekly(report.Weekdays, report.TimeOfDay, report.Timezone); err == nil {
+				next = schedule.Next(now)
+			} else {
+				app.Logger.WithError(err).Warnf("Task report %s has an invalid schedule", report.ID)
+			}
+
+			_, err = tx.ExecContext(ctx, `UPDATE task_reports SET next_run_at = $2, last_run_at = $3 WHERE id = $1`, report.ID, next, now)
+			return err
+		})
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := app.postTaskReport(ctx, report, report.CreatedBy); err != nil {
+			app.Logger.WithError(err).Errorf("Failed to post task report %s", report.ID)
+		}
+	}
+}
+
+// postTaskReport builds the report's digest and posts it as a system
+// message from senderID.
+func (app *Application) postTaskReport(ctx context.Context, report domain.TaskReport, senderID string) (map[string]interface{}, error) {
+	location, err := time.LoadLocation(report.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	now := time.Now()
+	digest := reports.Digest{
+		Name:           report.Name,
+		CompletedSince: now.Add(-defaultReportWindow),
+		GeneratedAt:    now,
+		Location:       location,
+	}
+	if report.LastRunAt != nil && report.LastRunAt.Before(now) {
+		digest.CompletedSince = *report.LastRunAt
+	}
+
+	err = app.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM tasks WHERE team_id = $1 AND status IN ('todo', 'in_progress', 'review') AND deleted_at IS NULL
+	`, report.TeamID).Scan(&digest.Open)
+	if err != nil {
+		return nil, err
+	}
+
+	if digest.Overdue, err = app.reportItems(ctx, `
+		WHERE t.team_id = $1 AND t.status IN ('todo', 'in_progress', 'review') AND t.due_date < $2 AND t.deleted_at IS NULL
+		ORDER BY t.due_date
+	`, report.TeamID, now); err != nil {
+		return nil, err
+	}
+
+	if digest.Completed, err = app.reportItems(ctx, `
+		WHERE t.team_id = $1 AND t.status = 'done' AND t.completed_at >= $2 AND t.deleted_at IS NULL
+		ORDER BY t.completed_at
+	`, report.TeamID, digest.CompletedSince); err != nil {
+		return nil, err
+	}
+
+	return app.createMessage(ctx, report.TeamID, report.ChannelID, senderID, digest.Render(), string(domain.MessageTypeSystem))
+}
+
+func (app *Application) reportItems(ctx context.Context, where string, args ...interface{}) ([]reports.Item, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT t.title, t.priority, t.due_date, COALESCE(u.username, '')
+		FROM tasks t
+		LEFT JOIN users u ON u.id = t.assignee_id
+	`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []reports.Item{}
+	for rows.Next() {
+		var item reports.Item
+		if err := rows.Scan(&item.Title, &item.Priority, &item.DueDate, &item.Assignee); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
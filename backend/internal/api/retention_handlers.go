@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+func (app *Application) loadRetentionPolicy(ctx context.Context, teamID string) (domain.RetentionPolicy, error) {
+	policy := domain.RetentionPolicy{TeamID: teamID}
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT message_retention_days, file_retention_days, updated_at
+		FROM team_retention_policies
+		WHERE team_id = $1
+	`, teamID).Scan(&policy.MessageRetentionDays, &policy.FileRetentionDays, &policy.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return policy, nil
+	}
+	return policy, err
+}
+
+// retentionDays turns a period from a request into what's stored, where 0
+// means keep forever.
+func retentionDays(days int) *int {
+	if days == 0 {
+		return nil
+	}
+	return &days
+}
+
+func retentionCutoff(now time.Time, days *int) *time.Time {
+	if days == nil {
+		return nil
+	}
+	cutoff := now.AddDate(0, 0, -*days)
+	return &cutoff
+}
+
+func (app *Application) getRetentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	policy, err := app.loadRetentionPolicy(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get retention policy")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, policy)
+}
+
+func (app *Application) updateRetentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.UpdateRetentionPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if (req.MessageRetentionDays != nil && *req.MessageRetentionDays < 0) ||
+		(req.FileRetentionDays != nil && *req.FileRetentionDays < 0) {
+		respondWithError(w, http.StatusBadRequest, "Retention periods cannot be negative")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	policy, err := app.loadRetentionPolicy(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get retention policy")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if req.MessageRetentionDays != nil {
+		policy.MessageRetentionDays = retentionDays(*req.MessageRetentionDays)
+	}
+	if req.FileRetentionDays != nil {
+		policy.FileRetentionDays = retentionDays(*req.FileRetentionDays)
+	}
+
+	err = app.DB.QueryRow(`
+		INSERT INTO team_retention_policies (team_id, message_retention_days, file_retention_days, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (team_id) DO UPDATE
+		SET message_retention_days = EXCLUDED.message_retention_days,
+		    file_retention_days = EXCLUDED.file_retention_days,
+		    updated_by = EXCLUDED.updated_by
+		RETURNING updated_at
+	`, teamID, policy.MessageRetentionDays, policy.FileRetentionDays, claims.UserID).Scan(&policy.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update retention policy")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update retention policy")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, policy)
+}
+
+// retentionPreviewHandler counts what the team's retention policy would
+// purge if it ran now, per channel. message_days and file_days preview a
+// hypothetical policy instead (0 keeps forever). Nothing is deleted.
+func (app *Application) retentionPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	policy, err := app.loadRetentionPolicy(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get retention policy")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	preview := domain.RetentionPreview{Channels: []domain.ChannelRetentionImpact{}, GeneratedAt: time.Now()}
+
+	query := r.URL.Query()
+	for param, target := range map[string]**int{
+		"message_days": &policy.MessageRetentionDays,
+		"file_days":    &policy.FileRetentionDays,
+	} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid "+param+" parameter, expected a number of days (0 keeps forever)")
+			return
+		}
+		*target = retentionDays(days)
+		preview.Hypothetical = true
+	}
+	if preview.Hypothetical {
+		policy.UpdatedAt = nil
+	}
+
+	preview.Policy = policy
+	preview.MessageCutoff = retentionCutoff(preview.GeneratedAt, policy.MessageRetentionDays)
+	preview.FileCutoff = retentionCutoff(preview.GeneratedAt, policy.FileRetentionDays)
+
+	rows, err := app.DB.QueryContext(r.Context(), `
+		SELECT c.id, c.name, COUNT(m.id)
+		FROM channels c
+		LEFT JOIN messages m ON m.channel_id = c.id AND m.created_at < $2::timestamptz
+		WHERE c.team_id = $1
+		GROUP BY c.id, c.name
+		ORDER BY c.name
+	`, teamID, preview.MessageCutoff)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to count messages for retention preview")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	byChannel := map[string]int{}
+	for rows.Next() {
+		var impact domain.ChannelRetentionImpact
+		if err := rows.Scan(&impact.ChannelID, &impact.ChannelName, &impact.Messages); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan retention preview row")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		byChannel[impact.ChannelID] = len(preview.Channels)
+		preview.Channels = append(preview.Channels, impact)
+		preview.TotalMessages += impact.Messages
+	}
+	if err := rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating retention preview rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Files go when they pass the file period or with their message
+	fileRows, err := app.DB.QueryContext(r.Context(), `
+		SELECT m.channel_id, COUNT(a.id), COALESCE(SUM(a.file_size), 0)
+		FROM attachments a
+		JOIN messages m ON m.id = a.message_id
+		WHERE m.team_id = $1
+		  AND (m.created_at < $2::timestamptz OR a.created_at < $3::timestamptz)
+		GROUP BY m.channel_id
+	`, teamID, preview.MessageCutoff, preview.FileCutoff)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to count files for retention preview")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer fileRows.Close()
+
+	for fileRows.Next() {
+		var channelID string
+		var files int
+		var bytes int64
+		if err := fileRows.Scan(&channelID, &files, &bytes); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan retention preview row")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if i, ok := byChannel[channelID]; ok {
+			preview.Channels[i].Files = files
+			preview.Channels[i].FileBytes = bytes
+		}
+		preview.TotalFiles += files
+		preview.TotalBytes += bytes
+	}
+	if err := fileRows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating retention preview rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, preview)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/hooks"
+	"github.com/cbalite/backend/internal/schema"
+	"github.com/cbalite/backend/internal/websocket"
+)
+
+// Kinds of published schema.
+const (
+	schemaKindWebhook   = "webhooks"
+	schemaKindWebSocket = "websocket"
+)
+
+type schemaEntry struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Direction   string `json:"direction,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+// schemaCatalog holds the JSON Schemas for webhook payloads and WebSocket
+// frames. They're generated from the payload structs once, at startup.
+type schemaCatalog struct {
+	entries   []schemaEntry
+	documents map[string]*schema.Schema
+}
+
+func newSchemaCatalog(prefix string) *schemaCatalog {
+	c := &schemaCatalog{documents: make(map[string]*schema.Schema)}
+
+	for _, eventType := range hooks.SupportedEvents {
+		name := string(eventType)
+		c.add(prefix, schemaEntry{
+			Kind:        schemaKindWebhook,
+			Name:        name,
+			Description: "Body of " + name + " webhooks, sent with X-CBA-Event: " + name,
+		}, schema.Generate(events.Payloads[eventType]))
+	}
+
+	for _, event := range websocket.Events {
+		frame := schema.Generate(websocket.Message{})
+		frame.Properties["type"].Enum = []string{string(event.Type)}
+		if event.Data != nil {
+			frame.Properties["data"] = schema.Generate(event.Data)
+		}
+		c.add(prefix, schemaEntry{
+			Kind:        schemaKindWebSocket,
+			Name:        string(event.Type),
+			Direction:   event.Direction,
+			Description: event.Description,
+		}, frame)
+	}
+
+	return c
+}
+
+func (c *schemaCatalog) add(prefix string, entry schemaEntry, document *schema.Schema) {
+	key := entry.Kind + "/" + entry.Name
+	entry.URL = prefix + "/schemas/" + key
+
+	document.Dialect = schema.Dialect
+	document.ID = entry.URL
+	document.Title = entry.Name
+	document.Description = entry.Description
+
+	c.entries = append(c.entries, entry)
+	c.documents[key] = document
+}
+
+// listSchemasHandler indexes the published schemas. Like /meta it needs no
+// authentication, so integration authors can fetch schemas in their builds.
+func (c *schemaCatalog) listSchemasHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"schemas": c.entries,
+	})
+}
+
+func (c *schemaCatalog) getSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	document, ok := c.documents[vars["kind"]+"/"+vars["name"]]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Schema not found")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	respondWithJSON(w, http.StatusOK, document)
+}
@@ -0,0 +1,276 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cbalite/backend/internal/apitest"
+	"github.com/cbalite/backend/internal/events"
+	eventfakes "github.com/cbalite/backend/internal/events/fakes"
+	"github.com/cbalite/backend/pkg/client"
+)
+
+// team is a team on a test server with a client per member, by username.
+type team struct {
+	srv     *apitest.Server
+	id      string
+	clients map[string]*client.Client
+	ids     map[string]string
+}
+
+// newTeam registers owner and creates their team, then invites the others
+// with the given roles.
+func newTeam(t *testing.T, owner string, roles map[string]string) *team {
+	t.Helper()
+	ctx := context.Background()
+	tm := &team{srv: apitest.NewServer(t), clients: make(map[string]*client.Client), ids: make(map[string]string)}
+
+	tm.signUp(t, owner)
+	created, err := tm.clients[owner].CreateTeam(ctx, "Platform", "")
+	if err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	tm.id = created.ID
+
+	for username, role := range roles {
+		tm.signUp(t, username)
+		invitation := client.Invitation{Email: username + "@example.com", Role: role}
+		if _, err := tm.clients[owner].InviteMember(ctx, tm.id, invitation); err != nil {
+			t.Fatalf("InviteMember(%s): %v", username, err)
+		}
+	}
+	return tm
+}
+
+func (tm *team) signUp(t *testing.T, username string) {
+	t.Helper()
+	c := client.New(tm.srv.URL)
+	session, err := c.Register(context.Background(), client.Registration{
+		Email:    username + "@example.com",
+		Username: username,
+		Password: "correct horse battery",
+	})
+	if err != nil {
+		t.Fatalf("Register(%s): %v", username, err)
+	}
+	tm.clients[username] = c
+	tm.ids[username] = session.User.ID
+}
+
+func statusOf(err error) int {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	if err == nil {
+		return http.StatusOK
+	}
+	return 0
+}
+
+func TestEventsArePublished(t *testing.T) {
+	tm := newTeam(t, "olivia", nil)
+	ctx := context.Background()
+	recorder := eventfakes.NewRecorder(tm.srv.App.Events,
+		events.MemberJoined, events.MessagePosted, events.TaskCreated, events.TaskUpdated, events.TaskCommented)
+
+	olivia := tm.clients["olivia"]
+	tm.signUp(t, "max")
+	if _, err := olivia.InviteMember(ctx, tm.id, client.Invitation{Email: "max@example.com"}); err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	member := tm.clients["max"]
+
+	channels, err := member.Channels(ctx, tm.id)
+	if err != nil || len(channels) == 0 {
+		t.Fatalf("Channels = %v, %v", channels, err)
+	}
+	if _, err := member.SendMessage(ctx, channels[0].ID, client.NewMessage{Content: "hello"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	task, err := olivia.CreateTask(ctx, tm.id, client.NewTask{Title: "Rotate keys"})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	done := "done"
+	if _, err := member.UpdateTask(ctx, task.ID, client.TaskUpdate{Status: &done}); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if _, err := member.AddTaskComment(ctx, task.ID, "Rotated"); err != nil {
+		t.Fatalf("AddTaskComment: %v", err)
+	}
+
+	// Subscribers run concurrently, so only the set of events is certain,
+	// not their order
+	recorded := recorder.Wait(5, time.Second)
+	var got []string
+	for _, event := range recorded {
+		if event.TeamID != tm.id {
+			t.Errorf("%s for team %s, want %s", event.Type, event.TeamID, tm.id)
+		}
+		got = append(got, fmt.Sprintf("%s by %s", event.Type, event.ActorID))
+	}
+	want := []string{
+		"member.joined by " + tm.ids["olivia"],
+		"message.posted by " + tm.ids["max"],
+		"task.commented by " + tm.ids["max"],
+		"task.created by " + tm.ids["olivia"],
+		"task.updated by " + tm.ids["max"],
+	}
+	sort.Strings(got)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("published %v, want %v", got, want)
+	}
+}
+
+func TestFailedRequestsPublishNothing(t *testing.T) {
+	tm := newTeam(t, "olivia", map[string]string{"max": "member"})
+	ctx := context.Background()
+	recorder := eventfakes.NewRecorder(tm.srv.App.Events, events.TaskCreated, events.MessagePosted)
+
+	tm.srv.DB.Err = errors.New("database is down")
+	if _, err := tm.clients["olivia"].CreateTask(ctx, tm.id, client.NewTask{Title: "Rotate keys"}); statusOf(err) != http.StatusInternalServerError {
+		t.Errorf("CreateTask with the database down = %v, want 500", err)
+	}
+	tm.srv.DB.Err = nil
+
+	if _, err := tm.clients["max"].CreateTask(ctx, tm.id, client.NewTask{}); statusOf(err) != http.StatusBadRequest {
+		t.Errorf("CreateTask without a title = %v, want 400", err)
+	}
+	if got := recorder.Wait(1, 50*time.Millisecond); len(got) != 0 {
+		t.Errorf("published %d events for failed requests", len(got))
+	}
+}
+
+func TestTaskDeletePermissions(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdBy string
+		deletedBy string
+		want      int
+	}{
+		{name: "own task", createdBy: "max", deletedBy: "max", want: http.StatusOK},
+		{name: "another member's task", createdBy: "ana", deletedBy: "max", want: http.StatusForbidden},
+		{name: "admin", createdBy: "max", deletedBy: "ada", want: http.StatusOK},
+		{name: "owner", createdBy: "max", deletedBy: "olivia", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newTeam(t, "olivia", map[string]string{"ada": "admin", "max": "member", "ana": "member"})
+			ctx := context.Background()
+
+			task, err := tm.clients[tt.createdBy].CreateTask(ctx, tm.id, client.NewTask{Title: "Rotate keys"})
+			if err != nil {
+				t.Fatalf("CreateTask: %v", err)
+			}
+			if task.Permissions == nil || !task.Permissions.CanDelete {
+				t.Errorf("the creator's permissions = %+v, want can_delete", task.Permissions)
+			}
+
+			seen, err := tm.clients[tt.deletedBy].Task(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("Task: %v", err)
+			}
+			canDelete := seen.Permissions != nil && seen.Permissions.CanDelete
+			if canDelete != (tt.want == http.StatusOK) {
+				t.Errorf("%s sees can_delete %v, but deleting should answer %d", tt.deletedBy, canDelete, tt.want)
+			}
+
+			_, err = tm.clients[tt.deletedBy].DeleteTask(ctx, task.ID)
+			if got := statusOf(err); got != tt.want {
+				t.Errorf("DeleteTask by %s = %v, want %d", tt.deletedBy, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveMemberPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string
+		removed string
+		want    int
+	}{
+		{name: "admin removes a member", actor: "ada", removed: "max", want: http.StatusOK},
+		{name: "admin removes an admin", actor: "ada", removed: "alan", want: http.StatusForbidden},
+		{name: "owner removes an admin", actor: "olivia", removed: "alan", want: http.StatusOK},
+		{name: "member removes a member", actor: "max", removed: "ana", want: http.StatusForbidden},
+		{name: "admin removes the owner", actor: "ada", removed: "olivia", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newTeam(t, "olivia", map[string]string{"ada": "admin", "alan": "admin", "max": "member", "ana": "member"})
+
+			_, err := tm.clients[tt.actor].RemoveMember(context.Background(), tm.id, tm.ids[tt.removed])
+			if got := statusOf(err); got != tt.want {
+				t.Fatalf("RemoveMember = %v, want %d", err, tt.want)
+			}
+
+			members, err := tm.clients["olivia"].TeamMembers(context.Background(), tm.id)
+			if err != nil {
+				t.Fatalf("TeamMembers: %v", err)
+			}
+			stillMember := false
+			for _, member := range members {
+				if member.UserID == tm.ids[tt.removed] {
+					stillMember = true
+				}
+			}
+			if stillMember != (tt.want != http.StatusOK) {
+				t.Errorf("%s still a member: %v", tt.removed, stillMember)
+			}
+		})
+	}
+}
+
+func TestMessageHistoryPages(t *testing.T) {
+	tm := newTeam(t, "olivia", nil)
+	ctx := context.Background()
+	olivia := tm.clients["olivia"]
+	channels, err := olivia.Channels(ctx, tm.id)
+	if err != nil || len(channels) == 0 {
+		t.Fatalf("Channels = %v, %v", channels, err)
+	}
+	general := channels[0].ID
+
+	const sent = 60
+	for i := 0; i < sent; i++ {
+		if _, err := olivia.SendMessage(ctx, general, client.NewMessage{Content: fmt.Sprintf("message %d", i)}); err != nil {
+			t.Fatalf("SendMessage %d: %v", i, err)
+		}
+	}
+
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{limit: 0, want: 50},
+		{limit: 1, want: 1},
+		{limit: 10, want: 10},
+		{limit: 200, want: sent},
+	}
+	for _, tt := range tests {
+		messages, err := olivia.Messages(ctx, general, client.MessageQuery{Limit: tt.limit})
+		if err != nil {
+			t.Fatalf("Messages(limit %d): %v", tt.limit, err)
+		}
+		if len(messages) != tt.want {
+			t.Errorf("limit %d returned %d messages, want %d", tt.limit, len(messages), tt.want)
+			continue
+		}
+		// A page is the newest messages, oldest first
+		if messages[0].Content != fmt.Sprintf("message %d", sent-tt.want) ||
+			messages[len(messages)-1].Content != fmt.Sprintf("message %d", sent-1) {
+			t.Errorf("limit %d returned %q to %q", tt.limit, messages[0].Content, messages[len(messages)-1].Content)
+		}
+	}
+
+	if _, err := olivia.Messages(ctx, general, client.MessageQuery{Limit: 201}); statusOf(err) != http.StatusBadRequest {
+		t.Errorf("Messages(limit 201) = %v, want 400", err)
+	}
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/share"
+	"github.com/cbalite/backend/internal/token"
+)
+
+const (
+	// Public views are rendered at most once a minute per link; revoking a
+	// link drops its cached copy straight away.
+	shareCacheTTL      = time.Minute
+	sharedMessageLimit = 100
+)
+
+func shareCacheKey(tokenHash string) string {
+	return "share:" + tokenHash
+}
+
+func (app *Application) createShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateShareLink
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	switch req.Kind {
+	case domain.ShareLinkBoard:
+		req.ChannelID = nil
+	case domain.ShareLinkChannel:
+		if req.ChannelID == nil || *req.ChannelID == "" {
+			respondWithError(w, http.StatusBadRequest, "channel_id is required for channel links")
+			return
+		}
+	default:
+		respondWithError(w, http.StatusBadRequest, "kind must be board or channel")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if req.ChannelID != nil {
+		var channelType string
+		var isPrivate bool
+		err := app.DB.QueryRow(`
+			SELECT type, is_private FROM channels WHERE id = $1 AND team_id = $2
+		`, *req.ChannelID, teamID).Scan(&channelType, &isPrivate)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusBadRequest, "Channel not found in this team")
+			} else {
+				app.Logger.WithError(err).Error("Failed to get channel")
+				respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		if isPrivate || channelType == "direct" {
+			respondWithError(w, http.StatusBadRequest, "Private channels and direct messages can't be shared publicly")
+			return
+		}
+	}
+
+	secret, tokenHash, err := token.Generate()
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to generate share link token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create share link")
+		return
+	}
+
+	link := domain.ShareLink{
+		ID:        uuid.New().String(),
+		TeamID:    teamID,
+		Kind:      req.Kind,
+		ChannelID: req.ChannelID,
+		Token:     secret,
+		CreatedBy: claims.UserID,
+		CreatedAt: time.Now(),
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO share_links (id, team_id, kind, channel_id, token_hash, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, link.ID, link.TeamID, link.Kind, link.ChannelID, tokenHash, link.CreatedBy, link.CreatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create share link")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create share link")
+		return
+	}
+
+	// The token is only ever returned here; it's stored hashed
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"link": link,
+		"url":  "/api/v1/public/" + secret,
+	})
+}
+
+func (app *Application) getShareLinksHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT id, team_id, kind, channel_id, created_by, created_at, revoked_at
+		FROM share_links
+		WHERE team_id = $1
+		ORDER BY created_at
+	`, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get share links")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	links := []domain.ShareLink{}
+	for rows.Next() {
+		var link domain.ShareLink
+		err := rows.Scan(&link.ID, &link.TeamID, &link.Kind, &link.ChannelID, &link.CreatedBy, &link.CreatedAt, &link.RevokedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan share link row")
+			continue
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating share link rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, links)
+}
+
+func (app *Application) revokeShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	linkID := mux.Vars(r)["shareLinkId"]
+
+	var teamID, tokenHash string
+	err := app.DB.QueryRow(`SELECT team_id, token_hash FROM share_links WHERE id = $1`, linkID).Scan(&teamID, &tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Share link not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get share link")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	_, err = app.DB.Exec(`UPDATE share_links SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, linkID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to revoke share link")
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke share link")
+		return
+	}
+
+	if err := app.Cache.Delete(r.Context(), shareCacheKey(tokenHash)); err != nil {
+		app.Logger.WithError(err).Warn("Failed to drop cached share link view")
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Share link revoked successfully"})
+}
+
+// getPublicShareHandler serves a share link's read-only view. It is
+// unauthenticated; the token in the URL identifies the link.
+func (app *Application) getPublicShareHandler(w http.ResponseWriter, r *http.Request) {
+	tokenHash := token.Hash(mux.Vars(r)["token"])
+	cacheKey := shareCacheKey(tokenHash)
+
+	if cached, err := app.Cache.Get(r.Context(), cacheKey); err == nil {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(shareCacheTTL.Seconds())))
+		respondWithJSON(w, http.StatusOK, json.RawMessage(cached))
+		return
+	} else if err != cache.ErrCacheMiss {
+		app.Logger.WithError(err).Warn("Failed to read share link cache")
+	}
+
+	var link domain.ShareLink
+	err := app.DB.QueryRow(`
+		SELECT id, team_id, kind, channel_id
+		FROM share_links
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`, tokenHash).Scan(&link.ID, &link.TeamID, &link.Kind, &link.ChannelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Link not found or no longer shared")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get share link")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	view := share.View{Kind: string(link.Kind), GeneratedAt: time.Now()}
+	if link.Kind == domain.ShareLinkChannel {
+		view.Channel, err = app.loadSharedChannel(r.Context(), *link.ChannelID)
+	} else {
+		view.Board, err = app.loadSharedBoard(r.Context(), link.TeamID)
+	}
+	if err != nil {
+		app.Logger.WithError(err).Errorf("Failed to render share link %s", link.ID)
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if err := app.Cache.Set(r.Context(), cacheKey, view, shareCacheTTL); err != nil {
+		app.Logger.WithError(err).Warn("Failed to cache share link view")
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(shareCacheTTL.Seconds())))
+	respondWithJSON(w, http.StatusOK, view)
+}
+
+func (app *Application) loadSharedChannel(ctx context.Context, channelID string) (*share.Channel, error) {
+	meta, err := app.getChannelMeta(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	channel := &share.Channel{Name: meta.Name, Description: meta.Description, Messages: []share.Message{}}
+
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT m.id, m.content, m.is_pinned, m.created_at, u.username, u.first_name, u.last_name
+		FROM messages m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.channel_id = $1 AND m.is_deleted = false
+		ORDER BY m.created_at DESC
+		LIMIT $2
+	`, channelID, sharedMessageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var message share.Message
+		var username, firstName, lastName string
+		err := rows.Scan(&message.ID, &message.Content, &message.IsPinned, &message.CreatedAt, &username, &firstName, &lastName)
+		if err != nil {
+			return nil, err
+		}
+		message.Author = share.Author{Name: share.DisplayName(firstName, lastName, username)}
+		channel.Messages = append(channel.Messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Oldest first, like the channel view
+	for i, j := 0, len(channel.Messages)-1; i < j; i, j = i+1, j-1 {
+		channel.Messages[i], channel.Messages[j] = channel.Messages[j], channel.Messages[i]
+	}
+
+	return channel, nil
+}
+
+func (app *Application) loadSharedBoard(ctx context.Context, teamID string) (*share.Board, error) {
+	team, err := app.getTeamMeta(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	board := &share.Board{Team: team.Name, Tasks: []share.Task{}}
+
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT t.id, t.title, t.status, t.priority, t.due_date, u.username, u.first_name, u.last_name
+		FROM tasks t
+		LEFT JOIN users u ON t.assignee_id = u.id
+		WHERE t.team_id = $1 AND t.status <> 'cancelled' AND t.deleted_at IS NULL
+		ORDER BY t.created_at
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task share.Task
+		var username, firstName, lastName *string
+		err := rows.Scan(&task.ID, &task.Title, &task.Status, &task.Priority, &task.DueDate, &username, &firstName, &lastName)
+		if err != nil {
+			return nil, err
+		}
+		if username != nil {
+			task.Assignee = &share.Author{Name: share.DisplayName(*firstName, *lastName, *username)}
+		}
+		board.Tasks = append(board.Tasks, task)
+	}
+
+	return board, rows.Err()
+}
@@ -0,0 +1,385 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const (
+	snippetColumns              = `id, team_id, shortcut, title, content, usage_count, last_used_at, created_by, created_at, updated_at`
+	defaultSnippetAnalyticsDays = 30
+	maxSnippetAnalyticsDays     = 365
+)
+
+var shortcutPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// normalizeShortcut accepts "standup" or ";standup" and returns the stored form.
+func normalizeShortcut(shortcut string) (string, bool) {
+	shortcut = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(shortcut), ";"))
+	return shortcut, shortcutPattern.MatchString(shortcut)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSnippet(row rowScanner) (domain.Snippet, error) {
+	var snippet domain.Snippet
+	err := row.Scan(&snippet.ID, &snippet.TeamID, &snippet.Shortcut, &snippet.Title, &snippet.Content,
+		&snippet.UsageCount, &snippet.LastUsedAt, &snippet.CreatedBy, &snippet.CreatedAt, &snippet.UpdatedAt)
+	return snippet, err
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+func (app *Application) getSnippetsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	if _, err := app.getTeamRole(teamID, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	// Optional prefix search, used by clients to autocomplete ";shortcut"
+	search := strings.ToLower(strings.TrimPrefix(r.URL.Query().Get("q"), ";"))
+
+	rows, err := app.DB.Query(`
+		SELECT `+snippetColumns+`
+		FROM snippets
+		WHERE team_id = $1 AND ($2 = '' OR shortcut LIKE $2 || '%' OR title ILIKE '%' || $2 || '%')
+		ORDER BY shortcut
+	`, teamID, search)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get snippets")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	snippets := []domain.Snippet{}
+	for rows.Next() {
+		snippet, err := scanSnippet(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan snippet row")
+			continue
+		}
+		snippets = append(snippets, snippet)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating snippet rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, snippets)
+}
+
+func (app *Application) createSnippetHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	var req domain.CreateSnippet
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	shortcut, valid := normalizeShortcut(req.Shortcut)
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, "Shortcut must be 1-50 characters of a-z, 0-9, _ or -")
+		return
+	}
+
+	if strings.TrimSpace(req.Content) == "" {
+		respondWithError(w, http.StatusBadRequest, "Snippet content is required")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	snippet, err := scanSnippet(app.DB.QueryRow(`
+		INSERT INTO snippets (id, team_id, shortcut, title, content, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING `+snippetColumns,
+		uuid.New().String(), teamID, shortcut, req.Title, req.Content, claims.UserID))
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A snippet with this shortcut already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create snippet")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create snippet")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, snippet)
+}
+
+func (app *Application) updateSnippetHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	snippetID := vars["snippetId"]
+
+	var req domain.UpdateSnippet
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	snippet, err := scanSnippet(app.DB.QueryRow(`SELECT `+snippetColumns+` FROM snippets WHERE id = $1`, snippetID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Snippet not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get snippet")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, snippet.TeamID, claims.UserID) {
+		return
+	}
+
+	if req.Shortcut != nil {
+		shortcut, valid := normalizeShortcut(*req.Shortcut)
+		if !valid {
+			respondWithError(w, http.StatusBadRequest, "Shortcut must be 1-50 characters of a-z, 0-9, _ or -")
+			return
+		}
+		snippet.Shortcut = shortcut
+	}
+	if req.Title != nil {
+		snippet.Title = *req.Title
+	}
+	if req.Content != nil {
+		if strings.TrimSpace(*req.Content) == "" {
+			respondWithError(w, http.StatusBadRequest, "Snippet content is required")
+			return
+		}
+		snippet.Content = *req.Content
+	}
+
+	snippet, err = scanSnippet(app.DB.QueryRow(`
+		UPDATE snippets SET shortcut = $2, title = $3, content = $4
+		WHERE id = $1
+		RETURNING `+snippetColumns,
+		snippet.ID, snippet.Shortcut, snippet.Title, snippet.Content))
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A snippet with this shortcut already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to update snippet")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update snippet")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, snippet)
+}
+
+func (app *Application) deleteSnippetHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	snippetID := vars["snippetId"]
+
+	var teamID string
+	err := app.DB.QueryRow(`SELECT team_id FROM snippets WHERE id = $1`, snippetID).Scan(&teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Snippet not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get snippet")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM snippets WHERE id = $1`, snippetID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete snippet")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete snippet")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Snippet deleted successfully"})
+}
+
+func (app *Application) getSnippetAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	days := defaultSnippetAnalyticsDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSnippetAnalyticsDays {
+			respondWithError(w, http.StatusBadRequest, "days must be between 1 and 365")
+			return
+		}
+		days = parsed
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+
+	rows, err := app.DB.Query(`
+		SELECT s.id, s.shortcut, s.title, s.usage_count, s.last_used_at,
+		       COUNT(su.id) AS uses, COUNT(DISTINCT su.user_id) AS unique_users
+		FROM snippets s
+		LEFT JOIN snippet_usages su ON su.snippet_id = s.id AND su.used_at >= $2
+		WHERE s.team_id = $1
+		GROUP BY s.id
+		ORDER BY uses DESC, s.shortcut
+	`, teamID, since)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get snippet analytics")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	snippets := []map[string]interface{}{}
+	totalUses := 0
+	for rows.Next() {
+		var id, shortcut, title string
+		var usageCount, uses, uniqueUsers int
+		var lastUsedAt *time.Time
+
+		if err := rows.Scan(&id, &shortcut, &title, &usageCount, &lastUsedAt, &uses, &uniqueUsers); err != nil {
+			app.Logger.WithError(err).Error("Failed to scan snippet analytics row")
+			continue
+		}
+
+		snippets = append(snippets, map[string]interface{}{
+			"snippet_id":   id,
+			"shortcut":     shortcut,
+			"title":        title,
+			"uses":         uses,
+			"unique_users": uniqueUsers,
+			"total_uses":   usageCount,
+			"last_used_at": lastUsedAt,
+		})
+		totalUses += uses
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating snippet analytics rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"team_id":    teamID,
+		"since":      since,
+		"days":       days,
+		"total_uses": totalUses,
+		"snippets":   snippets,
+	})
+}
+
+// snippetCommand handles "/snippet <shortcut> [text]": it posts the snippet
+// content (followed by any extra text) as the caller and records the usage.
+func (app *Application) snippetCommand(ctx context.Context, inv commandInvocation) (map[string]interface{}, error) {
+	ref, extra, _ := strings.Cut(inv.Args, " ")
+	shortcut, valid := normalizeShortcut(ref)
+	if !valid {
+		return nil, &commandError{message: "Usage: /snippet <shortcut> [text]"}
+	}
+
+	var snippetID, content string
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT id, content FROM snippets WHERE team_id = $1 AND shortcut = $2
+	`, inv.TeamID, shortcut).Scan(&snippetID, &content)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &commandError{message: "Snippet ;" + shortcut + " not found"}
+		}
+		return nil, err
+	}
+
+	if extra = strings.TrimSpace(extra); extra != "" {
+		content += " " + extra
+	}
+
+	message, err := app.createMessage(ctx, inv.TeamID, inv.ChannelID, inv.UserID, content, string(domain.MessageTypeText))
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.DB.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE snippets SET usage_count = usage_count + 1, last_used_at = NOW() WHERE id = $1
+		`, snippetID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO snippet_usages (id, snippet_id, team_id, channel_id, user_id, used_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+		`, uuid.New().String(), snippetID, inv.TeamID, inv.ChannelID, inv.UserID)
+		return err
+	})
+	if err != nil {
+		// The message is already posted; losing one analytics row is acceptable
+		app.Logger.WithError(err).Error("Failed to record snippet usage")
+	}
+
+	return message, nil
+}
@@ -0,0 +1,538 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/inbound"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/statuspage"
+)
+
+const statusComponentColumns = `id, team_id, name, COALESCE(description, ''), status, external_id, created_at, updated_at`
+
+func (app *Application) createStatusComponentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateStatusComponent
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	component := domain.StatusComponent{
+		ID:          uuid.New().String(),
+		TeamID:      teamID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Status:      domain.ComponentOperational,
+		ExternalID:  req.ExternalID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if component.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Component name is required")
+		return
+	}
+	if req.Status != nil {
+		if !statuspage.IsValidStatus(*req.Status) {
+			respondWithError(w, http.StatusBadRequest, "Invalid component status")
+			return
+		}
+		component.Status = *req.Status
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	_, err := app.DB.Exec(`
+		INSERT INTO status_components (id, team_id, name, description, status, external_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, component.ID, component.TeamID, component.Name, component.Description, component.Status,
+		component.ExternalID, component.CreatedAt, component.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A component with this name or external ID already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create status component")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create status component")
+		return
+	}
+
+	app.refreshStatusCards(r.Context(), teamID, claims.UserID)
+
+	respondWithJSON(w, http.StatusCreated, component)
+}
+
+func (app *Application) getStatusComponentsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	components, err := app.loadStatusComponents(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get status components")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, components)
+}
+
+// updateStatusComponentHandler edits a component. Status changes are posted
+// to subscribed channels exactly like changes reported by a provider.
+func (app *Application) updateStatusComponentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateStatusComponent
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Status != nil && !statuspage.IsValidStatus(*req.Status) {
+		respondWithError(w, http.StatusBadRequest, "Invalid component status")
+		return
+	}
+	if req.Name != nil {
+		*req.Name = strings.TrimSpace(*req.Name)
+		if *req.Name == "" {
+			respondWithError(w, http.StatusBadRequest, "Component name cannot be empty")
+			return
+		}
+	}
+
+	component, ok := app.loadStatusComponent(w, r.Context(), mux.Vars(r)["componentId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, component.TeamID, claims.UserID) {
+		return
+	}
+
+	if req.Name != nil || req.Description != nil {
+		err := app.DB.QueryRow(`
+			UPDATE status_components
+			SET name = COALESCE($2, name), description = COALESCE($3, description)
+			WHERE id = $1
+			RETURNING `+statusComponentColumns+`
+		`, component.ID, req.Name, req.Description).Scan(&component.ID, &component.TeamID, &component.Name,
+			&component.Description, &component.Status, &component.ExternalID, &component.CreatedAt, &component.UpdatedAt)
+		if err != nil {
+			if isUniqueViolation(err) {
+				respondWithError(w, http.StatusConflict, "A component with this name already exists")
+				return
+			}
+			app.Logger.WithError(err).Error("Failed to update status component")
+			respondWithError(w, http.StatusInternalServerError, "Failed to update status component")
+			return
+		}
+	}
+
+	if req.Status != nil && *req.Status != component.Status {
+		updated, err := app.applyComponentStatus(r.Context(), component, *req.Status, req.Message, claims.UserID)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to update component status")
+			respondWithError(w, http.StatusInternalServerError, "Failed to update status component")
+			return
+		}
+		component = updated
+	} else if req.Name != nil {
+		app.refreshStatusCards(r.Context(), component.TeamID, claims.UserID)
+	}
+
+	respondWithJSON(w, http.StatusOK, component)
+}
+
+func (app *Application) deleteStatusComponentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	component, ok := app.loadStatusComponent(w, r.Context(), mux.Vars(r)["componentId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, component.TeamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM status_components WHERE id = $1`, component.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete status component")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete status component")
+		return
+	}
+
+	app.refreshStatusCards(r.Context(), component.TeamID, claims.UserID)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Status component deleted successfully"})
+}
+
+// createStatusSubscriptionHandler makes a channel receive status changes and
+// pins a status card in it.
+func (app *Application) createStatusSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateStatusSubscription
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChannelID == "" {
+		respondWithError(w, http.StatusBadRequest, "channel_id is required")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	channelTeamID, err := app.getChannelTeam(req.ChannelID, claims.UserID)
+	if err != nil || channelTeamID != teamID {
+		if err != nil && err != sql.ErrNoRows {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Channel not found in this team")
+		return
+	}
+
+	subscription := domain.StatusSubscription{
+		ID:        uuid.New().String(),
+		TeamID:    teamID,
+		ChannelID: req.ChannelID,
+		CreatedBy: claims.UserID,
+		CreatedAt: time.Now(),
+	}
+
+	_, err = app.DB.Exec(`
+		INSERT INTO status_subscriptions (id, team_id, channel_id, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, subscription.ID, subscription.TeamID, subscription.ChannelID, subscription.CreatedBy, subscription.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Channel is already subscribed")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create status subscription")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create status subscription")
+		return
+	}
+
+	app.refreshStatusCards(r.Context(), teamID, claims.UserID)
+
+	respondWithJSON(w, http.StatusCreated, subscription)
+}
+
+func (app *Application) getStatusSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamMember(w, teamID, claims.UserID) {
+		return
+	}
+
+	subscriptions, err := app.loadStatusSubscriptions(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get status subscriptions")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, subscriptions)
+}
+
+func (app *Application) deleteStatusSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	subscriptionID := mux.Vars(r)["subscriptionId"]
+
+	var teamID string
+	var cardMessageID *string
+	err := app.DB.QueryRow(`
+		SELECT team_id, card_message_id FROM status_subscriptions WHERE id = $1
+	`, subscriptionID).Scan(&teamID, &cardMessageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Status subscription not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get status subscription")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.Exec(`DELETE FROM status_subscriptions WHERE id = $1`, subscriptionID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete status subscription")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete status subscription")
+		return
+	}
+
+	if cardMessageID != nil {
+		if _, err := app.DB.Exec(`UPDATE messages SET is_pinned = false WHERE id = $1`, *cardMessageID); err != nil {
+			app.Logger.WithError(err).Error("Failed to unpin status card")
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Status subscription deleted successfully"})
+}
+
+// statusWebhookHandler adapts a provider payload parser into an inbound
+// webhook handler. Unknown components are added to the team's component
+// list the first time they're reported.
+func (app *Application) statusWebhookHandler(parse func([]byte) ([]statuspage.Update, error)) inbound.Handler {
+	return func(ctx context.Context, hook domain.InboundWebhook, body []byte) error {
+		updates, err := parse(body)
+		if err != nil {
+			return err
+		}
+
+		for _, update := range updates {
+			component, found, err := app.findStatusComponent(ctx, hook.TeamID, update)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				component = domain.StatusComponent{
+					ID:     uuid.New().String(),
+					TeamID: hook.TeamID,
+					Name:   update.Name,
+					Status: domain.ComponentOperational,
+				}
+				if update.ExternalID != "" {
+					component.ExternalID = &update.ExternalID
+				}
+
+				_, err := app.DB.ExecContext(ctx, `
+					INSERT INTO status_components (id, team_id, name, status, external_id, created_at, updated_at)
+					VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+				`, component.ID, component.TeamID, component.Name, component.Status, component.ExternalID)
+				if err != nil {
+					return err
+				}
+			}
+
+			if component.Status == update.Status {
+				if !found {
+					app.refreshStatusCards(ctx, hook.TeamID, hook.CreatedBy)
+				}
+				continue
+			}
+
+			if _, err := app.applyComponentStatus(ctx, component, update.Status, update.Message, hook.CreatedBy); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// applyComponentStatus records a component's new status, announces the change
+// in every subscribed channel and refreshes their status cards.
+func (app *Application) applyComponentStatus(ctx context.Context, component domain.StatusComponent, status domain.ComponentStatus, message, actorID string) (domain.StatusComponent, error) {
+	previous := component.Status
+
+	err := app.DB.QueryRowContext(ctx, `
+		UPDATE status_components SET status = $2 WHERE id = $1 RETURNING updated_at
+	`, component.ID, status).Scan(&component.UpdatedAt)
+	if err != nil {
+		return component, err
+	}
+	component.Status = status
+
+	subscriptions, err := app.loadStatusSubscriptions(ctx, component.TeamID)
+	if err != nil {
+		return component, err
+	}
+
+	content := statuspage.ChangeMessage(component, previous, message)
+	for _, subscription := range subscriptions {
+		_, err := app.createMessage(ctx, component.TeamID, subscription.ChannelID, actorID, content, string(domain.MessageTypeSystem))
+		if err != nil {
+			app.Logger.WithError(err).Errorf("Failed to post status change to channel %s", subscription.ChannelID)
+		}
+	}
+
+	app.refreshStatusCards(ctx, component.TeamID, actorID)
+	return component, nil
+}
+
+// refreshStatusCards rewrites the pinned status card in each subscribed
+// channel, posting a new card where there is none yet.
+func (app *Application) refreshStatusCards(ctx context.Context, teamID, actorID string) {
+	components, err := app.loadStatusComponents(ctx, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get status components")
+		return
+	}
+
+	subscriptions, err := app.loadStatusSubscriptions(ctx, teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get status subscriptions")
+		return
+	}
+
+	card := statuspage.Card(components)
+	for _, subscription := range subscriptions {
+		if subscription.CardMessageID != nil {
+			result, err := app.DB.ExecContext(ctx, `
+				UPDATE messages SET content = $2, is_edited = true, updated_at = NOW()
+				WHERE id = $1 AND is_deleted = false
+			`, *subscription.CardMessageID, card)
+			if err != nil {
+				app.Logger.WithError(err).Error("Failed to update status card")
+				continue
+			}
+			if updated, _ := result.RowsAffected(); updated > 0 {
+				continue
+			}
+		}
+
+		message, err := app.createMessage(ctx, teamID, subscription.ChannelID, actorID, card, string(domain.MessageTypeSystem))
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to post status card")
+			continue
+		}
+
+		_, err = app.DB.ExecContext(ctx, `
+			WITH pinned AS (UPDATE messages SET is_pinned = true WHERE id = $2)
+			UPDATE status_subscriptions SET card_message_id = $2 WHERE id = $1
+		`, subscription.ID, message["id"])
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to pin status card")
+		}
+	}
+}
+
+func (app *Application) findStatusComponent(ctx context.Context, teamID string, update statuspage.Update) (domain.StatusComponent, bool, error) {
+	component, err := scanStatusComponent(app.DB.QueryRowContext(ctx, `
+		SELECT `+statusComponentColumns+`
+		FROM status_components
+		WHERE team_id = $1 AND (($2 <> '' AND external_id = $2) OR ($2 = '' AND LOWER(name) = LOWER($3)))
+	`, teamID, update.ExternalID, update.Name))
+	if err == sql.ErrNoRows {
+		return component, false, nil
+	}
+	return component, err == nil, err
+}
+
+func (app *Application) loadStatusComponent(w http.ResponseWriter, ctx context.Context, componentID string) (domain.StatusComponent, bool) {
+	component, err := scanStatusComponent(app.DB.QueryRowContext(ctx, `
+		SELECT `+statusComponentColumns+` FROM status_components WHERE id = $1
+	`, componentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Status component not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get status component")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return component, false
+	}
+
+	return component, true
+}
+
+func (app *Application) loadStatusComponents(ctx context.Context, teamID string) ([]domain.StatusComponent, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT `+statusComponentColumns+`
+		FROM status_components
+		WHERE team_id = $1
+		ORDER BY name
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := []domain.StatusComponent{}
+	for rows.Next() {
+		component, err := scanStatusComponent(rows)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+
+	return components, rows.Err()
+}
+
+func (app *Application) loadStatusSubscriptions(ctx context.Context, teamID string) ([]domain.StatusSubscription, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT id, team_id, channel_id, card_message_id, created_by, created_at
+		FROM status_subscriptions
+		WHERE team_id = $1
+		ORDER BY created_at
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []domain.StatusSubscription{}
+	for rows.Next() {
+		var s domain.StatusSubscription
+		if err := rows.Scan(&s.ID, &s.TeamID, &s.ChannelID, &s.CardMessageID, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func scanStatusComponent(row rowScanner) (domain.StatusComponent, error) {
+	var c domain.StatusComponent
+	err := row.Scan(&c.ID, &c.TeamID, &c.Name, &c.Description, &c.Status, &c.ExternalID, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// primeWindow is how far back primeCaches looks for activity.
+const primeWindow = 24 * time.Hour
+
+// warmCache waits for Redis, retrying with backoff, then primes the cache so
+// the first requests after a deploy don't all go to the database. It runs in
+// the background and gives up when ctx is cancelled.
+func (app *Application) warmCache(ctx context.Context) {
+	if !app.Cache.Enabled() {
+		return
+	}
+	for delay := time.Second; ; delay = min(2*delay, time.Minute) {
+		err := app.Cache.HealthCheck()
+		if err == nil {
+			break
+		}
+		app.Logger.WithError(err).Warnf("Redis not reachable yet, retrying in %s; running with a cold cache", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+	if app.Config.Redis.LazyConnect {
+		app.Logger.Info("Connected to Redis cache")
+	}
+
+	primed, err := app.primeCaches(ctx, app.Config.Redis.PrimeLimit)
+	if err != nil {
+		app.Logger.WithError(err).Warnf("Stopped priming the cache after %d channel members", primed)
+		return
+	}
+	if primed > 0 {
+		app.Logger.Infof("Primed the cache for %d recently active channel members", primed)
+	}
+}
+
+// primeCaches loads the channel and team metadata, team role and channel
+// access of up to limit people who posted in the last day, the ones most
+// likely to be back first. It returns how many it primed.
+func (app *Application) primeCaches(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		return 0, nil
+	}
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT active.channel_id, active.user_id, c.team_id
+		FROM (
+			SELECT channel_id, user_id, MAX(created_at) AS last_posted
+			FROM messages
+			WHERE created_at > $1
+			GROUP BY channel_id, user_id
+			ORDER BY last_posted DESC
+			LIMIT $2
+		) active
+		JOIN channels c ON c.id = active.channel_id
+	`, time.Now().Add(-primeWindow), limit)
+	if err != nil {
+		return 0, err
+	}
+	type member struct{ channelID, userID, teamID string }
+	var members []member
+	for rows.Next() {
+		var m member
+		if err := rows.Scan(&m.channelID, &m.userID, &m.teamID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		members = append(members, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Each lookup caches its answer on a miss
+	primed := 0
+	for _, m := range members {
+		if err := ctx.Err(); err != nil {
+			return primed, err
+		}
+		if _, err := app.getChannelMeta(ctx, m.channelID); err != nil && err != sql.ErrNoRows {
+			return primed, err
+		}
+		if _, err := app.getTeamMeta(ctx, m.teamID); err != nil && err != sql.ErrNoRows {
+			return primed, err
+		}
+		if _, err := app.getTeamRole(m.teamID, m.userID); err != nil && err != sql.ErrNoRows {
+			return primed, err
+		}
+		if _, err := app.getChannelAccess(m.channelID, m.userID); err != nil && err != sql.ErrNoRows {
+			return primed, err
+		}
+		primed++
+	}
+	return primed, nil
+}
@@ -0,0 +1,424 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/llm"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const (
+	suggestionLookback     = 7 * 24 * time.Hour
+	suggestionScanLimit    = 200
+	maxSuggestionTitleSize = 255
+)
+
+const suggestionSystemPrompt = `You extract action items from team chat messages. Each message is ` +
+	`prefixed with [number], its date and author. Return only JSON of the form ` +
+	`{"tasks":[{"message":1,"title":"...","description":"...","assignee":"username or empty",` +
+	`"due_date":"YYYY-MM-DD or empty"}]}. Only include concrete commitments or requests, use a short ` +
+	`imperative title, resolve relative dates like "by Friday" against the message date, and return ` +
+	`{"tasks":[]} when there are none.`
+
+// actionItemPattern is a cheap pre-filter so only messages that look like
+// commitments or requests are sent to the provider.
+var actionItemPattern = regexp.MustCompile(`(?i)\b(i(?:'|’)ll|i will|i(?:'|’)m going to|we(?:'|’)ll|we need to|we should|need to|todo|to-do|action item|can you|could you|please|follow up|by (?:mon|tues|wednes|thurs|fri|satur|sun)day|by (?:tomorrow|tonight|eod|end of (?:day|week))|deadline|due)\b`)
+
+type suggestedTask struct {
+	Message     int    `json:"message"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Assignee    string `json:"assignee"`
+	DueDate     string `json:"due_date"`
+}
+
+type scannedMessage struct {
+	ID        string
+	Username  string
+	Content   string
+	CreatedAt time.Time
+}
+
+func (app *Application) getSuggestedTasksHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	channelID := vars["channelId"]
+
+	teamID, err := app.getChannelTeam(channelID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if app.LLM == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Task suggestions are not available on this server")
+		return
+	}
+
+	settings, err := app.loadAISettings(r.Context(), teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get AI settings")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if !settings.TaskSuggestionsEnabled {
+		respondWithError(w, http.StatusForbidden, "Task suggestions are disabled for this team")
+		return
+	}
+
+	// Suggestions already found are still worth returning when a new scan
+	// can't run, so scan failures only degrade the response.
+	analyzed, err := app.scanForTaskSuggestions(r.Context(), teamID, channelID, claims.UserID, settings)
+	budgetExhausted := err == errTokenBudgetExceeded
+	if err != nil && !budgetExhausted {
+		app.Logger.WithError(err).Error("Failed to scan channel for task suggestions")
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT s.id, s.team_id, s.channel_id, s.message_id, s.title, COALESCE(s.description, ''),
+		       s.assignee_id, s.due_date, s.status, s.task_id, m.content, s.created_at
+		FROM task_suggestions s
+		JOIN messages m ON m.id = s.message_id
+		WHERE s.channel_id = $1 AND s.status = 'pending' AND m.is_deleted = false
+		ORDER BY m.created_at DESC
+	`, channelID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get task suggestions")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	suggestions := []domain.TaskSuggestion{}
+	for rows.Next() {
+		suggestion, err := scanTaskSuggestion(rows)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan task suggestion row")
+			continue
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating task suggestion rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"suggestions":      suggestions,
+		"analyzed":         analyzed,
+		"budget_exhausted": budgetExhausted,
+	})
+}
+
+func scanTaskSuggestion(row rowScanner) (domain.TaskSuggestion, error) {
+	var s domain.TaskSuggestion
+	err := row.Scan(&s.ID, &s.TeamID, &s.ChannelID, &s.MessageID, &s.Title, &s.Description,
+		&s.AssigneeID, &s.DueDate, &s.Status, &s.TaskID, &s.Excerpt, &s.CreatedAt)
+	return s, err
+}
+
+// scanForTaskSuggestions analyzes messages posted since the channel's last
+// scan and stores any action items the provider finds. It returns the number
+// of messages sent to the provider.
+func (app *Application) scanForTaskSuggestions(ctx context.Context, teamID, channelID, userID string, settings domain.TeamAISettings) (int, error) {
+	since := time.Now().Add(-suggestionLookback)
+
+	var scannedUntil time.Time
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT scanned_until FROM task_suggestion_scans WHERE channel_id = $1
+	`, channelID).Scan(&scannedUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if scannedUntil.After(since) {
+		since = scannedUntil
+	}
+
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT m.id, u.username, m.content, m.created_at
+		FROM messages m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.channel_id = $1 AND m.created_at > $2 AND m.is_deleted = false AND m.type = 'text'
+		ORDER BY m.created_at
+		LIMIT $3
+	`, channelID, since, suggestionScanLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	var candidates []scannedMessage
+	latest := since
+	for rows.Next() {
+		var msg scannedMessage
+		if err := rows.Scan(&msg.ID, &msg.Username, &msg.Content, &msg.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		latest = msg.CreatedAt
+		if actionItemPattern.MatchString(msg.Content) {
+			candidates = append(candidates, msg)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(candidates) > 0 {
+		if err := app.suggestTasks(ctx, teamID, channelID, userID, settings, candidates); err != nil {
+			return 0, err
+		}
+	}
+
+	if latest.After(since) {
+		_, err = app.DB.ExecContext(ctx, `
+			INSERT INTO task_suggestion_scans (channel_id, scanned_until)
+			VALUES ($1, $2)
+			ON CONFLICT (channel_id) DO UPDATE SET scanned_until = EXCLUDED.scanned_until
+		`, channelID, latest)
+		if err != nil {
+			return len(candidates), err
+		}
+	}
+
+	return len(candidates), nil
+}
+
+func (app *Application) suggestTasks(ctx context.Context, teamID, channelID, userID string, settings domain.TeamAISettings, candidates []scannedMessage) error {
+	lines := make([]string, len(candidates))
+	for i, msg := range candidates {
+		lines[i] = fmt.Sprintf("[%d] %s %s: %s", i+1, msg.CreatedAt.Format("Mon 2006-01-02"), msg.Username, msg.Content)
+	}
+	transcript := strings.Join(lines, "\n")
+
+	if err := reserveAIBudget(settings, llm.EstimateTokens(suggestionSystemPrompt+transcript)+app.Config.LLM.MaxTokens); err != nil {
+		return err
+	}
+
+	resp, err := app.LLM.Complete(ctx, llm.Request{
+		Messages: []llm.Message{
+			{Role: "system", Content: suggestionSystemPrompt},
+			{Role: "user", Content: transcript},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	app.recordLLMUsage(ctx, teamID, userID, "task_suggestions", resp)
+
+	var result struct {
+		Tasks []suggestedTask `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(llm.StripCodeFence(resp.Content)), &result); err != nil {
+		// The messages were paid for already; don't retry them on every request
+		app.Logger.WithError(err).Warn("Provider returned unparseable task suggestions")
+		return nil
+	}
+
+	for _, task := range result.Tasks {
+		if task.Message < 1 || task.Message > len(candidates) {
+			continue
+		}
+		msg := candidates[task.Message-1]
+
+		title := strings.TrimSpace(task.Title)
+		if title == "" {
+			continue
+		}
+		if len(title) > maxSuggestionTitleSize {
+			title = title[:maxSuggestionTitleSize]
+		}
+
+		var assigneeID *string
+		if task.Assignee != "" {
+			if id, err := app.resolveTeamMember(ctx, teamID, task.Assignee); err == nil {
+				assigneeID = &id
+			}
+		}
+
+		var dueDate *time.Time
+		if parsed, err := time.Parse("2006-01-02", task.DueDate); err == nil {
+			dueDate = &parsed
+		}
+
+		_, err := app.DB.ExecContext(ctx, `
+			INSERT INTO task_suggestions (team_id, channel_id, message_id, title, description, assignee_id, due_date, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+			ON CONFLICT (message_id, title) DO NOTHING
+		`, teamID, channelID, msg.ID, title, strings.TrimSpace(task.Description), assigneeID, dueDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (app *Application) loadTaskSuggestion(w http.ResponseWriter, suggestionID, userID string) (domain.TaskSuggestion, bool) {
+	suggestion, err := scanTaskSuggestion(app.DB.QueryRow(`
+		SELECT s.id, s.team_id, s.channel_id, s.message_id, s.title, COALESCE(s.description, ''),
+		       s.assignee_id, s.due_date, s.status, s.task_id, m.content, s.created_at
+		FROM task_suggestions s
+		JOIN messages m ON m.id = s.message_id
+		WHERE s.id = $1
+	`, suggestionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task suggestion not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task suggestion")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return suggestion, false
+	}
+
+	if _, err := app.getChannelTeam(suggestion.ChannelID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this channel")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check channel access")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return suggestion, false
+	}
+
+	return suggestion, true
+}
+
+// resolveTaskSuggestion moves a pending suggestion to the given status. It
+// returns false if someone else resolved it first.
+func (app *Application) resolveTaskSuggestion(suggestionID, userID string, status domain.TaskSuggestionStatus) (bool, error) {
+	result, err := app.DB.Exec(`
+		UPDATE task_suggestions SET status = $2, resolved_by = $3
+		WHERE id = $1 AND status = 'pending'
+	`, suggestionID, string(status), userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (app *Application) acceptTaskSuggestionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	// The body is optional; it lets the user tweak the task before creating it
+	var req domain.AcceptTaskSuggestion
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	suggestion, ok := app.loadTaskSuggestion(w, mux.Vars(r)["suggestionId"], claims.UserID)
+	if !ok {
+		return
+	}
+
+	title := suggestion.Title
+	if req.Title != nil && strings.TrimSpace(*req.Title) != "" {
+		title = strings.TrimSpace(*req.Title)
+	}
+
+	priority := string(domain.PriorityMedium)
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	if !isValidPriority(priority) {
+		respondWithError(w, http.StatusBadRequest, "Invalid priority")
+		return
+	}
+
+	assigneeID := suggestion.AssigneeID
+	if req.AssigneeID != nil {
+		assigneeID = nil
+		if *req.AssigneeID != "" {
+			if _, err := app.getTeamRole(suggestion.TeamID, *req.AssigneeID); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Assignee must be a team member")
+				return
+			}
+			assigneeID = req.AssigneeID
+		}
+	}
+
+	claimed, err := app.resolveTaskSuggestion(suggestion.ID, claims.UserID, domain.TaskSuggestionAccepted)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to accept task suggestion")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !claimed {
+		respondWithError(w, http.StatusConflict, "Task suggestion was already resolved")
+		return
+	}
+
+	task, err := app.createTask(r.Context(), suggestion.TeamID, claims.UserID, title, suggestion.Description,
+		priority, assigneeID, suggestion.DueDate)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create task from suggestion")
+		app.DB.Exec(`UPDATE task_suggestions SET status = 'pending', resolved_by = NULL WHERE id = $1`, suggestion.ID)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create task")
+		return
+	}
+
+	if _, err := app.DB.Exec(`UPDATE task_suggestions SET task_id = $2 WHERE id = $1`, suggestion.ID, task["id"]); err != nil {
+		app.Logger.WithError(err).Error("Failed to link task to suggestion")
+	}
+
+	respondWithJSON(w, http.StatusCreated, task)
+}
+
+func (app *Application) dismissTaskSuggestionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	suggestion, ok := app.loadTaskSuggestion(w, mux.Vars(r)["suggestionId"], claims.UserID)
+	if !ok {
+		return
+	}
+
+	claimed, err := app.resolveTaskSuggestion(suggestion.ID, claims.UserID, domain.TaskSuggestionDismissed)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to dismiss task suggestion")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !claimed {
+		respondWithError(w, http.StatusConflict, "Task suggestion was already resolved")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Task suggestion dismissed"})
+}
@@ -0,0 +1,406 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+const (
+	maxOutboxOperations = 100
+	maxClientIDLength   = 64
+)
+
+const (
+	outboxMessageCreate = "message.create"
+	outboxTaskUpdate    = "task.update"
+)
+
+// outboxOperation is one change a client queued while offline. Messages use
+// channel_id, content and reply_to_id; task edits use task_id, changes (as
+// for PUT /tasks/{taskId}), base_version (the task's updated_at when the
+// client last saw it) and on_conflict.
+type outboxOperation struct {
+	ClientID    string     `json:"client_id"`
+	Type        string     `json:"type"`
+	ChannelID   string     `json:"channel_id"`
+	Content     string     `json:"content"`
+	ReplyToID   string     `json:"reply_to_id"`
+	TaskID      string     `json:"task_id"`
+	BaseVersion *time.Time `json:"base_version"`
+	Changes     taskUpdate `json:"changes"`
+	OnConflict  string     `json:"on_conflict"`
+}
+
+// syncOutboxHandler applies the operations a client queued while offline,
+// in order, and reports what happened to each. One operation failing
+// doesn't stop the rest, so clients should queue edits that depend on
+// each other only after what they depend on has synced.
+func (app *Application) syncOutboxHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req struct {
+		Operations []outboxOperation `json:"operations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Operations) == 0 {
+		respondWithError(w, http.StatusBadRequest, "No operations to sync")
+		return
+	}
+	if len(req.Operations) > maxOutboxOperations {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d operations can be synced at once", maxOutboxOperations))
+		return
+	}
+
+	outcomes := make([]domain.OutboxOutcome, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		outcome := domain.OutboxOutcome{ClientID: op.ClientID, Type: op.Type}
+		var err error
+		switch {
+		case op.ClientID == "" || len(op.ClientID) > maxClientIDLength:
+			outcome.Status = domain.OutboxRejected
+			outcome.Error = fmt.Sprintf("client_id is required and at most %d characters", maxClientIDLength)
+		case op.Type == outboxMessageCreate:
+			outcome, err = app.syncMessage(r.Context(), claims, op, outcome)
+		case op.Type == outboxTaskUpdate:
+			outcome, err = app.syncTaskUpdate(r.Context(), claims, op, outcome)
+		default:
+			outcome.Status = domain.OutboxRejected
+			outcome.Error = "Unknown operation type " + op.Type
+		}
+		if err != nil {
+			app.Logger.WithError(err).Errorf("Failed to sync %s operation %s", op.Type, op.ClientID)
+			outcome = domain.OutboxOutcome{
+				ClientID: op.ClientID,
+				Type:     op.Type,
+				Status:   domain.OutboxFailed,
+				Error:    "Internal server error",
+			}
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"results": outcomes,
+	})
+}
+
+func rejectOutbox(outcome domain.OutboxOutcome, message string) (domain.OutboxOutcome, error) {
+	outcome.Status = domain.OutboxRejected
+	outcome.Error = message
+	return outcome, nil
+}
+
+// syncMessage posts a queued message once: replaying a client_id that was
+// already posted reports the earlier message as a duplicate.
+func (app *Application) syncMessage(ctx context.Context, claims *middleware.Claims, op outboxOperation, outcome domain.OutboxOutcome) (domain.OutboxOutcome, error) {
+	existing, err := app.messageByClientID(ctx, claims.UserID, op.ClientID)
+	if err != nil && err != sql.ErrNoRows {
+		return outcome, err
+	}
+	if err == nil {
+		outcome.Status = domain.OutboxDuplicate
+		outcome.Result = existing
+		return outcome, nil
+	}
+
+	if op.Content == "" {
+		return rejectOutbox(outcome, "Message content is required")
+	}
+	// Commands act on the state of the moment, which has moved on
+	if strings.HasPrefix(op.Content, "/") {
+		return rejectOutbox(outcome, "Slash commands can't be sent while offline")
+	}
+	if !middleware.IsCanonicalUUID(op.ChannelID) {
+		return rejectOutbox(outcome, "channel_id must be a channel ID")
+	}
+
+	access, err := app.getChannelAccess(op.ChannelID, claims.UserID)
+	if err == sql.ErrNoRows {
+		return rejectOutbox(outcome, "Access denied to this channel")
+	}
+	if err != nil {
+		return outcome, err
+	}
+	if access.HistoryUntil != nil {
+		return rejectOutbox(outcome, "This channel is no longer shared with your team")
+	}
+
+	message := outgoingMessage{
+		TeamID:    access.TeamID,
+		ChannelID: op.ChannelID,
+		UserID:    claims.UserID,
+		Content:   op.Content,
+		Type:      string(domain.MessageTypeText),
+		ClientID:  op.ClientID,
+	}
+	if access.Guest() {
+		message.OriginTeamID = access.OriginTeamID
+	}
+	if op.ReplyToID != "" {
+		if !middleware.IsCanonicalUUID(op.ReplyToID) {
+			return rejectOutbox(outcome, "reply_to_id must be a message ID")
+		}
+		var parentExists bool
+		err = app.DB.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1 AND channel_id = $2 AND is_deleted = false)
+		`, op.ReplyToID, op.ChannelID).Scan(&parentExists)
+		if err != nil {
+			return outcome, err
+		}
+		if !parentExists {
+			return rejectOutbox(outcome, "Replies must be to a message in the same channel")
+		}
+		message.ReplyToID = op.ReplyToID
+	}
+
+	posted, err := app.postMessage(ctx, message)
+	if errors.Is(err, repository.ErrDuplicate) {
+		// Another sync of the same outbox got there first
+		existing, err := app.messageByClientID(ctx, claims.UserID, op.ClientID)
+		if err != nil {
+			return outcome, err
+		}
+		outcome.Status = domain.OutboxDuplicate
+		outcome.Result = existing
+		return outcome, nil
+	}
+	if err != nil {
+		return outcome, err
+	}
+
+	outcome.Status = domain.OutboxApplied
+	outcome.Result = posted
+	return outcome, nil
+}
+
+func (app *Application) messageByClientID(ctx context.Context, userID, clientID string) (map[string]interface{}, error) {
+	var id, teamID, channelID, content, messageType string
+	var replyToID *string
+	var isDeleted bool
+	var createdAt, updatedAt time.Time
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT id, team_id, channel_id, content, type, reply_to_id, is_deleted, created_at, updated_at
+		FROM messages WHERE user_id = $1 AND client_id = $2
+	`, userID, clientID).Scan(&id, &teamID, &channelID, &content, &messageType, &replyToID, &isDeleted,
+		&createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	message := map[string]interface{}{
+		"id":         id,
+		"team_id":    teamID,
+		"channel_id": channelID,
+		"content":    content,
+		"type":       messageType,
+		"sender_id":  userID,
+		"client_id":  clientID,
+		"is_deleted": isDeleted,
+		"created_at": createdAt,
+		"updated_at": updatedAt,
+	}
+	if replyToID != nil {
+		message["reply_to_id"] = *replyToID
+	}
+	return message, nil
+}
+
+// syncTaskUpdate applies a queued task edit. Fields changed after
+// base_version, by anyone on any device, are conflicts: server_wins (the default) keeps their
+// value and applies the rest, client_wins overwrites it, and reject
+// changes nothing. Fields that already hold the client's value are left
+// out, so replaying an edit that was applied is harmless.
+func (app *Application) syncTaskUpdate(ctx context.Context, claims *middleware.Claims, op outboxOperation, outcome domain.OutboxOutcome) (domain.OutboxOutcome, error) {
+	if op.OnConflict == "" {
+		op.OnConflict = domain.ConflictServerWins
+	}
+	switch op.OnConflict {
+	case domain.ConflictServerWins, domain.ConflictClientWins, domain.ConflictReject:
+	default:
+		return rejectOutbox(outcome, "on_conflict must be server_wins, client_wins or reject")
+	}
+	if op.BaseVersion == nil {
+		return rejectOutbox(outcome, "base_version is required")
+	}
+	if !middleware.IsCanonicalUUID(op.TaskID) {
+		return rejectOutbox(outcome, "task_id must be a task ID")
+	}
+
+	changes := op.Changes
+	changes.clearDueDate = false
+	if changes.Title != nil && *changes.Title == "" {
+		return rejectOutbox(outcome, "Task title cannot be empty")
+	}
+	if changes.Status != nil && !isValidTaskStatus(*changes.Status) {
+		return rejectOutbox(outcome, "Invalid task status")
+	}
+	if changes.Priority != nil && !isValidPriority(*changes.Priority) {
+		return rejectOutbox(outcome, "Invalid task priority")
+	}
+
+	stored, err := app.Tasks.Get(ctx, op.TaskID)
+	if err == sql.ErrNoRows {
+		return rejectOutbox(outcome, "Task not found")
+	}
+	if err != nil {
+		return outcome, err
+	}
+	teamID, createdBy, current := stored.TeamID, stored.CreatedBy, fieldsOfTask(stored)
+
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		return outcome, err
+	}
+	subject := authz.NewSubject(claims, role)
+	if !authz.Task(subject, createdBy).CanEdit {
+		return rejectOutbox(outcome, "Access denied to this task")
+	}
+
+	changedSince, err := app.taskFieldsChangedSince(ctx, op.TaskID, *op.BaseVersion)
+	if err != nil {
+		return outcome, err
+	}
+
+	wanted := changes.values()
+	currentValues := current.values()
+	apply := make(map[string]bool)
+	var conflicts []string
+	for _, field := range taskFieldNames {
+		value, ok := wanted[field]
+		if !ok || value == currentValues[field] {
+			continue
+		}
+		if changedSince[field] {
+			conflicts = append(conflicts, field)
+			if op.OnConflict != domain.ConflictClientWins {
+				continue
+			}
+		}
+		apply[field] = true
+	}
+	outcome.Conflicts = conflicts
+
+	if len(conflicts) > 0 && op.OnConflict == domain.ConflictReject {
+		apply = nil
+		outcome.Status = domain.OutboxConflict
+		outcome.Error = "The task was changed by someone else since base_version"
+	} else {
+		outcome.Status = domain.OutboxApplied
+	}
+
+	var task map[string]interface{}
+	if len(apply) > 0 {
+		task, err = app.updateTask(ctx, teamID, op.TaskID, claims.UserID, changes.only(apply))
+		if err != nil {
+			return outcome, err
+		}
+		task = maps.Clone(task)
+	} else {
+		task = taskMap(stored)
+	}
+	task["permissions"] = authz.Task(subject, createdBy)
+	outcome.Result = task
+	return outcome, nil
+}
+
+// taskFieldNames are the fields taskFields.values holds, in a fixed order.
+var taskFieldNames = []string{"title", "description", "status", "priority", "assignee_id", "due_date"}
+
+// taskFieldsChangedSince returns the fields changed by events after since.
+func (app *Application) taskFieldsChangedSince(ctx context.Context, taskID string, since time.Time) (map[string]bool, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT changes FROM task_events WHERE task_id = $1 AND created_at > $2
+	`, taskID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changed := make(map[string]bool)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var changes map[string]domain.TaskFieldChange
+		if err := json.Unmarshal(data, &changes); err != nil {
+			return nil, fmt.Errorf("failed to decode task event changes: %w", err)
+		}
+		for field := range changes {
+			changed[field] = true
+		}
+	}
+	return changed, rows.Err()
+}
+
+// values gives the fields the update sets, in the form taskFields.values
+// uses, so the two can be compared.
+func (u taskUpdate) values() map[string]interface{} {
+	values := make(map[string]interface{})
+	if u.Title != nil {
+		values["title"] = *u.Title
+	}
+	if u.Description != nil {
+		values["description"] = *u.Description
+	}
+	if u.Status != nil {
+		values["status"] = *u.Status
+	}
+	if u.Priority != nil {
+		values["priority"] = *u.Priority
+	}
+	if u.AssigneeID != nil {
+		if *u.AssigneeID == "" {
+			values["assignee_id"] = nil
+		} else {
+			values["assignee_id"] = *u.AssigneeID
+		}
+	}
+	if u.DueDate != nil {
+		values["due_date"] = u.DueDate.UTC().Format(time.RFC3339)
+	}
+	return values
+}
+
+// only keeps the fields named in fields.
+func (u taskUpdate) only(fields map[string]bool) taskUpdate {
+	var kept taskUpdate
+	if fields["title"] {
+		kept.Title = u.Title
+	}
+	if fields["description"] {
+		kept.Description = u.Description
+	}
+	if fields["status"] {
+		kept.Status = u.Status
+	}
+	if fields["priority"] {
+		kept.Priority = u.Priority
+	}
+	if fields["assignee_id"] {
+		kept.AssigneeID = u.AssigneeID
+	}
+	if fields["due_date"] {
+		kept.DueDate = u.DueDate
+	}
+	return kept
+}
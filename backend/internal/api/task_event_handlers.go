@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+var (
+	errNothingToUndo    = errors.New("task has no change to undo")
+	errTaskChangedSince = errors.New("task has changed since the event")
+)
+
+// taskFields are the parts of a task its history tracks.
+type taskFields struct {
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	AssigneeID  *string
+	DueDate     *time.Time
+}
+
+func fieldsOfTask(task domain.Task) taskFields {
+	return taskFields{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      string(task.Status),
+		Priority:    string(task.Priority),
+		AssigneeID:  task.AssigneeID,
+		DueDate:     task.DueDate,
+	}
+}
+
+// values gives each field as it is stored in an event: strings, or nil when
+// unset.
+func (f taskFields) values() map[string]interface{} {
+	values := map[string]interface{}{
+		"title":       f.Title,
+		"description": f.Description,
+		"status":      f.Status,
+		"priority":    f.Priority,
+		"assignee_id": nil,
+		"due_date":    nil,
+	}
+	if f.AssigneeID != nil {
+		values["assignee_id"] = *f.AssigneeID
+	}
+	if f.DueDate != nil {
+		values["due_date"] = f.DueDate.UTC().Format(time.RFC3339)
+	}
+	return values
+}
+
+// matches reports whether the fields still hold the values an event left
+// them with.
+func (f taskFields) matches(changes map[string]domain.TaskFieldChange) bool {
+	values := f.values()
+	for field, change := range changes {
+		if values[field] != change.To {
+			return false
+		}
+	}
+	return true
+}
+
+// diffTaskFields lists the fields that differ. A nil before is a new task,
+// for which every field that is set counts as changed.
+func diffTaskFields(before *taskFields, after taskFields) map[string]domain.TaskFieldChange {
+	changes := make(map[string]domain.TaskFieldChange)
+	for field, to := range after.values() {
+		var from interface{}
+		if before != nil {
+			from = before.values()[field]
+		} else if to == "" {
+			continue
+		}
+		if from != to {
+			changes[field] = domain.TaskFieldChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+// revertUpdate builds the update that puts back what event changed.
+func revertUpdate(event domain.TaskEvent) (taskUpdate, error) {
+	var update taskUpdate
+	for field, change := range event.Changes {
+		from, _ := change.From.(string)
+		switch field {
+		case "title":
+			update.Title = &from
+		case "description":
+			update.Description = &from
+		case "status":
+			update.Status = &from
+		case "priority":
+			update.Priority = &from
+		case "assignee_id":
+			// Empty unassigns, as it does for clients
+			update.AssigneeID = &from
+		case "due_date":
+			if change.From == nil {
+				update.clearDueDate = true
+				continue
+			}
+			dueDate, err := time.Parse(time.RFC3339, from)
+			if err != nil {
+				return update, fmt.Errorf("event %s has an invalid due date: %w", event.ID, err)
+			}
+			update.DueDate = &dueDate
+		}
+	}
+	return update, nil
+}
+
+// getTaskEventsHandler returns a task's history, oldest first.
+func (app *Application) getTaskEventsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	if _, ok := app.taskTeamForMember(w, taskID, claims.UserID); !ok {
+		return
+	}
+
+	taskEvents, err := app.Tasks.Events(r.Context(), taskID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get task events")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if taskEvents == nil {
+		taskEvents = []domain.TaskEvent{}
+	}
+
+	respondWithJSON(w, http.StatusOK, taskEvents)
+}
+
+// latestUndoableTaskEvent finds the most recent update to a task that hasn't
+// been undone. Undos aren't themselves undoable, so undoing repeatedly walks
+// back through the task's history.
+func (app *Application) latestUndoableTaskEvent(ctx context.Context, taskID string) (domain.TaskEvent, error) {
+	event, err := app.Tasks.LatestUndoable(ctx, taskID)
+	if err == sql.ErrNoRows {
+		return event, errNothingToUndo
+	}
+	return event, err
+}
+
+// undoTaskHandler reverts the most recent change to a task. It refuses when
+// the fields that change touched have been changed again since, rather than
+// overwrite someone else's edit.
+func (app *Application) undoTaskHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	teamID, ok := app.taskTeamForMember(w, taskID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check team membership")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	event, err := app.latestUndoableTaskEvent(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, errNothingToUndo) {
+			respondWithError(w, http.StatusConflict, "Nothing to undo")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to get task events")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	update, err := revertUpdate(event)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to build task undo")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	task, err := app.applyTaskUpdate(r.Context(), teamID, taskID, claims.UserID, update, &event)
+	if err != nil {
+		// A concurrent undo of the same event loses on the unique index
+		if errors.Is(err, errTaskChangedSince) || errors.Is(err, repository.ErrDuplicate) {
+			respondWithError(w, http.StatusConflict, "The task has changed since; undo would overwrite newer edits")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to undo task change")
+		respondWithError(w, http.StatusInternalServerError, "Failed to undo task change")
+		return
+	}
+
+	// The task map is also the event payload, so decorate a copy
+	response := maps.Clone(task)
+	createdBy, _ := task["created_by"].(string)
+	response["permissions"] = authz.Task(authz.NewSubject(claims, role), createdBy)
+	response["reverted_event_id"] = event.ID
+
+	respondWithJSON(w, http.StatusOK, response)
+}
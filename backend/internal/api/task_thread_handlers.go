@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+// taskTeamForMember returns the team of a task the user can see, writing the
+// error response and returning false otherwise.
+func (app *Application) taskTeamForMember(w http.ResponseWriter, taskID, userID string) (string, bool) {
+	task, err := app.Tasks.Get(context.Background(), taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return "", false
+	}
+
+	if !app.requireTeamMember(w, task.TeamID, userID) {
+		return "", false
+	}
+	return task.TeamID, true
+}
+
+func (app *Application) getTaskCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	if _, ok := app.taskTeamForMember(w, taskID, claims.UserID); !ok {
+		return
+	}
+
+	comments, err := app.Tasks.Comments(r.Context(), taskID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get task comments")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if comments == nil {
+		comments = []domain.TaskComment{}
+	}
+
+	respondWithJSON(w, http.StatusOK, comments)
+}
+
+func (app *Application) createTaskCommentHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	var req domain.CreateTaskComment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" || len(req.Content) > 1000 {
+		respondWithError(w, http.StatusBadRequest, "Comment must be between 1 and 1000 characters")
+		return
+	}
+
+	teamID, ok := app.taskTeamForMember(w, taskID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	comment, err := app.addTaskComment(r.Context(), teamID, taskID, claims.UserID, req.Content, nil)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to create task comment")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create comment")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, comment)
+}
+
+// addTaskComment stores a comment and publishes task.commented. messageID is
+// set for comments that came from the task's thread; if that message already
+// has a comment, it returns sql.ErrNoRows.
+func (app *Application) addTaskComment(ctx context.Context, teamID, taskID, userID, content string, messageID *string) (domain.TaskComment, error) {
+	comment := domain.TaskComment{TaskID: taskID, UserID: userID, Content: content, MessageID: messageID}
+	if err := app.Tasks.AddComment(ctx, &comment); err != nil {
+		return comment, err
+	}
+
+	app.Events.Publish(ctx, events.Event{
+		Type:    events.TaskCommented,
+		TeamID:  teamID,
+		ActorID: userID,
+		Data:    comment,
+	})
+
+	return comment, nil
+}
+
+func (app *Application) getTaskThreadHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	if _, ok := app.taskTeamForMember(w, taskID, claims.UserID); !ok {
+		return
+	}
+
+	thread := domain.TaskThread{TaskID: taskID}
+	err := app.DB.QueryRow(`
+		SELECT m.channel_id, m.id FROM tasks t
+		JOIN messages m ON m.id = t.thread_message_id
+		WHERE t.id = $1 AND t.deleted_at IS NULL
+	`, taskID).Scan(&thread.ChannelID, &thread.MessageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task has no thread")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task thread")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, thread)
+}
+
+// linkTaskThreadHandler starts a thread for the task in one of its team's
+// channels. From then on comments on the task are posted as replies in the
+// thread, and replies in the thread become comments.
+func (app *Application) linkTaskThreadHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	var req domain.LinkTaskThread
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChannelID == "" {
+		respondWithError(w, http.StatusBadRequest, "channel_id is required")
+		return
+	}
+
+	teamID, ok := app.taskTeamForMember(w, taskID, claims.UserID)
+	if !ok {
+		return
+	}
+
+	var channelType string
+	err := app.DB.QueryRow(`SELECT type FROM channels WHERE id = $1 AND team_id = $2`, req.ChannelID, teamID).Scan(&channelType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "Channel must belong to the task's team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	if channelType == string(domain.ChannelTypeDirect) {
+		respondWithError(w, http.StatusBadRequest, "Tasks can't be linked to direct messages")
+		return
+	}
+
+	var title string
+	var linked bool
+	err = app.DB.QueryRow(`
+		SELECT title, thread_message_id IS NOT NULL FROM tasks WHERE id = $1 AND deleted_at IS NULL
+	`, taskID).Scan(&title, &linked)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get task")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if linked {
+		respondWithError(w, http.StatusConflict, "Task already has a thread")
+		return
+	}
+
+	content := fmt.Sprintf("🧵 Discussion for task \"%s\". Replies here are added to the task's comments.", title)
+	message, err := app.createMessage(r.Context(), teamID, req.ChannelID, claims.UserID, content, string(domain.MessageTypeSystem))
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to post task thread")
+		respondWithError(w, http.StatusInternalServerError, "Failed to link thread")
+		return
+	}
+	messageID := message["id"].(string)
+
+	result, err := app.DB.Exec(`
+		UPDATE tasks SET thread_message_id = $2 WHERE id = $1 AND thread_message_id IS NULL
+	`, taskID, messageID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to link task thread")
+		respondWithError(w, http.StatusInternalServerError, "Failed to link thread")
+		return
+	}
+	if linked, _ := result.RowsAffected(); linked == 0 {
+		respondWithError(w, http.StatusConflict, "Task already has a thread")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, domain.TaskThread{
+		TaskID:    taskID,
+		ChannelID: req.ChannelID,
+		MessageID: messageID,
+	})
+}
+
+// unlinkTaskThreadHandler stops mirroring. Comments and replies made so far
+// stay where they are.
+func (app *Application) unlinkTaskThreadHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	if _, ok := app.taskTeamForMember(w, taskID, claims.UserID); !ok {
+		return
+	}
+
+	if _, err := app.DB.Exec(`UPDATE tasks SET thread_message_id = NULL WHERE id = $1`, taskID); err != nil {
+		app.Logger.WithError(err).Error("Failed to unlink task thread")
+		respondWithError(w, http.StatusInternalServerError, "Failed to unlink thread")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Thread unlinked"})
+}
+
+// mirrorCommentToThread posts a task comment as a reply in the task's
+// thread. Comments that came from the thread already have a message and are
+// skipped.
+func (app *Application) mirrorCommentToThread(ctx context.Context, event events.Event) {
+	comment, ok := event.Data.(domain.TaskComment)
+	if !ok || comment.MessageID != nil {
+		return
+	}
+
+	var threadID, channelID string
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT m.id, m.channel_id FROM tasks t
+		JOIN messages m ON m.id = t.thread_message_id AND m.is_deleted = false
+		WHERE t.id = $1 AND t.deleted_at IS NULL
+	`, comment.TaskID).Scan(&threadID, &channelID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			app.Logger.WithError(err).Error("Failed to get task thread")
+		}
+		return
+	}
+
+	message, err := app.postMessage(ctx, outgoingMessage{
+		TeamID:        event.TeamID,
+		ChannelID:     channelID,
+		UserID:        comment.UserID,
+		Content:       comment.Content,
+		Type:          string(domain.MessageTypeText),
+		ReplyToID:     threadID,
+		TaskCommentID: comment.ID,
+	})
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to mirror task comment to thread")
+		return
+	}
+
+	if _, err := app.DB.ExecContext(ctx, `
+		UPDATE task_comments SET message_id = $2 WHERE id = $1
+	`, comment.ID, message["id"]); err != nil {
+		app.Logger.WithError(err).Error("Failed to link task comment to message")
+	}
+}
+
+// mirrorThreadReplyToTask adds replies in a task's thread to its comments.
+// Replies that were themselves mirrored from a comment are skipped.
+func (app *Application) mirrorThreadReplyToTask(ctx context.Context, event events.Event) {
+	message, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	messageID, _ := message["id"].(string)
+	threadID, _ := message["reply_to_id"].(string)
+	senderID, _ := message["sender_id"].(string)
+	content, _ := message["content"].(string)
+	if threadID == "" || message["task_comment_id"] != nil || message["type"] == string(domain.MessageTypeSystem) {
+		return
+	}
+
+	var taskID string
+	err := app.DB.QueryRowContext(ctx, `SELECT id FROM tasks WHERE thread_message_id = $1 AND deleted_at IS NULL`, threadID).Scan(&taskID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			app.Logger.WithError(err).Error("Failed to get thread task")
+		}
+		return
+	}
+
+	_, err = app.addTaskComment(ctx, event.TeamID, taskID, senderID, content, &messageID)
+	if err != nil && err != sql.ErrNoRows {
+		app.Logger.WithError(err).Error("Failed to mirror thread reply to task")
+	}
+}
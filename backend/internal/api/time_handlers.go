@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/timeparse"
+)
+
+// userLocation is the timezone times are read in for the user: the one given
+// explicitly, else the one from their working hours, else UTC.
+func (app *Application) userLocation(ctx context.Context, userID, timezone string) (*time.Location, error) {
+	if timezone == "" {
+		err := app.DB.QueryRowContext(ctx, `SELECT timezone FROM user_working_hours WHERE user_id = $1`, userID).Scan(&timezone)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(timezone)
+}
+
+// parseTimeHandler resolves a natural language time such as "tomorrow 9am"
+// or "next monday" in the user's timezone, so clients can show what it means
+// before scheduling anything with it.
+func (app *Application) parseTimeHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.ParseTimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		respondWithError(w, http.StatusBadRequest, "Text is required")
+		return
+	}
+
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Unknown timezone")
+			return
+		}
+	}
+
+	loc, err := app.userLocation(r.Context(), claims.UserID, req.Timezone)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get user timezone")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	now := time.Now()
+	resolved, err := timeparse.Parse(req.Text, now, loc)
+	if err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, "Couldn't understand \""+req.Text+"\". Try something like \"tomorrow 9am\", \"next monday\" or \"in 2 hours\"")
+		return
+	}
+
+	local := resolved.In(loc)
+	respondWithJSON(w, http.StatusOK, domain.ParsedTime{
+		Text:       req.Text,
+		ResolvedAt: resolved.UTC(),
+		LocalTime:  local.Format(time.RFC3339),
+		Display:    local.Format("Mon 2 Jan 2006 15:04 MST"),
+		Timezone:   loc.String(),
+		InPast:     !resolved.After(now),
+	})
+}
@@ -0,0 +1,471 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/apiversion"
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/messagepolicy"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+// undoWindow is how long a deletion can be undone. After that deleted tasks
+// are purged and the snapshots kept for undo are dropped.
+const undoWindow = 10 * time.Minute
+
+// errUndoForbidden means the actor can no longer reach the resource.
+var errUndoForbidden = errors.New("actor can no longer reach the resource")
+
+// newUndoableAction starts the record of an action that can be undone until
+// the window closes. The repository making the change fills in the
+// snapshot and stores it.
+func (app *Application) newUndoableAction(ctx context.Context, kind domain.UndoKind, teamID, actorID, resourceID string) domain.UndoableAction {
+	now := time.Now()
+	id := uuid.New().String()
+	return domain.UndoableAction{
+		ID:         id,
+		Kind:       kind,
+		TeamID:     teamID,
+		ActorID:    actorID,
+		ResourceID: resourceID,
+		URL:        apiversion.FromContext(ctx).Prefix() + "/undo/" + id,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(undoWindow),
+	}
+}
+
+// deleteTaskHandler hides a task instead of dropping it, so the deletion can
+// be undone; purgeExpiredUndos removes it for good afterwards.
+func (app *Application) deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	taskID := mux.Vars(r)["taskId"]
+
+	task, err := app.Tasks.Get(r.Context(), taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get task")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	teamID := task.TeamID
+
+	role, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !authz.Task(authz.NewSubject(claims, role), task.CreatedBy).CanDelete {
+		respondWithError(w, http.StatusForbidden, "Only the task's creator and team admins can delete it")
+		return
+	}
+
+	action := app.newUndoableAction(r.Context(), domain.UndoTaskDelete, teamID, claims.UserID, taskID)
+	err = app.Tasks.Delete(r.Context(), taskID, &action)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Task not found")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to delete task")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete task")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Task deleted",
+		"undo":    action,
+	})
+}
+
+// removeTeamMemberHandler removes someone from a team, or lets a member
+// leave. The owner can't be removed, and only the owner can remove admins.
+func (app *Application) removeTeamMemberHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+	userID := vars["userId"]
+
+	actorRole, err := app.getTeamRole(teamID, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusForbidden, "Access denied to this team")
+		} else {
+			app.Logger.WithError(err).Error("Failed to check team membership")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	memberRole, err := app.getTeamRole(teamID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Team member not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get team member")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	switch {
+	case memberRole == "owner":
+		respondWithError(w, http.StatusForbidden, "The team owner can't be removed")
+		return
+	case userID == claims.UserID:
+		// Anyone else may leave
+	case actorRole != "owner" && actorRole != "admin":
+		respondWithError(w, http.StatusForbidden, "Only team owners and admins can perform this action")
+		return
+	case memberRole == "admin" && actorRole != "owner":
+		respondWithError(w, http.StatusForbidden, "Only the team owner can remove admins")
+		return
+	}
+
+	action := app.newUndoableAction(r.Context(), domain.UndoMemberRemove, teamID, claims.UserID, userID)
+	err = app.Teams.RemoveMember(r.Context(), teamID, userID, &action)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Team member not found")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to remove team member")
+		respondWithError(w, http.StatusInternalServerError, "Failed to remove team member")
+		return
+	}
+	app.invalidateAccess(r.Context(), userCacheScope(userID))
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Team member removed",
+		"undo":    action,
+	})
+}
+
+// archiveChannelHandler makes a channel read-only without losing anything,
+// so it can be brought back by undoing the archive. Team admins can archive
+// any channel but the team's default and direct messages.
+func (app *Application) archiveChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	channelID := mux.Vars(r)["channelId"]
+
+	channel, err := app.Channels.Get(r.Context(), channelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	teamID := channel.TeamID
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	if channel.Type == domain.ChannelTypeDirect {
+		respondWithError(w, http.StatusBadRequest, "Direct message channels cannot be archived")
+		return
+	}
+	if channel.IsDefault {
+		respondWithError(w, http.StatusBadRequest, "Default channels cannot be archived")
+		return
+	}
+
+	action := app.newUndoableAction(r.Context(), domain.UndoChannelArchive, teamID, claims.UserID, channelID)
+	err = app.Channels.Archive(r.Context(), channelID, &action)
+	if err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			respondWithError(w, http.StatusConflict, "Channel is already archived")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to archive channel")
+		respondWithError(w, http.StatusInternalServerError, "Failed to archive channel")
+		return
+	}
+	app.invalidateAccess(r.Context(), channelCacheScope(channelID))
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Channel archived",
+		"undo":    action,
+	})
+}
+
+// unarchiveChannelHandler brings back an archived channel, for when the
+// archive can no longer be undone.
+func (app *Application) unarchiveChannelHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	channelID := mux.Vars(r)["channelId"]
+
+	current, err := app.Channels.Get(r.Context(), channelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get channel")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	if !app.requireTeamAdmin(w, current.TeamID, claims.UserID) {
+		return
+	}
+
+	channel, err := app.Channels.Unarchive(r.Context(), channelID)
+	if err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			respondWithError(w, http.StatusConflict, "Channel is not archived")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to unarchive channel")
+		respondWithError(w, http.StatusInternalServerError, "Failed to unarchive channel")
+		return
+	}
+	app.invalidateAccess(r.Context(), channelCacheScope(channelID))
+
+	respondWithJSON(w, http.StatusOK, channel)
+}
+
+// undoHandler takes back a deletion within the undo window and returns the
+// restored resource. Only the person who made the deletion can undo it.
+func (app *Application) undoHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	actionID := mux.Vars(r)["actionId"]
+
+	action, err := app.Undos.Get(r.Context(), actionID)
+	if err != nil && err != sql.ErrNoRows {
+		app.Logger.WithError(err).Error("Failed to get undoable action")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if err == sql.ErrNoRows || action.ActorID != claims.UserID {
+		respondWithError(w, http.StatusNotFound, "Action not found")
+		return
+	}
+
+	if action.UndoneAt != nil {
+		respondWithError(w, http.StatusConflict, "This action has already been undone")
+		return
+	}
+	if !time.Now().Before(action.ExpiresAt) {
+		respondWithError(w, http.StatusGone, "This action can no longer be undone")
+		return
+	}
+
+	var restored interface{}
+	switch action.Kind {
+	case domain.UndoMessageDelete:
+		restored, err = app.restoreMessage(r.Context(), claims, action)
+	case domain.UndoTaskDelete:
+		restored, err = app.restoreTask(r.Context(), claims, action)
+	case domain.UndoMemberRemove:
+		restored, err = app.restoreTeamMember(r.Context(), claims, action)
+	case domain.UndoChannelArchive:
+		restored, err = app.restoreChannel(r.Context(), claims, action)
+	default:
+		err = repository.ErrConflict
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUndoUnavailable):
+			respondWithError(w, http.StatusConflict, "This action has already been undone or has expired")
+		case errors.Is(err, repository.ErrConflict):
+			respondWithError(w, http.StatusConflict, "This can no longer be restored")
+		case errors.Is(err, errUndoForbidden):
+			respondWithError(w, http.StatusForbidden, "You no longer have access to restore this")
+		default:
+			app.Logger.WithError(err).Error("Failed to undo action")
+			respondWithError(w, http.StatusInternalServerError, "Failed to undo action")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, restored)
+}
+
+func (app *Application) restoreMessage(ctx context.Context, claims *middleware.Claims, action domain.UndoableAction) (map[string]interface{}, error) {
+	var snapshot repository.DeletedMessage
+	if err := json.Unmarshal(action.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	access, err := app.getChannelAccess(snapshot.ChannelID, claims.UserID)
+	if err == sql.ErrNoRows || (err == nil && access.HistoryUntil != nil) {
+		return nil, errUndoForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := app.Messages.Restore(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := app.messageActor(access, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := app.loadMessagePolicy(ctx, access.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := map[string]interface{}{
+		"id":         message.ID,
+		"team_id":    access.TeamID,
+		"channel_id": message.ChannelID,
+		"content":    message.Content,
+		"type":       message.Type,
+		"sender_id":  message.UserID,
+		"is_edited":  message.IsEdited,
+		"created_at": message.CreatedAt,
+		"updated_at": message.UpdatedAt,
+		"permissions": authz.Message(authz.NewSubject(claims, actor.Role), policy, messagepolicy.Message{
+			SenderID:  message.UserID,
+			Type:      string(message.Type),
+			CreatedAt: message.CreatedAt,
+		}, time.Now(), false),
+	}
+	if message.ReplyToID != nil {
+		restored["reply_to_id"] = *message.ReplyToID
+	}
+	return restored, nil
+}
+
+func (app *Application) restoreTask(ctx context.Context, claims *middleware.Claims, action domain.UndoableAction) (map[string]interface{}, error) {
+	role, err := app.getTeamRole(action.TeamID, claims.UserID)
+	if err == sql.ErrNoRows {
+		return nil, errUndoForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := app.Tasks.Restore(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+
+	task := taskMap(restored)
+	task["permissions"] = authz.Task(authz.NewSubject(claims, role), restored.CreatedBy)
+	return task, nil
+}
+
+// restoreTeamMember puts a removed member back with their old role and
+// channels. Admins who removed someone need to still be admins; people who
+// left can always rejoin.
+func (app *Application) restoreTeamMember(ctx context.Context, claims *middleware.Claims, action domain.UndoableAction) (map[string]interface{}, error) {
+	userID := action.ResourceID
+
+	if userID != claims.UserID {
+		role, err := app.getTeamRole(action.TeamID, claims.UserID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if role != "owner" && role != "admin" {
+			return nil, errUndoForbidden
+		}
+	}
+
+	member, err := app.Teams.RestoreMember(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	app.invalidateAccess(ctx, userCacheScope(userID))
+
+	user := map[string]interface{}{
+		"email":      member.User.Email,
+		"username":   member.User.Username,
+		"first_name": member.User.FirstName,
+		"last_name":  member.User.LastName,
+	}
+	if member.User.Avatar != "" {
+		user["avatar"] = member.User.Avatar
+	}
+	return map[string]interface{}{
+		"user_id":    userID,
+		"role":       member.Role,
+		"joined_at":  member.JoinedAt,
+		"updated_at": member.UpdatedAt,
+		"user":       user,
+	}, nil
+}
+
+// restoreChannel unarchives a channel. Whoever archived it needs to still be
+// a team admin.
+func (app *Application) restoreChannel(ctx context.Context, claims *middleware.Claims, action domain.UndoableAction) (*domain.Channel, error) {
+	role, err := app.getTeamRole(action.TeamID, claims.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if role != "owner" && role != "admin" {
+		return nil, errUndoForbidden
+	}
+
+	// The channel may have been deleted or unarchived since
+	channel, err := app.Channels.Restore(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	app.invalidateAccess(ctx, channelCacheScope(channel.ID))
+	return &channel, nil
+}
+
+// purgeExpiredUndos makes deletions final once they can no longer be undone:
+// deleted tasks are removed and the snapshots kept for undo are dropped.
+func (app *Application) purgeExpiredUndos(ctx context.Context) error {
+	_, err := app.DB.ExecContext(ctx, `
+		DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	`, time.Now().Add(-undoWindow))
+	if err != nil {
+		return err
+	}
+
+	_, err = app.DB.ExecContext(ctx, `DELETE FROM undoable_actions WHERE expires_at < NOW()`)
+	return err
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cbalite/backend/internal/metrics"
+)
+
+// publicStatusTTL is how long a /status result is reused. Uptime monitors
+// poll often and the checks behind the answer shouldn't run on every poll.
+const publicStatusTTL = 10 * time.Second
+
+// publicStatusHandler answers external uptime checks with the overall state,
+// version and region only; per-service detail stays on /api/v1/health.
+func (app *Application) publicStatusHandler() http.HandlerFunc {
+	var mu sync.Mutex
+	var healthy bool
+	var checkedAt time.Time
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if time.Since(checkedAt) >= publicStatusTTL {
+			healthy = app.DB.Ready() && app.DB.HealthCheck() == nil && app.Cache.HealthCheck() == nil
+			checkedAt = time.Now()
+		}
+		ok, at := healthy, checkedAt
+		mu.Unlock()
+
+		status, code := "ok", http.StatusOK
+		if !ok {
+			status, code = "degraded", http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(publicStatusTTL.Seconds())))
+		respondWithJSON(w, code, map[string]interface{}{
+			"status":     status,
+			"version":    app.Config.App.Version,
+			"region":     app.Config.App.Region,
+			"checked_at": at,
+		})
+	}
+}
+
+// readyHandler answers 503 while the database checks are failing, as
+// during a failover, so load balancers can take the server out of rotation
+// until it has reconnected. It reads the last check rather than running one.
+func (app *Application) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.DB.Ready() {
+		respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable"})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// metricsHandler serves the Prometheus metrics, to scrapers holding
+// METRICS_TOKEN when one is set. Like /status it sits outside the API
+// stack, so scrapes aren't rate limited or logged as API calls.
+func (app *Application) metricsHandler() http.Handler {
+	expected := []byte("Bearer " + app.Config.Metrics.Token)
+	handler := metrics.Default.Handler()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.Config.Metrics.Token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			respondWithError(w, http.StatusUnauthorized, "Invalid metrics token")
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
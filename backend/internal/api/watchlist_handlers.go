@@ -0,0 +1,431 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/watchlist"
+)
+
+const watchListColumns = `id, team_id, name, keywords, patterns, case_sensitive, notify_channel_id, is_enabled, created_by, created_at, updated_at`
+
+func scanWatchList(row rowScanner) (domain.WatchList, error) {
+	var list domain.WatchList
+	err := row.Scan(&list.ID, &list.TeamID, &list.Name, pq.Array(&list.Keywords), pq.Array(&list.Patterns),
+		&list.CaseSensitive, &list.NotifyChannelID, &list.IsEnabled, &list.CreatedBy, &list.CreatedAt, &list.UpdatedAt)
+	if list.Keywords == nil {
+		list.Keywords = []string{}
+	}
+	if list.Patterns == nil {
+		list.Patterns = []string{}
+	}
+	return list, err
+}
+
+// validateWatchList checks a list is ready to store, writing the error
+// response if not: it must have a name, compile, and notify a channel of
+// its own team.
+func (app *Application) validateWatchList(w http.ResponseWriter, ctx context.Context, list domain.WatchList) bool {
+	if list.Name == "" || len(list.Name) > 100 {
+		respondWithError(w, http.StatusBadRequest, "Watch list name must be 1-100 characters")
+		return false
+	}
+
+	if _, err := watchlist.Compile(list); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	if list.NotifyChannelID == nil {
+		return true
+	}
+	if !middleware.IsCanonicalUUID(*list.NotifyChannelID) {
+		respondWithError(w, http.StatusBadRequest, "notify_channel_id must be a UUID")
+		return false
+	}
+
+	var exists bool
+	err := app.DB.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM channels WHERE id = $1 AND team_id = $2)
+	`, *list.NotifyChannelID, list.TeamID).Scan(&exists)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to check notify channel")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return false
+	}
+	if !exists {
+		respondWithError(w, http.StatusBadRequest, "The notify channel must belong to the team")
+		return false
+	}
+	return true
+}
+
+func (app *Application) createWatchListHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	var req domain.CreateWatchList
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	list := domain.WatchList{
+		TeamID:          teamID,
+		Name:            strings.TrimSpace(req.Name),
+		Keywords:        watchlist.Normalize(req.Keywords),
+		Patterns:        watchlist.Normalize(req.Patterns),
+		CaseSensitive:   req.CaseSensitive,
+		NotifyChannelID: req.NotifyChannelID,
+	}
+	if !app.validateWatchList(w, r.Context(), list) {
+		return
+	}
+
+	list, err := scanWatchList(app.DB.QueryRowContext(r.Context(), `
+		INSERT INTO watch_lists (id, team_id, name, keywords, patterns, case_sensitive, notify_channel_id, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		RETURNING `+watchListColumns,
+		uuid.New().String(), list.TeamID, list.Name, pq.Array(list.Keywords), pq.Array(list.Patterns),
+		list.CaseSensitive, list.NotifyChannelID, claims.UserID))
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A watch list with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to create watch list")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create watch list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, list)
+}
+
+func (app *Application) getWatchListsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	lists, err := app.loadWatchLists(r.Context(), teamID, false)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get watch lists")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func (app *Application) updateWatchListHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.UpdateWatchList
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	list, ok := app.loadWatchList(w, r.Context(), mux.Vars(r)["watchListId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, list.TeamID, claims.UserID) {
+		return
+	}
+
+	if req.Name != nil {
+		list.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Keywords != nil {
+		list.Keywords = watchlist.Normalize(*req.Keywords)
+	}
+	if req.Patterns != nil {
+		list.Patterns = watchlist.Normalize(*req.Patterns)
+	}
+	if req.CaseSensitive != nil {
+		list.CaseSensitive = *req.CaseSensitive
+	}
+	if req.NotifyChannelID != nil {
+		list.NotifyChannelID = req.NotifyChannelID
+		if *req.NotifyChannelID == "" {
+			list.NotifyChannelID = nil
+		}
+	}
+	if req.IsEnabled != nil {
+		list.IsEnabled = *req.IsEnabled
+	}
+
+	if !app.validateWatchList(w, r.Context(), list) {
+		return
+	}
+
+	list, err := scanWatchList(app.DB.QueryRowContext(r.Context(), `
+		UPDATE watch_lists
+		SET name = $2, keywords = $3, patterns = $4, case_sensitive = $5, notify_channel_id = $6, is_enabled = $7
+		WHERE id = $1
+		RETURNING `+watchListColumns,
+		list.ID, list.Name, pq.Array(list.Keywords), pq.Array(list.Patterns), list.CaseSensitive,
+		list.NotifyChannelID, list.IsEnabled))
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "A watch list with this name already exists")
+			return
+		}
+		app.Logger.WithError(err).Error("Failed to update watch list")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update watch list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func (app *Application) deleteWatchListHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	list, ok := app.loadWatchList(w, r.Context(), mux.Vars(r)["watchListId"])
+	if !ok {
+		return
+	}
+
+	if !app.requireTeamAdmin(w, list.TeamID, claims.UserID) {
+		return
+	}
+
+	if _, err := app.DB.ExecContext(r.Context(), `DELETE FROM watch_lists WHERE id = $1`, list.ID); err != nil {
+		app.Logger.WithError(err).Error("Failed to delete watch list")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete watch list")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Watch list deleted successfully"})
+}
+
+// getWatchListMatchesHandler is the audit of flagged messages, newest
+// first, optionally for one list. Matches outlive deleted lists.
+func (app *Application) getWatchListMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	teamID := mux.Vars(r)["teamId"]
+	listID := r.URL.Query().Get("watch_list_id")
+	if listID != "" && !middleware.IsCanonicalUUID(listID) {
+		respondWithError(w, http.StatusBadRequest, "watch_list_id must be a UUID")
+		return
+	}
+
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 200 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = parsed
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	rows, err := app.DB.QueryContext(r.Context(), `
+		SELECT id, watch_list_id, watch_list_name, team_id, message_id, channel_id, user_id, hits, created_at
+		FROM watch_list_matches
+		WHERE team_id = $1 AND ($2 = '' OR watch_list_id::text = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, teamID, listID, limit)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get watch list matches")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer rows.Close()
+
+	matches := []domain.WatchListMatch{}
+	for rows.Next() {
+		var match domain.WatchListMatch
+		var hits []byte
+		err := rows.Scan(&match.ID, &match.WatchListID, &match.WatchListName, &match.TeamID, &match.MessageID,
+			&match.ChannelID, &match.UserID, &hits, &match.CreatedAt)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to scan watch list match row")
+			continue
+		}
+		if err := json.Unmarshal(hits, &match.Hits); err != nil {
+			app.Logger.WithError(err).Error("Failed to decode watch list hits")
+			continue
+		}
+		matches = append(matches, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		app.Logger.WithError(err).Error("Error iterating watch list match rows")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, matches)
+}
+
+func (app *Application) loadWatchList(w http.ResponseWriter, ctx context.Context, listID string) (domain.WatchList, bool) {
+	list, err := scanWatchList(app.DB.QueryRowContext(ctx, `SELECT `+watchListColumns+` FROM watch_lists WHERE id = $1`, listID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Watch list not found")
+		} else {
+			app.Logger.WithError(err).Error("Failed to get watch list")
+			respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return list, false
+	}
+	return list, true
+}
+
+func (app *Application) loadWatchLists(ctx context.Context, teamID string, enabledOnly bool) ([]domain.WatchList, error) {
+	rows, err := app.DB.QueryContext(ctx, `
+		SELECT `+watchListColumns+`
+		FROM watch_lists
+		WHERE team_id = $1 AND (is_enabled OR NOT $2)
+		ORDER BY name
+	`, teamID, enabledOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []domain.WatchList{}
+	for rows.Next() {
+		list, err := scanWatchList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
+
+// checkWatchLists runs each new message past the team's enabled watch
+// lists. A match is audited, announced in the list's notify channel and
+// published as message.flagged for automation rules. System messages are
+// skipped, so the announcements can't flag themselves.
+func (app *Application) checkWatchLists(ctx context.Context, event events.Event) {
+	message, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	messageID, _ := message["id"].(string)
+	content, _ := message["content"].(string)
+	if message["type"] == string(domain.MessageTypeSystem) || messageID == "" || content == "" {
+		return
+	}
+
+	lists, err := app.loadWatchLists(ctx, event.TeamID, true)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get watch lists")
+		return
+	}
+
+	for _, list := range lists {
+		matcher, err := watchlist.Compile(list)
+		if err != nil {
+			app.Logger.WithError(err).Errorf("Skipping watch list %s", list.ID)
+			continue
+		}
+
+		hits := matcher.Match(content)
+		if len(hits) == 0 {
+			continue
+		}
+
+		app.flagMessage(ctx, event, message, list, hits)
+	}
+}
+
+func (app *Application) flagMessage(ctx context.Context, event events.Event, message map[string]interface{}, list domain.WatchList, hits []domain.WatchListHit) {
+	messageID, _ := message["id"].(string)
+	channelID, _ := message["channel_id"].(string)
+
+	encoded, err := json.Marshal(hits)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to encode watch list hits")
+		return
+	}
+
+	_, err = app.DB.ExecContext(ctx, `
+		INSERT INTO watch_list_matches (watch_list_id, watch_list_name, team_id, message_id, channel_id, user_id, hits)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, list.ID, list.Name, event.TeamID, messageID, channelID, event.ActorID, encoded)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to record watch list match")
+	}
+
+	if list.NotifyChannelID != nil {
+		channel, err := app.getChannelMeta(ctx, channelID)
+		if err != nil {
+			app.Logger.WithError(err).Error("Failed to get flagged message's channel")
+		}
+		sender, _ := message["sender"].(map[string]interface{})
+		username, _ := sender["username"].(string)
+
+		notice := watchlist.Notice(list, username, channel.Name, hits)
+		if _, err := app.createMessage(ctx, list.TeamID, *list.NotifyChannelID, list.CreatedBy, notice, string(domain.MessageTypeSystem)); err != nil {
+			app.Logger.WithError(err).Error("Failed to post watch list notice")
+		}
+	}
+
+	// Other subscribers share the original payload, so build a copy
+	flagged := make(map[string]interface{}, len(message)+3)
+	for key, value := range message {
+		flagged[key] = value
+	}
+	flagged["watch_list"] = map[string]interface{}{"id": list.ID, "name": list.Name}
+	flagged["hits"] = hits
+	flagged["terms"] = watchlist.Terms(hits)
+
+	app.Events.Publish(ctx, events.Event{
+		Type:    events.MessageFlagged,
+		TeamID:  event.TeamID,
+		ActorID: event.ActorID,
+		Data:    flagged,
+	})
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/automation"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+const maxWelcomeTemplateLength = 4000
+
+func (app *Application) loadWelcomeSettings(teamID string) (domain.TeamWelcomeSettings, error) {
+	settings := domain.TeamWelcomeSettings{TeamID: teamID}
+
+	err := app.DB.QueryRow(`
+		SELECT enabled, template, sender_id, updated_at
+		FROM team_welcome_settings
+		WHERE team_id = $1
+	`, teamID).Scan(&settings.Enabled, &settings.Template, &settings.SenderID, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+
+	return settings, err
+}
+
+func (app *Application) getWelcomeSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	settings, err := app.loadWelcomeSettings(teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get welcome settings")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	app.respondWithWelcomeSettings(w, r, settings, claims.UserID)
+}
+
+func (app *Application) updateWelcomeSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+
+	var req domain.UpdateTeamWelcomeSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if !app.requireTeamAdmin(w, teamID, claims.UserID) {
+		return
+	}
+
+	settings, err := app.loadWelcomeSettings(teamID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to get welcome settings")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Template != nil {
+		if len(*req.Template) > maxWelcomeTemplateLength {
+			respondWithError(w, http.StatusBadRequest, "Welcome template is too long")
+			return
+		}
+		settings.Template = *req.Template
+	}
+	if req.SenderID != nil {
+		// An empty sender falls back to the team owner
+		if *req.SenderID == "" {
+			settings.SenderID = nil
+		} else {
+			if _, err := app.getTeamRole(teamID, *req.SenderID); err != nil {
+				if err == sql.ErrNoRows {
+					respondWithError(w, http.StatusBadRequest, "Welcome sender must be a team member")
+				} else {
+					app.Logger.WithError(err).Error("Failed to check team membership")
+					respondWithError(w, http.StatusInternalServerError, "Internal server error")
+				}
+				return
+			}
+			settings.SenderID = req.SenderID
+		}
+	}
+
+	if settings.Enabled && settings.Template == "" {
+		respondWithError(w, http.StatusBadRequest, "A template is required to enable welcome messages")
+		return
+	}
+
+	err = app.DB.QueryRow(`
+		INSERT INTO team_welcome_settings (team_id, enabled, template, sender_id, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (team_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled, template = EXCLUDED.template, sender_id = EXCLUDED.sender_id
+		RETURNING updated_at
+	`, teamID, settings.Enabled, settings.Template, settings.SenderID).Scan(&settings.UpdatedAt)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to update welcome settings")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update welcome settings")
+		return
+	}
+
+	app.respondWithWelcomeSettings(w, r, settings, claims.UserID)
+}
+
+// respondWithWelcomeSettings includes the team's default channels and a
+// preview of the template rendered as if the caller had just joined.
+func (app *Application) respondWithWelcomeSettings(w http.ResponseWriter, r *http.Request, settings domain.TeamWelcomeSettings, userID string) {
+	data, err := app.welcomeTemplateData(r.Context(), settings.TeamID, userID, userID)
+	if err != nil {
+		app.Logger.WithError(err).Error("Failed to build welcome template data")
+		respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"settings":         settings,
+		"default_channels": data["default_channels"],
+		"preview":          automation.Render(settings.Template, data),
+	})
+}
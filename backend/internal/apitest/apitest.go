@@ -0,0 +1,119 @@
+// Package apitest runs the API in memory for end-to-end handler tests: the
+// real router and middleware over fake repositories, an in-memory cache and
+// a hub on a fake clock, with no Postgres or Redis.
+//
+//	srv := apitest.NewServer(t)
+//	c := client.New(srv.URL)
+//	session, err := c.Register(ctx, client.Registration{...})
+//
+// Only the endpoints behind the repositories work this way, which covers
+// what pkg/client calls. The rest of the API still queries the database
+// and answers 500.
+package apitest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cbalite/backend/internal/api"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/deprecation"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/features"
+	"github.com/cbalite/backend/internal/inbound"
+	"github.com/cbalite/backend/internal/llm"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/internal/repository/fakes"
+	"github.com/cbalite/backend/internal/throttle"
+	"github.com/cbalite/backend/internal/websocket"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// Server is an API server on a local port.
+type Server struct {
+	*httptest.Server
+	// App is the application behind the server, to reach its hub, cache
+	// and event bus.
+	App *api.Application
+	// DB is what the fake repositories store, to seed data and check
+	// what requests left behind.
+	DB *fakes.DB
+	// Clock drives the hub's timers, which only fire when it is advanced.
+	Clock *websocket.FakeClock
+}
+
+// NewServer starts a server that is closed when the test ends. Rate limits
+// and admission control are off, so tests can send as much as they like.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	cfg := &config.Config{
+		App: config.AppConfig{Env: "test"},
+		JWT: config.JWTConfig{
+			SecretKey:          "apitest-secret",
+			AccessTokenExpiry:  15 * time.Minute,
+			RefreshTokenExpiry: 24 * time.Hour,
+			AppTokenExpiry:     time.Hour,
+		},
+		CORS: config.CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "Authorization"},
+		},
+	}
+
+	log := logger.Nop()
+	memory := cache.NewMemory()
+	db := fakes.New()
+	clock := websocket.NewFakeClock(time.Now())
+
+	app := &api.Application{
+		Config:         cfg,
+		Logger:         log,
+		Repositories:   db.Repositories(),
+		Cache:          memory,
+		Invalidator:    cache.NewInvalidator(memory, broadcaster{}, log),
+		WSHub:          websocket.NewTestHub(log, clock),
+		Events:         events.NewBus(log),
+		LLM:            llm.New(&cfg.LLM),
+		Inbound:        inbound.NewRegistry(),
+		Throttle:       throttle.NewLimiter(&cfg.RateLimit, memory),
+		Features:       features.Parse(""),
+		Deprecations:   deprecation.NewTracker(deprecation.Notices),
+		AuthMiddleware: middleware.NewAuthMiddleware(&cfg.JWT, log),
+	}
+	app.Setup()
+	// The hub delivers to WebSocket clients as usual; rooms are only
+	// compacted when Clock moves
+	go app.WSHub.Run()
+
+	srv := &Server{
+		Server: httptest.NewServer(app.Handler(pool{})),
+		App:    app,
+		DB:     db,
+		Clock:  clock,
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// broadcaster has no other instances to tell about invalidations.
+type broadcaster struct{}
+
+func (broadcaster) Notify(ctx context.Context, channel, payload string) error {
+	return nil
+}
+
+func (broadcaster) Listen(ctx context.Context, channel string, handle func(payload string), reconnected func()) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// pool always has a connection free.
+type pool struct{}
+
+func (pool) Admit(ctx context.Context) error {
+	return nil
+}
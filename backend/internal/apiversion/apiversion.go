@@ -0,0 +1,150 @@
+package apiversion
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cbalite/backend/pkg/httpjson"
+)
+
+// Version is a major version of the REST API. Each one is served under its
+// own path prefix, /api/v1, /api/v2 and so on.
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+
+	// Latest is the newest version this server speaks.
+	Latest = V2
+)
+
+// Supported lists the versions this server serves, oldest first.
+var Supported = []Version{V1, V2}
+
+// mediaTypePrefix starts the vendor media types clients can put in Accept
+// to pick a version without changing paths, e.g.
+// "application/vnd.cbalite.v2+json".
+const mediaTypePrefix = "application/vnd.cbalite."
+
+func (v Version) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+// MarshalText makes versions appear as "v2" in JSON.
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Prefix is the path the version's routes are mounted under.
+func (v Version) Prefix() string {
+	return "/api/" + v.String()
+}
+
+// Parse reads a version as "v2" or "2".
+func Parse(s string) (Version, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(s), "v"))
+	if err != nil {
+		return 0, false
+	}
+	for _, v := range Supported {
+		if int(v) == n {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+type contextKey struct{}
+
+// FromContext returns the version the request is served as. Requests that
+// didn't go through Pin are treated as v1.
+func FromContext(ctx context.Context) Version {
+	if v, ok := ctx.Value(contextKey{}).(Version); ok {
+		return v
+	}
+	return V1
+}
+
+// Pin marks every request through a version's router with that version,
+// so handlers shared between versions can tell them apart.
+func Pin(v Version) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("API-Version", v.String())
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, v)))
+		})
+	}
+}
+
+// Negotiate lets clients that can't change paths pick a version with the
+// Accept header. A request for /api/v1/... that accepts
+// "application/vnd.cbalite.v2+json" is routed as /api/v2/...; an explicit
+// media type version wins over the one in the path. It must wrap the router,
+// since routing depends on the rewritten path.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current, rest, ok := split(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+
+		requested, named := accepted(r.Header.Get("Accept"))
+		if !named {
+			next.ServeHTTP(w, r)
+			return
+		}
+		v, ok := Parse(requested)
+		if !ok {
+			httpjson.Respond(w, http.StatusNotAcceptable, map[string]interface{}{
+				"error":     "Unsupported API version " + requested,
+				"code":      "unsupported_api_version",
+				"supported": Supported,
+			})
+			return
+		}
+		if v.String() == current {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rewritten := new(http.Request)
+		*rewritten = *r
+		url := *r.URL
+		url.Path = v.Prefix() + rest
+		url.RawPath = ""
+		rewritten.URL = &url
+		next.ServeHTTP(w, rewritten)
+	})
+}
+
+// split breaks "/api/v1/teams" into "v1" and "/teams".
+func split(path string) (string, string, bool) {
+	if !strings.HasPrefix(path, "/api/v") {
+		return "", "", false
+	}
+	segment := strings.TrimPrefix(path, "/api/")
+	rest := ""
+	if i := strings.IndexByte(segment, '/'); i >= 0 {
+		segment, rest = segment[:i], segment[i:]
+	}
+	return segment, rest, true
+}
+
+// accepted returns the version named by the first vendor media type in an
+// Accept header, such as "v2" for "application/vnd.cbalite.v2+json".
+func accepted(accept string) (string, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.HasPrefix(mediaType, mediaTypePrefix) {
+			continue
+		}
+		return strings.TrimSuffix(strings.TrimPrefix(mediaType, mediaTypePrefix), "+json"), true
+	}
+	return "", false
+}
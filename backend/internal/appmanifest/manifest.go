@@ -0,0 +1,161 @@
+package appmanifest
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/hooks"
+)
+
+const (
+	maxWebhooks = 20
+	maxCommands = 20
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{2,39}$`)
+	commandPattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+)
+
+// eventScopes is the scope an app needs to receive each webhook event.
+var eventScopes = map[events.Type]authz.Scope{
+	events.MessagePosted: authz.MessagesRead,
+	events.TaskCreated:   authz.TasksRead,
+	events.TaskUpdated:   authz.TasksRead,
+	events.MemberJoined:  authz.ChannelsRead,
+}
+
+// ValidationError lists every problem found in a manifest so developers can
+// fix them in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid app manifest: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks a manifest and normalises it in place. reserved lists the
+// slash command names the server already handles.
+func Validate(m *domain.AppManifest, reserved []string) error {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" || len(m.Name) > 100 {
+		fail("name is required and must be at most 100 characters")
+	}
+
+	for _, scope := range m.Scopes {
+		if !authz.IsValidScope(scope) {
+			fail("unknown scope %q", scope)
+		}
+	}
+
+	for _, uri := range m.RedirectURIs {
+		if !ValidRedirectURI(uri) {
+			fail("redirect URI %q must be an https URL without a fragment, or http on a loopback host", uri)
+		}
+	}
+
+	if m.Bot != nil {
+		m.Bot.Username = strings.ToLower(strings.TrimSpace(m.Bot.Username))
+		m.Bot.DisplayName = strings.TrimSpace(m.Bot.DisplayName)
+		if !usernamePattern.MatchString(m.Bot.Username) {
+			fail("bot username must be 3-40 lowercase letters, digits, dashes or underscores")
+		}
+		if m.Bot.DisplayName == "" {
+			m.Bot.DisplayName = m.Name
+		}
+		if len([]rune(m.Bot.DisplayName)) > 50 {
+			fail("bot display_name must be at most 50 characters")
+		}
+	}
+
+	if len(m.Webhooks) > maxWebhooks {
+		fail("at most %d webhooks are allowed", maxWebhooks)
+	}
+	for _, webhook := range m.Webhooks {
+		if !hooks.IsSupportedEvent(webhook.Event) {
+			fail("webhook event %q is not supported", webhook.Event)
+			continue
+		}
+		if scope := eventScopes[events.Type(webhook.Event)]; !authz.HasScope(m.Scopes, scope) {
+			fail("webhook event %q needs the %s scope", webhook.Event, scope)
+		}
+		if !isAbsoluteURL(webhook.URL, true) {
+			fail("webhook URL %q must be an http(s) URL", webhook.URL)
+		}
+	}
+
+	if len(m.Commands) > maxCommands {
+		fail("at most %d commands are allowed", maxCommands)
+	}
+	seen := make(map[string]bool)
+	for i := range m.Commands {
+		command := &m.Commands[i]
+		command.Name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(command.Name), "/"))
+		switch {
+		case !commandPattern.MatchString(command.Name):
+			fail("command name %q must be 1-32 lowercase letters, digits or dashes", command.Name)
+		case seen[command.Name]:
+			fail("command /%s is declared twice", command.Name)
+		case contains(reserved, command.Name):
+			fail("command /%s is built in", command.Name)
+		}
+		seen[command.Name] = true
+
+		if !isAbsoluteURL(command.URL, true) {
+			fail("command URL %q must be an http(s) URL", command.URL)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidRedirectURI reports whether authorization codes may be sent to raw:
+// an https URL without a fragment, or plain http to a loopback host for
+// apps being developed locally.
+func ValidRedirectURI(raw string) bool {
+	if !isAbsoluteURL(raw, true) {
+		return false
+	}
+	parsed, _ := url.Parse(raw)
+	if parsed.Scheme == "https" {
+		return true
+	}
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func isAbsoluteURL(raw string, httpOnly bool) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" || parsed.Fragment != "" {
+		return false
+	}
+	return !httpOnly || parsed.Scheme == "http" || parsed.Scheme == "https"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
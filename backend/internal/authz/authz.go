@@ -0,0 +1,159 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/pkg/httpjson"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// Scope is a permission a team grants a third-party app when installing it.
+type Scope string
+
+const (
+	ChannelsRead  Scope = "channels:read"
+	MessagesRead  Scope = "messages:read"
+	MessagesWrite Scope = "messages:write"
+	TasksRead     Scope = "tasks:read"
+	TasksWrite    Scope = "tasks:write"
+)
+
+var AllScopes = []Scope{ChannelsRead, MessagesRead, MessagesWrite, TasksRead, TasksWrite}
+
+var ErrNotInstalled = errors.New("app is not installed for this team")
+
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if string(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes splits a space separated OAuth scope string.
+func ParseScopes(value string) []string {
+	return strings.Fields(value)
+}
+
+func HasScope(scopes []string, scope Scope) bool {
+	for _, s := range scopes {
+		if s == string(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store looks up what the authorizer can't tell from the request alone.
+type Store interface {
+	// InstalledScopes returns the scopes the team currently grants the
+	// app, or ErrNotInstalled.
+	InstalledScopes(ctx context.Context, teamID, appID string) ([]string, error)
+
+	// ResourceTeam resolves the team owning a path parameter such as
+	// channelId or taskId, or "" when there is no such resource.
+	ResourceTeam(ctx context.Context, param, id string) (string, error)
+}
+
+// Authorizer restricts app tokens to the routes their scopes cover. Tokens
+// issued to people at login carry no app and are left alone; every route
+// without a rule is closed to apps.
+type Authorizer struct {
+	rules  map[string]Scope
+	store  Store
+	logger *logger.Logger
+}
+
+func NewAuthorizer(store Store, logger *logger.Logger) *Authorizer {
+	return &Authorizer{
+		rules:  make(map[string]Scope),
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Require opens the route with the given method and path template to apps
+// holding scope.
+func (a *Authorizer) Require(method, pathTemplate string, scope Scope) {
+	a.rules[method+" "+pathTemplate] = scope
+}
+
+// Enforce must run after authentication.
+func (a *Authorizer) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := middleware.GetUserFromContext(r.Context())
+		if !ok || claims.AppID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var scope Scope
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				scope = a.rules[r.Method+" "+template]
+			}
+		}
+		if scope == "" {
+			respondWithError(w, http.StatusForbidden, "This endpoint is not available to apps")
+			return
+		}
+		if !HasScope(claims.Scopes, scope) {
+			respondWithError(w, http.StatusForbidden, "Token is missing the "+string(scope)+" scope")
+			return
+		}
+
+		// Uninstalling an app or narrowing its scopes takes effect on
+		// tokens that were already issued.
+		installed, err := a.store.InstalledScopes(r.Context(), claims.TeamID, claims.AppID)
+		if err != nil {
+			if errors.Is(err, ErrNotInstalled) {
+				respondWithError(w, http.StatusUnauthorized, "App is no longer installed")
+			} else {
+				a.logger.WithError(err).Error("Failed to check app installation")
+				respondWithError(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		if !HasScope(installed, scope) {
+			respondWithError(w, http.StatusForbidden, "The team has not granted the "+string(scope)+" scope")
+			return
+		}
+
+		if !a.sameTeam(w, r, claims.TeamID) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameTeam keeps app tokens inside the team that installed the app.
+func (a *Authorizer) sameTeam(w http.ResponseWriter, r *http.Request, teamID string) bool {
+	for param, value := range mux.Vars(r) {
+		owner := value
+		if param != "teamId" {
+			var err error
+			owner, err = a.store.ResourceTeam(r.Context(), param, value)
+			if err != nil {
+				a.logger.WithError(err).Errorf("Failed to resolve team for %s", param)
+				respondWithError(w, http.StatusInternalServerError, "Internal server error")
+				return false
+			}
+		}
+		if owner != teamID {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return false
+		}
+	}
+	return true
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	httpjson.Error(w, code, message)
+}
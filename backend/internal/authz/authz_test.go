@@ -0,0 +1,232 @@
+package authz_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/authz"
+	"github.com/cbalite/backend/internal/authz/fakes"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// newRouter serves a few routes behind the authorizer, authenticating every
+// request as claims. Channel c1 belongs to t1 and c2 to t2.
+func newRouter(store *fakes.Store, claims *middleware.Claims) http.Handler {
+	authorizer := authz.NewAuthorizer(store, logger.Nop())
+	authorizer.Require(http.MethodGet, "/channels/{channelId}/messages", authz.MessagesRead)
+	authorizer.Require(http.MethodPost, "/channels/{channelId}/messages", authz.MessagesWrite)
+	authorizer.Require(http.MethodGet, "/teams/{teamId}/tasks", authz.TasksRead)
+	store.SetOwner("channelId", "c1", "t1")
+	store.SetOwner("channelId", "c2", "t2")
+
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), middleware.UserContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, authorizer.Enforce)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	r.HandleFunc("/channels/{channelId}/messages", ok).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/teams/{teamId}/tasks", ok).Methods(http.MethodGet)
+	r.HandleFunc("/teams/{teamId}/members", ok).Methods(http.MethodGet)
+	return r
+}
+
+func TestEnforce(t *testing.T) {
+	app := func(scopes ...string) *middleware.Claims {
+		return &middleware.Claims{UserID: "u1", AppID: "a1", TeamID: "t1", Scopes: scopes}
+	}
+	tests := []struct {
+		name      string
+		claims    *middleware.Claims
+		installed []string // scopes t1 grants a1; nil when it isn't installed
+		storeErr  error
+		method    string
+		path      string
+		want      int
+		message   string
+	}{
+		{
+			name:   "user token",
+			claims: &middleware.Claims{UserID: "u1"}, method: http.MethodGet, path: "/teams/t2/members",
+			want: http.StatusNoContent,
+		},
+		{
+			name:   "app with the scope",
+			claims: app("messages:read"), installed: []string{"messages:read"},
+			method: http.MethodGet, path: "/channels/c1/messages",
+			want: http.StatusNoContent,
+		},
+		{
+			name:   "route without a rule",
+			claims: app("messages:read"), installed: []string{"messages:read"},
+			method: http.MethodGet, path: "/teams/t1/members",
+			want: http.StatusForbidden, message: "not available to apps",
+		},
+		{
+			name:   "token without the scope",
+			claims: app("messages:read"), installed: []string{"messages:read", "messages:write"},
+			method: http.MethodPost, path: "/channels/c1/messages",
+			want: http.StatusForbidden, message: "missing the messages:write scope",
+		},
+		{
+			name:   "uninstalled app",
+			claims: app("messages:read"),
+			method: http.MethodGet, path: "/channels/c1/messages",
+			want: http.StatusUnauthorized, message: "no longer installed",
+		},
+		{
+			name:   "scope since revoked",
+			claims: app("messages:read", "tasks:read"), installed: []string{"messages:read"},
+			method: http.MethodGet, path: "/teams/t1/tasks",
+			want: http.StatusForbidden, message: "has not granted the tasks:read scope",
+		},
+		{
+			name:   "another team's channel",
+			claims: app("messages:read"), installed: []string{"messages:read"},
+			method: http.MethodGet, path: "/channels/c2/messages",
+			want: http.StatusNotFound,
+		},
+		{
+			name:   "another team",
+			claims: app("tasks:read"), installed: []string{"tasks:read"},
+			method: http.MethodGet, path: "/teams/t2/tasks",
+			want: http.StatusNotFound,
+		},
+		{
+			name:   "missing channel",
+			claims: app("messages:read"), installed: []string{"messages:read"},
+			method: http.MethodGet, path: "/channels/nope/messages",
+			want: http.StatusNotFound,
+		},
+		{
+			name:   "store failure",
+			claims: app("messages:read"), installed: []string{"messages:read"}, storeErr: errors.New("connection refused"),
+			method: http.MethodGet, path: "/channels/c1/messages",
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := fakes.NewStore()
+			if tt.installed != nil {
+				store.Install("t1", "a1", tt.installed...)
+			}
+			handler := newRouter(store, tt.claims)
+			store.Err = tt.storeErr
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.want {
+				t.Fatalf("%s %s = %d %s, want %d", tt.method, tt.path, w.Code, w.Body, tt.want)
+			}
+			if tt.message != "" && !strings.Contains(w.Body.String(), tt.message) {
+				t.Errorf("body = %s, want it to say %q", w.Body, tt.message)
+			}
+		})
+	}
+}
+
+func TestUninstallingRevokesIssuedTokens(t *testing.T) {
+	store := fakes.NewStore()
+	store.Install("t1", "a1", "messages:read")
+	handler := newRouter(store, &middleware.Claims{UserID: "u1", AppID: "a1", TeamID: "t1", Scopes: []string{"messages:read"}})
+
+	get := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/channels/c1/messages", nil))
+		return w.Code
+	}
+	if code := get(); code != http.StatusNoContent {
+		t.Fatalf("before uninstalling: %d", code)
+	}
+	store.Uninstall("t1", "a1")
+	if code := get(); code != http.StatusUnauthorized {
+		t.Errorf("after uninstalling: %d, want 401", code)
+	}
+}
+
+func TestTaskPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject authz.Subject
+		want    authz.Permissions
+	}{
+		{
+			name:    "creator",
+			subject: authz.Subject{UserID: "u1", Role: "member"},
+			want:    authz.Permissions{CanEdit: true, CanDelete: true, CanPost: true},
+		},
+		{
+			name:    "another member",
+			subject: authz.Subject{UserID: "u2", Role: "member"},
+			want:    authz.Permissions{CanEdit: true, CanPost: true},
+		},
+		{
+			name:    "admin",
+			subject: authz.Subject{UserID: "u2", Role: "admin"},
+			want:    authz.Permissions{CanEdit: true, CanDelete: true, CanPost: true},
+		},
+		{
+			name:    "not a member",
+			subject: authz.Subject{UserID: "u1"},
+		},
+		{
+			name:    "app that can only read",
+			subject: authz.Subject{UserID: "u1", Role: "owner", AppID: "a1", Scopes: []string{"tasks:read"}},
+		},
+		{
+			name:    "app that can write",
+			subject: authz.Subject{UserID: "u1", Role: "owner", AppID: "a1", Scopes: []string{"tasks:write"}},
+			want:    authz.Permissions{CanEdit: true, CanDelete: true, CanPost: true},
+		},
+	}
+	for _, tt := range tests {
+		if got := authz.Task(tt.subject, "u1"); got != tt.want {
+			t.Errorf("%s: Task = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChannelPermissions(t *testing.T) {
+	admin := authz.Subject{UserID: "u1", Role: "admin"}
+	member := authz.Subject{UserID: "u2", Role: "member"}
+	guest := authz.Subject{UserID: "u3"}
+	tests := []struct {
+		name                           string
+		subject                        authz.Subject
+		channelType                    domain.ChannelType
+		isPrivate, isDefault, readOnly bool
+		want                           authz.Permissions
+	}{
+		{name: "admin", subject: admin, channelType: domain.ChannelTypeCustom,
+			want: authz.Permissions{CanEdit: true, CanDelete: true, CanInvite: true, CanPost: true}},
+		{name: "admin of a default channel", subject: admin, channelType: domain.ChannelTypeCustom, isDefault: true,
+			want: authz.Permissions{CanEdit: true, CanInvite: true, CanPost: true}},
+		{name: "admin of a private channel", subject: admin, channelType: domain.ChannelTypeCustom, isPrivate: true,
+			want: authz.Permissions{CanEdit: true, CanDelete: true, CanPost: true}},
+		{name: "admin in a direct channel", subject: admin, channelType: domain.ChannelTypeDirect,
+			want: authz.Permissions{CanPost: true}},
+		{name: "member", subject: member, channelType: domain.ChannelTypeCustom,
+			want: authz.Permissions{CanPost: true}},
+		{name: "guest from a shared team", subject: guest, channelType: domain.ChannelTypeCustom,
+			want: authz.Permissions{CanPost: true}},
+		{name: "archived", subject: admin, channelType: domain.ChannelTypeCustom, readOnly: true,
+			want: authz.Permissions{CanEdit: true, CanDelete: true, CanInvite: true}},
+	}
+	for _, tt := range tests {
+		got := authz.Channel(tt.subject, string(tt.channelType), tt.isPrivate, tt.isDefault, tt.readOnly)
+		if got != tt.want {
+			t.Errorf("%s: Channel = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package fakes provides an in-memory authz.Store for tests.
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cbalite/backend/internal/authz"
+)
+
+// Store keeps app installations and resource ownership in maps. The zero
+// value is not usable; call NewStore.
+type Store struct {
+	// Err, when set, is returned by every lookup, to exercise the
+	// authorizer's error paths.
+	Err error
+
+	mu        sync.Mutex
+	installed map[string][]string
+	resources map[string]string
+}
+
+var _ authz.Store = (*Store)(nil)
+
+func NewStore() *Store {
+	return &Store{
+		installed: make(map[string][]string),
+		resources: make(map[string]string),
+	}
+}
+
+// Install grants the app scopes in the team, replacing any earlier grant.
+func (s *Store) Install(teamID, appID string, scopes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.installed[teamID+"/"+appID] = scopes
+}
+
+func (s *Store) Uninstall(teamID, appID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.installed, teamID+"/"+appID)
+}
+
+// SetOwner records that the resource named by a path parameter, such as
+// channelId, belongs to teamID.
+func (s *Store) SetOwner(param, id, teamID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources[param+"/"+id] = teamID
+}
+
+func (s *Store) InstalledScopes(ctx context.Context, teamID, appID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	scopes, ok := s.installed[teamID+"/"+appID]
+	if !ok {
+		return nil, authz.ErrNotInstalled
+	}
+	return scopes, nil
+}
+
+// ResourceTeam returns "" for resources without an owner, as the real store
+// does for missing rows.
+func (s *Store) ResourceTeam(ctx context.Context, param, id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Err != nil {
+		return "", s.Err
+	}
+	return s.resources[param+"/"+id], nil
+}
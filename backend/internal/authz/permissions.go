@@ -0,0 +1,105 @@
+package authz
+
+import (
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/messagepolicy"
+	"github.com/cbalite/backend/internal/middleware"
+)
+
+// Permissions are the capability flags sent with teams, channels, messages
+// and tasks, so clients only offer what the caller is allowed to do. Actions
+// that don't apply to a resource are false.
+type Permissions struct {
+	CanEdit   bool `json:"can_edit"`
+	CanDelete bool `json:"can_delete"`
+	CanInvite bool `json:"can_invite"`
+	CanPost   bool `json:"can_post"`
+}
+
+// Subject is who permissions are computed for: a user, or an app acting for
+// the user who installed it.
+type Subject struct {
+	UserID string
+	// Role in the team owning the resource; empty for guests reaching a
+	// shared channel from another team.
+	Role   string
+	AppID  string
+	Scopes []string
+}
+
+func NewSubject(claims *middleware.Claims, role string) Subject {
+	return Subject{
+		UserID: claims.UserID,
+		Role:   role,
+		AppID:  claims.AppID,
+		Scopes: claims.Scopes,
+	}
+}
+
+func (s Subject) app() bool {
+	return s.AppID != ""
+}
+
+func (s Subject) member() bool {
+	return s.Role != ""
+}
+
+func (s Subject) admin() bool {
+	return s.Role == "owner" || s.Role == "admin"
+}
+
+// allows reports whether an app's scopes cover scope. People aren't limited
+// by scopes.
+func (s Subject) allows(scope Scope) bool {
+	return !s.app() || HasScope(s.Scopes, scope)
+}
+
+// Team: can_edit and can_invite for owners and admins, can_delete for the
+// owner, can_post (create tasks) for members.
+func Team(s Subject) Permissions {
+	return Permissions{
+		CanEdit:   !s.app() && s.admin(),
+		CanDelete: !s.app() && s.Role == "owner",
+		CanInvite: !s.app() && s.admin(),
+		CanPost:   s.member() && s.allows(TasksWrite),
+	}
+}
+
+// Channel: admins of the owning team edit, delete and invite other teams
+// into its non-direct channels; anyone with access posts unless the channel
+// is read-only to them: archived, or a share they only kept history of.
+func Channel(s Subject, channelType string, isPrivate, isDefault, readOnly bool) Permissions {
+	manage := !s.app() && s.admin() && channelType != string(domain.ChannelTypeDirect)
+	return Permissions{
+		CanEdit:   manage,
+		CanDelete: manage && !isDefault,
+		CanInvite: manage && !isPrivate,
+		CanPost:   !readOnly && s.allows(MessagesWrite),
+	}
+}
+
+// Message: editing and deleting follow the team's message policy; can_post
+// is replying in the thread.
+func Message(s Subject, policy domain.MessagePolicy, message messagepolicy.Message, now time.Time, readOnly bool) Permissions {
+	if readOnly {
+		return Permissions{}
+	}
+	actor := messagepolicy.Actor{UserID: s.UserID, Role: s.Role}
+	return Permissions{
+		CanEdit:   !s.app() && messagepolicy.CanEdit(policy, message, actor, now) == nil,
+		CanDelete: !s.app() && messagepolicy.CanDelete(policy, message, actor, now) == nil,
+		CanPost:   s.allows(MessagesWrite),
+	}
+}
+
+// Task: members edit and comment (can_post); the creator and admins delete.
+func Task(s Subject, createdBy string) Permissions {
+	write := s.member() && s.allows(TasksWrite)
+	return Permissions{
+		CanEdit:   write,
+		CanDelete: write && (s.admin() || createdBy == s.UserID),
+		CanPost:   write,
+	}
+}
@@ -0,0 +1,189 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// MaxChainDepth bounds how many rules may fire in a row when rule actions
+// produce events that trigger further rules.
+const MaxChainDepth = 3
+
+// Executor performs rule actions. It is implemented by the API layer so that
+// actions go through the same code paths (and emit the same events) as user
+// requests.
+type Executor interface {
+	PostMessage(ctx context.Context, teamID, actorID, channel, content string) error
+	CreateTask(ctx context.Context, teamID, actorID string, params map[string]string) error
+	AssignTask(ctx context.Context, teamID, actorID, taskID, assignee string) error
+	SetTaskStatus(ctx context.Context, teamID, actorID, taskID, status string) error
+	Escalate(ctx context.Context, teamID, actorID, policy, summary, sourceType, sourceID string) error
+}
+
+type Engine struct {
+	db       *database.PostgresDB
+	executor Executor
+	logger   *logger.Logger
+}
+
+func NewEngine(db *database.PostgresDB, executor Executor, logger *logger.Logger) *Engine {
+	return &Engine{
+		db:       db,
+		executor: executor,
+		logger:   logger,
+	}
+}
+
+func (e *Engine) Start(bus *events.Bus) {
+	for _, trigger := range Triggers {
+		bus.Subscribe(trigger, e.handleEvent)
+	}
+}
+
+type chainKey struct{}
+
+// chainFrom returns the IDs of the rules whose actions led to the event being
+// handled, oldest first.
+func chainFrom(ctx context.Context) []string {
+	chain, _ := ctx.Value(chainKey{}).([]string)
+	return chain
+}
+
+func withRule(ctx context.Context, ruleID string) context.Context {
+	chain := append(append([]string(nil), chainFrom(ctx)...), ruleID)
+	return context.WithValue(ctx, chainKey{}, chain)
+}
+
+func (e *Engine) handleEvent(ctx context.Context, event events.Event) {
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT `+RuleColumns+`
+		FROM automation_rules
+		WHERE team_id = $1 AND trigger = $2 AND is_enabled = true
+		ORDER BY created_at
+	`, event.TeamID, string(event.Type))
+	if err != nil {
+		e.logger.WithError(err).Error("Failed to load automation rules")
+		return
+	}
+
+	var rules []domain.AutomationRule
+	for rows.Next() {
+		rule, err := ScanRule(rows)
+		if err != nil {
+			e.logger.WithError(err).Error("Failed to scan automation rule row")
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	rows.Close()
+
+	if len(rules) == 0 {
+		return
+	}
+
+	data := payload(event)
+	chain := chainFrom(ctx)
+
+	for _, rule := range rules {
+		if !Matches(rule.Conditions, data) {
+			continue
+		}
+
+		if reason := loopReason(chain, rule.ID); reason != "" {
+			e.logger.Warnf("Automation rule %s skipped: %s", rule.ID, reason)
+			e.recordRun(ctx, rule, event, domain.AutomationRunSkipped, reason, 0, len(chain))
+			continue
+		}
+
+		executed, err := e.execute(withRule(ctx, rule.ID), rule, event, data)
+		if err != nil {
+			e.logger.WithError(err).Warnf("Automation rule %s failed", rule.ID)
+			e.recordRun(ctx, rule, event, domain.AutomationRunFailed, err.Error(), executed, len(chain))
+			continue
+		}
+
+		e.recordRun(ctx, rule, event, domain.AutomationRunSucceeded, "", executed, len(chain))
+	}
+}
+
+func loopReason(chain []string, ruleID string) string {
+	if len(chain) >= MaxChainDepth {
+		return fmt.Sprintf("max chain depth of %d reached", MaxChainDepth)
+	}
+	for _, id := range chain {
+		if id == ruleID {
+			return "rule already ran earlier in this chain"
+		}
+	}
+	return ""
+}
+
+func (e *Engine) execute(ctx context.Context, rule domain.AutomationRule, event events.Event, data map[string]interface{}) (int, error) {
+	taskID, _ := lookup(data, "id")
+	isTaskEvent := event.Type == events.TaskCreated || event.Type == events.TaskUpdated
+
+	for i, action := range rule.Actions {
+		params := make(map[string]string, len(action.Params))
+		for key, value := range action.Params {
+			params[key] = Render(value, data)
+		}
+
+		var err error
+		switch action.Type {
+		case ActionPostMessage:
+			err = e.executor.PostMessage(ctx, rule.TeamID, rule.CreatedBy, params["channel"], params["content"])
+		case ActionCreateTask:
+			err = e.executor.CreateTask(ctx, rule.TeamID, rule.CreatedBy, params)
+		case ActionAssignTask:
+			if !isTaskEvent {
+				err = fmt.Errorf("%s requires a task trigger", action.Type)
+				break
+			}
+			err = e.executor.AssignTask(ctx, rule.TeamID, rule.CreatedBy, taskID, params["assignee"])
+		case ActionSetTaskStatus:
+			if !isTaskEvent {
+				err = fmt.Errorf("%s requires a task trigger", action.Type)
+				break
+			}
+			err = e.executor.SetTaskStatus(ctx, rule.TeamID, rule.CreatedBy, taskID, params["status"])
+		case ActionEscalate:
+			summary := params["summary"]
+			if summary == "" {
+				summary = rule.Name
+			}
+			// The triggering event is the escalation's source, so a rule
+			// firing twice for the same message or task pages only once
+			sourceID, _ := lookup(data, "id")
+			err = e.executor.Escalate(ctx, rule.TeamID, rule.CreatedBy, params["policy"], summary, string(event.Type), sourceID)
+		default:
+			err = fmt.Errorf("unsupported action %q", action.Type)
+		}
+
+		if err != nil {
+			return i, fmt.Errorf("action %d (%s): %w", i+1, action.Type, err)
+		}
+	}
+
+	return len(rule.Actions), nil
+}
+
+func (e *Engine) recordRun(ctx context.Context, rule domain.AutomationRule, event events.Event, status, runErr string, executed, depth int) {
+	var errValue *string
+	if runErr != "" {
+		errValue = &runErr
+	}
+
+	_, err := e.db.ExecContext(ctx, `
+		INSERT INTO automation_runs (id, rule_id, team_id, event_id, event_type, status, error, actions_executed, chain_depth, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+	`, uuid.New().String(), rule.ID, rule.TeamID, event.ID, string(event.Type), status, errValue, executed, depth)
+	if err != nil {
+		e.logger.WithError(err).Error("Failed to record automation run")
+	}
+}
@@ -0,0 +1,76 @@
+// Package fakes provides an automation.Executor that records rule actions
+// instead of performing them.
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cbalite/backend/internal/automation"
+)
+
+// Call is one action the engine asked for. Args holds the action's
+// arguments after the team and actor, by name.
+type Call struct {
+	Action  string
+	TeamID  string
+	ActorID string
+	Args    map[string]string
+}
+
+// Executor records each call. Set Err to make every action fail.
+type Executor struct {
+	Err error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ automation.Executor = (*Executor)(nil)
+
+func NewExecutor() *Executor {
+	return &Executor{}
+}
+
+func (e *Executor) record(action, teamID, actorID string, args map[string]string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, Call{Action: action, TeamID: teamID, ActorID: actorID, Args: args})
+	return e.Err
+}
+
+func (e *Executor) PostMessage(ctx context.Context, teamID, actorID, channel, content string) error {
+	return e.record(automation.ActionPostMessage, teamID, actorID, map[string]string{"channel": channel, "content": content})
+}
+
+func (e *Executor) CreateTask(ctx context.Context, teamID, actorID string, params map[string]string) error {
+	args := make(map[string]string, len(params))
+	for k, v := range params {
+		args[k] = v
+	}
+	return e.record(automation.ActionCreateTask, teamID, actorID, args)
+}
+
+func (e *Executor) AssignTask(ctx context.Context, teamID, actorID, taskID, assignee string) error {
+	return e.record(automation.ActionAssignTask, teamID, actorID, map[string]string{"task_id": taskID, "assignee": assignee})
+}
+
+func (e *Executor) SetTaskStatus(ctx context.Context, teamID, actorID, taskID, status string) error {
+	return e.record(automation.ActionSetTaskStatus, teamID, actorID, map[string]string{"task_id": taskID, "status": status})
+}
+
+func (e *Executor) Escalate(ctx context.Context, teamID, actorID, policy, summary, sourceType, sourceID string) error {
+	return e.record(automation.ActionEscalate, teamID, actorID, map[string]string{
+		"policy":      policy,
+		"summary":     summary,
+		"source_type": sourceType,
+		"source_id":   sourceID,
+	})
+}
+
+// Calls returns the actions requested so far, in order.
+func (e *Executor) Calls() []Call {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Call(nil), e.calls...)
+}
@@ -0,0 +1,197 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+)
+
+const (
+	OperatorEquals      = "equals"
+	OperatorNotEquals   = "not_equals"
+	OperatorContains    = "contains"
+	OperatorNotContains = "not_contains"
+	OperatorStartsWith  = "starts_with"
+)
+
+const (
+	ActionPostMessage   = "post_message"
+	ActionCreateTask    = "create_task"
+	ActionAssignTask    = "assign_task"
+	ActionSetTaskStatus = "set_task_status"
+	ActionEscalate      = "escalate"
+)
+
+// Triggers lists the bus events a rule can be attached to.
+var Triggers = []events.Type{
+	events.MessagePosted,
+	events.TaskCreated,
+	events.TaskUpdated,
+	events.MemberJoined,
+	events.MessageClassified,
+	events.MessageFlagged,
+}
+
+// requiredParams lists, per action type, the params a rule must provide.
+var requiredParams = map[string][]string{
+	ActionPostMessage:   {"channel", "content"},
+	ActionCreateTask:    {"title"},
+	ActionAssignTask:    {"assignee"},
+	ActionSetTaskStatus: {"status"},
+	ActionEscalate:      {"policy"},
+}
+
+// RuleColumns is the column list ScanRule expects, in order.
+const RuleColumns = `id, team_id, name, trigger, conditions, actions, is_enabled, created_by, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func ScanRule(row scanner) (domain.AutomationRule, error) {
+	var rule domain.AutomationRule
+	var conditions, actions []byte
+
+	err := row.Scan(&rule.ID, &rule.TeamID, &rule.Name, &rule.Trigger, &conditions, &actions,
+		&rule.IsEnabled, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return rule, err
+	}
+
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return rule, fmt.Errorf("failed to decode rule conditions: %w", err)
+	}
+	if err := json.Unmarshal(actions, &rule.Actions); err != nil {
+		return rule, fmt.Errorf("failed to decode rule actions: %w", err)
+	}
+
+	if rule.Conditions == nil {
+		rule.Conditions = []domain.RuleCondition{}
+	}
+
+	return rule, nil
+}
+
+func IsValidTrigger(trigger string) bool {
+	for _, t := range Triggers {
+		if string(t) == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateRule(rule domain.AutomationRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("rule name is required")
+	}
+
+	if !IsValidTrigger(rule.Trigger) {
+		return fmt.Errorf("unsupported trigger %q", rule.Trigger)
+	}
+
+	for _, condition := range rule.Conditions {
+		if condition.Field == "" {
+			return fmt.Errorf("condition field is required")
+		}
+		switch condition.Operator {
+		case OperatorEquals, OperatorNotEquals, OperatorContains, OperatorNotContains, OperatorStartsWith:
+		default:
+			return fmt.Errorf("unsupported condition operator %q", condition.Operator)
+		}
+	}
+
+	if len(rule.Actions) == 0 {
+		return fmt.Errorf("at least one action is required")
+	}
+
+	for _, action := range rule.Actions {
+		params, ok := requiredParams[action.Type]
+		if !ok {
+			return fmt.Errorf("unsupported action %q", action.Type)
+		}
+		for _, param := range params {
+			if strings.TrimSpace(action.Params[param]) == "" {
+				return fmt.Errorf("action %s requires param %q", action.Type, param)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether every condition holds for the event payload.
+func Matches(conditions []domain.RuleCondition, data map[string]interface{}) bool {
+	for _, condition := range conditions {
+		value, _ := lookup(data, condition.Field)
+		actual := strings.ToLower(value)
+		expected := strings.ToLower(condition.Value)
+
+		var ok bool
+		switch condition.Operator {
+		case OperatorEquals:
+			ok = actual == expected
+		case OperatorNotEquals:
+			ok = actual != expected
+		case OperatorContains:
+			ok = strings.Contains(actual, expected)
+		case OperatorNotContains:
+			ok = !strings.Contains(actual, expected)
+		case OperatorStartsWith:
+			ok = strings.HasPrefix(actual, expected)
+		}
+
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var templateVar = regexp.MustCompile(`\{\{\s*([\w.]+)\s*\}\}`)
+
+// Render substitutes {{field.path}} placeholders with values from the event
+// payload; unknown fields render as empty strings.
+func Render(template string, data map[string]interface{}) string {
+	return templateVar.ReplaceAllStringFunc(template, func(match string) string {
+		field := templateVar.FindStringSubmatch(match)[1]
+		value, _ := lookup(data, field)
+		return value
+	})
+}
+
+func lookup(data map[string]interface{}, path string) (string, bool) {
+	var current interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if current, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+
+	if current == nil {
+		return "", false
+	}
+	return fmt.Sprint(current), true
+}
+
+// payload normalises an event's data into a generic map.
+func payload(event events.Event) map[string]interface{} {
+	if data, ok := event.Data.(map[string]interface{}); ok {
+		return data
+	}
+
+	data := map[string]interface{}{}
+	raw, err := json.Marshal(event.Data)
+	if err == nil {
+		_ = json.Unmarshal(raw, &data)
+	}
+	return data
+}
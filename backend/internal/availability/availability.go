@@ -0,0 +1,74 @@
+package availability
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/scheduler"
+)
+
+// Schedule answers whether someone is within their working hours.
+type Schedule struct {
+	start     scheduler.Weekly
+	endHour   int
+	endMinute int
+}
+
+// New validates working hours. The working day must end after it starts on
+// the same day.
+func New(hours domain.WorkingHours) (Schedule, error) {
+	start, err := scheduler.ParseWeekly(hours.Weekdays, hours.StartTime, hours.Timezone)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	end, err := time.Parse("15:04", hours.EndTime)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("invalid end time %q, expected HH:MM", hours.EndTime)
+	}
+	if end.Hour()*60+end.Minute() <= start.Hour*60+start.Minute {
+		return Schedule{}, fmt.Errorf("end time must be after start time")
+	}
+
+	return Schedule{start: start, endHour: end.Hour(), endMinute: end.Minute()}, nil
+}
+
+// Working reports whether t falls inside working hours.
+func (s Schedule) Working(t time.Time) bool {
+	local := t.In(s.start.Location)
+
+	workday := false
+	for _, weekday := range s.start.Weekdays {
+		if local.Weekday() == weekday {
+			workday = true
+			break
+		}
+	}
+	if !workday {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	return minute >= s.start.Hour*60+s.start.Minute && minute < s.endHour*60+s.endMinute
+}
+
+// NextStart is when the next working day starts after t.
+func (s Schedule) NextStart(t time.Time) time.Time {
+	return s.start.Next(t)
+}
+
+func (s Schedule) Availability(t time.Time) domain.Availability {
+	availability := domain.Availability{
+		Status:    domain.AvailabilityWorking,
+		LocalTime: t.In(s.start.Location).Format("15:04"),
+		Timezone:  s.start.Location.String(),
+	}
+	if !s.Working(t) {
+		next := s.NextStart(t)
+		availability.Status = domain.AvailabilityOutsideHours
+		availability.Hint = "outside working hours"
+		availability.NextAvailableAt = &next
+	}
+	return availability
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is what the app needs from a cache. Callers treat it as best effort:
+// a miss or an error means doing the work without it.
+type Cache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	Increment(ctx context.Context, key string) (int64, error)
+	HealthCheck() error
+	Close() error
+
+	// Enabled is false for Noop, so callers that need shared state (like
+	// rate limit counters) can fall back to something local.
+	Enabled() bool
+}
+
+// Noop is the cache for deployments without Redis: every read misses and
+// writes are dropped.
+type Noop struct{}
+
+func (Noop) Get(ctx context.Context, key string) (string, error) {
+	return "", ErrCacheMiss
+}
+
+func (Noop) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return nil
+}
+
+func (Noop) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+func (Noop) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	return nil
+}
+
+func (Noop) Increment(ctx context.Context, key string) (int64, error) {
+	return 0, ErrCacheDisabled
+}
+
+func (Noop) HealthCheck() error {
+	return nil
+}
+
+func (Noop) Close() error {
+	return nil
+}
+
+func (Noop) Enabled() bool {
+	return false
+}
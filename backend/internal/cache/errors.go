@@ -6,4 +6,5 @@ var (
 	ErrCacheMiss = errors.New("cache miss")
 	ErrCacheInvalidType = errors.New("invalid cache type")
 	ErrCacheConnectionFailed = errors.New("cache connection failed")
+	ErrCacheDisabled = errors.New("cache disabled")
 )
\ No newline at end of file
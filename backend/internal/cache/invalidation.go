@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// InvalidationChannel is the Postgres channel invalidations are sent on.
+const InvalidationChannel = "cache_invalidation"
+
+const (
+	versionKeyPrefix = "cache_version:"
+	// versionKeyTTL outlives anything cached under a version, so a version
+	// that expires and starts again from zero can't bring old values back.
+	versionKeyTTL = 24 * time.Hour
+	// versionMemoTTL bounds how long an instance trusts a version it
+	// remembers, should a notification go missing.
+	versionMemoTTL = time.Minute
+	// listenRetryDelay is the wait before listening again after Listen
+	// fails outright.
+	listenRetryDelay = 10 * time.Second
+)
+
+// Broadcaster carries invalidations to every instance, this one included.
+// *database.PostgresDB implements it with LISTEN/NOTIFY.
+type Broadcaster interface {
+	Notify(ctx context.Context, channel, payload string) error
+	// Listen calls handle with each payload sent on channel until ctx
+	// ends, and reconnected when notifications may have been lost.
+	Listen(ctx context.Context, channel string, handle func(payload string), reconnected func()) error
+}
+
+// Invalidator versions cached values by scope, such as a user's team
+// memberships or a channel's settings. Values are cached under keys that
+// carry the current version of each scope they depend on. Invalidating a
+// scope bumps its version in the shared cache and tells every instance, so
+// they all move on to new keys at once.
+//
+// Old values are never deleted, just no longer read, and expire on their
+// own. A read that raced a write can only store what it loaded under the
+// old version, where no one will look. Instances remember versions, so
+// reads don't pay a round trip for them, keep recently read values in
+// memory in front of the shared cache, and share one load between
+// concurrent misses for the same key rather than all hitting the database.
+type Invalidator struct {
+	cache  Cache
+	bus    Broadcaster
+	logger *logger.Logger
+
+	local local
+
+	mu       sync.Mutex
+	versions map[string]memoVersion
+	flights  map[string]*flight
+}
+
+type memoVersion struct {
+	version   int64
+	expiresAt time.Time
+}
+
+type flight struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+type invalidation struct {
+	Scope   string `json:"scope"`
+	Version int64  `json:"version"`
+}
+
+func NewInvalidator(cache Cache, bus Broadcaster, logger *logger.Logger) *Invalidator {
+	return &Invalidator{
+		cache:    cache,
+		bus:      bus,
+		logger:   logger,
+		versions: make(map[string]memoVersion),
+		flights:  make(map[string]*flight),
+	}
+}
+
+// Start listens for invalidations from other instances.
+func (inv *Invalidator) Start() {
+	go inv.listen()
+	go inv.sweep()
+}
+
+func (inv *Invalidator) listen() {
+	for {
+		err := inv.bus.Listen(context.Background(), InvalidationChannel, inv.receive, inv.forget)
+		inv.logger.WithError(err).Error("Cache invalidation listener stopped")
+		// Anything sent while not listening was missed
+		inv.forget()
+		time.Sleep(listenRetryDelay)
+	}
+}
+
+func (inv *Invalidator) sweep() {
+	ticker := time.NewTicker(versionMemoTTL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		inv.mu.Lock()
+		for scope, memo := range inv.versions {
+			if !now.Before(memo.expiresAt) {
+				delete(inv.versions, scope)
+			}
+		}
+		inv.mu.Unlock()
+	}
+}
+
+func (inv *Invalidator) receive(payload string) {
+	var msg invalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		inv.logger.WithError(err).Warn("Ignoring malformed cache invalidation")
+		return
+	}
+
+	// Only scopes this instance has read need updating; the rest are
+	// looked up when first used.
+	inv.mu.Lock()
+	if _, ok := inv.versions[msg.Scope]; ok {
+		inv.rememberLocked(msg.Scope, msg.Version)
+	}
+	inv.mu.Unlock()
+}
+
+// forget drops every remembered version, so they're read again from the
+// shared cache.
+func (inv *Invalidator) forget() {
+	inv.mu.Lock()
+	inv.versions = make(map[string]memoVersion)
+	inv.mu.Unlock()
+}
+
+// rememberLocked never moves a scope back to an older version, whatever
+// order notifications arrive in. Must hold inv.mu.
+func (inv *Invalidator) rememberLocked(scope string, version int64) {
+	if memo, ok := inv.versions[scope]; ok && memo.version > version {
+		version = memo.version
+	}
+	inv.versions[scope] = memoVersion{version: version, expiresAt: time.Now().Add(versionMemoTTL)}
+}
+
+// Invalidate moves the scopes to new versions. Call it after the write
+// commits: a version bumped earlier could be read, and filled with data the
+// write is about to replace, before the write lands.
+func (inv *Invalidator) Invalidate(ctx context.Context, scopes ...string) error {
+	// Without a shared cache nothing is cached
+	if !inv.cache.Enabled() {
+		return nil
+	}
+
+	var errs []error
+	for _, scope := range scopes {
+		key := versionKeyPrefix + scope
+		version, err := inv.cache.Increment(ctx, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to bump version of %s: %w", scope, err))
+			continue
+		}
+		if err := inv.cache.Expire(ctx, key, versionKeyTTL); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set expiry of %s: %w", scope, err))
+		}
+
+		// The bumped version is the latest there is, even if this
+		// instance remembers a higher one from before the key expired
+		inv.mu.Lock()
+		inv.versions[scope] = memoVersion{version: version, expiresAt: time.Now().Add(versionMemoTTL)}
+		inv.mu.Unlock()
+
+		payload, _ := json.Marshal(invalidation{Scope: scope, Version: version})
+		if err := inv.bus.Notify(ctx, InvalidationChannel, string(payload)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to announce invalidation of %s: %w", scope, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (inv *Invalidator) version(ctx context.Context, scope string) int64 {
+	inv.mu.Lock()
+	memo, ok := inv.versions[scope]
+	inv.mu.Unlock()
+	if ok && time.Now().Before(memo.expiresAt) {
+		return memo.version
+	}
+
+	var version int64
+	raw, err := inv.cache.Get(ctx, versionKeyPrefix+scope)
+	switch {
+	case err == nil:
+		version, _ = strconv.ParseInt(raw, 10, 64)
+	case !errors.Is(err, ErrCacheMiss):
+		// Don't remember a version the cache couldn't confirm
+		return version
+	}
+
+	inv.mu.Lock()
+	inv.rememberLocked(scope, version)
+	version = inv.versions[scope].version
+	inv.mu.Unlock()
+	return version
+}
+
+// key appends the current version of each scope to key.
+func (inv *Invalidator) key(ctx context.Context, key string, scopes []string) string {
+	versions := make([]string, len(scopes))
+	for i, scope := range scopes {
+		versions[i] = strconv.FormatInt(inv.version(ctx, scope), 10)
+	}
+	return key + "@" + strings.Join(versions, ".")
+}
+
+// do runs load once for concurrent callers asking for the same key.
+func (inv *Invalidator) do(key string, load func() (interface{}, error)) (interface{}, error) {
+	inv.mu.Lock()
+	if f, ok := inv.flights[key]; ok {
+		inv.mu.Unlock()
+		<-f.done
+		return f.value, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	inv.flights[key] = f
+	inv.mu.Unlock()
+
+	defer func() {
+		inv.mu.Lock()
+		delete(inv.flights, key)
+		inv.mu.Unlock()
+		close(f.done)
+	}()
+
+	f.value, f.err = load()
+	return f.value, f.err
+}
+
+// Load returns the value cached under key for the current versions of
+// scopes, from this instance's memory or else the shared cache, calling load
+// and caching its result for ttl on a miss. Lookups are counted in
+// cbalite_cache_lookups_total under the part of key before its first colon,
+// so keys should start with a name for what they hold. Errors
+// from load aren't cached, so a lookup that finds nothing should return a
+// zero value rather than an error to be cached.
+func Load[T any](ctx context.Context, inv *Invalidator, key string, ttl time.Duration, scopes []string, load func() (T, error)) (T, error) {
+	if !inv.cache.Enabled() {
+		return load()
+	}
+
+	name := cacheName(key)
+	key = inv.key(ctx, key, scopes)
+	if data, ok := inv.local.get(key); ok {
+		var value T
+		if json.Unmarshal(data, &value) == nil {
+			cacheLookups.With(name, "local").Inc()
+			return value, nil
+		}
+	}
+	if cached, err := inv.cache.Get(ctx, key); err == nil {
+		var value T
+		if json.Unmarshal([]byte(cached), &value) == nil {
+			inv.local.set(key, []byte(cached))
+			cacheLookups.With(name, "shared").Inc()
+			return value, nil
+		}
+	}
+
+	cacheLookups.With(name, "miss").Inc()
+	value, err := inv.do(key, func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return value, err
+		}
+		if data, err := json.Marshal(value); err == nil {
+			inv.local.set(key, data)
+			if err := inv.cache.Set(ctx, key, data, ttl); err != nil {
+				inv.logger.WithError(err).Warn("Failed to cache " + key)
+			}
+		}
+		return value, nil
+	})
+	typed, _ := value.(T)
+	return typed, err
+}
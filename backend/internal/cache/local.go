@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cbalite/backend/internal/metrics"
+)
+
+const (
+	// localTTL is how long an instance keeps a value in memory. Keys carry
+	// scope versions, so an invalidation moves reads to a new key however
+	// long the old value would have lived; the TTL just lets cold keys go.
+	localTTL = 30 * time.Second
+	// localMaxEntries bounds the memory the local layer takes.
+	localMaxEntries = 10000
+)
+
+var cacheLookups = metrics.Default.NewCounterVec("cbalite_cache_lookups_total",
+	"Cached reads by what answered them: local (this instance's memory), shared (Redis) or miss (the database).",
+	"cache", "result")
+
+// local keeps recently read values in process, in front of the shared
+// cache, so the hottest keys cost neither a Redis nor a database round
+// trip. Values are kept as the JSON the shared cache holds and decoded on
+// every read, so callers can't change what other callers get.
+type local struct {
+	mu      sync.Mutex
+	entries map[string]localEntry
+}
+
+type localEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+func (l *local) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(l.entries, key)
+		return nil, false
+	}
+	return entry.data, true
+}
+
+func (l *local) set(key string, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.entries == nil {
+		l.entries = make(map[string]localEntry)
+	}
+	if len(l.entries) >= localMaxEntries {
+		l.evictLocked()
+	}
+	l.entries[key] = localEntry{data: data, expiresAt: time.Now().Add(localTTL)}
+}
+
+// evictLocked drops expired entries and, if that frees too little, a
+// quarter of the rest, whichever the map hands out first. Must hold l.mu.
+func (l *local) evictLocked() {
+	now := time.Now()
+	for key, entry := range l.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(l.entries, key)
+		}
+	}
+	for key := range l.entries {
+		if len(l.entries) < localMaxEntries*3/4 {
+			return
+		}
+		delete(l.entries, key)
+	}
+}
+
+// cacheName labels lookups by the part of the key before its first colon,
+// such as team_role.
+func cacheName(key string) string {
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process cache with Redis semantics for the operations in
+// Cache, for tests and local tools that shouldn't need a Redis server.
+// Unlike Noop it keeps what it's given, so it reports itself as enabled.
+type Memory struct {
+	// Now is the clock expirations are measured against; tests can
+	// replace it to expire keys without waiting.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		Now:     time.Now,
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// lookup returns a live entry, dropping it if it has expired. Must hold
+// m.mu.
+func (m *Memory) lookup(key string) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if ok && !entry.expiresAt.IsZero() && !m.Now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, ok
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key)
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+// Set stores strings and byte slices as they are and anything else as JSON,
+// like RedisCache. A zero expiration keeps the key until it's deleted.
+func (m *Memory) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	var data string
+	switch v := value.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		jsonData, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal value: %w", err)
+		}
+		data = string(jsonData)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := memoryEntry{value: data}
+	if expiration > 0 {
+		entry.expiresAt = m.Now().Add(expiration)
+	}
+	m.entries[key] = entry
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// Expire sets a key's time to live; missing keys are left alone.
+func (m *Memory) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key)
+	if !ok {
+		return nil
+	}
+	if expiration <= 0 {
+		delete(m.entries, key)
+		return nil
+	}
+	entry.expiresAt = m.Now().Add(expiration)
+	m.entries[key] = entry
+	return nil
+}
+
+// Increment adds one to an integer value, starting from zero for a missing
+// key, and keeps the key's expiry.
+func (m *Memory) Increment(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, _ := m.lookup(key)
+	var n int64
+	if entry.value != "" {
+		var err error
+		if n, err = strconv.ParseInt(entry.value, 10, 64); err != nil {
+			return 0, fmt.Errorf("value of %s is not an integer", key)
+		}
+	}
+	n++
+	entry.value = strconv.FormatInt(n, 10)
+	m.entries[key] = entry
+	return n, nil
+}
+
+func (m *Memory) HealthCheck() error {
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) Enabled() bool {
+	return true
+}
@@ -16,6 +16,17 @@ type RedisCache struct {
 }
 
 func NewRedisCache(cfg *config.RedisConfig) (*RedisCache, error) {
+	r := NewLazyRedisCache(cfg)
+	if err := r.HealthCheck(); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return r, nil
+}
+
+// NewLazyRedisCache doesn't wait for Redis: connections are made on first
+// use, and until Redis is reachable operations fail like a cold cache.
+func NewLazyRedisCache(cfg *config.RedisConfig) *RedisCache {
 	client := redis.NewClient(&redis.Options{
 		Addr:         cfg.Addr,
 		Username:     cfg.Username,
@@ -25,17 +36,10 @@ func NewRedisCache(cfg *config.RedisConfig) (*RedisCache, error) {
 		MinIdleConns: cfg.MinIdleConns,
 	})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
-	}
-
 	return &RedisCache{
 		client: client,
 		config: cfg,
-	}, nil
+	}
 }
 
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
@@ -168,6 +172,10 @@ func (r *RedisCache) HealthCheck() error {
 	return r.client.Ping(ctx).Err()
 }
 
+func (r *RedisCache) Enabled() bool {
+	return true
+}
+
 func (r *RedisCache) FlushDB(ctx context.Context) error {
 	return r.client.FlushDB(ctx).Err()
 }
\ No newline at end of file
@@ -0,0 +1,96 @@
+package classify
+
+import (
+	"context"
+	"regexp"
+)
+
+const (
+	UrgencyLow    = "low"
+	UrgencyNormal = "normal"
+	UrgencyHigh   = "high"
+	UrgencyUrgent = "urgent"
+)
+
+const (
+	SentimentNegative = "negative"
+	SentimentNeutral  = "neutral"
+	SentimentPositive = "positive"
+)
+
+var (
+	Urgencies  = []string{UrgencyLow, UrgencyNormal, UrgencyHigh, UrgencyUrgent}
+	Sentiments = []string{SentimentNegative, SentimentNeutral, SentimentPositive}
+)
+
+type Labels struct {
+	Urgency   string `json:"urgency"`
+	Sentiment string `json:"sentiment"`
+}
+
+// Result is a classification plus the provider usage it cost, if any.
+type Result struct {
+	Labels           Labels
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Classifier assigns urgency and sentiment labels to a message.
+type Classifier interface {
+	Classify(ctx context.Context, text string) (*Result, error)
+}
+
+func IsValidUrgency(urgency string) bool {
+	return contains(Urgencies, urgency)
+}
+
+func IsValidSentiment(sentiment string) bool {
+	return contains(Sentiments, sentiment)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	urgentPattern   = regexp.MustCompile(`(?i)\b(urgent|asap|emergency|outage|down|sev ?1|p0|critical|immediately|production is)\b`)
+	highPattern     = regexp.MustCompile(`(?i)\b(important|blocked|blocker|broken|failing|error|bug|escalat\w*|today|sev ?2|p1)\b`)
+	lowPattern      = regexp.MustCompile(`(?i)\b(fyi|no rush|whenever|low priority|nice to have)\b`)
+	negativePattern = regexp.MustCompile(`(?i)\b(angry|frustrat\w*|annoy\w*|terrible|awful|unacceptable|disappoint\w*|upset|complain\w*|refund|cancel\w*|worst|hate|still not|not working)\b`)
+	positivePattern = regexp.MustCompile(`(?i)\b(thanks?|thank you|great|awesome|love|perfect|excellent|appreciate\w*|happy|resolved|works now)\b`)
+)
+
+// KeywordClassifier labels messages with keyword heuristics. It costs
+// nothing to run and is used when no model provider is configured or a
+// team's token budget is exhausted.
+type KeywordClassifier struct{}
+
+func (KeywordClassifier) Classify(ctx context.Context, text string) (*Result, error) {
+	labels := Labels{Urgency: UrgencyNormal, Sentiment: SentimentNeutral}
+
+	switch {
+	case urgentPattern.MatchString(text):
+		labels.Urgency = UrgencyUrgent
+	case highPattern.MatchString(text):
+		labels.Urgency = UrgencyHigh
+	case lowPattern.MatchString(text):
+		labels.Urgency = UrgencyLow
+	}
+
+	negative := len(negativePattern.FindAllString(text, -1))
+	positive := len(positivePattern.FindAllString(text, -1))
+	switch {
+	case negative > positive:
+		labels.Sentiment = SentimentNegative
+	case positive > negative:
+		labels.Sentiment = SentimentPositive
+	}
+
+	return &Result{Labels: labels, Model: "keywords"}, nil
+}
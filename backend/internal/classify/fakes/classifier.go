@@ -0,0 +1,59 @@
+// Package fakes provides a classify.Classifier with fixed answers for tests.
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cbalite/backend/internal/classify"
+)
+
+// Classifier labels known texts as told and everything else with Default.
+type Classifier struct {
+	Default classify.Labels
+	// Err, when set, fails every classification.
+	Err error
+
+	mu     sync.Mutex
+	labels map[string]classify.Labels
+	texts  []string
+}
+
+var _ classify.Classifier = (*Classifier)(nil)
+
+// NewClassifier labels messages normal and neutral unless told otherwise.
+func NewClassifier() *Classifier {
+	return &Classifier{
+		Default: classify.Labels{Urgency: classify.UrgencyNormal, Sentiment: classify.SentimentNeutral},
+		labels:  make(map[string]classify.Labels),
+	}
+}
+
+// Label makes the classifier give text these labels.
+func (c *Classifier) Label(text, urgency, sentiment string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.labels[text] = classify.Labels{Urgency: urgency, Sentiment: sentiment}
+}
+
+func (c *Classifier) Classify(ctx context.Context, text string) (*classify.Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.texts = append(c.texts, text)
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	labels, ok := c.labels[text]
+	if !ok {
+		labels = c.Default
+	}
+	return &classify.Result{Labels: labels}, nil
+}
+
+// Texts returns the texts classified so far.
+func (c *Classifier) Texts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.texts...)
+}
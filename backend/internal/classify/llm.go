@@ -0,0 +1,61 @@
+package classify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cbalite/backend/internal/llm"
+)
+
+const classifyMaxTokens = 30
+
+const classifySystemPrompt = `You label support and team chat messages. Reply with only JSON of the ` +
+	`form {"urgency":"low|normal|high|urgent","sentiment":"negative|neutral|positive"}. ` +
+	`"urgent" means something is broken or blocking people right now.`
+
+// LLMClassifier labels messages with a chat completion model.
+type LLMClassifier struct {
+	provider llm.Provider
+}
+
+func NewLLMClassifier(provider llm.Provider) *LLMClassifier {
+	return &LLMClassifier{provider: provider}
+}
+
+func (c *LLMClassifier) Classify(ctx context.Context, text string) (*Result, error) {
+	resp, err := c.provider.Complete(ctx, llm.Request{
+		Messages: []llm.Message{
+			{Role: "system", Content: classifySystemPrompt},
+			{Role: "user", Content: text},
+		},
+		MaxTokens: classifyMaxTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
+
+	if err := json.Unmarshal([]byte(llm.StripCodeFence(resp.Content)), &result.Labels); err != nil {
+		return result, fmt.Errorf("failed to decode classification: %w", err)
+	}
+
+	result.Labels.Urgency = strings.ToLower(result.Labels.Urgency)
+	result.Labels.Sentiment = strings.ToLower(result.Labels.Sentiment)
+	if !IsValidUrgency(result.Labels.Urgency) || !IsValidSentiment(result.Labels.Sentiment) {
+		return result, fmt.Errorf("unexpected classification labels %+v", result.Labels)
+	}
+
+	return result, nil
+}
+
+// EstimateTokens approximates the cost of classifying text, for budgeting.
+func EstimateTokens(text string) int {
+	return llm.EstimateTokens(classifySystemPrompt+text) + classifyMaxTokens
+}
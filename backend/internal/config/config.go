@@ -2,12 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 type Config struct {
@@ -22,15 +21,29 @@ type Config struct {
 	CORS     CORSConfig
 	RateLimit RateLimitConfig
 	TLS      TLSConfig
+	LLM      LLMConfig
+	Clients  ClientsConfig
+	Metrics  MetricsConfig
+	Admission AdmissionConfig
 }
 
 type AppConfig struct {
-	Env  string
-	Port string
-	Host string
+	Env     string
+	Port    string
+	Host    string
+	Version string
+	Region  string
+
+	// RegionEndpoints maps each region to the base URL clients there should
+	// use, for the hints in /meta. RegionHeader names the header the edge
+	// sets to the region nearest the client.
+	RegionEndpoints map[string]string
+	RegionHeader    string
 }
 
 type DatabaseConfig struct {
+	// Host may list several hosts, comma separated, each optionally with
+	// its own port ("db-a:5432,db-b:5432"). They're tried in turn.
 	Host               string
 	Port               int
 	User               string
@@ -40,26 +53,62 @@ type DatabaseConfig struct {
 	MaxConnections     int
 	MaxIdleConnections int
 	MaxLifetimeMinutes int
+	// AcquireTimeout is how long a request waits for a connection when
+	// every one is in use before it's turned away with a 503. Zero lets
+	// requests queue for as long as it takes.
+	AcquireTimeout time.Duration
+	// TargetSessionAttrs is "read-write" to only use a host that accepts
+	// writes, skipping standbys, or "any" (libpq's target_session_attrs).
+	TargetSessionAttrs string
+	// ReadRetries is how many times a SELECT that failed for lack of a
+	// working connection is run again, with growing pauses, before giving
+	// up.
+	ReadRetries int
+	// HealthCheckInterval is how often the server checks it's still talking
+	// to a usable database, and drops its connections when it isn't.
+	HealthCheckInterval time.Duration
 }
 
 type RedisConfig struct {
+	// Enabled false runs without Redis: nothing is cached and rate limits
+	// are counted per process.
+	Enabled bool
+	// LazyConnect starts the server without waiting for Redis to answer.
+	LazyConnect  bool
 	Addr         string
 	Username     string
 	Password     string
 	DB           int
 	PoolSize     int
 	MinIdleConns int
+	// PrimeLimit is how many recently active channel members have their
+	// channel and team cached at startup; zero turns priming off.
+	PrimeLimit int
 }
 
 type JWTConfig struct {
 	SecretKey           string
 	AccessTokenExpiry   time.Duration
 	RefreshTokenExpiry  time.Duration
+	AppTokenExpiry      time.Duration
 }
 
 type WebSocketConfig struct {
 	ReadBufferSize  int
 	WriteBufferSize int
+	// SendBufferSize is how many messages can wait for a slow client
+	// before new ones are dropped.
+	SendBufferSize int
+	// RoomCompactionInterval is how often the hub rebuilds its room maps
+	// to give back memory.
+	RoomCompactionInterval time.Duration
+
+	// Backplane shares WebSocket events between servers through Redis
+	// streams, one per region. RegionPeers maps every other region to the
+	// Redis holding its stream ("eu-west=redis-eu:6379"); servers read
+	// those streams too, so events reach clients in every region.
+	Backplane   bool
+	RegionPeers map[string]string
 }
 
 type TwilioConfig struct {
@@ -98,8 +147,36 @@ type CORSConfig struct {
 }
 
 type RateLimitConfig struct {
-	RequestsPerMinute int
-	Burst             int
+	RequestsPerMinute       int
+	Burst                   int
+	StatusRequestsPerMinute int
+
+	// TrustedProxies lists the addresses (IPs or CIDRs) of the load
+	// balancers and proxies in front of the server. Only they are believed
+	// about the client's address in X-Forwarded-For and X-Real-IP.
+	TrustedProxies []string
+
+	// Authenticated API requests are limited per user, per team and across
+	// every team, so one team's automation can't use up the capacity the
+	// rest share. GlobalConcurrency is per server; the others are counted
+	// in the shared cache. Zero turns a limit off.
+	UserPerMinute     int
+	TeamPerMinute     int
+	GlobalPerSecond   int
+	GlobalConcurrency int
+
+	// Messages posted by app bots and inbound webhooks are counted per
+	// integration, apart from people's requests, against a per-team and a
+	// per-channel limit. Zero turns a limit off.
+	IntegrationTeamPerMinute    int
+	IntegrationChannelPerMinute int
+
+	// An integration throttled IntegrationSuspendAfter times within
+	// IntegrationSuspendWindow is suspended in that team for
+	// IntegrationSuspendFor. Zero never suspends.
+	IntegrationSuspendAfter  int
+	IntegrationSuspendWindow time.Duration
+	IntegrationSuspendFor    time.Duration
 }
 
 type TLSConfig struct {
@@ -108,46 +185,109 @@ type TLSConfig struct {
 	KeyFile  string
 }
 
+// LLMConfig points at an OpenAI-compatible chat completions API. AI features
+// stay disabled when no base URL is set.
+// ClientsConfig describes what the server tells client apps through
+// /api/v1/meta and pushed notifications.
+type ClientsConfig struct {
+	// MinVersions maps a platform (ios, android, web) to the oldest client
+	// version still supported.
+	MinVersions map[string]string
+
+	// FeatureFlags uses the features.Parse format, e.g. "compact_mode,new_composer:25".
+	FeatureFlags string
+
+	// DeepLinkBase prefixes the deep links in pushed notifications, e.g.
+	// "cbalite://" or "https://app.example.com/".
+	DeepLinkBase string
+}
+
+// MetricsConfig controls the Prometheus endpoint at /metrics. When Token is
+// set, scrapers must send it as a bearer token.
+type MetricsConfig struct {
+	Enabled bool
+	Token   string
+}
+
+// AdmissionConfig bounds how many API requests of each class a server
+// handles at once and how many wait for a turn. Requests that find the queue
+// full, or wait longer than QueueTimeout, are shed with a 503.
+type AdmissionConfig struct {
+	Enabled      bool
+	QueueTimeout time.Duration
+	Read         AdmissionLimit
+	Write        AdmissionLimit
+	Heavy        AdmissionLimit
+}
+
+type AdmissionLimit struct {
+	Concurrency int
+	Queue       int
+}
+
+type LLMConfig struct {
+	BaseURL   string
+	APIKey    string
+	Model     string
+	MaxTokens int
+	Timeout   time.Duration
+}
+
 func Load() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
-		if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("error loading .env file: %w", err)
-		}
+	if err := loadEnvFiles(); err != nil {
+		return nil, err
 	}
 
 	config := &Config{
 		App: AppConfig{
-			Env:  getEnv("APP_ENV", "development"),
-			Port: getEnv("APP_PORT", "8080"),
-			Host: getEnv("APP_HOST", "0.0.0.0"),
+			Env:     getEnv("APP_ENV", "development"),
+			Port:    getEnv("APP_PORT", "8080"),
+			Host:    getEnv("APP_HOST", "0.0.0.0"),
+			Version: getEnv("APP_VERSION", "dev"),
+			Region:  getEnv("APP_REGION", "local"),
+
+			RegionEndpoints: getEnvAsMap("REGION_ENDPOINTS"),
+			RegionHeader:    getEnv("REGION_HEADER", "X-Edge-Region"),
 		},
 		Database: DatabaseConfig{
-			Host:               getEnv("DB_HOST", "localhost"),
-			Port:               getEnvAsInt("DB_PORT", 5432),
-			User:               getEnv("DB_USER", "postgres"),
-			Password:           getEnv("DB_PASSWORD", ""),
-			DBName:             getEnv("DB_NAME", "cbalite"),
-			SSLMode:            getEnv("DB_SSL_MODE", "disable"),
-			MaxConnections:     getEnvAsInt("DB_MAX_CONNECTIONS", 25),
-			MaxIdleConnections: getEnvAsInt("DB_MAX_IDLE_CONNECTIONS", 25),
-			MaxLifetimeMinutes: getEnvAsInt("DB_MAX_LIFETIME_CONNECTIONS", 5),
+			Host:                getEnv("DB_HOST", "localhost"),
+			Port:                getEnvAsInt("DB_PORT", 5432),
+			User:                getEnv("DB_USER", "postgres"),
+			Password:            getEnv("DB_PASSWORD", ""),
+			DBName:              getEnv("DB_NAME", "cbalite"),
+			SSLMode:             getEnv("DB_SSL_MODE", "disable"),
+			MaxConnections:      getEnvAsInt("DB_MAX_CONNECTIONS", 25),
+			MaxIdleConnections:  getEnvAsInt("DB_MAX_IDLE_CONNECTIONS", 25),
+			MaxLifetimeMinutes:  getEnvAsInt("DB_MAX_LIFETIME_CONNECTIONS", 5),
+			AcquireTimeout:      getEnvAsDuration("DB_ACQUIRE_TIMEOUT", 2*time.Second),
+			TargetSessionAttrs:  getEnv("DB_TARGET_SESSION_ATTRS", "any"),
+			ReadRetries:         getEnvAsInt("DB_READ_RETRIES", 3),
+			HealthCheckInterval: getEnvAsDuration("DB_HEALTH_CHECK_INTERVAL", 5*time.Second),
 		},
 		Redis: RedisConfig{
+			Enabled:      getEnvAsBool("REDIS_ENABLED", true),
+			LazyConnect:  getEnvAsBool("REDIS_LAZY_CONNECT", false),
 			Addr:         getEnv("REDIS_ADDR", "localhost:6379"),
 			Username:     getEnv("REDIS_USERNAME", ""),
 			Password:     getEnv("REDIS_PASSWORD", ""),
 			DB:           getEnvAsInt("REDIS_DB", 0),
 			PoolSize:     getEnvAsInt("REDIS_POOL_SIZE", 10),
 			MinIdleConns: getEnvAsInt("REDIS_MIN_IDLE_CONNS", 5),
+			PrimeLimit:   getEnvAsInt("REDIS_PRIME_LIMIT", 2000),
 		},
 		JWT: JWTConfig{
 			SecretKey:          getEnv("JWT_SECRET_KEY", ""),
-			AccessTokenExpiry:  getEnvAsDuration("JWT_ACCESS_TOKEN_EXPIRY", 15*time.Minute),
-			RefreshTokenExpiry: getEnvAsDuration("JWT_REFRESH_TOKEN_EXPIRY", 7*24*time.Hour),
+			AccessTokenExpiry:  getEnvAsDuration("JWT_ACCESS_TOKEN_EXPIRY", DefaultAccessTokenExpiry),
+			RefreshTokenExpiry: getEnvAsDuration("JWT_REFRESH_TOKEN_EXPIRY", DefaultRefreshTokenExpiry),
+			AppTokenExpiry:     getEnvAsDuration("JWT_APP_TOKEN_EXPIRY", DefaultAppTokenExpiry),
 		},
 		WebSocket: WebSocketConfig{
 			ReadBufferSize:  getEnvAsInt("WS_READ_BUFFER_SIZE", 1024),
 			WriteBufferSize: getEnvAsInt("WS_WRITE_BUFFER_SIZE", 1024),
+			SendBufferSize:         getEnvAsInt("WS_SEND_BUFFER_SIZE", 256),
+			RoomCompactionInterval: getEnvAsDuration("WS_ROOM_COMPACTION_INTERVAL", 5*time.Minute),
+			Backplane:              getEnvAsBool("WS_BACKPLANE_ENABLED", true),
+			RegionPeers:            getEnvAsMap("REGION_PEERS"),
 		},
 		Twilio: TwilioConfig{
 			AccountSID:  getEnv("TWILIO_ACCOUNT_SID", ""),
@@ -173,18 +313,63 @@ func Load() (*Config, error) {
 		CORS: CORSConfig{
 			AllowedOrigins:   getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
 			AllowedMethods:   getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-			AllowedHeaders:   getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization"}),
+			AllowedHeaders:   getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Kiosk-Token", "X-Client-Platform", "X-Client-Version", "X-Client-Capabilities", "X-Request-ID"}),
 			AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", true),
 		},
 		RateLimit: RateLimitConfig{
-			RequestsPerMinute: getEnvAsInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
-			Burst:             getEnvAsInt("RATE_LIMIT_BURST", 10),
+			RequestsPerMinute:       getEnvAsInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
+			Burst:                   getEnvAsInt("RATE_LIMIT_BURST", 10),
+			StatusRequestsPerMinute: getEnvAsInt("RATE_LIMIT_STATUS_REQUESTS_PER_MINUTE", 30),
+			TrustedProxies:          getEnvAsSlice("TRUSTED_PROXIES", nil),
+
+			UserPerMinute:     getEnvAsInt("RATE_LIMIT_USER_PER_MINUTE", 600),
+			TeamPerMinute:     getEnvAsInt("RATE_LIMIT_TEAM_PER_MINUTE", 6000),
+			GlobalPerSecond:   getEnvAsInt("RATE_LIMIT_GLOBAL_PER_SECOND", 1000),
+			GlobalConcurrency: getEnvAsInt("RATE_LIMIT_GLOBAL_CONCURRENCY", 256),
+
+			IntegrationTeamPerMinute:    getEnvAsInt("RATE_LIMIT_INTEGRATION_TEAM_PER_MINUTE", 120),
+			IntegrationChannelPerMinute: getEnvAsInt("RATE_LIMIT_INTEGRATION_CHANNEL_PER_MINUTE", 30),
+			IntegrationSuspendAfter:     getEnvAsInt("RATE_LIMIT_INTEGRATION_SUSPEND_AFTER", 50),
+			IntegrationSuspendWindow:    getEnvAsDuration("RATE_LIMIT_INTEGRATION_SUSPEND_WINDOW", 10*time.Minute),
+			IntegrationSuspendFor:       getEnvAsDuration("RATE_LIMIT_INTEGRATION_SUSPEND_FOR", 30*time.Minute),
 		},
 		TLS: TLSConfig{
 			Enabled:  getEnvAsBool("TLS_ENABLED", false),
 			CertFile: getEnv("TLS_CERT_FILE", ""),
 			KeyFile:  getEnv("TLS_KEY_FILE", ""),
 		},
+		LLM: LLMConfig{
+			BaseURL:   getEnv("LLM_BASE_URL", ""),
+			APIKey:    getEnv("LLM_API_KEY", ""),
+			Model:     getEnv("LLM_MODEL", "gpt-4o-mini"),
+			MaxTokens: getEnvAsInt("LLM_MAX_TOKENS", 512),
+			Timeout:   getEnvAsDuration("LLM_TIMEOUT", 30*time.Second),
+		},
+		Clients: ClientsConfig{
+			MinVersions:  getEnvAsMap("CLIENT_MIN_VERSIONS"),
+			FeatureFlags: getEnv("FEATURE_FLAGS", ""),
+			DeepLinkBase: getEnv("CLIENT_DEEP_LINK_BASE", "cbalite://"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvAsBool("METRICS_ENABLED", true),
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Admission: AdmissionConfig{
+			Enabled:      getEnvAsBool("ADMISSION_ENABLED", true),
+			QueueTimeout: getEnvAsDuration("ADMISSION_QUEUE_TIMEOUT", 500*time.Millisecond),
+			Read: AdmissionLimit{
+				Concurrency: getEnvAsInt("ADMISSION_READ_CONCURRENCY", 128),
+				Queue:       getEnvAsInt("ADMISSION_READ_QUEUE", 64),
+			},
+			Write: AdmissionLimit{
+				Concurrency: getEnvAsInt("ADMISSION_WRITE_CONCURRENCY", 64),
+				Queue:       getEnvAsInt("ADMISSION_WRITE_QUEUE", 32),
+			},
+			Heavy: AdmissionLimit{
+				Concurrency: getEnvAsInt("ADMISSION_HEAVY_CONCURRENCY", 4),
+				Queue:       getEnvAsInt("ADMISSION_HEAVY_QUEUE", 4),
+			},
+		},
 	}
 
 	if err := config.Validate(); err != nil {
@@ -203,13 +388,55 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("DB_PASSWORD is required")
 	}
 
+	if c.Database.TargetSessionAttrs != "any" && c.Database.TargetSessionAttrs != "read-write" {
+		return fmt.Errorf(`DB_TARGET_SESSION_ATTRS must be "any" or "read-write"`)
+	}
+
 	if c.TLS.Enabled && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
 		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE are required when TLS is enabled")
 	}
 
+	if _, err := ParseTrustedProxies(c.RateLimit.TrustedProxies); err != nil {
+		return fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+
+	if _, ok := c.WebSocket.RegionPeers[c.App.Region]; ok {
+		return fmt.Errorf("REGION_PEERS must not include APP_REGION (%s)", c.App.Region)
+	}
+
+	if c.App.IsProduction() {
+		return c.validateProduction()
+	}
+
 	return nil
 }
 
+// ParseTrustedProxies reads a list of IPs and CIDRs as networks; a lone IP
+// is a network of one.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR", proxy)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -251,6 +478,17 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 	return defaultValue
 }
 
+// getEnvAsMap reads "key=value,key=value" pairs.
+func getEnvAsMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range splitString(os.Getenv(key), ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return result
+}
+
 func splitString(s string, sep string) []string {
 	var result []string
 	for _, item := range strings.Split(s, sep) {
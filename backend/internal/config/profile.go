@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Environments with their own rules. Anything else in APP_ENV is treated
+// like development.
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Token lifetimes used when the JWT_*_EXPIRY variables aren't set. Production
+// may shorten them but not stretch them.
+const (
+	DefaultAccessTokenExpiry  = 15 * time.Minute
+	DefaultRefreshTokenExpiry = 7 * 24 * time.Hour
+	DefaultAppTokenExpiry     = time.Hour
+)
+
+// exampleSecretKey is the JWT secret shipped in .env.example.
+const exampleSecretKey = "your-secret-key-change-in-production"
+
+func (a AppConfig) IsProduction() bool {
+	return a.Env == EnvProduction
+}
+
+// loadEnvFiles loads the profile for APP_ENV (.env.production, .env.staging,
+// ...) and then .env. Variables already in the environment win over both,
+// and the profile wins over .env. APP_ENV itself can come from the
+// environment or from .env.
+func loadEnvFiles() error {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		values, err := godotenv.Read(".env")
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error loading .env file: %w", err)
+		}
+		env = values["APP_ENV"]
+	}
+
+	var files []string
+	if env != "" {
+		files = append(files, ".env."+env)
+	}
+	files = append(files, ".env")
+
+	for _, file := range files {
+		if err := godotenv.Load(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error loading %s file: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// validateProduction reports every production rule the configuration
+// breaks, not just the first, so an operator can fix them in one pass.
+func (c *Config) validateProduction() error {
+	var errs []error
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			errs = append(errs, errors.New(`CORS_ALLOWED_ORIGINS must list origins, not "*"`))
+			break
+		}
+	}
+
+	if !c.TLS.Enabled && !isLocalHost(c.App.Host) {
+		errs = append(errs, fmt.Errorf("TLS_ENABLED is required when APP_HOST (%s) isn't localhost; bind to 127.0.0.1 behind a TLS-terminating proxy instead", c.App.Host))
+	}
+
+	if c.JWT.SecretKey == exampleSecretKey {
+		errs = append(errs, errors.New("JWT_SECRET_KEY is still the example value"))
+	}
+	for _, expiry := range []struct {
+		name           string
+		value, maximum time.Duration
+	}{
+		{"JWT_ACCESS_TOKEN_EXPIRY", c.JWT.AccessTokenExpiry, DefaultAccessTokenExpiry},
+		{"JWT_REFRESH_TOKEN_EXPIRY", c.JWT.RefreshTokenExpiry, DefaultRefreshTokenExpiry},
+		{"JWT_APP_TOKEN_EXPIRY", c.JWT.AppTokenExpiry, DefaultAppTokenExpiry},
+	} {
+		if expiry.value > expiry.maximum {
+			errs = append(errs, fmt.Errorf("%s (%s) can't be longer than the default %s", expiry.name, expiry.value, expiry.maximum))
+		}
+	}
+
+	if c.Metrics.Enabled && c.Metrics.Token == "" && !isLocalHost(c.App.Host) {
+		errs = append(errs, errors.New("METRICS_TOKEN is required when metrics are enabled and APP_HOST isn't localhost"))
+	}
+
+	if strings.EqualFold(c.Logger.Level, "debug") {
+		errs = append(errs, errors.New("LOG_LEVEL=debug logs request details; use info or above"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func isLocalHost(host string) bool {
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Package correlation carries the ID that ties together everything one
+// action caused: the HTTP request, the events it published, the jobs and
+// webhook deliveries they led to, and the WebSocket frames sent along the
+// way. Support can follow "user clicked send" across every log and payload
+// from the ID the client got back in X-Request-ID.
+package correlation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// Header carries the ID on API responses. A request that already has one,
+// set by a proxy or a client retrying, keeps it.
+const Header = "X-Request-ID"
+
+// maxLength bounds IDs taken from request headers, which end up in logs,
+// payloads and database rows.
+const maxLength = 128
+
+type contextKey struct{}
+
+// New returns a fresh ID, for work no request started, such as a scheduled
+// job's run.
+func New() string {
+	return uuid.New().String()
+}
+
+// Parse accepts an ID from a request header if it's short and made only of
+// letters, digits and -_.:, and reports whether it was.
+func Parse(s string) (string, bool) {
+	if s == "" || len(s) > maxLength {
+		return "", false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// NewContext returns ctx carrying id. An empty id leaves ctx as it is.
+func NewContext(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID ctx carries, or "".
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Log adds the ID ctx carries, if any, to log lines as request_id, the
+// field the request log uses, so one search finds them all.
+func Log(ctx context.Context, log *logger.Logger) *logger.Logger {
+	if id := FromContext(ctx); id != "" {
+		return log.WithRequestID(id)
+	}
+	return log
+}
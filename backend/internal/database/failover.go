@@ -0,0 +1,311 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/metrics"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// ErrNoPrimary means every host was reachable only as a read-only standby,
+// as happens mid-failover before a standby is promoted.
+var ErrNoPrimary = errors.New("no database host accepts writes")
+
+const (
+	// connectTimeoutSeconds keeps a dead host from holding up the next.
+	connectTimeoutSeconds = 5
+	// readRetryBackoff is the first pause before running a read again; it
+	// doubles with each retry.
+	readRetryBackoff = 250 * time.Millisecond
+)
+
+var (
+	readRetries = metrics.Default.NewCounterVec("cbalite_db_read_retries_total",
+		"Reads run again after failing for lack of a working database connection.")
+	poolResets = metrics.Default.NewCounterVec("cbalite_db_pool_resets_total",
+		"Times the connection pool was dropped because the database check failed.")
+)
+
+// pqConn is everything database/sql uses of a lib/pq connection.
+type pqConn interface {
+	driver.Conn
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// connector opens connections to the first host in the list that will
+// take them, trying the one that last worked first. With read-write
+// sessions it skips standbys, the way libpq's target_session_attrs does, so
+// after a failover new connections find the promoted primary.
+//
+// Connections carry the generation they were opened in. Resetting the pool
+// starts a new generation, and database/sql drops older connections instead
+// of reusing them, including ones to an old primary that's still up.
+type connector struct {
+	hosts     []string
+	cfg       *config.DatabaseConfig
+	readWrite bool
+
+	preferred  atomic.Int32
+	generation atomic.Uint64
+}
+
+func newConnector(cfg *config.DatabaseConfig) *connector {
+	var hosts []string
+	for _, host := range strings.Split(cfg.Host, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return &connector{
+		hosts:     hosts,
+		cfg:       cfg,
+		readWrite: cfg.TargetSessionAttrs == "read-write",
+	}
+}
+
+// dsn builds the connection string for one host, which may carry its own
+// port.
+func (c *connector) dsn(host string) string {
+	port := strconv.Itoa(c.cfg.Port)
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
+		host, port, c.cfg.User, c.cfg.Password, c.cfg.DBName, c.cfg.SSLMode, connectTimeoutSeconds,
+	)
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, _, err := c.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pc, ok := conn.(pqConn); ok {
+		return &generationConn{pqConn: pc, generation: c.generation.Load(), connector: c}, nil
+	}
+	return conn, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// open connects to the first usable host and returns its connection string
+// too.
+func (c *connector) open(ctx context.Context) (driver.Conn, string, error) {
+	if len(c.hosts) == 0 {
+		return nil, "", errors.New("DB_HOST is empty")
+	}
+
+	start := int(c.preferred.Load())
+	var errs []error
+	for i := range c.hosts {
+		index := (start + i) % len(c.hosts)
+		dsn := c.dsn(c.hosts[index])
+
+		conn, err := c.dial(ctx, dsn)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.hosts[index], err))
+			continue
+		}
+		c.preferred.Store(int32(index))
+		return conn, dsn, nil
+	}
+	return nil, "", fmt.Errorf("no usable database host: %w", errors.Join(errs...))
+}
+
+func (c *connector) dial(ctx context.Context, dsn string) (driver.Conn, error) {
+	pqConnector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pqConnector.Connect(ctx)
+	if err != nil || !c.readWrite {
+		return conn, err
+	}
+
+	readOnly, err := isReadOnly(ctx, conn)
+	if err == nil && readOnly {
+		err = ErrNoPrimary
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// reset starts a new generation, so pooled connections are replaced as
+// they come back to the pool.
+func (c *connector) reset() {
+	c.generation.Add(1)
+	poolResets.With().Inc()
+}
+
+func isReadOnly(ctx context.Context, conn driver.Conn) (bool, error) {
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return false, errors.New("connection can't run queries")
+	}
+	rows, err := queryer.QueryContext(ctx, "SHOW transaction_read_only", nil)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	values := make([]driver.Value, 1)
+	if err := rows.Next(values); err != nil {
+		return false, err
+	}
+	switch v := values[0].(type) {
+	case string:
+		return v == "on", nil
+	case []byte:
+		return string(v) == "on", nil
+	}
+	return false, fmt.Errorf("unexpected transaction_read_only value %v", values[0])
+}
+
+type generationConn struct {
+	pqConn
+	generation uint64
+	connector  *connector
+}
+
+// IsValid is asked before database/sql reuses a pooled connection.
+func (c *generationConn) IsValid() bool {
+	return c.generation == c.connector.generation.Load() && c.pqConn.IsValid()
+}
+
+// isConnectionError reports whether err means the query never got a working
+// connection, as opposed to the query itself failing.
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrNoPrimary) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03": // admin_shutdown, crash_shutdown, cannot_connect_now
+			return true
+		}
+		return pqErr.Code.Class() == "08" // connection_exception
+	}
+	return false
+}
+
+// isRead reports whether a statement only reads, so running it twice is
+// harmless.
+func isRead(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	return len(query) >= 6 && strings.EqualFold(query[:6], "SELECT")
+}
+
+// retryRead runs a read again while it fails for lack of a working
+// connection, up to DB_READ_RETRIES times with doubling pauses. Statements
+// that write are run once: a write whose connection broke may still have
+// committed.
+func (db *PostgresDB) retryRead(ctx context.Context, query string, run func() error) error {
+	err := run()
+	if err == nil || !isRead(query) {
+		return err
+	}
+
+	backoff := readRetryBackoff
+	for attempt := 0; attempt < db.config.ReadRetries && isConnectionError(err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		readRetries.With().Inc()
+		err = run()
+	}
+	return err
+}
+
+// Ready reports whether the last database check passed. It's false while
+// the database is unreachable or, with read-write sessions, while no host
+// accepts writes.
+func (db *PostgresDB) Ready() bool {
+	return db.ready.Load()
+}
+
+// WatchFailover checks the database every DB_HEALTH_CHECK_INTERVAL. When a
+// check fails the server stops reporting ready and drops its pooled
+// connections, so new ones go to whichever host is now the primary; it
+// reports ready again once a check passes. Call it once.
+func (db *PostgresDB) WatchFailover(log *logger.Logger) {
+	if db.config.HealthCheckInterval <= 0 {
+		return
+	}
+	metrics.Default.NewGaugeFunc("cbalite_db_ready", "1 while the last database check passed, 0 otherwise.", func() float64 {
+		if db.Ready() {
+			return 1
+		}
+		return 0
+	})
+
+	go func() {
+		ticker := time.NewTicker(db.config.HealthCheckInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			// A check waiting behind a busy pool would fail for the wrong
+			// reason; the queries keeping it busy find failures soon enough
+			if db.full() {
+				continue
+			}
+
+			err := db.checkPrimary()
+			if err == nil {
+				if !db.ready.Swap(true) {
+					log.Info("Database check passed; ready again")
+				}
+				continue
+			}
+
+			if db.ready.Swap(false) {
+				log.WithError(err).Warn("Database check failed; not ready until it passes")
+			}
+			db.connector.reset()
+		}
+	}()
+}
+
+func (db *PostgresDB) checkPrimary() error {
+	ctx, cancel := context.WithTimeout(context.Background(), db.config.HealthCheckInterval)
+	defer cancel()
+
+	var readOnly string
+	if err := db.DB.QueryRowContext(ctx, "SHOW transaction_read_only").Scan(&readOnly); err != nil {
+		return err
+	}
+	if db.connector.readWrite && readOnly == "on" {
+		return ErrNoPrimary
+	}
+	return nil
+}
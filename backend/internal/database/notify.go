@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	// listenerPingInterval finds a dead connection that no notification
+	// would have noticed.
+	listenerPingInterval = 90 * time.Second
+)
+
+// Notify sends payload to every session listening on channel, this
+// instance's included.
+func (db *PostgresDB) Notify(ctx context.Context, channel, payload string) error {
+	_, err := db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
+
+// Listen calls handle with each payload sent on channel until ctx ends. It
+// holds a connection of its own, outside the pool, to the host the pool
+// would use. It reconnects when the connection drops; notifications sent
+// while it was down are lost, so reconnected is called once it's back. If
+// the host can't be reached again, as after a failover, Listen returns so
+// the caller can listen again on the new primary.
+func (db *PostgresDB) Listen(ctx context.Context, channel string, handle func(payload string), reconnected func()) error {
+	conn, dsn, err := db.connector.open(ctx)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+
+	failed := make(chan error, 1)
+	listener := pq.NewListener(dsn, listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		if event == pq.ListenerEventConnectionAttemptFailed {
+			select {
+			case failed <- err:
+			default:
+			}
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-failed:
+			return fmt.Errorf("lost connection for %s: %w", channel, err)
+		case notification := <-listener.Notify:
+			// The listener sends nil after reconnecting
+			if notification == nil {
+				reconnected()
+				continue
+			}
+			handle(notification.Extra)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cbalite/backend/internal/metrics"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// ErrPoolExhausted means no connection came free within the acquisition
+// timeout.
+var ErrPoolExhausted = errors.New("no database connection available")
+
+const (
+	monitorInterval = 5 * time.Second
+	// saturationLogInterval keeps a long saturation from flooding the logs;
+	// each report covers the queries since the last one.
+	saturationLogInterval = time.Minute
+	topSourcesLogged      = 5
+)
+
+const packagePrefix = "github.com/cbalite/backend/internal/database."
+
+var (
+	poolAcquireTimeouts = metrics.Default.NewCounterVec("cbalite_db_acquire_timeouts_total",
+		"Requests turned away because no database connection came free within DB_ACQUIRE_TIMEOUT.")
+	queriesIssued = metrics.Default.NewCounterVec("cbalite_db_queries_total",
+		"Queries, statements and transactions started, whatever their outcome.")
+)
+
+// querySources counts queries by the function that issued them, so a
+// saturated pool can be traced to the code keeping it busy. Finding the
+// caller walks the stack, so it's only done while the pool is saturated.
+type querySources struct {
+	active atomic.Bool
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (s *querySources) record() {
+	queriesIssued.With().Inc()
+	if !s.active.Load() {
+		return
+	}
+	source := callerOutsidePackage()
+
+	s.mu.Lock()
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+	}
+	s.counts[source]++
+	s.mu.Unlock()
+}
+
+// start begins counting sources.
+func (s *querySources) start() {
+	s.active.Store(true)
+}
+
+// stop stops counting sources and forgets those counted.
+func (s *querySources) stop() {
+	s.active.Store(false)
+	s.mu.Lock()
+	s.counts = nil
+	s.mu.Unlock()
+}
+
+// top returns the busiest sources since the last reset and starts counting
+// again.
+func (s *querySources) top(n int) string {
+	s.mu.Lock()
+	counts := s.counts
+	s.counts = nil
+	s.mu.Unlock()
+
+	sources := make([]string, 0, len(counts))
+	for source := range counts {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if counts[sources[i]] != counts[sources[j]] {
+			return counts[sources[i]] > counts[sources[j]]
+		}
+		return sources[i] < sources[j]
+	})
+	if len(sources) > n {
+		sources = sources[:n]
+	}
+
+	parts := make([]string, len(sources))
+	for i, source := range sources {
+		parts[i] = fmt.Sprintf("%s (%d)", source, counts[source])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// callerOutsidePackage names the first function up the stack that isn't
+// in this package, such as main.(*Application).getTeamRole.
+func callerOutsidePackage() string {
+	var pcs [8]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) {
+			if i := strings.LastIndex(frame.Function, "/"); i >= 0 {
+				return frame.Function[i+1:]
+			}
+			return frame.Function
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// The query methods of sql.DB are wrapped to count queries and where they
+// come from, and to retry reads through a failover; otherwise they behave
+// like the originals.
+
+func (db *PostgresDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	db.sources.record()
+	return db.query(context.Background(), query, args...)
+}
+
+func (db *PostgresDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.sources.record()
+	return db.query(ctx, query, args...)
+}
+
+func (db *PostgresDB) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := db.retryRead(ctx, query, func() (err error) {
+		rows, err = db.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (db *PostgresDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	db.sources.record()
+	return db.queryRow(context.Background(), query, args...)
+}
+
+func (db *PostgresDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	db.sources.record()
+	return db.queryRow(ctx, query, args...)
+}
+
+func (db *PostgresDB) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	db.retryRead(ctx, query, func() error {
+		row = db.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+func (db *PostgresDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	db.sources.record()
+	return db.DB.Exec(query, args...)
+}
+
+func (db *PostgresDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.sources.record()
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+func (db *PostgresDB) Begin() (*sql.Tx, error) {
+	db.sources.record()
+	return db.DB.Begin()
+}
+
+func (db *PostgresDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	db.sources.record()
+	return db.DB.BeginTx(ctx, opts)
+}
+
+// full reports whether every connection the pool may open is in use.
+func (db *PostgresDB) full() bool {
+	stats := db.Stats()
+	return stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections
+}
+
+// Admit lets a request in when the pool has a connection to spare, or one
+// comes free within the acquisition timeout, and returns ErrPoolExhausted
+// otherwise. Turning requests away early beats queueing them until the
+// HTTP write timeout, by which point the client has given up anyway. A
+// pool with room costs nothing more than reading its stats.
+func (db *PostgresDB) Admit(ctx context.Context) error {
+	if db.config.AcquireTimeout <= 0 || !db.full() {
+		return nil
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, db.config.AcquireTimeout)
+	defer cancel()
+
+	conn, err := db.Conn(acquireCtx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			poolAcquireTimeouts.With().Inc()
+			return ErrPoolExhausted
+		}
+		// The request was cancelled or the database is down; the handler
+		// reports that better than we can
+		return nil
+	}
+	// The connection goes back to the pool for the request's own queries
+	conn.Close()
+	return nil
+}
+
+// Monitor exports the pool's stats as metrics and, while requests are
+// waiting for connections, logs which code issued the most queries. Call
+// it once.
+func (db *PostgresDB) Monitor(log *logger.Logger) {
+	stat := func(read func(sql.DBStats) float64) func() float64 {
+		return func() float64 { return read(db.Stats()) }
+	}
+	metrics.Default.NewGaugeFunc("cbalite_db_connections_max", "Most connections the pool may open.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	metrics.Default.NewGaugeFunc("cbalite_db_connections_open", "Connections open, in use or idle.",
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	metrics.Default.NewGaugeFunc("cbalite_db_connections_in_use", "Connections running a query or transaction.",
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	metrics.Default.NewGaugeFunc("cbalite_db_connections_idle", "Open connections waiting for work.",
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	metrics.Default.NewCounterFunc("cbalite_db_waits_total", "Times a query had to wait for a connection.",
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	metrics.Default.NewCounterFunc("cbalite_db_wait_seconds_total", "Time spent waiting for connections.",
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+
+	go db.watchSaturation(log)
+}
+
+func (db *PostgresDB) watchSaturation(log *logger.Logger) {
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
+	last := db.Stats()
+	var lastLogged time.Time
+	for range ticker.C {
+		stats := db.Stats()
+		waits := stats.WaitCount - last.WaitCount
+		waited := stats.WaitDuration - last.WaitDuration
+		last = stats
+
+		// Sources are counted from the first check that finds queries
+		// waiting, so reports cover the queries since then, or since the
+		// last report
+		if waits == 0 {
+			db.sources.stop()
+			continue
+		}
+		if !db.sources.active.Load() {
+			db.sources.start()
+			continue
+		}
+		if time.Since(lastLogged) < saturationLogInterval {
+			continue
+		}
+		lastLogged = time.Now()
+		log.Warnf("Database pool saturated: %d of %d connections in use, %d queries waited %s in the last %s; top query sources: %s",
+			stats.InUse, stats.MaxOpenConnections, waits, waited.Round(time.Millisecond), monitorInterval, db.sources.top(topSourcesLogged))
+	}
+}
@@ -4,27 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/lib/pq"
 	"github.com/cbalite/backend/internal/config"
 )
 
 type PostgresDB struct {
 	*sql.DB
-	config *config.DatabaseConfig
+	config    *config.DatabaseConfig
+	connector *connector
+	sources   querySources
+	ready     atomic.Bool
 }
 
 func NewPostgresDB(cfg *config.DatabaseConfig) (*PostgresDB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
-	)
-
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
-	}
+	connector := newConnector(cfg)
+	db := sql.OpenDB(connector)
 
 	db.SetMaxOpenConns(cfg.MaxConnections)
 	db.SetMaxIdleConns(cfg.MaxIdleConnections)
@@ -34,10 +30,13 @@ func NewPostgresDB(cfg *config.DatabaseConfig) (*PostgresDB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgresDB{
-		DB:     db,
-		config: cfg,
-	}, nil
+	postgres := &PostgresDB{
+		DB:        db,
+		config:    cfg,
+		connector: connector,
+	}
+	postgres.ready.Store(true)
+	return postgres, nil
 }
 
 func (db *PostgresDB) Close() error {
@@ -52,11 +51,11 @@ func (db *PostgresDB) HealthCheck() error {
 }
 
 func (db *PostgresDB) BeginTransaction(ctx context.Context) (*sql.Tx, error) {
-	return db.DB.BeginTx(ctx, nil)
+	return db.BeginTx(ctx, nil)
 }
 
 func (db *PostgresDB) RunInTransaction(ctx context.Context, fn func(*sql.Tx) error) error {
-	tx, err := db.DB.BeginTx(ctx, nil)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -0,0 +1,168 @@
+package deprecation
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/pkg/httpjson"
+)
+
+// Notice announces a change clients need to make before an endpoint or
+// payload field goes away.
+type Notice struct {
+	// Method and Endpoint (a route template such as
+	// "/api/v1/teams/{teamId}/members") name the deprecated route. An
+	// empty Method covers every method.
+	Method   string     `json:"method,omitempty"`
+	Endpoint string     `json:"endpoint"`
+	Message  string     `json:"message"`
+	Since    *time.Time `json:"since,omitempty"`
+	Sunset   *time.Time `json:"sunset,omitempty"`
+	// Link points at migration docs or the replacement.
+	Link string `json:"link,omitempty"`
+}
+
+// Notices lists the deprecations currently in effect; clients read them
+// from /api/v1/meta, and routes listed here get Deprecation and Sunset
+// headers.
+var Notices = []Notice{}
+
+// Call is who called a deprecated route: a user directly, or an app acting
+// for one.
+type Call struct {
+	Method   string
+	Endpoint string
+	UserID   string
+	AppID    string
+}
+
+// Usage is how often a caller used a route since the last flush.
+type Usage struct {
+	Call
+	Calls int64
+	First time.Time
+	Last  time.Time
+}
+
+// Tracker applies the notices to requests and counts calls to deprecated
+// routes in memory until they're flushed.
+type Tracker struct {
+	notices map[string]Notice
+
+	mu    sync.Mutex
+	usage map[Call]*Usage
+}
+
+func NewTracker(notices []Notice) *Tracker {
+	t := &Tracker{
+		notices: make(map[string]Notice, len(notices)),
+		usage:   make(map[Call]*Usage),
+	}
+	for _, notice := range notices {
+		t.notices[notice.Method+" "+notice.Endpoint] = notice
+	}
+	return t
+}
+
+// lookup finds the notice for the request's matched route.
+func (t *Tracker) lookup(r *http.Request) (Notice, bool) {
+	if len(t.notices) == 0 {
+		return Notice{}, false
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return Notice{}, false
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return Notice{}, false
+	}
+	if notice, ok := t.notices[r.Method+" "+template]; ok {
+		return notice, true
+	}
+	notice, ok := t.notices[" "+template]
+	return notice, ok
+}
+
+// Annotate adds Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers
+// to responses from deprecated routes, and answers 410 Gone once a route's
+// sunset has passed. It must run on a router, where the route is matched.
+func (t *Tracker) Annotate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notice, ok := t.lookup(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if notice.Since != nil {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(notice.Since.Unix(), 10))
+		} else {
+			w.Header().Set("Deprecation", "true")
+		}
+		if notice.Sunset != nil {
+			w.Header().Set("Sunset", notice.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if notice.Link != "" {
+			w.Header().Add("Link", "<"+notice.Link+`>; rel="deprecation"; type="text/html"`)
+		}
+
+		if notice.Sunset != nil && !time.Now().Before(*notice.Sunset) {
+			httpjson.Respond(w, http.StatusGone, map[string]string{
+				"error": notice.Message,
+				"code":  "endpoint_sunset",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Record counts calls to deprecated routes by the authenticated caller. It
+// must run after authentication.
+func (t *Tracker) Record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if notice, ok := t.lookup(r); ok {
+			if claims, ok := middleware.GetUserFromContext(r.Context()); ok {
+				t.count(Call{
+					Method:   r.Method,
+					Endpoint: notice.Endpoint,
+					UserID:   claims.UserID,
+					AppID:    claims.AppID,
+				}, time.Now())
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (t *Tracker) count(call Call, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage, ok := t.usage[call]
+	if !ok {
+		usage = &Usage{Call: call, First: at}
+		t.usage[call] = usage
+	}
+	usage.Calls++
+	usage.Last = at
+}
+
+// Drain returns the counts gathered since the last call and starts over.
+func (t *Tracker) Drain() []Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := make([]Usage, 0, len(t.usage))
+	for _, u := range t.usage {
+		usage = append(usage, *u)
+	}
+	t.usage = make(map[Call]*Usage)
+	return usage
+}
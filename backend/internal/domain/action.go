@@ -0,0 +1,34 @@
+package domain
+
+// QuickAction describes a named action the command palette can run through
+// POST /actions, with the params it takes.
+type QuickAction struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Params      []ActionParam `json:"params"`
+}
+
+// ActionParam is one param of a quick action. Every param is a string, like
+// automation rule params; Kind tells the palette how to complete it.
+type ActionParam struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	Description string   `json:"description"`
+	Required    bool     `json:"required"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// Kinds of action params.
+const (
+	ActionParamText    = "text"
+	ActionParamChannel = "channel"
+	ActionParamUser    = "user"
+	ActionParamTask    = "task"
+	ActionParamTime    = "time"
+)
+
+type RunQuickAction struct {
+	Action string            `json:"action" validate:"required"`
+	TeamID string            `json:"team_id" validate:"required,uuid"`
+	Params map[string]string `json:"params"`
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+)
+
+type TeamAISettings struct {
+	TeamID                 string    `json:"team_id" db:"team_id"`
+	SummariesEnabled       bool      `json:"summaries_enabled" db:"summaries_enabled"`
+	TaskSuggestionsEnabled bool      `json:"task_suggestions_enabled" db:"task_suggestions_enabled"`
+	MonthlyTokenBudget     int       `json:"monthly_token_budget" db:"monthly_token_budget"`
+	TokensUsed             int       `json:"tokens_used"`
+	UpdatedAt              time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type UpdateTeamAISettings struct {
+	SummariesEnabled       *bool `json:"summaries_enabled,omitempty"`
+	TaskSuggestionsEnabled *bool `json:"task_suggestions_enabled,omitempty"`
+	MonthlyTokenBudget     *int  `json:"monthly_token_budget,omitempty" validate:"omitempty,min=0"`
+}
+
+type ChannelSummary struct {
+	ChannelID    string    `json:"channel_id"`
+	Since        time.Time `json:"since"`
+	MessageCount int       `json:"message_count"`
+	Summary      string    `json:"summary"`
+	Cached       bool      `json:"cached"`
+	TokensUsed   int       `json:"tokens_used"`
+	GeneratedAt  time.Time `json:"generated_at"`
+}
+
+type TaskSuggestionStatus string
+
+const (
+	TaskSuggestionPending   TaskSuggestionStatus = "pending"
+	TaskSuggestionAccepted  TaskSuggestionStatus = "accepted"
+	TaskSuggestionDismissed TaskSuggestionStatus = "dismissed"
+)
+
+type TaskSuggestion struct {
+	ID          string               `json:"id" db:"id"`
+	TeamID      string               `json:"team_id" db:"team_id"`
+	ChannelID   string               `json:"channel_id" db:"channel_id"`
+	MessageID   string               `json:"message_id" db:"message_id"`
+	Title       string               `json:"title" db:"title"`
+	Description string               `json:"description" db:"description"`
+	AssigneeID  *string              `json:"assignee_id,omitempty" db:"assignee_id"`
+	DueDate     *time.Time           `json:"due_date,omitempty" db:"due_date"`
+	Status      TaskSuggestionStatus `json:"status" db:"status"`
+	TaskID      *string              `json:"task_id,omitempty" db:"task_id"`
+	Excerpt     string               `json:"excerpt"`
+	CreatedAt   time.Time            `json:"created_at" db:"created_at"`
+}
+
+type AcceptTaskSuggestion struct {
+	Title      *string `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Priority   *string `json:"priority,omitempty"`
+	AssigneeID *string `json:"assignee_id,omitempty"`
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"time"
+)
+
+type App struct {
+	ID            string       `json:"id" db:"id"`
+	Name          string       `json:"name" db:"name"`
+	Description   string       `json:"description" db:"description"`
+	ClientID      string       `json:"client_id" db:"client_id"`
+	ClientSecret  string       `json:"client_secret,omitempty"`
+	SigningSecret string       `json:"signing_secret,omitempty"`
+	RedirectURIs  []string     `json:"redirect_uris" db:"redirect_uris"`
+	Manifest      *AppManifest `json:"manifest,omitempty" db:"manifest"`
+	BotUserID     *string      `json:"bot_user_id,omitempty" db:"bot_user_id"`
+	OwnerID       string       `json:"owner_id" db:"owner_id"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// CreateApp registers an app either from a manifest, which then supplies the
+// name, description and redirect URIs, or from those fields alone.
+type CreateApp struct {
+	Name         string       `json:"name" validate:"omitempty,min=1,max=100"`
+	Description  string       `json:"description"`
+	RedirectURIs []string     `json:"redirect_uris"`
+	Manifest     *AppManifest `json:"manifest,omitempty"`
+}
+
+type AppInstallation struct {
+	ID          string    `json:"id" db:"id"`
+	TeamID      string    `json:"team_id" db:"team_id"`
+	AppID       string    `json:"app_id" db:"app_id"`
+	AppName     string    `json:"app_name" db:"app_name"`
+	Scopes      []string  `json:"scopes" db:"scopes"`
+	InstalledBy string    `json:"installed_by" db:"installed_by"`
+	InstalledAt time.Time `json:"installed_at" db:"installed_at"`
+}
+
+type UpdateAppInstallation struct {
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+}
+
+// AuthorizeApp is the consent a team admin gives in the OAuth2
+// authorization-code flow.
+type AuthorizeApp struct {
+	ClientID    string `json:"client_id" validate:"required"`
+	TeamID      string `json:"team_id" validate:"required"`
+	RedirectURI string `json:"redirect_uri" validate:"required"`
+	Scope       string `json:"scope" validate:"required"`
+	State       string `json:"state"`
+}
+
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	TeamID       string `json:"team_id"`
+}
+
+// AppManifest declares everything an app needs from a team. Installing the
+// app provisions its bot user, webhooks and slash commands together.
+type AppManifest struct {
+	Name         string               `json:"name"`
+	Description  string               `json:"description,omitempty"`
+	Scopes       []string             `json:"scopes"`
+	RedirectURIs []string             `json:"redirect_uris,omitempty"`
+	Bot          *AppManifestBot      `json:"bot,omitempty"`
+	Webhooks     []AppManifestWebhook `json:"webhooks,omitempty"`
+	Commands     []AppManifestCommand `json:"commands,omitempty"`
+}
+
+type AppManifestBot struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+}
+
+type AppManifestWebhook struct {
+	Event string `json:"event"`
+	URL   string `json:"url"`
+}
+
+type AppManifestCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+type InstallApp struct {
+	AppID  string   `json:"app_id" validate:"required"`
+	Scopes []string `json:"scopes,omitempty"`
+}
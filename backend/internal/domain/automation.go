@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+)
+
+type AutomationRule struct {
+	ID         string          `json:"id" db:"id"`
+	TeamID     string          `json:"team_id" db:"team_id"`
+	Name       string          `json:"name" db:"name"`
+	Trigger    string          `json:"trigger" db:"trigger"`
+	Conditions []RuleCondition `json:"conditions" db:"conditions"`
+	Actions    []RuleAction    `json:"actions" db:"actions"`
+	IsEnabled  bool            `json:"is_enabled" db:"is_enabled"`
+	CreatedBy  string          `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+type RuleCondition struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+type RuleAction struct {
+	Type   string            `json:"type"`
+	Params map[string]string `json:"params"`
+}
+
+type AutomationRun struct {
+	ID              string    `json:"id" db:"id"`
+	RuleID          string    `json:"rule_id" db:"rule_id"`
+	TeamID          string    `json:"team_id" db:"team_id"`
+	EventID         string    `json:"event_id" db:"event_id"`
+	EventType       string    `json:"event_type" db:"event_type"`
+	Status          string    `json:"status" db:"status"`
+	Error           string    `json:"error,omitempty" db:"error"`
+	ActionsExecuted int       `json:"actions_executed" db:"actions_executed"`
+	ChainDepth      int       `json:"chain_depth" db:"chain_depth"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+const (
+	AutomationRunSucceeded = "succeeded"
+	AutomationRunFailed    = "failed"
+	AutomationRunSkipped   = "skipped"
+)
+
+type CreateAutomationRule struct {
+	Name       string          `json:"name" validate:"required,min=1,max=100"`
+	Trigger    string          `json:"trigger" validate:"required"`
+	Conditions []RuleCondition `json:"conditions"`
+	Actions    []RuleAction    `json:"actions" validate:"required,min=1"`
+	IsEnabled  *bool           `json:"is_enabled,omitempty"`
+}
+
+type UpdateAutomationRule struct {
+	Name       *string          `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Trigger    *string          `json:"trigger,omitempty"`
+	Conditions *[]RuleCondition `json:"conditions,omitempty"`
+	Actions    *[]RuleAction    `json:"actions,omitempty"`
+	IsEnabled  *bool            `json:"is_enabled,omitempty"`
+}
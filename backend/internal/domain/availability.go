@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+)
+
+// WorkingHours is when a user is usually at work, in their own timezone.
+type WorkingHours struct {
+	Timezone          string    `json:"timezone" db:"timezone"`
+	Weekdays          []string  `json:"weekdays" db:"weekdays"`
+	StartTime         string    `json:"start_time" db:"start_time"`
+	EndTime           string    `json:"end_time" db:"end_time"`
+	HoldNotifications bool      `json:"hold_notifications" db:"hold_notifications"`
+	UpdatedAt         time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+type UpdateWorkingHours struct {
+	Timezone          string   `json:"timezone"`
+	Weekdays          []string `json:"weekdays" validate:"required"`
+	StartTime         string   `json:"start_time" validate:"required"`
+	EndTime           string   `json:"end_time" validate:"required"`
+	HoldNotifications *bool    `json:"hold_notifications,omitempty"`
+}
+
+type AvailabilityStatus string
+
+const (
+	AvailabilityWorking      AvailabilityStatus = "working"
+	AvailabilityOutsideHours AvailabilityStatus = "outside_working_hours"
+)
+
+// Availability is the hint shown next to a member, computed from their
+// working hours at the time of the request.
+type Availability struct {
+	Status          AvailabilityStatus `json:"status"`
+	Hint            string             `json:"hint,omitempty"`
+	LocalTime       string             `json:"local_time"`
+	Timezone        string             `json:"timezone"`
+	NextAvailableAt *time.Time         `json:"next_available_at,omitempty"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+)
+
+type ChannelShareStatus string
+
+const (
+	ChannelSharePending ChannelShareStatus = "pending"
+	ChannelShareActive  ChannelShareStatus = "active"
+	ChannelShareEnded   ChannelShareStatus = "ended"
+)
+
+// ChannelShare is a channel of the host team opened up to a guest team.
+type ChannelShare struct {
+	ID                string             `json:"id" db:"id"`
+	ChannelID         string             `json:"channel_id" db:"channel_id"`
+	ChannelName       string             `json:"channel_name"`
+	HostTeamID        string             `json:"host_team_id" db:"host_team_id"`
+	HostTeamName      string             `json:"host_team_name"`
+	GuestTeamID       string             `json:"guest_team_id" db:"guest_team_id"`
+	GuestTeamName     string             `json:"guest_team_name"`
+	Status            ChannelShareStatus `json:"status" db:"status"`
+	GuestKeepsHistory bool               `json:"guest_keeps_history" db:"guest_keeps_history"`
+	RequestedBy       string             `json:"requested_by" db:"requested_by"`
+	AcceptedBy        *string            `json:"accepted_by,omitempty" db:"accepted_by"`
+	EndedBy           *string            `json:"ended_by,omitempty" db:"ended_by"`
+	CreatedAt         time.Time          `json:"created_at" db:"created_at"`
+	AcceptedAt        *time.Time         `json:"accepted_at,omitempty" db:"accepted_at"`
+	EndedAt           *time.Time         `json:"ended_at,omitempty" db:"ended_at"`
+}
+
+type CreateChannelShare struct {
+	TeamID            string `json:"team_id" validate:"required"`
+	GuestKeepsHistory bool   `json:"guest_keeps_history"`
+}
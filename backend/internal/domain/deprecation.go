@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// DeprecatedEndpointUsage is how often one caller has used a deprecated
+// endpoint. AppID is empty for calls the user made directly.
+type DeprecatedEndpointUsage struct {
+	Method        string    `json:"method" db:"method"`
+	Endpoint      string    `json:"endpoint" db:"endpoint"`
+	UserID        string    `json:"user_id" db:"user_id"`
+	Username      string    `json:"username" db:"username"`
+	AppID         string    `json:"app_id,omitempty" db:"app_id"`
+	AppName       string    `json:"app_name,omitempty" db:"app_name"`
+	Calls         int64     `json:"calls" db:"calls"`
+	FirstCalledAt time.Time `json:"first_called_at" db:"first_called_at"`
+	LastCalledAt  time.Time `json:"last_called_at" db:"last_called_at"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatHTML ExportFormat = "html"
+)
+
+type ExportStatus string
+
+const (
+	ExportStatusPending   ExportStatus = "pending"
+	ExportStatusRunning   ExportStatus = "running"
+	ExportStatusCompleted ExportStatus = "completed"
+	ExportStatusFailed    ExportStatus = "failed"
+)
+
+type ChannelExport struct {
+	ID           string       `json:"id" db:"id"`
+	TeamID       string       `json:"team_id" db:"team_id"`
+	ChannelID    string       `json:"channel_id" db:"channel_id"`
+	Format       ExportFormat `json:"format" db:"format"`
+	From         time.Time    `json:"from" db:"range_start"`
+	To           time.Time    `json:"to" db:"range_end"`
+	Status       ExportStatus `json:"status" db:"status"`
+	Progress     int          `json:"progress" db:"progress"`
+	MessageCount int          `json:"message_count" db:"message_count"`
+	Error        *string      `json:"error,omitempty" db:"error"`
+	RequestedBy  string       `json:"requested_by" db:"requested_by"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	CompletedAt  *time.Time   `json:"completed_at,omitempty" db:"completed_at"`
+}
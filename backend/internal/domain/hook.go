@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+type RestHook struct {
+	ID        string    `json:"id" db:"id"`
+	TeamID    string    `json:"team_id" db:"team_id"`
+	Event     string    `json:"event" db:"event"`
+	TargetURL string    `json:"target_url" db:"target_url"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateRestHook struct {
+	Event     string `json:"event" validate:"required"`
+	TargetURL string `json:"target_url" validate:"required,url"`
+}
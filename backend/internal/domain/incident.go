@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"time"
+)
+
+type IncidentSeverity string
+
+const (
+	SeveritySev1 IncidentSeverity = "sev1"
+	SeveritySev2 IncidentSeverity = "sev2"
+	SeveritySev3 IncidentSeverity = "sev3"
+	SeveritySev4 IncidentSeverity = "sev4"
+)
+
+type IncidentStatus string
+
+const (
+	IncidentInvestigating IncidentStatus = "investigating"
+	IncidentIdentified    IncidentStatus = "identified"
+	IncidentMonitoring    IncidentStatus = "monitoring"
+	IncidentResolved      IncidentStatus = "resolved"
+)
+
+type Incident struct {
+	ID              string           `json:"id" db:"id"`
+	TeamID          string           `json:"team_id" db:"team_id"`
+	Number          int              `json:"number" db:"number"`
+	Title           string           `json:"title" db:"title"`
+	Severity        IncidentSeverity `json:"severity" db:"severity"`
+	Status          IncidentStatus   `json:"status" db:"status"`
+	ChannelID       *string          `json:"channel_id,omitempty" db:"channel_id"`
+	StatusMessageID *string          `json:"status_message_id,omitempty" db:"status_message_id"`
+	TemplateID      *string          `json:"template_id,omitempty" db:"template_id"`
+	CreatedBy       string           `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+	ResolvedAt      *time.Time       `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// IncidentDetail is an incident with its transition history and linked tasks.
+type IncidentDetail struct {
+	Incident
+	Events []IncidentEvent `json:"events"`
+	Tasks  []IncidentTask  `json:"tasks"`
+}
+
+type IncidentEvent struct {
+	ID         string    `json:"id" db:"id"`
+	IncidentID string    `json:"incident_id" db:"incident_id"`
+	Type       string    `json:"type" db:"type"`
+	FromValue  string    `json:"from,omitempty" db:"from_value"`
+	ToValue    string    `json:"to,omitempty" db:"to_value"`
+	Note       string    `json:"note,omitempty" db:"note"`
+	ActorID    *string   `json:"actor_id,omitempty" db:"actor_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type IncidentTask struct {
+	TaskID   string    `json:"task_id" db:"task_id"`
+	Title    string    `json:"title" db:"title"`
+	Status   string    `json:"status" db:"status"`
+	LinkedBy string    `json:"linked_by" db:"linked_by"`
+	LinkedAt time.Time `json:"linked_at" db:"linked_at"`
+}
+
+type IncidentTemplate struct {
+	ID                 string           `json:"id" db:"id"`
+	TeamID             string           `json:"team_id" db:"team_id"`
+	Name               string           `json:"name" db:"name"`
+	ChannelPrefix      string           `json:"channel_prefix" db:"channel_prefix"`
+	ChannelDescription string           `json:"channel_description" db:"channel_description"`
+	StatusTemplate     string           `json:"status_template" db:"status_template"`
+	DefaultSeverity    IncidentSeverity `json:"default_severity" db:"default_severity"`
+	CreatedBy          string           `json:"created_by" db:"created_by"`
+	CreatedAt          time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// IncidentDocument is a generated artifact attached to an incident. Content
+// holds the rendered Markdown and Data the structured form.
+type IncidentDocument struct {
+	ID         string      `json:"id" db:"id"`
+	IncidentID string      `json:"incident_id" db:"incident_id"`
+	Kind       string      `json:"kind" db:"kind"`
+	Content    string      `json:"content" db:"content"`
+	Data       interface{} `json:"data" db:"data"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+type CreateIncident struct {
+	TeamID     string            `json:"team_id" validate:"required"`
+	Title      string            `json:"title" validate:"required,min=1,max=255"`
+	Severity   *IncidentSeverity `json:"severity,omitempty" validate:"omitempty,oneof=sev1 sev2 sev3 sev4"`
+	TemplateID *string           `json:"template_id,omitempty"`
+}
+
+type UpdateIncident struct {
+	Title    *string           `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Severity *IncidentSeverity `json:"severity,omitempty" validate:"omitempty,oneof=sev1 sev2 sev3 sev4"`
+	Status   *IncidentStatus   `json:"status,omitempty" validate:"omitempty,oneof=investigating identified monitoring resolved"`
+	Note     string            `json:"note,omitempty"`
+}
+
+type LinkIncidentTask struct {
+	TaskID string `json:"task_id" validate:"required"`
+}
+
+type CreateIncidentTemplate struct {
+	Name               string            `json:"name" validate:"required,min=1,max=100"`
+	ChannelPrefix      string            `json:"channel_prefix" validate:"omitempty,max=20"`
+	ChannelDescription string            `json:"channel_description"`
+	StatusTemplate     string            `json:"status_template"`
+	DefaultSeverity    *IncidentSeverity `json:"default_severity,omitempty" validate:"omitempty,oneof=sev1 sev2 sev3 sev4"`
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+)
+
+// IntegrationKind is what posted a message on a team's behalf: an app's bot
+// (acting through an app token) or an inbound webhook.
+type IntegrationKind string
+
+const (
+	IntegrationApp            IntegrationKind = "app"
+	IntegrationInboundWebhook IntegrationKind = "inbound_webhook"
+)
+
+func IsValidIntegrationKind(kind string) bool {
+	switch IntegrationKind(kind) {
+	case IntegrationApp, IntegrationInboundWebhook:
+		return true
+	}
+	return false
+}
+
+const (
+	ThrottleRateLimited = "integration_rate_limited"
+	ThrottleSuspended   = "integration_suspended"
+)
+
+// IntegrationThrottle is why an integration's message was refused. It is
+// both the body of the 429 the integration gets and what the delivery log
+// records. Scope, Limit and WindowSeconds describe the limit that was hit;
+// SuspendedUntil is set when the integration is suspended.
+type IntegrationThrottle struct {
+	Code              string     `json:"code"`
+	Scope             string     `json:"scope,omitempty"`
+	Limit             int        `json:"limit,omitempty"`
+	WindowSeconds     int        `json:"window_seconds,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds"`
+	SuspendedUntil    *time.Time `json:"suspended_until,omitempty"`
+}
+
+// IntegrationDelivery is a message an integration tried to post and was
+// refused.
+type IntegrationDelivery struct {
+	ID              string              `json:"id" db:"id"`
+	TeamID          string              `json:"team_id" db:"team_id"`
+	IntegrationKind IntegrationKind     `json:"integration_kind" db:"integration_kind"`
+	IntegrationID   string              `json:"integration_id" db:"integration_id"`
+	ChannelID       *string             `json:"channel_id,omitempty" db:"channel_id"`
+	Error           IntegrationThrottle `json:"error" db:"error"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+}
+
+// IntegrationSuspension keeps an integration from posting in a team until
+// SuspendedUntil.
+type IntegrationSuspension struct {
+	TeamID          string          `json:"team_id" db:"team_id"`
+	IntegrationKind IntegrationKind `json:"integration_kind" db:"integration_kind"`
+	IntegrationID   string          `json:"integration_id" db:"integration_id"`
+	Reason          string          `json:"reason" db:"reason"`
+	SuspendedUntil  time.Time       `json:"suspended_until" db:"suspended_until"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+)
+
+type KioskToken struct {
+	ID         string     `json:"id" db:"id"`
+	TeamID     string     `json:"team_id" db:"team_id"`
+	Name       string     `json:"name" db:"name"`
+	Token      string     `json:"token,omitempty"`
+	ChannelIDs []string   `json:"channel_ids"`
+	TaskBoard  bool       `json:"task_board" db:"task_board"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedBy  string     `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type CreateKioskToken struct {
+	Name       string     `json:"name" validate:"required,min=1,max=100"`
+	ChannelIDs []string   `json:"channel_ids"`
+	TaskBoard  bool       `json:"task_board"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
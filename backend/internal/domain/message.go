@@ -38,15 +38,18 @@ type Attachment struct {
 }
 
 type Channel struct {
-	ID          string      `json:"id" db:"id"`
-	TeamID      string      `json:"team_id" db:"team_id"`
-	Name        string      `json:"name" db:"name"`
-	Description string      `json:"description" db:"description"`
-	Type        ChannelType `json:"type" db:"type"`
-	IsPrivate   bool        `json:"is_private" db:"is_private"`
-	CreatedBy   string      `json:"created_by" db:"created_by"`
-	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+	ID                    string      `json:"id" db:"id"`
+	TeamID                string      `json:"team_id" db:"team_id"`
+	Name                  string      `json:"name" db:"name"`
+	Description           string      `json:"description" db:"description"`
+	Type                  ChannelType `json:"type" db:"type"`
+	IsPrivate             bool        `json:"is_private" db:"is_private"`
+	IsDefault             bool        `json:"is_default" db:"is_default"`
+	ClassificationEnabled bool        `json:"classification_enabled" db:"classification_enabled"`
+	CreatedBy             string      `json:"created_by" db:"created_by"`
+	CreatedAt             time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time   `json:"updated_at" db:"updated_at"`
+	ArchivedAt            *time.Time  `json:"archived_at,omitempty" db:"archived_at"`
 }
 
 type ChannelType string
@@ -70,9 +73,17 @@ type UpdateMessage struct {
 }
 
 type CreateChannel struct {
-	TeamID      string      `json:"team_id" validate:"required"`
-	Name        string      `json:"name" validate:"required,min=1,max=100"`
-	Description string      `json:"description" validate:"max=500"`
-	Type        ChannelType `json:"type" validate:"required,oneof=general random custom"`
-	IsPrivate   bool        `json:"is_private"`
-}
\ No newline at end of file
+	Name                  string `json:"name" validate:"required,min=1,max=100"`
+	Description           string `json:"description" validate:"max=500"`
+	IsPrivate             bool   `json:"is_private"`
+	IsDefault             bool   `json:"is_default"`
+	ClassificationEnabled bool   `json:"classification_enabled"`
+}
+
+type UpdateChannel struct {
+	Name                  *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description           *string `json:"description,omitempty" validate:"omitempty,max=500"`
+	IsPrivate             *bool   `json:"is_private,omitempty"`
+	IsDefault             *bool   `json:"is_default,omitempty"`
+	ClassificationEnabled *bool   `json:"classification_enabled,omitempty"`
+}
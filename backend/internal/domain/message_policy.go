@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+)
+
+// MessagePolicy says who may edit or delete messages in a team's channels
+// and for how long. A nil window leaves no time limit.
+type MessagePolicy struct {
+	TeamID                string     `json:"team_id" db:"team_id"`
+	MembersCanEdit        bool       `json:"members_can_edit" db:"members_can_edit"`
+	MembersCanDelete      bool       `json:"members_can_delete" db:"members_can_delete"`
+	EditWindowMinutes     *int       `json:"edit_window_minutes" db:"edit_window_minutes"`
+	DeleteWindowMinutes   *int       `json:"delete_window_minutes" db:"delete_window_minutes"`
+	AdminsCanDeleteOthers bool       `json:"admins_can_delete_others" db:"admins_can_delete_others"`
+	UpdatedAt             *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// UpdateMessagePolicy changes the fields that are set; a window of 0 removes
+// the time limit.
+type UpdateMessagePolicy struct {
+	MembersCanEdit        *bool `json:"members_can_edit,omitempty"`
+	MembersCanDelete      *bool `json:"members_can_delete,omitempty"`
+	EditWindowMinutes     *int  `json:"edit_window_minutes,omitempty" validate:"omitempty,min=0"`
+	DeleteWindowMinutes   *int  `json:"delete_window_minutes,omitempty" validate:"omitempty,min=0"`
+	AdminsCanDeleteOthers *bool `json:"admins_can_delete_others,omitempty"`
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+)
+
+type Notification struct {
+	ID        string                 `json:"id" db:"id"`
+	UserID    string                 `json:"user_id" db:"user_id"`
+	TeamID    string                 `json:"team_id,omitempty" db:"team_id"`
+	Type      string                 `json:"type" db:"type"`
+	Title     string                 `json:"title" db:"title"`
+	Body      string                 `json:"body" db:"body"`
+	Data      map[string]interface{} `json:"data" db:"data"`
+	ReadAt    *time.Time             `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+
+	// Urgent notifications are pushed straight away even outside the
+	// user's working hours; others wait in HeldUntil until the day starts.
+	Urgent    bool       `json:"urgent,omitempty"`
+	HeldUntil *time.Time `json:"held_until,omitempty" db:"held_until"`
+
+	// OnBehalfOf is set on copies sent to a delegate while the user it
+	// names is out of office.
+	OnBehalfOf *string `json:"on_behalf_of,omitempty" db:"on_behalf_of"`
+
+	// Push is filled in when the notification is pushed, for mobile
+	// clients to show it.
+	Push *PushMeta `json:"push,omitempty"`
+}
+
+// PushMeta tells mobile clients how to show a pushed notification.
+// Notifications with the same CollapseKey replace each other, Badge is the
+// user's unread notification count including this one, and DeepLink opens
+// the screen the notification is about.
+type PushMeta struct {
+	CollapseKey string       `json:"collapse_key"`
+	Priority    PushPriority `json:"priority"`
+	Badge       int          `json:"badge"`
+	DeepLink    string       `json:"deep_link"`
+}
+
+// PushPriority is the priority in each platform's terms: apns-priority 10
+// or 5, and FCM high or normal.
+type PushPriority struct {
+	APNs int    `json:"apns"`
+	FCM  string `json:"fcm"`
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"time"
+)
+
+type OnCallSchedule struct {
+	ID            string           `json:"id" db:"id"`
+	TeamID        string           `json:"team_id" db:"team_id"`
+	Name          string           `json:"name" db:"name"`
+	RotationHours int              `json:"rotation_hours" db:"rotation_hours"`
+	HandoffAt     time.Time        `json:"handoff_at" db:"handoff_at"`
+	Members       []string         `json:"members"`
+	Overrides     []OnCallOverride `json:"overrides"`
+	CreatedBy     string           `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+type OnCallOverride struct {
+	ID         string    `json:"id" db:"id"`
+	ScheduleID string    `json:"schedule_id" db:"schedule_id"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	StartsAt   time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time `json:"ends_at" db:"ends_at"`
+	CreatedBy  string    `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// OnCallShift is a continuous period one user is on call for a schedule.
+type OnCallShift struct {
+	UserID     string    `json:"user_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	IsOverride bool      `json:"is_override"`
+}
+
+// CurrentOnCall is who is on call for a schedule right now and until when.
+type CurrentOnCall struct {
+	ScheduleID   string    `json:"schedule_id"`
+	ScheduleName string    `json:"schedule_name"`
+	UserID       string    `json:"user_id"`
+	IsOverride   bool      `json:"is_override"`
+	Until        time.Time `json:"until"`
+}
+
+type CreateOnCallSchedule struct {
+	Name          string    `json:"name" validate:"required,min=1,max=100"`
+	RotationHours int       `json:"rotation_hours" validate:"omitempty,min=1"`
+	HandoffAt     time.Time `json:"handoff_at"`
+	Members       []string  `json:"members" validate:"required,min=1"`
+}
+
+type UpdateOnCallSchedule struct {
+	Name          *string    `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	RotationHours *int       `json:"rotation_hours,omitempty" validate:"omitempty,min=1"`
+	HandoffAt     *time.Time `json:"handoff_at,omitempty"`
+	Members       *[]string  `json:"members,omitempty" validate:"omitempty,min=1"`
+}
+
+type CreateOnCallOverride struct {
+	UserID   string    `json:"user_id" validate:"required"`
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required"`
+}
+
+const (
+	EscalationTargetUser     = "user"
+	EscalationTargetSchedule = "schedule"
+)
+
+type EscalationTarget struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// EscalationStep notifies its targets, then waits DelayMinutes for an
+// acknowledgement before escalating to the next step.
+type EscalationStep struct {
+	DelayMinutes int                `json:"delay_minutes"`
+	Targets      []EscalationTarget `json:"targets"`
+}
+
+type EscalationPolicy struct {
+	ID                string           `json:"id" db:"id"`
+	TeamID            string           `json:"team_id" db:"team_id"`
+	Name              string           `json:"name" db:"name"`
+	Steps             []EscalationStep `json:"steps" db:"steps"`
+	RepeatCount       int              `json:"repeat_count" db:"repeat_count"`
+	NotifyUrgentTasks bool             `json:"notify_urgent_tasks" db:"notify_urgent_tasks"`
+	CreatedBy         string           `json:"created_by" db:"created_by"`
+	CreatedAt         time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+type CreateEscalationPolicy struct {
+	Name              string           `json:"name" validate:"required,min=1,max=100"`
+	Steps             []EscalationStep `json:"steps" validate:"required,min=1"`
+	RepeatCount       int              `json:"repeat_count" validate:"min=0"`
+	NotifyUrgentTasks bool             `json:"notify_urgent_tasks"`
+}
+
+type UpdateEscalationPolicy struct {
+	Name              *string           `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Steps             *[]EscalationStep `json:"steps,omitempty" validate:"omitempty,min=1"`
+	RepeatCount       *int              `json:"repeat_count,omitempty" validate:"omitempty,min=0"`
+	NotifyUrgentTasks *bool             `json:"notify_urgent_tasks,omitempty"`
+}
+
+type EscalationStatus string
+
+const (
+	EscalationTriggered    EscalationStatus = "triggered"
+	EscalationAcknowledged EscalationStatus = "acknowledged"
+	EscalationResolved     EscalationStatus = "resolved"
+)
+
+type Escalation struct {
+	ID             string           `json:"id" db:"id"`
+	TeamID         string           `json:"team_id" db:"team_id"`
+	PolicyID       string           `json:"policy_id" db:"policy_id"`
+	SourceType     string           `json:"source_type" db:"source_type"`
+	SourceID       string           `json:"source_id,omitempty" db:"source_id"`
+	Summary        string           `json:"summary" db:"summary"`
+	Status         EscalationStatus `json:"status" db:"status"`
+	CurrentStep    int              `json:"current_step" db:"current_step"`
+	RepeatsDone    int              `json:"repeats_done" db:"repeats_done"`
+	NextStepAt     *time.Time       `json:"next_step_at,omitempty" db:"next_step_at"`
+	AcknowledgedBy *string          `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+	AcknowledgedAt *time.Time       `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	ResolvedAt     *time.Time       `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+type TriggerEscalation struct {
+	Summary string `json:"summary" validate:"required,min=1"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+)
+
+// OutOfOffice is a period a user is away. While it's on, DMs and mentions
+// get an automatic reply and task assignments point at the delegate.
+type OutOfOffice struct {
+	UserID     string     `json:"user_id" db:"user_id"`
+	StartsAt   time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time  `json:"ends_at" db:"ends_at"`
+	Message    string     `json:"message" db:"message"`
+	DelegateID *string    `json:"delegate_id,omitempty" db:"delegate_id"`
+	Active     bool       `json:"active"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+type UpdateOutOfOffice struct {
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     time.Time  `json:"ends_at" validate:"required"`
+	Message    string     `json:"message" validate:"max=1000"`
+	DelegateID *string    `json:"delegate_id,omitempty"`
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"time"
+)
+
+type Organization struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Slug      string    `json:"slug" db:"slug"`
+	OwnerID   string    `json:"owner_id" db:"owner_id"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"
+	OrgRoleAdmin  OrgRole = "admin"
+	OrgRoleMember OrgRole = "member"
+)
+
+type CreateOrganization struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+	Slug string `json:"slug" validate:"required,min=2,max=50"`
+}
+
+type AddOrganizationMember struct {
+	Email    string  `json:"email,omitempty" validate:"omitempty,email"`
+	Username string  `json:"username,omitempty"`
+	Role     OrgRole `json:"role" validate:"omitempty,oneof=admin member"`
+}
+
+// ProfileVisibility controls which contact details non-admins see in the
+// organization directory.
+type ProfileVisibility struct {
+	EmailVisible bool `json:"email_visible" db:"email_visible"`
+	PhoneVisible bool `json:"phone_visible" db:"phone_visible"`
+}
+
+type UpdateProfileVisibility struct {
+	EmailVisible *bool `json:"email_visible,omitempty"`
+	PhoneVisible *bool `json:"phone_visible,omitempty"`
+}
+
+// DirectoryProfile is a directory row as stored. Use ForViewer to serialize
+// it; never encode it directly.
+type DirectoryProfile struct {
+	UserID     string
+	Username   string
+	FirstName  string
+	LastName   string
+	Avatar     *string
+	Email      string
+	Phone      *string
+	Role       string
+	JoinedAt   time.Time
+	LastSeen   time.Time
+	Visibility ProfileVisibility
+}
+
+type DirectoryEntry struct {
+	UserID     string             `json:"user_id"`
+	Username   string             `json:"username"`
+	FirstName  string             `json:"first_name"`
+	LastName   string             `json:"last_name"`
+	Avatar     *string            `json:"avatar,omitempty"`
+	Email      *string            `json:"email,omitempty"`
+	Phone      *string            `json:"phone,omitempty"`
+	Role       string             `json:"role"`
+	JoinedAt   time.Time          `json:"joined_at"`
+	LastSeen   time.Time          `json:"last_seen"`
+	Visibility *ProfileVisibility `json:"visibility,omitempty"`
+}
+
+// ForViewer serializes the profile for the given caller. Org admins and the
+// user themselves see every field, including the visibility settings; other
+// members only see contact details the user has chosen to share.
+func (p DirectoryProfile) ForViewer(viewerID string, viewerIsAdmin bool) DirectoryEntry {
+	entry := DirectoryEntry{
+		UserID:    p.UserID,
+		Username:  p.Username,
+		FirstName: p.FirstName,
+		LastName:  p.LastName,
+		Avatar:    p.Avatar,
+		Role:      p.Role,
+		JoinedAt:  p.JoinedAt,
+		LastSeen:  p.LastSeen,
+	}
+
+	privileged := viewerIsAdmin || viewerID == p.UserID
+
+	if privileged || p.Visibility.EmailVisible {
+		email := p.Email
+		entry.Email = &email
+	}
+	if privileged || p.Visibility.PhoneVisible {
+		entry.Phone = p.Phone
+	}
+	if privileged {
+		visibility := p.Visibility
+		entry.Visibility = &visibility
+	}
+
+	return entry
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+)
+
+// TaskReport posts a digest of the team's open, overdue and recently
+// completed tasks into a channel on a weekly schedule.
+type TaskReport struct {
+	ID        string     `json:"id" db:"id"`
+	TeamID    string     `json:"team_id" db:"team_id"`
+	ChannelID string     `json:"channel_id" db:"channel_id"`
+	Name      string     `json:"name" db:"name"`
+	Weekdays  []string   `json:"weekdays" db:"weekdays"`
+	TimeOfDay string     `json:"time_of_day" db:"time_of_day"`
+	Timezone  string     `json:"timezone" db:"timezone"`
+	IsEnabled bool       `json:"is_enabled" db:"is_enabled"`
+	NextRunAt time.Time  `json:"next_run_at" db:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedBy string     `json:"created_by" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateTaskReport struct {
+	ChannelID string   `json:"channel_id" validate:"required"`
+	Name      string   `json:"name" validate:"required,max=100"`
+	Weekdays  []string `json:"weekdays" validate:"required"`
+	TimeOfDay string   `json:"time_of_day" validate:"required"`
+	Timezone  string   `json:"timezone"`
+}
+
+type UpdateTaskReport struct {
+	ChannelID *string  `json:"channel_id,omitempty"`
+	Name      *string  `json:"name,omitempty" validate:"omitempty,max=100"`
+	Weekdays  []string `json:"weekdays,omitempty"`
+	TimeOfDay *string  `json:"time_of_day,omitempty"`
+	Timezone  *string  `json:"timezone,omitempty"`
+	IsEnabled *bool    `json:"is_enabled,omitempty"`
+}
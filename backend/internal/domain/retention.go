@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+)
+
+// RetentionPolicy says how long a team keeps messages and files. A nil
+// period keeps them forever.
+type RetentionPolicy struct {
+	TeamID               string     `json:"team_id" db:"team_id"`
+	MessageRetentionDays *int       `json:"message_retention_days" db:"message_retention_days"`
+	FileRetentionDays    *int       `json:"file_retention_days" db:"file_retention_days"`
+	UpdatedAt            *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// UpdateRetentionPolicy changes the periods that are set; 0 means keep
+// forever.
+type UpdateRetentionPolicy struct {
+	MessageRetentionDays *int `json:"message_retention_days,omitempty" validate:"omitempty,min=0"`
+	FileRetentionDays    *int `json:"file_retention_days,omitempty" validate:"omitempty,min=0"`
+}
+
+type ChannelRetentionImpact struct {
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	Messages    int    `json:"messages"`
+	Files       int    `json:"files"`
+	FileBytes   int64  `json:"file_bytes"`
+}
+
+// RetentionPreview is what a policy would purge if it ran now.
+type RetentionPreview struct {
+	Policy        RetentionPolicy          `json:"policy"`
+	Hypothetical  bool                     `json:"hypothetical"`
+	MessageCutoff *time.Time               `json:"message_cutoff"`
+	FileCutoff    *time.Time               `json:"file_cutoff"`
+	Channels      []ChannelRetentionImpact `json:"channels"`
+	TotalMessages int                      `json:"total_messages"`
+	TotalFiles    int                      `json:"total_files"`
+	TotalBytes    int64                    `json:"total_file_bytes"`
+	GeneratedAt   time.Time                `json:"generated_at"`
+}
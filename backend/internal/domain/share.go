@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+)
+
+type ShareLinkKind string
+
+const (
+	ShareLinkBoard   ShareLinkKind = "board"
+	ShareLinkChannel ShareLinkKind = "channel"
+)
+
+type ShareLink struct {
+	ID        string        `json:"id" db:"id"`
+	TeamID    string        `json:"team_id" db:"team_id"`
+	Kind      ShareLinkKind `json:"kind" db:"kind"`
+	ChannelID *string       `json:"channel_id,omitempty" db:"channel_id"`
+	Token     string        `json:"token,omitempty"`
+	CreatedBy string        `json:"created_by" db:"created_by"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time    `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type CreateShareLink struct {
+	Kind      ShareLinkKind `json:"kind" validate:"required"`
+	ChannelID *string       `json:"channel_id,omitempty"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+)
+
+type Snippet struct {
+	ID         string     `json:"id" db:"id"`
+	TeamID     string     `json:"team_id" db:"team_id"`
+	Shortcut   string     `json:"shortcut" db:"shortcut"`
+	Title      string     `json:"title" db:"title"`
+	Content    string     `json:"content" db:"content"`
+	UsageCount int        `json:"usage_count" db:"usage_count"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedBy  string     `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateSnippet struct {
+	Shortcut string `json:"shortcut" validate:"required,min=1,max=50"`
+	Title    string `json:"title" validate:"max=100"`
+	Content  string `json:"content" validate:"required,min=1,max=4000"`
+}
+
+type UpdateSnippet struct {
+	Shortcut *string `json:"shortcut,omitempty" validate:"omitempty,min=1,max=50"`
+	Title    *string `json:"title,omitempty" validate:"omitempty,max=100"`
+	Content  *string `json:"content,omitempty" validate:"omitempty,min=1,max=4000"`
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+)
+
+type InboundWebhook struct {
+	ID             string     `json:"id" db:"id"`
+	TeamID         string     `json:"team_id" db:"team_id"`
+	Name           string     `json:"name" db:"name"`
+	Kind           string     `json:"kind" db:"kind"`
+	Token          string     `json:"token,omitempty"`
+	CreatedBy      string     `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	LastReceivedAt *time.Time `json:"last_received_at,omitempty" db:"last_received_at"`
+}
+
+type CreateInboundWebhook struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+	Kind string `json:"kind" validate:"required"`
+}
+
+type ComponentStatus string
+
+const (
+	ComponentOperational         ComponentStatus = "operational"
+	ComponentDegradedPerformance ComponentStatus = "degraded_performance"
+	ComponentPartialOutage       ComponentStatus = "partial_outage"
+	ComponentMajorOutage         ComponentStatus = "major_outage"
+	ComponentUnderMaintenance    ComponentStatus = "under_maintenance"
+)
+
+type StatusComponent struct {
+	ID          string          `json:"id" db:"id"`
+	TeamID      string          `json:"team_id" db:"team_id"`
+	Name        string          `json:"name" db:"name"`
+	Description string          `json:"description" db:"description"`
+	Status      ComponentStatus `json:"status" db:"status"`
+	ExternalID  *string         `json:"external_id,omitempty" db:"external_id"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+type CreateStatusComponent struct {
+	Name        string           `json:"name" validate:"required,min=1,max=100"`
+	Description string           `json:"description"`
+	Status      *ComponentStatus `json:"status,omitempty"`
+	ExternalID  *string          `json:"external_id,omitempty"`
+}
+
+type UpdateStatusComponent struct {
+	Name        *string          `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string          `json:"description,omitempty"`
+	Status      *ComponentStatus `json:"status,omitempty"`
+	Message     string           `json:"message,omitempty"`
+}
+
+type StatusSubscription struct {
+	ID            string    `json:"id" db:"id"`
+	TeamID        string    `json:"team_id" db:"team_id"`
+	ChannelID     string    `json:"channel_id" db:"channel_id"`
+	CardMessageID *string   `json:"card_message_id,omitempty" db:"card_message_id"`
+	CreatedBy     string    `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateStatusSubscription struct {
+	ChannelID string `json:"channel_id" validate:"required"`
+}
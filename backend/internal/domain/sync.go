@@ -0,0 +1,39 @@
+package domain
+
+// OutboxOutcome is what happened to one operation a client queued while
+// offline. Result is the message or task as it now stands, when there is
+// one; Conflicts lists the task fields the edit set that had also changed
+// on the server since the client's base version.
+type OutboxOutcome struct {
+	ClientID  string       `json:"client_id"`
+	Type      string       `json:"type"`
+	Status    OutboxStatus `json:"status"`
+	Error     string       `json:"error,omitempty"`
+	Conflicts []string     `json:"conflicts,omitempty"`
+	Result    interface{}  `json:"result,omitempty"`
+}
+
+type OutboxStatus string
+
+const (
+	// OutboxApplied: the operation was carried out, possibly in part (see
+	// Conflicts), or there was nothing left to change.
+	OutboxApplied OutboxStatus = "applied"
+	// OutboxDuplicate: the message was already posted by an earlier sync.
+	OutboxDuplicate OutboxStatus = "duplicate"
+	// OutboxConflict: the task changed since the client's base version and
+	// the conflict policy is reject, so nothing was changed.
+	OutboxConflict OutboxStatus = "conflict"
+	// OutboxRejected: the operation is invalid or no longer allowed.
+	OutboxRejected OutboxStatus = "rejected"
+	// OutboxFailed: the server couldn't apply it; the client may retry.
+	OutboxFailed OutboxStatus = "failed"
+)
+
+// How a queued task edit is applied when the same fields were changed
+// after the client's base version.
+const (
+	ConflictServerWins = "server_wins"
+	ConflictClientWins = "client_wins"
+	ConflictReject     = "reject"
+)
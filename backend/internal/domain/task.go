@@ -45,10 +45,23 @@ type TaskComment struct {
 	TaskID    string    `json:"task_id" db:"task_id"`
 	UserID    string    `json:"user_id" db:"user_id"`
 	Content   string    `json:"content" db:"content"`
+	MessageID *string   `json:"message_id,omitempty" db:"message_id"`
+	Username  string    `json:"username,omitempty"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// TaskThread is the channel thread a task's discussion is mirrored to.
+type TaskThread struct {
+	TaskID    string `json:"task_id"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+}
+
+type LinkTaskThread struct {
+	ChannelID string `json:"channel_id" validate:"required"`
+}
+
 type TaskActivity struct {
 	ID          string       `json:"id" db:"id"`
 	TaskID      string       `json:"task_id" db:"task_id"`
@@ -59,6 +72,35 @@ type TaskActivity struct {
 	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
 }
 
+// TaskEvent is one entry in a task's history. Changes maps each field the
+// event touched, such as "status" or "due_date", to its old and new value.
+type TaskEvent struct {
+	ID             string                     `json:"id" db:"id"`
+	TaskID         string                     `json:"task_id" db:"task_id"`
+	Type           TaskEventType              `json:"type" db:"type"`
+	ActorID        string                     `json:"actor_id" db:"actor_id"`
+	Username       string                     `json:"username,omitempty"`
+	Changes        map[string]TaskFieldChange `json:"changes" db:"changes"`
+	RevertsEventID *string                    `json:"reverts_event_id,omitempty" db:"reverts_event_id"`
+	RevertedBy     *string                    `json:"reverted_by,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at" db:"created_at"`
+}
+
+type TaskEventType string
+
+const (
+	TaskEventCreated  TaskEventType = "created"
+	TaskEventUpdated  TaskEventType = "updated"
+	TaskEventReverted TaskEventType = "reverted"
+)
+
+// TaskFieldChange holds a field's value before and after an event; null
+// means the field was unset, and due dates are RFC 3339 strings.
+type TaskFieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
 type CreateTask struct {
 	TeamID      string     `json:"team_id" validate:"required"`
 	Title       string     `json:"title" validate:"required,min=1,max=200"`
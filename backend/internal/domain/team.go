@@ -46,4 +46,18 @@ type UpdateTeam struct {
 type InviteTeamMember struct {
 	Email string   `json:"email" validate:"required,email"`
 	Role  TeamRole `json:"role" validate:"required,oneof=admin member"`
-}
\ No newline at end of file
+}
+
+type TeamWelcomeSettings struct {
+	TeamID    string    `json:"team_id" db:"team_id"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	Template  string    `json:"template" db:"template"`
+	SenderID  *string   `json:"sender_id,omitempty" db:"sender_id"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type UpdateTeamWelcomeSettings struct {
+	Enabled  *bool   `json:"enabled,omitempty"`
+	Template *string `json:"template,omitempty" validate:"omitempty,max=4000"`
+	SenderID *string `json:"sender_id,omitempty"`
+}
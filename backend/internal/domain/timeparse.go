@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+)
+
+type ParseTimeRequest struct {
+	Text     string `json:"text" validate:"required"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// ParsedTime is the preview shown to a user before they confirm a natural
+// language time like "tomorrow 9am".
+type ParsedTime struct {
+	Text       string    `json:"text"`
+	ResolvedAt time.Time `json:"resolved_at"`
+	LocalTime  string    `json:"local_time"`
+	Display    string    `json:"display"`
+	Timezone   string    `json:"timezone"`
+	InPast     bool      `json:"in_past"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+)
+
+// UndoableAction is a destructive action its actor can take back until
+// ExpiresAt. Snapshot holds what the action threw away, as JSON.
+type UndoableAction struct {
+	ID         string     `json:"id" db:"id"`
+	Kind       UndoKind   `json:"kind" db:"kind"`
+	TeamID     string     `json:"team_id" db:"team_id"`
+	ActorID    string     `json:"actor_id" db:"actor_id"`
+	ResourceID string     `json:"resource_id" db:"resource_id"`
+	Snapshot   []byte     `json:"-" db:"snapshot"`
+	URL        string     `json:"url"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	UndoneAt   *time.Time `json:"undone_at,omitempty" db:"undone_at"`
+}
+
+type UndoKind string
+
+const (
+	UndoMessageDelete  UndoKind = "message.delete"
+	UndoTaskDelete     UndoKind = "task.delete"
+	UndoMemberRemove   UndoKind = "member.remove"
+	UndoChannelArchive UndoKind = "channel.archive"
+)
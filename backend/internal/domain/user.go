@@ -14,6 +14,7 @@ type User struct {
 	FirstName    string    `json:"first_name" db:"first_name"`
 	LastName     string    `json:"last_name" db:"last_name"`
 	Avatar       string    `json:"avatar" db:"avatar"`
+	Phone        string    `json:"phone,omitempty" db:"phone"`
 	IsActive     bool      `json:"is_active" db:"is_active"`
 	IsVerified   bool      `json:"is_verified" db:"is_verified"`
 	LastSeen     time.Time `json:"last_seen" db:"last_seen"`
@@ -52,4 +53,5 @@ type UserUpdate struct {
 	FirstName string `json:"first_name,omitempty" validate:"omitempty,min=1,max=50"`
 	LastName  string `json:"last_name,omitempty" validate:"omitempty,min=1,max=50"`
 	Avatar    string `json:"avatar,omitempty" validate:"omitempty,url"`
+	Phone     string `json:"phone,omitempty" validate:"omitempty,max=30"`
 }
\ No newline at end of file
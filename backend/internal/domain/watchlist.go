@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+)
+
+// WatchList flags messages in a team that contain any of its keywords
+// (whole words) or match any of its patterns (regular expressions).
+// Matching ignores case unless CaseSensitive is set. Matches are posted to
+// NotifyChannelID when set, and always audited and published as
+// message.flagged for automation rules.
+type WatchList struct {
+	ID              string    `json:"id" db:"id"`
+	TeamID          string    `json:"team_id" db:"team_id"`
+	Name            string    `json:"name" db:"name"`
+	Keywords        []string  `json:"keywords" db:"keywords"`
+	Patterns        []string  `json:"patterns" db:"patterns"`
+	CaseSensitive   bool      `json:"case_sensitive" db:"case_sensitive"`
+	NotifyChannelID *string   `json:"notify_channel_id,omitempty" db:"notify_channel_id"`
+	IsEnabled       bool      `json:"is_enabled" db:"is_enabled"`
+	CreatedBy       string    `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateWatchList struct {
+	Name            string   `json:"name" validate:"required,min=1,max=100"`
+	Keywords        []string `json:"keywords"`
+	Patterns        []string `json:"patterns"`
+	CaseSensitive   bool     `json:"case_sensitive"`
+	NotifyChannelID *string  `json:"notify_channel_id,omitempty"`
+}
+
+// UpdateWatchList changes the fields that are set. An empty
+// NotifyChannelID stops posting matches to a channel.
+type UpdateWatchList struct {
+	Name            *string   `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Keywords        *[]string `json:"keywords,omitempty"`
+	Patterns        *[]string `json:"patterns,omitempty"`
+	CaseSensitive   *bool     `json:"case_sensitive,omitempty"`
+	NotifyChannelID *string   `json:"notify_channel_id,omitempty"`
+	IsEnabled       *bool     `json:"is_enabled,omitempty"`
+}
+
+// WatchListHit is one keyword or pattern that matched, with the text it
+// matched.
+type WatchListHit struct {
+	Term  string `json:"term"`
+	Regex bool   `json:"regex,omitempty"`
+	Text  string `json:"text"`
+}
+
+// WatchListMatch is the audit record of a message a watch list flagged.
+type WatchListMatch struct {
+	ID            string         `json:"id" db:"id"`
+	WatchListID   *string        `json:"watch_list_id,omitempty" db:"watch_list_id"`
+	WatchListName string         `json:"watch_list_name" db:"watch_list_name"`
+	TeamID        string         `json:"team_id" db:"team_id"`
+	MessageID     *string        `json:"message_id,omitempty" db:"message_id"`
+	ChannelID     *string        `json:"channel_id,omitempty" db:"channel_id"`
+	UserID        *string        `json:"user_id,omitempty" db:"user_id"`
+	Hits          []WatchListHit `json:"hits" db:"hits"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/metrics"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+var (
+	eventsPublished = metrics.Default.NewCounterVec("cbalite_events_published_total",
+		"Events published on the bus, by type.", "type")
+	handlerDuration = metrics.Default.NewHistogramVec("cbalite_event_handler_duration_seconds",
+		"Time from an event being published to a subscriber finishing with it, by type.", metrics.LatencyBuckets, "type")
+	handlerPanics = metrics.Default.NewCounterVec("cbalite_event_handler_panics_total",
+		"Subscribers that panicked, by event type.", "type")
+)
+
+type Type string
+
+const (
+	MessagePosted Type = "message.posted"
+	TaskCreated   Type = "task.created"
+	TaskUpdated   Type = "task.updated"
+	TaskCommented Type = "task.commented"
+	MemberJoined  Type = "member.joined"
+
+	MessageClassified Type = "message.classified"
+	MessageFlagged    Type = "message.flagged"
+)
+
+type Event struct {
+	ID         string      `json:"id"`
+	Type       Type        `json:"type"`
+	TeamID     string      `json:"team_id"`
+	ActorID    string      `json:"actor_id,omitempty"`
+	Data       interface{} `json:"data"`
+	OccurredAt time.Time   `json:"occurred_at"`
+
+	// CorrelationID ties the event to the request or job that caused it.
+	// Publish fills it in from the context.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+type Handler func(ctx context.Context, event Event)
+
+// Bus is an in-process publish/subscribe bus. Handlers run asynchronously so
+// publishers (usually HTTP handlers) never wait on subscribers.
+type Bus struct {
+	handlers map[Type][]Handler
+	logger   *logger.Logger
+	mu       sync.RWMutex
+}
+
+func NewBus(logger *logger.Logger) *Bus {
+	return &Bus{
+		handlers: make(map[Type][]Handler),
+		logger:   logger,
+	}
+}
+
+func (b *Bus) Subscribe(eventType Type, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	if event.CorrelationID == "" {
+		event.CorrelationID = correlation.FromContext(ctx)
+	}
+	if event.CorrelationID == "" {
+		event.CorrelationID = correlation.New()
+	}
+
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers[event.Type]...)
+	b.mu.RUnlock()
+
+	// Subscribers outlive the request that published the event, so keep the
+	// context values but drop its cancellation. Whatever they publish or
+	// queue in turn keeps the event's correlation ID.
+	ctx = correlation.NewContext(context.WithoutCancel(ctx), event.CorrelationID)
+	eventsPublished.With(string(event.Type)).Inc()
+
+	published := time.Now()
+	for _, handler := range handlers {
+		go b.dispatch(ctx, handler, event, published)
+	}
+}
+
+func (b *Bus) dispatch(ctx context.Context, handler Handler, event Event, published time.Time) {
+	defer func() {
+		handlerDuration.With(string(event.Type)).Observe(time.Since(published).Seconds())
+		if err := recover(); err != nil {
+			handlerPanics.With(string(event.Type)).Inc()
+			b.logger.WithFields(map[string]interface{}{
+				"error":      err,
+				"event":      event.Type,
+				"stack":      string(debug.Stack()),
+				"request_id": event.CorrelationID,
+			}).Error("Event handler panicked")
+		}
+	}()
+
+	handler(ctx, event)
+}
@@ -0,0 +1,65 @@
+// Package fakes helps tests check which events were published.
+package fakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cbalite/backend/internal/events"
+)
+
+// Recorder collects the events published on a bus. Bus handlers run on
+// their own goroutines, so tests should use Wait rather than reading Events
+// straight after the code under test returns.
+type Recorder struct {
+	mu      sync.Mutex
+	events  []events.Event
+	changed chan struct{}
+}
+
+// NewRecorder subscribes to the given event types on bus.
+func NewRecorder(bus *events.Bus, types ...events.Type) *Recorder {
+	r := &Recorder{changed: make(chan struct{})}
+	for _, eventType := range types {
+		bus.Subscribe(eventType, r.handle)
+	}
+	return r
+}
+
+func (r *Recorder) handle(ctx context.Context, event events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Events returns what has been recorded so far, in arrival order.
+func (r *Recorder) Events() []events.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]events.Event(nil), r.events...)
+}
+
+// Wait returns once n events have been recorded, or with what there is
+// after timeout.
+func (r *Recorder) Wait(n int, timeout time.Duration) []events.Event {
+	deadline := time.After(timeout)
+	for {
+		r.mu.Lock()
+		if len(r.events) >= n {
+			recorded := append([]events.Event(nil), r.events...)
+			r.mu.Unlock()
+			return recorded
+		}
+		changed := r.changed
+		r.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return r.Events()
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+// Payloads maps each event type to the struct describing its Data, which is
+// also the body of outgoing webhooks. Publishers build maps of the same
+// shape, since subscribers read them as maps; change both together.
+var Payloads = map[Type]interface{}{
+	MessagePosted:     MessagePayload{},
+	MessageClassified: ClassifiedMessagePayload{},
+	MessageFlagged:    FlaggedMessagePayload{},
+	TaskCreated:       TaskPayload{},
+	TaskUpdated:       TaskUpdatedPayload{},
+	TaskCommented:     domain.TaskComment{},
+	MemberJoined:      MemberJoinedPayload{},
+}
+
+// UserSummary names the user who sent a message or joined a team.
+type UserSummary struct {
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type MessagePayload struct {
+	ID            string      `json:"id" format:"uuid"`
+	TeamID        string      `json:"team_id" format:"uuid"`
+	ChannelID     string      `json:"channel_id" format:"uuid"`
+	Content       string      `json:"content"`
+	Type          string      `json:"type" validate:"oneof=text image file system"`
+	SenderID      string      `json:"sender_id" format:"uuid"`
+	Sender        UserSummary `json:"sender"`
+	ReplyToID     string      `json:"reply_to_id,omitempty" format:"uuid" doc:"The thread's root message, for replies"`
+	TaskCommentID string      `json:"task_comment_id,omitempty" format:"uuid" doc:"The task comment this message mirrors"`
+	OriginTeamID  string      `json:"origin_team_id,omitempty" format:"uuid" doc:"The guest team of the sender, in shared channels"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// MessageLabels are what the classifier made of a message.
+type MessageLabels struct {
+	Urgency   string `json:"urgency"`
+	Sentiment string `json:"sentiment"`
+}
+
+type ClassifiedMessagePayload struct {
+	MessagePayload
+	Labels MessageLabels `json:"labels"`
+}
+
+// WatchListRef names the watch list that flagged a message.
+type WatchListRef struct {
+	ID   string `json:"id" format:"uuid"`
+	Name string `json:"name"`
+}
+
+type FlaggedMessagePayload struct {
+	MessagePayload
+	WatchList WatchListRef          `json:"watch_list"`
+	Hits      []domain.WatchListHit `json:"hits"`
+	// Terms joins the matched keywords and patterns, for rule conditions.
+	Terms string `json:"terms"`
+}
+
+type TaskPayload struct {
+	ID          string     `json:"id" format:"uuid"`
+	TeamID      string     `json:"team_id" format:"uuid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status" validate:"oneof=todo in_progress review done cancelled"`
+	Priority    string     `json:"priority" validate:"oneof=low medium high urgent"`
+	AssigneeID  string     `json:"assignee_id,omitempty" format:"uuid"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	CreatedBy   string     `json:"created_by" format:"uuid"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TaskUpdatedPayload is the task after the change, with what its status
+// and assignee were before.
+type TaskUpdatedPayload struct {
+	TaskPayload
+	PreviousStatus     string `json:"previous_status" validate:"oneof=todo in_progress review done cancelled"`
+	PreviousAssigneeID string `json:"previous_assignee_id,omitempty" format:"uuid"`
+}
+
+type MemberJoinedPayload struct {
+	TeamID   string      `json:"team_id" format:"uuid"`
+	UserID   string      `json:"user_id" format:"uuid"`
+	Role     string      `json:"role" validate:"oneof=owner admin member"`
+	JoinedAt time.Time   `json:"joined_at"`
+	User     UserSummary `json:"user"`
+}
@@ -0,0 +1,255 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+const (
+	tickInterval = time.Minute
+	batchSize    = 500
+
+	// A running export that hasn't reported progress for this long belongs
+	// to a server that went away, and is picked up again.
+	staleAfter = 10 * time.Minute
+)
+
+// Exporter generates channel exports in the background. Requests are queued
+// in channel_exports; Wake starts on them straight away, and a periodic
+// sweep catches anything left behind by a restart.
+type Exporter struct {
+	db     *database.PostgresDB
+	logger *logger.Logger
+	wake   chan struct{}
+}
+
+func NewExporter(db *database.PostgresDB, logger *logger.Logger) *Exporter {
+	return &Exporter{
+		db:     db,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+func (e *Exporter) Start() {
+	go e.run()
+}
+
+// Wake tells the exporter a new export is waiting.
+func (e *Exporter) Wake() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Exporter) run() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		e.drain(context.Background())
+
+		select {
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+// drain runs queued exports one at a time until there are none left.
+func (e *Exporter) drain(ctx context.Context) {
+	for {
+		job, correlationID, err := e.claim(ctx)
+		if err == sql.ErrNoRows {
+			return
+		}
+		if err != nil {
+			e.logger.WithError(err).Error("Failed to claim channel export")
+			return
+		}
+
+		// Log lines trace back to the request that asked for the export
+		jobCtx := correlation.NewContext(ctx, correlationID)
+		if err := e.generate(jobCtx, job); err != nil {
+			log := correlation.Log(jobCtx, e.logger)
+			log.WithError(err).Errorf("Failed to generate channel export %s", job.ID)
+			_, err = e.db.ExecContext(ctx, `
+				UPDATE channel_exports SET status = 'failed', error = $2 WHERE id = $1
+			`, job.ID, err.Error())
+			if err != nil {
+				log.WithError(err).Errorf("Failed to mark channel export %s failed", job.ID)
+			}
+		}
+	}
+}
+
+// claim takes the oldest waiting export, with the correlation ID of the
+// request that queued it.
+func (e *Exporter) claim(ctx context.Context) (domain.ChannelExport, string, error) {
+	var job domain.ChannelExport
+	var correlationID string
+	err := e.db.QueryRowContext(ctx, `
+		UPDATE channel_exports SET status = 'running', progress = 0
+		WHERE id = (
+			SELECT id FROM channel_exports
+			WHERE status = 'pending'
+			   OR (status = 'running' AND updated_at < NOW() - $1 * INTERVAL '1 second')
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, team_id, channel_id, format, range_start, range_end, COALESCE(correlation_id, '')
+	`, int(staleAfter.Seconds())).Scan(&job.ID, &job.TeamID, &job.ChannelID, &job.Format, &job.From, &job.To, &correlationID)
+	return job, correlationID, err
+}
+
+func (e *Exporter) generate(ctx context.Context, job domain.ChannelExport) error {
+	transcript := &Transcript{From: job.From, To: job.To}
+	err := e.db.QueryRowContext(ctx, `
+		SELECT t.name, c.name FROM channels c JOIN teams t ON t.id = c.team_id WHERE c.id = $1
+	`, job.ChannelID).Scan(&transcript.Team, &transcript.Channel)
+	if err != nil {
+		return fmt.Errorf("failed to load channel: %w", err)
+	}
+
+	var total int
+	err = e.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM messages
+		WHERE channel_id = $1 AND created_at >= $2 AND created_at < $3
+	`, job.ChannelID, job.From, job.To).Scan(&total)
+	if err != nil {
+		return fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	messages := make([]*Message, 0, total)
+	after := job.From
+	afterID := ""
+	for {
+		batch, err := e.loadBatch(ctx, job, after, afterID)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		messages = append(messages, batch...)
+
+		last := batch[len(batch)-1]
+		after, afterID = last.CreatedAt, last.ID
+
+		// Rendering is quick; keep 100 for when the file is ready
+		if total > 0 {
+			progress := len(messages) * 99 / total
+			if progress > 99 {
+				progress = 99
+			}
+			if _, err := e.db.ExecContext(ctx, `UPDATE channel_exports SET progress = $2 WHERE id = $1`, job.ID, progress); err != nil {
+				correlation.Log(ctx, e.logger).WithError(err).Warnf("Failed to record progress of channel export %s", job.ID)
+			}
+		}
+
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	transcript.MessageCount = len(messages)
+	transcript.Messages = Threads(messages)
+	transcript.GeneratedAt = time.Now()
+
+	var result []byte
+	if job.Format == domain.ExportFormatHTML {
+		result, err = RenderHTML(transcript)
+	} else {
+		result, err = RenderJSON(transcript)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render export: %w", err)
+	}
+
+	_, err = e.db.ExecContext(ctx, `
+		UPDATE channel_exports
+		SET status = 'completed', progress = 100, message_count = $2, result = $3, error = NULL, completed_at = NOW()
+		WHERE id = $1
+	`, job.ID, transcript.MessageCount, string(result))
+	return err
+}
+
+// loadBatch reads the next page of messages after (after, afterID), with
+// their attachments.
+func (e *Exporter) loadBatch(ctx context.Context, job domain.ChannelExport, after time.Time, afterID string) ([]*Message, error) {
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT m.id, m.content, m.type, m.is_edited, m.is_deleted, m.reply_to_id, m.created_at,
+		       u.username, u.first_name, u.last_name
+		FROM messages m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.channel_id = $1 AND m.created_at < $2
+		  AND (m.created_at, m.id::text) > ($3, $4)
+		ORDER BY m.created_at, m.id::text
+		LIMIT $5
+	`, job.ChannelID, job.To, after, afterID, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	defer rows.Close()
+
+	batch := []*Message{}
+	byID := map[string]*Message{}
+	ids := []string{}
+	for rows.Next() {
+		var message Message
+		var username, firstName, lastName string
+		err := rows.Scan(&message.ID, &message.Content, &message.Type, &message.IsEdited, &message.IsDeleted,
+			&message.ReplyToID, &message.CreatedAt, &username, &firstName, &lastName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		message.Author = AuthorName(firstName, lastName, username)
+		if message.IsDeleted {
+			message.Content = ""
+		} else {
+			ids = append(ids, message.ID)
+		}
+		batch = append(batch, &message)
+		byID[message.ID] = &message
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return batch, nil
+	}
+
+	attachmentRows, err := e.db.QueryContext(ctx, `
+		SELECT message_id, file_name, file_size, file_type, url
+		FROM attachments
+		WHERE message_id = ANY($1::uuid[])
+		ORDER BY created_at
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attachments: %w", err)
+	}
+	defer attachmentRows.Close()
+
+	for attachmentRows.Next() {
+		var messageID string
+		var attachment Attachment
+		err := attachmentRows.Scan(&messageID, &attachment.FileName, &attachment.FileSize, &attachment.FileType, &attachment.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		byID[messageID].Attachments = append(byID[messageID].Attachments, attachment)
+	}
+
+	return batch, attachmentRows.Err()
+}
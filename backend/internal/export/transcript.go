@@ -0,0 +1,126 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"time"
+)
+
+// Transcript is a channel's history over a date range, with replies nested
+// under the message they answer.
+type Transcript struct {
+	Team         string     `json:"team"`
+	Channel      string     `json:"channel"`
+	From         time.Time  `json:"from"`
+	To           time.Time  `json:"to"`
+	GeneratedAt  time.Time  `json:"generated_at"`
+	MessageCount int        `json:"message_count"`
+	Messages     []*Message `json:"messages"`
+}
+
+// Message is one transcript entry. Deleted messages stay in as tombstones
+// without their content so the threads around them still read correctly.
+type Message struct {
+	ID          string       `json:"id"`
+	Author      string       `json:"author"`
+	Content     string       `json:"content,omitempty"`
+	Type        string       `json:"type"`
+	IsEdited    bool         `json:"is_edited"`
+	IsDeleted   bool         `json:"is_deleted"`
+	ReplyToID   *string      `json:"reply_to_id,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Replies     []*Message   `json:"replies,omitempty"`
+}
+
+type Attachment struct {
+	FileName string `json:"file_name"`
+	FileSize int64  `json:"file_size"`
+	FileType string `json:"file_type"`
+	URL      string `json:"url"`
+}
+
+// AuthorName is how people are named in transcripts: full name and
+// username, or just the username when no name is set.
+func AuthorName(firstName, lastName, username string) string {
+	name := firstName
+	if lastName != "" {
+		if name != "" {
+			name += " "
+		}
+		name += lastName
+	}
+	if name == "" {
+		return "@" + username
+	}
+	return name + " (@" + username + ")"
+}
+
+// Threads nests replies under their parents. messages must be in posting
+// order; replies to messages outside the exported range stay at the top
+// level and keep their reply_to_id.
+func Threads(messages []*Message) []*Message {
+	byID := make(map[string]*Message, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+
+	roots := []*Message{}
+	for _, message := range messages {
+		if message.ReplyToID != nil {
+			if parent, ok := byID[*message.ReplyToID]; ok {
+				parent.Replies = append(parent.Replies, message)
+				continue
+			}
+		}
+		roots = append(roots, message)
+	}
+	return roots
+}
+
+func RenderJSON(t *Transcript) ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"stamp": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 MST") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>#{{.Channel}} · {{.Team}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 48rem; margin: 2rem auto; color: #1d1c1d; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+.message { margin: 0.75rem 0; }
+.meta { color: #616061; font-size: 0.85rem; }
+.content { white-space: pre-wrap; }
+.deleted { color: #868686; font-style: italic; }
+.replies { margin-left: 1.5rem; padding-left: 0.75rem; border-left: 2px solid #ddd; }
+</style>
+</head>
+<body>
+<header>
+<h1>#{{.Channel}}</h1>
+<p class="meta">{{.Team}} · {{stamp .From}} to {{stamp .To}} · {{.MessageCount}} messages · generated {{stamp .GeneratedAt}}</p>
+</header>
+{{range .Messages}}{{if .ReplyToID}}<p class="meta">In reply to a message from before this export</p>
+{{end}}{{template "message" .}}{{end}}
+</body>
+</html>
+{{define "message"}}<div class="message" id="m-{{.ID}}">
+<div class="meta"><strong>{{.Author}}</strong> · {{stamp .CreatedAt}}{{if .IsEdited}} · edited{{end}}</div>
+{{if .IsDeleted}}<div class="deleted">This message was deleted.</div>{{else}}<div class="content">{{.Content}}</div>{{end}}
+{{range .Attachments}}<div class="attachment"><a href="{{.URL}}">{{.FileName}}</a> <span class="meta">{{.FileType}}, {{.FileSize}} bytes</span></div>
+{{end}}{{if .Replies}}<div class="replies">{{range .Replies}}{{template "message" .}}{{end}}</div>{{end}}
+</div>
+{{end}}`))
+
+func RenderHTML(t *Transcript) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, t); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
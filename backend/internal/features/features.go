@@ -0,0 +1,68 @@
+package features
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Set holds feature flags and the share of users each is rolled out to.
+type Set struct {
+	rollout map[string]int
+}
+
+// Parse reads a comma separated flag list such as "compact_mode,new_composer:25".
+// A bare name is on for everyone; name:N turns it on for N percent of users.
+// Malformed entries are skipped.
+func Parse(spec string) *Set {
+	s := &Set{rollout: make(map[string]int)}
+	for _, entry := range strings.Split(spec, ",") {
+		name, percent, hasPercent := strings.Cut(strings.TrimSpace(entry), ":")
+		if name == "" {
+			continue
+		}
+
+		share := 100
+		if hasPercent {
+			n, err := strconv.Atoi(percent)
+			if err != nil || n < 0 || n > 100 {
+				continue
+			}
+			share = n
+		}
+		s.rollout[name] = share
+	}
+	return s
+}
+
+// Enabled reports whether the flag is on for userID. Anonymous callers only
+// see flags rolled out to everyone.
+func (s *Set) Enabled(name, userID string) bool {
+	share, ok := s.rollout[name]
+	switch {
+	case !ok || share == 0:
+		return false
+	case share == 100:
+		return true
+	case userID == "":
+		return false
+	}
+
+	// The same user always lands in the same bucket for a flag
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + userID))
+	return int(h.Sum32()%100) < share
+}
+
+// EnabledFor lists the flags that are on for userID, sorted by name.
+func (s *Set) EnabledFor(userID string) []string {
+	enabled := []string{}
+	for name := range s.rollout {
+		if s.Enabled(name, userID) {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
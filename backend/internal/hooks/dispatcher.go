@@ -0,0 +1,128 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+const deliveryTimeout = 10 * time.Second
+
+// SupportedEvents lists the events that can be subscribed to through the REST
+// hooks API.
+var SupportedEvents = []events.Type{
+	events.MessagePosted,
+	events.TaskCreated,
+	events.TaskUpdated,
+	events.MemberJoined,
+}
+
+func IsSupportedEvent(eventType string) bool {
+	for _, supported := range SupportedEvents {
+		if string(supported) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher delivers bus events to the REST hooks subscribed to them, following
+// Zapier's REST hook conventions: the payload is the bare resource and a 410
+// response from the target removes the subscription.
+type Dispatcher struct {
+	db     *database.PostgresDB
+	client *http.Client
+	logger *logger.Logger
+}
+
+func NewDispatcher(db *database.PostgresDB, logger *logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		db:     db,
+		client: NewClient(deliveryTimeout),
+		logger: logger,
+	}
+}
+
+func (d *Dispatcher) Start(bus *events.Bus) {
+	for _, eventType := range SupportedEvents {
+		bus.Subscribe(eventType, d.handleEvent)
+	}
+}
+
+func (d *Dispatcher) handleEvent(ctx context.Context, event events.Event) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT id, team_id, event, target_url, created_by, created_at
+		FROM rest_hooks
+		WHERE team_id = $1 AND event = $2
+	`, event.TeamID, string(event.Type))
+	log := correlation.Log(ctx, d.logger)
+	if err != nil {
+		log.WithError(err).Error("Failed to load REST hooks")
+		return
+	}
+	defer rows.Close()
+
+	var hooks []domain.RestHook
+	for rows.Next() {
+		var hook domain.RestHook
+		if err := rows.Scan(&hook.ID, &hook.TeamID, &hook.Event, &hook.TargetURL, &hook.CreatedBy, &hook.CreatedAt); err != nil {
+			log.WithError(err).Error("Failed to scan REST hook row")
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+
+	for _, hook := range hooks {
+		if err := d.deliver(ctx, hook, event); err != nil {
+			log.WithError(err).Warnf("REST hook delivery failed for hook %s", hook.ID)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, hook domain.RestHook, event events.Event) error {
+	body, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.TargetURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CBA-Event", string(event.Type))
+	req.Header.Set("X-CBA-Delivery", event.ID)
+	req.Header.Set("X-CBA-Correlation-ID", event.CorrelationID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusGone {
+		if _, err := d.db.ExecContext(ctx, `DELETE FROM rest_hooks WHERE id = $1`, hook.ID); err != nil {
+			return fmt.Errorf("failed to remove gone hook: %w", err)
+		}
+		correlation.Log(ctx, d.logger).Infof("REST hook %s removed after 410 from target", hook.ID)
+		return nil
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("target responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateTarget means a hook target resolves to an address inside the
+// network, such as loopback, a private range or cloud metadata, which
+// hooks must not reach.
+var ErrPrivateTarget = errors.New("target address is not public")
+
+var (
+	// sharedAddressSpace is carrier-grade NAT (RFC 6598), which net.IP
+	// doesn't count as private.
+	sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+	// reservedSpace is 240.0.0.0/4, including the broadcast address.
+	reservedSpace = &net.IPNet{IP: net.IPv4(240, 0, 0, 0), Mask: net.CIDRMask(4, 32)}
+	// nat64 and sixToFour are IPv6 prefixes that carry an IPv4 address,
+	// which is checked in turn.
+	nat64     = &net.IPNet{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)}
+	sixToFour = &net.IPNet{IP: net.ParseIP("2002::"), Mask: net.CIDRMask(16, 128)}
+)
+
+func isPublic(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if ip[0] == 0 || sharedAddressSpace.Contains(ip) || reservedSpace.Contains(ip) {
+			return false
+		}
+	} else if nat64.Contains(ip) {
+		return isPublic(net.IP(ip[12:16]))
+	} else if sixToFour.Contains(ip) {
+		return isPublic(net.IP(ip[2:6]))
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// CheckTarget accepts an http(s) URL whose host resolves only to public
+// addresses. Deliveries check the address again as they connect, since
+// DNS can change in between.
+func CheckTarget(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return errors.New("target must be an http(s) URL")
+	}
+
+	host := target.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublic(ip) {
+			return ErrPrivateTarget
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr.IP) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client for calling out to hook and app URLs. It
+// refuses to connect to addresses that aren't public, whatever the name
+// resolved to, including on redirects, and ignores proxy settings so the
+// check applies to the target itself.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return ErrPrivateTarget
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"64:ff9b::808:808", true},
+		{"2002:0808:0808::1", true},
+
+		{"127.0.0.1", false},
+		{"127.8.9.10", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"::", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"192.168.1.1", false},
+		{"100.64.0.1", false},
+		{"100.127.255.255", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"fd12:3456::1", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b::7f00:1", false},
+		{"2002:7f00:0001::1", false},
+		{"2002:c0a8:0101::1", false},
+	}
+	for _, tt := range tests {
+		ip := net.ParseIP(tt.ip)
+		if ip == nil {
+			t.Fatalf("bad test IP %q", tt.ip)
+		}
+		if got := isPublic(ip); got != tt.want {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr error
+	}{
+		{url: "https://93.184.216.34/hook"},
+		{url: "http://[2606:4700:4700::1111]:8080/hook"},
+		{url: "http://127.0.0.1:8080/hook", wantErr: ErrPrivateTarget},
+		{url: "http://[::1]/hook", wantErr: ErrPrivateTarget},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: ErrPrivateTarget},
+		{url: "http://[::ffff:10.0.0.1]/hook", wantErr: ErrPrivateTarget},
+	}
+	for _, tt := range tests {
+		if err := CheckTarget(context.Background(), tt.url); !errors.Is(err, tt.wantErr) {
+			t.Errorf("CheckTarget(%s) = %v, want %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	for _, url := range []string{"ftp://93.184.216.34/", "93.184.216.34", "http:///path", "://"} {
+		if err := CheckTarget(context.Background(), url); err == nil || errors.Is(err, ErrPrivateTarget) {
+			t.Errorf("CheckTarget(%s) = %v, want a URL error", url, err)
+		}
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Get(server.URL)
+	if !errors.Is(err, ErrPrivateTarget) {
+		t.Errorf("Get(%s) = %v, want ErrPrivateTarget", server.URL, err)
+	}
+}
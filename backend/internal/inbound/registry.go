@@ -0,0 +1,74 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+// MaxBodyBytes caps the size of an inbound delivery.
+const MaxBodyBytes = 1 << 20
+
+var (
+	ErrUnknownKind = errors.New("unknown inbound webhook kind")
+
+	// ErrInvalidPayload is wrapped by handlers when a delivery can't be
+	// understood, so the sender gets a 400 rather than a 500.
+	ErrInvalidPayload = errors.New("invalid inbound webhook payload")
+)
+
+// Handler processes a delivery for an inbound webhook. The webhook has
+// already been authenticated by its token.
+type Handler func(ctx context.Context, hook domain.InboundWebhook, body []byte) error
+
+// Registry maps webhook kinds to the handlers that process their deliveries.
+type Registry struct {
+	handlers map[string]Handler
+	mu       sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[string]Handler),
+	}
+}
+
+func (r *Registry) Register(kind string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[kind] = handler
+}
+
+func (r *Registry) Supports(kind string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.handlers[kind]
+	return ok
+}
+
+// Kinds lists the registered kinds in alphabetical order.
+func (r *Registry) Kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (r *Registry) Dispatch(ctx context.Context, hook domain.InboundWebhook, body []byte) error {
+	r.mu.RLock()
+	handler, ok := r.handlers[hook.Kind]
+	r.mu.RUnlock()
+
+	if !ok {
+		return ErrUnknownKind
+	}
+	return handler(ctx, hook, body)
+}
@@ -0,0 +1,89 @@
+package incident
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+// DefaultStatusTemplate is used for the pinned status message when an
+// incident is declared without a template.
+const DefaultStatusTemplate = `**Incident #{{incident.number}}: {{incident.title}}**
+Severity: {{incident.severity}} · Status: {{incident.status}}
+Declared by @{{incident.declared_by}} · Last update {{incident.updated_at}}`
+
+const DefaultChannelPrefix = "inc"
+
+// Event types recorded in the incident history.
+const (
+	EventDeclared        = "declared"
+	EventSeverityChanged = "severity_changed"
+	EventStatusChanged   = "status_changed"
+	EventTitleChanged    = "title_changed"
+	EventTaskLinked      = "task_linked"
+)
+
+// Columns is the column list Scan expects, in order.
+const Columns = `id, team_id, number, title, severity, status, channel_id, status_message_id, template_id,
+	created_by, created_at, updated_at, resolved_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func Scan(row scanner) (domain.Incident, error) {
+	var i domain.Incident
+	err := row.Scan(&i.ID, &i.TeamID, &i.Number, &i.Title, &i.Severity, &i.Status, &i.ChannelID,
+		&i.StatusMessageID, &i.TemplateID, &i.CreatedBy, &i.CreatedAt, &i.UpdatedAt, &i.ResolvedAt)
+	return i, err
+}
+
+func IsValidSeverity(severity domain.IncidentSeverity) bool {
+	switch severity {
+	case domain.SeveritySev1, domain.SeveritySev2, domain.SeveritySev3, domain.SeveritySev4:
+		return true
+	}
+	return false
+}
+
+func IsValidStatus(status domain.IncidentStatus) bool {
+	switch status {
+	case domain.IncidentInvestigating, domain.IncidentIdentified, domain.IncidentMonitoring, domain.IncidentResolved:
+		return true
+	}
+	return false
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ChannelName builds the incident channel name, e.g. "inc-42-checkout-errors".
+// Channel names are limited to 100 characters.
+func ChannelName(prefix string, number int, title string) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	name := fmt.Sprintf("%s-%d", prefix, number)
+	if slug != "" {
+		name += "-" + slug
+	}
+	if len(name) > 100 {
+		name = strings.TrimRight(name[:100], "-")
+	}
+	return name
+}
+
+// TemplateData exposes the incident to status templates as {{incident.*}}.
+func TemplateData(incident domain.Incident, declaredBy string) map[string]interface{} {
+	return map[string]interface{}{
+		"incident": map[string]interface{}{
+			"number":      incident.Number,
+			"title":       incident.Title,
+			"severity":    string(incident.Severity),
+			"status":      string(incident.Status),
+			"declared_by": declaredBy,
+			"created_at":  incident.CreatedAt.UTC().Format(time.RFC1123),
+			"updated_at":  incident.UpdatedAt.UTC().Format(time.RFC1123),
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package incident
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+// DocumentTimeline is the kind of the document generated on resolution.
+const DocumentTimeline = "timeline"
+
+const (
+	EntryMessage = "message"
+	EntryEvent   = "event"
+)
+
+// Timeline is the structured export of an incident.
+type Timeline struct {
+	Incident        domain.Incident       `json:"incident"`
+	DurationSeconds int64                 `json:"duration_seconds"`
+	Entries         []TimelineEntry       `json:"entries"`
+	Tasks           []domain.IncidentTask `json:"tasks"`
+}
+
+type TimelineEntry struct {
+	At     time.Time `json:"at"`
+	Kind   string    `json:"kind"`
+	Author string    `json:"author,omitempty"`
+	Text   string    `json:"text"`
+}
+
+// ChannelMessage is a message from the incident channel, with the author's
+// username resolved.
+type ChannelMessage struct {
+	Author    string
+	Content   string
+	CreatedAt time.Time
+}
+
+// BuildTimeline merges the incident's transitions and channel messages into
+// one chronological list.
+func BuildTimeline(incident domain.Incident, events []domain.IncidentEvent, messages []ChannelMessage, tasks []domain.IncidentTask, actors map[string]string) Timeline {
+	end := time.Now()
+	if incident.ResolvedAt != nil {
+		end = *incident.ResolvedAt
+	}
+
+	timeline := Timeline{
+		Incident:        incident,
+		DurationSeconds: int64(end.Sub(incident.CreatedAt).Seconds()),
+		Entries:         []TimelineEntry{},
+		Tasks:           tasks,
+	}
+	if timeline.Tasks == nil {
+		timeline.Tasks = []domain.IncidentTask{}
+	}
+
+	for _, event := range events {
+		author := ""
+		if event.ActorID != nil {
+			author = actors[*event.ActorID]
+		}
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			At:     event.CreatedAt,
+			Kind:   EntryEvent,
+			Author: author,
+			Text:   describeEvent(event),
+		})
+	}
+
+	for _, message := range messages {
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			At:     message.CreatedAt,
+			Kind:   EntryMessage,
+			Author: message.Author,
+			Text:   message.Content,
+		})
+	}
+
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].At.Before(timeline.Entries[j].At)
+	})
+
+	return timeline
+}
+
+func describeEvent(event domain.IncidentEvent) string {
+	var text string
+	switch event.Type {
+	case EventDeclared:
+		text = fmt.Sprintf("Incident declared as %s", event.ToValue)
+	case EventSeverityChanged:
+		text = fmt.Sprintf("Severity changed from %s to %s", event.FromValue, event.ToValue)
+	case EventStatusChanged:
+		text = fmt.Sprintf("Status changed from %s to %s", event.FromValue, event.ToValue)
+	case EventTitleChanged:
+		text = fmt.Sprintf("Title changed to %q", event.ToValue)
+	case EventTaskLinked:
+		text = fmt.Sprintf("Task linked: %s", event.ToValue)
+	default:
+		text = event.Type
+	}
+
+	if event.Note != "" {
+		text += " — " + event.Note
+	}
+	return text
+}
+
+// Markdown renders the timeline as a document suitable for a postmortem.
+func (t Timeline) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Incident #%d: %s\n\n", t.Incident.Number, t.Incident.Title)
+	fmt.Fprintf(&b, "- Severity: %s\n", t.Incident.Severity)
+	fmt.Fprintf(&b, "- Status: %s\n", t.Incident.Status)
+	fmt.Fprintf(&b, "- Declared: %s\n", t.Incident.CreatedAt.UTC().Format(time.RFC3339))
+	if t.Incident.ResolvedAt != nil {
+		fmt.Fprintf(&b, "- Resolved: %s\n", t.Incident.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "- Duration: %s\n", time.Duration(t.DurationSeconds)*time.Second)
+
+	if len(t.Tasks) > 0 {
+		b.WriteString("\n## Tasks\n\n")
+		for _, task := range t.Tasks {
+			fmt.Fprintf(&b, "- [%s] %s (%s)\n", task.Status, task.Title, task.TaskID)
+		}
+	}
+
+	b.WriteString("\n## Timeline\n\n")
+	for _, entry := range t.Entries {
+		line := strings.ReplaceAll(entry.Text, "\n", " ")
+		if entry.Author != "" {
+			line = fmt.Sprintf("**%s**: %s", entry.Author, line)
+		}
+		if entry.Kind == EntryEvent {
+			line = "_" + line + "_"
+		}
+		fmt.Fprintf(&b, "- `%s` %s\n", entry.At.UTC().Format("2006-01-02 15:04:05"), line)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,78 @@
+// Package fakes provides a scripted llm.Provider for tests.
+package fakes
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cbalite/backend/internal/llm"
+)
+
+// ErrNoResponse is returned once a Provider has used up its script.
+var ErrNoResponse = errors.New("fakes: no scripted llm response left")
+
+// Provider answers completions from a script, in order, and keeps the
+// requests it was sent.
+type Provider struct {
+	mu        sync.Mutex
+	responses []scripted
+	requests  []llm.Request
+}
+
+type scripted struct {
+	response *llm.Response
+	err      error
+}
+
+var _ llm.Provider = (*Provider)(nil)
+
+func NewProvider() *Provider {
+	return &Provider{}
+}
+
+// Reply queues a response with the given content. Its usage is estimated
+// from the request and the content.
+func (p *Provider) Reply(content string) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses = append(p.responses, scripted{response: &llm.Response{Content: content, Model: "fake"}})
+	return p
+}
+
+// Fail queues an error.
+func (p *Provider) Fail(err error) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses = append(p.responses, scripted{err: err})
+	return p
+}
+
+func (p *Provider) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests = append(p.requests, req)
+	if len(p.responses) == 0 {
+		return nil, ErrNoResponse
+	}
+	next := p.responses[0]
+	p.responses = p.responses[1:]
+	if next.err != nil {
+		return nil, next.err
+	}
+
+	response := *next.response
+	for _, message := range req.Messages {
+		response.Usage.PromptTokens += llm.EstimateTokens(message.Content)
+	}
+	response.Usage.CompletionTokens = llm.EstimateTokens(response.Content)
+	return &response, nil
+}
+
+// Requests returns the requests sent so far.
+func (p *Provider) Requests() []llm.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]llm.Request(nil), p.requests...)
+}
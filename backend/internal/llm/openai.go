@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cbalite/backend/internal/config"
+)
+
+// OpenAIProvider talks to any endpoint implementing the OpenAI chat
+// completions API.
+type OpenAIProvider struct {
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+	client    *http.Client
+}
+
+func NewOpenAIProvider(cfg *config.LLMConfig) *OpenAIProvider {
+	return &OpenAIProvider{
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:    cfg.APIKey,
+		model:     cfg.Model,
+		maxTokens: cfg.MaxTokens,
+		client:    &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+type chatCompletionRequest struct {
+	Model     string    `json:"model"`
+	Messages  []Message `json:"messages"`
+	MaxTokens int       `json:"max_tokens,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 || maxTokens > p.maxTokens {
+		maxTokens = p.maxTokens
+	}
+
+	body, err := json.Marshal(chatCompletionRequest{
+		Model:     p.model,
+		Messages:  req.Messages,
+		MaxTokens: maxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode completion request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("completion request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read completion response: %w", err)
+	}
+
+	var completion chatCompletionResponse
+	if err := json.Unmarshal(raw, &completion); err != nil {
+		return nil, fmt.Errorf("failed to decode completion response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if completion.Error != nil {
+			return nil, fmt.Errorf("completion request returned %d: %s", resp.StatusCode, completion.Error.Message)
+		}
+		return nil, fmt.Errorf("completion request returned %d", resp.StatusCode)
+	}
+
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("completion response had no choices")
+	}
+
+	return &Response{
+		Content: strings.TrimSpace(completion.Choices[0].Message.Content),
+		Model:   completion.Model,
+		Usage:   completion.Usage,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cbalite/backend/internal/config"
+)
+
+var ErrNotConfigured = errors.New("llm provider not configured")
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type Request struct {
+	Messages  []Message
+	MaxTokens int
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+type Response struct {
+	Content string
+	Model   string
+	Usage   Usage
+}
+
+// Provider generates chat completions. Features that need a model depend on
+// this interface rather than on a specific vendor.
+type Provider interface {
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// New returns the provider described by the config, or nil when AI features
+// are not configured for this deployment.
+func New(cfg *config.LLMConfig) Provider {
+	if cfg.BaseURL == "" {
+		return nil
+	}
+	return NewOpenAIProvider(cfg)
+}
+
+// EstimateTokens gives a rough token count for budgeting before a request is
+// sent; providers report exact usage afterwards.
+func EstimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+// StripCodeFence removes the markdown code fence some models wrap JSON in.
+func StripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimPrefix(content, "json")
+	return strings.TrimSpace(strings.TrimSuffix(content, "```"))
+}
@@ -0,0 +1,101 @@
+package messagepolicy
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+var (
+	ErrSystemMessage   = errors.New("system messages can't be changed")
+	ErrNotSender       = errors.New("only the sender can edit a message")
+	ErrEditDisabled    = errors.New("editing messages is turned off for this team")
+	ErrEditExpired     = errors.New("the time to edit this message has passed")
+	ErrDeleteDisabled  = errors.New("deleting messages is turned off for this team")
+	ErrDeleteExpired   = errors.New("the time to delete this message has passed")
+	ErrDeleteForbidden = errors.New("you can only delete your own messages")
+)
+
+// Default is the policy of teams that haven't configured one: members may
+// edit and delete their own messages at any time and admins may delete
+// anyone's.
+func Default(teamID string) domain.MessagePolicy {
+	return domain.MessagePolicy{
+		TeamID:                teamID,
+		MembersCanEdit:        true,
+		MembersCanDelete:      true,
+		AdminsCanDeleteOthers: true,
+	}
+}
+
+// Message is what the policy needs to know about a message.
+type Message struct {
+	SenderID  string
+	Type      string
+	CreatedAt time.Time
+}
+
+// Actor is the user acting on a message, with their role in the team that
+// owns the channel. Guests from a shared channel have no role there and
+// follow the rules for members.
+type Actor struct {
+	UserID string
+	Role   string
+}
+
+func (a Actor) admin() bool {
+	return a.Role == "owner" || a.Role == "admin"
+}
+
+// CanEdit reports why the actor may not edit the message, or nil. Nobody
+// edits someone else's message. Owners and admins may always edit their
+// own; members only while the team allows it and within the edit window.
+func CanEdit(policy domain.MessagePolicy, message Message, actor Actor, now time.Time) error {
+	if message.Type == string(domain.MessageTypeSystem) {
+		return ErrSystemMessage
+	}
+	if message.SenderID != actor.UserID {
+		return ErrNotSender
+	}
+	if actor.admin() {
+		return nil
+	}
+	if !policy.MembersCanEdit {
+		return ErrEditDisabled
+	}
+	if expired(message.CreatedAt, policy.EditWindowMinutes, now) {
+		return ErrEditExpired
+	}
+	return nil
+}
+
+// CanDelete reports why the actor may not delete the message, or nil.
+// Members delete their own messages while the team allows it and within the
+// delete window; owners and admins delete their own at any time and anyone
+// else's when the team allows it.
+func CanDelete(policy domain.MessagePolicy, message Message, actor Actor, now time.Time) error {
+	if message.SenderID != actor.UserID {
+		if actor.admin() && policy.AdminsCanDeleteOthers {
+			return nil
+		}
+		return ErrDeleteForbidden
+	}
+	if message.Type == string(domain.MessageTypeSystem) {
+		return ErrSystemMessage
+	}
+	if actor.admin() {
+		return nil
+	}
+	if !policy.MembersCanDelete {
+		return ErrDeleteDisabled
+	}
+	if expired(message.CreatedAt, policy.DeleteWindowMinutes, now) {
+		return ErrDeleteExpired
+	}
+	return nil
+}
+
+func expired(createdAt time.Time, windowMinutes *int, now time.Time) bool {
+	return windowMinutes != nil && now.Sub(createdAt) > time.Duration(*windowMinutes)*time.Minute
+}
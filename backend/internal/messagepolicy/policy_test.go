@@ -0,0 +1,96 @@
+package messagepolicy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+func minutes(n int) *int {
+	return &n
+}
+
+var (
+	sent   = time.Date(2025, 6, 11, 10, 0, 0, 0, time.UTC)
+	member = Actor{UserID: "u1", Role: "member"}
+	admin  = Actor{UserID: "u1", Role: "admin"}
+	owner  = Actor{UserID: "u1", Role: "owner"}
+	guest  = Actor{UserID: "u1"}
+)
+
+func TestCanEdit(t *testing.T) {
+	windowed := Default("t1")
+	windowed.EditWindowMinutes = minutes(15)
+	locked := Default("t1")
+	locked.MembersCanEdit = false
+
+	tests := []struct {
+		name    string
+		policy  domain.MessagePolicy
+		message Message
+		actor   Actor
+		after   time.Duration
+		want    error
+	}{
+		{name: "own message", policy: Default("t1"), message: Message{SenderID: "u1", Type: "text"}, actor: member, after: 48 * time.Hour},
+		{name: "someone else's", policy: Default("t1"), message: Message{SenderID: "u2", Type: "text"}, actor: member, want: ErrNotSender},
+		{name: "admins can't edit others", policy: Default("t1"), message: Message{SenderID: "u2", Type: "text"}, actor: admin, want: ErrNotSender},
+		{name: "system message", policy: Default("t1"), message: Message{SenderID: "u1", Type: "system"}, actor: owner, want: ErrSystemMessage},
+		{name: "editing off", policy: locked, message: Message{SenderID: "u1", Type: "text"}, actor: member, want: ErrEditDisabled},
+		{name: "editing off for a guest", policy: locked, message: Message{SenderID: "u1", Type: "text"}, actor: guest, want: ErrEditDisabled},
+		{name: "editing off but admin", policy: locked, message: Message{SenderID: "u1", Type: "text"}, actor: admin},
+		{name: "inside the window", policy: windowed, message: Message{SenderID: "u1", Type: "text"}, actor: member, after: 14 * time.Minute},
+		{name: "at the end of the window", policy: windowed, message: Message{SenderID: "u1", Type: "text"}, actor: member, after: 15 * time.Minute},
+		{name: "past the window", policy: windowed, message: Message{SenderID: "u1", Type: "text"}, actor: member, after: 15*time.Minute + time.Second, want: ErrEditExpired},
+		{name: "past the window but owner", policy: windowed, message: Message{SenderID: "u1", Type: "text"}, actor: owner, after: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.message.CreatedAt = sent
+			if err := CanEdit(tt.policy, tt.message, tt.actor, sent.Add(tt.after)); !errors.Is(err, tt.want) {
+				t.Errorf("CanEdit = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanDelete(t *testing.T) {
+	windowed := Default("t1")
+	windowed.DeleteWindowMinutes = minutes(0)
+	locked := Default("t1")
+	locked.MembersCanDelete = false
+	noModeration := Default("t1")
+	noModeration.AdminsCanDeleteOthers = false
+
+	tests := []struct {
+		name    string
+		policy  domain.MessagePolicy
+		message Message
+		actor   Actor
+		after   time.Duration
+		want    error
+	}{
+		{name: "own message", policy: Default("t1"), message: Message{SenderID: "u1", Type: "text"}, actor: member, after: 48 * time.Hour},
+		{name: "someone else's", policy: Default("t1"), message: Message{SenderID: "u2", Type: "text"}, actor: member, want: ErrDeleteForbidden},
+		{name: "guest deleting someone else's", policy: Default("t1"), message: Message{SenderID: "u2", Type: "text"}, actor: guest, want: ErrDeleteForbidden},
+		{name: "admin moderating", policy: Default("t1"), message: Message{SenderID: "u2", Type: "text"}, actor: admin},
+		{name: "admin moderating a system message", policy: Default("t1"), message: Message{SenderID: "u2", Type: "system"}, actor: owner},
+		{name: "moderation off", policy: noModeration, message: Message{SenderID: "u2", Type: "text"}, actor: admin, want: ErrDeleteForbidden},
+		{name: "own system message", policy: Default("t1"), message: Message{SenderID: "u1", Type: "system"}, actor: member, want: ErrSystemMessage},
+		{name: "deleting off", policy: locked, message: Message{SenderID: "u1", Type: "text"}, actor: member, want: ErrDeleteDisabled},
+		{name: "deleting off but admin", policy: locked, message: Message{SenderID: "u1", Type: "text"}, actor: admin},
+		{name: "zero window straight away", policy: windowed, message: Message{SenderID: "u1", Type: "text"}, actor: member},
+		{name: "zero window later", policy: windowed, message: Message{SenderID: "u1", Type: "text"}, actor: member, after: time.Second, want: ErrDeleteExpired},
+		{name: "zero window but owner", policy: windowed, message: Message{SenderID: "u1", Type: "text"}, actor: owner, after: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.message.CreatedAt = sent
+			if err := CanDelete(tt.policy, tt.message, tt.actor, sent.Add(tt.after)); !errors.Is(err, tt.want) {
+				t.Errorf("CanDelete = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
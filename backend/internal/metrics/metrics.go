@@ -0,0 +1,351 @@
+// Package metrics keeps counters, gauges and histograms in memory and
+// serves them in the Prometheus text exposition format, without pulling in
+// the Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LatencyBuckets suit request and delivery latencies, in seconds.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry is a set of metrics served together. Register metrics once, at
+// startup; Default is the registry /metrics serves.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[name]; exists {
+		panic("metrics: " + name + " registered twice")
+	}
+	r.metrics[name] = m
+}
+
+// Handler writes every metric, sorted by name.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.RLock()
+		names := make([]string, 0, len(r.metrics))
+		for name := range r.metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		metrics := make([]metric, len(names))
+		for i, name := range names {
+			metrics[i] = r.metrics[name]
+		}
+		r.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		buf := bufio.NewWriter(w)
+		for _, m := range metrics {
+			m.write(buf)
+		}
+		buf.Flush()
+	})
+}
+
+// vec holds one series per combination of label values.
+type vec[S any] struct {
+	name   string
+	help   string
+	labels []string
+	create func() *S
+
+	mu     sync.RWMutex
+	series map[string]*S
+	keys   map[string][]string
+}
+
+func newVec[S any](name, help string, labels []string, create func() *S) *vec[S] {
+	return &vec[S]{
+		name:   name,
+		help:   help,
+		labels: labels,
+		create: create,
+		series: make(map[string]*S),
+		keys:   make(map[string][]string),
+	}
+}
+
+func (v *vec[S]) with(values ...string) *S {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; !ok {
+		s = v.create()
+		v.series[key] = s
+		v.keys[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each visits the series in a stable order.
+func (v *vec[S]) each(fn func(labels string, s *S)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v.mu.RLock()
+		s, values := v.series[key], v.keys[key]
+		v.mu.RUnlock()
+		fn(formatLabels(v.labels, values), s)
+	}
+}
+
+func (v *vec[S]) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, kind)
+}
+
+// Counter only goes up.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) Add(delta float64) {
+	addFloat(&c.bits, delta)
+}
+
+// Value returns the count so far.
+func (c *Counter) Value() float64 {
+	return loadFloat(&c.bits)
+}
+
+type CounterVec struct {
+	vec *vec[Counter]
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, labels, func() *Counter { return &Counter{} })}
+	r.register(name, c)
+	return c
+}
+
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.vec.with(values...)
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.vec.header(w, "counter")
+	c.vec.each(func(labels string, s *Counter) {
+		fmt.Fprintf(w, "%s%s %s\n", c.vec.name, labels, formatFloat(loadFloat(&s.bits)))
+	})
+}
+
+// Gauge goes up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+func (g *Gauge) Set(value float64) {
+	g.bits.Store(math.Float64bits(value))
+}
+
+func (g *Gauge) Add(delta float64) {
+	addFloat(&g.bits, delta)
+}
+
+// Value returns the gauge's current value.
+func (g *Gauge) Value() float64 {
+	return loadFloat(&g.bits)
+}
+
+type GaugeVec struct {
+	vec *vec[Gauge]
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: newVec(name, help, labels, func() *Gauge { return &Gauge{} })}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeVec) With(values ...string) *Gauge {
+	return g.vec.with(values...)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.vec.header(w, "gauge")
+	g.vec.each(func(labels string, s *Gauge) {
+		fmt.Fprintf(w, "%s%s %s\n", g.vec.name, labels, formatFloat(loadFloat(&s.bits)))
+	})
+}
+
+// gaugeFunc reads its value when scraped.
+type gaugeFunc struct {
+	name, help string
+	read       func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every
+// scrape, for values something else already keeps, such as pool stats.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &gaugeFunc{name: name, help: help, read: fn})
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, escapeHelp(g.help), g.name, g.name, formatFloat(g.read()))
+}
+
+// counterFunc reads its total when scraped.
+type counterFunc struct {
+	name, help string
+	read       func() float64
+}
+
+// NewCounterFunc registers a counter whose total is read from fn on every
+// scrape, for totals something else already keeps. fn must never go down.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(name, &counterFunc{name: name, help: help, read: fn})
+}
+
+func (c *counterFunc) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %s\n", c.name, escapeHelp(c.help), c.name, c.name, formatFloat(c.read()))
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	upper  []float64
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Uint64
+}
+
+func (h *Histogram) Observe(value float64) {
+	for i, upper := range h.upper {
+		if value <= upper {
+			h.counts[i].Add(1)
+		}
+	}
+	h.count.Add(1)
+	addFloat(&h.sum, value)
+}
+
+type HistogramVec struct {
+	vec *vec[Histogram]
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	upper := append([]float64(nil), buckets...)
+	sort.Float64s(upper)
+	h := &HistogramVec{vec: newVec(name, help, labels, func() *Histogram {
+		return &Histogram{upper: upper, counts: make([]atomic.Uint64, len(upper))}
+	})}
+	r.register(name, h)
+	return h
+}
+
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.vec.with(values...)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.vec.header(w, "histogram")
+	h.vec.each(func(labels string, s *Histogram) {
+		for i, upper := range s.upper {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.vec.name, withLabel(labels, "le", formatFloat(upper)), s.counts[i].Load())
+		}
+		count := s.count.Load()
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.vec.name, withLabel(labels, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.vec.name, labels, formatFloat(loadFloat(&s.sum)))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.vec.name, labels, count)
+	})
+}
+
+func addFloat(bits *atomic.Uint64, delta float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func loadFloat(bits *atomic.Uint64) float64 {
+	return math.Float64frombits(bits.Load())
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel adds one more label to a formatted label set.
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/metrics"
+)
+
+// Route classes admission control keeps apart, so a pile-up of exports
+// can't hold up reading messages.
+const (
+	ClassRead  = "read"
+	ClassWrite = "write"
+	ClassHeavy = "heavy"
+)
+
+// heavySuffixes mark routes that run long: AI calls, exports and report
+// runs.
+var heavySuffixes = []string{"/summarize", "/export", "/download", "/retention/preview", "/run"}
+
+var (
+	admissionInFlight = metrics.Default.NewGaugeVec("cbalite_admission_in_flight",
+		"API requests being handled, by route class.", "class")
+	admissionQueueDepth = metrics.Default.NewGaugeVec("cbalite_admission_queue_depth",
+		"API requests waiting for a turn, by route class.", "class")
+	admissionQueueWait = metrics.Default.NewHistogramVec("cbalite_admission_queue_wait_seconds",
+		"Time admitted requests waited for a turn, by route class.", metrics.LatencyBuckets, "class")
+	admissionShed = metrics.Default.NewCounterVec("cbalite_admission_shed_total",
+		"API requests shed with a 503, by route class and reason: queue_full or timeout.", "class", "reason")
+)
+
+// admissionClass lets Concurrency requests run and Queue more wait.
+type admissionClass struct {
+	name   string
+	slots  chan struct{}
+	queue  int64
+	queued atomic.Int64
+}
+
+func newAdmissionClass(name string, limit config.AdmissionLimit) *admissionClass {
+	if limit.Concurrency <= 0 {
+		return nil
+	}
+	return &admissionClass{
+		name:  name,
+		slots: make(chan struct{}, limit.Concurrency),
+		queue: int64(limit.Queue),
+	}
+}
+
+// acquire takes a slot, waiting up to timeout in the queue, and returns
+// why it couldn't.
+func (c *admissionClass) acquire(r *http.Request, timeout time.Duration) (shed string) {
+	select {
+	case c.slots <- struct{}{}:
+		return ""
+	default:
+	}
+
+	if c.queued.Add(1) > c.queue {
+		c.queued.Add(-1)
+		return "queue_full"
+	}
+	depth := admissionQueueDepth.With(c.name)
+	depth.Add(1)
+	defer func() {
+		c.queued.Add(-1)
+		depth.Add(-1)
+	}()
+
+	start := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		admissionQueueWait.With(c.name).Observe(time.Since(start).Seconds())
+		return ""
+	case <-timer.C:
+		return "timeout"
+	case <-r.Context().Done():
+		// The client gave up; nothing will read the answer
+		return "cancelled"
+	}
+}
+
+func (c *admissionClass) release() {
+	<-c.slots
+}
+
+// classify puts long-running routes in the heavy class, other reads in
+// read and the rest in write.
+func classify(r *http.Request) string {
+	for _, suffix := range heavySuffixes {
+		if strings.HasSuffix(r.URL.Path, suffix) {
+			return ClassHeavy
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ClassRead
+	}
+	return ClassWrite
+}
+
+// NewAdmissionMiddleware bounds the API requests of each class in flight,
+// queueing a few more for up to cfg.QueueTimeout. Past that, requests are
+// shed with a 503 and Retry-After straight away, which beats letting every
+// request's latency climb past the client's and proxy's timeouts.
+func NewAdmissionMiddleware(cfg *config.AdmissionConfig) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	classes := map[string]*admissionClass{
+		ClassRead:  newAdmissionClass(ClassRead, cfg.Read),
+		ClassWrite: newAdmissionClass(ClassWrite, cfg.Write),
+		ClassHeavy: newAdmissionClass(ClassHeavy, cfg.Heavy),
+	}
+	retryAfter := strconv.Itoa(int(math.Max(1, math.Ceil(cfg.QueueTimeout.Seconds()))))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := classes[classify(r)]
+			if class == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if reason := class.acquire(r, cfg.QueueTimeout); reason != "" {
+				if reason != "cancelled" {
+					admissionShed.With(class.name, reason).Inc()
+				}
+				w.Header().Set("Retry-After", retryAfter)
+				respondWithError(w, http.StatusServiceUnavailable, "Server is busy, try again shortly")
+				return
+			}
+			defer class.release()
+
+			inFlight := admissionInFlight.With(class.name)
+			inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cbalite/backend/internal/config"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{http.MethodGet, "/api/v1/teams/t1/channels", ClassRead},
+		{http.MethodHead, "/api/v1/teams/t1/channels", ClassRead},
+		{http.MethodOptions, "/api/v1/teams/t1/channels", ClassRead},
+		{http.MethodPost, "/api/v1/channels/c1/messages", ClassWrite},
+		{http.MethodDelete, "/api/v1/tasks/t1", ClassWrite},
+		{http.MethodPost, "/api/v1/channels/c1/summarize", ClassHeavy},
+		{http.MethodGet, "/api/v1/exports/e1/download", ClassHeavy},
+		{http.MethodPost, "/api/v1/teams/t1/export", ClassHeavy},
+		{http.MethodPost, "/api/v1/teams/t1/retention/preview", ClassHeavy},
+		{http.MethodPost, "/api/v1/reports/r1/run", ClassHeavy},
+		{http.MethodGet, "/api/v1/runbooks", ClassRead},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := classify(r); got != tt.want {
+			t.Errorf("classify(%s %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// blockingServer admits through cfg to a handler that holds each request
+// until release is closed. The returned function makes a request of the
+// given method in the background and returns its recorder once it is done.
+func blockingServer(t *testing.T, cfg config.AdmissionConfig) (started chan struct{}, release chan struct{}, do func(method string) <-chan *httptest.ResponseRecorder) {
+	t.Helper()
+	started = make(chan struct{}, 16)
+	release = make(chan struct{})
+	handler := NewAdmissionMiddleware(&cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	do = func(method string) <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(method, "/api/v1/things", nil))
+			done <- rec
+		}()
+		return done
+	}
+	return started, release, do
+}
+
+func waitForQueue(t *testing.T, class string, depth float64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for admissionQueueDepth.With(class).Value() != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s queue depth is %v, want %v", class, admissionQueueDepth.With(class).Value(), depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func expectShed(t *testing.T, done <-chan *httptest.ResponseRecorder, retryAfter string) {
+	t.Helper()
+	select {
+	case rec := <-done:
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want 503", rec.Code)
+		}
+		if got := rec.Header().Get("Retry-After"); got != retryAfter {
+			t.Errorf("Retry-After = %q, want %q", got, retryAfter)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request wasn't shed")
+	}
+}
+
+func TestAdmissionShedsWhenQueueIsFull(t *testing.T) {
+	cfg := config.AdmissionConfig{Enabled: true, QueueTimeout: time.Minute, Write: config.AdmissionLimit{Concurrency: 1, Queue: 1}}
+	started, release, do := blockingServer(t, cfg)
+	shed := admissionShed.With(ClassWrite, "queue_full")
+	before := shed.Value()
+
+	first := do(http.MethodPost)
+	<-started
+	second := do(http.MethodPost)
+	waitForQueue(t, ClassWrite, 1)
+
+	expectShed(t, do(http.MethodPost), "60")
+	if got := shed.Value() - before; got != 1 {
+		t.Errorf("counted %v queue_full sheds, want 1", got)
+	}
+
+	// The queued request gets the slot once the first is done
+	close(release)
+	for _, done := range []<-chan *httptest.ResponseRecorder{first, second} {
+		if rec := <-done; rec.Code != http.StatusNoContent {
+			t.Errorf("admitted request = %d", rec.Code)
+		}
+	}
+	waitForQueue(t, ClassWrite, 0)
+}
+
+func TestAdmissionShedsAfterQueueTimeout(t *testing.T) {
+	cfg := config.AdmissionConfig{Enabled: true, QueueTimeout: 20 * time.Millisecond, Write: config.AdmissionLimit{Concurrency: 1, Queue: 4}}
+	started, _, do := blockingServer(t, cfg)
+	shed := admissionShed.With(ClassWrite, "timeout")
+	before := shed.Value()
+
+	do(http.MethodPost)
+	<-started
+
+	start := time.Now()
+	expectShed(t, do(http.MethodPost), "1")
+	if waited := time.Since(start); waited < cfg.QueueTimeout {
+		t.Errorf("shed after %s, before the %s timeout", waited, cfg.QueueTimeout)
+	}
+	if got := shed.Value() - before; got != 1 {
+		t.Errorf("counted %v timeout sheds, want 1", got)
+	}
+	waitForQueue(t, ClassWrite, 0)
+}
+
+func TestAdmissionDoesNotCountCancelledRequests(t *testing.T) {
+	cfg := config.AdmissionConfig{Enabled: true, QueueTimeout: time.Minute, Write: config.AdmissionLimit{Concurrency: 1, Queue: 1}}
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	handler := NewAdmissionMiddleware(&cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	<-started
+
+	before := admissionShed.With(ClassWrite, "timeout").Value() + admissionShed.With(ClassWrite, "queue_full").Value()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx))
+		done <- rec
+	}()
+	waitForQueue(t, ClassWrite, 1)
+	cancel()
+
+	expectShed(t, done, "60")
+	after := admissionShed.With(ClassWrite, "timeout").Value() + admissionShed.With(ClassWrite, "queue_full").Value()
+	if after != before {
+		t.Errorf("a cancelled request was counted as shed")
+	}
+}
+
+func TestAdmissionClassesAreSeparate(t *testing.T) {
+	cfg := config.AdmissionConfig{
+		Enabled:      true,
+		QueueTimeout: time.Minute,
+		Read:         config.AdmissionLimit{Concurrency: 1},
+		Write:        config.AdmissionLimit{Concurrency: 1},
+	}
+	started, release, do := blockingServer(t, cfg)
+
+	write := do(http.MethodPost)
+	<-started
+	// Write is full, with no queue
+	expectShed(t, do(http.MethodPut), "60")
+
+	read := do(http.MethodGet)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("a read waited on a full write class")
+	}
+	close(release)
+	for _, done := range []<-chan *httptest.ResponseRecorder{write, read} {
+		if rec := <-done; rec.Code != http.StatusNoContent {
+			t.Errorf("admitted request = %d", rec.Code)
+		}
+	}
+}
+
+func TestAdmissionOff(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AdmissionConfig
+	}{
+		{name: "disabled", cfg: config.AdmissionConfig{Write: config.AdmissionLimit{Concurrency: 1}}},
+		{name: "class without a limit", cfg: config.AdmissionConfig{Enabled: true, Read: config.AdmissionLimit{Concurrency: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started, release, do := blockingServer(t, tt.cfg)
+			first, second := do(http.MethodPost), do(http.MethodPost)
+			for i := 0; i < 2; i++ {
+				select {
+				case <-started:
+				case <-time.After(time.Second):
+					t.Fatal("requests were limited")
+				}
+			}
+			close(release)
+			<-first
+			<-second
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/pkg/httpjson"
 	"github.com/cbalite/backend/pkg/logger"
 )
 
@@ -34,6 +35,13 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
+
+	// Set on tokens issued to third-party apps, which act for the
+	// installing user within one team and only within their scopes.
+	AppID  string   `json:"app_id,omitempty"`
+	TeamID string   `json:"team_id,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
@@ -111,6 +119,24 @@ func (a *AuthMiddleware) GenerateToken(userID, email, username string) (string,
 	return token.SignedString([]byte(a.jwtConfig.SecretKey))
 }
 
+// GenerateAppToken issues an access token for an installed app.
+func (a *AuthMiddleware) GenerateAppToken(userID, appID, teamID string, scopes []string) (string, error) {
+	claims := &Claims{
+		UserID: userID,
+		AppID:  appID,
+		TeamID: teamID,
+		Scopes: scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(a.jwtConfig.AppTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(a.jwtConfig.SecretKey))
+}
+
 func (a *AuthMiddleware) GenerateRefreshToken(userID string) (string, error) {
 	claims := &Claims{
 		UserID: userID,
@@ -153,7 +179,5 @@ func GetTokenFromContext(ctx context.Context) (string, bool) {
 }
 
 func respondWithError(w http.ResponseWriter, code int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	w.Write([]byte(`{"error":"` + message + `"}`))
+	httpjson.Error(w, code, message)
 }
\ No newline at end of file
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cbalite/backend/internal/config"
+)
+
+// ClientIPResolver works out the address a request came from. Forwarding
+// headers are only believed when the connection comes from a trusted proxy,
+// since anyone else can put whatever they like in them.
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+// NewClientIPResolver trusts the proxies in cfg.TrustedProxies, which
+// config validation has already checked.
+func NewClientIPResolver(cfg *config.RateLimitConfig) *ClientIPResolver {
+	trusted, _ := config.ParseTrustedProxies(cfg.TrustedProxies)
+	return &ClientIPResolver{trusted: trusted}
+}
+
+// ClientIP is the connection's address, or, when that's a trusted proxy,
+// the nearest address in X-Forwarded-For that isn't one (X-Real-IP when
+// there's no X-Forwarded-For).
+func (c *ClientIPResolver) ClientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !c.isTrusted(remote) {
+		return remote
+	}
+
+	// Each proxy appends the address it got the request from, so walk back
+	// from the end past the proxies we run
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if !c.isTrusted(hop) {
+				return hop
+			}
+		}
+		return remote
+	}
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(xri) != nil {
+		return xri
+	}
+	return remote
+}
+
+func (c *ClientIPResolver) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range c.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cbalite/backend/internal/config"
+)
+
+func TestClientIP(t *testing.T) {
+	resolver := NewClientIPResolver(&config.RateLimitConfig{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::1"}})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		realIP     string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:5555", want: "203.0.113.7"},
+		{name: "direct ignores forwarding headers", remoteAddr: "203.0.113.7:5555", xff: "198.51.100.1", realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "direct ipv6", remoteAddr: "[2001:db8::9]:5555", xff: "198.51.100.1", want: "2001:db8::9"},
+		{name: "remote without a port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+		{name: "through a proxy", remoteAddr: "10.1.2.3:5555", xff: "198.51.100.1", want: "198.51.100.1"},
+		{name: "through an ipv6 proxy", remoteAddr: "[2001:db8::1]:5555", xff: "198.51.100.1", want: "198.51.100.1"},
+		{name: "through a chain of proxies", remoteAddr: "10.1.2.3:5555", xff: "198.51.100.1, 10.9.9.9, 10.8.8.8", want: "198.51.100.1"},
+		{name: "spoofed hops before the client", remoteAddr: "10.1.2.3:5555", xff: "1.2.3.4, 198.51.100.1", want: "198.51.100.1"},
+		{name: "spoofed trusted hop", remoteAddr: "10.1.2.3:5555", xff: "198.51.100.1, 10.0.0.5", want: "198.51.100.1"},
+		{name: "only proxies", remoteAddr: "10.1.2.3:5555", xff: "10.0.0.5", want: "10.1.2.3"},
+		{name: "garbage hop", remoteAddr: "10.1.2.3:5555", xff: "198.51.100.1, not-an-ip", want: "10.1.2.3"},
+		{name: "real ip", remoteAddr: "10.1.2.3:5555", realIP: " 198.51.100.2 ", want: "198.51.100.2"},
+		{name: "forwarded for wins over real ip", remoteAddr: "10.1.2.3:5555", xff: "198.51.100.1", realIP: "198.51.100.2", want: "198.51.100.1"},
+		{name: "garbage real ip", remoteAddr: "10.1.2.3:5555", realIP: "localhost", want: "10.1.2.3"},
+		{name: "untrusted ipv6 neighbour", remoteAddr: "[2001:db8::2]:5555", xff: "198.51.100.1", want: "2001:db8::2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := resolver.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,6 +21,9 @@ func NewCORSMiddleware(cfg *config.CORSConfig) func(http.Handler) http.Handler {
 
 			w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
 			w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+			// Browser clients need these to see the API version and
+			// deprecation notices, and to quote the request ID to support
+			w.Header().Set("Access-Control-Expose-Headers", "API-Version, Deprecation, Sunset, Link, Retry-After, X-RateLimit-Layer, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Request-ID")
 			
 			if cfg.AllowCredentials {
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
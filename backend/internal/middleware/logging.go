@@ -4,14 +4,15 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/correlation"
 	"github.com/cbalite/backend/pkg/logger"
 )
 
 type responseWriter struct {
 	http.ResponseWriter
-	status int
-	size   int
+	request *http.Request
+	status  int
+	size    int
 }
 
 func (rw *responseWriter) WriteHeader(status int) {
@@ -25,16 +26,31 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
+// RequestFor returns the request a response is being written for, when the
+// writer came through the logging middleware, so code that only has the
+// writer can say what failed.
+func RequestFor(w http.ResponseWriter) (*http.Request, bool) {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw.request, true
+	}
+	return nil, false
+}
+
 func NewLoggingMiddleware(log *logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			requestID := uuid.New().String()
-			
-			w.Header().Set("X-Request-ID", requestID)
+			requestID, ok := correlation.Parse(r.Header.Get(correlation.Header))
+			if !ok {
+				requestID = correlation.New()
+			}
+			r = r.WithContext(correlation.NewContext(r.Context(), requestID))
+
+			w.Header().Set(correlation.Header, requestID)
 			
 			wrapped := &responseWriter{
 				ResponseWriter: w,
+				request:        r,
 				status:         http.StatusOK,
 			}
 
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Pool is what admission needs from the database; *database.PostgresDB
+// implements it.
+type Pool interface {
+	// Admit returns an error when no connection came free in time.
+	Admit(ctx context.Context) error
+}
+
+// NewPoolAdmissionMiddleware turns requests away with a 503 while the
+// database pool is exhausted, asking clients to retry after
+// acquireTimeout.
+func NewPoolAdmissionMiddleware(pool Pool, acquireTimeout time.Duration) func(http.Handler) http.Handler {
+	retryAfter := strconv.Itoa(int(math.Max(1, math.Ceil(acquireTimeout.Seconds()))))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := pool.Admit(r.Context()); err != nil {
+				w.Header().Set("Retry-After", retryAfter)
+				respondWithError(w, http.StatusServiceUnavailable, "Server is busy, try again shortly")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
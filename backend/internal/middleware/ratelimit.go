@@ -1,35 +1,42 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
+	"sync"
 	"time"
-
-	"github.com/cbalite/backend/internal/cache"
-	"github.com/cbalite/backend/internal/config"
 )
 
-func NewRateLimitMiddleware(cfg *config.RateLimitConfig, cache *cache.RedisCache) func(http.Handler) http.Handler {
+// maxLocalClients caps the addresses the in-memory limiter tracks in one
+// window. Past it, new addresses share one count, so a flood of addresses
+// can't grow the map without bound.
+const maxLocalClients = 10000
+
+// NewLocalRateLimitMiddleware limits requests per client IP in process memory.
+// It is meant for cheap public endpoints that shouldn't cost a Redis round
+// trip or count against the client's API quota.
+func NewLocalRateLimitMiddleware(requestsPerMinute int, clients *ClientIPResolver) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	windowStart := time.Now()
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			clientIP := getClientIP(r)
-			key := fmt.Sprintf("rate_limit:%s", clientIP)
-			
-			ctx := r.Context()
-			count, err := cache.Increment(ctx, key)
-			if err != nil {
-				next.ServeHTTP(w, r)
-				return
+			mu.Lock()
+			if time.Since(windowStart) >= time.Minute {
+				counts = make(map[string]int)
+				windowStart = time.Now()
 			}
-
-			if count == 1 {
-				cache.Expire(ctx, key, time.Minute)
+			clientIP := clients.ClientIP(r)
+			if _, seen := counts[clientIP]; !seen && len(counts) >= maxLocalClients {
+				clientIP = "overflow"
 			}
+			counts[clientIP]++
+			count := counts[clientIP]
+			mu.Unlock()
 
-			if count > int64(cfg.RequestsPerMinute) {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write([]byte(`{"error":"Rate limit exceeded"}`))
+			if count > requestsPerMinute {
+				w.Header().Set("Retry-After", "60")
+				respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded")
 				return
 			}
 
@@ -37,13 +44,3 @@ func NewRateLimitMiddleware(cfg *config.RateLimitConfig, cache *cache.RedisCache
 		})
 	}
 }
-
-func getClientIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		return xff
-	}
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-	return r.RemoteAddr
-}
\ No newline at end of file
@@ -18,9 +18,7 @@ func NewRecoveryMiddleware(log *logger.Logger) func(http.Handler) http.Handler {
 						"path":  r.URL.Path,
 					}).Error("Panic recovered")
 
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write([]byte(`{"error":"Internal server error"}`))
+					respondWithError(w, http.StatusInternalServerError, "Internal server error")
 				}
 			}()
 
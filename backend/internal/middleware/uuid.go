@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/pkg/httpjson"
+)
+
+// ValidateIDParams rejects requests whose ID path parameters ({teamId},
+// {channelId}, {messageId}, {taskId} and every other {…Id}) aren't UUIDs in
+// canonical form, so malformed IDs get a 400 instead of reaching the
+// database. It must run on a router, where the path has been matched.
+func ValidateIDParams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for param, value := range mux.Vars(r) {
+			if !strings.HasSuffix(param, "Id") || IsCanonicalUUID(value) {
+				continue
+			}
+
+			httpjson.Respond(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid " + param + ": expected a UUID",
+				"code":  "invalid_id",
+				"param": param,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsCanonicalUUID accepts only the hyphenated 36 character form; uuid.Parse
+// would also take braces, urn: prefixes and bare hex. Handlers use it for
+// IDs that arrive in request bodies.
+func IsCanonicalUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(value)
+	return err == nil
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestIsCanonicalUUID(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c11", true},
+		{"6F1C1F7E-3C7A-4D55-9B8E-0A6A2F0E8C11", true},
+		{"00000000-0000-0000-0000-000000000000", true},
+		{"", false},
+		{"6f1c1f7e3c7a4d559b8e0a6a2f0e8c11", false},
+		{"{6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c11}", false},
+		{"urn:uuid:6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c11", false},
+		{"6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c1", false},
+		{"6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c111", false},
+		{"6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c1g", false},
+		{"6f1c1f7e+3c7a-4d55-9b8e-0a6a2f0e8c11", false},
+		{"' OR 1=1 --------------------------", false},
+	}
+	for _, tt := range tests {
+		if got := IsCanonicalUUID(tt.value); got != tt.want {
+			t.Errorf("IsCanonicalUUID(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidateIDParams(t *testing.T) {
+	const id = "6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c11"
+
+	router := mux.NewRouter()
+	router.Use(ValidateIDParams)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Handle("/teams/{teamId}/tasks/{taskId}", ok)
+	router.Handle("/teams/{teamId}/inbound/{kind}", ok)
+	router.Handle("/share/{token}", ok)
+
+	tests := []struct {
+		name      string
+		path      string
+		wantCode  int
+		wantParam string
+	}{
+		{name: "valid IDs", path: "/teams/" + id + "/tasks/" + id, wantCode: http.StatusNoContent},
+		{name: "bad team", path: "/teams/42/tasks/" + id, wantCode: http.StatusBadRequest, wantParam: "teamId"},
+		{name: "bad task", path: "/teams/" + id + "/tasks/" + id[:35], wantCode: http.StatusBadRequest, wantParam: "taskId"},
+		{name: "braced", path: "/teams/%7B" + id + "%7D/tasks/" + id, wantCode: http.StatusBadRequest, wantParam: "teamId"},
+		{name: "other params are left alone", path: "/teams/" + id + "/inbound/statuspage", wantCode: http.StatusNoContent},
+		{name: "tokens are left alone", path: "/share/not-a-uuid", wantCode: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantParam == "" {
+				return
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q isn't JSON: %v", rec.Body, err)
+			}
+			if body["code"] != "invalid_id" || body["param"] != tt.wantParam {
+				t.Errorf("body = %v, want invalid_id for %s", body, tt.wantParam)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/availability"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/websocket"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+const heldBatchSize = 100
+
+// Notifier stores a notification for the user and pushes it to any of their
+// open WebSocket connections. Non-urgent pushes to users outside their
+// working hours are held until their next working day starts. Pushed
+// notifications carry what mobile clients need to show them (see PushMeta).
+type Notifier struct {
+	db       *database.PostgresDB
+	hub      *websocket.Hub
+	linkBase string
+	logger   *logger.Logger
+}
+
+func NewNotifier(db *database.PostgresDB, hub *websocket.Hub, cfg *config.ClientsConfig, logger *logger.Logger) *Notifier {
+	return &Notifier{
+		db:       db,
+		hub:      hub,
+		linkBase: cfg.DeepLinkBase,
+		logger:   logger,
+	}
+}
+
+func (n *Notifier) Notify(ctx context.Context, notification domain.Notification) (domain.Notification, error) {
+	notification.ID = uuid.New().String()
+	notification.CreatedAt = time.Now()
+	if notification.Data == nil {
+		notification.Data = map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(notification.Data)
+	if err != nil {
+		return notification, err
+	}
+
+	var teamID *string
+	if notification.TeamID != "" {
+		teamID = &notification.TeamID
+	}
+
+	if !notification.Urgent {
+		notification.HeldUntil = n.holdUntil(ctx, notification.UserID, notification.CreatedAt)
+	}
+
+	_, err = n.db.ExecContext(ctx, `
+		INSERT INTO notifications (id, user_id, team_id, type, title, body, data, held_until, on_behalf_of, correlation_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11)
+	`, notification.ID, notification.UserID, teamID, notification.Type, notification.Title,
+		notification.Body, data, notification.HeldUntil, notification.OnBehalfOf, correlation.FromContext(ctx), notification.CreatedAt)
+	if err != nil {
+		return notification, err
+	}
+
+	if notification.HeldUntil == nil {
+		n.push(ctx, notification)
+	}
+
+	return notification, nil
+}
+
+func (n *Notifier) push(ctx context.Context, notification domain.Notification) {
+	notification.Push = n.pushMeta(ctx, notification)
+	n.hub.SendToUser(notification.UserID, &websocket.Message{
+		Type:      string(websocket.MessageTypeNotification),
+		UserID:    notification.UserID,
+		Data:      notification,
+		Timestamp: notification.CreatedAt,
+		Meta:      websocket.MetaFromContext(ctx),
+	})
+}
+
+// holdUntil returns when the user's working day next starts if they asked
+// for pushes to be held and are outside working hours, and nil otherwise.
+func (n *Notifier) holdUntil(ctx context.Context, userID string, now time.Time) *time.Time {
+	var hours domain.WorkingHours
+	var weekdays pq.StringArray
+	err := n.db.QueryRowContext(ctx, `
+		SELECT timezone, weekdays, start_time, end_time
+		FROM user_working_hours
+		WHERE user_id = $1 AND hold_notifications = true
+	`, userID).Scan(&hours.Timezone, &weekdays, &hours.StartTime, &hours.EndTime)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			n.logger.WithError(err).Error("Failed to get working hours")
+		}
+		return nil
+	}
+	hours.Weekdays = []string(weekdays)
+
+	schedule, err := availability.New(hours)
+	if err != nil || schedule.Working(now) {
+		return nil
+	}
+	next := schedule.NextStart(now)
+	return &next
+}
+
+// DeliverHeld pushes held notifications whose time has come. It runs as a
+// scheduler job.
+func (n *Notifier) DeliverHeld(ctx context.Context) error {
+	for {
+		rows, err := n.db.QueryContext(ctx, `
+			UPDATE notifications SET held_until = NULL
+			WHERE id IN (
+				SELECT id FROM notifications
+				WHERE held_until <= NOW()
+				ORDER BY held_until
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, user_id, COALESCE(team_id::text, ''), type, title, COALESCE(body, ''), data, read_at, on_behalf_of, created_at,
+				COALESCE(correlation_id, '')
+		`, heldBatchSize)
+		if err != nil {
+			return err
+		}
+
+		delivered := 0
+		for rows.Next() {
+			var notification domain.Notification
+			var data []byte
+			var correlationID string
+			err := rows.Scan(&notification.ID, &notification.UserID, &notification.TeamID, &notification.Type,
+				&notification.Title, &notification.Body, &data, &notification.ReadAt, &notification.OnBehalfOf, &notification.CreatedAt,
+				&correlationID)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			delivered++
+
+			// Already seen in the notification list; no need to push it
+			if notification.ReadAt != nil {
+				continue
+			}
+			if err := json.Unmarshal(data, &notification.Data); err != nil {
+				n.logger.WithError(err).Error("Failed to decode notification data")
+			}
+			// The push carries the ID of whatever raised the notification,
+			// when it was stored with one, rather than this job run's
+			n.push(correlation.NewContext(ctx, correlationID), notification)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		if delivered < heldBatchSize {
+			return nil
+		}
+	}
+}
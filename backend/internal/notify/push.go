@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+// UnreadCount is how many of the user's notifications they haven't read,
+// not counting those still held for their working hours.
+func (n *Notifier) UnreadCount(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := n.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL AND (held_until IS NULL OR held_until <= NOW())
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// pushMeta works out how mobile clients should show the notification. A
+// failed badge count is logged and sent as 0 rather than holding the push
+// back.
+func (n *Notifier) pushMeta(ctx context.Context, notification domain.Notification) *domain.PushMeta {
+	badge, err := n.UnreadCount(ctx, notification.UserID)
+	if err != nil {
+		n.logger.WithError(err).Error("Failed to count unread notifications")
+	}
+
+	priority := domain.PushPriority{APNs: 5, FCM: "normal"}
+	if notification.Urgent {
+		priority = domain.PushPriority{APNs: 10, FCM: "high"}
+	}
+
+	return &domain.PushMeta{
+		CollapseKey: collapseKey(notification),
+		Priority:    priority,
+		Badge:       badge,
+		DeepLink:    n.deepLink(notification),
+	}
+}
+
+// collapseKey groups notifications about the same conversation or
+// resource, most specific first: a thread, then a channel, then a task or
+// escalation. Anything else stands alone.
+func collapseKey(notification domain.Notification) string {
+	for _, field := range []string{"thread_id", "channel_id", "task_id", "escalation_id"} {
+		if id := stringField(notification.Data, field); id != "" {
+			return strings.TrimSuffix(field, "_id") + ":" + id
+		}
+	}
+	return "notification:" + notification.ID
+}
+
+// deepLink is the canonical link to the screen a notification is about:
+// the message (in its thread, if it has one), the task, the escalation, or
+// the notification itself.
+func (n *Notifier) deepLink(notification domain.Notification) string {
+	data := notification.Data
+	channelID, messageID := stringField(data, "channel_id"), stringField(data, "message_id")
+	taskID, escalationID := stringField(data, "task_id"), stringField(data, "escalation_id")
+
+	var path string
+	switch {
+	case channelID != "" && messageID != "":
+		path = "channels/" + channelID + "/messages/" + messageID
+		if threadID := stringField(data, "thread_id"); threadID != "" {
+			path += "?thread=" + threadID
+		}
+	case channelID != "":
+		path = "channels/" + channelID
+	case taskID != "":
+		path = "tasks/" + taskID
+	case escalationID != "":
+		path = "escalations/" + escalationID
+	default:
+		path = "notifications/" + notification.ID
+	}
+	return n.linkBase + path
+}
+
+func stringField(data map[string]interface{}, field string) string {
+	value, _ := data[field].(string)
+	return value
+}
@@ -0,0 +1,50 @@
+package oncall
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// Calendar renders the shifts as an iCalendar (RFC 5545) feed. names maps
+// user IDs to the display names used in event titles.
+func Calendar(schedule domain.OnCallSchedule, shifts []domain.OnCallShift, names map[string]string) string {
+	var b strings.Builder
+	now := time.Now().UTC().Format(icsTimeFormat)
+
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//CBA Lite//On-call//EN\r\n")
+	b.WriteString("CALSCALE:GREGORIAN\r\n")
+	fmt.Fprintf(&b, "X-WR-CALNAME:%s\r\n", icsEscaper.Replace("On call: "+schedule.Name))
+
+	for _, shift := range shifts {
+		name := names[shift.UserID]
+		if name == "" {
+			name = shift.UserID
+		}
+
+		summary := fmt.Sprintf("%s on call (%s)", name, schedule.Name)
+		if shift.IsOverride {
+			summary += " [override]"
+		}
+
+		b.WriteString("BEGIN:VEVENT\r\n")
+		fmt.Fprintf(&b, "UID:%s-%d@cbalite\r\n", schedule.ID, shift.StartsAt.Unix())
+		fmt.Fprintf(&b, "DTSTAMP:%s\r\n", now)
+		fmt.Fprintf(&b, "DTSTART:%s\r\n", shift.StartsAt.UTC().Format(icsTimeFormat))
+		fmt.Fprintf(&b, "DTEND:%s\r\n", shift.EndsAt.UTC().Format(icsTimeFormat))
+		fmt.Fprintf(&b, "SUMMARY:%s\r\n", icsEscaper.Replace(summary))
+		b.WriteString("TRANSP:TRANSPARENT\r\n")
+		b.WriteString("END:VEVENT\r\n")
+	}
+
+	b.WriteString("END:VCALENDAR\r\n")
+	return b.String()
+}
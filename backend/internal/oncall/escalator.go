@@ -0,0 +1,306 @@
+package oncall
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/events"
+	"github.com/cbalite/backend/internal/notify"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+const (
+	SourceManual = "manual"
+	SourceTask   = "task"
+
+	tickInterval = 30 * time.Second
+	batchSize    = 50
+)
+
+// ErrEscalationOpen is returned when the policy already has an unresolved
+// escalation for the same source.
+var ErrEscalationOpen = errors.New("an escalation is already open for this source")
+
+// Escalator runs escalation policies: it notifies the targets of each step
+// and moves on to the next step when nobody acknowledges in time.
+type Escalator struct {
+	db       *database.PostgresDB
+	notifier *notify.Notifier
+	logger   *logger.Logger
+}
+
+func NewEscalator(db *database.PostgresDB, notifier *notify.Notifier, logger *logger.Logger) *Escalator {
+	return &Escalator{
+		db:       db,
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// Start subscribes to task events, so urgent tasks page the on-call, and
+// starts advancing escalations in the background.
+func (e *Escalator) Start(bus *events.Bus) {
+	bus.Subscribe(events.TaskCreated, e.handleTaskEvent)
+	bus.Subscribe(events.TaskUpdated, e.handleTaskEvent)
+
+	go e.run()
+}
+
+func (e *Escalator) run() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := correlation.NewContext(context.Background(), correlation.New())
+		if err := e.advanceDue(ctx); err != nil {
+			correlation.Log(ctx, e.logger).WithError(err).Error("Failed to advance escalations")
+		}
+	}
+}
+
+// Trigger opens an escalation for the policy and notifies its first step.
+// Manual triggers may leave sourceID empty.
+func (e *Escalator) Trigger(ctx context.Context, policy domain.EscalationPolicy, sourceType, sourceID, summary string) (domain.Escalation, error) {
+	if len(policy.Steps) == 0 {
+		return domain.Escalation{}, fmt.Errorf("escalation policy %s has no steps", policy.ID)
+	}
+
+	id := uuid.New().String()
+	if sourceID == "" {
+		sourceID = id
+	}
+
+	nextStepAt := time.Now().Add(time.Duration(policy.Steps[0].DelayMinutes) * time.Minute)
+
+	escalation, err := ScanEscalation(e.db.QueryRowContext(ctx, `
+		INSERT INTO escalations (id, team_id, policy_id, source_type, source_id, summary, status,
+		                         current_step, repeats_done, next_step_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'triggered', 0, 0, $7, NOW(), NOW())
+		ON CONFLICT (policy_id, source_type, source_id) WHERE status <> 'resolved' DO NOTHING
+		RETURNING `+EscalationColumns+`
+	`, id, policy.TeamID, policy.ID, sourceType, sourceID, summary, nextStepAt))
+	if err == sql.ErrNoRows {
+		return escalation, ErrEscalationOpen
+	}
+	if err != nil {
+		return escalation, err
+	}
+
+	e.notifyStep(ctx, policy, escalation)
+	return escalation, nil
+}
+
+// Acknowledge stops a triggered escalation from escalating further.
+func (e *Escalator) Acknowledge(ctx context.Context, escalationID, userID string) (domain.Escalation, error) {
+	return ScanEscalation(e.db.QueryRowContext(ctx, `
+		UPDATE escalations
+		SET status = 'acknowledged', acknowledged_by = $2, acknowledged_at = NOW(), next_step_at = NULL
+		WHERE id = $1 AND status = 'triggered'
+		RETURNING `+EscalationColumns+`
+	`, escalationID, userID))
+}
+
+func (e *Escalator) Resolve(ctx context.Context, escalationID string) (domain.Escalation, error) {
+	return ScanEscalation(e.db.QueryRowContext(ctx, `
+		UPDATE escalations
+		SET status = 'resolved', resolved_at = NOW(), next_step_at = NULL
+		WHERE id = $1 AND status <> 'resolved'
+		RETURNING `+EscalationColumns+`
+	`, escalationID))
+}
+
+func (e *Escalator) resolveSource(ctx context.Context, teamID, sourceType, sourceID string) error {
+	_, err := e.db.ExecContext(ctx, `
+		UPDATE escalations
+		SET status = 'resolved', resolved_at = NOW(), next_step_at = NULL
+		WHERE team_id = $1 AND source_type = $2 AND source_id = $3 AND status <> 'resolved'
+	`, teamID, sourceType, sourceID)
+	return err
+}
+
+// handleTaskEvent pages every policy that opted into urgent tasks when a task
+// becomes urgent, and resolves the task's escalations once it's closed.
+func (e *Escalator) handleTaskEvent(ctx context.Context, event events.Event) {
+	task, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	taskID, _ := task["id"].(string)
+	status, _ := task["status"].(string)
+	priority, _ := task["priority"].(string)
+	title, _ := task["title"].(string)
+	if taskID == "" {
+		return
+	}
+
+	if status == string(domain.TaskStatusDone) || status == string(domain.TaskStatusCancelled) {
+		if err := e.resolveSource(ctx, event.TeamID, SourceTask, taskID); err != nil {
+			e.logger.WithError(err).Error("Failed to resolve task escalations")
+		}
+		return
+	}
+
+	if priority != string(domain.PriorityUrgent) {
+		return
+	}
+
+	// Each policy pages at most once per task, even if the escalation was
+	// resolved by hand and the task is edited again later.
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT `+PolicyColumns+`
+		FROM escalation_policies p
+		WHERE team_id = $1 AND notify_urgent_tasks = true
+		  AND NOT EXISTS (
+		      SELECT 1 FROM escalations
+		      WHERE policy_id = p.id AND source_type = $2 AND source_id = $3
+		  )
+	`, event.TeamID, SourceTask, taskID)
+	if err != nil {
+		e.logger.WithError(err).Error("Failed to load urgent task escalation policies")
+		return
+	}
+
+	var policies []domain.EscalationPolicy
+	for rows.Next() {
+		policy, err := ScanPolicy(rows)
+		if err != nil {
+			e.logger.WithError(err).Error("Failed to scan escalation policy row")
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	rows.Close()
+
+	for _, policy := range policies {
+		_, err := e.Trigger(ctx, policy, SourceTask, taskID, "Urgent task: "+title)
+		if err != nil && err != ErrEscalationOpen {
+			e.logger.WithError(err).Errorf("Failed to trigger escalation policy %s", policy.ID)
+		}
+	}
+}
+
+// advanceDue moves every escalation whose step timed out on to its next step,
+// starting over from the first step while repeats remain.
+func (e *Escalator) advanceDue(ctx context.Context) error {
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+EscalationColumns+`
+		FROM escalations
+		WHERE status = 'triggered' AND next_step_at <= NOW()
+		ORDER BY next_step_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, batchSize)
+	if err != nil {
+		return err
+	}
+
+	var due []domain.Escalation
+	for rows.Next() {
+		escalation, err := ScanEscalation(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, escalation)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, escalation := range due {
+		policy, err := LoadPolicy(ctx, e.db, escalation.PolicyID)
+		if err != nil {
+			e.logger.WithError(err).Errorf("Failed to load escalation policy %s", escalation.PolicyID)
+			continue
+		}
+
+		step, repeats := escalation.CurrentStep+1, escalation.RepeatsDone
+		if step >= len(policy.Steps) {
+			step, repeats = 0, repeats+1
+		}
+
+		if repeats > policy.RepeatCount || len(policy.Steps) == 0 {
+			// Out of steps: leave it triggered but stop paging
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE escalations SET next_step_at = NULL WHERE id = $1
+			`, escalation.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		nextStepAt := time.Now().Add(time.Duration(policy.Steps[step].DelayMinutes) * time.Minute)
+		_, err = tx.ExecContext(ctx, `
+			UPDATE escalations SET current_step = $2, repeats_done = $3, next_step_at = $4 WHERE id = $1
+		`, escalation.ID, step, repeats, nextStepAt)
+		if err != nil {
+			return err
+		}
+
+		escalation.CurrentStep, escalation.RepeatsDone, escalation.NextStepAt = step, repeats, &nextStepAt
+		e.notifyStep(ctx, policy, escalation)
+	}
+
+	return tx.Commit()
+}
+
+// notifyStep notifies everyone targeted by the escalation's current step.
+// Schedules resolve to whoever is on call right now.
+func (e *Escalator) notifyStep(ctx context.Context, policy domain.EscalationPolicy, escalation domain.Escalation) {
+	if escalation.CurrentStep >= len(policy.Steps) {
+		return
+	}
+	step := policy.Steps[escalation.CurrentStep]
+
+	notified := make(map[string]bool)
+	for _, target := range step.Targets {
+		userID := target.ID
+		if target.Type == domain.EscalationTargetSchedule {
+			schedule, err := LoadSchedule(ctx, e.db, target.ID)
+			if err != nil {
+				e.logger.WithError(err).Errorf("Failed to load on-call schedule %s", target.ID)
+				continue
+			}
+			userID, _ = OnCallAt(schedule, time.Now())
+		}
+
+		if userID == "" || notified[userID] {
+			continue
+		}
+		notified[userID] = true
+
+		_, err := e.notifier.Notify(ctx, domain.Notification{
+			UserID: userID,
+			TeamID: escalation.TeamID,
+			Type:   "escalation",
+			Urgent: true,
+			Title:  "Escalation: " + escalation.Summary,
+			Body:   fmt.Sprintf("Step %d of %d in %s. Acknowledge to stop escalating.", escalation.CurrentStep+1, len(policy.Steps), policy.Name),
+			Data: map[string]interface{}{
+				"escalation_id": escalation.ID,
+				"policy_id":     policy.ID,
+				"source_type":   escalation.SourceType,
+				"source_id":     escalation.SourceID,
+				"step":          escalation.CurrentStep,
+			},
+		})
+		if err != nil {
+			e.logger.WithError(err).Errorf("Failed to notify user %s of escalation %s", userID, escalation.ID)
+		}
+	}
+}
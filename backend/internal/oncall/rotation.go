@@ -0,0 +1,103 @@
+package oncall
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+// RotationUser returns who the rotation puts on call at the given time,
+// ignoring overrides. Rotations cycle through the members in order, handing
+// off every RotationHours starting at HandoffAt.
+func RotationUser(schedule domain.OnCallSchedule, at time.Time) string {
+	if len(schedule.Members) == 0 || schedule.RotationHours <= 0 {
+		return ""
+	}
+
+	rotation := time.Duration(schedule.RotationHours) * time.Hour
+	elapsed := at.Sub(schedule.HandoffAt)
+
+	turn := int(elapsed / rotation)
+	if elapsed < 0 && elapsed%rotation != 0 {
+		turn--
+	}
+
+	n := len(schedule.Members)
+	return schedule.Members[((turn%n)+n)%n]
+}
+
+// OnCallAt returns who is on call at the given time. Active overrides win
+// over the rotation; when overrides overlap, the most recently created one
+// applies.
+func OnCallAt(schedule domain.OnCallSchedule, at time.Time) (string, *domain.OnCallOverride) {
+	var active *domain.OnCallOverride
+	for i := range schedule.Overrides {
+		override := &schedule.Overrides[i]
+		if at.Before(override.StartsAt) || !at.Before(override.EndsAt) {
+			continue
+		}
+		if active == nil || override.CreatedAt.After(active.CreatedAt) {
+			active = override
+		}
+	}
+
+	if active != nil {
+		return active.UserID, active
+	}
+	return RotationUser(schedule, at), nil
+}
+
+// Shifts lists who is on call between from and to, merging consecutive
+// periods covered by the same user.
+func Shifts(schedule domain.OnCallSchedule, from, to time.Time) []domain.OnCallShift {
+	if !from.Before(to) || len(schedule.Members) == 0 || schedule.RotationHours <= 0 {
+		return []domain.OnCallShift{}
+	}
+
+	// Who is on call can only change at a handoff or at an override boundary
+	boundaries := []time.Time{from, to}
+
+	rotation := time.Duration(schedule.RotationHours) * time.Hour
+	handoff := schedule.HandoffAt.Add(rotation * time.Duration(int(from.Sub(schedule.HandoffAt)/rotation)))
+	for ; handoff.Before(to); handoff = handoff.Add(rotation) {
+		if handoff.After(from) {
+			boundaries = append(boundaries, handoff)
+		}
+	}
+
+	for _, override := range schedule.Overrides {
+		for _, t := range []time.Time{override.StartsAt, override.EndsAt} {
+			if t.After(from) && t.Before(to) {
+				boundaries = append(boundaries, t)
+			}
+		}
+	}
+
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	shifts := []domain.OnCallShift{}
+	for i := 0; i < len(boundaries)-1; i++ {
+		start, end := boundaries[i], boundaries[i+1]
+		if !start.Before(end) {
+			continue
+		}
+
+		userID, override := OnCallAt(schedule, start)
+		isOverride := override != nil
+
+		if last := len(shifts) - 1; last >= 0 && shifts[last].UserID == userID && shifts[last].IsOverride == isOverride {
+			shifts[last].EndsAt = end
+			continue
+		}
+
+		shifts = append(shifts, domain.OnCallShift{
+			UserID:     userID,
+			StartsAt:   start,
+			EndsAt:     end,
+			IsOverride: isOverride,
+		})
+	}
+
+	return shifts
+}
@@ -0,0 +1,152 @@
+package oncall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+)
+
+// ScheduleColumns is the column list ScanSchedule expects, in order.
+const ScheduleColumns = `id, team_id, name, rotation_hours, handoff_at, created_by, created_at, updated_at`
+
+// PolicyColumns is the column list ScanPolicy expects, in order.
+const PolicyColumns = `id, team_id, name, steps, repeat_count, notify_urgent_tasks, created_by, created_at, updated_at`
+
+// EscalationColumns is the column list ScanEscalation expects, in order.
+const EscalationColumns = `id, team_id, policy_id, source_type, source_id, summary, status, current_step,
+	repeats_done, next_step_at, acknowledged_by, acknowledged_at, resolved_at, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func ScanSchedule(row scanner) (domain.OnCallSchedule, error) {
+	var s domain.OnCallSchedule
+	err := row.Scan(&s.ID, &s.TeamID, &s.Name, &s.RotationHours, &s.HandoffAt, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+func ScanPolicy(row scanner) (domain.EscalationPolicy, error) {
+	var p domain.EscalationPolicy
+	var steps []byte
+
+	err := row.Scan(&p.ID, &p.TeamID, &p.Name, &steps, &p.RepeatCount, &p.NotifyUrgentTasks,
+		&p.CreatedBy, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return p, err
+	}
+
+	if err := json.Unmarshal(steps, &p.Steps); err != nil {
+		return p, fmt.Errorf("failed to decode policy steps: %w", err)
+	}
+
+	return p, nil
+}
+
+func ScanEscalation(row scanner) (domain.Escalation, error) {
+	var e domain.Escalation
+	err := row.Scan(&e.ID, &e.TeamID, &e.PolicyID, &e.SourceType, &e.SourceID, &e.Summary, &e.Status,
+		&e.CurrentStep, &e.RepeatsDone, &e.NextStepAt, &e.AcknowledgedBy, &e.AcknowledgedAt,
+		&e.ResolvedAt, &e.CreatedAt, &e.UpdatedAt)
+	return e, err
+}
+
+// LoadScheduleDetails fills in the schedule's members and the overrides that
+// haven't ended yet.
+func LoadScheduleDetails(ctx context.Context, db *database.PostgresDB, schedule *domain.OnCallSchedule) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT user_id FROM oncall_schedule_members WHERE schedule_id = $1 ORDER BY position
+	`, schedule.ID)
+	if err != nil {
+		return err
+	}
+
+	schedule.Members = []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return err
+		}
+		schedule.Members = append(schedule.Members, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT id, schedule_id, user_id, starts_at, ends_at, created_by, created_at
+		FROM oncall_overrides
+		WHERE schedule_id = $1 AND ends_at > NOW()
+		ORDER BY starts_at
+	`, schedule.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	schedule.Overrides = []domain.OnCallOverride{}
+	for rows.Next() {
+		var o domain.OnCallOverride
+		if err := rows.Scan(&o.ID, &o.ScheduleID, &o.UserID, &o.StartsAt, &o.EndsAt, &o.CreatedBy, &o.CreatedAt); err != nil {
+			return err
+		}
+		schedule.Overrides = append(schedule.Overrides, o)
+	}
+
+	return rows.Err()
+}
+
+// LoadSchedule loads a schedule with its members and upcoming overrides.
+func LoadSchedule(ctx context.Context, db *database.PostgresDB, scheduleID string) (domain.OnCallSchedule, error) {
+	schedule, err := ScanSchedule(db.QueryRowContext(ctx, `
+		SELECT `+ScheduleColumns+` FROM oncall_schedules WHERE id = $1
+	`, scheduleID))
+	if err != nil {
+		return schedule, err
+	}
+
+	err = LoadScheduleDetails(ctx, db, &schedule)
+	return schedule, err
+}
+
+func LoadPolicy(ctx context.Context, db *database.PostgresDB, policyID string) (domain.EscalationPolicy, error) {
+	return ScanPolicy(db.QueryRowContext(ctx, `
+		SELECT `+PolicyColumns+` FROM escalation_policies WHERE id = $1
+	`, policyID))
+}
+
+// ValidatePolicy checks the policy's steps. Targets are checked against the
+// team by the caller.
+func ValidatePolicy(policy domain.EscalationPolicy) error {
+	if len(policy.Steps) == 0 {
+		return fmt.Errorf("at least one escalation step is required")
+	}
+
+	for i, step := range policy.Steps {
+		if step.DelayMinutes < 1 {
+			return fmt.Errorf("step %d: delay_minutes must be at least 1", i+1)
+		}
+		if len(step.Targets) == 0 {
+			return fmt.Errorf("step %d: at least one target is required", i+1)
+		}
+		for _, target := range step.Targets {
+			if target.Type != domain.EscalationTargetUser && target.Type != domain.EscalationTargetSchedule {
+				return fmt.Errorf("step %d: unsupported target type %q", i+1, target.Type)
+			}
+			if target.ID == "" {
+				return fmt.Errorf("step %d: target id is required", i+1)
+			}
+		}
+	}
+
+	if policy.RepeatCount < 0 {
+		return fmt.Errorf("repeat_count cannot be negative")
+	}
+
+	return nil
+}
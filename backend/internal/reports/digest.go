@@ -0,0 +1,75 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// listLimit caps each section of the posted digest; the counts stay exact.
+const listLimit = 10
+
+type Item struct {
+	Title    string
+	Assignee string
+	Priority string
+	DueDate  *time.Time
+}
+
+// Digest is the task status a report posts: everything still open, the open
+// tasks past their due date, and what was completed since the last report.
+type Digest struct {
+	Name           string
+	Open           int
+	Overdue        []Item
+	Completed      []Item
+	CompletedSince time.Time
+	GeneratedAt    time.Time
+	Location       *time.Location
+}
+
+func (d Digest) day(t time.Time) string {
+	return t.In(d.Location).Format("Mon 2 Jan")
+}
+
+// Render formats the digest as a message card.
+func (d Digest) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** — %s\n", d.Name, d.day(d.GeneratedAt))
+	fmt.Fprintf(&b, "📋 Open: %d · ⚠️ Overdue: %d · ✅ Completed since %s: %d\n",
+		d.Open, len(d.Overdue), d.day(d.CompletedSince), len(d.Completed))
+
+	if len(d.Overdue) > 0 {
+		b.WriteString("\n**Overdue**\n")
+		d.writeItems(&b, d.Overdue, true)
+	}
+	if len(d.Completed) > 0 {
+		b.WriteString("\n**Completed**\n")
+		d.writeItems(&b, d.Completed, false)
+	}
+	if d.Open == 0 && len(d.Completed) == 0 {
+		b.WriteString("\nNothing on the board.")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (d Digest) writeItems(b *strings.Builder, items []Item, showDue bool) {
+	for i, item := range items {
+		if i == listLimit {
+			fmt.Fprintf(b, "…and %d more\n", len(items)-listLimit)
+			break
+		}
+		fmt.Fprintf(b, "• %s", item.Title)
+		if item.Assignee != "" {
+			fmt.Fprintf(b, " — @%s", item.Assignee)
+		}
+		if showDue && item.DueDate != nil {
+			fmt.Fprintf(b, ", due %s", d.day(*item.DueDate))
+		}
+		if item.Priority == "urgent" || item.Priority == "high" {
+			fmt.Fprintf(b, " (%s)", item.Priority)
+		}
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,244 @@
+package fakes
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Channels struct {
+	db *DB
+}
+
+var _ repository.Channels = Channels{}
+
+func (r Channels) Create(ctx context.Context, channel *domain.Channel) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	if _, ok := r.db.channelNamed(channel.TeamID, channel.Name); ok {
+		return repository.ErrDuplicate
+	}
+	now := time.Now()
+	channel.CreatedAt, channel.UpdatedAt = now, now
+	r.db.channels[channel.ID] = *channel
+	r.db.joinChannel(channel.ID, channel.CreatedBy, now)
+	return nil
+}
+
+func (r Channels) Get(ctx context.Context, id string) (repository.Channel, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return repository.Channel{}, r.db.Err
+	}
+
+	channel, ok := r.db.channels[id]
+	if !ok {
+		return repository.Channel{}, sql.ErrNoRows
+	}
+	return repository.Channel{Channel: channel}, nil
+}
+
+func (r Channels) ForTeam(ctx context.Context, teamID, userID string) ([]repository.Channel, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var channels []repository.Channel
+	for _, channel := range r.db.channels {
+		if channel.TeamID != teamID {
+			continue
+		}
+		if _, joined := r.db.channelMembers[channel.ID][userID]; channel.Type == domain.ChannelTypeDirect && !joined {
+			continue
+		}
+		channels = append(channels, repository.Channel{Channel: channel})
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	return channels, nil
+}
+
+func (r Channels) Access(ctx context.Context, channelID, userID string) (repository.ChannelAccess, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return repository.ChannelAccess{}, r.db.Err
+	}
+
+	channel, ok := r.db.channels[channelID]
+	if !ok {
+		return repository.ChannelAccess{}, sql.ErrNoRows
+	}
+	if _, ok := r.db.member(channel.TeamID, userID); !ok {
+		return repository.ChannelAccess{}, sql.ErrNoRows
+	}
+	if _, joined := r.db.channelMembers[channelID][userID]; channel.Type == domain.ChannelTypeDirect && !joined {
+		return repository.ChannelAccess{}, sql.ErrNoRows
+	}
+	return repository.ChannelAccess{
+		TeamID:       channel.TeamID,
+		OriginTeamID: channel.TeamID,
+		ArchivedAt:   channel.ArchivedAt,
+	}, nil
+}
+
+func (r Channels) Update(ctx context.Context, id string, update domain.UpdateChannel) (domain.Channel, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Channel{}, r.db.Err
+	}
+
+	channel, ok := r.db.channels[id]
+	if !ok {
+		return channel, sql.ErrNoRows
+	}
+	if update.Name != nil {
+		if other, ok := r.db.channelNamed(channel.TeamID, *update.Name); ok && other.ID != id {
+			return channel, repository.ErrDuplicate
+		}
+		channel.Name = *update.Name
+	}
+	if update.Description != nil {
+		channel.Description = *update.Description
+	}
+	if update.IsPrivate != nil {
+		channel.IsPrivate = *update.IsPrivate
+	}
+	if update.IsDefault != nil {
+		channel.IsDefault = *update.IsDefault
+	}
+	if update.ClassificationEnabled != nil {
+		channel.ClassificationEnabled = *update.ClassificationEnabled
+	}
+	channel.UpdatedAt = time.Now()
+	r.db.channels[id] = channel
+	return channel, nil
+}
+
+func (r Channels) Archive(ctx context.Context, id string, undo *domain.UndoableAction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	channel, ok := r.db.channels[id]
+	if !ok || channel.ArchivedAt != nil {
+		return repository.ErrConflict
+	}
+	if err := r.db.recordUndo(undo, struct{}{}); err != nil {
+		return err
+	}
+	now := time.Now()
+	channel.ArchivedAt = &now
+	r.db.channels[id] = channel
+	return nil
+}
+
+func (r Channels) Unarchive(ctx context.Context, id string) (domain.Channel, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Channel{}, r.db.Err
+	}
+
+	return r.db.unarchive(id)
+}
+
+func (r Channels) Restore(ctx context.Context, undo domain.UndoableAction) (domain.Channel, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Channel{}, r.db.Err
+	}
+
+	if err := r.db.undoAvailable(undo.ID); err != nil {
+		return domain.Channel{}, err
+	}
+	channel, err := r.db.unarchive(undo.ResourceID)
+	if err != nil {
+		return channel, err
+	}
+	r.db.markUndone(undo.ID)
+	return channel, nil
+}
+
+func (db *DB) unarchive(id string) (domain.Channel, error) {
+	channel, ok := db.channels[id]
+	if !ok || channel.ArchivedAt == nil {
+		return domain.Channel{}, repository.ErrConflict
+	}
+	channel.ArchivedAt = nil
+	db.channels[id] = channel
+	return channel, nil
+}
+
+func (r Channels) DefaultNames(ctx context.Context, teamID string) ([]string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var names []string
+	for _, channel := range r.db.channels {
+		if channel.TeamID == teamID && channel.IsDefault && !channel.IsPrivate {
+			names = append(names, channel.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (r Channels) OpenDirect(ctx context.Context, teamID, userA, userB string) (string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return "", r.db.Err
+	}
+
+	users := []string{userA, userB}
+	sort.Strings(users)
+	name := "dm-" + users[0] + "-" + users[1]
+
+	channel, ok := r.db.channelNamed(teamID, name)
+	if !ok {
+		now := time.Now()
+		channel = domain.Channel{
+			ID:        uuid.New().String(),
+			TeamID:    teamID,
+			Name:      name,
+			Type:      domain.ChannelTypeDirect,
+			IsPrivate: true,
+			CreatedBy: userA,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		r.db.channels[channel.ID] = channel
+	}
+	for _, userID := range users {
+		r.db.joinChannel(channel.ID, userID, time.Now())
+	}
+	return channel.ID, nil
+}
+
+func (db *DB) channelNamed(teamID, name string) (domain.Channel, bool) {
+	for _, channel := range db.channels {
+		if channel.TeamID == teamID && channel.Name == name {
+			return channel, true
+		}
+	}
+	return domain.Channel{}, false
+}
@@ -0,0 +1,166 @@
+// Package fakes provides in-memory repositories for tests.
+package fakes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+// DB holds everything the repositories store, in maps. It enforces the
+// unique keys the database does, but keeps no channel shares: users only
+// reach channels through their own teams. The zero value is not usable;
+// call New.
+type DB struct {
+	// Err, when set, is returned by every call, to exercise the handlers'
+	// error paths.
+	Err error
+
+	mu             sync.Mutex
+	users          map[string]domain.User
+	teams          map[string]domain.Team
+	members        []domain.TeamMember
+	channels       map[string]domain.Channel
+	channelMembers map[string]map[string]time.Time
+	messages       []message
+	labels         map[string]repository.MessageLabels
+	tasks          []task
+	taskEvents     []taskEvent
+	comments       []domain.TaskComment
+	undos          map[string]domain.UndoableAction
+	policies       map[string]domain.MessagePolicy
+	welcome        map[string]domain.TeamWelcomeSettings
+	outOfOffice    map[string]domain.OutOfOffice
+	workingHours   map[string]domain.WorkingHours
+}
+
+type message struct {
+	repository.NewMessage
+	IsPinned bool
+}
+
+type task struct {
+	domain.Task
+	Deleted bool
+}
+
+type taskEvent struct {
+	domain.TaskEvent
+	TeamID string
+}
+
+func New() *DB {
+	return &DB{
+		users:          make(map[string]domain.User),
+		teams:          make(map[string]domain.Team),
+		channels:       make(map[string]domain.Channel),
+		channelMembers: make(map[string]map[string]time.Time),
+		labels:         make(map[string]repository.MessageLabels),
+		undos:          make(map[string]domain.UndoableAction),
+		policies:       make(map[string]domain.MessagePolicy),
+		welcome:        make(map[string]domain.TeamWelcomeSettings),
+		outOfOffice:    make(map[string]domain.OutOfOffice),
+		workingHours:   make(map[string]domain.WorkingHours),
+	}
+}
+
+// Repositories returns repositories that share db.
+func (db *DB) Repositories() repository.Repositories {
+	return repository.Repositories{
+		Users:    Users{db: db},
+		Teams:    Teams{db: db},
+		Channels: Channels{db: db},
+		Messages: Messages{db: db},
+		Tasks:    Tasks{db: db},
+		Undos:    Undos{db: db},
+	}
+}
+
+// SetMessagePolicy stands in for a team owner changing the policy.
+func (db *DB) SetMessagePolicy(policy domain.MessagePolicy) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.policies[policy.TeamID] = policy
+}
+
+// SetWelcomeSettings stands in for a team admin setting up the welcome
+// message.
+func (db *DB) SetWelcomeSettings(settings domain.TeamWelcomeSettings) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.welcome[settings.TeamID] = settings
+}
+
+// SetOutOfOffice stands in for a user going away. It counts as active
+// between StartsAt and EndsAt.
+func (db *DB) SetOutOfOffice(ooo domain.OutOfOffice) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.outOfOffice[ooo.UserID] = ooo
+}
+
+func (db *DB) SetWorkingHours(userID string, hours domain.WorkingHours) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.workingHours[userID] = hours
+}
+
+// SetLabels stands in for the classifier labelling a message.
+func (db *DB) SetLabels(messageID string, labels repository.MessageLabels) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.labels[messageID] = labels
+}
+
+// undoAvailable fails when the action was already undone or its window has
+// closed. Restores check it first and call markUndone once nothing else can
+// fail, as the database does in one transaction.
+func (db *DB) undoAvailable(id string) error {
+	action, ok := db.undos[id]
+	if !ok || action.UndoneAt != nil || !time.Now().Before(action.ExpiresAt) {
+		return repository.ErrUndoUnavailable
+	}
+	return nil
+}
+
+func (db *DB) markUndone(id string) {
+	action := db.undos[id]
+	now := time.Now()
+	action.UndoneAt = &now
+	db.undos[id] = action
+}
+
+func (db *DB) recordUndo(undo *domain.UndoableAction, snapshot interface{}) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	undo.Snapshot = data
+	db.undos[undo.ID] = *undo
+	return nil
+}
+
+type Undos struct {
+	db *DB
+}
+
+var _ repository.Undos = Undos{}
+
+func (r Undos) Get(ctx context.Context, id string) (domain.UndoableAction, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.UndoableAction{}, r.db.Err
+	}
+
+	action, ok := r.db.undos[id]
+	if !ok {
+		return action, sql.ErrNoRows
+	}
+	return action, nil
+}
@@ -0,0 +1,163 @@
+package fakes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Messages struct {
+	db *DB
+}
+
+var _ repository.Messages = Messages{}
+
+func (r Messages) Create(ctx context.Context, msg *repository.NewMessage) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	if msg.ClientID != "" {
+		for _, existing := range r.db.messages {
+			if existing.UserID == msg.UserID && existing.ClientID == msg.ClientID {
+				return repository.ErrDuplicate
+			}
+		}
+	}
+	now := time.Now()
+	msg.CreatedAt, msg.UpdatedAt = now, now
+	r.db.messages = append(r.db.messages, message{NewMessage: *msg})
+	return nil
+}
+
+func (r Messages) Get(ctx context.Context, id string) (domain.Message, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Message{}, r.db.Err
+	}
+
+	i, ok := r.db.message(id)
+	if !ok || r.db.messages[i].IsDeleted {
+		return domain.Message{}, sql.ErrNoRows
+	}
+	return r.db.messages[i].Message, nil
+}
+
+func (r Messages) List(ctx context.Context, channelID string, query repository.MessageQuery) ([]repository.ListedMessage, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var listed []repository.ListedMessage
+	// Newest first, which is the reverse of the order they were posted
+	for i := len(r.db.messages) - 1; i >= 0 && len(listed) < query.Limit; i-- {
+		msg := r.db.messages[i]
+		labels, labelled := r.db.labels[msg.ID]
+		switch {
+		case msg.ChannelID != channelID || msg.IsDeleted,
+			query.Search != "" && !strings.Contains(strings.ToLower(msg.Content), strings.ToLower(query.Search)),
+			query.Urgency != "" && (!labelled || labels.Urgency != query.Urgency),
+			query.Sentiment != "" && (!labelled || labels.Sentiment != query.Sentiment),
+			query.Thread != "" && (msg.ReplyToID == nil || *msg.ReplyToID != query.Thread),
+			query.Until != nil && msg.CreatedAt.After(*query.Until):
+			continue
+		}
+
+		entry := repository.ListedMessage{Message: msg.Message, IsPinned: msg.IsPinned, Sender: r.db.users[msg.UserID]}
+		if labelled {
+			entry.Labels = &labels
+		}
+		if team, ok := r.db.teams[msg.OriginTeamID]; ok {
+			entry.OriginTeam = &team
+		}
+		listed = append(listed, entry)
+	}
+	return listed, nil
+}
+
+func (r Messages) Edit(ctx context.Context, id, content string) (time.Time, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return time.Time{}, r.db.Err
+	}
+
+	i, ok := r.db.message(id)
+	if !ok || r.db.messages[i].IsDeleted {
+		return time.Time{}, sql.ErrNoRows
+	}
+	msg := &r.db.messages[i]
+	msg.Content = content
+	msg.IsEdited = true
+	msg.UpdatedAt = time.Now()
+	return msg.UpdatedAt, nil
+}
+
+func (r Messages) Delete(ctx context.Context, id string, undo *domain.UndoableAction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	i, ok := r.db.message(id)
+	if !ok || r.db.messages[i].IsDeleted {
+		return sql.ErrNoRows
+	}
+	msg := &r.db.messages[i]
+	snapshot := repository.DeletedMessage{ChannelID: msg.ChannelID, Content: msg.Content}
+	if err := r.db.recordUndo(undo, snapshot); err != nil {
+		return err
+	}
+	msg.IsDeleted = true
+	msg.Content = ""
+	msg.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r Messages) Restore(ctx context.Context, undo domain.UndoableAction) (domain.Message, error) {
+	var snapshot repository.DeletedMessage
+	if err := json.Unmarshal(undo.Snapshot, &snapshot); err != nil {
+		return domain.Message{}, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Message{}, r.db.Err
+	}
+
+	if err := r.db.undoAvailable(undo.ID); err != nil {
+		return domain.Message{}, err
+	}
+	i, ok := r.db.message(undo.ResourceID)
+	if !ok || !r.db.messages[i].IsDeleted {
+		return domain.Message{}, repository.ErrConflict
+	}
+	r.db.markUndone(undo.ID)
+
+	msg := &r.db.messages[i]
+	msg.IsDeleted = false
+	msg.Content = snapshot.Content
+	msg.UpdatedAt = time.Now()
+	return msg.Message, nil
+}
+
+func (db *DB) message(id string) (int, bool) {
+	for i, msg := range db.messages {
+		if msg.ID == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,272 @@
+package fakes
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Tasks struct {
+	db *DB
+}
+
+var _ repository.Tasks = Tasks{}
+
+func (r Tasks) Create(ctx context.Context, t *domain.Task, event domain.TaskEvent) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	now := time.Now()
+	t.CreatedAt, t.UpdatedAt = now, now
+	r.db.tasks = append(r.db.tasks, task{Task: *t})
+	event.TaskID = t.ID
+	return r.db.addTaskEvent(t.TeamID, event)
+}
+
+func (r Tasks) Get(ctx context.Context, id string) (domain.Task, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Task{}, r.db.Err
+	}
+
+	i, ok := r.db.task(id)
+	if !ok {
+		return domain.Task{}, sql.ErrNoRows
+	}
+	return r.db.tasks[i].Task, nil
+}
+
+func (r Tasks) ForTeam(ctx context.Context, teamID string) ([]domain.Task, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var tasks []domain.Task
+	for i := len(r.db.tasks) - 1; i >= 0; i-- {
+		if t := r.db.tasks[i]; t.TeamID == teamID && !t.Deleted {
+			tasks = append(tasks, t.Task)
+		}
+	}
+	return tasks, nil
+}
+
+// Update holds the store while record runs, so record mustn't call back into
+// the repositories.
+func (r Tasks) Update(ctx context.Context, id string, update repository.TaskUpdate,
+	record func(before, after domain.Task) (*domain.TaskEvent, error)) (before, after domain.Task, err error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return before, after, r.db.Err
+	}
+
+	i, ok := r.db.task(id)
+	if !ok {
+		return before, after, sql.ErrNoRows
+	}
+	before = r.db.tasks[i].Task
+	after = before
+
+	if update.Title != nil {
+		after.Title = *update.Title
+	}
+	if update.Description != nil {
+		after.Description = *update.Description
+	}
+	if update.Status != nil {
+		after.Status = domain.TaskStatus(*update.Status)
+		after.CompletedAt = nil
+		if after.Status == domain.TaskStatusDone {
+			now := time.Now()
+			after.CompletedAt = &now
+		}
+	}
+	if update.Priority != nil {
+		after.Priority = domain.Priority(*update.Priority)
+	}
+	if update.AssigneeID != nil {
+		after.AssigneeID = nil
+		if *update.AssigneeID != "" {
+			assigneeID := *update.AssigneeID
+			after.AssigneeID = &assigneeID
+		}
+	}
+	if update.DueDate != nil {
+		dueDate := *update.DueDate
+		after.DueDate = &dueDate
+	} else if update.ClearDueDate {
+		after.DueDate = nil
+	}
+	if !update.Empty() {
+		after.UpdatedAt = time.Now()
+	}
+
+	event, err := record(before, after)
+	if err != nil {
+		return before, after, err
+	}
+	if event != nil {
+		event.TaskID = id
+		if err := r.db.addTaskEvent(after.TeamID, *event); err != nil {
+			return before, after, err
+		}
+	}
+	r.db.tasks[i].Task = after
+	return before, after, nil
+}
+
+func (r Tasks) Delete(ctx context.Context, id string, undo *domain.UndoableAction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	i, ok := r.db.task(id)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := r.db.recordUndo(undo, struct{}{}); err != nil {
+		return err
+	}
+	r.db.tasks[i].Deleted = true
+	return nil
+}
+
+func (r Tasks) Restore(ctx context.Context, undo domain.UndoableAction) (domain.Task, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Task{}, r.db.Err
+	}
+
+	if err := r.db.undoAvailable(undo.ID); err != nil {
+		return domain.Task{}, err
+	}
+	for i, t := range r.db.tasks {
+		if t.ID == undo.ResourceID && t.Deleted {
+			r.db.markUndone(undo.ID)
+			r.db.tasks[i].Deleted = false
+			return t.Task, nil
+		}
+	}
+	return domain.Task{}, repository.ErrConflict
+}
+
+func (r Tasks) Events(ctx context.Context, taskID string) ([]domain.TaskEvent, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var events []domain.TaskEvent
+	for _, event := range r.db.taskEvents {
+		if event.TaskID == taskID {
+			events = append(events, r.db.withRevert(event.TaskEvent))
+		}
+	}
+	return events, nil
+}
+
+func (r Tasks) LatestUndoable(ctx context.Context, taskID string) (domain.TaskEvent, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.TaskEvent{}, r.db.Err
+	}
+
+	for i := len(r.db.taskEvents) - 1; i >= 0; i-- {
+		event := r.db.withRevert(r.db.taskEvents[i].TaskEvent)
+		if event.TaskID == taskID && event.Type == domain.TaskEventUpdated && event.RevertedBy == nil {
+			return event, nil
+		}
+	}
+	return domain.TaskEvent{}, sql.ErrNoRows
+}
+
+func (r Tasks) Comments(ctx context.Context, taskID string) ([]domain.TaskComment, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var comments []domain.TaskComment
+	for _, comment := range r.db.comments {
+		if comment.TaskID == taskID {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (r Tasks) AddComment(ctx context.Context, comment *domain.TaskComment) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	if comment.MessageID != nil {
+		for _, existing := range r.db.comments {
+			if existing.MessageID != nil && *existing.MessageID == *comment.MessageID {
+				return sql.ErrNoRows
+			}
+		}
+	}
+	now := time.Now()
+	comment.ID = uuid.New().String()
+	comment.Username = r.db.users[comment.UserID].Username
+	comment.CreatedAt, comment.UpdatedAt = now, now
+	r.db.comments = append(r.db.comments, *comment)
+	return nil
+}
+
+// task finds a task that isn't deleted.
+func (db *DB) task(id string) (int, bool) {
+	for i, t := range db.tasks {
+		if t.ID == id && !t.Deleted {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// addTaskEvent fails with ErrDuplicate when the event reverts one that was
+// already reverted, as the unique index on reverts_event_id does.
+func (db *DB) addTaskEvent(teamID string, event domain.TaskEvent) error {
+	if event.RevertsEventID != nil {
+		for _, existing := range db.taskEvents {
+			if existing.RevertsEventID != nil && *existing.RevertsEventID == *event.RevertsEventID {
+				return repository.ErrDuplicate
+			}
+		}
+	}
+	event.ID = uuid.New().String()
+	event.Username = db.users[event.ActorID].Username
+	event.CreatedAt = time.Now()
+	db.taskEvents = append(db.taskEvents, taskEvent{TaskEvent: event, TeamID: teamID})
+	return nil
+}
+
+// withRevert fills in the event that undid event, if any.
+func (db *DB) withRevert(event domain.TaskEvent) domain.TaskEvent {
+	for _, other := range db.taskEvents {
+		if other.RevertsEventID != nil && *other.RevertsEventID == event.ID {
+			revertedBy := other.ID
+			event.RevertedBy = &revertedBy
+		}
+	}
+	return event
+}
@@ -0,0 +1,277 @@
+package fakes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Teams struct {
+	db *DB
+}
+
+var _ repository.Teams = Teams{}
+
+func (r Teams) Create(ctx context.Context, team *domain.Team) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	now := time.Now()
+	team.CreatedAt, team.UpdatedAt = now, now
+	team.IsActive = true
+	r.db.teams[team.ID] = *team
+	r.db.addMember(team.ID, team.OwnerID, string(domain.TeamRoleOwner), now)
+
+	general := domain.Channel{
+		ID:          uuid.New().String(),
+		TeamID:      team.ID,
+		Name:        "general",
+		Description: "General discussion",
+		Type:        domain.ChannelTypeGeneral,
+		IsDefault:   true,
+		CreatedBy:   team.OwnerID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.db.channels[general.ID] = general
+	r.db.joinChannel(general.ID, team.OwnerID, now)
+	return nil
+}
+
+func (r Teams) Get(ctx context.Context, id string) (domain.Team, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.Team{}, r.db.Err
+	}
+
+	team, ok := r.db.teams[id]
+	if !ok {
+		return team, sql.ErrNoRows
+	}
+	return team, nil
+}
+
+func (r Teams) ForUser(ctx context.Context, userID string) ([]repository.Membership, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var memberships []repository.Membership
+	for _, member := range r.db.members {
+		if member.UserID == userID {
+			memberships = append(memberships, repository.Membership{
+				Team:     r.db.teams[member.TeamID],
+				Role:     member.Role,
+				JoinedAt: member.JoinedAt,
+			})
+		}
+	}
+	sort.SliceStable(memberships, func(i, j int) bool { return memberships[i].Team.Name < memberships[j].Team.Name })
+	return memberships, nil
+}
+
+func (r Teams) Membership(ctx context.Context, teamID, userID string) (repository.Membership, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return repository.Membership{}, r.db.Err
+	}
+
+	member, ok := r.db.member(teamID, userID)
+	if !ok {
+		return repository.Membership{}, sql.ErrNoRows
+	}
+	return repository.Membership{Team: r.db.teams[teamID], Role: member.Role, JoinedAt: member.JoinedAt}, nil
+}
+
+func (r Teams) Role(ctx context.Context, teamID, userID string) (string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return "", r.db.Err
+	}
+
+	member, ok := r.db.member(teamID, userID)
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return member.Role, nil
+}
+
+func (r Teams) Members(ctx context.Context, teamID string) ([]repository.Member, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	var members []repository.Member
+	for _, member := range r.db.members {
+		if member.TeamID == teamID {
+			members = append(members, repository.Member{TeamMember: member, User: r.db.users[member.UserID]})
+		}
+	}
+	return members, nil
+}
+
+func (r Teams) AddMember(ctx context.Context, teamID, userID, role string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	if _, ok := r.db.member(teamID, userID); ok {
+		return repository.ErrDuplicate
+	}
+	now := time.Now()
+	r.db.addMember(teamID, userID, role, now)
+	for _, channel := range r.db.channels {
+		if channel.TeamID == teamID && channel.IsDefault && !channel.IsPrivate {
+			r.db.joinChannel(channel.ID, userID, now)
+		}
+	}
+	return nil
+}
+
+func (r Teams) RemoveMember(ctx context.Context, teamID, userID string, undo *domain.UndoableAction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	member, ok := r.db.member(teamID, userID)
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	snapshot := repository.RemovedMember{Role: member.Role, JoinedAt: member.JoinedAt}
+	for channelID, joined := range r.db.channelMembers {
+		if joinedAt, ok := joined[userID]; ok && r.db.channels[channelID].TeamID == teamID {
+			snapshot.Channels = append(snapshot.Channels, repository.ChannelMembership{ChannelID: channelID, JoinedAt: joinedAt})
+		}
+	}
+	if err := r.db.recordUndo(undo, snapshot); err != nil {
+		return err
+	}
+
+	for _, membership := range snapshot.Channels {
+		delete(r.db.channelMembers[membership.ChannelID], userID)
+	}
+	r.db.removeMember(teamID, userID)
+	return nil
+}
+
+func (r Teams) RestoreMember(ctx context.Context, undo domain.UndoableAction) (repository.Member, error) {
+	var snapshot repository.RemovedMember
+	if err := json.Unmarshal(undo.Snapshot, &snapshot); err != nil {
+		return repository.Member{}, err
+	}
+	userID := undo.ResourceID
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return repository.Member{}, r.db.Err
+	}
+
+	if err := r.db.undoAvailable(undo.ID); err != nil {
+		return repository.Member{}, err
+	}
+	// Someone may have added them back already, maybe with another role
+	if _, ok := r.db.member(undo.TeamID, userID); ok {
+		return repository.Member{}, repository.ErrConflict
+	}
+	r.db.markUndone(undo.ID)
+
+	member := r.db.addMember(undo.TeamID, userID, snapshot.Role, snapshot.JoinedAt)
+	for _, membership := range snapshot.Channels {
+		if _, ok := r.db.channels[membership.ChannelID]; ok {
+			r.db.joinChannel(membership.ChannelID, userID, membership.JoinedAt)
+		}
+	}
+	return repository.Member{TeamMember: member, User: r.db.users[userID]}, nil
+}
+
+func (r Teams) MessagePolicy(ctx context.Context, teamID string) (domain.MessagePolicy, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.MessagePolicy{}, r.db.Err
+	}
+
+	policy, ok := r.db.policies[teamID]
+	if !ok {
+		return policy, sql.ErrNoRows
+	}
+	return policy, nil
+}
+
+func (r Teams) WelcomeSettings(ctx context.Context, teamID string) (domain.TeamWelcomeSettings, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.TeamWelcomeSettings{}, r.db.Err
+	}
+
+	settings, ok := r.db.welcome[teamID]
+	if !ok {
+		return settings, sql.ErrNoRows
+	}
+	return settings, nil
+}
+
+func (db *DB) member(teamID, userID string) (domain.TeamMember, bool) {
+	for _, member := range db.members {
+		if member.TeamID == teamID && member.UserID == userID {
+			return member, true
+		}
+	}
+	return domain.TeamMember{}, false
+}
+
+func (db *DB) addMember(teamID, userID, role string, joinedAt time.Time) domain.TeamMember {
+	member := domain.TeamMember{
+		ID:        uuid.New().String(),
+		TeamID:    teamID,
+		UserID:    userID,
+		Role:      role,
+		JoinedAt:  joinedAt,
+		UpdatedAt: time.Now(),
+	}
+	db.members = append(db.members, member)
+	// Members are listed in the order they joined
+	sort.SliceStable(db.members, func(i, j int) bool { return db.members[i].JoinedAt.Before(db.members[j].JoinedAt) })
+	return member
+}
+
+func (db *DB) removeMember(teamID, userID string) {
+	for i, member := range db.members {
+		if member.TeamID == teamID && member.UserID == userID {
+			db.members = append(db.members[:i], db.members[i+1:]...)
+			return
+		}
+	}
+}
+
+func (db *DB) joinChannel(channelID, userID string, joinedAt time.Time) {
+	if db.channelMembers[channelID] == nil {
+		db.channelMembers[channelID] = make(map[string]time.Time)
+	}
+	if _, ok := db.channelMembers[channelID][userID]; !ok {
+		db.channelMembers[channelID][userID] = joinedAt
+	}
+}
@@ -0,0 +1,141 @@
+package fakes
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Users struct {
+	db *DB
+}
+
+var _ repository.Users = Users{}
+
+func (r Users) Create(ctx context.Context, user *domain.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	for _, existing := range r.db.users {
+		if existing.Email == user.Email || existing.Username == user.Username {
+			return repository.ErrDuplicate
+		}
+	}
+	r.db.users[user.ID] = *user
+	return nil
+}
+
+func (r Users) Get(ctx context.Context, id string) (domain.User, error) {
+	return r.find(func(user domain.User) bool { return user.ID == id })
+}
+
+func (r Users) FindByLogin(ctx context.Context, login string) (domain.User, error) {
+	return r.find(func(user domain.User) bool { return user.Email == login || user.Username == login })
+}
+
+func (r Users) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	return r.find(func(user domain.User) bool { return user.Email == email })
+}
+
+func (r Users) FindByUsername(ctx context.Context, username string) (domain.User, error) {
+	return r.find(func(user domain.User) bool { return user.Username == username })
+}
+
+// find returns the active user that matches.
+func (r Users) find(match func(domain.User) bool) (domain.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return domain.User{}, r.db.Err
+	}
+
+	for _, user := range r.db.users {
+		if user.IsActive && match(user) {
+			return user, nil
+		}
+	}
+	return domain.User{}, sql.ErrNoRows
+}
+
+func (r Users) Update(ctx context.Context, id string, update domain.UserUpdate) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	user, ok := r.db.users[id]
+	if !ok || !user.IsActive {
+		return nil
+	}
+	if update.FirstName != "" {
+		user.FirstName = update.FirstName
+	}
+	if update.LastName != "" {
+		user.LastName = update.LastName
+	}
+	if update.Avatar != "" {
+		user.Avatar = update.Avatar
+	}
+	if update.Phone != "" {
+		user.Phone = update.Phone
+	}
+	user.UpdatedAt = time.Now()
+	r.db.users[id] = user
+	return nil
+}
+
+func (r Users) Touch(ctx context.Context, id string, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return r.db.Err
+	}
+
+	if user, ok := r.db.users[id]; ok {
+		user.LastSeen = at
+		r.db.users[id] = user
+	}
+	return nil
+}
+
+func (r Users) OutOfOffice(ctx context.Context, ids []string) (map[string]domain.OutOfOffice, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	now := time.Now()
+	result := map[string]domain.OutOfOffice{}
+	for _, id := range ids {
+		ooo, ok := r.db.outOfOffice[id]
+		if ok && !ooo.StartsAt.After(now) && ooo.EndsAt.After(now) {
+			ooo.Active = true
+			result[id] = ooo
+		}
+	}
+	return result, nil
+}
+
+func (r Users) WorkingHours(ctx context.Context, ids []string) (map[string]domain.WorkingHours, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.Err != nil {
+		return nil, r.db.Err
+	}
+
+	result := map[string]domain.WorkingHours{}
+	for _, id := range ids {
+		if hours, ok := r.db.workingHours[id]; ok {
+			result[id] = hours
+		}
+	}
+	return result, nil
+}
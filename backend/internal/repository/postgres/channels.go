@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Channels struct {
+	db *database.PostgresDB
+}
+
+const channelColumns = `c.id, c.team_id, c.name, COALESCE(c.description, ''), c.type, c.is_private, c.is_default,
+	c.classification_enabled, c.created_by, c.created_at, c.updated_at, c.archived_at`
+
+func scanChannel(row rowScanner, extra ...interface{}) (domain.Channel, error) {
+	var channel domain.Channel
+	dest := append([]interface{}{&channel.ID, &channel.TeamID, &channel.Name, &channel.Description, &channel.Type,
+		&channel.IsPrivate, &channel.IsDefault, &channel.ClassificationEnabled, &channel.CreatedBy,
+		&channel.CreatedAt, &channel.UpdatedAt, &channel.ArchivedAt}, extra...)
+	err := row.Scan(dest...)
+	return channel, err
+}
+
+// channelShared is whether a channel is shared with any team right now.
+const channelShared = `EXISTS(SELECT 1 FROM channel_shares s WHERE s.channel_id = c.id AND s.status = 'active')`
+
+func (r Channels) Create(ctx context.Context, channel *domain.Channel) error {
+	err := r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO channels (id, team_id, name, description, type, is_private, is_default,
+			                      classification_enabled, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+			RETURNING created_at, updated_at
+		`, channel.ID, channel.TeamID, channel.Name, channel.Description, channel.Type, channel.IsPrivate,
+			channel.IsDefault, channel.ClassificationEnabled, channel.CreatedBy).Scan(&channel.CreatedAt, &channel.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO channel_members (channel_id, user_id, joined_at) VALUES ($1, $2, NOW())
+		`, channel.ID, channel.CreatedBy)
+		return err
+	})
+	if isUniqueViolation(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r Channels) Get(ctx context.Context, id string) (repository.Channel, error) {
+	var channel repository.Channel
+	var err error
+	channel.Channel, err = scanChannel(r.db.QueryRowContext(ctx, `
+		SELECT `+channelColumns+`, `+channelShared+`
+		FROM channels c
+		WHERE c.id = $1
+	`, id), &channel.Shared)
+	return channel, err
+}
+
+func (r Channels) ForTeam(ctx context.Context, teamID, userID string) ([]repository.Channel, error) {
+	// The team's own channels plus those other teams share with it
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+channelColumns+`, `+channelShared+`, ht.name
+		FROM channels c
+		JOIN teams ht ON ht.id = c.team_id
+		WHERE (c.team_id = $1
+		       AND (c.type <> 'direct' OR EXISTS(
+		           SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $2)))
+		   OR EXISTS(
+		       SELECT 1 FROM channel_shares s
+		       WHERE s.channel_id = c.id AND s.guest_team_id = $1 AND s.status = 'active')
+		ORDER BY c.name
+	`, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []repository.Channel
+	for rows.Next() {
+		var channel repository.Channel
+		var hostTeamName string
+		channel.Channel, err = scanChannel(rows, &channel.Shared, &hostTeamName)
+		if err != nil {
+			return nil, err
+		}
+		if channel.TeamID != teamID {
+			channel.HostTeamName = hostTeamName
+		}
+		channels = append(channels, channel)
+	}
+	return channels, rows.Err()
+}
+
+func (r Channels) Access(ctx context.Context, channelID, userID string) (repository.ChannelAccess, error) {
+	var access repository.ChannelAccess
+	err := r.db.QueryRowContext(ctx, `
+		SELECT c.team_id, via.team_id, via.history_until, c.archived_at
+		FROM channels c
+		JOIN LATERAL (
+			SELECT tm.team_id, NULL::timestamptz AS history_until, 0 AS rank
+			FROM team_members tm
+			WHERE tm.team_id = c.team_id AND tm.user_id = $2
+			  AND (c.type <> 'direct' OR EXISTS(
+			      SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $2))
+			UNION ALL
+			SELECT s.guest_team_id, CASE WHEN s.status = 'ended' THEN s.ended_at END,
+			       CASE WHEN s.status = 'active' THEN 1 ELSE 2 END
+			FROM channel_shares s
+			JOIN team_members tm ON tm.team_id = s.guest_team_id AND tm.user_id = $2
+			WHERE s.channel_id = c.id
+			  AND (s.status = 'active' OR (s.status = 'ended' AND s.guest_keeps_history AND s.accepted_at IS NOT NULL))
+			ORDER BY rank, history_until DESC
+			LIMIT 1
+		) via ON true
+		WHERE c.id = $1
+	`, channelID, userID).Scan(&access.TeamID, &access.OriginTeamID, &access.HistoryUntil, &access.ArchivedAt)
+	return access, err
+}
+
+func (r Channels) Update(ctx context.Context, id string, update domain.UpdateChannel) (domain.Channel, error) {
+	var sets []string
+	args := []interface{}{id}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if update.Name != nil {
+		set("name", *update.Name)
+	}
+	if update.Description != nil {
+		set("description", *update.Description)
+	}
+	if update.IsPrivate != nil {
+		set("is_private", *update.IsPrivate)
+	}
+	if update.IsDefault != nil {
+		set("is_default", *update.IsDefault)
+	}
+	if update.ClassificationEnabled != nil {
+		set("classification_enabled", *update.ClassificationEnabled)
+	}
+	if len(sets) == 0 {
+		channel, err := r.Get(ctx, id)
+		return channel.Channel, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE channels c SET %s
+		WHERE c.id = $1
+		RETURNING `+channelColumns, strings.Join(sets, ", "))
+
+	channel, err := scanChannel(r.db.QueryRowContext(ctx, query, args...))
+	if isUniqueViolation(err) {
+		return channel, repository.ErrDuplicate
+	}
+	return channel, err
+}
+
+func (r Channels) Archive(ctx context.Context, id string, undo *domain.UndoableAction) error {
+	return r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE channels SET archived_at = NOW() WHERE id = $1 AND archived_at IS NULL
+		`, id)
+		if err != nil {
+			return err
+		}
+		archived, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if archived == 0 {
+			return repository.ErrConflict
+		}
+		return recordUndo(ctx, tx, undo, struct{}{})
+	})
+}
+
+func (r Channels) Unarchive(ctx context.Context, id string) (domain.Channel, error) {
+	var channel domain.Channel
+	err := r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		channel, err = unarchiveChannel(ctx, tx, id)
+		return err
+	})
+	return channel, err
+}
+
+func (r Channels) Restore(ctx context.Context, undo domain.UndoableAction) (domain.Channel, error) {
+	var channel domain.Channel
+	err := r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		if err := claimUndo(ctx, tx, undo.ID); err != nil {
+			return err
+		}
+		// The channel may have been deleted or unarchived since
+		var err error
+		channel, err = unarchiveChannel(ctx, tx, undo.ResourceID)
+		return err
+	})
+	return channel, err
+}
+
+// unarchiveChannel clears a channel's archive and returns the channel, or
+// repository.ErrConflict when it isn't archived.
+func unarchiveChannel(ctx context.Context, tx *sql.Tx, id string) (domain.Channel, error) {
+	channel, err := scanChannel(tx.QueryRowContext(ctx, `
+		UPDATE channels c SET archived_at = NULL
+		WHERE c.id = $1 AND c.archived_at IS NOT NULL
+		RETURNING `+channelColumns, id))
+	if err == sql.ErrNoRows {
+		return channel, repository.ErrConflict
+	}
+	return channel, err
+}
+
+func (r Channels) DefaultNames(ctx context.Context, teamID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT name FROM channels
+		WHERE team_id = $1 AND is_default = true AND is_private = false
+		ORDER BY name
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// OpenDirect names direct channels after both user IDs in sorted order, so
+// each pair has exactly one.
+func (r Channels) OpenDirect(ctx context.Context, teamID, userA, userB string) (string, error) {
+	users := []string{userA, userB}
+	sort.Strings(users)
+	name := "dm-" + users[0] + "-" + users[1]
+
+	var channelID string
+	err := r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO channels (id, team_id, name, description, type, is_private, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, '', 'direct', true, $4, NOW(), NOW())
+			ON CONFLICT (team_id, name) DO NOTHING
+		`, uuid.New().String(), teamID, name, userA)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			SELECT id FROM channels WHERE team_id = $1 AND name = $2
+		`, teamID, name).Scan(&channelID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO channel_members (channel_id, user_id, joined_at)
+			VALUES ($1, $2, NOW()), ($1, $3, NOW())
+			ON CONFLICT DO NOTHING
+		`, channelID, users[0], users[1])
+		return err
+	})
+	return channelID, err
+}
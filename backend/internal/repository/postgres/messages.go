@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Messages struct {
+	db *database.PostgresDB
+}
+
+// nullable stores an empty string as NULL.
+func nullable(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func (r Messages) Create(ctx context.Context, message *repository.NewMessage) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO messages (id, team_id, channel_id, user_id, content, type, reply_to_id, origin_team_id, client_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		RETURNING created_at, updated_at
+	`, message.ID, message.TeamID, message.ChannelID, message.UserID, message.Content, message.Type,
+		message.ReplyToID, nullable(message.OriginTeamID), nullable(message.ClientID)).Scan(&message.CreatedAt, &message.UpdatedAt)
+	if isUniqueViolation(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+const messageColumns = `id, team_id, channel_id, user_id, content, type, reply_to_id, is_edited, is_deleted, created_at, updated_at`
+
+func scanMessage(row rowScanner) (domain.Message, error) {
+	var message domain.Message
+	err := row.Scan(&message.ID, &message.TeamID, &message.ChannelID, &message.UserID, &message.Content, &message.Type,
+		&message.ReplyToID, &message.IsEdited, &message.IsDeleted, &message.CreatedAt, &message.UpdatedAt)
+	return message, err
+}
+
+func (r Messages) Get(ctx context.Context, id string) (domain.Message, error) {
+	return scanMessage(r.db.QueryRowContext(ctx, `
+		SELECT `+messageColumns+` FROM messages WHERE id = $1 AND is_deleted = false
+	`, id))
+}
+
+func (r Messages) List(ctx context.Context, channelID string, query repository.MessageQuery) ([]repository.ListedMessage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.id, m.team_id, m.channel_id, m.content, m.type, m.user_id, m.is_pinned, m.reply_to_id, m.is_edited,
+		       m.created_at, m.updated_at, u.username, u.first_name, u.last_name,
+		       ml.urgency, ml.sentiment, ot.id, ot.name
+		FROM messages m
+		JOIN users u ON m.user_id = u.id
+		LEFT JOIN message_labels ml ON ml.message_id = m.id
+		LEFT JOIN teams ot ON ot.id = m.origin_team_id
+		WHERE m.channel_id = $1 AND m.is_deleted = false
+		  AND ($3 = '' OR m.content ILIKE '%' || $3 || '%')
+		  AND ($4 = '' OR ml.urgency = $4)
+		  AND ($5 = '' OR ml.sentiment = $5)
+		  AND ($6 = '' OR m.reply_to_id::text = $6)
+		  AND ($7::timestamptz IS NULL OR m.created_at <= $7)
+		ORDER BY m.created_at DESC
+		LIMIT $2
+	`, channelID, query.Limit, query.Search, query.Urgency, query.Sentiment, query.Thread, query.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []repository.ListedMessage
+	for rows.Next() {
+		var message repository.ListedMessage
+		var urgency, sentiment, originTeamID, originTeamName *string
+		err := rows.Scan(&message.ID, &message.TeamID, &message.ChannelID, &message.Content, &message.Type,
+			&message.UserID, &message.IsPinned, &message.ReplyToID, &message.IsEdited, &message.CreatedAt,
+			&message.UpdatedAt, &message.Sender.Username, &message.Sender.FirstName, &message.Sender.LastName,
+			&urgency, &sentiment, &originTeamID, &originTeamName)
+		if err != nil {
+			return nil, err
+		}
+		message.Sender.ID = message.UserID
+		if urgency != nil && sentiment != nil {
+			message.Labels = &repository.MessageLabels{Urgency: *urgency, Sentiment: *sentiment}
+		}
+		if originTeamID != nil && originTeamName != nil {
+			message.OriginTeam = &domain.Team{ID: *originTeamID, Name: *originTeamName}
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+func (r Messages) Edit(ctx context.Context, id, content string) (time.Time, error) {
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE messages SET content = $2, is_edited = true, updated_at = NOW()
+		WHERE id = $1 AND is_deleted = false
+		RETURNING updated_at
+	`, id, content).Scan(&updatedAt)
+	return updatedAt, err
+}
+
+func (r Messages) Delete(ctx context.Context, id string, undo *domain.UndoableAction) error {
+	return r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		var snapshot repository.DeletedMessage
+		err := tx.QueryRowContext(ctx, `
+			UPDATE messages m SET is_deleted = true, content = '', updated_at = NOW()
+			FROM (SELECT content FROM messages WHERE id = $1 AND is_deleted = false FOR UPDATE) previous
+			WHERE m.id = $1
+			RETURNING m.channel_id, previous.content
+		`, id).Scan(&snapshot.ChannelID, &snapshot.Content)
+		if err != nil {
+			return err
+		}
+		return recordUndo(ctx, tx, undo, snapshot)
+	})
+}
+
+func (r Messages) Restore(ctx context.Context, undo domain.UndoableAction) (domain.Message, error) {
+	var snapshot repository.DeletedMessage
+	if err := json.Unmarshal(undo.Snapshot, &snapshot); err != nil {
+		return domain.Message{}, err
+	}
+
+	var message domain.Message
+	err := r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		if err := claimUndo(ctx, tx, undo.ID); err != nil {
+			return err
+		}
+		// Retention may have removed the message since
+		var err error
+		message, err = scanMessage(tx.QueryRowContext(ctx, `
+			UPDATE messages SET is_deleted = false, content = $2, updated_at = NOW()
+			WHERE id = $1 AND is_deleted = true
+			RETURNING `+messageColumns, undo.ResourceID, snapshot.Content))
+		if err == sql.ErrNoRows {
+			return repository.ErrConflict
+		}
+		return err
+	})
+	return message, err
+}
@@ -0,0 +1,85 @@
+// Package postgres stores the API's data in PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+// New returns repositories backed by db.
+func New(db *database.PostgresDB) repository.Repositories {
+	return repository.Repositories{
+		Users:    Users{db: db},
+		Teams:    Teams{db: db},
+		Channels: Channels{db: db},
+		Messages: Messages{db: db},
+		Tasks:    Tasks{db: db},
+		Undos:    Undos{db: db},
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// recordUndo saves undo with a snapshot of what the action threw away, in
+// the transaction making the change so the two commit together.
+func recordUndo(ctx context.Context, tx *sql.Tx, undo *domain.UndoableAction, snapshot interface{}) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	undo.Snapshot = data
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO undoable_actions (id, team_id, actor_id, kind, resource_id, snapshot, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, undo.ID, undo.TeamID, undo.ActorID, undo.Kind, undo.ResourceID, undo.Snapshot,
+		undo.CreatedAt, undo.ExpiresAt)
+	return err
+}
+
+// claimUndo marks an action undone, failing if another request got there
+// first or the window closed since the action was loaded.
+func claimUndo(ctx context.Context, tx *sql.Tx, actionID string) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE undoable_actions SET undone_at = NOW()
+		WHERE id = $1 AND undone_at IS NULL AND expires_at > NOW()
+	`, actionID)
+	if err != nil {
+		return err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if claimed == 0 {
+		return repository.ErrUndoUnavailable
+	}
+	return nil
+}
+
+type Undos struct {
+	db *database.PostgresDB
+}
+
+func (r Undos) Get(ctx context.Context, id string) (domain.UndoableAction, error) {
+	var action domain.UndoableAction
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, kind, team_id, actor_id, resource_id, snapshot, created_at, expires_at, undone_at
+		FROM undoable_actions WHERE id = $1
+	`, id).Scan(&action.ID, &action.Kind, &action.TeamID, &action.ActorID, &action.ResourceID,
+		&action.Snapshot, &action.CreatedAt, &action.ExpiresAt, &action.UndoneAt)
+	return action, err
+}
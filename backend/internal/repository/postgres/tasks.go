@@ -0,0 +1,302 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Tasks struct {
+	db *database.PostgresDB
+}
+
+const taskColumns = `id, team_id, title, COALESCE(description, ''), status, priority, assignee_id, due_date,
+	created_by, created_at, updated_at, completed_at`
+
+func scanTask(row rowScanner) (domain.Task, error) {
+	var task domain.Task
+	err := row.Scan(&task.ID, &task.TeamID, &task.Title, &task.Description, &task.Status, &task.Priority,
+		&task.AssigneeID, &task.DueDate, &task.CreatedBy, &task.CreatedAt, &task.UpdatedAt, &task.CompletedAt)
+	return task, err
+}
+
+func insertTaskEvent(ctx context.Context, tx *sql.Tx, teamID string, event domain.TaskEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_events (task_id, team_id, actor_id, type, changes, reverts_event_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, event.TaskID, teamID, event.ActorID, event.Type, changes, event.RevertsEventID)
+	// A concurrent undo of the same event loses on the unique index
+	if isUniqueViolation(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r Tasks) Create(ctx context.Context, task *domain.Task, event domain.TaskEvent) error {
+	return r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO tasks (id, team_id, title, description, status, priority, assignee_id, due_date, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+			RETURNING created_at, updated_at
+		`, task.ID, task.TeamID, task.Title, task.Description, task.Status, task.Priority, task.AssigneeID,
+			task.DueDate, task.CreatedBy).Scan(&task.CreatedAt, &task.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		event.TaskID = task.ID
+		return insertTaskEvent(ctx, tx, task.TeamID, event)
+	})
+}
+
+func (r Tasks) Get(ctx context.Context, id string) (domain.Task, error) {
+	return scanTask(r.db.QueryRowContext(ctx, `
+		SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND deleted_at IS NULL
+	`, id))
+}
+
+func (r Tasks) ForTeam(ctx context.Context, teamID string) ([]domain.Task, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE team_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (r Tasks) Update(ctx context.Context, id string, update repository.TaskUpdate,
+	record func(before, after domain.Task) (*domain.TaskEvent, error)) (before, after domain.Task, err error) {
+	var sets []string
+	args := []interface{}{id}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if update.Title != nil {
+		set("title", *update.Title)
+	}
+	if update.Description != nil {
+		set("description", *update.Description)
+	}
+	if update.Status != nil {
+		set("status", *update.Status)
+		if *update.Status == string(domain.TaskStatusDone) {
+			sets = append(sets, "completed_at = NOW()")
+		} else {
+			sets = append(sets, "completed_at = NULL")
+		}
+	}
+	if update.Priority != nil {
+		set("priority", *update.Priority)
+	}
+	if update.AssigneeID != nil {
+		// An empty assignee unassigns the task
+		if *update.AssigneeID == "" {
+			sets = append(sets, "assignee_id = NULL")
+		} else {
+			set("assignee_id", *update.AssigneeID)
+		}
+	}
+	if update.DueDate != nil {
+		set("due_date", *update.DueDate)
+	} else if update.ClearDueDate {
+		sets = append(sets, "due_date = NULL")
+	}
+
+	err = r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		before, err = scanTask(tx.QueryRowContext(ctx, `
+			SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+		`, id))
+		if err != nil {
+			return err
+		}
+
+		after = before
+		if len(sets) > 0 {
+			query := fmt.Sprintf(`UPDATE tasks SET %s WHERE id = $1 RETURNING `+taskColumns, strings.Join(sets, ", "))
+			if after, err = scanTask(tx.QueryRowContext(ctx, query, args...)); err != nil {
+				return err
+			}
+		}
+
+		event, err := record(before, after)
+		if err != nil || event == nil {
+			return err
+		}
+		event.TaskID = id
+		return insertTaskEvent(ctx, tx, after.TeamID, *event)
+	})
+	return before, after, err
+}
+
+func (r Tasks) Delete(ctx context.Context, id string, undo *domain.UndoableAction) error {
+	return r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE tasks SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL
+		`, id)
+		if err != nil {
+			return err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return sql.ErrNoRows
+		}
+		return recordUndo(ctx, tx, undo, struct{}{})
+	})
+}
+
+func (r Tasks) Restore(ctx context.Context, undo domain.UndoableAction) (domain.Task, error) {
+	var task domain.Task
+	err := r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		if err := claimUndo(ctx, tx, undo.ID); err != nil {
+			return err
+		}
+		var err error
+		task, err = scanTask(tx.QueryRowContext(ctx, `
+			UPDATE tasks SET deleted_at = NULL
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			RETURNING `+taskColumns, undo.ResourceID))
+		if err == sql.ErrNoRows {
+			return repository.ErrConflict
+		}
+		return err
+	})
+	return task, err
+}
+
+const taskEventColumns = `e.id, e.task_id, e.type, e.actor_id, u.username, e.changes, e.reverts_event_id, r.id, e.created_at`
+
+// taskEventJoins pairs each event with the user who made it and the event
+// that undid it, if any.
+const taskEventJoins = `
+	FROM task_events e
+	JOIN users u ON u.id = e.actor_id
+	LEFT JOIN task_events r ON r.reverts_event_id = e.id
+`
+
+func scanTaskEvent(row rowScanner) (domain.TaskEvent, error) {
+	var event domain.TaskEvent
+	var changes []byte
+	err := row.Scan(&event.ID, &event.TaskID, &event.Type, &event.ActorID, &event.Username, &changes,
+		&event.RevertsEventID, &event.RevertedBy, &event.CreatedAt)
+	if err != nil {
+		return event, err
+	}
+	if err := json.Unmarshal(changes, &event.Changes); err != nil {
+		return event, fmt.Errorf("failed to decode task event changes: %w", err)
+	}
+	return event, nil
+}
+
+func (r Tasks) Events(ctx context.Context, taskID string) ([]domain.TaskEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+taskEventColumns+taskEventJoins+`
+		WHERE e.task_id = $1
+		ORDER BY e.created_at, e.id
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.TaskEvent
+	for rows.Next() {
+		event, err := scanTaskEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// LatestUndoable skips reverts, which aren't themselves undoable, so
+// undoing repeatedly walks back through the task's history.
+func (r Tasks) LatestUndoable(ctx context.Context, taskID string) (domain.TaskEvent, error) {
+	return scanTaskEvent(r.db.QueryRowContext(ctx, `
+		SELECT `+taskEventColumns+taskEventJoins+`
+		WHERE e.task_id = $1 AND e.type = $2 AND r.id IS NULL
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT 1
+	`, taskID, domain.TaskEventUpdated))
+}
+
+const taskCommentColumns = `c.id, c.task_id, c.user_id, c.content, c.message_id, u.username, c.created_at, c.updated_at`
+
+func scanTaskComment(row rowScanner) (domain.TaskComment, error) {
+	var comment domain.TaskComment
+	err := row.Scan(&comment.ID, &comment.TaskID, &comment.UserID, &comment.Content, &comment.MessageID,
+		&comment.Username, &comment.CreatedAt, &comment.UpdatedAt)
+	return comment, err
+}
+
+func (r Tasks) Comments(ctx context.Context, taskID string) ([]domain.TaskComment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+taskCommentColumns+`
+		FROM task_comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.task_id = $1
+		ORDER BY c.created_at
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []domain.TaskComment
+	for rows.Next() {
+		comment, err := scanTaskComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r Tasks) AddComment(ctx context.Context, comment *domain.TaskComment) error {
+	added, err := scanTaskComment(r.db.QueryRowContext(ctx, `
+		WITH c AS (
+			INSERT INTO task_comments (task_id, user_id, content, message_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (message_id) WHERE message_id IS NOT NULL DO NOTHING
+			RETURNING *
+		)
+		SELECT `+taskCommentColumns+` FROM c JOIN users u ON u.id = c.user_id
+	`, comment.TaskID, comment.UserID, comment.Content, comment.MessageID))
+	if err != nil {
+		return err
+	}
+	*comment = added
+	return nil
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Teams struct {
+	db *database.PostgresDB
+}
+
+func (r Teams) Create(ctx context.Context, team *domain.Team) error {
+	return r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO teams (id, name, description, owner_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+			RETURNING created_at, updated_at
+		`, team.ID, team.Name, team.Description, team.OwnerID).Scan(&team.CreatedAt, &team.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert team: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO team_members (team_id, user_id, role, joined_at)
+			VALUES ($1, $2, 'owner', NOW())
+		`, team.ID, team.OwnerID)
+		if err != nil {
+			return fmt.Errorf("failed to add team owner as member: %w", err)
+		}
+
+		channelID := uuid.New().String()
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO channels (id, team_id, name, description, type, is_default, created_by, created_at, updated_at)
+			VALUES ($1, $2, 'general', 'General discussion', 'general', true, $3, NOW(), NOW())
+		`, channelID, team.ID, team.OwnerID)
+		if err != nil {
+			return fmt.Errorf("failed to create default channel: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO channel_members (channel_id, user_id, joined_at)
+			VALUES ($1, $2, NOW())
+		`, channelID, team.OwnerID)
+		if err != nil {
+			return fmt.Errorf("failed to add team owner to default channel: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r Teams) Get(ctx context.Context, id string) (domain.Team, error) {
+	var team domain.Team
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, COALESCE(description, ''), owner_id, created_at, updated_at FROM teams WHERE id = $1
+	`, id).Scan(&team.ID, &team.Name, &team.Description, &team.OwnerID, &team.CreatedAt, &team.UpdatedAt)
+	return team, err
+}
+
+const membershipColumns = `t.id, t.name, COALESCE(t.description, ''), t.owner_id, t.created_at, t.updated_at,
+	tm.role, tm.joined_at`
+
+func scanMembership(row rowScanner) (repository.Membership, error) {
+	var membership repository.Membership
+	team := &membership.Team
+	err := row.Scan(&team.ID, &team.Name, &team.Description, &team.OwnerID, &team.CreatedAt, &team.UpdatedAt,
+		&membership.Role, &membership.JoinedAt)
+	return membership, err
+}
+
+func (r Teams) ForUser(ctx context.Context, userID string) ([]repository.Membership, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+membershipColumns+`
+		FROM teams t
+		JOIN team_members tm ON t.id = tm.team_id
+		WHERE tm.user_id = $1
+		ORDER BY t.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []repository.Membership
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+	return memberships, rows.Err()
+}
+
+func (r Teams) Membership(ctx context.Context, teamID, userID string) (repository.Membership, error) {
+	return scanMembership(r.db.QueryRowContext(ctx, `
+		SELECT `+membershipColumns+`
+		FROM teams t
+		JOIN team_members tm ON t.id = tm.team_id
+		WHERE t.id = $1 AND tm.user_id = $2
+	`, teamID, userID))
+}
+
+func (r Teams) Role(ctx context.Context, teamID, userID string) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT role FROM team_members WHERE team_id = $1 AND user_id = $2
+	`, teamID, userID).Scan(&role)
+	return role, err
+}
+
+const memberColumns = `tm.team_id, tm.user_id, tm.role, tm.joined_at, tm.updated_at,
+	u.id, u.email, u.username, u.first_name, u.last_name, COALESCE(u.avatar, '')`
+
+func scanMember(row rowScanner) (repository.Member, error) {
+	var member repository.Member
+	err := row.Scan(&member.TeamID, &member.UserID, &member.Role, &member.JoinedAt, &member.UpdatedAt,
+		&member.User.ID, &member.User.Email, &member.User.Username, &member.User.FirstName, &member.User.LastName,
+		&member.User.Avatar)
+	return member, err
+}
+
+func (r Teams) Members(ctx context.Context, teamID string) ([]repository.Member, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+memberColumns+`
+		FROM team_members tm
+		JOIN users u ON tm.user_id = u.id
+		WHERE tm.team_id = $1
+		ORDER BY tm.joined_at
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []repository.Member
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r Teams) AddMember(ctx context.Context, teamID, userID, role string) error {
+	return r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO team_members (team_id, user_id, role, joined_at, updated_at)
+			VALUES ($1, $2, $3, NOW(), NOW())
+		`, teamID, userID, role)
+		if isUniqueViolation(err) {
+			return repository.ErrDuplicate
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert team member: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO channel_members (channel_id, user_id, joined_at)
+			SELECT id, $2, NOW() FROM channels
+			WHERE team_id = $1 AND is_default = true AND is_private = false
+			ON CONFLICT DO NOTHING
+		`, teamID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to join default channels: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r Teams) RemoveMember(ctx context.Context, teamID, userID string, undo *domain.UndoableAction) error {
+	return r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		var snapshot repository.RemovedMember
+		rows, err := tx.QueryContext(ctx, `
+			DELETE FROM channel_members cm USING channels c
+			WHERE c.id = cm.channel_id AND c.team_id = $1 AND cm.user_id = $2
+			RETURNING cm.channel_id, cm.joined_at
+		`, teamID, userID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var membership repository.ChannelMembership
+			if err := rows.Scan(&membership.ChannelID, &membership.JoinedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			snapshot.Channels = append(snapshot.Channels, membership)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			DELETE FROM team_members WHERE team_id = $1 AND user_id = $2
+			RETURNING role, joined_at
+		`, teamID, userID).Scan(&snapshot.Role, &snapshot.JoinedAt)
+		if err != nil {
+			return err
+		}
+
+		return recordUndo(ctx, tx, undo, snapshot)
+	})
+}
+
+func (r Teams) RestoreMember(ctx context.Context, undo domain.UndoableAction) (repository.Member, error) {
+	var snapshot repository.RemovedMember
+	if err := json.Unmarshal(undo.Snapshot, &snapshot); err != nil {
+		return repository.Member{}, err
+	}
+	userID := undo.ResourceID
+
+	err := r.db.RunInTransaction(ctx, func(tx *sql.Tx) error {
+		if err := claimUndo(ctx, tx, undo.ID); err != nil {
+			return err
+		}
+
+		// Someone may have added them back already, maybe with another role
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO team_members (team_id, user_id, role, joined_at, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (team_id, user_id) DO NOTHING
+		`, undo.TeamID, userID, snapshot.Role, snapshot.JoinedAt)
+		if err != nil {
+			return err
+		}
+		added, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if added == 0 {
+			return repository.ErrConflict
+		}
+
+		// Channels deleted in the meantime are skipped
+		for _, membership := range snapshot.Channels {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO channel_members (channel_id, user_id, joined_at)
+				SELECT id, $2, $3 FROM channels WHERE id = $1
+				ON CONFLICT DO NOTHING
+			`, membership.ChannelID, userID, membership.JoinedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return repository.Member{}, err
+	}
+
+	return scanMember(r.db.QueryRowContext(ctx, `
+		SELECT `+memberColumns+`
+		FROM team_members tm
+		JOIN users u ON u.id = tm.user_id
+		WHERE tm.team_id = $1 AND tm.user_id = $2
+	`, undo.TeamID, userID))
+}
+
+func (r Teams) MessagePolicy(ctx context.Context, teamID string) (domain.MessagePolicy, error) {
+	policy := domain.MessagePolicy{TeamID: teamID}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT members_can_edit, members_can_delete, edit_window_minutes, delete_window_minutes,
+		       admins_can_delete_others, updated_at
+		FROM team_message_policies
+		WHERE team_id = $1
+	`, teamID).Scan(&policy.MembersCanEdit, &policy.MembersCanDelete, &policy.EditWindowMinutes,
+		&policy.DeleteWindowMinutes, &policy.AdminsCanDeleteOthers, &policy.UpdatedAt)
+	return policy, err
+}
+
+func (r Teams) WelcomeSettings(ctx context.Context, teamID string) (domain.TeamWelcomeSettings, error) {
+	settings := domain.TeamWelcomeSettings{TeamID: teamID}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT enabled, template, sender_id FROM team_welcome_settings WHERE team_id = $1
+	`, teamID).Scan(&settings.Enabled, &settings.Template, &settings.SenderID)
+	return settings, err
+}
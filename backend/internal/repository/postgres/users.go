@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/cbalite/backend/internal/database"
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/repository"
+)
+
+type Users struct {
+	db *database.PostgresDB
+}
+
+const userColumns = `id, email, username, password_hash, first_name, last_name, COALESCE(avatar, ''),
+	COALESCE(phone, ''), is_active, is_verified, last_seen, created_at, updated_at`
+
+func scanUser(row rowScanner) (domain.User, error) {
+	var user domain.User
+	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PasswordHash, &user.FirstName, &user.LastName,
+		&user.Avatar, &user.Phone, &user.IsActive, &user.IsVerified, &user.LastSeen, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
+
+func (r Users) Create(ctx context.Context, user *domain.User) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO users (id, email, username, password_hash, first_name, last_name, is_active, is_verified, last_seen, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, user.ID, user.Email, user.Username, user.PasswordHash,
+		user.FirstName, user.LastName, user.IsActive, user.IsVerified,
+		user.LastSeen, user.CreatedAt, user.UpdatedAt)
+	if isUniqueViolation(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r Users) Get(ctx context.Context, id string) (domain.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users WHERE id = $1 AND is_active = true
+	`, id))
+}
+
+func (r Users) FindByLogin(ctx context.Context, login string) (domain.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users WHERE (email = $1 OR username = $1) AND is_active = true
+	`, login))
+}
+
+func (r Users) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users WHERE email = $1 AND is_active = true
+	`, email))
+}
+
+func (r Users) FindByUsername(ctx context.Context, username string) (domain.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users WHERE username = $1 AND is_active = true
+	`, username))
+}
+
+func (r Users) Update(ctx context.Context, id string, update domain.UserUpdate) error {
+	var sets []string
+	args := []interface{}{id}
+	set := func(column, value string) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if update.FirstName != "" {
+		set("first_name", update.FirstName)
+	}
+	if update.LastName != "" {
+		set("last_name", update.LastName)
+	}
+	if update.Avatar != "" {
+		set("avatar", update.Avatar)
+	}
+	if update.Phone != "" {
+		set("phone", update.Phone)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE users SET %s WHERE id = $1 AND is_active = true`, strings.Join(sets, ", "))
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r Users) Touch(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET last_seen = $1 WHERE id = $2", at, id)
+	return err
+}
+
+const outOfOfficeColumns = `user_id, starts_at, ends_at, message, delegate_id, updated_at, starts_at <= NOW()`
+
+func (r Users) OutOfOffice(ctx context.Context, ids []string) (map[string]domain.OutOfOffice, error) {
+	result := map[string]domain.OutOfOffice{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+outOfOfficeColumns+`
+		FROM user_out_of_office
+		WHERE user_id = ANY($1::uuid[]) AND starts_at <= NOW() AND ends_at > NOW()
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ooo domain.OutOfOffice
+		err := rows.Scan(&ooo.UserID, &ooo.StartsAt, &ooo.EndsAt, &ooo.Message, &ooo.DelegateID, &ooo.UpdatedAt, &ooo.Active)
+		if err != nil {
+			return nil, err
+		}
+		result[ooo.UserID] = ooo
+	}
+	return result, rows.Err()
+}
+
+func (r Users) WorkingHours(ctx context.Context, ids []string) (map[string]domain.WorkingHours, error) {
+	result := map[string]domain.WorkingHours{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, timezone, weekdays, start_time, end_time
+		FROM user_working_hours
+		WHERE user_id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var hours domain.WorkingHours
+		var weekdays pq.StringArray
+		if err := rows.Scan(&userID, &hours.Timezone, &weekdays, &hours.StartTime, &hours.EndTime); err != nil {
+			return nil, err
+		}
+		hours.Weekdays = []string(weekdays)
+		result[userID] = hours
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,288 @@
+// Package repository declares how the API reads and writes users, teams,
+// channels, messages and tasks, so handlers don't depend on the database
+// behind them. postgres implements it for the server; fakes keeps
+// everything in memory for tests.
+//
+// Lookups of a single row return sql.ErrNoRows when there is no such row,
+// as database/sql does, so handlers check for it the same way either way.
+// Methods that record an undoable action take it with everything but the
+// snapshot filled in, and store it in the same transaction as the change.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+var (
+	// ErrDuplicate means a unique value, such as an email address or a
+	// channel name within a team, is already taken.
+	ErrDuplicate = errors.New("already exists")
+	// ErrConflict means the row isn't in the state the change needs, such
+	// as archiving a channel that is already archived.
+	ErrConflict = errors.New("conflicts with the current state")
+	// ErrUndoUnavailable means an undoable action was already undone or its
+	// window closed.
+	ErrUndoUnavailable = errors.New("action was already undone or has expired")
+)
+
+// Repositories is everything the API stores.
+type Repositories struct {
+	Users    Users
+	Teams    Teams
+	Channels Channels
+	Messages Messages
+	Tasks    Tasks
+	Undos    Undos
+}
+
+type Users interface {
+	// Create adds a user, failing with ErrDuplicate when the email or
+	// username is taken.
+	Create(ctx context.Context, user *domain.User) error
+	// Get returns an active user.
+	Get(ctx context.Context, id string) (domain.User, error)
+	// FindByLogin returns the active user with this email or username.
+	FindByLogin(ctx context.Context, login string) (domain.User, error)
+	FindByEmail(ctx context.Context, email string) (domain.User, error)
+	FindByUsername(ctx context.Context, username string) (domain.User, error)
+	// Update changes the profile fields of update that aren't empty.
+	Update(ctx context.Context, id string, update domain.UserUpdate) error
+	// Touch records when the user was last seen.
+	Touch(ctx context.Context, id string, at time.Time) error
+	// OutOfOffice returns the users among ids who are out of office right
+	// now.
+	OutOfOffice(ctx context.Context, ids []string) (map[string]domain.OutOfOffice, error)
+	// WorkingHours returns the working hours of the users among ids who have
+	// set them.
+	WorkingHours(ctx context.Context, ids []string) (map[string]domain.WorkingHours, error)
+}
+
+// Membership is one of a user's teams.
+type Membership struct {
+	Team     domain.Team
+	Role     string
+	JoinedAt time.Time
+}
+
+// Member is a team member with their profile.
+type Member struct {
+	domain.TeamMember
+	User domain.User
+}
+
+type Teams interface {
+	// Create adds a team with its owner as the first member and a general
+	// channel, which the owner joins.
+	Create(ctx context.Context, team *domain.Team) error
+	Get(ctx context.Context, id string) (domain.Team, error)
+	// ForUser lists the user's teams by name.
+	ForUser(ctx context.Context, userID string) ([]Membership, error)
+	// Membership returns one of the user's teams.
+	Membership(ctx context.Context, teamID, userID string) (Membership, error)
+	// Role returns the user's role in the team.
+	Role(ctx context.Context, teamID, userID string) (string, error)
+	// Members lists the team's members in the order they joined.
+	Members(ctx context.Context, teamID string) ([]Member, error)
+	// AddMember adds the user to the team and its public default channels.
+	// It fails with ErrDuplicate when they are already a member.
+	AddMember(ctx context.Context, teamID, userID, role string) error
+	// RemoveMember removes the member from the team and its channels, and
+	// records undo with what was removed. It returns sql.ErrNoRows when the
+	// user isn't a member.
+	RemoveMember(ctx context.Context, teamID, userID string, undo *domain.UndoableAction) error
+	// RestoreMember claims undo and puts the member it removed back with
+	// their role and channels. It fails with ErrConflict when they have
+	// been added back since.
+	RestoreMember(ctx context.Context, undo domain.UndoableAction) (Member, error)
+	// MessagePolicy returns the policy the team has set.
+	MessagePolicy(ctx context.Context, teamID string) (domain.MessagePolicy, error)
+	// WelcomeSettings returns the team's welcome message settings.
+	WelcomeSettings(ctx context.Context, teamID string) (domain.TeamWelcomeSettings, error)
+}
+
+// ChannelAccess is how a user reaches a channel: through the channel's own
+// team or as a guest through a share.
+type ChannelAccess struct {
+	TeamID       string
+	OriginTeamID string
+	// HistoryUntil is set when the user's team only keeps read access to
+	// what was said before its share ended.
+	HistoryUntil *time.Time
+	// ArchivedAt is set while the channel is archived and read-only.
+	ArchivedAt *time.Time
+}
+
+// Guest reports whether the user reaches the channel through a share.
+func (a ChannelAccess) Guest() bool {
+	return a.OriginTeamID != a.TeamID
+}
+
+// Channel is a channel with whether it's shared with other teams.
+type Channel struct {
+	domain.Channel
+	Shared bool
+	// HostTeamName is the name of the channel's team. Only ForTeam sets
+	// it, for channels other teams share.
+	HostTeamName string
+}
+
+type Channels interface {
+	// Create adds a channel with its creator as the first member, failing
+	// with ErrDuplicate when the team has a channel of that name.
+	Create(ctx context.Context, channel *domain.Channel) error
+	Get(ctx context.Context, id string) (Channel, error)
+	// ForTeam lists the team's channels the user can see, and those other
+	// teams share with it, by name.
+	ForTeam(ctx context.Context, teamID, userID string) ([]Channel, error)
+	// Access decides who can see a channel: members of its team (and, for
+	// direct channels, only the participants), plus members of teams it is
+	// shared with.
+	Access(ctx context.Context, channelID, userID string) (ChannelAccess, error)
+	// Update changes the fields of update that are set, failing with
+	// ErrDuplicate when the new name is taken.
+	Update(ctx context.Context, id string, update domain.UpdateChannel) (domain.Channel, error)
+	// Archive makes the channel read-only and records undo. It fails with
+	// ErrConflict when the channel is already archived.
+	Archive(ctx context.Context, id string, undo *domain.UndoableAction) error
+	// Unarchive fails with ErrConflict when the channel isn't archived.
+	Unarchive(ctx context.Context, id string) (domain.Channel, error)
+	// Restore claims undo and unarchives the channel it archived.
+	Restore(ctx context.Context, undo domain.UndoableAction) (domain.Channel, error)
+	// DefaultNames lists the names of the team's public default channels.
+	DefaultNames(ctx context.Context, teamID string) ([]string, error)
+	// OpenDirect returns the team's direct channel between two users,
+	// creating it the first time.
+	OpenDirect(ctx context.Context, teamID, userA, userB string) (string, error)
+}
+
+// NewMessage is a message to store. OriginTeamID labels messages from
+// guests in a shared channel; ClientID is the sender's own ID for a message
+// queued while offline.
+type NewMessage struct {
+	domain.Message
+	OriginTeamID string
+	ClientID     string
+}
+
+// MessageLabels are a message's classification.
+type MessageLabels struct {
+	Urgency   string
+	Sentiment string
+}
+
+// ListedMessage is a message as channel history shows it.
+type ListedMessage struct {
+	domain.Message
+	IsPinned   bool
+	Sender     domain.User
+	Labels     *MessageLabels
+	OriginTeam *domain.Team
+}
+
+// MessageQuery filters a channel's messages. Empty filters match
+// everything.
+type MessageQuery struct {
+	Limit     int
+	Search    string
+	Urgency   string
+	Sentiment string
+	// Thread keeps the replies to this message.
+	Thread string
+	// Until leaves out messages posted after it.
+	Until *time.Time
+}
+
+type Messages interface {
+	// Create stores a message, setting its timestamps.
+	Create(ctx context.Context, message *NewMessage) error
+	// Get returns a message that isn't deleted.
+	Get(ctx context.Context, id string) (domain.Message, error)
+	// List returns up to query.Limit of a channel's messages, newest first.
+	List(ctx context.Context, channelID string, query MessageQuery) ([]ListedMessage, error)
+	// Edit replaces a message's content and returns when it was updated.
+	Edit(ctx context.Context, id, content string) (time.Time, error)
+	// Delete clears a message's content, keeping it in undo.
+	Delete(ctx context.Context, id string, undo *domain.UndoableAction) error
+	// Restore claims undo and puts back the message it deleted. It fails
+	// with ErrConflict when the message is gone for good.
+	Restore(ctx context.Context, undo domain.UndoableAction) (domain.Message, error)
+}
+
+// TaskUpdate carries a partial task update; nil fields are left unchanged.
+// An empty AssigneeID unassigns the task.
+type TaskUpdate struct {
+	Title       *string
+	Description *string
+	Status      *string
+	Priority    *string
+	AssigneeID  *string
+	DueDate     *time.Time
+	// ClearDueDate removes the due date when DueDate is nil.
+	ClearDueDate bool
+}
+
+// Empty reports whether the update changes nothing.
+func (u TaskUpdate) Empty() bool {
+	return u.Title == nil && u.Description == nil && u.Status == nil && u.Priority == nil &&
+		u.AssigneeID == nil && u.DueDate == nil && !u.ClearDueDate
+}
+
+type Tasks interface {
+	// Create stores a task and the event that starts its history, setting
+	// the task's timestamps.
+	Create(ctx context.Context, task *domain.Task, event domain.TaskEvent) error
+	// Get returns a task that isn't deleted.
+	Get(ctx context.Context, id string) (domain.Task, error)
+	// ForTeam lists the team's tasks, newest first.
+	ForTeam(ctx context.Context, teamID string) ([]domain.Task, error)
+	// Update applies update to a task while holding it. record is given the
+	// task before and after and returns the event to add to its history, or
+	// nil for none; an error from it abandons the update.
+	Update(ctx context.Context, id string, update TaskUpdate,
+		record func(before, after domain.Task) (*domain.TaskEvent, error)) (before, after domain.Task, err error)
+	// Delete hides a task and records undo.
+	Delete(ctx context.Context, id string, undo *domain.UndoableAction) error
+	// Restore claims undo and brings back the task it deleted.
+	Restore(ctx context.Context, undo domain.UndoableAction) (domain.Task, error)
+	// Events returns a task's history, oldest first.
+	Events(ctx context.Context, taskID string) ([]domain.TaskEvent, error)
+	// LatestUndoable returns the most recent update to a task that hasn't
+	// been undone.
+	LatestUndoable(ctx context.Context, taskID string) (domain.TaskEvent, error)
+	// Comments returns a task's comments, oldest first.
+	Comments(ctx context.Context, taskID string) ([]domain.TaskComment, error)
+	// AddComment stores a comment, setting its ID, author's username and
+	// timestamps. A comment whose MessageID already has one returns
+	// sql.ErrNoRows.
+	AddComment(ctx context.Context, comment *domain.TaskComment) error
+}
+
+// The snapshots undoable actions keep of what they threw away.
+type (
+	// DeletedMessage is what deleting a message clears.
+	DeletedMessage struct {
+		ChannelID string `json:"channel_id"`
+		Content   string `json:"content"`
+	}
+
+	// RemovedMember is what removing a member drops: their role and the
+	// team's channels they were in.
+	RemovedMember struct {
+		Role     string              `json:"role"`
+		JoinedAt time.Time           `json:"joined_at"`
+		Channels []ChannelMembership `json:"channels"`
+	}
+
+	ChannelMembership struct {
+		ChannelID string    `json:"channel_id"`
+		JoinedAt  time.Time `json:"joined_at"`
+	}
+)
+
+type Undos interface {
+	Get(ctx context.Context, id string) (domain.UndoableAction, error)
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Timezones are user input; don't depend on the host having tzdata
+	_ "time/tzdata"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Weekly recurs on the given weekdays at a wall-clock time in a timezone,
+// so "Monday 9am" stays 9am across daylight saving changes.
+type Weekly struct {
+	Weekdays []time.Weekday
+	Hour     int
+	Minute   int
+	Location *time.Location
+}
+
+// ParseWeekly reads weekdays as three-letter names ("mon", "tue", ...), the
+// time as "HH:MM" and the timezone as an IANA name.
+func ParseWeekly(weekdays []string, at, timezone string) (Weekly, error) {
+	var w Weekly
+	if len(weekdays) == 0 {
+		return w, fmt.Errorf("at least one weekday is required")
+	}
+	for _, name := range weekdays {
+		day, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return w, fmt.Errorf("unknown weekday %q, expected mon, tue, wed, thu, fri, sat or sun", name)
+		}
+		w.Weekdays = append(w.Weekdays, day)
+	}
+
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return w, fmt.Errorf("invalid time %q, expected HH:MM", at)
+	}
+	w.Hour, w.Minute = t.Hour(), t.Minute()
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if w.Location, err = time.LoadLocation(timezone); err != nil {
+		return w, fmt.Errorf("unknown timezone %q", timezone)
+	}
+
+	return w, nil
+}
+
+// Next returns the first occurrence strictly after the given time.
+func (w Weekly) Next(after time.Time) time.Time {
+	local := after.In(w.Location)
+	for days := 0; days <= 7; days++ {
+		day := local.AddDate(0, 0, days)
+		candidate := time.Date(day.Year(), day.Month(), day.Day(), w.Hour, w.Minute, 0, 0, w.Location)
+		if !candidate.After(after) {
+			continue
+		}
+		for _, weekday := range w.Weekdays {
+			if candidate.Weekday() == weekday {
+				return candidate
+			}
+		}
+	}
+	// Unreachable with at least one weekday
+	return after.Add(7 * 24 * time.Hour)
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/cbalite/backend/internal/correlation"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// Job is one periodic piece of background work. Jobs find their own due
+// work in the database, so running the same job on several servers is safe
+// as long as the job claims rows before acting on them.
+type Job func(ctx context.Context) error
+
+type entry struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs registered jobs at fixed intervals.
+type Scheduler struct {
+	logger *logger.Logger
+	jobs   []entry
+}
+
+func New(logger *logger.Logger) *Scheduler {
+	return &Scheduler{logger: logger}
+}
+
+// Every registers a job to run every interval once the scheduler starts.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.jobs = append(s.jobs, entry{name: name, interval: interval, run: job})
+}
+
+// Start runs each job in its own goroutine. A slow job delays only its own
+// next run.
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		go s.loop(job)
+	}
+}
+
+func (s *Scheduler) loop(job entry) {
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// Each run gets a correlation ID of its own, for the events and
+		// messages it causes
+		ctx := correlation.NewContext(context.Background(), correlation.New())
+		if err := job.run(ctx); err != nil {
+			correlation.Log(ctx, s.logger).WithError(err).Errorf("Scheduled job %s failed", job.name)
+		}
+	}
+}
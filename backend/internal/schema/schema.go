@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Dialect is the JSON Schema version documents are written in.
+const Dialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema that Go types map to.
+type Schema struct {
+	Dialect     string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Type is a string, or a list of them for nullable fields.
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Document generates a standalone schema for v's type.
+func Document(id, title, description string, v interface{}) *Schema {
+	s := Generate(v)
+	s.Dialect = Dialect
+	s.ID = id
+	s.Title = title
+	s.Description = description
+	return s
+}
+
+// Generate describes the JSON encoding of v's type. Struct fields follow
+// their json tags: fields without omitempty are required and nil pointers
+// without it encode as null. Fields can add a description with a doc tag,
+// a format with a format tag, and the values of a validate "oneof" become
+// an enum.
+func Generate(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return generate(reflect.TypeOf(v))
+}
+
+func generate(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == rawJSONType {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return generate(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: generate(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: generate(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(s, t)
+		return s
+	default:
+		// interface{} can hold anything
+		return &Schema{}
+	}
+}
+
+func addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a name of their own are flattened, as
+		// encoding/json does
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(s, field.Type)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := generate(field.Type)
+		property.Description = field.Tag.Get("doc")
+		if format := field.Tag.Get("format"); format != "" {
+			property.Format = format
+		}
+		property.Enum = oneOf(field.Tag.Get("validate"))
+
+		omitempty := strings.Contains(options, "omitempty")
+		if field.Type.Kind() == reflect.Pointer && !omitempty && property.Type != nil {
+			property.Type = []interface{}{property.Type, "null"}
+		}
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = property
+	}
+}
+
+// oneOf reads the allowed values from a validate tag such as
+// "required,oneof=low medium high".
+func oneOf(validate string) []string {
+	for _, rule := range strings.Split(validate, ",") {
+		if values, ok := strings.CutPrefix(rule, "oneof="); ok {
+			return strings.Fields(values)
+		}
+	}
+	return nil
+}
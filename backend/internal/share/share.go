@@ -0,0 +1,67 @@
+package share
+
+import (
+	"strings"
+	"time"
+)
+
+// The public views below are the only shapes served through share links.
+// They carry display names instead of user records so emails, avatars and
+// user IDs never leave the team.
+
+type Author struct {
+	Name string `json:"name"`
+}
+
+type Message struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	Author    Author    `json:"author"`
+	IsPinned  bool      `json:"is_pinned"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Channel struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Messages    []Message `json:"messages"`
+}
+
+type Task struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Status   string     `json:"status"`
+	Priority string     `json:"priority"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+	Assignee *Author    `json:"assignee,omitempty"`
+}
+
+type Board struct {
+	Team  string `json:"team"`
+	Tasks []Task `json:"tasks"`
+}
+
+// View is the body of a public share link response; exactly one of Channel
+// and Board is set.
+type View struct {
+	Kind        string    `json:"kind"`
+	Channel     *Channel  `json:"channel,omitempty"`
+	Board       *Board    `json:"board,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// DisplayName shortens the last name to an initial, e.g. "Ada L.", falling
+// back to the username for accounts without a name.
+func DisplayName(firstName, lastName, username string) string {
+	firstName = strings.TrimSpace(firstName)
+	lastName = strings.TrimSpace(lastName)
+
+	switch {
+	case firstName != "" && lastName != "":
+		return firstName + " " + string([]rune(lastName)[:1]) + "."
+	case firstName != "":
+		return firstName
+	default:
+		return username
+	}
+}
@@ -0,0 +1,175 @@
+package statuspage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cbalite/backend/internal/domain"
+	"github.com/cbalite/backend/internal/inbound"
+)
+
+// Inbound webhook kinds handled by this package.
+const (
+	KindStatuspage = "statuspage"
+	KindGeneric    = "status"
+)
+
+// Update is a component status change reported by a provider.
+type Update struct {
+	ExternalID string
+	Name       string
+	Status     domain.ComponentStatus
+	Message    string
+}
+
+func IsValidStatus(status domain.ComponentStatus) bool {
+	switch status {
+	case domain.ComponentOperational, domain.ComponentDegradedPerformance, domain.ComponentPartialOutage,
+		domain.ComponentMajorOutage, domain.ComponentUnderMaintenance:
+		return true
+	}
+	return false
+}
+
+var labels = map[domain.ComponentStatus]string{
+	domain.ComponentOperational:         "🟢 Operational",
+	domain.ComponentDegradedPerformance: "🟡 Degraded performance",
+	domain.ComponentPartialOutage:       "🟠 Partial outage",
+	domain.ComponentMajorOutage:         "🔴 Major outage",
+	domain.ComponentUnderMaintenance:    "🔧 Under maintenance",
+}
+
+func Label(status domain.ComponentStatus) string {
+	if label, ok := labels[status]; ok {
+		return label
+	}
+	return string(status)
+}
+
+// ChangeMessage is posted to subscribed channels when a component changes.
+func ChangeMessage(component domain.StatusComponent, previous domain.ComponentStatus, message string) string {
+	text := fmt.Sprintf("**%s** is now %s (was %s)", component.Name, Label(component.Status), strings.ToLower(Label(previous)))
+	if message != "" {
+		text += "\n" + message
+	}
+	return text
+}
+
+// Card renders the pinned status card summarising every component.
+func Card(components []domain.StatusComponent) string {
+	var b strings.Builder
+	b.WriteString("**Service status**\n")
+
+	if len(components) == 0 {
+		b.WriteString("No components configured.")
+		return b.String()
+	}
+
+	allOperational := true
+	for _, component := range components {
+		fmt.Fprintf(&b, "%s — %s\n", component.Name, Label(component.Status))
+		if component.Status != domain.ComponentOperational {
+			allOperational = false
+		}
+	}
+
+	if allOperational {
+		b.WriteString("All systems operational.")
+	} else {
+		b.WriteString("Some systems are affected.")
+	}
+	return b.String()
+}
+
+// ParseStatuspage reads an Atlassian Statuspage component subscription
+// webhook. Incident notifications carry no component change and yield no
+// updates.
+func ParseStatuspage(body []byte) ([]Update, error) {
+	var payload struct {
+		ComponentUpdate *struct {
+			NewStatus   string `json:"new_status"`
+			ComponentID string `json:"component_id"`
+		} `json:"component_update"`
+		Component *struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			Status      string `json:"status"`
+			Description string `json:"description"`
+		} `json:"component"`
+		Incident *struct {
+			Name string `json:"name"`
+		} `json:"incident"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", inbound.ErrInvalidPayload, err)
+	}
+
+	if payload.Component == nil {
+		if payload.Incident != nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: missing component", inbound.ErrInvalidPayload)
+	}
+
+	update := Update{
+		ExternalID: payload.Component.ID,
+		Name:       payload.Component.Name,
+		Status:     domain.ComponentStatus(payload.Component.Status),
+	}
+	if payload.ComponentUpdate != nil && payload.ComponentUpdate.NewStatus != "" {
+		update.Status = domain.ComponentStatus(payload.ComponentUpdate.NewStatus)
+	}
+
+	if update.ExternalID == "" || update.Name == "" || !IsValidStatus(update.Status) {
+		return nil, fmt.Errorf("%w: component id, name and a known status are required", inbound.ErrInvalidPayload)
+	}
+
+	return []Update{update}, nil
+}
+
+// ParseGeneric reads the built-in format, which any monitoring tool can post:
+//
+//	{"component": "API", "status": "major_outage", "message": "Elevated errors"}
+//
+// or a list of those under "components".
+func ParseGeneric(body []byte) ([]Update, error) {
+	type item struct {
+		Component string `json:"component"`
+		Status    string `json:"status"`
+		Message   string `json:"message"`
+	}
+
+	var payload struct {
+		item
+		Components []item `json:"components"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", inbound.ErrInvalidPayload, err)
+	}
+
+	items := payload.Components
+	if payload.Component != "" {
+		items = append(items, payload.item)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: no components", inbound.ErrInvalidPayload)
+	}
+
+	updates := make([]Update, 0, len(items))
+	for _, item := range items {
+		update := Update{
+			Name:    strings.TrimSpace(item.Component),
+			Status:  domain.ComponentStatus(item.Status),
+			Message: item.Message,
+		}
+		if update.Name == "" || !IsValidStatus(update.Status) {
+			return nil, fmt.Errorf("%w: component name and a known status are required", inbound.ErrInvalidPayload)
+		}
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
@@ -0,0 +1,280 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/metrics"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/pkg/httpjson"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// The layers of the request limiter, from narrowest to widest. The ip
+// layer covers every API request; the others only authenticated ones.
+const (
+	LayerIP     = "ip"
+	LayerUser   = "user"
+	LayerTeam   = "team"
+	LayerGlobal = "global"
+)
+
+var (
+	requestsThrottled = metrics.Default.NewCounterVec("cbalite_requests_throttled_total",
+		"API requests refused by the layered rate limiter, by the layer that refused them.", "layer")
+	requestsInFlight = metrics.Default.NewGaugeVec("cbalite_requests_in_flight",
+		"Authenticated API requests this server is handling.")
+)
+
+// RequestThrottle describes the limit a request ran into. It's the throttle
+// field of the 429's body.
+type RequestThrottle struct {
+	Layer             string `json:"layer"`
+	Limit             int    `json:"limit"`
+	WindowSeconds     int    `json:"window_seconds,omitempty"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+}
+
+// Teams resolves the team that owns a path parameter such as channelId, or
+// "" when it owns none, and says whether a user belongs to a team.
+type Teams interface {
+	ResourceTeam(ctx context.Context, param, id string) (string, error)
+	IsMember(ctx context.Context, teamID, userID string) (bool, error)
+}
+
+// teamParams are the path parameters a request's team is looked up from,
+// when the route has no teamId.
+var teamParams = []string{"channelId", "taskId"}
+
+// RequestLimiter limits authenticated API requests in layers: per user, per
+// team, and across the whole deployment, by rate and by requests in flight.
+// Each layer counts in its own keys, in the shared cache when there is one,
+// so a team running hot uses up its own budget, not everyone's.
+//
+// Layers are checked narrowest first and a request stops counting at the
+// first one that refuses it. A user or team that keeps going after being
+// throttled therefore doesn't eat into the wider budgets. A request only
+// counts against a team its caller belongs to, so outsiders can't use up a
+// team's budget. Counting is best effort: a cache error lets the request
+// through.
+type RequestLimiter struct {
+	cfg     *config.RateLimitConfig
+	teams   Teams
+	clients *middleware.ClientIPResolver
+	logger  *logger.Logger
+	counter
+	// now is the clock windows are counted by; tests replace it.
+	now func() time.Time
+
+	inFlight atomic.Int64
+}
+
+func NewRequestLimiter(cfg *config.RateLimitConfig, cache cache.Cache, teams Teams, logger *logger.Logger) *RequestLimiter {
+	return &RequestLimiter{cfg: cfg, teams: teams, clients: middleware.NewClientIPResolver(cfg), logger: logger, counter: newCounter(cache), now: time.Now}
+}
+
+// usage is how much of one layer's limit a request found used.
+type usage struct {
+	layer  string
+	limit  int
+	count  int64
+	window time.Duration
+	start  time.Time
+}
+
+func (u usage) remaining() int64 {
+	if remaining := int64(u.limit) - u.count; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// tighter reports whether u has less of its limit left than other.
+func (u usage) tighter(other *usage) bool {
+	return other == nil || u.remaining()*int64(other.limit) < other.remaining()*int64(u.limit)
+}
+
+type ipUsageKey struct{}
+
+// EnforceIP limits every API request per client IP. It goes in front of the
+// router; Enforce covers the other layers once the caller is known, and
+// reports whichever layer is closest to its limit.
+func (l *RequestLimiter) EnforceIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := l.cfg.RequestsPerMinute
+		if limit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := l.now()
+		start := now.Truncate(time.Minute)
+		key := fmt.Sprintf("request_rate:%s:%s:%d", LayerIP, l.clients.ClientIP(r), start.Unix())
+		count, err := l.increment(r.Context(), key, time.Minute)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		u := usage{layer: LayerIP, limit: limit, count: count, window: time.Minute, start: start}
+		if count > int64(limit) {
+			l.refuse(w, u, now)
+			return
+		}
+		setLimitHeaders(w, u)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ipUsageKey{}, u)))
+	})
+}
+
+// Enforce must run after authentication and route matching, since the team
+// comes from the token or the route.
+func (l *RequestLimiter) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		now := l.now()
+		layers := []struct {
+			layer  string
+			id     string
+			limit  int
+			window time.Duration
+		}{
+			{LayerUser, claims.UserID, l.cfg.UserPerMinute, time.Minute},
+			{LayerTeam, l.team(ctx, r, claims), l.cfg.TeamPerMinute, time.Minute},
+			{LayerGlobal, "all", l.cfg.GlobalPerSecond, time.Second},
+		}
+
+		var tightest *usage
+		if u, ok := ctx.Value(ipUsageKey{}).(usage); ok {
+			tightest = &u
+		}
+		for _, layer := range layers {
+			if layer.id == "" || layer.limit <= 0 {
+				continue
+			}
+			start := now.Truncate(layer.window)
+			key := fmt.Sprintf("request_rate:%s:%s:%d", layer.layer, layer.id, start.Unix())
+			count, err := l.increment(ctx, key, layer.window)
+			if err != nil {
+				continue
+			}
+
+			u := usage{layer: layer.layer, limit: layer.limit, count: count, window: layer.window, start: start}
+			if count > int64(layer.limit) {
+				l.refuse(w, u, now)
+				return
+			}
+			if u.tighter(tightest) {
+				tightest = &u
+			}
+		}
+
+		// Requests in flight are counted last, so one refused above never
+		// held a slot
+		if limit := l.cfg.GlobalConcurrency; limit > 0 {
+			inFlight := l.inFlight.Add(1)
+			requestsInFlight.With().Add(1)
+			defer func() {
+				l.inFlight.Add(-1)
+				requestsInFlight.With().Add(-1)
+			}()
+			if inFlight > int64(limit) {
+				l.refuse(w, usage{layer: LayerGlobal, limit: limit, count: inFlight}, now)
+				return
+			}
+		}
+
+		if tightest != nil {
+			setLimitHeaders(w, *tightest)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// team is the team the request acts in: an app token's team, or the route's
+// team or the team owning the channel or task it names, when the caller is
+// a member. Requests into other teams, which authorization turns away or
+// which reach a shared channel as a guest, aren't charged to the team.
+func (l *RequestLimiter) team(ctx context.Context, r *http.Request, claims *middleware.Claims) string {
+	if claims.TeamID != "" {
+		return claims.TeamID
+	}
+
+	vars := mux.Vars(r)
+	teamID := vars["teamId"]
+	for _, param := range teamParams {
+		if teamID != "" {
+			break
+		}
+		id := vars[param]
+		if id == "" {
+			continue
+		}
+		var err error
+		teamID, err = l.teams.ResourceTeam(ctx, param, id)
+		if err != nil {
+			l.logger.WithError(err).Warnf("Failed to resolve team for rate limiting %s", param)
+			return ""
+		}
+	}
+	if teamID == "" {
+		return ""
+	}
+
+	member, err := l.teams.IsMember(ctx, teamID, claims.UserID)
+	if err != nil {
+		l.logger.WithError(err).Warn("Failed to check team membership for rate limiting")
+		return ""
+	}
+	if !member {
+		return ""
+	}
+	return teamID
+}
+
+func (l *RequestLimiter) refuse(w http.ResponseWriter, u usage, now time.Time) {
+	requestsThrottled.With(u.layer).Inc()
+
+	// Requests in flight have no window; by the time the client retries
+	// some will have finished
+	retryAfter := 1
+	if u.window > 0 {
+		retryAfter = int(u.start.Add(u.window).Sub(now).Seconds()) + 1
+	}
+	throttle := RequestThrottle{
+		Layer:             u.layer,
+		Limit:             u.limit,
+		WindowSeconds:     int(u.window.Seconds()),
+		RetryAfterSeconds: retryAfter,
+	}
+
+	setLimitHeaders(w, u)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	httpjson.Respond(w, http.StatusTooManyRequests, map[string]interface{}{
+		"error":    "Rate limit exceeded",
+		"throttle": throttle,
+	})
+}
+
+// setLimitHeaders reports the layer closest to its limit, or the one that
+// refused the request.
+func setLimitHeaders(w http.ResponseWriter, u usage) {
+	w.Header().Set("X-RateLimit-Layer", u.layer)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(u.limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(u.remaining(), 10))
+	if u.window > 0 {
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(u.start.Add(u.window).Unix(), 10))
+	}
+}
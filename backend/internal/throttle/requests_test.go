@@ -0,0 +1,252 @@
+package throttle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/middleware"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// memberTeams puts channel c1 in team t1, whose members are listed.
+type memberTeams map[string]bool
+
+func (m memberTeams) ResourceTeam(ctx context.Context, param, id string) (string, error) {
+	if param == "channelId" && id == "c1" {
+		return "t1", nil
+	}
+	return "", nil
+}
+
+func (m memberTeams) IsMember(ctx context.Context, teamID, userID string) (bool, error) {
+	return teamID == "t1" && m[userID], nil
+}
+
+// newTestLimiter serves /teams/{teamId} and /channels/{channelId} behind
+// EnforceIP and Enforce, as the user named in the X-User header, at a fixed
+// time in the middle of a minute.
+func newTestLimiter(cfg config.RateLimitConfig, teams Teams, handler http.Handler) (*RequestLimiter, http.Handler) {
+	limiter := NewRequestLimiter(&cfg, cache.NewMemory(), teams, logger.Nop())
+	now := time.Date(2025, 6, 11, 10, 0, 30, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user := r.Header.Get("X-User"); user != "" {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, &middleware.Claims{UserID: user}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	router := mux.NewRouter()
+	router.Use(authenticate, limiter.Enforce)
+	router.Handle("/teams/{teamId}", handler)
+	router.Handle("/channels/{channelId}", handler)
+	return limiter, limiter.EnforceIP(router)
+}
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
+
+type call struct {
+	user, path, ip string
+	// layer is the one that should refuse the call, or "" to let it through
+	layer string
+}
+
+func TestRequestLimiterLayers(t *testing.T) {
+	members := memberTeams{"alice": true, "bob": true, "carol": true}
+	tests := []struct {
+		name  string
+		cfg   config.RateLimitConfig
+		calls []call
+	}{
+		{
+			name: "ip before anything else",
+			cfg:  config.RateLimitConfig{RequestsPerMinute: 2, UserPerMinute: 1},
+			calls: []call{
+				{user: "alice", path: "/teams/t1", ip: "203.0.113.1"},
+				{user: "alice", path: "/teams/t1", ip: "203.0.113.1", layer: LayerUser},
+				{path: "/teams/t1", ip: "203.0.113.1", layer: LayerIP},
+				{path: "/teams/t1", ip: "203.0.113.2"},
+			},
+		},
+		{
+			name: "user before team",
+			cfg:  config.RateLimitConfig{UserPerMinute: 2, TeamPerMinute: 3},
+			calls: []call{
+				{user: "alice", path: "/teams/t1"},
+				{user: "alice", path: "/teams/t1"},
+				// Refused by the user layer, so not charged to the team
+				{user: "alice", path: "/teams/t1", layer: LayerUser},
+				{user: "alice", path: "/teams/t1", layer: LayerUser},
+				{user: "bob", path: "/teams/t1"},
+				{user: "bob", path: "/teams/t1", layer: LayerTeam},
+			},
+		},
+		{
+			name: "team before global",
+			cfg:  config.RateLimitConfig{TeamPerMinute: 1, GlobalPerSecond: 2},
+			calls: []call{
+				{user: "alice", path: "/teams/t1"},
+				{user: "bob", path: "/channels/c1", layer: LayerTeam},
+				{user: "carol", path: "/teams/t1", layer: LayerTeam},
+				// Outside the team, only the global layer counts
+				{user: "dave", path: "/teams/t1"},
+				{user: "erin", path: "/teams/t2", layer: LayerGlobal},
+			},
+		},
+		{
+			name: "outsiders don't use up a team's budget",
+			cfg:  config.RateLimitConfig{TeamPerMinute: 1},
+			calls: []call{
+				{user: "mallory", path: "/teams/t1"},
+				{user: "mallory", path: "/channels/c1"},
+				{user: "alice", path: "/channels/c1"},
+				{user: "bob", path: "/teams/t1", layer: LayerTeam},
+			},
+		},
+		{
+			name: "anonymous requests only count per ip",
+			cfg:  config.RateLimitConfig{RequestsPerMinute: 5, UserPerMinute: 1, GlobalPerSecond: 1},
+			calls: []call{
+				{path: "/teams/t1"},
+				{path: "/teams/t1"},
+				{path: "/teams/t1"},
+			},
+		},
+		{
+			name: "zero turns a layer off",
+			cfg:  config.RateLimitConfig{},
+			calls: []call{
+				{user: "alice", path: "/teams/t1"},
+				{user: "alice", path: "/teams/t1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, handler := newTestLimiter(tt.cfg, members, ok)
+			for i, c := range tt.calls {
+				req := httptest.NewRequest(http.MethodGet, c.path, nil)
+				if c.user != "" {
+					req.Header.Set("X-User", c.user)
+				}
+				if c.ip != "" {
+					req.RemoteAddr = c.ip + ":1234"
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				if c.layer == "" {
+					if rec.Code != http.StatusNoContent {
+						t.Fatalf("call %d by %q refused by %s", i, c.user, rec.Header().Get("X-RateLimit-Layer"))
+					}
+					continue
+				}
+				if rec.Code != http.StatusTooManyRequests {
+					t.Fatalf("call %d by %q = %d, want refused by %s", i, c.user, rec.Code, c.layer)
+				}
+				var body struct {
+					Throttle RequestThrottle `json:"throttle"`
+				}
+				json.Unmarshal(rec.Body.Bytes(), &body)
+				if body.Throttle.Layer != c.layer || rec.Header().Get("X-RateLimit-Layer") != c.layer {
+					t.Errorf("call %d by %q refused by %s, want %s", i, c.user, body.Throttle.Layer, c.layer)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestLimiterReportsTightestLayer(t *testing.T) {
+	cfg := config.RateLimitConfig{RequestsPerMinute: 100, UserPerMinute: 4, TeamPerMinute: 10}
+	_, handler := newTestLimiter(cfg, memberTeams{"alice": true}, ok)
+
+	req := httptest.NewRequest(http.MethodGet, "/teams/t1", nil)
+	req.Header.Set("X-User", "alice")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	want := map[string]string{
+		"X-RateLimit-Layer":     LayerUser,
+		"X-RateLimit-Limit":     "4",
+		"X-RateLimit-Remaining": "3",
+		"X-RateLimit-Reset":     "1749636060",
+	}
+	for header, value := range want {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestRequestLimiterRetryAfter(t *testing.T) {
+	cfg := config.RateLimitConfig{UserPerMinute: 1}
+	_, handler := newTestLimiter(cfg, memberTeams{}, ok)
+
+	var rec *httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/teams/t1", nil)
+		req.Header.Set("X-User", "alice")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+	}
+	// 30 seconds into the minute
+	if got := rec.Header().Get("Retry-After"); got != "31" {
+		t.Errorf("Retry-After = %q, want 31", got)
+	}
+}
+
+func TestRequestLimiterConcurrency(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	})
+	limiter, handler := newTestLimiter(config.RateLimitConfig{GlobalConcurrency: 1}, memberTeams{}, slow)
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/teams/t1", nil)
+		req.Header.Set("X-User", "alice")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if rec := request(); rec.Code != http.StatusNoContent {
+			t.Errorf("first request = %d", rec.Code)
+		}
+	}()
+	<-started
+
+	rec := request()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Layer") != LayerGlobal {
+		t.Errorf("second request = %d from %s, want refused by global", rec.Code, rec.Header().Get("X-RateLimit-Layer"))
+	}
+	close(release)
+	wg.Wait()
+
+	if n := limiter.inFlight.Load(); n != 0 {
+		t.Errorf("%d requests still counted in flight", n)
+	}
+	go func() { <-started }()
+	if rec := request(); rec.Code != http.StatusNoContent {
+		t.Errorf("request after the first finished = %d", rec.Code)
+	}
+}
@@ -0,0 +1,158 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cbalite/backend/internal/cache"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/internal/domain"
+)
+
+const window = time.Minute
+
+// Integration identifies an app or inbound webhook posting messages.
+type Integration struct {
+	Kind domain.IntegrationKind
+	ID   string
+}
+
+func (i Integration) String() string {
+	return string(i.Kind) + ":" + i.ID
+}
+
+// Limiter counts the messages each integration posts in fixed one-minute
+// windows, in the shared cache when there is one and in process memory
+// otherwise. Counters are kept per integration and apart from the per-IP
+// request limiter, so a flooding integration uses up neither people's
+// budget nor that of the team's other integrations.
+type Limiter struct {
+	cfg *config.RateLimitConfig
+	counter
+}
+
+// counter keeps expiring counts in the shared cache when there is one and in
+// process memory otherwise.
+type counter struct {
+	cache cache.Cache
+
+	mu        sync.Mutex
+	counts    map[string]*localCount
+	nextSweep time.Time
+}
+
+type localCount struct {
+	n       int64
+	expires time.Time
+}
+
+func NewLimiter(cfg *config.RateLimitConfig, cache cache.Cache) *Limiter {
+	return &Limiter{cfg: cfg, counter: newCounter(cache)}
+}
+
+func newCounter(cache cache.Cache) counter {
+	return counter{cache: cache, counts: make(map[string]*localCount)}
+}
+
+// Allow counts one message from the integration in the team and, when
+// channelID is set, the channel. It returns nil if the message may be
+// posted. Otherwise it returns the limit that was hit, and abusive is true
+// once the integration has been throttled often enough to be suspended.
+// Counting is best effort: a cache error lets the message through.
+func (l *Limiter) Allow(ctx context.Context, integration Integration, teamID, channelID string) (throttle *domain.IntegrationThrottle, abusive bool) {
+	now := time.Now()
+	start := now.Truncate(window)
+
+	scopes := []struct {
+		name  string
+		id    string
+		limit int
+	}{
+		{"team", teamID, l.cfg.IntegrationTeamPerMinute},
+		{"channel", channelID, l.cfg.IntegrationChannelPerMinute},
+	}
+	for _, scope := range scopes {
+		if scope.id == "" || scope.limit <= 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("integration_rate:%s:%s:%s:%d", integration, scope.name, scope.id, start.Unix())
+		count, err := l.increment(ctx, key, window)
+		if err != nil || count <= int64(scope.limit) {
+			continue
+		}
+
+		throttle = &domain.IntegrationThrottle{
+			Code:              domain.ThrottleRateLimited,
+			Scope:             scope.name,
+			Limit:             scope.limit,
+			WindowSeconds:     int(window.Seconds()),
+			RetryAfterSeconds: int(start.Add(window).Sub(now).Seconds()) + 1,
+		}
+		return throttle, l.strike(ctx, integration, teamID)
+	}
+
+	return nil, false
+}
+
+// Forgive clears the integration's throttled count in the team, once it has
+// been suspended or an admin has lifted its suspension.
+func (l *Limiter) Forgive(ctx context.Context, integration Integration, teamID string) {
+	key := strikeKey(integration, teamID)
+	if l.cache.Enabled() {
+		l.cache.Delete(ctx, key)
+		return
+	}
+
+	l.mu.Lock()
+	delete(l.counts, key)
+	l.mu.Unlock()
+}
+
+// strike counts a throttled message and reports whether the integration has
+// now been throttled too often.
+func (l *Limiter) strike(ctx context.Context, integration Integration, teamID string) bool {
+	if l.cfg.IntegrationSuspendAfter <= 0 {
+		return false
+	}
+
+	count, err := l.increment(ctx, strikeKey(integration, teamID), l.cfg.IntegrationSuspendWindow)
+	return err == nil && count >= int64(l.cfg.IntegrationSuspendAfter)
+}
+
+func strikeKey(integration Integration, teamID string) string {
+	return fmt.Sprintf("integration_throttled:%s:%s", integration, teamID)
+}
+
+func (c *counter) increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if c.cache.Enabled() {
+		count, err := c.cache.Increment(ctx, key)
+		if err == nil && count == 1 {
+			c.cache.Expire(ctx, key, ttl)
+		}
+		return count, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.After(c.nextSweep) {
+		for k, local := range c.counts {
+			if now.After(local.expires) {
+				delete(c.counts, k)
+			}
+		}
+		c.nextSweep = now.Add(window)
+	}
+
+	local, ok := c.counts[key]
+	if !ok || now.After(local.expires) {
+		local = &localCount{expires: now.Add(ttl)}
+		c.counts[key] = local
+	}
+	local.n++
+	return local.n, nil
+}
@@ -0,0 +1,311 @@
+package timeparse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Times of day used when an expression names a day but no time, or a part
+// of the day instead of a time.
+const (
+	defaultHour   = 9
+	morningHour   = 9
+	afternoonHour = 14
+	eveningHour   = 18
+	tonightHour   = 20
+)
+
+// maxAhead bounds relative expressions, so huge amounts are refused rather
+// than overflowing.
+const maxAhead = 100 * 365 * 24 * time.Hour
+
+var ErrUnrecognized = errors.New("couldn't understand that time")
+
+var (
+	relativePattern = regexp.MustCompile(`^in (an?|half an|\d+) ?(minutes?|mins?|m|hours?|hrs?|h|days?|d|weeks?|w)$`)
+	isoDatePattern  = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	monthDayPattern = regexp.MustCompile(`\b(january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec)\.? (\d{1,2})(?:st|nd|rd|th)?\b`)
+	dayMonthPattern = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)? (january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec)\b`)
+	weekdayPattern  = regexp.MustCompile(`\b(?:(next|this) )?(monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tues|tue|wed|thurs|thur|thu|fri|sat|sun)\b`)
+	clockPattern    = regexp.MustCompile(`\b(\d{1,2})(?::(\d{2}))? ?(am|pm)\b|\b(\d{1,2}):(\d{2})\b`)
+	fillerPattern   = regexp.MustCompile(`\b(at|on|the|of|this)\b|[,.]`)
+)
+
+var months = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse resolves expressions like "tomorrow 9am", "next monday",
+// "in 2 hours", "friday at 3:30pm", "may 4 noon" or an RFC 3339 timestamp,
+// relative to now in loc. A day without a time means 9am; a time without a
+// day means its next occurrence. "tonight" means 8pm, or the next hour once
+// it's past 8pm.
+func Parse(text string, now time.Time, loc *time.Location) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, nil
+	}
+
+	s := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	now = now.In(loc)
+
+	switch s {
+	case "":
+		return time.Time{}, ErrUnrecognized
+	case "now":
+		return now, nil
+	}
+
+	if m := relativePattern.FindStringSubmatch(s); m != nil {
+		return relative(now, m[1], m[2])
+	}
+
+	var day *time.Time
+	setDay := func(t time.Time) error {
+		if day != nil {
+			return fmt.Errorf("%w: more than one day given", ErrUnrecognized)
+		}
+		day = &t
+		return nil
+	}
+	hour, minute, hasTime := 0, 0, false
+	setTime := func(h, m int) error {
+		if hasTime {
+			return fmt.Errorf("%w: more than one time given", ErrUnrecognized)
+		}
+		hour, minute, hasTime = h, m, true
+		return nil
+	}
+	take := func(pattern *regexp.Regexp) []string {
+		m := pattern.FindStringSubmatch(s)
+		if m != nil {
+			s = strings.Replace(s, m[0], " ", 1)
+		}
+		return m
+	}
+	takeWord := func(word string) bool {
+		pattern := regexp.MustCompile(`\b` + word + `\b`)
+		if !pattern.MatchString(s) {
+			return false
+		}
+		s = pattern.ReplaceAllString(s, " ")
+		return true
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	// Days
+	if takeWord("day after tomorrow") {
+		if err := setDay(today.AddDate(0, 0, 2)); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if takeWord("tomorrow") {
+		if err := setDay(today.AddDate(0, 0, 1)); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if takeWord("today") {
+		if err := setDay(today); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if takeWord("tonight") {
+		if err := setDay(today); err != nil {
+			return time.Time{}, err
+		}
+		if !clockPattern.MatchString(s) {
+			hour, minute, hasTime = tonightHour, 0, true
+			// Once the evening has started, tonight is the next hour
+			if now.Hour() >= tonightHour {
+				next := time.Date(today.Year(), today.Month(), today.Day(), now.Hour()+1, 0, 0, 0, loc)
+				*day = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, loc)
+				hour = next.Hour()
+			}
+		}
+	}
+	if takeWord("next week") {
+		if err := setDay(nextWeekday(today, time.Monday, false)); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if m := take(isoDatePattern); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		dayOfMonth, _ := strconv.Atoi(m[3])
+		t, err := date(year, time.Month(month), dayOfMonth, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if err := setDay(t); err != nil {
+			return time.Time{}, err
+		}
+	}
+	for _, pattern := range []*regexp.Regexp{monthDayPattern, dayMonthPattern} {
+		m := take(pattern)
+		if m == nil {
+			continue
+		}
+		monthName, dayText := m[1], m[2]
+		if pattern == dayMonthPattern {
+			monthName, dayText = m[2], m[1]
+		}
+		dayOfMonth, _ := strconv.Atoi(dayText)
+		t, err := date(today.Year(), months[monthName[:3]], dayOfMonth, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// Dates without a year are the next time that date comes round
+		if t.Before(today) {
+			if t, err = date(today.Year()+1, months[monthName[:3]], dayOfMonth, loc); err != nil {
+				return time.Time{}, err
+			}
+		}
+		if err := setDay(t); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if m := take(weekdayPattern); m != nil {
+		if err := setDay(nextWeekday(today, weekdays[m[2][:3]], m[1] == "this")); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	// Times
+	if m := take(clockPattern); m != nil {
+		h, min, err := clock(m)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if err := setTime(h, min); err != nil {
+			return time.Time{}, err
+		}
+	}
+	for word, h := range map[string]int{"noon": 12, "midday": 12, "midnight": 0, "morning": morningHour, "afternoon": afternoonHour, "evening": eveningHour} {
+		if takeWord(word) {
+			if err := setTime(h, 0); err != nil {
+				return time.Time{}, err
+			}
+		}
+	}
+
+	if strings.TrimSpace(fillerPattern.ReplaceAllString(s, " ")) != "" {
+		return time.Time{}, ErrUnrecognized
+	}
+	if day == nil && !hasTime {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	if day == nil {
+		t := time.Date(today.Year(), today.Month(), today.Day(), hour, minute, 0, 0, loc)
+		if !t.After(now) {
+			t = time.Date(today.Year(), today.Month(), today.Day()+1, hour, minute, 0, 0, loc)
+		}
+		return t, nil
+	}
+	if !hasTime {
+		hour = defaultHour
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc), nil
+}
+
+func relative(now time.Time, amount, unit string) (time.Time, error) {
+	n := 1
+	half := false
+	switch amount {
+	case "a", "an":
+	case "half an":
+		half = true
+	default:
+		var err error
+		if n, err = strconv.Atoi(amount); err != nil {
+			return time.Time{}, fmt.Errorf("%w: too far ahead", ErrUnrecognized)
+		}
+	}
+
+	var step time.Duration
+	switch unit[0] {
+	case 'm':
+		step = time.Minute
+	case 'h':
+		step = time.Hour
+	case 'd':
+		step = 24 * time.Hour
+	case 'w':
+		step = 7 * 24 * time.Hour
+	}
+	if n > int(maxAhead/step) {
+		return time.Time{}, fmt.Errorf("%w: too far ahead", ErrUnrecognized)
+	}
+
+	switch {
+	case half:
+		return now.Add(step / 2), nil
+	case unit[0] == 'd':
+		return now.AddDate(0, 0, n), nil
+	case unit[0] == 'w':
+		return now.AddDate(0, 0, 7*n), nil
+	}
+	return now.Add(time.Duration(n) * step), nil
+}
+
+// nextWeekday is the next such weekday after today. "this friday" on a
+// Friday means today.
+func nextWeekday(today time.Time, weekday time.Weekday, includeToday bool) time.Time {
+	days := (int(weekday) - int(today.Weekday()) + 7) % 7
+	if days == 0 && !includeToday {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+func date(year int, month time.Month, day int, loc *time.Location) (time.Time, error) {
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	if t.Day() != day || t.Month() != month {
+		return time.Time{}, fmt.Errorf("%w: no such date", ErrUnrecognized)
+	}
+	return t, nil
+}
+
+func clock(m []string) (int, int, error) {
+	hourText, minuteText, meridiem := m[1], m[2], m[3]
+	if hourText == "" {
+		hourText, minuteText = m[4], m[5]
+	}
+
+	hour, _ := strconv.Atoi(hourText)
+	minute := 0
+	if minuteText != "" {
+		minute, _ = strconv.Atoi(minuteText)
+	}
+	if minute > 59 {
+		return 0, 0, fmt.Errorf("%w: invalid time", ErrUnrecognized)
+	}
+
+	switch meridiem {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("%w: invalid time", ErrUnrecognized)
+		}
+		hour %= 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	default:
+		if hour > 23 {
+			return 0, 0, fmt.Errorf("%w: invalid time", ErrUnrecognized)
+		}
+	}
+	return hour, minute, nil
+}
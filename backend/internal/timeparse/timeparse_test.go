@@ -0,0 +1,95 @@
+package timeparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Two hours behind UTC, so local and UTC days differ in the evening
+	loc := time.FixedZone("UTC-2", -2*60*60)
+	at := func(value string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	// A Wednesday morning
+	wednesday := at("2025-06-11 10:30")
+
+	tests := []struct {
+		name string
+		text string
+		now  time.Time
+		want string
+		// wantErr is set when the text should be refused
+		wantErr bool
+	}{
+		{name: "now", text: "now", now: wednesday, want: "2025-06-11 10:30"},
+		{name: "rfc 3339", text: "2025-07-01T12:00:00Z", now: wednesday, want: "2025-07-01 10:00"},
+		{name: "tomorrow with time", text: "tomorrow 9am", now: wednesday, want: "2025-06-12 09:00"},
+		{name: "tomorrow defaults to 9am", text: "Tomorrow", now: wednesday, want: "2025-06-12 09:00"},
+		{name: "day after tomorrow", text: "day after tomorrow at noon", now: wednesday, want: "2025-06-13 12:00"},
+		{name: "today afternoon", text: "today afternoon", now: wednesday, want: "2025-06-11 14:00"},
+		{name: "time later today", text: "3:30pm", now: wednesday, want: "2025-06-11 15:30"},
+		{name: "time already passed", text: "10am", now: wednesday, want: "2025-06-12 10:00"},
+		{name: "24 hour clock", text: "17:45", now: wednesday, want: "2025-06-11 17:45"},
+		{name: "midnight", text: "midnight", now: wednesday, want: "2025-06-12 00:00"},
+		{name: "weekday", text: "friday at 3:30pm", now: wednesday, want: "2025-06-13 15:30"},
+		{name: "same weekday is next week", text: "wednesday", now: wednesday, want: "2025-06-18 09:00"},
+		{name: "next weekday", text: "next mon", now: wednesday, want: "2025-06-16 09:00"},
+		{name: "next week", text: "next week", now: wednesday, want: "2025-06-16 09:00"},
+		{name: "this friday on a friday", text: "this friday", now: at("2025-06-13 08:00"), want: "2025-06-13 09:00"},
+		{name: "this friday on a friday with time", text: "this friday 5pm", now: at("2025-06-13 15:00"), want: "2025-06-13 17:00"},
+		{name: "friday on a friday", text: "friday", now: at("2025-06-13 08:00"), want: "2025-06-20 09:00"},
+		{name: "tonight", text: "tonight", now: wednesday, want: "2025-06-11 20:00"},
+		{name: "tonight with time", text: "tonight at 11pm", now: wednesday, want: "2025-06-11 23:00"},
+		{name: "tonight after 8pm", text: "tonight", now: at("2025-06-11 21:10"), want: "2025-06-11 22:00"},
+		{name: "tonight before midnight", text: "tonight", now: at("2025-06-11 23:30"), want: "2025-06-12 00:00"},
+		{name: "month day", text: "may 4 noon", now: at("2025-03-01 09:00"), want: "2025-05-04 12:00"},
+		{name: "day month", text: "4th july", now: wednesday, want: "2025-07-04 09:00"},
+		{name: "month day passed", text: "may 4", now: wednesday, want: "2026-05-04 09:00"},
+		{name: "dec 31 on dec 31", text: "dec 31", now: at("2025-12-31 08:00"), want: "2025-12-31 09:00"},
+		{name: "dec 31 on jan 1", text: "dec 31", now: at("2026-01-01 08:00"), want: "2026-12-31 09:00"},
+		{name: "jan 1 on dec 31", text: "jan 1", now: at("2025-12-31 08:00"), want: "2026-01-01 09:00"},
+		{name: "dec 31 in the local year", text: "dec 31 6pm", now: time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC), want: "2025-12-31 18:00"},
+		{name: "iso date", text: "2026-11-01 14:00", now: wednesday, want: "2026-11-01 14:00"},
+		{name: "in minutes", text: "in 15 min", now: wednesday, want: "2025-06-11 10:45"},
+		{name: "in an hour", text: "in an hour", now: wednesday, want: "2025-06-11 11:30"},
+		{name: "in half an hour", text: "in half an hour", now: wednesday, want: "2025-06-11 11:00"},
+		{name: "in days", text: "in 3 days", now: wednesday, want: "2025-06-14 10:30"},
+		{name: "in weeks", text: "in 2w", now: wednesday, want: "2025-06-25 10:30"},
+
+		{name: "empty", text: "  ", now: wednesday, wantErr: true},
+		{name: "gibberish", text: "whenever", now: wednesday, wantErr: true},
+		{name: "leftover words", text: "tomorrow or so", now: wednesday, wantErr: true},
+		{name: "two days", text: "tomorrow friday", now: wednesday, wantErr: true},
+		{name: "two times", text: "9am noon", now: wednesday, wantErr: true},
+		{name: "no such date", text: "feb 30", now: wednesday, wantErr: true},
+		{name: "no such iso date", text: "2025-02-29", now: wednesday, wantErr: true},
+		{name: "hour out of range", text: "13pm", now: wednesday, wantErr: true},
+		{name: "minute out of range", text: "10:75", now: wednesday, wantErr: true},
+		{name: "huge relative", text: "in 99999999999 minutes", now: wednesday, wantErr: true},
+		{name: "relative past int64", text: "in 99999999999999999999 days", now: wednesday, wantErr: true},
+		{name: "relative too far", text: "in 9999 weeks", now: wednesday, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text, tt.now, loc)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnrecognized) {
+					t.Fatalf("Parse(%q) = %v, %v; want ErrUnrecognized", tt.text, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.text, err)
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Parse(%q) = %s, want %s", tt.text, got.In(loc).Format("Mon 2006-01-02 15:04"), want.Format("Mon 2006-01-02 15:04"))
+			}
+		})
+	}
+}
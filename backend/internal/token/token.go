@@ -0,0 +1,24 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Generate returns a new random secret for use in URLs or headers, and the
+// hash that is stored in its place.
+func Generate() (string, string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	secret := hex.EncodeToString(buf)
+	return secret, Hash(secret), nil
+}
+
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Compare compares dotted numeric versions such as "2.10.1" and returns -1, 0
+// or 1. A leading "v" and any pre-release or build suffix are ignored, and
+// missing parts count as zero.
+func Compare(a, b string) int {
+	pa, pb := parts(a), parts(b)
+	for len(pa) < len(pb) {
+		pa = append(pa, 0)
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, 0)
+	}
+
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func parts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	var nums []int
+	for _, part := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(part)
+		nums = append(nums, n)
+	}
+	return nums
+}
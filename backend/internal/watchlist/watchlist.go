@@ -0,0 +1,139 @@
+package watchlist
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cbalite/backend/internal/domain"
+)
+
+const (
+	MaxTerms         = 200
+	MaxTermLength    = 200
+	maxHitTextLength = 100
+)
+
+// Word boundaries are spelled out rather than using \b, which only knows
+// ASCII letters.
+const (
+	wordStart = `(?:^|[^\p{L}\p{N}_])`
+	wordEnd   = `(?:$|[^\p{L}\p{N}_])`
+)
+
+type term struct {
+	source string
+	regex  bool
+	re     *regexp.Regexp
+	// group is the submatch holding the matched text; keywords need one to
+	// leave out the characters around the word.
+	group int
+}
+
+// Matcher is a compiled watch list.
+type Matcher struct {
+	terms []term
+}
+
+// Normalize trims the keywords and patterns and drops empty and duplicate
+// ones, keeping their order.
+func Normalize(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	normalized := []string{}
+	for _, t := range terms {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		normalized = append(normalized, t)
+	}
+	return normalized
+}
+
+// Compile builds the matcher for a list. Keywords match whole words;
+// patterns use RE2 syntax (https://github.com/google/re2/wiki/Syntax).
+// Both fold case unless the list is case sensitive. It fails on a list
+// with no terms, too many or too long terms, or a pattern that doesn't
+// compile or would match empty text, naming the offending pattern.
+func Compile(list domain.WatchList) (*Matcher, error) {
+	if len(list.Keywords)+len(list.Patterns) == 0 {
+		return nil, fmt.Errorf("a watch list needs at least one keyword or pattern")
+	}
+	if len(list.Keywords)+len(list.Patterns) > MaxTerms {
+		return nil, fmt.Errorf("a watch list can have at most %d keywords and patterns", MaxTerms)
+	}
+
+	flags := "(?i)"
+	if list.CaseSensitive {
+		flags = ""
+	}
+
+	m := &Matcher{}
+	for _, keyword := range list.Keywords {
+		if len(keyword) > MaxTermLength {
+			return nil, fmt.Errorf("keywords can be at most %d characters", MaxTermLength)
+		}
+		re := regexp.MustCompile(flags + wordStart + "(" + regexp.QuoteMeta(keyword) + ")" + wordEnd)
+		m.terms = append(m.terms, term{source: keyword, re: re, group: 1})
+	}
+	for _, pattern := range list.Patterns {
+		if len(pattern) > MaxTermLength {
+			return nil, fmt.Errorf("patterns can be at most %d characters", MaxTermLength)
+		}
+		re, err := regexp.Compile(flags + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("pattern %q matches empty text", pattern)
+		}
+		m.terms = append(m.terms, term{source: pattern, regex: true, re: re})
+	}
+
+	return m, nil
+}
+
+// Match returns a hit for each keyword and pattern found in content, with
+// the first text each one matched.
+func (m *Matcher) Match(content string) []domain.WatchListHit {
+	var hits []domain.WatchListHit
+	for _, t := range m.terms {
+		match := t.re.FindStringSubmatch(content)
+		if match == nil {
+			continue
+		}
+
+		text := match[t.group]
+		if runes := []rune(text); len(runes) > maxHitTextLength {
+			text = string(runes[:maxHitTextLength]) + "…"
+		}
+		hits = append(hits, domain.WatchListHit{Term: t.source, Regex: t.regex, Text: text})
+	}
+	return hits
+}
+
+// Notice is the message posted to a list's notify channel when it flags a
+// message. It names the matched terms rather than quoting the message, which
+// may be in a channel the moderators can't see.
+func Notice(list domain.WatchList, sender, channel string, hits []domain.WatchListHit) string {
+	terms := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Regex {
+			terms = append(terms, "/"+hit.Term+"/")
+		} else {
+			terms = append(terms, fmt.Sprintf("%q", hit.Term))
+		}
+	}
+	return fmt.Sprintf("**%s** flagged a message from @%s in #%s\nMatched: %s", list.Name, sender, channel, strings.Join(terms, ", "))
+}
+
+// Terms joins the matched keywords and patterns for the message.flagged
+// payload, where rule conditions can test them with contains.
+func Terms(hits []domain.WatchListHit) string {
+	terms := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		terms = append(terms, hit.Term)
+	}
+	return strings.Join(terms, ", ")
+}
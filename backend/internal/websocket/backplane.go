@@ -0,0 +1,299 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cbalite/backend/internal/metrics"
+	"github.com/google/uuid"
+)
+
+const (
+	// backplaneQueueSize is how many events can wait to be published before
+	// new ones are dropped.
+	backplaneQueueSize      = 1024
+	backplanePublishTimeout = 2 * time.Second
+	// backplaneErrorLogInterval keeps an unreachable backplane from
+	// flooding the logs.
+	backplaneErrorLogInterval = 10 * time.Second
+
+	// presenceSyncInterval is how often a server tells the others who is
+	// connected to it, so presence recovers from lost events.
+	presenceSyncInterval = 30 * time.Second
+	// remotePresenceTTL is how long another server's presence is trusted
+	// without a sync, after which its users count as gone.
+	remotePresenceTTL = 3 * presenceSyncInterval
+)
+
+// Kinds of backplane event.
+const (
+	eventBroadcast    = "broadcast"
+	eventUsers        = "users"
+	eventPresenceSync = "presence_sync"
+	eventDisconnect   = "disconnect"
+)
+
+var (
+	backplaneEvents = metrics.Default.NewCounterVec("cbalite_ws_backplane_events_total",
+		"WebSocket events exchanged with other servers, by direction (sent, received) and the region they came from.", "direction", "region")
+	backplaneDropped = metrics.Default.NewCounterVec("cbalite_ws_backplane_dropped_total",
+		"WebSocket events not shared with other servers because the backplane queue was full.")
+	backplaneReceivedDropped = metrics.Default.NewCounterVec("cbalite_ws_backplane_received_dropped_total",
+		"WebSocket events from other servers not delivered here because the hub's broadcast queue was full, by the region they came from.", "region")
+)
+
+// Backplane carries WebSocket events between servers. Each region has its
+// own stream: servers add their events to their region's stream and read
+// every region's.
+type Backplane interface {
+	// Publish adds an event to this region's stream.
+	Publish(ctx context.Context, event []byte) error
+	// Consume calls handle with each event added to any region's stream
+	// from now on, with the region whose stream it was read from, until
+	// ctx ends.
+	Consume(ctx context.Context, handle func(region string, event []byte))
+}
+
+// envelope is a hub event as it travels over the backplane.
+//
+// Events only ever travel from the server they started on to the others.
+// A server delivers what it reads to its own clients and never publishes
+// it again, and skips the events it published itself, so nothing can go
+// round in a loop or be delivered twice, whatever the regions' topology.
+type envelope struct {
+	Kind   string `json:"kind"`
+	Origin string `json:"origin"`
+	Region string `json:"region"`
+
+	// Message is the frame as clients get it, so it's encoded once.
+	Message    json.RawMessage `json:"message,omitempty"`
+	UserIDs    []string        `json:"user_ids,omitempty"`
+	AcceptedAt time.Time       `json:"accepted_at,omitempty"`
+
+	Presence []presenceRecord `json:"presence,omitempty"`
+}
+
+// presenceRecord is one user connected to a server.
+type presenceRecord struct {
+	UserID      string `json:"user_id"`
+	TeamID      string `json:"team_id"`
+	OutOfOffice bool   `json:"out_of_office,omitempty"`
+}
+
+// remoteServer is what a hub knows of another server's connections.
+type remoteServer struct {
+	region string
+	seen   time.Time
+	users  map[string]presenceRecord
+}
+
+// SetRegion tags the hub's connections and presence with the region the
+// server runs in. Call it before Run.
+func (h *Hub) SetRegion(region string) {
+	h.region = region
+}
+
+// UseBackplane shares the hub's broadcasts and presence with every other
+// server on the backplane, in this region and the others, until ctx ends.
+// Call it before Run.
+func (h *Hub) UseBackplane(ctx context.Context, backplane Backplane) {
+	h.backplane = backplane
+	h.instanceID = uuid.NewString()
+	h.outgoing = make(chan []byte, backplaneQueueSize)
+
+	go h.publishEvents(ctx)
+	go backplane.Consume(ctx, h.receive)
+}
+
+// replicateFrame queues a frame for the other servers and returns it.
+func (h *Hub) replicateFrame(kind string, frame *Frame, userIDs []string) *Frame {
+	if h.backplane != nil {
+		h.replicate(&envelope{Kind: kind, Message: frame.out.Bytes(), UserIDs: userIDs, AcceptedAt: frame.out.acceptedAt})
+	}
+	return frame
+}
+
+// replicate queues an event for the other servers. It never blocks: when
+// the backplane falls behind, events are dropped for the other servers,
+// like a slow client's.
+func (h *Hub) replicate(event *envelope) {
+	if h.backplane == nil {
+		return
+	}
+	event.Origin = h.instanceID
+	event.Region = h.region
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal backplane event")
+		return
+	}
+	select {
+	case h.outgoing <- data:
+	default:
+		backplaneDropped.With().Inc()
+	}
+}
+
+func (h *Hub) publishEvents(ctx context.Context) {
+	var lastLogged time.Time
+	for {
+		var data []byte
+		select {
+		case <-ctx.Done():
+			return
+		case data = <-h.outgoing:
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, backplanePublishTimeout)
+		err := h.backplane.Publish(publishCtx, data)
+		cancel()
+		if err != nil {
+			if time.Since(lastLogged) >= backplaneErrorLogInterval {
+				lastLogged = time.Now()
+				h.logger.WithError(err).Warn("Failed to publish WebSocket event to the backplane")
+			}
+			continue
+		}
+		backplaneEvents.With("sent", h.region).Inc()
+	}
+}
+
+// receive delivers another server's event to this server's clients. It
+// runs on the backplane's readers and doesn't wait for room in the
+// broadcast queue: when the hub falls behind, broadcasts from other
+// servers are dropped, as replicate drops them on the way out.
+func (h *Hub) receive(region string, data []byte) {
+	var event envelope
+	if err := json.Unmarshal(data, &event); err != nil {
+		h.logger.WithError(err).Warn("Ignoring malformed backplane event")
+		return
+	}
+	// Only the server an event started on may add it to a stream, and only
+	// to its own region's; anything else was copied and is dropped
+	if event.Origin == h.instanceID || event.Region != region {
+		return
+	}
+	backplaneEvents.With("received", region).Inc()
+
+	switch event.Kind {
+	case eventPresenceSync:
+		h.syncRemote(event)
+		return
+	case eventDisconnect:
+		for _, userID := range event.UserIDs {
+			h.disconnectUser(userID)
+		}
+		return
+	}
+
+	// The frame goes out as it came in; the message is only for routing
+	var message Message
+	if err := json.Unmarshal(event.Message, &message); err != nil {
+		h.logger.WithError(err).Warn("Ignoring backplane event with a malformed message")
+		return
+	}
+	out := &outbound{buf: bytes.NewBuffer(event.Message), acceptedAt: event.AcceptedAt}
+	out.refs.Store(1)
+	frame := &Frame{message: &message, out: out}
+
+	switch event.Kind {
+	case eventBroadcast:
+		if MessageType(message.Type) == MessageTypePresence {
+			h.trackRemotePresence(event.Origin, event.Region, &message)
+		}
+		select {
+		case h.broadcast <- frame:
+		default:
+			backplaneReceivedDropped.With(region).Inc()
+		}
+	case eventUsers:
+		h.sendFrameToUsers(event.UserIDs, frame)
+	}
+}
+
+// syncRemote replaces what the hub knows of a server's connections with the
+// list it sent.
+func (h *Hub) syncRemote(event envelope) {
+	users := make(map[string]presenceRecord, len(event.Presence))
+	for _, record := range event.Presence {
+		users[record.UserID+":"+record.TeamID] = record
+	}
+
+	h.mu.Lock()
+	h.remote[event.Origin] = &remoteServer{region: event.Region, seen: h.clock.Now(), users: users}
+	h.mu.Unlock()
+}
+
+// trackRemotePresence applies a presence event from another server between
+// syncs.
+func (h *Hub) trackRemotePresence(origin, region string, message *Message) {
+	data, _ := message.Data.(map[string]interface{})
+	status, _ := data["status"].(string)
+	outOfOffice, _ := data["out_of_office"].(bool)
+	teamID, _ := strings.CutPrefix(message.Room, "team:")
+	record := presenceRecord{UserID: message.UserID, TeamID: teamID, OutOfOffice: outOfOffice}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	server := h.remote[origin]
+	if server == nil {
+		server = &remoteServer{region: region, seen: h.clock.Now(), users: make(map[string]presenceRecord)}
+		h.remote[origin] = server
+	}
+	if status == "online" {
+		server.users[record.UserID+":"+record.TeamID] = record
+	} else {
+		delete(server.users, record.UserID+":"+record.TeamID)
+	}
+}
+
+// syncPresence tells the other servers who is connected here and forgets
+// servers that have gone quiet.
+func (h *Hub) syncPresence() {
+	now := h.clock.Now()
+
+	h.mu.Lock()
+	var records []presenceRecord
+	seen := make(map[string]bool)
+	for _, client := range h.clients {
+		key := client.UserID + ":" + client.TeamID
+		if client.ReadOnly || client.UserID == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		records = append(records, presenceRecord{UserID: client.UserID, TeamID: client.TeamID, OutOfOffice: client.OutOfOffice})
+	}
+	for id, server := range h.remote {
+		if now.Sub(server.seen) > remotePresenceTTL {
+			delete(h.remote, id)
+		}
+	}
+	h.mu.Unlock()
+
+	h.replicate(&envelope{Kind: eventPresenceSync, Presence: records})
+}
+
+// remoteTeammates adds the users of teamID connected to other servers, if
+// in watch (or everyone, if nil), to online with the region they're
+// connected in. Users connected here keep this region. Must hold h.mu.
+func (h *Hub) remoteTeammates(teamID string, watch map[string]bool, online map[string]string) {
+	now := h.clock.Now()
+	for _, server := range h.remote {
+		if now.Sub(server.seen) > remotePresenceTTL {
+			continue
+		}
+		for _, record := range server.users {
+			if record.TeamID != teamID || (watch != nil && !watch[record.UserID]) {
+				continue
+			}
+			if _, ok := online[record.UserID]; !ok {
+				online[record.UserID] = server.region
+			}
+		}
+	}
+}
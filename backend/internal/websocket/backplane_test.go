@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// idleBackplane publishes nowhere and reads nothing until ctx ends.
+type idleBackplane struct {
+	consuming chan struct{}
+	stopped   chan struct{}
+}
+
+func (b *idleBackplane) Publish(ctx context.Context, event []byte) error {
+	return nil
+}
+
+func (b *idleBackplane) Consume(ctx context.Context, handle func(region string, event []byte)) {
+	close(b.consuming)
+	<-ctx.Done()
+	close(b.stopped)
+}
+
+func newBackplaneHub(t *testing.T) (*Hub, *idleBackplane) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	backplane := &idleBackplane{consuming: make(chan struct{}), stopped: make(chan struct{})}
+	hub := NewTestHub(logger.Nop(), NewFakeClock(time.Unix(0, 0)))
+	hub.SetRegion("us")
+	hub.UseBackplane(ctx, backplane)
+	<-backplane.consuming
+	return hub, backplane
+}
+
+func fillBroadcastQueue(t *testing.T, hub *Hub) {
+	t.Helper()
+	for len(hub.broadcast) < cap(hub.broadcast) {
+		hub.SendToRoom("team:t1", &Message{Type: string(MessageTypeChat)})
+	}
+}
+
+// within fails the test if fn hasn't returned after a second.
+func within(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s blocked", what)
+	}
+}
+
+func TestReceiveDropsWhenBroadcastQueueIsFull(t *testing.T) {
+	hub, _ := newBackplaneHub(t)
+	fillBroadcastQueue(t, hub)
+
+	message, _ := json.Marshal(&Message{Type: string(MessageTypeChat), Room: "team:t1"})
+	event, _ := json.Marshal(&envelope{Kind: eventBroadcast, Origin: "other-server", Region: "eu", Message: message})
+
+	before := backplaneReceivedDropped.With("eu").Value()
+	within(t, "receive", func() { hub.receive("eu", event) })
+	if got := backplaneReceivedDropped.With("eu").Value() - before; got != 1 {
+		t.Errorf("dropped %v events, want 1", got)
+	}
+}
+
+func TestPresenceDoesNotWaitForBroadcastQueue(t *testing.T) {
+	hub, _ := newBackplaneHub(t)
+	fillBroadcastQueue(t, hub)
+
+	client := &Client{ID: "c1", UserID: "u1", TeamID: "t1", Hub: hub, Rooms: make(map[string]bool)}
+	hub.Register(client)
+	within(t, "registering", func() { hub.registerClient(<-hub.register) })
+	within(t, "unregistering", func() { hub.unregisterClient(client) })
+}
+
+func TestBackplaneStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	backplane := &idleBackplane{consuming: make(chan struct{}), stopped: make(chan struct{})}
+	hub := NewTestHub(logger.Nop(), NewFakeClock(time.Unix(0, 0)))
+	hub.UseBackplane(ctx, backplane)
+	<-backplane.consuming
+
+	cancel()
+	select {
+	case <-backplane.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("backplane still consuming after the context ended")
+	}
+}
@@ -5,13 +5,17 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/cbalite/backend/internal/correlation"
 )
 
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512 * 1024
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize is the most a frame may be before the connection is
+	// dropped; frames up to this size get an error frame when they break
+	// their type's limit in messageLimits.
+	maxMessageSize = 64 * 1024
 )
 
 func (c *Client) ReadPump() {
@@ -21,9 +25,9 @@ func (c *Client) ReadPump() {
 	}()
 
 	c.Conn.SetReadLimit(maxMessageSize)
-	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.Conn.SetReadDeadline(c.Hub.clock.Now().Add(pongWait))
 	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.Conn.SetReadDeadline(c.Hub.clock.Now().Add(pongWait))
 		return nil
 	})
 
@@ -31,26 +35,23 @@ func (c *Client) ReadPump() {
 		_, message, err := c.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				c.Hub.logger.WithError(err).Errorf("WebSocket error for client %s", c.ID)
+				c.Hub.logger.WithRequestID(c.ID).WithError(err).Errorf("WebSocket error for client %s", c.ID)
 			}
 			break
 		}
 
-		var msg Message
-		if err := json.Unmarshal(message, &msg); err != nil {
-			c.Hub.logger.WithError(err).Error("Failed to unmarshal message")
-			continue
+		if !c.handleMessage(message) {
+			c.Hub.logger.WithRequestID(c.ID).Warnf("Disconnecting client %s (User: %s) for flooding", c.ID, c.UserID)
+			c.Conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "Too many messages"),
+				c.Hub.clock.Now().Add(writeWait))
+			break
 		}
-
-		msg.UserID = c.UserID
-		msg.Timestamp = time.Now()
-
-		c.handleMessage(&msg)
 	}
 }
 
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(pingPeriod)
+	ticker := c.Hub.clock.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
@@ -58,8 +59,8 @@ func (c *Client) WritePump() {
 
 	for {
 		select {
-		case message, ok := <-c.Send:
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+		case message, ok := <-c.send:
+			c.Conn.SetWriteDeadline(c.Hub.clock.Now().Add(writeWait))
 			if !ok {
 				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
@@ -67,22 +68,38 @@ func (c *Client) WritePump() {
 
 			w, err := c.Conn.NextWriter(websocket.TextMessage)
 			if err != nil {
+				observeWritten([]time.Time{message.acceptedAt}, time.Time{}, err)
+				message.release()
 				return
 			}
-			w.Write(message)
+			w.Write(message.Bytes())
+			// Acceptance times of the tracked frames in this write
+			var batch [8]time.Time
+			accepted := append(batch[:0], message.acceptedAt)
+			message.release()
 
-			n := len(c.Send)
+			n := len(c.send)
 			for i := 0; i < n; i++ {
+				next, ok := <-c.send
+				if !ok {
+					break
+				}
 				w.Write([]byte{'\n'})
-				w.Write(<-c.Send)
+				w.Write(next.Bytes())
+				if !next.acceptedAt.IsZero() {
+					accepted = append(accepted, next.acceptedAt)
+				}
+				next.release()
 			}
 
-			if err := w.Close(); err != nil {
+			err = w.Close()
+			observeWritten(accepted, c.Hub.clock.Now(), err)
+			if err != nil {
 				return
 			}
 
-		case <-ticker.C:
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+		case <-ticker.C():
+			c.Conn.SetWriteDeadline(c.Hub.clock.Now().Add(writeWait))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -90,36 +107,94 @@ func (c *Client) WritePump() {
 	}
 }
 
-func (c *Client) handleMessage(msg *Message) {
+// handleMessage validates and routes one frame from the client. Rejected
+// frames get an error frame back and count extra against the flood budget;
+// it returns false once the client is over budget and should be dropped.
+// Each frame gets its own correlation ID, which the frames it's relayed as
+// and its error frame carry in meta.
+func (c *Client) handleMessage(frame []byte) bool {
+	now := c.Hub.clock.Now()
+	correlationID := correlation.New()
+
+	var msg Message
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		c.sendError(&frameError{Code: ErrorInvalidJSON, Message: "Message must be a JSON object"}, "", correlationID)
+		return c.flood.spend(now, floodViolationCost)
+	}
+
+	if err := c.validate(&msg, len(frame)); err != nil {
+		c.Hub.logger.WithRequestID(correlationID).Debugf("Rejected %s message from client %s: %s", msg.Type, c.ID, err.Message)
+		c.sendError(err, msg.Type, correlationID)
+		return c.flood.spend(now, floodViolationCost)
+	}
+	if !c.flood.spend(now, 1) {
+		return false
+	}
+
+	// Read-only clients (kiosks) may still negotiate what they receive
+	if MessageType(msg.Type) == MessageTypeHello {
+		c.handleHello(&msg)
+		return true
+	}
+	if c.ReadOnly {
+		c.Hub.logger.Debugf("Ignoring %s message from read-only client %s", msg.Type, c.ID)
+		return true
+	}
+
+	msg.UserID = c.UserID
+	msg.Timestamp = now
+	msg.Meta = MetaFor(correlationID)
+
 	switch MessageType(msg.Type) {
 	case MessageTypeChat:
-		c.handleChatMessage(msg)
+		c.handleChatMessage(&msg)
 	case MessageTypeTaskUpdate:
-		c.handleTaskUpdate(msg)
+		c.handleTaskUpdate(&msg)
 	case MessageTypeTyping:
-		c.handleTypingIndicator(msg)
+		c.handleTypingIndicator(&msg)
 	case MessageTypeNotification:
-		c.handleNotification(msg)
-	default:
-		c.Hub.logger.Warnf("Unknown message type: %s", msg.Type)
+		c.handleNotification(&msg)
+	case MessageTypePresenceWatch:
+		c.handlePresenceWatch(&msg)
 	}
+	return true
+}
+
+// sendError reports a rejected frame to the client.
+func (c *Client) sendError(err *frameError, messageType, correlationID string) {
+	data := map[string]interface{}{
+		"code":    err.Code,
+		"message": err.Message,
+	}
+	if messageType != "" {
+		data["message_type"] = messageType
+	}
+	c.Hub.sendToClient(c, &Message{
+		Type:      string(MessageTypeError),
+		Data:      data,
+		Timestamp: c.Hub.clock.Now(),
+		Meta:      MetaFor(correlationID),
+	})
 }
 
 func (c *Client) handleChatMessage(msg *Message) {
 	if msg.Room == "" {
 		msg.Room = "team:" + c.TeamID
 	}
-	c.Hub.broadcast <- msg
+	// Chat frames are accepted once they pass validation; counting from
+	// here puts every recipient in the room in the delivery SLO.
+	msg.AcceptedAt = msg.Timestamp
+	c.Hub.publish(msg)
 }
 
 func (c *Client) handleTaskUpdate(msg *Message) {
 	msg.Room = "team:" + c.TeamID
-	c.Hub.broadcast <- msg
+	c.Hub.publish(msg)
 }
 
 func (c *Client) handleTypingIndicator(msg *Message) {
 	msg.Room = "team:" + c.TeamID
-	c.Hub.broadcast <- msg
+	c.Hub.publish(msg)
 }
 
 func (c *Client) handleNotification(msg *Message) {
@@ -157,15 +232,17 @@ func (c *Client) LeaveRoom(room string) {
 }
 
 func (c *Client) SendMessage(message interface{}) error {
-	data, err := json.Marshal(message)
+	out, err := c.Hub.encode(message)
 	if err != nil {
 		return err
 	}
+	defer out.release()
 
-	select {
-	case c.Send <- data:
-		return nil
-	default:
+	c.Hub.mu.RLock()
+	defer c.Hub.mu.RUnlock()
+
+	if c.Hub.clients[c.ID] != c || !c.Hub.deliver(c, out) {
 		return websocket.ErrCloseSent
 	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,264 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeConn is an in-memory connection. Frames the server writes arrive on
+// written; frames put on incoming are what the client sends.
+type fakeConn struct {
+	incoming chan []byte
+	written  chan written
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type written struct {
+	messageType int
+	data        []byte
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{incoming: make(chan []byte, 16), written: make(chan written, 64)}
+}
+
+func (c *fakeConn) ReadMessage() (int, []byte, error) {
+	data, ok := <-c.incoming
+	if !ok {
+		return 0, nil, &websocket.CloseError{Code: websocket.CloseGoingAway}
+	}
+	return websocket.TextMessage, data, nil
+}
+
+func (c *fakeConn) NextWriter(messageType int) (io.WriteCloser, error) {
+	return &fakeWriter{conn: c, messageType: messageType}, nil
+}
+
+func (c *fakeConn) WriteMessage(messageType int, data []byte) error {
+	c.written <- written{messageType: messageType, data: data}
+	return nil
+}
+
+func (c *fakeConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	return c.WriteMessage(messageType, data)
+}
+
+func (c *fakeConn) SetReadLimit(limit int64)                    {}
+func (c *fakeConn) SetReadDeadline(t time.Time) error           { return nil }
+func (c *fakeConn) SetWriteDeadline(t time.Time) error          { return nil }
+func (c *fakeConn) SetPongHandler(h func(appData string) error) {}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+type fakeWriter struct {
+	conn        *fakeConn
+	messageType int
+	buf         bytes.Buffer
+}
+
+func (w *fakeWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *fakeWriter) Close() error {
+	return w.conn.WriteMessage(w.messageType, w.buf.Bytes())
+}
+
+func nextWrite(t *testing.T, conn *fakeConn) written {
+	t.Helper()
+	select {
+	case w := <-conn.written:
+		return w
+	case <-time.After(time.Second):
+		t.Fatal("nothing written")
+		return written{}
+	}
+}
+
+// startWritePump runs the client's write pump and waits for its ping ticker.
+func startWritePump(t *testing.T, client *Client, clock *FakeClock) {
+	t.Helper()
+	before := clock.Tickers()
+	go client.WritePump()
+	waitFor(t, "the ping ticker", func() bool { return clock.Tickers() == before+1 })
+}
+
+func TestWritePumpPingsOnTheClock(t *testing.T) {
+	hub, clock := newHub(t)
+	conn := newFakeConn()
+	client := connect(hub, "c1", "u1", "t1")
+	client.Conn = conn
+	startWritePump(t, client, clock)
+
+	clock.Advance(pingPeriod - time.Second)
+	select {
+	case w := <-conn.written:
+		t.Fatalf("wrote %d before the ping period", w.messageType)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Second)
+	if w := nextWrite(t, conn); w.messageType != websocket.PingMessage {
+		t.Errorf("wrote message type %d, want a ping", w.messageType)
+	}
+
+	clock.Advance(pingPeriod)
+	if w := nextWrite(t, conn); w.messageType != websocket.PingMessage {
+		t.Errorf("wrote message type %d, want a second ping", w.messageType)
+	}
+}
+
+func TestWritePumpBatchesQueuedMessages(t *testing.T) {
+	hub, clock := newHub(t)
+	conn := newFakeConn()
+	client := connect(hub, "c1", "u1", "t1")
+	client.Conn = conn
+
+	for _, text := range []string{"one", "two", "three"} {
+		hub.SendToTeam("t1", &Message{Type: string(MessageTypeChat), Data: text})
+	}
+	hub.Drain()
+	startWritePump(t, client, clock)
+
+	w := nextWrite(t, conn)
+	if w.messageType != websocket.TextMessage {
+		t.Fatalf("wrote message type %d, want text", w.messageType)
+	}
+	lines := strings.Split(string(w.data), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"one"`) || !strings.Contains(lines[2], `"three"`) {
+		t.Errorf("wrote %q, want the three messages one per line", w.data)
+	}
+}
+
+func TestWritePumpClosesWhenUnregistered(t *testing.T) {
+	hub, clock := newHub(t)
+	conn := newFakeConn()
+	client := connect(hub, "c1", "u1", "t1")
+	client.Conn = conn
+	startWritePump(t, client, clock)
+
+	hub.Unregister(client)
+	hub.Drain()
+
+	if w := nextWrite(t, conn); w.messageType != websocket.CloseMessage {
+		t.Errorf("wrote message type %d, want a close", w.messageType)
+	}
+	waitFor(t, "the connection to close", conn.isClosed)
+	waitFor(t, "the ping ticker to stop", func() bool { return clock.Tickers() == 0 })
+}
+
+func TestReadPumpUnregistersWhenTheConnectionEnds(t *testing.T) {
+	hub, _ := newHub(t)
+	conn := newFakeConn()
+	client := connect(hub, "c1", "u1", "t1")
+	client.Conn = conn
+
+	done := make(chan struct{})
+	go func() {
+		client.ReadPump()
+		close(done)
+	}()
+	conn.incoming <- []byte(`{"type":"chat","data":{"content":"hello"}}`)
+	close(conn.incoming)
+	<-done
+	hub.Drain()
+
+	if !conn.isClosed() {
+		t.Error("connection left open")
+	}
+	if hub.Stats().Clients != 0 {
+		t.Error("client still registered")
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		readOnly bool
+		frame    string
+		// room is where the frame should be relayed, or "" for nowhere
+		room      string
+		errorCode string
+	}{
+		{name: "chat goes to the team", frame: `{"type":"chat","data":{"content":"hi"}}`, room: "team:t1"},
+		{name: "chat to a joined room", frame: `{"type":"chat","room":"project:1","data":{"content":"hi"}}`, room: "project:1"},
+		{name: "typing goes to the team", frame: `{"type":"typing","room":"project:1"}`, room: "team:t1"},
+		{name: "not JSON", frame: `hi`, errorCode: ErrorInvalidJSON},
+		{name: "unknown type", frame: `{"type":"presence"}`, errorCode: ErrorUnknownType},
+		{name: "read-only", readOnly: true, frame: `{"type":"chat","data":{"content":"hi"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, clock := newHub(t)
+			client := connect(hub, "c1", "u1", "t1")
+			client.ReadOnly = tt.readOnly
+			client.JoinRoom("project:1")
+
+			if !client.handleMessage([]byte(tt.frame)) {
+				t.Fatal("client disconnected")
+			}
+			hub.Drain()
+
+			got := received(t, client)
+			switch {
+			case tt.errorCode != "":
+				if len(got) != 1 {
+					t.Fatalf("got %v, want an error", types(got))
+				}
+				data, _ := got[0].Data.(map[string]interface{})
+				if got[0].Type != string(MessageTypeError) || data["code"] != tt.errorCode {
+					t.Fatalf("got %+v, want a %s error", got, tt.errorCode)
+				}
+				if got[0].Meta == nil || got[0].Meta.CorrelationID == "" {
+					t.Error("error frame has no correlation ID")
+				}
+			case tt.room != "":
+				if len(got) != 1 {
+					t.Fatalf("got %v, want the frame relayed back", types(got))
+				}
+				if got[0].Room != tt.room || got[0].UserID != "u1" || !got[0].Timestamp.Equal(clock.Now()) {
+					t.Errorf("relayed %+v, want room %s from u1 at %s", got[0], tt.room, clock.Now())
+				}
+			default:
+				if len(got) != 0 {
+					t.Errorf("got %v, want nothing", types(got))
+				}
+			}
+		})
+	}
+}
+
+func TestSendMessageAfterUnregister(t *testing.T) {
+	hub, _ := newHub(t)
+	client := connect(hub, "c1", "u1", "t1")
+
+	if err := client.SendMessage(&Message{Type: string(MessageTypeNotification)}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	hub.Unregister(client)
+	hub.Drain()
+
+	if err := client.SendMessage(&Message{Type: string(MessageTypeNotification)}); !errors.Is(err, websocket.ErrCloseSent) {
+		t.Errorf("SendMessage after unregistering = %v, want ErrCloseSent", err)
+	}
+}
@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Frame is a message serialized once and sent as is to every recipient.
+// The message is kept for routing and filtering only; changing it after
+// the frame is made doesn't change what is sent.
+type Frame struct {
+	message *Message
+	out     *outbound
+	// pooled frames are the hub's own, made per broadcast; their buffer
+	// goes back to the pool once the broadcast is done.
+	pooled bool
+}
+
+// NewFrame serializes message for callers that send the same payload to
+// many users or rooms. The frame can be kept and reused.
+func NewFrame(message *Message) (*Frame, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	out := &outbound{buf: bytes.NewBuffer(data), acceptedAt: message.AcceptedAt}
+	out.refs.Store(1)
+	return &Frame{message: message, out: out}, nil
+}
+
+// newFrame serializes message into a pooled buffer for a single broadcast.
+func (h *Hub) newFrame(message *Message) (*Frame, error) {
+	out, err := h.encode(message)
+	if err != nil {
+		return nil, err
+	}
+	out.acceptedAt = message.AcceptedAt
+	return &Frame{message: message, out: out, pooled: true}, nil
+}
+
+func (f *Frame) done() {
+	if f.pooled {
+		f.out.release()
+	}
+}
+
+// publish serializes message on the caller's goroutine, keeping that work
+// off the hub loop, and queues it for broadcast here and on the other
+// servers.
+func (h *Hub) publish(message *Message) {
+	frame, err := h.newFrame(message)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal message")
+		return
+	}
+	h.broadcast <- h.replicateFrame(eventBroadcast, frame, nil)
+}
+
+// SendFrame broadcasts a frame to its message's room, or to everyone when
+// the message has no room, on every server.
+func (h *Hub) SendFrame(frame *Frame) {
+	h.broadcast <- h.replicateFrame(eventBroadcast, frame, nil)
+}
+
+// SendFrameToUsers delivers a frame to every connection of the given users,
+// on every server.
+func (h *Hub) SendFrameToUsers(userIDs []string, frame *Frame) {
+	h.replicateFrame(eventUsers, frame, userIDs)
+	h.sendFrameToUsers(userIDs, frame)
+}
+
+// sendFrameToUsers delivers a frame to the given users' connections on
+// this server.
+func (h *Hub) sendFrameToUsers(userIDs []string, frame *Frame) {
+	users := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		users[userID] = true
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, client := range h.clients {
+		if users[client.UserID] {
+			h.deliver(client, frame.out)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package websocket
+
+import "sort"
+
+// ProtocolVersion is the newest WebSocket protocol the server speaks.
+// Clients that send a hello get min(theirs, ProtocolVersion).
+const ProtocolVersion = 1
+
+// Event categories a client can opt into with a hello. Chat messages,
+// notifications, errors and hellos aren't in a category and always arrive.
+const (
+	CategoryPresence = "presence"
+	CategoryTyping   = "typing"
+	CategoryTasks    = "tasks"
+)
+
+var allCategories = []string{CategoryPresence, CategoryTyping, CategoryTasks}
+
+// categoryOf is the category a message type belongs to, or "" if it isn't
+// optional.
+func categoryOf(messageType string) string {
+	switch MessageType(messageType) {
+	case MessageTypePresence, MessageTypeUserStatus:
+		return CategoryPresence
+	case MessageTypeTyping:
+		return CategoryTyping
+	case MessageTypeTaskUpdate:
+		return CategoryTasks
+	}
+	return ""
+}
+
+// Categories is what the client receives: every category until it sends a
+// hello, minus typing and presence for compact clients.
+func (c *Client) Categories() []string {
+	var categories []string
+	for _, category := range allCategories {
+		if c.receives(category) {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+func knownCategory(category string) bool {
+	for _, known := range allCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) receives(category string) bool {
+	if c.Compact && (category == CategoryTyping || category == CategoryPresence) {
+		return false
+	}
+	return c.categories == nil || c.categories[category]
+}
+
+// handleHello negotiates the protocol version and event categories with the
+// client and replies with what was accepted.
+func (c *Client) handleHello(msg *Message) {
+	data, _ := msg.Data.(map[string]interface{})
+
+	version := ProtocolVersion
+	if requested, ok := data["protocol_version"].(float64); ok && int(requested) < version {
+		version = int(requested)
+	}
+
+	var unsupported []string
+	if requested, ok := data["categories"].([]interface{}); ok {
+		categories := make(map[string]bool)
+		for _, item := range requested {
+			category, _ := item.(string)
+			if !knownCategory(category) {
+				unsupported = append(unsupported, category)
+				continue
+			}
+			categories[category] = true
+		}
+
+		c.Hub.mu.Lock()
+		c.categories = categories
+		c.Hub.mu.Unlock()
+	}
+	sort.Strings(unsupported)
+
+	c.Hub.mu.RLock()
+	accepted := c.Categories()
+	c.Hub.mu.RUnlock()
+	if accepted == nil {
+		accepted = []string{}
+	}
+
+	reply := map[string]interface{}{
+		"protocol_version":      version,
+		"categories":            accepted,
+		"heartbeat_interval_ms": pingPeriod.Milliseconds(),
+	}
+	if len(unsupported) > 0 {
+		reply["unsupported_categories"] = unsupported
+	}
+	c.Hub.sendToClient(c, &Message{
+		Type:      string(MessageTypeHello),
+		Data:      reply,
+		Timestamp: c.Hub.clock.Now(),
+	})
+}
@@ -1,65 +1,139 @@
 package websocket
 
 import (
-	"encoding/json"
+	"bytes"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/cbalite/backend/internal/config"
 	"github.com/cbalite/backend/pkg/logger"
 )
 
+// Defaults for settings left at zero in the config.
+const (
+	defaultSendBufferSize     = 256
+	defaultCompactionInterval = 5 * time.Minute
+)
+
 type Hub struct {
 	clients    map[string]*Client
 	rooms      map[string]map[*Client]bool
-	broadcast  chan *Message
+	broadcast  chan *Frame
 	register   chan *Client
 	unregister chan *Client
 	logger     *logger.Logger
+	clock      Clock
 	mu         sync.RWMutex
+
+	sendBufferSize     int
+	compactionInterval time.Duration
+	buffers            sync.Pool
+	metrics            hubMetrics
+
+	// region tags connections and presence; see SetRegion.
+	region string
+	// The backplane, when there is one, and this hub's identity on it;
+	// see UseBackplane. remote is guarded by mu.
+	backplane  Backplane
+	instanceID string
+	outgoing   chan []byte
+	remote     map[string]*remoteServer
 }
 
 type Client struct {
-	ID       string
-	UserID   string
-	TeamID   string
-	Conn     *websocket.Conn
-	Hub      *Hub
-	Send     chan []byte
-	Rooms    map[string]bool
+	ID     string
+	UserID string
+	TeamID string
+	Conn   Conn
+	Hub    *Hub
+	Rooms  map[string]bool
+	// Region is where the server holding the connection runs. Register
+	// sets it.
+	Region string
+
+	// send queues encoded messages for WritePump. Register creates it.
+	send    chan *outbound
+	dropped atomic.Int64
+
+	// ReadOnly clients (kiosk wallboards) only receive messages for the
+	// rooms they were registered with and can't send anything.
+	ReadOnly bool
+
+	// Compact clients are on slow or metered connections and skip typing
+	// and presence events.
+	Compact bool
+
+	// OutOfOffice shows the user's away badge in presence events.
+	OutOfOffice bool
+	// categories the client asked for in its hello; nil until then, which
+	// means all of them. Guarded by Hub.mu.
+	categories map[string]bool
+
+	// presenceWatch limits presence events to these users; nil means the
+	// whole team. Guarded by Hub.mu.
+	presenceWatch map[string]bool
+
+	flood floodGuard
 }
 
 type Message struct {
-	Type      string      `json:"type"`
-	Room      string      `json:"room,omitempty"`
-	UserID    string      `json:"user_id,omitempty"`
-	Data      interface{} `json:"data,omitempty"`
-	Timestamp time.Time   `json:"timestamp"`
+	Type      string       `json:"type"`
+	Room      string       `json:"room,omitempty"`
+	UserID    string       `json:"user_id,omitempty"`
+	Data      interface{}  `json:"data,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+	Meta      *MessageMeta `json:"meta,omitempty" doc:"Set by the server on frames an API request, job or client frame caused"`
+
+	// AcceptedAt is when the server accepted the message this carries.
+	// Setting it counts the message's deliveries in the delivery SLO
+	// metrics; it isn't sent.
+	AcceptedAt time.Time `json:"-"`
 }
 
 type MessageType string
 
 const (
-	MessageTypeChat         MessageType = "chat"
-	MessageTypeTaskUpdate   MessageType = "task_update"
-	MessageTypeUserStatus   MessageType = "user_status"
-	MessageTypeNotification MessageType = "notification"
-	MessageTypeTyping       MessageType = "typing"
-	MessageTypePresence     MessageType = "presence"
+	MessageTypeChat          MessageType = "chat"
+	MessageTypeTaskUpdate    MessageType = "task_update"
+	MessageTypeUserStatus    MessageType = "user_status"
+	MessageTypeNotification  MessageType = "notification"
+	MessageTypeTyping        MessageType = "typing"
+	MessageTypePresence      MessageType = "presence"
+	MessageTypeHello         MessageType = "hello"
+	MessageTypeError         MessageType = "error"
+	MessageTypePresenceWatch MessageType = "presence_watch"
 )
 
-func NewHub(logger *logger.Logger) *Hub {
-	return &Hub{
-		clients:    make(map[string]*Client),
-		rooms:      make(map[string]map[*Client]bool),
-		broadcast:  make(chan *Message, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		logger:     logger,
+func NewHub(cfg *config.WebSocketConfig, logger *logger.Logger) *Hub {
+	h := &Hub{
+		clients:            make(map[string]*Client),
+		rooms:              make(map[string]map[*Client]bool),
+		remote:             make(map[string]*remoteServer),
+		broadcast:          make(chan *Frame, 256),
+		register:           make(chan *Client),
+		unregister:         make(chan *Client),
+		logger:             logger,
+		clock:              realClock{},
+		sendBufferSize:     cfg.SendBufferSize,
+		compactionInterval: cfg.RoomCompactionInterval,
+	}
+	if h.sendBufferSize <= 0 {
+		h.sendBufferSize = defaultSendBufferSize
 	}
+	if h.compactionInterval <= 0 {
+		h.compactionInterval = defaultCompactionInterval
+	}
+	h.buffers.New = func() interface{} {
+		h.metrics.allocated.Add(1)
+		return new(bytes.Buffer)
+	}
+	return h
 }
 
 func (h *Hub) Register(client *Client) {
+	// Made here rather than in Run so WritePump can start straight away
+	client.send = make(chan *outbound, h.sendBufferSize)
 	h.register <- client
 }
 
@@ -68,6 +142,17 @@ func (h *Hub) Unregister(client *Client) {
 }
 
 func (h *Hub) Run() {
+	compaction := h.clock.NewTicker(h.compactionInterval)
+	defer compaction.Stop()
+
+	// Without a backplane there's no one to tell
+	var presenceSync <-chan time.Time
+	if h.backplane != nil {
+		ticker := h.clock.NewTicker(presenceSyncInterval)
+		defer ticker.Stop()
+		presenceSync = ticker.C()
+	}
+
 	for {
 		select {
 		case client := <-h.register:
@@ -76,44 +161,95 @@ func (h *Hub) Run() {
 		case client := <-h.unregister:
 			h.unregisterClient(client)
 
-		case message := <-h.broadcast:
-			h.broadcastMessage(message)
+		case frame := <-h.broadcast:
+			h.broadcastFrame(frame)
+
+		case <-compaction.C():
+			h.CompactRooms()
+
+		case <-presenceSync:
+			h.syncPresence()
 		}
 	}
 }
 
 func (h *Hub) registerClient(client *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
+	client.Region = h.region
 	h.clients[client.ID] = client
 	h.logger.Infof("Client registered: %s (User: %s)", client.ID, client.UserID)
 
+	h.sendHello(client)
+
+	if client.ReadOnly {
+		for room := range client.Rooms {
+			h.joinRoom(client, room)
+		}
+		h.mu.Unlock()
+		return
+	}
+
 	h.joinRoom(client, "global")
 	if client.TeamID != "" {
 		h.joinRoom(client, "team:"+client.TeamID)
 	}
+	h.mu.Unlock()
 
 	h.sendPresenceUpdate(client, true)
 }
 
 func (h *Hub) unregisterClient(client *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
-	if _, ok := h.clients[client.ID]; ok {
-		delete(h.clients, client.ID)
-		close(client.Send)
+	if _, ok := h.clients[client.ID]; !ok {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.clients, client.ID)
+	close(client.send)
 
-		for room := range client.Rooms {
-			h.leaveRoom(client, room)
-		}
+	for room := range client.Rooms {
+		h.leaveRoom(client, room)
+	}
+	h.mu.Unlock()
 
-		h.logger.Infof("Client unregistered: %s (User: %s)", client.ID, client.UserID)
+	h.logger.Infof("Client unregistered: %s (User: %s)", client.ID, client.UserID)
+	if !client.ReadOnly {
 		h.sendPresenceUpdate(client, false)
 	}
 }
 
+// sendHello greets a new connection with its identity and the server clock,
+// which clients use to correct for skew in relative timestamps.
+func (h *Hub) sendHello(client *Client) {
+	now := h.clock.Now()
+	out, err := h.encode(&Message{
+		Type: string(MessageTypeHello),
+		Data: map[string]interface{}{
+			"client_id":   client.ID,
+			"user_id":     client.UserID,
+			"team_id":     client.TeamID,
+			"read_only":   client.ReadOnly,
+			"compact":     client.Compact,
+			"region":      client.Region,
+			"server_time": now,
+
+			"protocol_version":      ProtocolVersion,
+			"categories":            client.Categories(),
+			"heartbeat_interval_ms": pingPeriod.Milliseconds(),
+		},
+		Timestamp: now,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal hello message")
+		return
+	}
+	defer out.release()
+
+	h.deliver(client, out)
+}
+
 func (h *Hub) joinRoom(client *Client, room string) {
 	if h.rooms[room] == nil {
 		h.rooms[room] = make(map[*Client]bool)
@@ -134,64 +270,117 @@ func (h *Hub) leaveRoom(client *Client, room string) {
 	h.logger.Debugf("Client %s left room %s", client.ID, room)
 }
 
-func (h *Hub) broadcastMessage(message *Message) {
-	data, err := json.Marshal(message)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to marshal message")
-		return
-	}
+// broadcastFrame sends one serialized frame to every client in its room
+// that wants it.
+func (h *Hub) broadcastFrame(frame *Frame) {
+	defer frame.done()
 
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
+	message := frame.message
 	if message.Room != "" {
-		if clients, ok := h.rooms[message.Room]; ok {
-			for client := range clients {
-				select {
-				case client.Send <- data:
-				default:
-					h.logger.Warnf("Client %s send channel is full, dropping message", client.ID)
-				}
+		for client := range h.rooms[message.Room] {
+			if client.wants(message) {
+				h.deliver(client, frame.out)
 			}
 		}
 	} else {
 		for _, client := range h.clients {
-			select {
-			case client.Send <- data:
-			default:
-				h.logger.Warnf("Client %s send channel is full, dropping message", client.ID)
+			if client.wants(message) {
+				h.deliver(client, frame.out)
 			}
 		}
 	}
 }
 
+// wants filters out event categories the client didn't negotiate, the
+// chatty ones compact clients opted out of, and presence for users the
+// client isn't watching.
+func (c *Client) wants(message *Message) bool {
+	category := categoryOf(message.Type)
+	if category == CategoryPresence && !c.watchesPresenceOf(message.UserID) {
+		return false
+	}
+	return category == "" || c.receives(category)
+}
+
 func (h *Hub) SendToUser(userID string, message *Message) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	frame, err := h.newFrame(message)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal message")
+		return
+	}
+	defer frame.done()
 
-	data, err := json.Marshal(message)
+	h.SendFrameToUsers([]string{userID}, frame)
+}
+
+// sendToClient delivers a message to one connection if it is still
+// registered; its send channel is closed once it isn't.
+func (h *Hub) sendToClient(client *Client, message *Message) {
+	out, err := h.encode(message)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to marshal message")
 		return
 	}
+	defer out.release()
 
-	for _, client := range h.clients {
-		if client.UserID == userID {
-			select {
-			case client.Send <- data:
-			default:
-				h.logger.Warnf("Client %s send channel is full, dropping message", client.ID)
-			}
-		}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.clients[client.ID] == client {
+		h.deliver(client, out)
 	}
 }
 
 func (h *Hub) SendToTeam(teamID string, message *Message) {
 	message.Room = "team:" + teamID
-	h.broadcast <- message
+	h.publish(message)
+}
+
+func (h *Hub) SendToRoom(room string, message *Message) {
+	message.Room = room
+	h.publish(message)
+}
+
+// DisconnectUser closes every connection belonging to userID, on every
+// server.
+func (h *Hub) DisconnectUser(userID string) {
+	h.replicate(&envelope{Kind: eventDisconnect, UserIDs: []string{userID}})
+	h.disconnectUser(userID)
+}
+
+// disconnectUser closes userID's connections on this server.
+func (h *Hub) disconnectUser(userID string) {
+	h.mu.RLock()
+	var clients []*Client
+	for _, client := range h.clients {
+		if client.UserID == userID {
+			clients = append(clients, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		h.unregister <- client
+	}
 }
 
+// sendPresenceUpdate tells the client's team it came or went. It runs on
+// the hub loop, so it delivers the frame itself: queueing it on the
+// broadcast channel the loop reads would block once that is full. Must not
+// hold h.mu.
 func (h *Hub) sendPresenceUpdate(client *Client, online bool) {
+	frame, err := h.newFrame(presenceMessage(client, online))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal presence message")
+		return
+	}
+	h.broadcastFrame(h.replicateFrame(eventBroadcast, frame, nil))
+}
+
+func presenceMessage(client *Client, online bool) *Message {
 	status := "offline"
 	if online {
 		status = "online"
@@ -200,29 +389,50 @@ func (h *Hub) sendPresenceUpdate(client *Client, online bool) {
 	message := &Message{
 		Type:      string(MessageTypePresence),
 		UserID:    client.UserID,
-		Data:      map[string]interface{}{"status": status},
-		Timestamp: time.Now(),
+		Data:      map[string]interface{}{"status": status, "out_of_office": client.OutOfOffice, "region": client.Region},
+		Timestamp: client.Hub.clock.Now(),
 	}
 
 	if client.TeamID != "" {
 		message.Room = "team:" + client.TeamID
 	}
 
-	h.broadcast <- message
+	return message
+}
+
+// SetOutOfOffice updates the away badge on the user's connections and tells
+// their teams.
+func (h *Hub) SetOutOfOffice(userID string, outOfOffice bool) {
+	h.mu.Lock()
+	rooms := map[string]*Message{}
+	for _, client := range h.clients {
+		if client.UserID != userID || client.ReadOnly {
+			continue
+		}
+		client.OutOfOffice = outOfOffice
+		message := presenceMessage(client, true)
+		rooms[message.Room] = message
+	}
+	h.mu.Unlock()
+
+	for _, message := range rooms {
+		h.publish(message)
+	}
 }
 
 func (h *Hub) GetOnlineUsers(teamID string) []string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	userMap := make(map[string]bool)
+	userMap := make(map[string]string)
 	roomName := "team:" + teamID
 
 	if clients, ok := h.rooms[roomName]; ok {
 		for client := range clients {
-			userMap[client.UserID] = true
+			userMap[client.UserID] = client.Region
 		}
 	}
+	h.remoteTeammates(teamID, nil, userMap)
 
 	users := make([]string, 0, len(userMap))
 	for userID := range userMap {
@@ -230,4 +440,4 @@ func (h *Hub) GetOnlineUsers(teamID string) []string {
 	}
 
 	return users
-}
\ No newline at end of file
+}
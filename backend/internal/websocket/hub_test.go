@@ -0,0 +1,392 @@
+package websocket
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+var testEpoch = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func newHub(t *testing.T) (*Hub, *FakeClock) {
+	t.Helper()
+	clock := NewFakeClock(testEpoch)
+	return NewTestHub(logger.Nop(), clock), clock
+}
+
+// connect registers a client of teamID and drains the hub, discarding what
+// the registration sent it.
+func connect(hub *Hub, id, userID, teamID string) *Client {
+	client := &Client{ID: id, UserID: userID, TeamID: teamID, Hub: hub, Rooms: make(map[string]bool)}
+	hub.Register(client)
+	hub.Drain()
+	client.DiscardPending()
+	return client
+}
+
+// received decodes and releases what is queued for the client.
+func received(t *testing.T, client *Client) []Message {
+	t.Helper()
+	var messages []Message
+	for {
+		select {
+		case out, ok := <-client.send:
+			if !ok {
+				return messages
+			}
+			var message Message
+			if err := json.Unmarshal(out.Bytes(), &message); err != nil {
+				t.Fatalf("client %s got a malformed frame: %v", client.ID, err)
+			}
+			out.release()
+			messages = append(messages, message)
+		default:
+			return messages
+		}
+	}
+}
+
+func types(messages []Message) []string {
+	var types []string
+	for _, message := range messages {
+		types = append(types, message.Type)
+	}
+	return types
+}
+
+func presenceStatus(message Message) string {
+	data, _ := message.Data.(map[string]interface{})
+	status, _ := data["status"].(string)
+	return status
+}
+
+func TestRegisterGreetsAndJoinsRooms(t *testing.T) {
+	hub, _ := newHub(t)
+	teammate := connect(hub, "c0", "u0", "t1")
+
+	client := &Client{ID: "c1", UserID: "u1", TeamID: "t1", Hub: hub, Rooms: make(map[string]bool)}
+	hub.Register(client)
+	if n := hub.Drain(); n != 1 {
+		t.Fatalf("Drain handled %d events, want 1", n)
+	}
+
+	got := received(t, client)
+	if len(got) == 0 || got[0].Type != string(MessageTypeHello) {
+		t.Fatalf("first message = %v, want a hello", types(got))
+	}
+	hello, _ := got[0].Data.(map[string]interface{})
+	if hello["client_id"] != "c1" || hello["team_id"] != "t1" {
+		t.Errorf("hello = %v", hello)
+	}
+	if !client.Rooms["global"] || !client.Rooms["team:t1"] || len(client.Rooms) != 2 {
+		t.Errorf("rooms = %v, want global and team:t1", client.Rooms)
+	}
+
+	presence := received(t, teammate)
+	if len(presence) != 1 || presence[0].Type != string(MessageTypePresence) ||
+		presence[0].UserID != "u1" || presenceStatus(presence[0]) != "online" {
+		t.Errorf("teammate got %+v, want u1 online", presence)
+	}
+	if stats := hub.Stats(); stats.Clients != 2 || stats.Rooms != 2 {
+		t.Errorf("stats = %+v, want 2 clients in 2 rooms", stats)
+	}
+}
+
+func TestReadOnlyClientsOnlyJoinTheirRooms(t *testing.T) {
+	hub, _ := newHub(t)
+	teammate := connect(hub, "c0", "u0", "t1")
+
+	kiosk := &Client{ID: "k1", TeamID: "t1", Hub: hub, ReadOnly: true, Rooms: map[string]bool{"kiosk:k1": true}}
+	hub.Register(kiosk)
+	hub.Drain()
+
+	if len(kiosk.Rooms) != 1 || !kiosk.Rooms["kiosk:k1"] {
+		t.Errorf("rooms = %v, want only kiosk:k1", kiosk.Rooms)
+	}
+	if got := received(t, teammate); len(got) != 0 {
+		t.Errorf("teammate got %v for a kiosk, want nothing", types(got))
+	}
+
+	hub.Unregister(kiosk)
+	hub.Drain()
+	if got := received(t, teammate); len(got) != 0 {
+		t.Errorf("teammate got %v when the kiosk left, want nothing", types(got))
+	}
+}
+
+func TestUnregisterClosesAndLeaves(t *testing.T) {
+	hub, _ := newHub(t)
+	teammate := connect(hub, "c0", "u0", "t1")
+	client := connect(hub, "c1", "u1", "t1")
+	received(t, teammate)
+
+	hub.Unregister(client)
+	hub.Unregister(client)
+	hub.Drain()
+
+	if _, ok := <-client.send; ok {
+		t.Error("send channel still open after unregistering")
+	}
+	if len(client.Rooms) != 0 {
+		t.Errorf("rooms = %v after unregistering, want none", client.Rooms)
+	}
+	presence := received(t, teammate)
+	if len(presence) != 1 || presence[0].UserID != "u1" || presenceStatus(presence[0]) != "offline" {
+		t.Errorf("teammate got %+v, want one u1 offline", presence)
+	}
+	if stats := hub.Stats(); stats.Clients != 1 {
+		t.Errorf("%d clients registered, want 1", stats.Clients)
+	}
+}
+
+func TestDisconnectUserClosesEveryConnection(t *testing.T) {
+	hub, _ := newHub(t)
+	phone := connect(hub, "c1", "u1", "t1")
+	laptop := connect(hub, "c2", "u1", "t2")
+	other := connect(hub, "c3", "u2", "t1")
+
+	hub.DisconnectUser("u1")
+	hub.Drain()
+
+	for _, client := range []*Client{phone, laptop} {
+		received(t, client)
+		if _, ok := <-client.send; ok {
+			t.Errorf("client %s still connected", client.ID)
+		}
+	}
+	if hub.clients[other.ID] != other {
+		t.Error("another user's connection was closed")
+	}
+}
+
+func TestRooms(t *testing.T) {
+	tests := []struct {
+		name    string
+		join    []string
+		leave   []string
+		room    string
+		receive bool
+	}{
+		{name: "joined", join: []string{"project:1"}, room: "project:1", receive: true},
+		{name: "never joined", room: "project:1", receive: false},
+		{name: "left", join: []string{"project:1"}, leave: []string{"project:1"}, room: "project:1", receive: false},
+		{name: "left another", join: []string{"project:1", "project:2"}, leave: []string{"project:2"}, room: "project:1", receive: true},
+		{name: "own team", room: "team:t1", receive: true},
+		{name: "other team", room: "team:t2", receive: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, _ := newHub(t)
+			client := connect(hub, "c1", "u1", "t1")
+			for _, room := range tt.join {
+				client.JoinRoom(room)
+			}
+			for _, room := range tt.leave {
+				client.LeaveRoom(room)
+			}
+
+			hub.SendToRoom(tt.room, &Message{Type: string(MessageTypeChat), Data: "hi"})
+			hub.Drain()
+
+			got := received(t, client)
+			if (len(got) == 1) != tt.receive {
+				t.Errorf("client got %v from %s, want a message: %v", types(got), tt.room, tt.receive)
+			}
+		})
+	}
+}
+
+func TestLeavingTheLastClientRemovesTheRoom(t *testing.T) {
+	hub, _ := newHub(t)
+	client := connect(hub, "c1", "u1", "t1")
+	client.JoinRoom("project:1")
+	client.LeaveRoom("project:1")
+
+	if _, ok := hub.rooms["project:1"]; ok {
+		t.Error("empty room kept")
+	}
+}
+
+func TestSlowClientsDropFrames(t *testing.T) {
+	hub, _ := newHub(t)
+	hub.sendBufferSize = 4
+	slow := connect(hub, "slow", "u1", "t1")
+	fast := connect(hub, "fast", "u2", "t1")
+	slow.DiscardPending()
+
+	const sent = 10
+	delivered := 0
+	for i := 0; i < sent; i++ {
+		hub.SendToTeam("t1", &Message{Type: string(MessageTypeChat), Data: i})
+		hub.Drain()
+		delivered += len(received(t, fast))
+	}
+
+	if delivered != sent {
+		t.Errorf("fast client got %d messages, want %d", delivered, sent)
+	}
+	if got := len(received(t, slow)); got != hub.sendBufferSize {
+		t.Errorf("slow client has %d messages queued, want %d", got, hub.sendBufferSize)
+	}
+	if got := slow.dropped.Load(); got != sent-4 {
+		t.Errorf("slow client dropped %d messages, want %d", got, sent-4)
+	}
+	if stats := hub.Stats(); stats.Dropped != sent-4 {
+		t.Errorf("hub counted %d drops, want %d", stats.Dropped, sent-4)
+	}
+}
+
+func TestCompactionRunsOnTheClock(t *testing.T) {
+	hub, clock := newHub(t)
+	client := connect(hub, "c1", "u1", "t1")
+	// A membership the hub no longer tracks, as left behind by a client
+	// replaced under the same ID
+	stale := &Client{ID: "c1", Rooms: make(map[string]bool)}
+	hub.rooms["team:t1"][stale] = true
+
+	go hub.Run()
+	waitFor(t, "the compaction ticker", func() bool { return clock.Tickers() == 1 })
+
+	clock.Advance(defaultCompactionInterval - time.Second)
+	if stats := hub.Stats(); stats.Compactions != 0 {
+		t.Fatalf("compacted %d times before the interval", stats.Compactions)
+	}
+
+	clock.Advance(time.Second)
+	waitFor(t, "compaction", func() bool { return hub.Stats().Compactions == 1 })
+
+	if stats := hub.Stats(); stats.StaleRemoved != 1 {
+		t.Errorf("removed %d stale memberships, want 1", stats.StaleRemoved)
+	}
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	if members := hub.rooms["team:t1"]; len(members) != 1 || !members[client] {
+		t.Errorf("team room = %v, want only the registered client", members)
+	}
+}
+
+// waitFor polls cond until it holds, for goroutines the test can't step.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// broadcastMessage is how the hub loop broadcast before frames: the loop
+// received the message itself and serialized it there, once per broadcast.
+// It's kept as the baseline for BenchmarkBroadcastMessage.
+func (h *Hub) broadcastMessage(message *Message) {
+	out, err := h.encode(message)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal message")
+		return
+	}
+	defer out.release()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if message.Room != "" {
+		for client := range h.rooms[message.Room] {
+			if client.wants(message) {
+				h.deliver(client, out)
+			}
+		}
+	} else {
+		for _, client := range h.clients {
+			if client.wants(message) {
+				h.deliver(client, out)
+			}
+		}
+	}
+}
+
+var broadcastSizes = []int{100, 1000, 10000}
+
+// benchmarkHub registers n clients in one team room.
+func benchmarkHub(b *testing.B, n int) (*Hub, []*Client) {
+	b.Helper()
+	hub := NewTestHub(logger.Nop(), NewFakeClock(testEpoch))
+	clients := make([]*Client, n)
+	for i := range clients {
+		// Read-only clients join just their rooms and don't announce
+		// presence, which would be n² deliveries while setting up
+		clients[i] = &Client{
+			ID:       "bench-" + strconv.Itoa(i),
+			UserID:   "bench-user-" + strconv.Itoa(i),
+			TeamID:   "bench",
+			Hub:      hub,
+			Rooms:    map[string]bool{"team:bench": true},
+			ReadOnly: true,
+		}
+		hub.Register(clients[i])
+		hub.Drain()
+	}
+	discardAll(clients)
+	return hub, clients
+}
+
+func discardAll(clients []*Client) {
+	for _, client := range clients {
+		client.DiscardPending()
+	}
+}
+
+func benchmarkMessage() *Message {
+	return &Message{
+		Type:      string(MessageTypeChat),
+		Room:      "team:bench",
+		Data:      map[string]interface{}{"content": strings.Repeat("x", 64), "channel_id": "bench"},
+		Timestamp: testEpoch,
+	}
+}
+
+// BenchmarkBroadcastFrame measures a chat message broadcast to a room the
+// way the hub does it: serialized once into a shared frame by the sender,
+// then queued for every client by the loop.
+func BenchmarkBroadcastFrame(b *testing.B) {
+	for _, n := range broadcastSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			hub, clients := benchmarkHub(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hub.SendToRoom("team:bench", benchmarkMessage())
+				hub.Drain()
+				b.StopTimer()
+				discardAll(clients)
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// BenchmarkBroadcastMessage measures the same broadcast through
+// broadcastMessage, the implementation frames replaced, which passed the
+// message itself to the loop.
+func BenchmarkBroadcastMessage(b *testing.B) {
+	for _, n := range broadcastSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			hub, clients := benchmarkHub(b, n)
+			queue := make(chan *Message, 256)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				queue <- benchmarkMessage()
+				hub.broadcastMessage(<-queue)
+				b.StopTimer()
+				discardAll(clients)
+				b.StartTimer()
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// messageLimits caps the size of each frame type clients may send. Types
+// that aren't listed can't be sent by clients at all.
+var messageLimits = map[MessageType]int{
+	MessageTypeChat:          16 * 1024,
+	MessageTypeTaskUpdate:    16 * 1024,
+	MessageTypeTyping:        512,
+	MessageTypeNotification:  1024,
+	MessageTypeHello:         1024,
+	MessageTypePresenceWatch: 24 * 1024,
+}
+
+const maxRoomLength = 128
+
+// ReservedRoomPrefix starts the names of rooms the server puts sockets in
+// itself, such as kiosk rooms. Clients can't join or leave them.
+const ReservedRoomPrefix = "kiosk:"
+
+// Flood protection: each frame costs 1 and each rejected frame
+// floodViolationCost from a budget of floodBudget per floodWindow. A client
+// that runs out is disconnected.
+const (
+	floodWindow        = 10 * time.Second
+	floodBudget        = 100
+	floodViolationCost = 10
+)
+
+// Error codes sent in error frames.
+const (
+	ErrorInvalidJSON  = "invalid_json"
+	ErrorUnknownType  = "unknown_type"
+	ErrorTooLarge     = "message_too_large"
+	ErrorInvalidField = "invalid_field"
+)
+
+// frameError is a rejected frame, reported to the client as an error frame.
+type frameError struct {
+	Code    string
+	Message string
+}
+
+func (e *frameError) Error() string {
+	return e.Message
+}
+
+func invalidField(format string, args ...interface{}) *frameError {
+	return &frameError{Code: ErrorInvalidField, Message: fmt.Sprintf(format, args...)}
+}
+
+// validate checks a frame of size bytes against the limits for its type.
+func (c *Client) validate(msg *Message, size int) *frameError {
+	limit, ok := messageLimits[MessageType(msg.Type)]
+	if !ok {
+		return &frameError{Code: ErrorUnknownType, Message: fmt.Sprintf("Unknown message type %q", msg.Type)}
+	}
+	if size > limit {
+		return &frameError{Code: ErrorTooLarge, Message: fmt.Sprintf("%s messages are limited to %d bytes", msg.Type, limit)}
+	}
+
+	data, isObject := msg.Data.(map[string]interface{})
+	if msg.Data != nil && !isObject {
+		return invalidField("data must be an object")
+	}
+
+	switch MessageType(msg.Type) {
+	case MessageTypeChat:
+		if msg.Room != "" && !c.inRoom(msg.Room) {
+			return invalidField("room %q isn't one this connection has joined", msg.Room)
+		}
+		if content, _ := data["content"].(string); content == "" {
+			return invalidField("data.content is required")
+		}
+	case MessageTypeTaskUpdate:
+		if data == nil {
+			return invalidField("data is required")
+		}
+	case MessageTypeHello:
+		if version, ok := data["protocol_version"]; ok {
+			if n, isNumber := version.(float64); !isNumber || n < 1 || n != float64(int(n)) {
+				return invalidField("data.protocol_version must be a positive integer")
+			}
+		}
+		if categories, ok := data["categories"]; ok {
+			items, isArray := categories.([]interface{})
+			if !isArray {
+				return invalidField("data.categories must be an array of strings")
+			}
+			for _, item := range items {
+				if _, isString := item.(string); !isString {
+					return invalidField("data.categories must be an array of strings")
+				}
+			}
+		}
+	case MessageTypePresenceWatch:
+		return validPresenceWatch(data)
+	case MessageTypeNotification:
+		action, _ := data["action"].(string)
+		if action != "join_room" && action != "leave_room" {
+			return invalidField("data.action must be join_room or leave_room")
+		}
+		room, _ := data["room"].(string)
+		if room == "" || len(room) > maxRoomLength {
+			return invalidField("data.room must be 1 to %d characters", maxRoomLength)
+		}
+		if strings.HasPrefix(room, ReservedRoomPrefix) {
+			return invalidField("room %q can't be joined or left", room)
+		}
+	}
+	return nil
+}
+
+func (c *Client) inRoom(room string) bool {
+	c.Hub.mu.RLock()
+	defer c.Hub.mu.RUnlock()
+	return c.Rooms[room]
+}
+
+// floodGuard tracks one connection's budget. It is only used from ReadPump,
+// so it needs no locking.
+type floodGuard struct {
+	windowStart time.Time
+	spent       int
+}
+
+// spend charges cost and reports whether the client is still within budget.
+func (g *floodGuard) spend(now time.Time, cost int) bool {
+	if now.Sub(g.windowStart) >= floodWindow {
+		g.windowStart = now
+		g.spent = 0
+	}
+	g.spent += cost
+	return g.spent <= floodBudget
+}
@@ -0,0 +1,119 @@
+package websocket
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	long := strings.Repeat("x", 16*1024)
+	tests := []struct {
+		name  string
+		frame string
+		want  string
+	}{
+		{name: "chat", frame: `{"type":"chat","data":{"content":"hi"}}`},
+		{name: "chat to a joined room", frame: `{"type":"chat","room":"project:1","data":{"content":"hi"}}`},
+		{name: "chat to another room", frame: `{"type":"chat","room":"project:2","data":{"content":"hi"}}`, want: ErrorInvalidField},
+		{name: "chat without content", frame: `{"type":"chat","data":{}}`, want: ErrorInvalidField},
+		{name: "chat too large", frame: `{"type":"chat","data":{"content":"` + long + `"}}`, want: ErrorTooLarge},
+		{name: "data not an object", frame: `{"type":"chat","data":"hi"}`, want: ErrorInvalidField},
+		{name: "unknown type", frame: `{"type":"shout"}`, want: ErrorUnknownType},
+		{name: "server-only type", frame: `{"type":"presence"}`, want: ErrorUnknownType},
+		{name: "typing", frame: `{"type":"typing"}`},
+		{name: "typing too large", frame: `{"type":"typing","data":{"x":"` + strings.Repeat("x", 512) + `"}}`, want: ErrorTooLarge},
+		{name: "task update", frame: `{"type":"task_update","data":{"id":"1"}}`},
+		{name: "task update without data", frame: `{"type":"task_update"}`, want: ErrorInvalidField},
+		{name: "hello", frame: `{"type":"hello","data":{"protocol_version":1,"categories":["typing"]}}`},
+		{name: "hello with a fractional version", frame: `{"type":"hello","data":{"protocol_version":1.5}}`, want: ErrorInvalidField},
+		{name: "hello with version zero", frame: `{"type":"hello","data":{"protocol_version":0}}`, want: ErrorInvalidField},
+		{name: "hello with categories not strings", frame: `{"type":"hello","data":{"categories":[1]}}`, want: ErrorInvalidField},
+		{name: "hello with categories not an array", frame: `{"type":"hello","data":{"categories":"typing"}}`, want: ErrorInvalidField},
+		{name: "join room", frame: `{"type":"notification","data":{"action":"join_room","room":"project:3"}}`},
+		{name: "unknown action", frame: `{"type":"notification","data":{"action":"shout","room":"project:3"}}`, want: ErrorInvalidField},
+		{name: "join without room", frame: `{"type":"notification","data":{"action":"join_room"}}`, want: ErrorInvalidField},
+		{name: "join room too long", frame: `{"type":"notification","data":{"action":"join_room","room":"` + strings.Repeat("r", maxRoomLength+1) + `"}}`, want: ErrorInvalidField},
+		{name: "join kiosk room", frame: `{"type":"notification","data":{"action":"join_room","room":"kiosk:abc"}}`, want: ErrorInvalidField},
+		{name: "leave kiosk room", frame: `{"type":"notification","data":{"action":"leave_room","room":"kiosk:abc"}}`, want: ErrorInvalidField},
+		{name: "watch everyone", frame: `{"type":"presence_watch","data":{"all":true}}`},
+		{name: "watch users", frame: `{"type":"presence_watch","data":{"user_ids":["6f1c1f7e-3c7a-4d55-9b8e-0a6a2f0e8c11"]}}`},
+		{name: "watch with all not a boolean", frame: `{"type":"presence_watch","data":{"all":"yes"}}`, want: ErrorInvalidField},
+		{name: "watch a non-ID", frame: `{"type":"presence_watch","data":{"user_ids":["bob"]}}`, want: ErrorInvalidField},
+		{name: "watch without users", frame: `{"type":"presence_watch","data":{}}`, want: ErrorInvalidField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, _ := newHub(t)
+			client := connect(hub, "c1", "u1", "t1")
+			client.JoinRoom("project:1")
+
+			var msg Message
+			if err := json.Unmarshal([]byte(tt.frame), &msg); err != nil {
+				t.Fatal(err)
+			}
+			err := client.validate(&msg, len(tt.frame))
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("rejected: %s", err.Message)
+			case tt.want != "" && err == nil:
+				t.Errorf("accepted, want %s", tt.want)
+			case tt.want != "" && err.Code != tt.want:
+				t.Errorf("rejected with %s (%s), want %s", err.Code, err.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestFloodGuard(t *testing.T) {
+	start := time.Date(2025, 6, 11, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// frames are sent one per interval, each costing cost
+		frames   int
+		cost     int
+		interval time.Duration
+		want     bool
+	}{
+		{name: "within budget", frames: floodBudget, cost: 1, want: true},
+		{name: "one over budget", frames: floodBudget + 1, cost: 1, want: false},
+		{name: "violations", frames: floodBudget / floodViolationCost, cost: floodViolationCost, want: true},
+		{name: "one violation too many", frames: floodBudget/floodViolationCost + 1, cost: floodViolationCost, want: false},
+		{name: "spread over windows", frames: 3 * floodBudget, cost: 1, interval: floodWindow / floodBudget, want: true},
+		{name: "too fast for the window", frames: 3 * floodBudget, cost: 1, interval: floodWindow / (2 * floodBudget), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var guard floodGuard
+			ok := true
+			for i := 0; i < tt.frames && ok; i++ {
+				ok = guard.spend(start.Add(time.Duration(i)*tt.interval), tt.cost)
+			}
+			if ok != tt.want {
+				t.Errorf("within budget = %v after %d frames, want %v", ok, tt.frames, tt.want)
+			}
+		})
+	}
+}
+
+func TestFloodingDisconnects(t *testing.T) {
+	hub, clock := newHub(t)
+	client := connect(hub, "c1", "u1", "t1")
+
+	// Rejected frames cost floodViolationCost each
+	for i := 0; i < floodBudget/floodViolationCost; i++ {
+		if !client.handleMessage([]byte(`not json`)) {
+			t.Fatalf("disconnected after %d bad frames", i+1)
+		}
+	}
+	if client.handleMessage([]byte(`{"type":"typing"}`)) {
+		t.Fatal("still connected with the budget spent")
+	}
+
+	// A new window starts the budget over
+	clock.Advance(floodWindow)
+	if !client.handleMessage([]byte(`{"type":"typing"}`)) {
+		t.Error("disconnected in a new window")
+	}
+}
@@ -0,0 +1,111 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/cbalite/backend/internal/correlation"
+)
+
+// Directions a frame type can travel in.
+const (
+	FromServer = "server"
+	FromClient = "client"
+	FromBoth   = "both"
+)
+
+// Event describes the data of one frame type. Frames are Messages; Data is
+// the struct for their data field, or nil where clients choose it.
+type Event struct {
+	Type        MessageType
+	Direction   string
+	Description string
+	Data        interface{}
+}
+
+// Events lists every frame type the socket carries. The hub builds frame
+// data as maps of the same shape; change both together.
+var Events = []Event{
+	{MessageTypeHello, FromBoth, "Sent on connect, and in reply to a client hello negotiating the protocol version and categories", HelloData{}},
+	{MessageTypeError, FromServer, "A frame from the client was rejected", ErrorData{}},
+	{MessageTypePresence, FromServer, "A teammate came online, went offline or changed their away badge", PresenceData{}},
+	{MessageTypePresenceWatch, FromBoth, "Limits presence events to chosen users; the reply lists who is watched and online", PresenceWatchData{}},
+	{MessageTypeChat, FromBoth, "A chat message in a room. Clients need data.content; kiosk rooms receive the message.posted payload", nil},
+	{MessageTypeTaskUpdate, FromBoth, "A task changed. Kiosk task boards receive the fields below; other data comes from clients", TaskBoardData{}},
+	{MessageTypeTyping, FromClient, "A user is typing; relayed to their team as sent", nil},
+	{MessageTypeNotification, FromClient, "Joins or leaves a room", RoomActionData{}},
+}
+
+// MessageMeta says where a server frame came from. CorrelationID is the
+// X-Request-ID of the API request that caused it, the ID of the job run or
+// the ID given to the client frame it relays, as found on the events,
+// webhooks and log lines it also caused.
+type MessageMeta struct {
+	CorrelationID string `json:"correlation_id"`
+}
+
+// MetaFromContext is the meta for frames sent on behalf of ctx, or nil when
+// ctx has no correlation ID.
+func MetaFromContext(ctx context.Context) *MessageMeta {
+	return MetaFor(correlation.FromContext(ctx))
+}
+
+// MetaFor is the meta for frames caused by correlationID, or nil when it's
+// empty.
+func MetaFor(correlationID string) *MessageMeta {
+	if correlationID == "" {
+		return nil
+	}
+	return &MessageMeta{CorrelationID: correlationID}
+}
+
+// HelloData is sent on connect; replies to a client hello only carry the
+// negotiated fields. Clients send protocol_version and categories.
+type HelloData struct {
+	ClientID              string    `json:"client_id,omitempty" format:"uuid"`
+	UserID                string    `json:"user_id,omitempty"`
+	TeamID                string    `json:"team_id,omitempty"`
+	ReadOnly              bool      `json:"read_only,omitempty"`
+	Compact               bool      `json:"compact,omitempty"`
+	ServerTime            time.Time `json:"server_time,omitempty"`
+	ProtocolVersion       int       `json:"protocol_version"`
+	Categories            []string  `json:"categories" doc:"Event categories the connection receives: presence, typing and tasks"`
+	HeartbeatIntervalMS   int64     `json:"heartbeat_interval_ms" doc:"How often the server pings"`
+	UnsupportedCategories []string  `json:"unsupported_categories,omitempty" doc:"Categories the client asked for that the server doesn't know"`
+}
+
+type ErrorData struct {
+	Code        string `json:"code" validate:"oneof=invalid_json unknown_type message_too_large invalid_field"`
+	Message     string `json:"message"`
+	MessageType string `json:"message_type,omitempty" doc:"Type of the rejected frame"`
+}
+
+type PresenceData struct {
+	Status      string `json:"status" validate:"oneof=online offline"`
+	OutOfOffice bool   `json:"out_of_office"`
+}
+
+// PresenceWatchData is sent by clients with user_ids or all; replies also
+// list who of them is online.
+type PresenceWatchData struct {
+	All     bool     `json:"all,omitempty" doc:"Watch the whole team"`
+	UserIDs []string `json:"user_ids,omitempty" doc:"Users to watch, replacing the previous set"`
+	Online  []string `json:"online,omitempty" doc:"Watched users with an open connection, in replies"`
+}
+
+// TaskBoardData is the part of a task kiosk boards show.
+type TaskBoardData struct {
+	Event      string     `json:"event" validate:"oneof=task.created task.updated"`
+	ID         string     `json:"id" format:"uuid"`
+	Title      string     `json:"title"`
+	Status     string     `json:"status" validate:"oneof=todo in_progress review done cancelled"`
+	Priority   string     `json:"priority" validate:"oneof=low medium high urgent"`
+	AssigneeID string     `json:"assignee_id,omitempty" format:"uuid"`
+	DueDate    *time.Time `json:"due_date,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type RoomActionData struct {
+	Action string `json:"action" validate:"oneof=join_room leave_room"`
+	Room   string `json:"room"`
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Buffers that grew past this (a huge broadcast) are left to the garbage
+// collector instead of pinning that much memory in the pool.
+const maxPooledBuffer = 64 * 1024
+
+// outbound is a message encoded once and shared by every client it goes
+// to. Each holder has a reference; the last to let go returns the buffer to
+// the hub's pool.
+type outbound struct {
+	buf  *bytes.Buffer
+	pool *sync.Pool
+	refs atomic.Int32
+	// acceptedAt is copied from the message; see Message.AcceptedAt.
+	acceptedAt time.Time
+}
+
+func (o *outbound) Bytes() []byte {
+	return o.buf.Bytes()
+}
+
+// release drops a reference. Buffers from NewFrame aren't pooled and are
+// never recycled, so frames holding them stay valid.
+func (o *outbound) release() {
+	if o.pool == nil || o.refs.Add(-1) != 0 {
+		return
+	}
+	if o.buf.Cap() <= maxPooledBuffer {
+		o.pool.Put(o.buf)
+	}
+	o.buf = nil
+}
+
+// encode marshals message into a pooled buffer. The caller holds the only
+// reference and must release it.
+func (h *Hub) encode(message interface{}) (*outbound, error) {
+	h.metrics.encoded.Add(1)
+
+	buf := h.buffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(message); err != nil {
+		h.buffers.Put(buf)
+		return nil, err
+	}
+	// Drop the newline Encode adds; WritePump separates batched messages
+	buf.Truncate(buf.Len() - 1)
+
+	out := &outbound{buf: buf, pool: &h.buffers}
+	out.refs.Store(1)
+	return out, nil
+}
+
+// deliver queues out for client without blocking, taking a reference for
+// it. Must hold h.mu, so client.send isn't closed underneath.
+func (h *Hub) deliver(client *Client, out *outbound) bool {
+	out.refs.Add(1)
+	select {
+	case client.send <- out:
+		h.metrics.delivered.Add(1)
+		return true
+	default:
+		out.release()
+		h.metrics.dropped.Add(1)
+		observeDropped(out)
+		h.logger.Warnf("Client %s send channel is full, dropping message (%d dropped so far)", client.ID, client.dropped.Add(1))
+		return false
+	}
+}
+
+type hubMetrics struct {
+	encoded      atomic.Int64
+	allocated    atomic.Int64
+	delivered    atomic.Int64
+	dropped      atomic.Int64
+	compactions  atomic.Int64
+	staleRemoved atomic.Int64
+}
+
+// HubStats is a snapshot of the hub for health checks. Messages encoded
+// against buffers allocated shows how well broadcast buffers are reused.
+type HubStats struct {
+	Clients          int   `json:"clients"`
+	Rooms            int   `json:"rooms"`
+	MessagesEncoded  int64 `json:"messages_encoded"`
+	BuffersAllocated int64 `json:"buffers_allocated"`
+	Delivered        int64 `json:"delivered"`
+	Dropped          int64 `json:"dropped"`
+	Compactions      int64 `json:"compactions"`
+	StaleRemoved     int64 `json:"stale_memberships_removed"`
+}
+
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	clients, rooms := len(h.clients), len(h.rooms)
+	h.mu.RUnlock()
+
+	return HubStats{
+		Clients:          clients,
+		Rooms:            rooms,
+		MessagesEncoded:  h.metrics.encoded.Load(),
+		BuffersAllocated: h.metrics.allocated.Load(),
+		Delivered:        h.metrics.delivered.Load(),
+		Dropped:          h.metrics.dropped.Load(),
+		Compactions:      h.metrics.compactions.Load(),
+		StaleRemoved:     h.metrics.staleRemoved.Load(),
+	}
+}
+
+// CompactRooms rebuilds the client and room maps. Go maps never give back
+// their buckets, so a room that once held thousands of clients keeps that
+// memory after they leave; rebuilding also drops memberships of clients
+// that are no longer registered, and empty rooms.
+func (h *Hub) CompactRooms() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clients := make(map[string]*Client, len(h.clients))
+	for id, client := range h.clients {
+		clients[id] = client
+	}
+	h.clients = clients
+
+	stale := 0
+	rooms := make(map[string]map[*Client]bool, len(h.rooms))
+	for room, members := range h.rooms {
+		kept := make(map[*Client]bool, len(members))
+		for client := range members {
+			if h.clients[client.ID] != client {
+				stale++
+				continue
+			}
+			kept[client] = true
+		}
+		if len(kept) > 0 {
+			rooms[room] = kept
+		}
+	}
+	h.rooms = rooms
+
+	h.metrics.compactions.Add(1)
+	h.metrics.staleRemoved.Add(int64(stale))
+	if stale > 0 {
+		h.logger.Infof("Room compaction removed %d stale memberships", stale)
+	}
+}
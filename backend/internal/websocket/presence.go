@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// maxPresenceWatch caps how many users one connection can watch.
+const maxPresenceWatch = 500
+
+// handlePresenceWatch replaces the set of users whose presence the client
+// receives, or goes back to the whole team with "all": true. The reply
+// lists who is watched and which of them are online right now, since
+// earlier presence events were filtered out.
+func (c *Client) handlePresenceWatch(msg *Message) {
+	data, _ := msg.Data.(map[string]interface{})
+
+	var watch map[string]bool
+	if all, _ := data["all"].(bool); !all {
+		watch = make(map[string]bool)
+		items, _ := data["user_ids"].([]interface{})
+		for _, item := range items {
+			watch[item.(string)] = true
+		}
+	}
+
+	c.Hub.mu.Lock()
+	c.presenceWatch = watch
+	online := c.Hub.onlineTeammates(c, watch)
+	c.Hub.mu.Unlock()
+
+	reply := map[string]interface{}{
+		"all":     watch == nil,
+		"online":  sortedKeys(online),
+		"regions": online,
+	}
+	if watch != nil {
+		reply["user_ids"] = sortedKeys(watch)
+	}
+	c.Hub.sendToClient(c, &Message{
+		Type:      string(MessageTypePresenceWatch),
+		Data:      reply,
+		Timestamp: c.Hub.clock.Now(),
+		Meta:      msg.Meta,
+	})
+}
+
+// onlineTeammates maps the users in watch (or everyone, if nil) with a
+// connection in client's team, on this server or another, to the region
+// they're connected in. Must hold h.mu.
+func (h *Hub) onlineTeammates(client *Client, watch map[string]bool) map[string]string {
+	online := make(map[string]string)
+	for _, other := range h.clients {
+		if other.ReadOnly || other.TeamID != client.TeamID || other.TeamID == "" {
+			continue
+		}
+		if watch == nil || watch[other.UserID] {
+			online[other.UserID] = other.Region
+		}
+	}
+	if client.TeamID != "" {
+		h.remoteTeammates(client.TeamID, watch, online)
+	}
+	return online
+}
+
+// watchesPresenceOf reports whether presence events for userID reach the
+// client. Must hold h.mu.
+func (c *Client) watchesPresenceOf(userID string) bool {
+	return c.presenceWatch == nil || c.presenceWatch[userID]
+}
+
+func validPresenceWatch(data map[string]interface{}) *frameError {
+	if all, ok := data["all"]; ok {
+		if _, isBool := all.(bool); !isBool {
+			return invalidField("data.all must be a boolean")
+		}
+		if all == true {
+			return nil
+		}
+	}
+
+	items, ok := data["user_ids"].([]interface{})
+	if !ok {
+		return invalidField("data.user_ids must be an array of user IDs")
+	}
+	if len(items) > maxPresenceWatch {
+		return invalidField("data.user_ids can list at most %d users", maxPresenceWatch)
+	}
+	for _, item := range items {
+		id, _ := item.(string)
+		if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+			return invalidField("data.user_ids must be an array of user IDs")
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](set map[string]V) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
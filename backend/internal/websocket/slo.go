@@ -0,0 +1,40 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/cbalite/backend/internal/metrics"
+)
+
+// Delivery SLO metrics, for messages sent with Message.AcceptedAt set. Each
+// recipient connection counts once: delivered when the frame is written to
+// its socket, dropped when its send buffer is full, failed when the write
+// errors.
+var (
+	messageDeliveries = metrics.Default.NewCounterVec("cbalite_message_deliveries_total",
+		"Messages handed to recipient connections, by outcome (delivered, dropped, failed).", "outcome")
+	messageDeliveryLatency = metrics.Default.NewHistogramVec("cbalite_message_delivery_latency_seconds",
+		"Time from the server accepting a message to writing it to a recipient's socket.", metrics.LatencyBuckets)
+)
+
+func observeDropped(out *outbound) {
+	if !out.acceptedAt.IsZero() {
+		messageDeliveries.With("dropped").Inc()
+	}
+}
+
+// observeWritten records a batch of frames written to one socket at now,
+// or that failed with err.
+func observeWritten(accepted []time.Time, now time.Time, err error) {
+	for _, at := range accepted {
+		if at.IsZero() {
+			continue
+		}
+		if err != nil {
+			messageDeliveries.With("failed").Inc()
+			continue
+		}
+		messageDeliveries.With("delivered").Inc()
+		messageDeliveryLatency.With().Observe(now.Sub(at).Seconds())
+	}
+}
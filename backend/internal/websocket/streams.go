@@ -0,0 +1,156 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+const (
+	streamKeyPrefix = "ws_events:"
+	// streamMaxLen trims each region's stream to about this many events;
+	// a reader that falls further behind than that misses some.
+	streamMaxLen = 10000
+	streamBlock  = 5 * time.Second
+	streamBatch  = 100
+	// streamRetryDelay is the pause before reading again after an error.
+	streamRetryDelay = time.Second
+)
+
+// StreamBackplane is a Backplane over Redis streams, one per region. Each
+// region's stream lives in that region's Redis; servers write to their own
+// and read the others' over the peers' addresses. A reader that loses its
+// connection picks up where it left off, so a short outage between regions
+// delays events rather than losing them.
+type StreamBackplane struct {
+	region string
+	local  *redis.Client
+	peers  map[string]*redis.Client
+	logger *logger.Logger
+}
+
+// NewStreamBackplane uses the server's Redis for its own region's stream
+// and the addresses in peers, keyed by region, for the others'. Peers are
+// reached with the same credentials and database.
+func NewStreamBackplane(cfg *config.RedisConfig, region string, peers map[string]string, logger *logger.Logger) *StreamBackplane {
+	client := func(addr string) *redis.Client {
+		return redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			DB:       cfg.DB,
+			// Reads block, so they get connections of their own
+			ReadTimeout: streamBlock + 5*time.Second,
+		})
+	}
+
+	b := &StreamBackplane{
+		region: region,
+		local:  client(cfg.Addr),
+		peers:  make(map[string]*redis.Client, len(peers)),
+		logger: logger,
+	}
+	for peer, addr := range peers {
+		b.peers[peer] = client(addr)
+	}
+	return b
+}
+
+func (b *StreamBackplane) Publish(ctx context.Context, event []byte) error {
+	return b.local.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKeyPrefix + b.region,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": event},
+	}).Err()
+}
+
+// Consume reads this region's stream and every peer's, each on its own
+// goroutine, until ctx ends.
+func (b *StreamBackplane) Consume(ctx context.Context, handle func(region string, event []byte)) {
+	regions := make([]string, 0, len(b.peers))
+	for region := range b.peers {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	b.logger.Infof("WebSocket backplane reading region %s and peers %v", b.region, regions)
+
+	for _, region := range regions {
+		go b.read(ctx, b.peers[region], region, handle)
+	}
+	b.read(ctx, b.local, b.region, handle)
+}
+
+func (b *StreamBackplane) read(ctx context.Context, client *redis.Client, region string, handle func(string, []byte)) {
+	stream := streamKeyPrefix + region
+	// Reading starts after the newest event when the server starts, and
+	// each read continues from the last event seen
+	var lastID string
+	failing := false
+
+	for ctx.Err() == nil {
+		var streams []redis.XStream
+		var err error
+		if lastID == "" {
+			lastID, err = newestID(ctx, client, stream)
+		} else {
+			streams, err = client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{stream, lastID},
+				Count:   streamBatch,
+				Block:   streamBlock,
+			}).Result()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+		}
+		if err != nil {
+			if !failing && ctx.Err() == nil {
+				b.logger.WithError(err).Warnf("Failed to read the %s WebSocket backplane stream; retrying", region)
+			}
+			failing = true
+			time.Sleep(streamRetryDelay)
+			continue
+		}
+		if failing {
+			b.logger.Infof("Reading the %s WebSocket backplane stream again", region)
+			failing = false
+		}
+
+		for _, s := range streams {
+			for _, message := range s.Messages {
+				lastID = message.ID
+				if event, ok := message.Values["event"].(string); ok {
+					handle(region, []byte(event))
+				}
+			}
+		}
+	}
+}
+
+// newestID is the ID of the newest event in the stream, or "0-0" when it's
+// empty.
+func newestID(ctx context.Context, client *redis.Client, stream string) (string, error) {
+	messages, err := client.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return "0-0", nil
+	}
+	return messages[0].ID, nil
+}
+
+// Close closes the Redis connections.
+func (b *StreamBackplane) Close() error {
+	var errs []error
+	errs = append(errs, b.local.Close())
+	for _, client := range b.peers {
+		errs = append(errs, client.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cbalite/backend/internal/config"
+	"github.com/cbalite/backend/pkg/logger"
+)
+
+// Test hooks. Nothing here is used by the server itself.
+
+// NewTestHub returns a hub that isn't meant to Run. Its channels are
+// buffered so Register, Unregister and broadcasts don't block, and the
+// test drives it with Step or Drain.
+func NewTestHub(logger *logger.Logger, clock Clock) *Hub {
+	h := NewHub(&config.WebSocketConfig{}, logger)
+	h.register = make(chan *Client, 256)
+	h.unregister = make(chan *Client, 256)
+	h.clock = clock
+	return h
+}
+
+// Step handles one pending registration, unregistration or broadcast,
+// reporting false if there was none. Room compaction doesn't run on a
+// timer here; call CompactRooms.
+func (h *Hub) Step() bool {
+	select {
+	case client := <-h.register:
+		h.registerClient(client)
+	case client := <-h.unregister:
+		h.unregisterClient(client)
+	case frame := <-h.broadcast:
+		h.broadcastFrame(frame)
+	default:
+		return false
+	}
+	return true
+}
+
+// Drain steps until nothing is pending, including anything the steps
+// queued themselves, and returns how many events it handled.
+func (h *Hub) Drain() int {
+	n := 0
+	for h.Step() {
+		n++
+	}
+	return n
+}
+
+// FakeClock only moves when Advance is called, firing any tickers that
+// come due on the way.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{clock: c, period: d, next: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Tickers counts the tickers that haven't been stopped, so a test can wait
+// for a pump to start before advancing the clock.
+func (c *FakeClock) Tickers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.tickers {
+		if !t.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// Advance moves the clock forward by d. Like time.Ticker, a ticker whose
+// reader is behind drops ticks rather than queueing them.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		for !t.stopped && !t.next.After(c.now) {
+			select {
+			case t.ch <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock   *FakeClock
+	period  time.Duration
+	next    time.Time
+	ch      chan time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
+
+// DiscardPending drops what is queued for the client, as if WritePump had
+// sent it, and returns how many messages there were.
+func (c *Client) DiscardPending() int {
+	n := 0
+	for {
+		select {
+		case out, ok := <-c.send:
+			if !ok {
+				return n
+			}
+			out.release()
+			n++
+		default:
+			return n
+		}
+	}
+}
@@ -0,0 +1,11 @@
+-- REST hook subscriptions (Zapier-compatible)
+CREATE TABLE IF NOT EXISTS rest_hooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    target_url VARCHAR(2000) NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_rest_hooks_team_event ON rest_hooks(team_id, event);
@@ -0,0 +1,34 @@
+-- Team automation rules (trigger -> conditions -> actions)
+CREATE TABLE IF NOT EXISTS automation_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(50) NOT NULL,
+    conditions JSONB NOT NULL DEFAULT '[]',
+    actions JSONB NOT NULL DEFAULT '[]',
+    is_enabled BOOLEAN DEFAULT true,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_automation_rules_team_trigger ON automation_rules(team_id, trigger);
+
+CREATE TRIGGER update_automation_rules_updated_at BEFORE UPDATE ON automation_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Automation run history
+CREATE TABLE IF NOT EXISTS automation_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rule_id UUID NOT NULL REFERENCES automation_rules(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('succeeded', 'failed', 'skipped')),
+    error TEXT,
+    actions_executed INTEGER NOT NULL DEFAULT 0,
+    chain_depth INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_automation_runs_rule_id ON automation_runs(rule_id, created_at DESC);
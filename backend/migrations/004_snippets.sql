@@ -0,0 +1,32 @@
+-- Team snippets (canned responses)
+CREATE TABLE IF NOT EXISTS snippets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    shortcut VARCHAR(50) NOT NULL,
+    title VARCHAR(100) NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    usage_count INTEGER NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, shortcut)
+);
+
+CREATE INDEX idx_snippets_team_id ON snippets(team_id);
+
+CREATE TRIGGER update_snippets_updated_at BEFORE UPDATE ON snippets
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Snippet usage log for analytics
+CREATE TABLE IF NOT EXISTS snippet_usages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    snippet_id UUID NOT NULL REFERENCES snippets(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES users(id),
+    used_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_snippet_usages_team_used_at ON snippet_usages(team_id, used_at DESC);
+CREATE INDEX idx_snippet_usages_snippet_id ON snippet_usages(snippet_id);
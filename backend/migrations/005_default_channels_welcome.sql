@@ -0,0 +1,32 @@
+-- Default (auto-join) channels and channel membership
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS is_default BOOLEAN DEFAULT false;
+
+UPDATE channels SET is_default = true WHERE type = 'general';
+
+CREATE TABLE IF NOT EXISTS channel_members (
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (channel_id, user_id)
+);
+
+CREATE INDEX idx_channel_members_user_id ON channel_members(user_id);
+
+INSERT INTO channel_members (channel_id, user_id, joined_at)
+SELECT c.id, tm.user_id, tm.joined_at
+FROM channels c
+JOIN team_members tm ON tm.team_id = c.team_id
+WHERE c.is_default = true
+ON CONFLICT DO NOTHING;
+
+-- Automated welcome DM per team
+CREATE TABLE IF NOT EXISTS team_welcome_settings (
+    team_id UUID PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    template TEXT NOT NULL DEFAULT '',
+    sender_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_team_welcome_settings_updated_at BEFORE UPDATE ON team_welcome_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
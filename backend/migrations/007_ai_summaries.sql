@@ -0,0 +1,31 @@
+-- Per-team AI feature settings and monthly token budgets
+CREATE TABLE IF NOT EXISTS team_ai_settings (
+    team_id UUID PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE,
+    summaries_enabled BOOLEAN NOT NULL DEFAULT false,
+    monthly_token_budget INTEGER NOT NULL DEFAULT 100000 CHECK (monthly_token_budget >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_team_ai_settings_updated_at BEFORE UPDATE ON team_ai_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    feature VARCHAR(50) NOT NULL,
+    model VARCHAR(100),
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_llm_usage_team_id_created_at ON llm_usage(team_id, created_at);
+
+-- Per-user read position in each channel
+CREATE TABLE IF NOT EXISTS channel_read_states (
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_read_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (channel_id, user_id)
+);
@@ -0,0 +1,31 @@
+-- Opt-in task suggestions mined from channel messages
+ALTER TABLE team_ai_settings ADD COLUMN IF NOT EXISTS task_suggestions_enabled BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS task_suggestions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    due_date TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'dismissed')),
+    task_id UUID REFERENCES tasks(id) ON DELETE SET NULL,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(message_id, title)
+);
+
+CREATE INDEX idx_task_suggestions_channel_id_status ON task_suggestions(channel_id, status);
+
+CREATE TRIGGER update_task_suggestions_updated_at BEFORE UPDATE ON task_suggestions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Tracks how far each channel has been analyzed so messages are only sent to
+-- the provider once
+CREATE TABLE IF NOT EXISTS task_suggestion_scans (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    scanned_until TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,13 @@
+-- Optional urgency/sentiment classification for designated channels
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS classification_enabled BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS message_labels (
+    message_id UUID PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    urgency VARCHAR(20) NOT NULL CHECK (urgency IN ('low', 'normal', 'high', 'urgent')),
+    sentiment VARCHAR(20) NOT NULL CHECK (sentiment IN ('negative', 'neutral', 'positive')),
+    model VARCHAR(100),
+    classified_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_labels_urgency ON message_labels(urgency);
+CREATE INDEX idx_message_labels_sentiment ON message_labels(sentiment);
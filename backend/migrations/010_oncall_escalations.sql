@@ -0,0 +1,93 @@
+-- In-app notifications
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_id UUID REFERENCES teams(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    data JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id_created_at ON notifications(user_id, created_at DESC);
+
+-- On-call rotations
+CREATE TABLE IF NOT EXISTS oncall_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    rotation_hours INTEGER NOT NULL DEFAULT 168 CHECK (rotation_hours > 0),
+    handoff_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS oncall_schedule_members (
+    schedule_id UUID NOT NULL REFERENCES oncall_schedules(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (schedule_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS oncall_overrides (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    schedule_id UUID NOT NULL REFERENCES oncall_schedules(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_oncall_overrides_schedule_id_ends_at ON oncall_overrides(schedule_id, ends_at);
+
+CREATE TRIGGER update_oncall_schedules_updated_at BEFORE UPDATE ON oncall_schedules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Escalation policies and the escalations they drive
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]',
+    repeat_count INTEGER NOT NULL DEFAULT 0 CHECK (repeat_count >= 0),
+    notify_urgent_tasks BOOLEAN NOT NULL DEFAULT false,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, name)
+);
+
+CREATE TRIGGER update_escalation_policies_updated_at BEFORE UPDATE ON escalation_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS escalations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL REFERENCES escalation_policies(id) ON DELETE CASCADE,
+    source_type VARCHAR(50) NOT NULL,
+    source_id VARCHAR(100) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'triggered' CHECK (status IN ('triggered', 'acknowledged', 'resolved')),
+    current_step INTEGER NOT NULL DEFAULT 0,
+    repeats_done INTEGER NOT NULL DEFAULT 0,
+    next_step_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_escalations_team_id_status ON escalations(team_id, status);
+CREATE INDEX idx_escalations_next_step_at ON escalations(next_step_at) WHERE status = 'triggered';
+CREATE UNIQUE INDEX idx_escalations_open_source ON escalations(policy_id, source_type, source_id)
+    WHERE status <> 'resolved';
+
+CREATE TRIGGER update_escalations_updated_at BEFORE UPDATE ON escalations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,78 @@
+-- Pinned messages
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_pinned BOOLEAN DEFAULT false;
+
+CREATE INDEX idx_messages_channel_id_pinned ON messages(channel_id) WHERE is_pinned = true;
+
+-- Templates used to spin up incident channels
+CREATE TABLE IF NOT EXISTS incident_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    channel_prefix VARCHAR(20) NOT NULL DEFAULT 'inc',
+    channel_description TEXT,
+    status_template TEXT NOT NULL,
+    default_severity VARCHAR(10) NOT NULL DEFAULT 'sev3' CHECK (default_severity IN ('sev1', 'sev2', 'sev3', 'sev4')),
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, name)
+);
+
+CREATE TRIGGER update_incident_templates_updated_at BEFORE UPDATE ON incident_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Incidents
+CREATE TABLE IF NOT EXISTS incidents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    severity VARCHAR(10) NOT NULL CHECK (severity IN ('sev1', 'sev2', 'sev3', 'sev4')),
+    status VARCHAR(20) NOT NULL DEFAULT 'investigating' CHECK (status IN ('investigating', 'identified', 'monitoring', 'resolved')),
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    status_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    template_id UUID REFERENCES incident_templates(id) ON DELETE SET NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(team_id, number)
+);
+
+CREATE INDEX idx_incidents_team_id_status ON incidents(team_id, status);
+
+CREATE TRIGGER update_incidents_updated_at BEFORE UPDATE ON incidents
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Severity and status transitions
+CREATE TABLE IF NOT EXISTS incident_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL,
+    from_value VARCHAR(50),
+    to_value VARCHAR(255),
+    note TEXT,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_incident_events_incident_id ON incident_events(incident_id, created_at);
+
+CREATE TABLE IF NOT EXISTS incident_tasks (
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    linked_by UUID NOT NULL REFERENCES users(id),
+    linked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (incident_id, task_id)
+);
+
+-- Documents generated for an incident, such as the timeline export on resolution
+CREATE TABLE IF NOT EXISTS incident_documents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    content TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(incident_id, kind)
+);
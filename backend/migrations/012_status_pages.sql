@@ -0,0 +1,42 @@
+-- Inbound webhooks: external services post to /inbound/{token}
+CREATE TABLE IF NOT EXISTS inbound_webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_received_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_inbound_webhooks_team_id ON inbound_webhooks(team_id);
+
+-- Built-in status page component model
+CREATE TABLE IF NOT EXISTS status_components (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    status VARCHAR(30) NOT NULL DEFAULT 'operational' CHECK (status IN ('operational', 'degraded_performance', 'partial_outage', 'major_outage', 'under_maintenance')),
+    external_id VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, name)
+);
+
+CREATE UNIQUE INDEX idx_status_components_external_id ON status_components(team_id, external_id)
+    WHERE external_id IS NOT NULL;
+
+CREATE TRIGGER update_status_components_updated_at BEFORE UPDATE ON status_components
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Channels that receive status changes and keep a pinned status card
+CREATE TABLE IF NOT EXISTS status_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    channel_id UUID UNIQUE NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    card_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,24 @@
+-- Kiosk tokens: read-only access to selected channels and the task board for
+-- wallboards, without a user account
+CREATE TABLE IF NOT EXISTS kiosk_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    task_board BOOLEAN NOT NULL DEFAULT false,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_kiosk_tokens_team_id ON kiosk_tokens(team_id);
+
+CREATE TABLE IF NOT EXISTS kiosk_token_channels (
+    token_id UUID NOT NULL REFERENCES kiosk_tokens(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    PRIMARY KEY (token_id, channel_id)
+);
+
+CREATE INDEX idx_kiosk_token_channels_channel_id ON kiosk_token_channels(channel_id);
@@ -0,0 +1,15 @@
+-- Public share links: a task board or channel published read-only behind a
+-- revocable token
+CREATE TABLE IF NOT EXISTS share_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('board', 'channel')),
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    CHECK ((kind = 'channel') = (channel_id IS NOT NULL))
+);
+
+CREATE INDEX idx_share_links_team_id ON share_links(team_id);
@@ -0,0 +1,38 @@
+-- Third-party apps authorised through OAuth2 and the teams that installed them
+CREATE TABLE IF NOT EXISTS apps (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    client_id VARCHAR(64) UNIQUE NOT NULL,
+    client_secret_hash VARCHAR(64) NOT NULL,
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_apps_owner_id ON apps(owner_id);
+
+CREATE TRIGGER update_apps_updated_at BEFORE UPDATE ON apps
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS app_installations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    installed_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash VARCHAR(64) UNIQUE,
+    installed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, app_id)
+);
+
+CREATE INDEX idx_app_installations_app_id ON app_installations(app_id);
+
+-- Authorization codes are single use and short lived
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    installation_id UUID NOT NULL REFERENCES app_installations(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
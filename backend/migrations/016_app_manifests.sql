@@ -0,0 +1,19 @@
+-- App manifests: the bot user, webhooks and slash commands an app brings
+-- along, provisioned when a team installs it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS manifest JSONB;
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS signing_secret VARCHAR(64);
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS bot_user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE rest_hooks ADD COLUMN IF NOT EXISTS installation_id UUID REFERENCES app_installations(id) ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS app_commands (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    installation_id UUID NOT NULL REFERENCES app_installations(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    description TEXT,
+    url VARCHAR(2000) NOT NULL,
+    UNIQUE(team_id, name)
+);
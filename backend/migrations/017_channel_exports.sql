@@ -0,0 +1,25 @@
+-- Channel history exports, generated in the background and kept for download
+CREATE TABLE IF NOT EXISTS channel_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('json', 'html')),
+    range_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    range_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    progress INTEGER NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    message_count INTEGER NOT NULL DEFAULT 0,
+    result TEXT,
+    error TEXT,
+    requested_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    CHECK (range_start < range_end)
+);
+
+CREATE INDEX idx_channel_exports_channel_id ON channel_exports(channel_id);
+CREATE INDEX idx_channel_exports_pending ON channel_exports(created_at) WHERE status IN ('pending', 'running');
+
+CREATE TRIGGER update_channel_exports_updated_at BEFORE UPDATE ON channel_exports
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
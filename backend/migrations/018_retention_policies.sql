@@ -0,0 +1,13 @@
+-- Per-team retention policy. NULL keeps messages or files forever.
+CREATE TABLE IF NOT EXISTS team_retention_policies (
+    team_id UUID PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE,
+    message_retention_days INTEGER CHECK (message_retention_days > 0),
+    file_retention_days INTEGER CHECK (file_retention_days > 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_team_retention_policies_updated_at BEFORE UPDATE ON team_retention_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE INDEX idx_attachments_created_at ON attachments(created_at);
@@ -0,0 +1,22 @@
+-- Recurring task status digests posted into a channel
+CREATE TABLE IF NOT EXISTS task_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    weekdays TEXT[] NOT NULL,
+    time_of_day VARCHAR(5) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    is_enabled BOOLEAN NOT NULL DEFAULT true,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_reports_team_id ON task_reports(team_id);
+CREATE INDEX idx_task_reports_next_run_at ON task_reports(next_run_at) WHERE is_enabled = true;
+
+CREATE TRIGGER update_task_reports_updated_at BEFORE UPDATE ON task_reports
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
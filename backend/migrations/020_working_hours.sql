@@ -0,0 +1,18 @@
+-- Per-user working hours, used for availability hints and to hold
+-- non-urgent notification pushes until the user's day starts
+CREATE TABLE IF NOT EXISTS user_working_hours (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    weekdays TEXT[] NOT NULL,
+    start_time VARCHAR(5) NOT NULL,
+    end_time VARCHAR(5) NOT NULL,
+    hold_notifications BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_user_working_hours_updated_at BEFORE UPDATE ON user_working_hours
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS held_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_notifications_held_until ON notifications(held_until) WHERE held_until IS NOT NULL;
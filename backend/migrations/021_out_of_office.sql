@@ -0,0 +1,24 @@
+-- Out-of-office periods. Each user has at most one, current or upcoming.
+CREATE TABLE IF NOT EXISTS user_out_of_office (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    delegate_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (starts_at < ends_at),
+    CHECK (delegate_id IS NULL OR delegate_id <> user_id)
+);
+
+CREATE TRIGGER update_user_out_of_office_updated_at BEFORE UPDATE ON user_out_of_office
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Who already got the automatic reply during a period, so each sender gets
+-- it only once
+CREATE TABLE IF NOT EXISTS out_of_office_replies (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    replied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, sender_id, period_start)
+);
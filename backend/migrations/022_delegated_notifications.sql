@@ -0,0 +1,5 @@
+-- Copies of an absent user's mentions and task assignments sent to their
+-- delegate point back at them, so they can review what happened on return
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS on_behalf_of UUID REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_notifications_on_behalf_of ON notifications(on_behalf_of, created_at DESC) WHERE on_behalf_of IS NOT NULL;
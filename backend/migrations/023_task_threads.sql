@@ -0,0 +1,9 @@
+-- A task can be linked to a channel thread: its root message. Comments on the
+-- task and replies in the thread are mirrored both ways, and message_id ties
+-- each comment to its copy in the thread.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS thread_message_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+ALTER TABLE task_comments ADD COLUMN IF NOT EXISTS message_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_tasks_thread_message_id ON tasks(thread_message_id) WHERE thread_message_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_task_comments_message_id ON task_comments(message_id) WHERE message_id IS NOT NULL;
+CREATE INDEX idx_messages_reply_to_id ON messages(reply_to_id) WHERE reply_to_id IS NOT NULL;
@@ -0,0 +1,27 @@
+-- A channel can be shared with another team. The host team's admin invites
+-- and the guest team's admin accepts; while active, members of both teams can
+-- use the channel. guest_keeps_history decides whether the guest team can
+-- still read what was said before the share ended.
+CREATE TABLE IF NOT EXISTS channel_shares (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    host_team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    guest_team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'ended')),
+    guest_keeps_history BOOLEAN NOT NULL DEFAULT false,
+    requested_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ended_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    CHECK (host_team_id <> guest_team_id)
+);
+
+CREATE UNIQUE INDEX idx_channel_shares_open ON channel_shares(channel_id, guest_team_id) WHERE status <> 'ended';
+CREATE INDEX idx_channel_shares_guest_team_id ON channel_shares(guest_team_id);
+CREATE INDEX idx_channel_shares_host_team_id ON channel_shares(host_team_id);
+
+-- Messages posted by guests are labeled with the team they came from; NULL
+-- means the channel's own team
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS origin_team_id UUID REFERENCES teams(id) ON DELETE SET NULL;
@@ -0,0 +1,15 @@
+-- Per-team rules for changing messages after they're sent. NULL windows
+-- leave no time limit.
+CREATE TABLE IF NOT EXISTS team_message_policies (
+    team_id UUID PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE,
+    members_can_edit BOOLEAN NOT NULL DEFAULT true,
+    members_can_delete BOOLEAN NOT NULL DEFAULT true,
+    edit_window_minutes INTEGER CHECK (edit_window_minutes > 0),
+    delete_window_minutes INTEGER CHECK (delete_window_minutes > 0),
+    admins_can_delete_others BOOLEAN NOT NULL DEFAULT true,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_team_message_policies_updated_at BEFORE UPDATE ON team_message_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,17 @@
+-- Who still calls deprecated endpoints, so they can be told before a sunset.
+-- app_id is NULL for calls a user made directly.
+CREATE TABLE IF NOT EXISTS deprecated_endpoint_calls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    method VARCHAR(10) NOT NULL,
+    endpoint TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app_id UUID REFERENCES apps(id) ON DELETE CASCADE,
+    calls BIGINT NOT NULL DEFAULT 0,
+    first_called_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_called_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_deprecated_endpoint_calls_caller ON deprecated_endpoint_calls
+    (method, endpoint, user_id, COALESCE(app_id, '00000000-0000-0000-0000-000000000000'::uuid));
+CREATE INDEX idx_deprecated_endpoint_calls_user_id ON deprecated_endpoint_calls(user_id);
+CREATE INDEX idx_deprecated_endpoint_calls_app_id ON deprecated_endpoint_calls(app_id);
@@ -0,0 +1,28 @@
+-- Every change to a task is kept as an event: who made it, when, and the old
+-- and new value of each field it changed. Rows are never updated; undoing a
+-- change appends a "reverted" event pointing at the one it undid.
+CREATE TABLE IF NOT EXISTS task_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('created', 'updated', 'reverted')),
+    changes JSONB NOT NULL,
+    reverts_event_id UUID REFERENCES task_events(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_events_task_id ON task_events(task_id, created_at DESC);
+CREATE INDEX idx_task_events_actor_id ON task_events(actor_id);
+-- A change can only be undone once
+CREATE UNIQUE INDEX idx_task_events_reverts_event_id ON task_events(reverts_event_id) WHERE reverts_event_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION reject_task_event_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'task events are immutable';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER task_events_immutable BEFORE UPDATE ON task_events
+    FOR EACH ROW EXECUTE FUNCTION reject_task_event_update();
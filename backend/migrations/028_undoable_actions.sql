@@ -0,0 +1,22 @@
+-- Deleted tasks are kept, hidden, until the undo window has passed.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_tasks_deleted_at ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Destructive actions their actor can take back for a short while. snapshot
+-- holds whatever the action threw away, such as a deleted message's content
+-- or a removed member's role and channels; rows are dropped once they expire.
+CREATE TABLE IF NOT EXISTS undoable_actions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('message.delete', 'task.delete', 'member.remove')),
+    resource_id UUID NOT NULL,
+    snapshot JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    undone_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_undoable_actions_actor_id ON undoable_actions(actor_id);
+CREATE INDEX idx_undoable_actions_expires_at ON undoable_actions(expires_at);
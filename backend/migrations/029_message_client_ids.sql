@@ -0,0 +1,5 @@
+-- Messages queued by a client while offline carry the ID the client gave
+-- them, so replaying the outbox after a lost response doesn't post twice.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS client_id VARCHAR(64);
+
+CREATE UNIQUE INDEX idx_messages_user_id_client_id ON messages(user_id, client_id) WHERE client_id IS NOT NULL;
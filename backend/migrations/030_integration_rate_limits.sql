@@ -0,0 +1,24 @@
+-- Integrations (app bots and inbound webhooks) that keep hitting their rate
+-- limits are suspended in the team for a while
+CREATE TABLE IF NOT EXISTS integration_suspensions (
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    integration_kind VARCHAR(20) NOT NULL CHECK (integration_kind IN ('app', 'inbound_webhook')),
+    integration_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    suspended_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, integration_kind, integration_id)
+);
+
+-- Messages integrations were refused, with the throttle error they got
+CREATE TABLE IF NOT EXISTS integration_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    integration_kind VARCHAR(20) NOT NULL CHECK (integration_kind IN ('app', 'inbound_webhook')),
+    integration_id UUID NOT NULL,
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    error JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_integration_deliveries_team_id ON integration_deliveries(team_id, created_at DESC);
@@ -0,0 +1,35 @@
+-- Keyword and pattern watch lists that flag matching messages
+CREATE TABLE IF NOT EXISTS watch_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    patterns TEXT[] NOT NULL DEFAULT '{}',
+    case_sensitive BOOLEAN NOT NULL DEFAULT false,
+    notify_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    is_enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, name)
+);
+
+CREATE TRIGGER update_watch_lists_updated_at BEFORE UPDATE ON watch_lists
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Audit of every message a watch list flagged; kept when the message or
+-- the list goes
+CREATE TABLE IF NOT EXISTS watch_list_matches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    watch_list_id UUID REFERENCES watch_lists(id) ON DELETE SET NULL,
+    watch_list_name VARCHAR(100) NOT NULL,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    hits JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_watch_list_matches_team_id ON watch_list_matches(team_id, created_at DESC);
+CREATE INDEX idx_watch_list_matches_watch_list_id ON watch_list_matches(watch_list_id, created_at DESC);
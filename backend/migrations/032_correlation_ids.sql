@@ -0,0 +1,4 @@
+-- Work queued by a request keeps the request's correlation ID, so what the
+-- job does later can be traced back to it
+ALTER TABLE channel_exports ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(128);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(128);
@@ -0,0 +1,6 @@
+-- Archived channels are kept, read-only, and can be brought back
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE undoable_actions DROP CONSTRAINT IF EXISTS undoable_actions_kind_check;
+ALTER TABLE undoable_actions ADD CONSTRAINT undoable_actions_kind_check
+    CHECK (kind IN ('message.delete', 'task.delete', 'member.remove', 'channel.archive'));
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Register creates an account and signs in as it.
+func (c *Client) Register(ctx context.Context, registration Registration) (*Session, error) {
+	var session Session
+	if err := c.do(ctx, http.MethodPost, "/auth/register", nil, registration, &session); err != nil {
+		return nil, err
+	}
+	c.SetTokens(session.AccessToken, session.RefreshToken)
+	return &session, nil
+}
+
+// Login signs in with an email address or username.
+func (c *Client) Login(ctx context.Context, emailOrUsername, password string) (*Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodPost, "/auth/login", nil, map[string]string{
+		"email_or_username": emailOrUsername,
+		"password":          password,
+	}, &session)
+	if err != nil {
+		return nil, err
+	}
+	c.SetTokens(session.AccessToken, session.RefreshToken)
+	return &session, nil
+}
+
+// Refresh swaps the refresh token for a new access token. Requests refresh
+// on their own when the access token has expired.
+func (c *Client) Refresh(ctx context.Context) (*Session, error) {
+	_, refreshToken := c.Tokens()
+	var session Session
+	err := c.send(ctx, http.MethodPost, "/auth/refresh", nil, map[string]string{
+		"refresh_token": refreshToken,
+	}, &session)
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshToken = refreshToken
+	c.SetTokens(session.AccessToken, refreshToken)
+	return &session, nil
+}
+
+// Logout ends the session and forgets the tokens.
+func (c *Client) Logout(ctx context.Context) error {
+	err := c.do(ctx, http.MethodPost, "/auth/logout", nil, nil, nil)
+	c.SetTokens("", "")
+	return err
+}
+
+// Me returns the signed-in user.
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/users/me", nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
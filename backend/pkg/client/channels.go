@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Channels lists the team's channels, including those shared with it.
+func (c *Client) Channels(ctx context.Context, teamID string) ([]Channel, error) {
+	var channels []Channel
+	if err := c.do(ctx, http.MethodGet, "/teams/"+url.PathEscape(teamID)+"/channels", nil, nil, &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// Channel gets a channel the user can see, through their team or a share.
+func (c *Client) Channel(ctx context.Context, channelID string) (*Channel, error) {
+	var channel Channel
+	if err := c.do(ctx, http.MethodGet, "/channels/"+url.PathEscape(channelID), nil, nil, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// CreateChannel adds a channel to the team, with the caller as its first
+// member.
+func (c *Client) CreateChannel(ctx context.Context, teamID string, channel NewChannel) (*Channel, error) {
+	var created Channel
+	if err := c.do(ctx, http.MethodPost, "/teams/"+url.PathEscape(teamID)+"/channels", nil, channel, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateChannel changes a channel's settings. Team admins only.
+func (c *Client) UpdateChannel(ctx context.Context, channelID string, update ChannelUpdate) (*Channel, error) {
+	var channel Channel
+	if err := c.do(ctx, http.MethodPut, "/channels/"+url.PathEscape(channelID), nil, update, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// ArchiveChannel makes a channel read-only and returns how to undo that.
+func (c *Client) ArchiveChannel(ctx context.Context, channelID string) (*UndoAction, error) {
+	var archived struct {
+		Undo UndoAction `json:"undo"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/channels/"+url.PathEscape(channelID)+"/archive", nil, nil, &archived); err != nil {
+		return nil, err
+	}
+	return &archived.Undo, nil
+}
+
+// UnarchiveChannel brings back an archived channel. Team admins only.
+func (c *Client) UnarchiveChannel(ctx context.Context, channelID string) (*Channel, error) {
+	var channel Channel
+	if err := c.do(ctx, http.MethodDelete, "/channels/"+url.PathEscape(channelID)+"/archive", nil, nil, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// Messages returns the latest messages in a channel, oldest first.
+func (c *Client) Messages(ctx context.Context, channelID string, query MessageQuery) ([]Message, error) {
+	values := url.Values{}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	for key, value := range map[string]string{
+		"q":         query.Search,
+		"urgency":   query.Urgency,
+		"sentiment": query.Sentiment,
+		"thread":    query.Thread,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	var messages []Message
+	if err := c.do(ctx, http.MethodGet, "/channels/"+url.PathEscape(channelID)+"/messages", values, nil, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (c *Client) SendMessage(ctx context.Context, channelID string, message NewMessage) (*Message, error) {
+	var sent Message
+	if err := c.do(ctx, http.MethodPost, "/channels/"+url.PathEscape(channelID)+"/messages", nil, message, &sent); err != nil {
+		return nil, err
+	}
+	return &sent, nil
+}
+
+// EditMessage replaces a message's content, if the team's message policy
+// allows it.
+func (c *Client) EditMessage(ctx context.Context, messageID, content string) (*Message, error) {
+	var message Message
+	err := c.do(ctx, http.MethodPut, "/messages/"+url.PathEscape(messageID), nil, map[string]string{
+		"content": content,
+	}, &message)
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// DeleteMessage deletes a message and returns how to undo that.
+func (c *Client) DeleteMessage(ctx context.Context, messageID string) (*UndoAction, error) {
+	var deleted struct {
+		Undo UndoAction `json:"undo"`
+	}
+	if err := c.do(ctx, http.MethodDelete, "/messages/"+url.PathEscape(messageID), nil, nil, &deleted); err != nil {
+		return nil, err
+	}
+	return &deleted.Undo, nil
+}
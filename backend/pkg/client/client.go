@@ -0,0 +1,149 @@
+// Package client is a Go client for the REST API and the WebSocket event
+// stream, for internal tools and bots.
+//
+//	c := client.New("https://chat.example.com")
+//	if _, err := c.Login(ctx, "bot@example.com", password); err != nil {
+//		return err
+//	}
+//	teams, err := c.Teams(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiPrefix is the API version the client speaks.
+const apiPrefix = "/api/v1"
+
+// Client calls the API on behalf of one user. It is safe for concurrent
+// use; a successful Login, Register or Refresh stores the tokens it uses.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+}
+
+// New returns a client for the server at baseURL, such as
+// "https://chat.example.com".
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithHTTPClient makes the client send requests through hc, e.g. one with
+// a custom transport or timeout.
+func (c *Client) WithHTTPClient(hc *http.Client) *Client {
+	c.httpClient = hc
+	return c
+}
+
+// SetTokens sets the tokens to authenticate with, for callers that keep
+// them between runs. An app's access token works without a refresh token.
+func (c *Client) SetTokens(accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken, c.refreshToken = accessToken, refreshToken
+}
+
+// Tokens returns the current tokens, which change when the access token is
+// refreshed.
+func (c *Client) Tokens() (accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accessToken, c.refreshToken
+}
+
+// APIError is a non-2xx response from the API. RequestID is the response's
+// X-Request-ID, which support can trace the request by.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	RequestID  string `json:"-"`
+	Message    string `json:"error"`
+	Code       string `json:"code,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("api: %d %s (%s)", e.StatusCode, e.Message, e.Code)
+	}
+	return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+}
+
+// do sends a JSON request and decodes the response into out, if given. A
+// 401 is retried once after refreshing the access token, when the client
+// has a refresh token.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	err := c.send(ctx, method, path, query, body, out)
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == http.StatusUnauthorized {
+		if _, refresh := c.Tokens(); refresh != "" && path != "/auth/refresh" {
+			if _, err := c.Refresh(ctx); err != nil {
+				return apiErr
+			}
+			return c.send(ctx, method, path, query, body, out)
+		}
+	}
+	return err
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	target := c.baseURL + apiPrefix + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token, _ := c.Tokens(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+		if json.NewDecoder(resp.Body).Decode(apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
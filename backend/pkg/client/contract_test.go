@@ -0,0 +1,344 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cbalite/backend/internal/apitest"
+	"github.com/cbalite/backend/pkg/client"
+)
+
+// These tests hold the client to the API it talks to, running each call
+// against the real router over fake repositories.
+
+func register(t *testing.T, srv *apitest.Server, username string) (*client.Client, *client.Session) {
+	t.Helper()
+	c := client.New(srv.URL)
+	session, err := c.Register(context.Background(), client.Registration{
+		Email:     username + "@example.com",
+		Username:  username,
+		Password:  "correct horse battery",
+		FirstName: username,
+		LastName:  "Tester",
+	})
+	if err != nil {
+		t.Fatalf("Register(%s): %v", username, err)
+	}
+	return c, session
+}
+
+// newTeam registers an owner with a team, and a member the owner invited.
+func newTeam(t *testing.T, srv *apitest.Server) (owner, member *client.Client, team *client.Team, memberID string) {
+	t.Helper()
+	ctx := context.Background()
+	owner, _ = register(t, srv, "olivia")
+	member, session := register(t, srv, "max")
+
+	team, err := owner.CreateTeam(ctx, "Platform", "Keeps the lights on")
+	if err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if _, err := owner.InviteMember(ctx, team.ID, client.Invitation{Email: "max@example.com"}); err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	return owner, member, team, session.User.ID
+}
+
+func statusOf(err error) int {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func TestAuth(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ctx := context.Background()
+	c, session := register(t, srv, "olivia")
+
+	if session.AccessToken == "" || session.RefreshToken == "" {
+		t.Fatalf("Register returned tokens %q and %q", session.AccessToken, session.RefreshToken)
+	}
+	me, err := c.Me(ctx)
+	if err != nil {
+		t.Fatalf("Me: %v", err)
+	}
+	if me.ID != session.User.ID || me.Username != "olivia" || me.Email != "olivia@example.com" {
+		t.Errorf("Me = %+v, want the registered user", me)
+	}
+
+	if _, err := client.New(srv.URL).Register(ctx, client.Registration{
+		Email: "olivia@example.com", Username: "olivia2", Password: "correct horse battery",
+	}); statusOf(err) != http.StatusConflict {
+		t.Errorf("registering a taken email = %v, want 409", err)
+	}
+
+	fresh := client.New(srv.URL)
+	if _, err := fresh.Login(ctx, "olivia", "wrong password"); statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("Login with the wrong password = %v, want 401", err)
+	}
+	login, err := fresh.Login(ctx, "olivia@example.com", "correct horse battery")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if login.User.ID != session.User.ID {
+		t.Errorf("Login as %s, want %s", login.User.ID, session.User.ID)
+	}
+
+	if _, err := fresh.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, err := fresh.Me(ctx); err != nil {
+		t.Errorf("Me after refreshing: %v", err)
+	}
+
+	if _, err := client.New(srv.URL).Me(ctx); statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("Me without a token = %v, want 401", err)
+	}
+}
+
+func TestTeamsAndMembers(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ctx := context.Background()
+	owner, member, team, memberID := newTeam(t, srv)
+
+	teams, err := member.Teams(ctx)
+	if err != nil {
+		t.Fatalf("Teams: %v", err)
+	}
+	if len(teams) != 1 || teams[0].ID != team.ID || teams[0].Role != "member" {
+		t.Fatalf("member's teams = %+v, want Platform as a member", teams)
+	}
+	got, err := owner.Team(ctx, team.ID)
+	if err != nil {
+		t.Fatalf("Team: %v", err)
+	}
+	if got.Name != "Platform" || got.Permissions == nil || !got.Permissions.CanInvite {
+		t.Errorf("owner's view of the team = %+v, want it invitable", got)
+	}
+
+	members, err := owner.TeamMembers(ctx, team.ID)
+	if err != nil {
+		t.Fatalf("TeamMembers: %v", err)
+	}
+	if len(members) != 2 || members[1].UserID != memberID || members[1].User.Username != "max" {
+		t.Fatalf("members = %+v, want the owner then max", members)
+	}
+
+	if _, err := member.RemoveMember(ctx, team.ID, members[0].UserID); statusOf(err) != http.StatusForbidden {
+		t.Errorf("a member removing the owner = %v, want 403", err)
+	}
+	undo, err := owner.RemoveMember(ctx, team.ID, memberID)
+	if err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	if undo.Kind != "member.remove" || undo.ResourceID != memberID {
+		t.Errorf("undo = %+v, want the removed member", undo)
+	}
+	if _, err := member.Team(ctx, team.ID); statusOf(err) != http.StatusForbidden && statusOf(err) != http.StatusNotFound {
+		t.Errorf("a removed member reading the team = %v, want it refused", err)
+	}
+
+	var restored client.TeamMember
+	if err := owner.Undo(ctx, undo.ID, &restored); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if restored.UserID != memberID || restored.Role != "member" {
+		t.Errorf("restored %+v, want max back as a member", restored)
+	}
+	if _, err := member.Team(ctx, team.ID); err != nil {
+		t.Errorf("Team after the removal was undone: %v", err)
+	}
+	if err := owner.Undo(ctx, undo.ID, nil); statusOf(err) != http.StatusConflict {
+		t.Errorf("undoing twice = %v, want 409", err)
+	}
+}
+
+func TestChannelsAndMessages(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ctx := context.Background()
+	owner, member, team, _ := newTeam(t, srv)
+
+	channels, err := member.Channels(ctx, team.ID)
+	if err != nil {
+		t.Fatalf("Channels: %v", err)
+	}
+	if len(channels) != 1 || channels[0].Name != "general" {
+		t.Fatalf("channels = %+v, want general", channels)
+	}
+
+	incidents, err := owner.CreateChannel(ctx, team.ID, client.NewChannel{Name: "incidents", Description: "When things break"})
+	if err != nil {
+		t.Fatalf("CreateChannel: %v", err)
+	}
+	if _, err := owner.CreateChannel(ctx, team.ID, client.NewChannel{Name: "incidents"}); statusOf(err) != http.StatusConflict {
+		t.Errorf("creating a channel with a taken name = %v, want 409", err)
+	}
+	topic := "When things break, and after"
+	updated, err := owner.UpdateChannel(ctx, incidents.ID, client.ChannelUpdate{Description: &topic})
+	if err != nil {
+		t.Fatalf("UpdateChannel: %v", err)
+	}
+	if updated.Description != topic || updated.Name != "incidents" {
+		t.Errorf("updated channel = %+v", updated)
+	}
+
+	general := channels[0].ID
+	first, err := member.SendMessage(ctx, general, client.NewMessage{Content: "Deploy is out"})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if first.SenderID == "" || first.Content != "Deploy is out" {
+		t.Errorf("sent %+v", first)
+	}
+	if _, err := owner.SendMessage(ctx, general, client.NewMessage{Content: "Thanks", ReplyToID: first.ID}); err != nil {
+		t.Fatalf("SendMessage reply: %v", err)
+	}
+
+	messages, err := owner.Messages(ctx, general, client.MessageQuery{})
+	if err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	replies, err := owner.Messages(ctx, general, client.MessageQuery{Thread: first.ID})
+	if err != nil {
+		t.Fatalf("Messages in a thread: %v", err)
+	}
+	if len(replies) != 1 || replies[0].Content != "Thanks" {
+		t.Errorf("replies = %+v, want the one reply", replies)
+	}
+	latest, err := owner.Messages(ctx, general, client.MessageQuery{Limit: 1})
+	if err != nil {
+		t.Fatalf("Messages with a limit: %v", err)
+	}
+	if len(latest) != 1 || latest[0].Content != "Thanks" {
+		t.Errorf("latest message = %+v, want the reply", latest)
+	}
+
+	edited, err := member.EditMessage(ctx, first.ID, "Deploy is out (v2)")
+	if err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	if edited.Content != "Deploy is out (v2)" {
+		t.Errorf("edited content = %q", edited.Content)
+	}
+	if _, err := owner.EditMessage(ctx, first.ID, "not mine"); statusOf(err) != http.StatusForbidden {
+		t.Errorf("editing someone else's message = %v, want 403", err)
+	}
+
+	undo, err := member.DeleteMessage(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	var restored client.Message
+	if err := member.Undo(ctx, undo.ID, &restored); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if restored.ID != first.ID || restored.Content != "Deploy is out (v2)" {
+		t.Errorf("restored %+v, want the edited message back", restored)
+	}
+
+	archive, err := owner.ArchiveChannel(ctx, incidents.ID)
+	if err != nil {
+		t.Fatalf("ArchiveChannel: %v", err)
+	}
+	if _, err := owner.SendMessage(ctx, incidents.ID, client.NewMessage{Content: "anyone?"}); statusOf(err) != http.StatusForbidden && statusOf(err) != http.StatusConflict {
+		t.Errorf("posting to an archived channel = %v, want it refused", err)
+	}
+	var unarchived client.Channel
+	if err := owner.Undo(ctx, archive.ID, &unarchived); err != nil {
+		t.Fatalf("Undo archive: %v", err)
+	}
+	if unarchived.ArchivedAt != nil {
+		t.Errorf("channel still archived after undo: %+v", unarchived)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ctx := context.Background()
+	owner, member, team, memberID := newTeam(t, srv)
+
+	task, err := owner.CreateTask(ctx, team.ID, client.NewTask{Title: "Rotate keys", Priority: "high", AssigneeID: memberID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if task.Status == "" || task.AssigneeID != memberID || task.Priority != "high" {
+		t.Errorf("created %+v", task)
+	}
+
+	tasks, err := member.Tasks(ctx, team.ID)
+	if err != nil {
+		t.Fatalf("Tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Fatalf("tasks = %+v, want the one created", tasks)
+	}
+
+	done := "done"
+	updated, err := member.UpdateTask(ctx, task.ID, client.TaskUpdate{Status: &done})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if updated.Status != "done" {
+		t.Errorf("status = %q, want done", updated.Status)
+	}
+
+	comment, err := member.AddTaskComment(ctx, task.ID, "Rotated")
+	if err != nil {
+		t.Fatalf("AddTaskComment: %v", err)
+	}
+	comments, err := owner.TaskComments(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("TaskComments: %v", err)
+	}
+	if len(comments) != 1 || comments[0].ID != comment.ID || comments[0].Username != "max" {
+		t.Errorf("comments = %+v, want max's comment", comments)
+	}
+
+	reverted, err := owner.UndoTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if reverted.Status != task.Status {
+		t.Errorf("status after undo = %q, want %q", reverted.Status, task.Status)
+	}
+	if _, err := owner.UndoTask(ctx, task.ID); statusOf(err) != http.StatusConflict {
+		t.Errorf("undoing with nothing left to undo = %v, want 409", err)
+	}
+
+	history, err := owner.TaskEvents(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("TaskEvents: %v", err)
+	}
+	var kinds []string
+	for _, event := range history {
+		kinds = append(kinds, event.Type)
+	}
+	if len(history) != 3 || history[2].RevertsEventID != history[1].ID {
+		t.Errorf("history = %v, want created, updated and its revert", kinds)
+	}
+
+	if _, err := member.DeleteTask(ctx, task.ID); statusOf(err) != http.StatusForbidden {
+		t.Errorf("a member deleting the owner's task = %v, want 403", err)
+	}
+	undo, err := owner.DeleteTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if _, err := owner.Task(ctx, task.ID); statusOf(err) != http.StatusNotFound {
+		t.Errorf("reading a deleted task = %v, want 404", err)
+	}
+	var restored client.Task
+	if err := owner.Undo(ctx, undo.ID, &restored); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if restored.ID != task.ID || restored.Title != "Rotate keys" {
+		t.Errorf("restored %+v", restored)
+	}
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// readTimeout is how long a connection may stay silent before it's treated
+// as dead; the server pings more often than this.
+const readTimeout = 75 * time.Second
+
+// Event is one frame from the event stream.
+type Event struct {
+	Type      string          `json:"type"`
+	Room      string          `json:"room,omitempty"`
+	UserID    string          `json:"user_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Decode unmarshals the event's data, e.g. into a Message for chat events.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// ListenOptions configure Listen. Zero values use the defaults.
+type ListenOptions struct {
+	// Categories limits the events received to these of "presence",
+	// "typing" and "tasks"; nil receives all of them.
+	Categories []string
+	// Rooms are joined on every connect, in addition to the team's.
+	Rooms []string
+	// Compact skips typing and presence events, for slow connections.
+	Compact bool
+
+	// MinBackoff and MaxBackoff bound the wait between reconnects. They
+	// default to 1 and 30 seconds.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnConnect is called each time a connection is set up.
+	OnConnect func()
+	// OnDisconnect is called with the reason a connection ended, before
+	// waiting to reconnect.
+	OnDisconnect func(err error)
+}
+
+// errSignedOut means the server treated the connection as anonymous, which
+// it does when the access token has expired.
+var errSignedOut = errors.New("client: event stream connected without a user")
+
+// Listen delivers events to handle until ctx is done, reconnecting with
+// exponential backoff and jitter when the connection drops. Rooms and
+// categories are set up again on each connect, but events sent while
+// disconnected are lost; fetch what's needed over REST in OnConnect.
+// handle runs on the reading goroutine, so it should return quickly.
+func (c *Client) Listen(ctx context.Context, opts ListenOptions, handle func(Event)) error {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = time.Second
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = max(30*time.Second, opts.MinBackoff)
+	}
+
+	backoff := opts.MinBackoff
+	for {
+		connected, err := c.listenOnce(ctx, opts, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			backoff = opts.MinBackoff
+		}
+		if errors.Is(err, errSignedOut) {
+			if _, refreshErr := c.Refresh(ctx); refreshErr != nil {
+				return refreshErr
+			}
+		}
+		if opts.OnDisconnect != nil {
+			opts.OnDisconnect(err)
+		}
+
+		// Full jitter keeps bots that lost the same server from
+		// reconnecting in lockstep
+		wait := time.Duration(rand.Int63n(int64(backoff)) + 1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, opts.MaxBackoff)
+	}
+}
+
+// listenOnce runs one connection until it fails, reporting whether it got
+// as far as the server's hello.
+func (c *Client) listenOnce(ctx context.Context, opts ListenOptions, handle func(Event)) (bool, error) {
+	target := "ws" + strings.TrimPrefix(c.baseURL, "http") + apiPrefix + "/ws"
+	if opts.Compact {
+		target += "?compact=true"
+	}
+	header := http.Header{}
+	token, _ := c.Tokens()
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, target, header)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// Unblock the read below when the caller gives up
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+	})
+
+	if err := subscribe(conn, opts); err != nil {
+		return false, err
+	}
+
+	connected := false
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return connected, err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		// The server batches queued events into one frame, one per line
+		for _, line := range bytes.Split(frame, []byte{'\n'}) {
+			var event Event
+			if err := json.Unmarshal(line, &event); err != nil {
+				continue
+			}
+			if event.Type == "hello" && !connected {
+				var hello struct {
+					UserID string `json:"user_id"`
+				}
+				event.Decode(&hello)
+				if token != "" && hello.UserID == "anonymous" {
+					return false, errSignedOut
+				}
+				connected = true
+				if opts.OnConnect != nil {
+					opts.OnConnect()
+				}
+			}
+			handle(event)
+		}
+	}
+}
+
+// subscribe asks for the configured categories and rooms.
+func subscribe(conn *websocket.Conn, opts ListenOptions) error {
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	defer conn.SetWriteDeadline(time.Time{})
+
+	if opts.Categories != nil {
+		err := conn.WriteJSON(map[string]interface{}{
+			"type": "hello",
+			"data": map[string]interface{}{"categories": opts.Categories},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, room := range opts.Rooms {
+		err := conn.WriteJSON(map[string]interface{}{
+			"type": "notification",
+			"data": map[string]interface{}{"action": "join_room", "room": room},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Tasks lists the team's tasks, newest first.
+func (c *Client) Tasks(ctx context.Context, teamID string) ([]Task, error) {
+	var tasks []Task
+	if err := c.do(ctx, http.MethodGet, "/teams/"+url.PathEscape(teamID)+"/tasks", nil, nil, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Task gets a task in one of the user's teams.
+func (c *Client) Task(ctx context.Context, taskID string) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodGet, "/tasks/"+url.PathEscape(taskID), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) CreateTask(ctx context.Context, teamID string, task NewTask) (*Task, error) {
+	var created Task
+	if err := c.do(ctx, http.MethodPost, "/teams/"+url.PathEscape(teamID)+"/tasks", nil, task, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateTask(ctx context.Context, taskID string, update TaskUpdate) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPut, "/tasks/"+url.PathEscape(taskID), nil, update, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// DeleteTask deletes a task and returns how to undo that.
+func (c *Client) DeleteTask(ctx context.Context, taskID string) (*UndoAction, error) {
+	var deleted struct {
+		Undo UndoAction `json:"undo"`
+	}
+	if err := c.do(ctx, http.MethodDelete, "/tasks/"+url.PathEscape(taskID), nil, nil, &deleted); err != nil {
+		return nil, err
+	}
+	return &deleted.Undo, nil
+}
+
+func (c *Client) TaskComments(ctx context.Context, taskID string) ([]TaskComment, error) {
+	var comments []TaskComment
+	if err := c.do(ctx, http.MethodGet, "/tasks/"+url.PathEscape(taskID)+"/comments", nil, nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// AddTaskComment comments on a task; the comment is mirrored to the task's
+// thread if it has one.
+func (c *Client) AddTaskComment(ctx context.Context, taskID, content string) (*TaskComment, error) {
+	var comment TaskComment
+	err := c.do(ctx, http.MethodPost, "/tasks/"+url.PathEscape(taskID)+"/comments", nil, map[string]string{
+		"content": content,
+	}, &comment)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// TaskEvents returns the task's history, oldest first.
+func (c *Client) TaskEvents(ctx context.Context, taskID string) ([]TaskEvent, error) {
+	var taskEvents []TaskEvent
+	if err := c.do(ctx, http.MethodGet, "/tasks/"+url.PathEscape(taskID)+"/events", nil, nil, &taskEvents); err != nil {
+		return nil, err
+	}
+	return taskEvents, nil
+}
+
+// UndoTask reverts the most recent change to a task that hasn't been undone.
+// It fails with a 409 APIError when there is nothing to undo or the fields
+// have been changed again since.
+func (c *Client) UndoTask(ctx context.Context, taskID string) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPost, "/tasks/"+url.PathEscape(taskID)+"/undo", nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
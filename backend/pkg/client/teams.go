@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Teams lists the teams the user belongs to, with their role in each.
+func (c *Client) Teams(ctx context.Context) ([]Team, error) {
+	var teams []Team
+	if err := c.do(ctx, http.MethodGet, "/teams", nil, nil, &teams); err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// Team gets one of the user's teams.
+func (c *Client) Team(ctx context.Context, teamID string) (*Team, error) {
+	var team Team
+	if err := c.do(ctx, http.MethodGet, "/teams/"+url.PathEscape(teamID), nil, nil, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// CreateTeam creates a team owned by the user, with a general channel.
+func (c *Client) CreateTeam(ctx context.Context, name, description string) (*Team, error) {
+	var team Team
+	err := c.do(ctx, http.MethodPost, "/teams", nil, map[string]string{
+		"name":        name,
+		"description": description,
+	}, &team)
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (c *Client) TeamMembers(ctx context.Context, teamID string) ([]TeamMember, error) {
+	var members []TeamMember
+	if err := c.do(ctx, http.MethodGet, "/teams/"+url.PathEscape(teamID)+"/members", nil, nil, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// InviteMember adds an existing user to the team. Team owners and admins
+// only.
+func (c *Client) InviteMember(ctx context.Context, teamID string, invitation Invitation) (*TeamMember, error) {
+	var member TeamMember
+	if err := c.do(ctx, http.MethodPost, "/teams/"+url.PathEscape(teamID)+"/members", nil, invitation, &member); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember removes a user from the team, or the caller themselves to
+// leave it, and returns how to undo that.
+func (c *Client) RemoveMember(ctx context.Context, teamID, userID string) (*UndoAction, error) {
+	var removed struct {
+		Undo UndoAction `json:"undo"`
+	}
+	path := "/teams/" + url.PathEscape(teamID) + "/members/" + url.PathEscape(userID)
+	if err := c.do(ctx, http.MethodDelete, path, nil, nil, &removed); err != nil {
+		return nil, err
+	}
+	return &removed.Undo, nil
+}
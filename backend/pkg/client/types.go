@@ -0,0 +1,266 @@
+package client
+
+import (
+	"time"
+)
+
+// These mirror the API's JSON. Fields the API omits in some responses are
+// left at their zero value.
+
+type User struct {
+	ID         string    `json:"id"`
+	Email      string    `json:"email"`
+	Username   string    `json:"username"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Avatar     string    `json:"avatar"`
+	Phone      string    `json:"phone,omitempty"`
+	IsActive   bool      `json:"is_active"`
+	IsVerified bool      `json:"is_verified"`
+	LastSeen   time.Time `json:"last_seen"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type Session struct {
+	User         User   `json:"user"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type Registration struct {
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Permissions say what the caller may do with a resource.
+type Permissions struct {
+	CanEdit   bool `json:"can_edit"`
+	CanDelete bool `json:"can_delete"`
+	CanInvite bool `json:"can_invite"`
+	CanPost   bool `json:"can_post"`
+}
+
+type Team struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	OwnerID     string       `json:"owner_id"`
+	Role        string       `json:"role,omitempty"`
+	JoinedAt    time.Time    `json:"joined_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at,omitempty"`
+	UpdatedAt   time.Time    `json:"updated_at,omitempty"`
+	Permissions *Permissions `json:"permissions,omitempty"`
+}
+
+// UserSummary is the part of a user shown next to their messages and
+// memberships.
+type UserSummary struct {
+	ID        string `json:"id,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Avatar    string `json:"avatar,omitempty"`
+}
+
+type OutOfOffice struct {
+	Until      time.Time `json:"until"`
+	DelegateID *string   `json:"delegate_id"`
+}
+
+// Availability is a member's working hours status, "working" or
+// "outside_working_hours".
+type Availability struct {
+	Status          string     `json:"status"`
+	Hint            string     `json:"hint,omitempty"`
+	LocalTime       string     `json:"local_time"`
+	Timezone        string     `json:"timezone"`
+	NextAvailableAt *time.Time `json:"next_available_at,omitempty"`
+}
+
+type TeamMember struct {
+	UserID       string        `json:"user_id"`
+	Role         string        `json:"role"`
+	JoinedAt     time.Time     `json:"joined_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	User         UserSummary   `json:"user"`
+	Availability *Availability `json:"availability,omitempty"`
+	OutOfOffice  *OutOfOffice  `json:"out_of_office,omitempty"`
+}
+
+// Invitation adds a user to a team by email or username. Role defaults to
+// "member".
+type Invitation struct {
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+type TeamRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Channel struct {
+	ID                    string       `json:"id"`
+	TeamID                string       `json:"team_id,omitempty"`
+	Name                  string       `json:"name"`
+	Description           string       `json:"description"`
+	Type                  string       `json:"type"`
+	IsPrivate             bool         `json:"is_private"`
+	IsDefault             bool         `json:"is_default"`
+	ClassificationEnabled bool         `json:"classification_enabled"`
+	IsShared              bool         `json:"is_shared"`
+	HostTeam              *TeamRef     `json:"host_team,omitempty"`
+	CreatedBy             string       `json:"created_by"`
+	CreatedAt             time.Time    `json:"created_at"`
+	UpdatedAt             time.Time    `json:"updated_at"`
+	ArchivedAt            *time.Time   `json:"archived_at,omitempty"`
+	Permissions           *Permissions `json:"permissions,omitempty"`
+}
+
+// NewChannel is a channel to create. Only team admins can create default
+// channels.
+type NewChannel struct {
+	Name                  string `json:"name"`
+	Description           string `json:"description,omitempty"`
+	IsPrivate             bool   `json:"is_private,omitempty"`
+	IsDefault             bool   `json:"is_default,omitempty"`
+	ClassificationEnabled bool   `json:"classification_enabled,omitempty"`
+}
+
+// ChannelUpdate changes the fields that are set.
+type ChannelUpdate struct {
+	Name                  *string `json:"name,omitempty"`
+	Description           *string `json:"description,omitempty"`
+	IsPrivate             *bool   `json:"is_private,omitempty"`
+	IsDefault             *bool   `json:"is_default,omitempty"`
+	ClassificationEnabled *bool   `json:"classification_enabled,omitempty"`
+}
+
+type MessageLabels struct {
+	Urgency   string `json:"urgency"`
+	Sentiment string `json:"sentiment"`
+}
+
+type Message struct {
+	ID          string         `json:"id"`
+	TeamID      string         `json:"team_id,omitempty"`
+	ChannelID   string         `json:"channel_id,omitempty"`
+	Content     string         `json:"content"`
+	Type        string         `json:"type"`
+	SenderID    string         `json:"sender_id"`
+	Sender      *UserSummary   `json:"sender,omitempty"`
+	ReplyToID   string         `json:"reply_to_id,omitempty"`
+	ClientID    string         `json:"client_id,omitempty"`
+	IsPinned    bool           `json:"is_pinned,omitempty"`
+	IsEdited    bool           `json:"is_edited,omitempty"`
+	OriginTeam  *TeamRef       `json:"origin_team,omitempty"`
+	Labels      *MessageLabels `json:"labels,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at,omitempty"`
+	Permissions *Permissions   `json:"permissions,omitempty"`
+}
+
+// NewMessage is a message to post. Type defaults to "text"; content
+// starting with "/" runs a slash command instead.
+type NewMessage struct {
+	Content   string `json:"content"`
+	Type      string `json:"type,omitempty"`
+	ReplyToID string `json:"reply_to_id,omitempty"`
+}
+
+// MessageQuery filters a channel's messages. Zero fields are left out.
+type MessageQuery struct {
+	Limit     int
+	Search    string
+	Urgency   string
+	Sentiment string
+	// Thread lists the replies to this message.
+	Thread string
+}
+
+// Warning flags something the caller may want to act on, such as an
+// assignee who is out of office.
+type Warning map[string]interface{}
+
+type Task struct {
+	ID          string       `json:"id"`
+	TeamID      string       `json:"team_id,omitempty"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Status      string       `json:"status"`
+	Priority    string       `json:"priority"`
+	AssigneeID  string       `json:"assignee_id,omitempty"`
+	DueDate     *time.Time   `json:"due_date,omitempty"`
+	CreatedBy   string       `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Permissions *Permissions `json:"permissions,omitempty"`
+	Warnings    []Warning    `json:"warnings,omitempty"`
+}
+
+type NewTask struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	AssigneeID  string     `json:"assignee_id,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+}
+
+// TaskUpdate changes the fields that are set. An empty AssigneeID
+// unassigns the task.
+type TaskUpdate struct {
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Status      *string    `json:"status,omitempty"`
+	Priority    *string    `json:"priority,omitempty"`
+	AssigneeID  *string    `json:"assignee_id,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+}
+
+type TaskComment struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	UserID    string    `json:"user_id"`
+	Content   string    `json:"content"`
+	MessageID *string   `json:"message_id,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskEvent is one change in a task's history. Changes maps each field it
+// touched to its old and new value.
+type TaskEvent struct {
+	ID             string                 `json:"id"`
+	TaskID         string                 `json:"task_id"`
+	Type           string                 `json:"type"`
+	ActorID        string                 `json:"actor_id"`
+	Username       string                 `json:"username,omitempty"`
+	Changes        map[string]FieldChange `json:"changes"`
+	RevertsEventID string                 `json:"reverts_event_id,omitempty"`
+	RevertedBy     string                 `json:"reverted_by,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// FieldChange holds a field's value before and after a change; nil means it
+// was unset.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// UndoAction is returned by deletions that can be taken back with Undo
+// until ExpiresAt.
+type UndoAction struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	ResourceID string    `json:"resource_id"`
+	URL        string    `json:"url"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
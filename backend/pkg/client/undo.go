@@ -0,0 +1,14 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Undo takes back a deletion and decodes the restored resource into out, a
+// *Message, *Task, *TeamMember or *Channel depending on the action's Kind; out may be
+// nil. It fails with a 410 APIError once the action has expired.
+func (c *Client) Undo(ctx context.Context, actionID string, out interface{}) error {
+	return c.do(ctx, http.MethodPost, "/undo/"+url.PathEscape(actionID), nil, nil, out)
+}
@@ -0,0 +1,55 @@
+// Package httpjson writes JSON responses for handlers and middleware alike.
+package httpjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// internalError is sent when a payload can't be encoded. It is built by
+// hand so the fallback itself can never fail.
+var internalError = []byte(`{"error":"Internal server error","code":"encoding_failed"}` + "\n")
+
+// Respond encodes payload in full before writing anything, so a value that
+// fails to marshal (or panics doing so) turns into a 500 with a JSON error
+// instead of a partial body after a 200 header. A nil payload sends only the
+// status. The encoding error, if any, is returned for the caller to log.
+func Respond(w http.ResponseWriter, code int, payload interface{}) error {
+	if payload == nil {
+		w.WriteHeader(code)
+		return nil
+	}
+
+	body, err := encode(payload)
+	if err != nil {
+		code, body = http.StatusInternalServerError, internalError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(code)
+	w.Write(body)
+	return err
+}
+
+// Error sends {"error": message}.
+func Error(w http.ResponseWriter, code int, message string) error {
+	return Respond(w, code, map[string]string{"error": message})
+}
+
+func encode(payload interface{}) (body []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("httpjson: panic encoding %T: %v", payload, p)
+		}
+	}()
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
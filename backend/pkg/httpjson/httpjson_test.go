@@ -0,0 +1,74 @@
+package httpjson
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// panicky panics when encoded, like a nil pointer inside a MarshalJSON.
+type panicky struct{}
+
+func (panicky) MarshalJSON() ([]byte, error) {
+	panic("boom")
+}
+
+type failing struct{}
+
+func (failing) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("can't encode")
+}
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     int
+		payload  interface{}
+		wantCode int
+		wantBody string
+		wantErr  bool
+	}{
+		{name: "object", code: http.StatusCreated, payload: map[string]int{"id": 1}, wantCode: http.StatusCreated, wantBody: `{"id":1}` + "\n"},
+		{name: "nil sends only the status", code: http.StatusNoContent, wantCode: http.StatusNoContent},
+		{name: "panic while encoding", code: http.StatusOK, payload: map[string]interface{}{"value": panicky{}}, wantCode: http.StatusInternalServerError, wantBody: string(internalError), wantErr: true},
+		{name: "marshal error", code: http.StatusOK, payload: []interface{}{failing{}}, wantCode: http.StatusInternalServerError, wantBody: string(internalError), wantErr: true},
+		{name: "unsupported value", code: http.StatusOK, payload: map[string]float64{"x": math.NaN()}, wantCode: http.StatusInternalServerError, wantBody: string(internalError), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			err := Respond(rec, tt.code, tt.payload)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want an error: %v", err, tt.wantErr)
+			}
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if tt.wantBody == "" {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(tt.wantBody)) {
+				t.Errorf("Content-Length = %s, want %d", got, len(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Error(rec, http.StatusNotFound, "Task not found")
+
+	if rec.Code != http.StatusNotFound || rec.Body.String() != `{"error":"Task not found"}`+"\n" {
+		t.Errorf("got %d %q", rec.Code, rec.Body)
+	}
+}
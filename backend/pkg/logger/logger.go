@@ -83,6 +83,11 @@ func Default() *Logger {
 	return logger
 }
 
+// Nop returns a logger that discards everything, for tests.
+func Nop() *Logger {
+	return &Logger{SugaredLogger: zap.NewNop().Sugar()}
+}
+
 func Fatal(msg string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, msg+"\n", args...)
 	os.Exit(1)